@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/harunnryd/heike/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+var adaptersCmd = &cobra.Command{
+	Use:   "adapters",
+	Short: "Inspect runtime adapters",
+	Long:  `Inspect, enable, disable and reload the input adapters of a running Heike daemon.`,
+}
+
+var adaptersStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show adapter connection status",
+	Long:  `Query the daemon health endpoint and display per-adapter connection state, last error, and reconnect attempts.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		if strings.TrimSpace(addr) == "" {
+			port := 0
+			if cfg != nil {
+				port = cfg.Server.Port
+			}
+			addr = fmt.Sprintf("http://127.0.0.1:%d", port)
+		}
+
+		statuses, err := fetchAdapterStatuses(strings.TrimRight(addr, "/"))
+		if err != nil {
+			return err
+		}
+
+		if len(statuses) == 0 {
+			fmt.Println("No input adapters enabled.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATE\tATTEMPTS\tLAST ERROR")
+		for _, st := range statuses {
+			lastErr := "-"
+			if st.LastError != "" {
+				lastErr = fmt.Sprintf("%s (%s)", st.LastError, st.LastErrorAt.Format("2006-01-02 15:04:05"))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", st.Name, st.State, st.ReconnectAttempts, lastErr)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	},
+}
+
+func fetchAdapterStatuses(baseURL string) ([]daemon.RuntimeAdapterStatus, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(baseURL + "/health")
+	if err != nil {
+		return nil, daemonUnreachableError(fmt.Errorf("failed to reach daemon at %s: %w", baseURL, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon health returned status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Adapters []daemon.RuntimeAdapterStatus `json:"adapters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode health response: %w", err)
+	}
+	return payload.Adapters, nil
+}
+
+var adaptersEnableCmd = &cobra.Command{
+	Use:   "enable [name]",
+	Short: "Start an adapter on a running daemon",
+	Long: `Build the named adapter (slack, telegram, discord, teams, email or webhook) from
+the config the daemon last loaded and start it, even if its enabled flag is off.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAdapterEnabled(cmd, args[0], true)
+	},
+}
+
+var adaptersDisableCmd = &cobra.Command{
+	Use:   "disable [name]",
+	Short: "Stop an adapter on a running daemon",
+	Long:  `Stop the named adapter and stop routing replies to it until it is enabled again or the daemon restarts.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAdapterEnabled(cmd, args[0], false)
+	},
+}
+
+var adaptersReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Apply adapter config changes on a running daemon",
+	Long: `Make the daemon read its config again and restart the adapters whose settings
+changed, e.g. after rotating a bot token. Other adapters keep running.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var result daemon.RuntimeAdapterReload
+		if err := daemonRequest(http.MethodPost, daemonURL(cmd)+"/api/v1/adapters/reload", nil, &result); err != nil {
+			return err
+		}
+		if len(result.Changed) == 0 {
+			fmt.Println("Adapter config unchanged.")
+			return nil
+		}
+		fmt.Printf("✓ Reloaded adapters: %s\n", strings.Join(result.Changed, ", "))
+		return nil
+	},
+}
+
+func setAdapterEnabled(cmd *cobra.Command, name string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	var status daemon.RuntimeAdapterStatus
+	endpoint := daemonURL(cmd) + "/api/v1/adapters/" + url.PathEscape(strings.TrimSpace(name)) + "/" + action
+	if err := daemonRequest(http.MethodPost, endpoint, nil, &status); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Adapter '%s' is %s.\n", status.Name, status.State)
+	return nil
+}
+
+func init() {
+	adaptersStatusCmd.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+	adaptersCmd.AddCommand(adaptersStatusCmd)
+	for _, c := range []*cobra.Command{adaptersEnableCmd, adaptersDisableCmd, adaptersReloadCmd} {
+		c.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+		adaptersCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(adaptersCmd)
+}
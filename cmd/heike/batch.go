@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/harunnryd/heike/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run lists of goals as background sessions",
+	Long:  `Submit a file of goals to a running daemon, follow progress, and export the aggregated results.`,
+}
+
+var batchSubmitCmd = &cobra.Command{
+	Use:   "submit <file>",
+	Short: "Submit a file of goals, one per line",
+	Long: `Submit each non-empty line of <file> as a goal. Lines starting with # are skipped; use - to read from stdin.
+Each goal runs in its own background session, with at most --concurrency goals in flight.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		goals, err := readGoals(args[0])
+		if err != nil {
+			return err
+		}
+		if len(goals) == 0 {
+			return usageError(fmt.Errorf("no goals in %s", args[0]))
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		wait, _ := cmd.Flags().GetBool("wait")
+		baseURL := daemonURL(cmd)
+
+		body, err := json.Marshal(map[string]interface{}{"goals": goals, "concurrency": concurrency})
+		if err != nil {
+			return err
+		}
+		var b daemon.RuntimeBatch
+		if err := daemonRequest(http.MethodPost, baseURL+"/api/v1/batches", bytes.NewReader(body), &b); err != nil {
+			return err
+		}
+		fmt.Printf("Batch %s submitted: %d goals, concurrency %d\n", b.ID, b.Progress.Total, b.Concurrency)
+		if !wait {
+			return nil
+		}
+
+		for b.Status != "completed" {
+			time.Sleep(2 * time.Second)
+			if err := daemonRequest(http.MethodGet, baseURL+"/api/v1/batches/"+b.ID, nil, &b); err != nil {
+				return err
+			}
+			fmt.Println(formatBatchProgress(b.Progress))
+		}
+		fmt.Printf("Batch %s completed. Export with: heike batch results %s\n", b.ID, b.ID)
+		return nil
+	},
+}
+
+var batchStatusCmd = &cobra.Command{
+	Use:   "status [batch-id]",
+	Short: "Show batch progress",
+	Long:  `List batches, or show per-goal status for one batch.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseURL := daemonURL(cmd)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+
+		if len(args) == 0 {
+			var payload struct {
+				Batches []daemon.RuntimeBatch `json:"batches"`
+			}
+			if err := daemonRequest(http.MethodGet, baseURL+"/api/v1/batches", nil, &payload); err != nil {
+				return err
+			}
+			if len(payload.Batches) == 0 {
+				fmt.Println("No batches.")
+				return nil
+			}
+			fmt.Fprintln(w, "ID\tSTATUS\tCREATED\tPROGRESS")
+			for _, b := range payload.Batches {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.ID, b.Status, b.CreatedAt.Format("2006-01-02 15:04:05"), formatBatchProgress(b.Progress))
+			}
+		} else {
+			var b daemon.RuntimeBatch
+			if err := daemonRequest(http.MethodGet, baseURL+"/api/v1/batches/"+args[0], nil, &b); err != nil {
+				return err
+			}
+			fmt.Printf("Batch %s (%s, concurrency %d)\n", b.ID, b.Status, b.Concurrency)
+			fmt.Printf("%s\n\n", formatBatchProgress(b.Progress))
+			fmt.Fprintln(w, "#\tSTATUS\tGOAL\tERROR")
+			for _, item := range b.Items {
+				errMsg := "-"
+				if item.Error != "" {
+					errMsg = item.Error
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", item.Index, item.Status, truncateGoal(item.Goal, 60), errMsg)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	},
+}
+
+var batchResultsCmd = &cobra.Command{
+	Use:   "results <batch-id>",
+	Short: "Export batch results as JSONL or CSV",
+	Long:  `Export one record per goal with its status, final answer, and error. Results of goals still running are empty.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "jsonl" && format != "csv" {
+			return usageError(fmt.Errorf("unsupported format %q (use jsonl or csv)", format))
+		}
+		output, _ := cmd.Flags().GetString("output")
+
+		out := io.Writer(os.Stdout)
+		if output != "" && output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("create %s: %w", output, err)
+			}
+			defer f.Close()
+			out = f
+		}
+		url := fmt.Sprintf("%s/api/v1/batches/%s/results?format=%s", daemonURL(cmd), args[0], format)
+		return daemonRequest(http.MethodGet, url, nil, out)
+	},
+}
+
+// readGoals returns the non-empty, non-comment lines of path ("-" for stdin).
+func readGoals(path string) ([]string, error) {
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, notFoundError(fmt.Errorf("goals file %s not found", path))
+			}
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var goals []string
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		goals = append(goals, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read goals: %w", err)
+	}
+	return goals, nil
+}
+
+func daemonURL(cmd *cobra.Command) string {
+	addr, _ := cmd.Flags().GetString("addr")
+	if strings.TrimSpace(addr) == "" {
+		port := 0
+		if cfg != nil {
+			port = cfg.Server.Port
+		}
+		addr = fmt.Sprintf("http://127.0.0.1:%d", port)
+	}
+	return strings.TrimRight(addr, "/")
+}
+
+// setDaemonAuth sends HEIKE_API_KEY as a bearer token, for daemons that
+// configure server.auth.
+func setDaemonAuth(req *http.Request) {
+	if key := strings.TrimSpace(os.Getenv("HEIKE_API_KEY")); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+}
+
+// daemonRequest calls the daemon HTTP API. A JSON response is decoded into
+// out, unless out is an io.Writer, which receives the raw body.
+func daemonRequest(method, url string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	setDaemonAuth(req)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return daemonUnreachableError(fmt.Errorf("failed to reach daemon: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var payload struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		err := fmt.Errorf("daemon api returned status %d: %s", resp.StatusCode, payload.Error.Message)
+		switch payload.Error.Code {
+		case "not_found":
+			return notFoundError(err)
+		case "invalid_input":
+			return usageError(err)
+		}
+		return err
+	}
+
+	if w, ok := out.(io.Writer); ok {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode daemon response: %w", err)
+	}
+	return nil
+}
+
+func formatBatchProgress(p daemon.RuntimeBatchProgress) string {
+	return fmt.Sprintf("%d/%d done (%d completed, %d failed, %d running, %d queued, %d pending)",
+		p.Completed+p.Failed, p.Total, p.Completed, p.Failed, p.Running, p.Queued, p.Pending)
+}
+
+func truncateGoal(goal string, max int) string {
+	goal = strings.Join(strings.Fields(goal), " ")
+	runes := []rune(goal)
+	if len(runes) <= max {
+		return goal
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+func init() {
+	batchSubmitCmd.Flags().Int("concurrency", 0, "Maximum goals in flight (default batch.concurrency, capped at batch.max_concurrency)")
+	batchSubmitCmd.Flags().Bool("wait", false, "Wait for the batch to finish, printing progress")
+	batchResultsCmd.Flags().String("format", "jsonl", "Export format: jsonl or csv")
+	batchResultsCmd.Flags().StringP("output", "o", "", "Write results to a file instead of stdout")
+	for _, c := range []*cobra.Command{batchSubmitCmd, batchStatusCmd, batchResultsCmd} {
+		c.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+		batchCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(batchCmd)
+}
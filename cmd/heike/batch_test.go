@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGoals_SkipsBlankAndCommentLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goals.txt")
+	content := "# tickets to classify\nclassify ticket 1\n\n  summarize ticket 2  \n#skip\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write goals: %v", err)
+	}
+
+	goals, err := readGoals(path)
+	if err != nil {
+		t.Fatalf("readGoals: %v", err)
+	}
+	if len(goals) != 2 || goals[0] != "classify ticket 1" || goals[1] != "summarize ticket 2" {
+		t.Fatalf("goals = %q", goals)
+	}
+}
+
+func TestReadGoals_MissingFile(t *testing.T) {
+	_, err := readGoals(filepath.Join(t.TempDir(), "missing.txt"))
+	var cliErr *cliError
+	if !errors.As(err, &cliErr) || cliErr.code != exitNotFound {
+		t.Fatalf("expected not found cli error, got %v", err)
+	}
+}
@@ -29,11 +29,11 @@ var configViewCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		loadedCfg, err := loadConfigForCommand(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		if loadedCfg == nil {
-			return fmt.Errorf("config is not initialized; run 'heike config init' first")
+			return configError(fmt.Errorf("config is not initialized; run 'heike config init' first"))
 		}
 
 		redacted := redactConfigSecrets(loadedCfg)
@@ -86,6 +86,21 @@ var configInitCmd = &cobra.Command{
 	},
 }
 
+var configDefaultsCmd = &cobra.Command{
+	Use:   "defaults",
+	Short: "Print built-in defaults and prompts",
+	Long:  `Print the embedded default configuration and prompts, the lowest layer under the config file, HEIKE_* environment variables and flags.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := config.DefaultsYAML()
+		if err != nil {
+			return fmt.Errorf("failed to read defaults: %w", err)
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	},
+}
+
 func loadConfigForCommand(cmd *cobra.Command) (*config.Config, error) {
 	if cfg != nil {
 		return cfg, nil
@@ -117,6 +132,26 @@ func redactConfigSecrets(in *config.Config) *config.Config {
 	out.Adapters.Slack.SigningSecret = maskSecret(out.Adapters.Slack.SigningSecret)
 	out.Adapters.Slack.BotToken = maskSecret(out.Adapters.Slack.BotToken)
 	out.Adapters.Telegram.BotToken = maskSecret(out.Adapters.Telegram.BotToken)
+	out.Adapters.Discord.BotToken = maskSecret(out.Adapters.Discord.BotToken)
+	out.Adapters.Teams.AppPassword = maskSecret(out.Adapters.Teams.AppPassword)
+	out.Adapters.Email.IMAP.Password = maskSecret(out.Adapters.Email.IMAP.Password)
+	out.Adapters.Email.SMTP.Password = maskSecret(out.Adapters.Email.SMTP.Password)
+	out.Adapters.Transcription.APIKey = maskSecret(out.Adapters.Transcription.APIKey)
+	out.Tools.Web.APIKey = maskSecret(out.Tools.Web.APIKey)
+	out.Tools.Finance.CryptoAPIKey = maskSecret(out.Tools.Finance.CryptoAPIKey)
+	out.Tools.GitHub.Token = maskSecret(out.Tools.GitHub.Token)
+	out.Tools.Calendar.CalDAV.Password = maskSecret(out.Tools.Calendar.CalDAV.Password)
+	out.Tools.Calendar.Google.AccessToken = maskSecret(out.Tools.Calendar.Google.AccessToken)
+	out.Tools.Calendar.Google.ClientSecret = maskSecret(out.Tools.Calendar.Google.ClientSecret)
+	out.Tools.Calendar.Google.RefreshToken = maskSecret(out.Tools.Calendar.Google.RefreshToken)
+	out.Tools.Email.SMTP.Password = maskSecret(out.Tools.Email.SMTP.Password)
+	if len(in.Adapters.Webhook.Routes) > 0 {
+		out.Adapters.Webhook.Routes = make([]config.WebhookRouteConfig, len(in.Adapters.Webhook.Routes))
+		copy(out.Adapters.Webhook.Routes, in.Adapters.Webhook.Routes)
+		for i := range out.Adapters.Webhook.Routes {
+			out.Adapters.Webhook.Routes[i].Secret = maskSecret(out.Adapters.Webhook.Routes[i].Secret)
+		}
+	}
 
 	return &out
 }
@@ -134,5 +169,6 @@ func maskSecret(secret string) string {
 func init() {
 	configCmd.AddCommand(configViewCmd)
 	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configDefaultsCmd)
 	rootCmd.AddCommand(configCmd)
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -53,6 +54,19 @@ func TestConfigViewCmd(t *testing.T) {
 	}
 }
 
+func TestConfigDefaultsCmd(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	if err := configDefaultsCmd.RunE(cmd, nil); err != nil {
+		t.Fatalf("config defaults failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "\nprompts:\n") || !strings.Contains(out.String(), "\nserver:\n") {
+		t.Fatalf("defaults output missing sections:\n%s", out.String())
+	}
+}
+
 func TestRedactConfigSecrets(t *testing.T) {
 	original := &config.Config{
 		Models: config.ModelsConfig{
@@ -70,6 +84,16 @@ func TestRedactConfigSecrets(t *testing.T) {
 				BotToken: "telegram-secret-token",
 			},
 		},
+		Tools: config.ToolsConfig{
+			Web:     config.WebToolConfig{APIKey: "brave-secret-key"},
+			Finance: config.FinanceToolConfig{CryptoAPIKey: "CG-secret-key"},
+			GitHub:  config.GitHubToolConfig{Token: "ghp_secret_token"},
+			Calendar: config.CalendarToolConfig{
+				CalDAV: config.CalDAVCalendarConfig{Password: "caldav-secret"},
+				Google: config.GoogleCalendarConfig{RefreshToken: "google-refresh-token"},
+			},
+			Email: config.EmailToolConfig{SMTP: config.EmailServerConfig{Password: "smtp-secret"}},
+		},
 	}
 
 	redacted := redactConfigSecrets(original)
@@ -92,6 +116,24 @@ func TestRedactConfigSecrets(t *testing.T) {
 	if redacted.Adapters.Telegram.BotToken == original.Adapters.Telegram.BotToken {
 		t.Fatal("telegram bot token should be masked")
 	}
+	if redacted.Tools.Web.APIKey == original.Tools.Web.APIKey {
+		t.Fatal("web search API key should be masked")
+	}
+	if redacted.Tools.Finance.CryptoAPIKey == original.Tools.Finance.CryptoAPIKey {
+		t.Fatal("finance crypto API key should be masked")
+	}
+	if redacted.Tools.GitHub.Token == original.Tools.GitHub.Token {
+		t.Fatal("github token should be masked")
+	}
+	if redacted.Tools.Calendar.CalDAV.Password == original.Tools.Calendar.CalDAV.Password {
+		t.Fatal("caldav password should be masked")
+	}
+	if redacted.Tools.Calendar.Google.RefreshToken == original.Tools.Calendar.Google.RefreshToken {
+		t.Fatal("google refresh token should be masked")
+	}
+	if redacted.Tools.Email.SMTP.Password == original.Tools.Email.SMTP.Password {
+		t.Fatal("email tool smtp password should be masked")
+	}
 
 	// Ensure original struct is not mutated.
 	if original.Models.Registry[0].APIKey != "sk-secret-123456" {
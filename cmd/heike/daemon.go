@@ -8,6 +8,7 @@ import (
 
 	"github.com/harunnryd/heike/cmd/heike/runtime"
 
+	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/daemon"
 	"github.com/harunnryd/heike/internal/daemon/components"
 
@@ -26,13 +27,16 @@ func runDaemonCommand(cmd *cobra.Command, args []string) error {
 	forceClean, _ := cmd.Flags().GetBool("force-clean-locks")
 
 	if cfg == nil {
-		return fmt.Errorf("config not loaded")
+		return configError(fmt.Errorf("config not loaded"))
 	}
 
 	runtimeComp := runtime.NewDaemonRuntimeComponent(workspaceID, cfg, runtime.AdapterBuildOptions{
 		IncludeCLI:        false,
 		IncludeSystemNull: true,
 	})
+	runtimeComp.SetConfigLoader(func() (*config.Config, error) {
+		return config.Load(cmd)
+	})
 	defer func() {
 		_ = runtimeComp.Stop(context.Background())
 	}()
@@ -44,9 +48,13 @@ func runDaemonCommand(cmd *cobra.Command, args []string) error {
 	daemonMgr.SetForceCleanup(forceClean)
 
 	httpComp := components.NewHTTPServerComponentWithDependencies(daemonMgr, &cfg.Server, []string{runtimeComp.Name()})
+	grpcComp := components.NewGRPCServerComponent(daemonMgr, &cfg.Server, []string{runtimeComp.Name()})
+	alertsComp := components.NewAlertsComponent(daemonMgr, &cfg.Daemon, []string{runtimeComp.Name()})
 
 	daemonMgr.AddComponent(runtimeComp)
 	daemonMgr.AddComponent(httpComp)
+	daemonMgr.AddComponent(grpcComp)
+	daemonMgr.AddComponent(alertsComp)
 
 	slog.Info("Heike Daemon starting up...", "port", cfg.Server.Port, "workspace", workspaceID)
 	err = daemonMgr.Start(context.Background())
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/model/contract"
+
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debug recorded turns",
+	Long:  `Inspect and re-run turns recorded in session wire logs.`,
+}
+
+var debugReplayCmd = &cobra.Command{
+	Use:   "replay [session-id]",
+	Short: "Replay a turn with edited tool results",
+	Long: `Re-run a recorded turn with some tool results replaced, to see how the
+model responds to different tool output. Requires models.wire_log.enabled.
+
+First export the turn's tool results to a fixture file, edit the outputs,
+then replay with the fixture:
+
+  heike debug replay <session> --export fixture.json
+  heike debug replay <session> --fixture fixture.json
+
+The first completion that saw an edited result is sent again with the edits
+applied, and its recorded and new responses are printed. Tools are not run
+and the session is not changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exportPath, _ := cmd.Flags().GetString("export")
+		fixturePath, _ := cmd.Flags().GetString("fixture")
+		if (exportPath == "") == (fixturePath == "") {
+			return fmt.Errorf("exactly one of --export or --fixture is required")
+		}
+
+		entries, err := readSessionWireLog(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		traceID, _ := cmd.Flags().GetString("trace")
+		turn, err := model.WireTurn(entries, traceID)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if exportPath != "" {
+			fixture := model.ExtractToolFixture(turn)
+			if len(fixture.Results) == 0 {
+				return fmt.Errorf("turn %s made no tool calls", turn[0].TraceID)
+			}
+			if err := model.WriteToolFixture(exportPath, fixture); err != nil {
+				return fmt.Errorf("failed to write fixture: %w", err)
+			}
+			fmt.Fprintf(out, "Exported %d tool result(s) of turn %s to %s\n", len(fixture.Results), fixture.TraceID, exportPath)
+			return nil
+		}
+
+		fixture, err := model.ReadToolFixture(fixturePath)
+		if err != nil {
+			return err
+		}
+		if fixture.TraceID != "" && fixture.TraceID != turn[0].TraceID {
+			if traceID != "" {
+				return fmt.Errorf("fixture is for trace %s, not %s", fixture.TraceID, traceID)
+			}
+			if turn, err = model.WireTurn(entries, fixture.TraceID); err != nil {
+				return err
+			}
+		}
+
+		models := config.ModelsConfig{}
+		var httpClients *httpclient.Factory
+		if cfg != nil {
+			models = cfg.Models
+			if httpClients, err = httpclient.ForConfig(cfg.HTTP); err != nil {
+				return err
+			}
+		}
+		router, err := model.NewModelRouterWithHTTPClients(models, httpClients)
+		if err != nil {
+			return fmt.Errorf("failed to initialize model router: %w", err)
+		}
+		result, err := model.ReplayTurn(cmd.Context(), router, turn, fixture)
+		if err != nil {
+			return err
+		}
+
+		full, _ := cmd.Flags().GetBool("full")
+		writeReplayResult(out, result, full)
+		return nil
+	},
+}
+
+func writeReplayResult(w io.Writer, result *model.ReplayResult, full bool) {
+	fmt.Fprintf(w, "=== Replayed completion #%d (%s) with edited results for %s\n\n", result.Step+1, result.Model, strings.Join(result.Substituted, ", "))
+	fmt.Fprintln(w, "--- recorded")
+	writeReplayResponse(w, result.Recorded, full)
+	fmt.Fprintln(w, "--- replayed")
+	writeReplayResponse(w, result.Replayed, full)
+}
+
+func writeReplayResponse(w io.Writer, resp *contract.CompletionResponse, full bool) {
+	if resp == nil {
+		fmt.Fprintln(w, "< (no response recorded)")
+		return
+	}
+	if resp.Content != "" || len(resp.ToolCalls) == 0 {
+		fmt.Fprintf(w, "< assistant: %s\n", traceText(resp.Content, full))
+	}
+	for _, tc := range resp.ToolCalls {
+		fmt.Fprintf(w, "< call %s %s\n", tc.Name, traceText(tc.Input, full))
+	}
+	fmt.Fprintln(w)
+}
+
+func init() {
+	debugReplayCmd.Flags().String("trace", "", "Trace ID of the turn (default: the last turn)")
+	debugReplayCmd.Flags().String("export", "", "Write the turn's tool results to this fixture file")
+	debugReplayCmd.Flags().String("fixture", "", "Replay with the tool results in this fixture file")
+	debugReplayCmd.Flags().Bool("full", false, "Print responses without truncation")
+	debugReplayCmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
+	debugCmd.AddCommand(debugReplayCmd)
+	rootCmd.AddCommand(debugCmd)
+}
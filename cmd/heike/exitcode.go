@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// Exit codes returned by the heike binary. They are part of the CLI contract;
+// see docs/reference/command-reference.md.
+const (
+	exitOK                = 0
+	exitError             = 1
+	exitUsage             = 2
+	exitConfig            = 3
+	exitDaemonUnreachable = 4
+	exitNotFound          = 5
+	exitDenied            = 6
+)
+
+// cliError attaches an exit code and a stable machine-readable kind to an
+// error without changing its message.
+type cliError struct {
+	code int
+	kind string
+	err  error
+}
+
+func (e *cliError) Error() string { return e.err.Error() }
+func (e *cliError) Unwrap() error { return e.err }
+
+func configError(err error) error {
+	return &cliError{code: exitConfig, kind: "config_error", err: err}
+}
+
+func daemonUnreachableError(err error) error {
+	return &cliError{code: exitDaemonUnreachable, kind: "daemon_unreachable", err: err}
+}
+
+func notFoundError(err error) error {
+	return &cliError{code: exitNotFound, kind: "not_found", err: err}
+}
+
+func usageError(err error) error {
+	return &cliError{code: exitUsage, kind: "usage_error", err: err}
+}
+
+// classifyError maps err to its exit code and kind. Explicit CLI errors win;
+// otherwise the internal error taxonomy decides.
+func classifyError(err error) (int, string) {
+	var cliErr *cliError
+	switch {
+	case err == nil:
+		return exitOK, ""
+	case errors.As(err, &cliErr):
+		return cliErr.code, cliErr.kind
+	case errors.Is(err, heikeErrors.ErrNotFound):
+		return exitNotFound, "not_found"
+	case errors.Is(err, heikeErrors.ErrPermissionDenied), errors.Is(err, heikeErrors.ErrApprovalRequired):
+		return exitDenied, "approval_denied"
+	case errors.Is(err, heikeErrors.ErrInvalidInput):
+		return exitUsage, "usage_error"
+	default:
+		return exitError, "error"
+	}
+}
+
+// writeError prints err to w, as a JSON object when asJSON is set, and
+// returns the exit code.
+func writeError(w io.Writer, err error, asJSON bool) int {
+	code, kind := classifyError(err)
+	if !asJSON {
+		fmt.Fprintln(w, "Error:", err)
+		return code
+	}
+
+	payload := struct {
+		Error struct {
+			Kind     string `json:"kind"`
+			Message  string `json:"message"`
+			ExitCode int    `json:"exit_code"`
+		} `json:"error"`
+	}{}
+	payload.Error.Kind = kind
+	payload.Error.Message = err.Error()
+	payload.Error.ExitCode = code
+	_ = json.NewEncoder(w).Encode(payload)
+	return code
+}
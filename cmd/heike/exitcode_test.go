@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		kind string
+	}{
+		{"config", configError(errors.New("bad yaml")), exitConfig, "config_error"},
+		{"daemon", daemonUnreachableError(errors.New("refused")), exitDaemonUnreachable, "daemon_unreachable"},
+		{"wrapped not found", fmt.Errorf("show: %w", notFoundError(errors.New("skill not found: x"))), exitNotFound, "not_found"},
+		{"taxonomy not found", heikeErrors.NotFound("session"), exitNotFound, "not_found"},
+		{"denied", heikeErrors.PermissionDenied("tool blocked"), exitDenied, "approval_denied"},
+		{"invalid input", heikeErrors.InvalidInput("bad flag"), exitUsage, "usage_error"},
+		{"generic", errors.New("boom"), exitError, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, kind := classifyError(tt.err)
+			if code != tt.code || kind != tt.kind {
+				t.Fatalf("expected (%d, %s), got (%d, %s)", tt.code, tt.kind, code, kind)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	err := notFoundError(errors.New("skill not found: x"))
+
+	var text bytes.Buffer
+	if code := writeError(&text, err, false); code != exitNotFound {
+		t.Fatalf("expected exit %d, got %d", exitNotFound, code)
+	}
+	if !strings.Contains(text.String(), "skill not found: x") {
+		t.Fatalf("unexpected text output: %q", text.String())
+	}
+
+	var out bytes.Buffer
+	writeError(&out, err, true)
+	var payload struct {
+		Error struct {
+			Kind     string `json:"kind"`
+			Message  string `json:"message"`
+			ExitCode int    `json:"exit_code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", out.String(), err)
+	}
+	if payload.Error.Kind != "not_found" || payload.Error.ExitCode != exitNotFound || payload.Error.Message != "skill not found: x" {
+		t.Fatalf("unexpected payload: %+v", payload.Error)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/runtime/discovery"
+	"github.com/harunnryd/heike/internal/store"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// initSelfTestTimeout bounds the connectivity self-test at the end of init.
+const initSelfTestTimeout = 30 * time.Second
+
+// initProvider is a provider offered by the init wizard with its starter model.
+type initProvider struct {
+	Name    string
+	Model   string
+	EnvVar  string
+	BaseURL string
+	OAuth   bool
+}
+
+var initProviders = []initProvider{
+	{Name: "openai", Model: "gpt-4-turbo", EnvVar: "OPENAI_API_KEY"},
+	{Name: "anthropic", Model: "claude-3-haiku", EnvVar: "ANTHROPIC_API_KEY"},
+	{Name: "gemini", Model: "gemini-2.0-flash", EnvVar: "GEMINI_API_KEY"},
+	{Name: "zai", Model: "glm-5", EnvVar: "ZAI_API_KEY"},
+	{Name: "groq", Model: "llama-3.3-70b-versatile", EnvVar: "GROQ_API_KEY"},
+	{Name: "openrouter", Model: "meta-llama/llama-3.3-70b-instruct", EnvVar: "OPENROUTER_API_KEY"},
+	{Name: "ollama", Model: "local-llama", BaseURL: config.DefaultOllamaBaseURL},
+	{Name: "openai-codex", Model: "gpt-5.2-codex", OAuth: true},
+}
+
+// initConfigFile is the starter config written by init. Everything it leaves
+// out falls back to the built-in defaults.
+type initConfigFile struct {
+	Models initModelsConfig `yaml:"models"`
+}
+
+type initModelsConfig struct {
+	Default  string              `yaml:"default"`
+	Fallback string              `yaml:"fallback,omitempty"`
+	Registry []initRegistryEntry `yaml:"registry"`
+}
+
+type initRegistryEntry struct {
+	Name     string `yaml:"name"`
+	Provider string `yaml:"provider"`
+	BaseURL  string `yaml:"base_url,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up Heike for first run",
+	Long: `Create the ~/.heike directories, write a starter config.yaml for the chosen
+providers, register the bundled skills globally and run a connectivity self-test.
+
+Prompts for providers and API keys unless --no-input is set. Blank API keys
+fall back to the provider's environment variable (e.g. OPENAI_API_KEY).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		noInput, _ := cmd.Flags().GetBool("no-input")
+		skipSelfTest, _ := cmd.Flags().GetBool("skip-self-test")
+		providerNames, _ := cmd.Flags().GetStringSlice("providers")
+
+		out := cmd.OutOrStdout()
+		in := bufio.NewReader(cmd.InOrStdin())
+
+		configPath, err := initConfigPath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(configPath); err == nil && !force {
+			return configError(fmt.Errorf("config already exists at %s; rerun with --force to overwrite", configPath))
+		} else if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check config file: %w", err)
+		}
+
+		dirs, err := createInitDirs(configPath)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			fmt.Fprintf(out, "✓ Directory %s\n", dir)
+		}
+
+		if len(providerNames) == 0 && !noInput {
+			providerNames, err = promptProviders(in, out)
+			if err != nil {
+				return err
+			}
+		}
+		providers, err := selectInitProviders(providerNames)
+		if err != nil {
+			return usageError(err)
+		}
+
+		file := initConfigFile{Models: initModelsConfig{Default: providers[0].Model}}
+		if len(providers) > 1 {
+			file.Models.Fallback = providers[1].Model
+		}
+		for _, p := range providers {
+			entry := initRegistryEntry{Name: p.Model, Provider: p.Name, BaseURL: p.BaseURL}
+			switch {
+			case p.OAuth:
+				if !noInput && promptYesNo(in, out, fmt.Sprintf("Log in to %s now?", p.Name), true) {
+					if err := loginCodex(cmd.Context()); err != nil {
+						fmt.Fprintf(out, "! %v (run 'heike provider login %s' later)\n", err, p.Name)
+					}
+				}
+			case p.EnvVar != "" && !noInput:
+				key, err := promptLine(in, out, fmt.Sprintf("API key for %s (blank to use %s): ", p.Name, p.EnvVar))
+				if err != nil {
+					return err
+				}
+				entry.APIKey = key
+			}
+			file.Models.Registry = append(file.Models.Registry, entry)
+		}
+
+		if err := writeInitConfig(configPath, file); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "✓ Wrote config to %s\n", configPath)
+
+		installed, err := registerBundledSkills()
+		if err != nil {
+			fmt.Fprintf(out, "! Skipped skill registration: %v\n", err)
+		} else if len(installed) == 0 {
+			fmt.Fprintln(out, "✓ Skills already registered")
+		} else {
+			fmt.Fprintf(out, "✓ Registered skills: %s\n", strings.Join(installed, ", "))
+		}
+
+		if skipSelfTest {
+			return nil
+		}
+		fmt.Fprintln(out, "Running connectivity self-test...")
+		if err := runInitSelfTest(cmd); err != nil {
+			fmt.Fprintf(out, "! Self-test failed: %v\n", err)
+			fmt.Fprintln(out, "Check your API keys, then run 'heike config view' to verify the configuration.")
+			return nil
+		}
+		fmt.Fprintln(out, "✓ All providers reachable")
+		return nil
+	},
+}
+
+func initConfigPath() (string, error) {
+	if path := strings.TrimSpace(cfgFile); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".heike", "config.yaml"), nil
+}
+
+// createInitDirs creates the config directory, global skills directory and
+// default workspace layout, returning them in creation order.
+func createInitDirs(configPath string) ([]string, error) {
+	skillsDir, err := store.GetSkillsDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve skills directory: %w", err)
+	}
+	workspaceRoot := runtimeWorkspaceRootPath()
+	workspaceDir, err := store.GetWorkspacePath(config.DefaultWorkspaceID, workspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace directory: %w", err)
+	}
+	sessionsDir, err := store.GetSessionsDir(config.DefaultWorkspaceID, workspaceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("resolve sessions directory: %w", err)
+	}
+
+	dirs := []string{filepath.Dir(configPath), skillsDir, workspaceDir, sessionsDir}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	return dirs, nil
+}
+
+func promptProviders(in *bufio.Reader, out io.Writer) ([]string, error) {
+	fmt.Fprintln(out, "Available providers:")
+	for i, p := range initProviders {
+		fmt.Fprintf(out, "  %d) %s (%s)\n", i+1, p.Name, p.Model)
+	}
+	answer, err := promptLine(in, out, fmt.Sprintf("Select providers, first is the default [%s]: ", initProviders[0].Name))
+	if err != nil {
+		return nil, err
+	}
+	if answer == "" {
+		return []string{initProviders[0].Name}, nil
+	}
+	return strings.Split(answer, ","), nil
+}
+
+// selectInitProviders resolves provider names or 1-based menu numbers,
+// keeping the given order and dropping duplicates.
+func selectInitProviders(names []string) ([]initProvider, error) {
+	if len(names) == 0 {
+		return []initProvider{initProviders[0]}, nil
+	}
+	selected := make([]initProvider, 0, len(names))
+	seen := make(map[string]bool)
+	for _, raw := range names {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		var match *initProvider
+		if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= len(initProviders) {
+			match = &initProviders[n-1]
+		} else {
+			for i := range initProviders {
+				if initProviders[i].Name == name {
+					match = &initProviders[i]
+					break
+				}
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("unknown provider %q", strings.TrimSpace(raw))
+		}
+		if seen[match.Name] {
+			continue
+		}
+		seen[match.Name] = true
+		selected = append(selected, *match)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no providers selected")
+	}
+	return selected, nil
+}
+
+func promptLine(in *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func promptYesNo(in *bufio.Reader, out io.Writer, prompt string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	answer, err := promptLine(in, out, fmt.Sprintf("%s %s ", prompt, hint))
+	if err != nil || answer == "" {
+		return def
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
+
+func writeInitConfig(path string, file initConfigFile) error {
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	header := "# Generated by 'heike init'. Unset fields use built-in defaults;\n" +
+		"# run 'heike config view' to see the resolved configuration.\n"
+	// The file may hold API keys, so keep it private to the user.
+	if err := os.WriteFile(path, append([]byte(header), data...), 0600); err != nil {
+		return fmt.Errorf("failed to write config to %s: %w", path, err)
+	}
+	return nil
+}
+
+// registerBundledSkills copies bundled skills into the global skills
+// directory so they load outside the source tree. Existing skills are kept.
+func registerBundledSkills() ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	sources, err := resolveRuntimeSkillSources(wd)
+	if err != nil {
+		return nil, fmt.Errorf("resolve runtime skill sources: %w", err)
+	}
+	bundledRoot, ok := skillSourcePathByKind(sources, discovery.SourceBundled)
+	if !ok {
+		return nil, fmt.Errorf("bundled skill source is not configured")
+	}
+	entries, err := os.ReadDir(bundledRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no bundled skills found at %s", bundledRoot)
+		}
+		return nil, fmt.Errorf("read bundled skills: %w", err)
+	}
+	globalRoot, err := store.GetSkillsDir()
+	if err != nil {
+		return nil, fmt.Errorf("resolve skills directory: %w", err)
+	}
+
+	installed := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		sourceDir := filepath.Join(bundledRoot, entry.Name())
+		if _, err := os.Stat(filepath.Join(sourceDir, "SKILL.md")); err != nil {
+			continue
+		}
+		destDir := filepath.Join(globalRoot, entry.Name())
+		if _, err := os.Stat(destDir); err == nil {
+			continue
+		}
+		if err := copySkillDirectory(sourceDir, destDir); err != nil {
+			return installed, fmt.Errorf("copy skill %s: %w", entry.Name(), err)
+		}
+		installed = append(installed, entry.Name())
+	}
+	return installed, nil
+}
+
+// runInitSelfTest reloads the written config and probes every provider.
+func runInitSelfTest(cmd *cobra.Command) error {
+	loadedCfg, err := config.Load(cmd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	httpClients, err := httpclient.ForConfig(loadedCfg.HTTP)
+	if err != nil {
+		return err
+	}
+	router, err := model.NewModelRouterWithHTTPClients(loadedCfg.Models, httpClients)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), initSelfTestTimeout)
+	defer cancel()
+	return router.Health(ctx)
+}
+
+func init() {
+	initCmd.Flags().StringSlice("providers", nil, "Providers to configure, first is the default (e.g. openai,anthropic)")
+	initCmd.Flags().Bool("no-input", false, "Do not prompt; API keys come from environment variables")
+	initCmd.Flags().Bool("skip-self-test", false, "Skip the provider connectivity self-test")
+	initCmd.Flags().Bool("force", false, "Overwrite an existing config file")
+	rootCmd.AddCommand(initCmd)
+}
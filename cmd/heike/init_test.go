@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInitCmd_WritesConfigAndRegistersSkills(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	t.Chdir(project)
+	skillDir := filepath.Join(project, "skills", "greeter")
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		t.Fatalf("mkdir skill: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: greeter\n---\n"), 0644); err != nil {
+		t.Fatalf("write skill: %v", err)
+	}
+
+	if err := initCmd.Flags().Set("skip-self-test", "true"); err != nil {
+		t.Fatalf("set flag: %v", err)
+	}
+	t.Cleanup(func() { initCmd.Flags().Set("skip-self-test", "false") })
+
+	var out bytes.Buffer
+	initCmd.SetIn(strings.NewReader("2,openai\nsk-ant-test\n\n"))
+	initCmd.SetOut(&out)
+	t.Cleanup(func() {
+		initCmd.SetIn(nil)
+		initCmd.SetOut(nil)
+	})
+
+	if err := initCmd.RunE(initCmd, nil); err != nil {
+		t.Fatalf("init failed: %v\n%s", err, out.String())
+	}
+
+	configPath := filepath.Join(home, ".heike", "config.yaml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	var written initConfigFile
+	if err := yaml.Unmarshal(data, &written); err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if written.Models.Default != "claude-3-haiku" || written.Models.Fallback != "gpt-4-turbo" {
+		t.Fatalf("unexpected default/fallback: %+v", written.Models)
+	}
+	if len(written.Models.Registry) != 2 || written.Models.Registry[0].APIKey != "sk-ant-test" || written.Models.Registry[1].APIKey != "" {
+		t.Fatalf("unexpected registry: %+v", written.Models.Registry)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".heike", "skills", "greeter", "SKILL.md")); err != nil {
+		t.Fatalf("expected bundled skill registered globally: %v", err)
+	}
+
+	if err := initCmd.RunE(initCmd, nil); err == nil {
+		t.Fatal("expected existing config to be rejected without --force")
+	}
+}
+
+func TestSelectInitProviders(t *testing.T) {
+	providers, err := selectInitProviders([]string{"3", " Gemini ", "ollama"})
+	if err != nil {
+		t.Fatalf("selectInitProviders failed: %v", err)
+	}
+	if len(providers) != 2 || providers[0].Name != "gemini" || providers[1].Name != "ollama" {
+		t.Fatalf("unexpected providers: %+v", providers)
+	}
+
+	if _, err := selectInitProviders([]string{"mistral"}); err == nil {
+		t.Fatal("expected unknown provider error")
+	}
+}
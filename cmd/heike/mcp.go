@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/harunnryd/heike/cmd/heike/runtime"
+	"github.com/harunnryd/heike/cmd/heike/runtime/initializers"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/tool/mcp"
+	"github.com/harunnryd/heike/internal/tooling"
+
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Model Context Protocol integration",
+	Long:  `Expose heike to other agents and editors over the Model Context Protocol.`,
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve heike tools and skills over MCP stdio",
+	Long: `Run an MCP server on stdin/stdout. Built-in and skill tools are offered as
+MCP tools and run under the workspace governance policy; skills are offered as
+MCP prompts. Logs go to stderr.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return configError(fmt.Errorf("config not loaded"))
+		}
+		workspaceID := runtime.ResolveWorkspaceID(cmd)
+
+		engineComponent, err := initializers.NewPolicyInitializer().Initialize(cmd.Context(), cfg, workspaceID)
+		if err != nil {
+			return fmt.Errorf("init policy: %w", err)
+		}
+		tools, err := tooling.Build(workspaceID, engineComponent.(*policy.Engine), "", cfg)
+		if err != nil {
+			return fmt.Errorf("build tooling: %w", err)
+		}
+		defer tools.Close()
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		prompts, err := skillPrompts(wd)
+		if err != nil {
+			return err
+		}
+
+		allow, _ := cmd.Flags().GetStringSlice("tools")
+		server := mcp.NewServer(mcp.ServeOptions{
+			Tools:   tools.Runner,
+			Prompts: prompts,
+			Allow:   allow,
+			Version: version,
+		})
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := server.Serve(ctx, cmd.InOrStdin(), cmd.OutOrStdout()); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("mcp server: %w", err)
+		}
+		return nil
+	},
+}
+
+// skillPrompts returns the runtime skills as MCP prompts.
+func skillPrompts(workspacePath string) ([]mcp.Prompt, error) {
+	registry, warnings, err := loadRuntimeSkillRegistry(workspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("load runtime skills: %w", err)
+	}
+	printSkillLoadWarnings(warnings)
+
+	names, err := registry.List("name")
+	if err != nil {
+		return nil, fmt.Errorf("list skills: %w", err)
+	}
+	prompts := make([]mcp.Prompt, 0, len(names))
+	for _, name := range names {
+		skill, err := registry.Get(name)
+		if err != nil || skill == nil || strings.TrimSpace(skill.Content) == "" {
+			continue
+		}
+		prompts = append(prompts, mcp.Prompt{
+			Name:        skill.Name,
+			Description: skill.Description,
+			Text:        skill.Content,
+		})
+	}
+	return prompts, nil
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+	mcpCmd.AddCommand(mcpServeCmd)
+	mcpServeCmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
+	mcpServeCmd.Flags().StringSlice("tools", nil, "Only offer these tools (comma-separated)")
+}
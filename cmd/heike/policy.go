@@ -35,7 +35,7 @@ var policyShowCmd = &cobra.Command{
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		workspacePath, err := store.GetWorkspacePath(workspaceID, cfg.Daemon.WorkspacePath)
@@ -73,12 +73,12 @@ var policySetCmd = &cobra.Command{
 
 		configPath, err := resolveGovernanceConfigPath(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to resolve config path: %w", err)
+			return configError(fmt.Errorf("failed to resolve config path: %w", err))
 		}
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		if autoAllow {
@@ -86,7 +86,7 @@ var policySetCmd = &cobra.Command{
 		} else if requireApproval {
 			cfg.Governance.RequireApproval = append(cfg.Governance.RequireApproval, toolName)
 		} else {
-			return fmt.Errorf("must specify --allow or --require-approval")
+			return usageError(fmt.Errorf("must specify --allow or --require-approval"))
 		}
 
 		if err := saveGovernanceConfig(configPath, cfg.Governance); err != nil {
@@ -108,12 +108,12 @@ var policyDenyCmd = &cobra.Command{
 
 		configPath, err := resolveGovernanceConfigPath(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to resolve config path: %w", err)
+			return configError(fmt.Errorf("failed to resolve config path: %w", err))
 		}
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		cfg.Governance.RequireApproval = append(cfg.Governance.RequireApproval, toolName)
@@ -137,12 +137,12 @@ var policyRequireApprovalCmd = &cobra.Command{
 
 		configPath, err := resolveGovernanceConfigPath(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to resolve config path: %w", err)
+			return configError(fmt.Errorf("failed to resolve config path: %w", err))
 		}
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		cfg.Governance.RequireApproval = append(cfg.Governance.RequireApproval, toolName)
@@ -170,7 +170,7 @@ var policyAuditCmd = &cobra.Command{
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		auditLogger, err := policy.NewAuditLogger(workspaceID, cfg.Daemon.WorkspacePath, &policy.AuditPolicy{
@@ -216,7 +216,7 @@ var policyStatsCmd = &cobra.Command{
 
 		cfg, err := config.Load(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return configError(fmt.Errorf("failed to load config: %w", err))
 		}
 
 		fmt.Println("=== Policy Statistics ===")
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/harunnryd/heike/internal/auth"
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/model"
 
 	"github.com/spf13/cobra"
 )
@@ -13,41 +20,125 @@ var providerCmd = &cobra.Command{
 	Short: "Manage LLM providers",
 }
 
+// loginVerifyTimeout bounds the API key check run by provider login.
+const loginVerifyTimeout = 30 * time.Second
+
 var loginCmd = &cobra.Command{
 	Use:   "login [provider]",
-	Short: "Authenticate with a provider (e.g. openai-codex)",
-	Args:  cobra.ExactArgs(1),
+	Short: "Authenticate with a provider (openai-codex, anthropic, gemini)",
+	Long: `Authenticate with a provider and store the credential in the secret store
+selected by auth.secret_store: files under ~/.heike/auth/ (file, the default)
+or the OS keychain (keyring).
+
+openai-codex runs the browser OAuth flow. anthropic and gemini do not offer
+OAuth for API clients, so login verifies an API key and saves it, as
+~/.heike/auth/<provider>.json (or --auth-file) with the file store, where
+registry entries without an api_key pick it up.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		providerName := args[0]
-		if providerName != "openai-codex" {
-			return fmt.Errorf("currently only 'openai-codex' is supported for interactive login")
+		switch {
+		case providerName == "openai-codex":
+			return loginCodex(cmd.Context())
+		case auth.IsAPIKeyProvider(providerName):
+			return loginAPIKey(cmd, providerName)
+		default:
+			return usageError(fmt.Errorf("unsupported provider %q for login (use openai-codex, anthropic or gemini)", providerName))
 		}
+	},
+}
 
-		fmt.Printf("Initiating OAuth login for %s...\n", providerName)
+// loginCodex runs the interactive OpenAI Codex OAuth flow and saves the token.
+func loginCodex(ctx context.Context) error {
+	fmt.Println("Initiating OAuth login for openai-codex...")
 
-		token, err := auth.LoginCodexOAuthInteractive(cmd.Context(), auth.CodexOAuthConfig{
-			CallbackAddr: cfg.Auth.Codex.CallbackAddr,
-			RedirectURI:  cfg.Auth.Codex.RedirectURI,
-			OAuthTimeout: cfg.Auth.Codex.OAuthTimeout,
-			TokenPath:    cfg.Auth.Codex.TokenPath,
-		})
-		if err != nil {
-			return fmt.Errorf("login failed: %w", err)
+	token, err := auth.LoginCodexOAuthInteractive(ctx, auth.CodexOAuthConfig{
+		CallbackAddr: cfg.Auth.Codex.CallbackAddr,
+		RedirectURI:  cfg.Auth.Codex.RedirectURI,
+		OAuthTimeout: cfg.Auth.Codex.OAuthTimeout,
+		TokenPath:    cfg.Auth.Codex.TokenPath,
+	})
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	// Save Token
+	if err := auth.SaveToken(token, cfg.Auth.Codex.TokenPath); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	fmt.Println("Successfully logged in to openai-codex!")
+	if auth.SecretBackend() == auth.SecretStoreKeyring {
+		fmt.Println("Token saved to the OS keyring.")
+	}
+	fmt.Printf("Access Token: %s... (expires in %d seconds)\n", token.AccessToken[:10], token.ExpiresIn)
+
+	return nil
+}
+
+// loginAPIKey verifies an API key against the provider and saves it to the
+// provider's auth file.
+func loginAPIKey(cmd *cobra.Command, providerName string) error {
+	out := cmd.OutOrStdout()
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	authFile, _ := cmd.Flags().GetString("auth-file")
+	skipVerify, _ := cmd.Flags().GetBool("skip-verify")
+
+	if apiKey == "" {
+		fmt.Fprintf(out, "API key for %s: ", providerName)
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("read api key: %w", err)
 		}
+		apiKey = line
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return usageError(fmt.Errorf("no api key given for %s", providerName))
+	}
 
-		// Save Token
-		if err := auth.SaveToken(token, cfg.Auth.Codex.TokenPath); err != nil {
-			return fmt.Errorf("failed to save token: %w", err)
+	if !skipVerify {
+		fmt.Fprintf(out, "Verifying %s API key...\n", providerName)
+		if err := verifyProviderAPIKey(cmd.Context(), providerName, apiKey); err != nil {
+			return fmt.Errorf("login failed: %w", err)
 		}
+	}
 
-		fmt.Printf("Successfully logged in to %s!\n", providerName)
-		fmt.Printf("Access Token: %s... (expires in %d seconds)\n", token.AccessToken[:10], token.ExpiresIn)
+	path, err := auth.SaveProviderAPIKey(providerName, apiKey, authFile)
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %w", err)
+	}
+	fmt.Fprintf(out, "Saved %s API key to %s\n", providerName, path)
+	if authFile != "" && auth.SecretBackend() == auth.SecretStoreFile {
+		fmt.Fprintf(out, "Set auth_file: %s on the %s entries in models.registry to use it.\n", path, providerName)
+	}
+	return nil
+}
 
-		return nil
-	},
+// verifyProviderAPIKey runs the router health probe for a throwaway registry
+// entry holding apiKey.
+func verifyProviderAPIKey(ctx context.Context, providerName, apiKey string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	router, err := model.NewModelRouter(config.ModelsConfig{
+		HealthTimeout: config.DefaultModelHealthTimeout,
+		Registry: []config.ModelRegistry{
+			{Name: "login-" + providerName, Provider: providerName, APIKey: apiKey},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, loginVerifyTimeout)
+	defer cancel()
+	return router.Health(ctx)
 }
 
 func init() {
+	loginCmd.Flags().String("api-key", "", "API key to store (anthropic, gemini); prompted when omitted")
+	loginCmd.Flags().String("auth-file", "", "Where to store the API key with the file secret store (default ~/.heike/auth/<provider>.json)")
+	loginCmd.Flags().Bool("skip-verify", false, "Save the API key without checking it against the provider")
 	rootCmd.AddCommand(providerCmd)
 	providerCmd.AddCommand(loginCmd)
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/auth"
+)
+
+func TestLoginCmd_SavesPromptedAPIKey(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := loginCmd.Flags().Set("skip-verify", "true"); err != nil {
+		t.Fatalf("set flag: %v", err)
+	}
+	t.Cleanup(func() { loginCmd.Flags().Set("skip-verify", "false") })
+
+	var out bytes.Buffer
+	loginCmd.SetIn(strings.NewReader("sk-ant-test\n"))
+	loginCmd.SetOut(&out)
+	t.Cleanup(func() {
+		loginCmd.SetIn(nil)
+		loginCmd.SetOut(nil)
+	})
+
+	if err := loginCmd.RunE(loginCmd, []string{"anthropic"}); err != nil {
+		t.Fatalf("login failed: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), filepath.Join(home, ".heike", "auth", "anthropic.json")) {
+		t.Fatalf("output should name the auth file: %s", out.String())
+	}
+	key, err := auth.LoadProviderAPIKey("anthropic", "")
+	if err != nil || key != "sk-ant-test" {
+		t.Fatalf("saved key = %q, %v", key, err)
+	}
+}
+
+func TestLoginCmd_UnsupportedProvider(t *testing.T) {
+	err := loginCmd.RunE(loginCmd, []string{"zai"})
+	var cliErr *cliError
+	if !errors.As(err, &cliErr) || cliErr.code != exitUsage {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
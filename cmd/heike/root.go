@@ -1,9 +1,9 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
+	"github.com/harunnryd/heike/internal/auth"
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/logger"
 
@@ -11,8 +11,9 @@ import (
 )
 
 var (
-	cfgFile string
-	cfg     *config.Config
+	cfgFile    string
+	cfg        *config.Config
+	jsonErrors bool
 )
 
 var rootCmd = &cobra.Command{
@@ -20,11 +21,18 @@ var rootCmd = &cobra.Command{
 	Short: "Heike AI Runtime",
 	Long:  `Heike is a deterministic, guarded, and proactive AI runtime.`,
 	RunE:  runDaemonCommand,
+	// Errors are printed by Execute so --json can format them.
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = jsonErrors
+
 		var err error
 		cfg, err = config.Load(cmd)
 		if err != nil {
-			return err
+			return configError(err)
+		}
+		if err := auth.SetSecretBackend(cfg.Auth.SecretStore); err != nil {
+			return configError(err)
 		}
 
 		logger.Setup(cfg.Server.LogLevel)
@@ -33,16 +41,36 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	wrapArgValidators(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(writeError(os.Stderr, err, jsonErrors))
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.heike/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&jsonErrors, "json", false, "print errors as JSON on stderr")
 	rootCmd.PersistentFlags().String("server.log_level", config.DefaultServerLogLevel, "log level (debug, info, warn, error)")
 	rootCmd.PersistentFlags().Int("server.port", config.DefaultServerPort, "server port")
 	rootCmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
 	rootCmd.Flags().Bool("force-clean-locks", false, "Force cleanup of stale lock files (default: warn-only)")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
+}
+
+// wrapArgValidators marks positional argument errors as usage errors.
+func wrapArgValidators(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(c *cobra.Command, args []string) error {
+			if err := validate(c, args); err != nil {
+				c.SilenceUsage = jsonErrors
+				return usageError(err)
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		wrapArgValidators(sub)
+	}
 }
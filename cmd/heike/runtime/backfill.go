@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/daemon"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/orchestrator/memory"
+)
+
+type memoryBackfiller interface {
+	BackfillMemory(ctx context.Context, opts memory.BackfillOptions, progress func(done, total int)) (memory.BackfillResult, error)
+}
+
+// memoryBackfillJobs tracks the transcript backfill. One runs at a time;
+// the last one is kept until the next starts.
+type memoryBackfillJobs struct {
+	mu  sync.Mutex
+	job *daemon.RuntimeMemoryBackfill
+	now func() time.Time
+}
+
+func newMemoryBackfillJobs() *memoryBackfillJobs {
+	return &memoryBackfillJobs{now: time.Now}
+}
+
+func (j *memoryBackfillJobs) start(ctx context.Context, opts memory.BackfillOptions, backfiller memoryBackfiller) (daemon.RuntimeMemoryBackfill, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.job != nil && j.job.Status == reembedRunning {
+		return *j.job, fmt.Errorf("memory backfill already running: %w", heikeErrors.ErrConflict)
+	}
+	job := &daemon.RuntimeMemoryBackfill{Status: reembedRunning, StartedAt: j.now()}
+	j.job = job
+
+	go func() {
+		result, err := backfiller.BackfillMemory(ctx, opts, func(done, total int) {
+			j.mu.Lock()
+			job.Done, job.Total = done, total
+			j.mu.Unlock()
+		})
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		completedAt := j.now()
+		job.CompletedAt = &completedAt
+		job.Sessions, job.Chunks = result.Sessions, result.Chunks
+		if err != nil {
+			slog.Error("Memory backfill failed", "error", err)
+			job.Status = reembedFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = reembedCompleted
+	}()
+	return *job, nil
+}
+
+func (j *memoryBackfillJobs) get() (daemon.RuntimeMemoryBackfill, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.job == nil {
+		return daemon.RuntimeMemoryBackfill{}, heikeErrors.NotFound("no memory backfill")
+	}
+	return *j.job, nil
+}
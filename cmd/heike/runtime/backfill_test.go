@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/orchestrator/memory"
+)
+
+type fakeBackfiller struct {
+	release chan struct{}
+	err     error
+}
+
+func (f *fakeBackfiller) BackfillMemory(ctx context.Context, opts memory.BackfillOptions, progress func(done, total int)) (memory.BackfillResult, error) {
+	progress(0, 3)
+	<-f.release
+	progress(3, 3)
+	return memory.BackfillResult{Sessions: 2, Chunks: 5}, f.err
+}
+
+func waitBackfill(t *testing.T, jobs *memoryBackfillJobs, status string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := jobs.get(); err == nil && job.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := jobs.get()
+	t.Fatalf("job status = %+v, want %s", job, status)
+}
+
+func TestMemoryBackfillJobs_OneRunningAtATime(t *testing.T) {
+	jobs := newMemoryBackfillJobs()
+	if _, err := jobs.get(); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("get before start error = %v, want not found", err)
+	}
+	backfiller := &fakeBackfiller{release: make(chan struct{})}
+
+	job, err := jobs.start(context.Background(), memory.BackfillOptions{}, backfiller)
+	if err != nil || job.Status != reembedRunning {
+		t.Fatalf("start = %+v, %v", job, err)
+	}
+	if _, err := jobs.start(context.Background(), memory.BackfillOptions{}, backfiller); !errors.Is(err, heikeErrors.ErrConflict) {
+		t.Fatalf("second start error = %v, want conflict", err)
+	}
+
+	close(backfiller.release)
+	waitBackfill(t, jobs, reembedCompleted)
+	job, _ = jobs.get()
+	if job.Done != 3 || job.Total != 3 || job.Sessions != 2 || job.Chunks != 5 || job.CompletedAt == nil {
+		t.Fatalf("completed job = %+v", job)
+	}
+}
+
+func TestMemoryBackfillJobs_RecordsFailure(t *testing.T) {
+	jobs := newMemoryBackfillJobs()
+	backfiller := &fakeBackfiller{release: make(chan struct{}), err: errors.New("embedding model unavailable")}
+	close(backfiller.release)
+
+	if _, err := jobs.start(context.Background(), memory.BackfillOptions{}, backfiller); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitBackfill(t, jobs, reembedFailed)
+	if job, _ := jobs.get(); job.Error != "embedding model unavailable" {
+		t.Fatalf("failed job = %+v", job)
+	}
+}
@@ -4,20 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path/filepath"
 
 	"github.com/harunnryd/heike/cmd/heike/runtime/initializers"
 
 	"github.com/harunnryd/heike/internal/adapter"
+	"github.com/harunnryd/heike/internal/backup"
+	"github.com/harunnryd/heike/internal/batch"
 	"github.com/harunnryd/heike/internal/concurrency"
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/egress"
+	"github.com/harunnryd/heike/internal/featureflag"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/knowledge"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/objectstore"
 	"github.com/harunnryd/heike/internal/orchestrator"
 	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/scheduler"
 	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/store"
 	"github.com/harunnryd/heike/internal/tool"
+	"github.com/harunnryd/heike/internal/webhook"
 	"github.com/harunnryd/heike/internal/worker"
 	"github.com/harunnryd/heike/internal/zanshin"
 )
@@ -37,6 +46,11 @@ type RuntimeComponents struct {
 	InteractiveWorker *worker.Worker
 	BackgroundWorker  *worker.Worker
 	Scheduler         *scheduler.Scheduler
+	Knowledge         *knowledge.Syncer
+	Backup            *backup.Manager
+	Batch             *batch.Manager
+	Webhooks          *webhook.Notifier
+	Features          *featureflag.Flags
 
 	ToolRunner    *tool.Runner
 	ToolRegistry  *tool.Registry
@@ -46,6 +60,9 @@ type RuntimeComponents struct {
 	Zanshin *zanshin.Engine
 
 	Locks *concurrency.SimpleSessionLockManager
+
+	// closeTools disconnects MCP servers.
+	closeTools func()
 }
 
 type AdapterBuildOptions struct {
@@ -90,6 +107,10 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 			msgType = ingress.TypeCommand
 		case string(ingress.TypeCron):
 			msgType = ingress.TypeCron
+		case string(ingress.TypeEmail):
+			msgType = ingress.TypeEmail
+		case string(ingress.TypeWebhook):
+			msgType = ingress.TypeWebhook
 		case string(ingress.TypeSystemEvent):
 			msgType = ingress.TypeSystemEvent
 		}
@@ -104,9 +125,15 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 		return nil
 	}
 
+	attachmentsDir, err := store.GetAttachmentsDir(workspaceID, cfg.Daemon.WorkspacePath)
+	if err != nil {
+		components.cleanup()
+		return nil, fmt.Errorf("resolve attachments directory: %w", err)
+	}
 	adapterMgr, err := adapter.NewRuntimeManager(cfg.Adapters, eventHandler, adapter.RuntimeAdapterOptions{
 		IncludeCLI:        adapterOpts.IncludeCLI,
 		IncludeSystemNull: adapterOpts.IncludeSystemNull,
+		AttachmentsDir:    attachmentsDir,
 	})
 	if err != nil {
 		components.cleanup()
@@ -129,6 +156,28 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 		return nil, fmt.Errorf("init policy engine: %w", err)
 	}
 	components.PolicyEngine = policyComponent.(*policy.Engine)
+	if notifier, ok := components.AdapterMgr.OutputAdapter("desktop"); ok {
+		components.PolicyEngine.OnApprovalRequested(func(app policy.Approval) {
+			content := fmt.Sprintf("Approval required for %s (id %s)", app.Tool, app.ID)
+			if err := notifier.Send(ctx, "", content); err != nil {
+				slog.Warn("Failed to send approval notification", "approval", app.ID, "error", err)
+			}
+		})
+	}
+
+	// The bundled skill library backs both skill and tool discovery.
+	library, err := skill.InstallLibrary(cfg.Discovery.BundledVersion)
+	if err != nil {
+		slog.Warn("Bundled skill library", "error", err)
+	}
+	if library != nil && library.Switched {
+		for _, change := range library.Changes {
+			slog.Warn("Bundled skill changed since last run", "skill", change.Name, "change", change.Change, "from", library.Previous, "to", library.Version)
+		}
+	}
+	if library != nil && len(library.Pending) > 0 {
+		slog.Info("Bundled skills pinned", "version", library.Version, "embedded", library.Embedded, "pending_changes", len(library.Pending))
+	}
 
 	toolsInitializer := initializers.NewToolsInitializer(components.StoreWorker, components.PolicyEngine)
 	toolsComponent, err := toolsInitializer.Initialize(ctx, cfg, workspaceID)
@@ -139,9 +188,11 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 	toolsStruct := toolsComponent.(struct {
 		Registry *tool.Registry
 		Runner   *tool.Runner
+		Close    func()
 	})
 	components.ToolRegistry = toolsStruct.Registry
 	components.ToolRunner = toolsStruct.Runner
+	components.closeTools = toolsStruct.Close
 
 	components.SkillRegistry = skill.NewRegistry()
 	loadWarnings := skill.LoadRuntimeRegistry(components.SkillRegistry, skill.RuntimeLoadOptions{
@@ -150,6 +201,7 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 		WorkspacePath:     "",
 		ProjectPath:       cfg.Discovery.ProjectPath,
 		SourceOrder:       cfg.Discovery.SkillSources,
+		BundledVersion:    cfg.Discovery.BundledVersion,
 	})
 	for _, warn := range loadWarnings {
 		slog.Warn("Failed to load skill registry source", "error", warn, "workspace", workspaceID)
@@ -165,7 +217,25 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 			return nil, fmt.Errorf("register output adapter %s: %w", outputAdapter.Name(), err)
 		}
 	}
+	if cfg.Scheduler.Digest.Enabled {
+		if err := registerDigestAdapters(egressComponent, cfg, workspaceID); err != nil {
+			components.cleanup()
+			return nil, fmt.Errorf("register digest adapter: %w", err)
+		}
+	}
 	components.Egress = egressComponent
+	components.AdapterMgr.SetOutputRegistry(egressComponent)
+
+	featuresPath, err := store.GetFeatureFlagsPath(workspaceID, cfg.Daemon.WorkspacePath)
+	if err != nil {
+		components.cleanup()
+		return nil, fmt.Errorf("resolve feature flags path: %w", err)
+	}
+	components.Features, err = featureflag.Load(featuresPath, cfg.Features)
+	if err != nil {
+		components.cleanup()
+		return nil, fmt.Errorf("init feature flags: %w", err)
+	}
 
 	orchestratorInitializer := initializers.NewOrchestratorInitializer(components.StoreWorker, components.ToolRunner, components.PolicyEngine, components.SkillRegistry, components.Egress)
 	orchComponent, err := orchestratorInitializer.Initialize(ctx, cfg, workspaceID)
@@ -186,11 +256,55 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 		InteractiveWorker *worker.Worker
 		BackgroundWorker  *worker.Worker
 		Locks             *concurrency.SimpleSessionLockManager
+		Webhooks          *webhook.Notifier
 	})
 	components.Ingress = workersStruct.Ingress
 	components.InteractiveWorker = workersStruct.InteractiveWorker
 	components.BackgroundWorker = workersStruct.BackgroundWorker
 	components.Locks = workersStruct.Locks
+	components.Webhooks = workersStruct.Webhooks
+	// Chat adapters that can render approve/deny controls get one per request
+	// in the session's channel.
+	components.PolicyEngine.OnApprovalRequested(func(app policy.Approval) {
+		if app.SessionID == "" {
+			return
+		}
+		sess, err := components.StoreWorker.GetSession(app.SessionID)
+		if err != nil || sess == nil {
+			return
+		}
+		output, ok := components.AdapterMgr.OutputAdapter(sess.Metadata["source"])
+		if !ok {
+			return
+		}
+		if notifier, ok := output.(adapter.ApprovalNotifier); ok {
+			if err := notifier.NotifyApproval(ctx, app.SessionID, app.ID, app.Tool); err != nil {
+				slog.Warn("Failed to send approval notification", "approval", app.ID, "adapter", output.Name(), "error", err)
+			}
+		}
+	})
+	if components.Webhooks != nil {
+		components.PolicyEngine.OnApprovalRequested(func(app policy.Approval) {
+			if app.SessionID == "" {
+				return
+			}
+			sess, err := components.StoreWorker.GetSession(app.SessionID)
+			if err != nil || sess == nil || sess.Metadata[ingress.NotifyURLMetadataKey] == "" {
+				return
+			}
+			components.Webhooks.ApprovalRequested(sess.Metadata[ingress.NotifyURLMetadataKey], webhook.ApprovalPayload{
+				ApprovalID: app.ID,
+				SessionID:  app.SessionID,
+				Tool:       app.Tool,
+				Input:      app.Input,
+				CreatedAt:  app.CreatedAt,
+			})
+		})
+	}
+	if kernel, ok := components.Orchestrator.(*orchestrator.DefaultKernel); ok {
+		kernel.SetDelayedSubmitter(components.Ingress)
+		kernel.SetFeatureFlags(components.Features)
+	}
 	components.Zanshin = zanshin.NewEngine(cfg.Zanshin, func() int {
 		if components.Ingress == nil {
 			return 0
@@ -198,6 +312,13 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 		return len(components.Ingress.InteractiveQueue())
 	})
 
+	batchManager, err := newBatchManager(cfg, components.Ingress, components.StoreWorker)
+	if err != nil {
+		components.cleanup()
+		return nil, fmt.Errorf("init batch: %w", err)
+	}
+	components.Batch = batchManager
+
 	schedulerInitializer := initializers.NewSchedulerInitializer(components.Ingress)
 	schedComponent, err := schedulerInitializer.Initialize(ctx, cfg, workspaceID)
 	if err != nil {
@@ -206,10 +327,213 @@ func NewRuntimeComponentsWithOptions(ctx context.Context, cfg *config.Config, wo
 	}
 	components.Scheduler = schedComponent.(*scheduler.Scheduler)
 
+	if cfg.Knowledge.Enabled {
+		syncer, err := newKnowledgeSyncer(cfg, workspaceID, components.StoreWorker)
+		if err != nil {
+			components.cleanup()
+			return nil, fmt.Errorf("init knowledge sync: %w", err)
+		}
+		components.Knowledge = syncer
+	}
+
+	if cfg.Backup.Enabled {
+		manager, err := newBackupManager(cfg, workspaceID)
+		if err != nil {
+			components.cleanup()
+			return nil, fmt.Errorf("init backup: %w", err)
+		}
+		components.Backup = manager
+	}
+
 	slog.Info("Runtime components initialized successfully", "workspace", workspaceID)
 	return components, nil
 }
 
+// registerDigestAdapters routes scheduler output into the workspace digest and
+// exposes a "digest" adapter that sessions can add as an egress target.
+func registerDigestAdapters(eg egress.Egress, cfg *config.Config, workspaceID string) error {
+	dir := cfg.Scheduler.Digest.Dir
+	if dir == "" {
+		dir = config.DefaultSchedulerDigestDir
+	}
+	if !filepath.IsAbs(dir) {
+		workspacePath, err := store.GetWorkspacePath(workspaceID, cfg.Daemon.WorkspacePath)
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(workspacePath, dir)
+	}
+
+	for _, name := range []string{"scheduler", "digest"} {
+		digest, err := adapter.NewDigestAdapter(name, dir, cfg.Scheduler.Digest.Period)
+		if err != nil {
+			return err
+		}
+		_ = eg.Unregister(name)
+		if err := eg.Register(digest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBatchManager submits batch goals to the background lane, each in its own
+// session, and follows them through the store's event tracker.
+func newBatchManager(cfg *config.Config, in *ingress.Ingress, storeWorker *store.Worker) (*batch.Manager, error) {
+	bc := cfg.Batch
+	pollInterval, err := config.DurationOrDefault(bc.PollInterval, config.DefaultBatchPollInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse batch poll interval: %w", err)
+	}
+	maxGoals := bc.MaxGoals
+	if maxGoals <= 0 {
+		maxGoals = config.DefaultBatchMaxGoals
+	}
+	maxConcurrency := bc.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = config.DefaultBatchMaxConcurrency
+	}
+	maxBatches := bc.MaxBatches
+	if maxBatches <= 0 {
+		maxBatches = config.DefaultBatchMaxBatches
+	}
+
+	submit := func(ctx context.Context, sessionID, goal string) (string, error) {
+		evt := ingress.NewEvent("batch", ingress.TypeBatch, sessionID, goal, nil)
+		if err := in.Submit(ctx, &evt); err != nil {
+			return "", err
+		}
+		return evt.ID, nil
+	}
+	return batch.NewManager(batch.Config{
+		MaxGoals:           maxGoals,
+		DefaultConcurrency: bc.Concurrency,
+		MaxConcurrency:     maxConcurrency,
+		PollInterval:       pollInterval,
+		MaxBatches:         maxBatches,
+	}, submit, storeWorker)
+}
+
+// newKnowledgeSyncer builds the connectors enabled under knowledge and a
+// syncer that embeds their pages into the knowledge collection.
+func newKnowledgeSyncer(cfg *config.Config, workspaceID string, storeWorker *store.Worker) (*knowledge.Syncer, error) {
+	kc := cfg.Knowledge
+	interval, err := config.DurationOrDefault(kc.SyncInterval, config.DefaultKnowledgeSyncInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse knowledge sync interval: %w", err)
+	}
+	timeout, err := config.DurationOrDefault(kc.RequestTimeout, config.DefaultKnowledgeRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse knowledge request timeout: %w", err)
+	}
+
+	var connectors []knowledge.Connector
+	if kc.Notion.Enabled {
+		connector, err := knowledge.NewNotionConnector(kc.Notion.BaseURL, kc.Notion.Token, kc.Notion.PageIDs, timeout)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+	if kc.Confluence.Enabled {
+		connector, err := knowledge.NewConfluenceConnector(kc.Confluence.BaseURL, kc.Confluence.Email, kc.Confluence.APIToken, kc.Confluence.SpaceKeys, timeout)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+	if kc.GoogleDrive.Enabled {
+		connector, err := knowledge.NewGoogleDriveConnector(kc.GoogleDrive.BaseURL, kc.GoogleDrive.AccessToken, kc.GoogleDrive.FolderIDs, timeout)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+	if len(connectors) == 0 {
+		slog.Warn("Knowledge sync enabled but no connectors are configured", "workspace", workspaceID)
+	}
+
+	httpClients, err := httpclient.ForConfig(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	router, err := model.NewModelRouterWithHTTPClients(cfg.Models, httpClients)
+	if err != nil {
+		return nil, fmt.Errorf("model router init: %w", err)
+	}
+	embeddingModel := cfg.Models.Embedding
+	if embeddingModel == "" {
+		embeddingModel = config.DefaultModelEmbedding
+	}
+	embed := func(ctx context.Context, text string) ([]float32, error) {
+		return router.RouteEmbedding(ctx, embeddingModel, text)
+	}
+
+	knowledgeDir, err := store.GetKnowledgeDir(workspaceID, cfg.Daemon.WorkspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve knowledge directory: %w", err)
+	}
+	collection := kc.Collection
+	if collection == "" {
+		collection = config.DefaultKnowledgeCollection
+	}
+
+	return knowledge.NewSyncer(knowledge.SyncerConfig{
+		Collection:   collection,
+		Interval:     interval,
+		ChunkSize:    kc.ChunkSize,
+		ChunkOverlap: kc.ChunkOverlap,
+		StatePath:    filepath.Join(knowledgeDir, "state.json"),
+	}, storeWorker, embed, connectors...)
+}
+
+// newBackupManager opens the configured s3:// or gs:// location with
+// credentials from the environment.
+func newBackupManager(cfg *config.Config, workspaceID string) (*backup.Manager, error) {
+	bc := cfg.Backup
+	loc, err := objectstore.ParseURI(bc.URI)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := config.DurationOrDefault(bc.RequestTimeout, config.DefaultBackupRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse backup request timeout: %w", err)
+	}
+	bucket, err := objectstore.OpenFromEnv(loc, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := config.DurationOrDefault(bc.Interval, config.DefaultBackupInterval)
+	if err != nil {
+		return nil, fmt.Errorf("parse backup interval: %w", err)
+	}
+	snapshotMaxAge, err := config.DurationOrDefault(bc.Lifecycle.SnapshotMaxAge, config.DefaultBackupSnapshotMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("parse backup snapshot max age: %w", err)
+	}
+	archiveMaxAge, err := config.DurationOrDefault(bc.Lifecycle.ArchiveMaxAge, config.DefaultBackupArchiveMaxAge)
+	if err != nil {
+		return nil, fmt.Errorf("parse backup archive max age: %w", err)
+	}
+	workspacePath, err := store.GetWorkspacePath(workspaceID, cfg.Daemon.WorkspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace path: %w", err)
+	}
+
+	return backup.NewManager(backup.Config{
+		WorkspaceID:      workspaceID,
+		WorkspacePath:    workspacePath,
+		Interval:         interval,
+		Transcripts:      bc.Transcripts,
+		Artifacts:        bc.Artifacts,
+		ArtifactMinBytes: bc.ArtifactMinBytes,
+		KeepLast:         bc.Lifecycle.KeepLast,
+		SnapshotMaxAge:   snapshotMaxAge,
+		ArchiveMaxAge:    archiveMaxAge,
+	}, bucket, loc)
+}
+
 func (r *RuntimeComponents) Start() error {
 	if r.Orchestrator == nil {
 		return fmt.Errorf("orchestrator not initialized")
@@ -243,11 +567,31 @@ func (r *RuntimeComponents) Start() error {
 
 	if r.AdapterMgr != nil {
 		r.AdapterMgr.Start(r.Ctx)
+		if r.Ingress != nil {
+			policy, err := overloadPolicy(r.Config.Ingress)
+			if err != nil {
+				r.cleanup()
+				return err
+			}
+			r.AdapterMgr.WatchLoad(r.Ctx, r.Ingress.Load, policy)
+		}
 	}
 
 	if r.Zanshin != nil {
 		r.Zanshin.Start(r.Ctx)
 	}
+
+	if r.Knowledge != nil {
+		r.Knowledge.Start(r.Ctx)
+	}
+
+	if r.Backup != nil {
+		r.Backup.Start(r.Ctx)
+	}
+
+	if r.Batch != nil {
+		r.Batch.Start(r.Ctx)
+	}
 	return nil
 }
 
@@ -272,6 +616,10 @@ func (r *RuntimeComponents) Stop() {
 		r.Orchestrator.Stop(r.Ctx)
 	}
 
+	if r.closeTools != nil {
+		r.closeTools()
+	}
+
 	if r.AdapterMgr != nil {
 		if err := r.AdapterMgr.Stop(r.Ctx); err != nil {
 			slog.Warn("Failed to stop adapter manager", "error", err)
@@ -293,3 +641,24 @@ func (r *RuntimeComponents) cleanup() {
 	slog.Debug("Cleaning up runtime components...")
 	r.Stop()
 }
+
+func overloadPolicy(cfg config.IngressConfig) (adapter.OverloadPolicy, error) {
+	interval, err := config.DurationOrDefault(cfg.OverloadCheckInterval, config.DefaultIngressOverloadCheckInterval)
+	if err != nil {
+		return adapter.OverloadPolicy{}, fmt.Errorf("parse ingress overload check interval: %w", err)
+	}
+	high := cfg.HighWaterMark
+	if high <= 0 {
+		high = config.DefaultIngressHighWaterMark
+	}
+	low := cfg.LowWaterMark
+	if low <= 0 {
+		low = config.DefaultIngressLowWaterMark
+	}
+	return adapter.OverloadPolicy{
+		HighWaterMark: high,
+		LowWaterMark:  low,
+		CheckInterval: interval,
+		BusyMessage:   cfg.BusyMessage,
+	}, nil
+}
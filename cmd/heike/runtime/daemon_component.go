@@ -2,14 +2,31 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	goruntime "runtime"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/harunnryd/heike/internal/adapter"
+	"github.com/harunnryd/heike/internal/batch"
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/daemon"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/featureflag"
 	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/orchestrator/memory"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/orchestrator/task"
 	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/store"
+
+	"github.com/oklog/ulid/v2"
 )
 
 type DaemonRuntimeComponent struct {
@@ -17,7 +34,10 @@ type DaemonRuntimeComponent struct {
 	cfg         *config.Config
 	workspaceID string
 	adapterOpts AdapterBuildOptions
+	loadConfig  func() (*config.Config, error)
 	runtime     *RuntimeComponents
+	reembeds    *reembedJobs
+	backfills   *memoryBackfillJobs
 	initialized bool
 	started     bool
 	stopped     bool
@@ -28,9 +48,19 @@ func NewDaemonRuntimeComponent(workspaceID string, cfg *config.Config, adapterOp
 		cfg:         cfg,
 		workspaceID: workspaceID,
 		adapterOpts: adapterOpts,
+		reembeds:    newReembedJobs(),
+		backfills:   newMemoryBackfillJobs(),
 	}
 }
 
+// SetConfigLoader sets how ReloadAdapters reads the config again. Without
+// one, reloading is refused.
+func (c *DaemonRuntimeComponent) SetConfigLoader(load func() (*config.Config, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadConfig = load
+}
+
 func (c *DaemonRuntimeComponent) Name() string {
 	return "Runtime"
 }
@@ -225,8 +255,36 @@ func (c *DaemonRuntimeComponent) SubmitEvent(ctx context.Context, evt daemon.Run
 		return "", fmt.Errorf("unsupported event type: %s", evt.Type)
 	}
 
-	normalized := ingress.NewEvent(evt.Source, msgType, evt.SessionID, evt.Content, evt.Metadata)
+	for _, target := range []string{evt.CallbackURL, evt.NotifyURL} {
+		if target == "" {
+			continue
+		}
+		if r.Webhooks == nil {
+			return "", heikeErrors.InvalidInput("callbacks are not available")
+		}
+		if err := r.Webhooks.Validate(target); err != nil {
+			return "", err
+		}
+	}
+
+	metadata := evt.Metadata
+	if evt.NotifyURL != "" {
+		metadata = make(map[string]string, len(evt.Metadata)+1)
+		for k, v := range evt.Metadata {
+			metadata[k] = v
+		}
+		metadata[ingress.NotifyURLMetadataKey] = evt.NotifyURL
+	}
+
+	normalized := ingress.NewEvent(evt.Source, msgType, evt.SessionID, evt.Content, metadata)
+	if evt.IdempotencyKey != "" {
+		normalized.ID = ingress.EventIDForKey(evt.Source, evt.IdempotencyKey)
+	}
+	normalized.CallbackURL = evt.CallbackURL
 	if err := r.Ingress.Submit(ctx, &normalized); err != nil {
+		if errors.Is(err, heikeErrors.ErrDuplicateEvent) {
+			return normalized.ID, err
+		}
 		return "", err
 	}
 	if r.Zanshin != nil {
@@ -261,24 +319,224 @@ func (c *DaemonRuntimeComponent) ListSessions(ctx context.Context) ([]daemon.Run
 			continue
 		}
 
-		metadataCopy := map[string]string(nil)
-		if len(meta.Metadata) > 0 {
-			metadataCopy = make(map[string]string, len(meta.Metadata))
-			for k, v := range meta.Metadata {
-				metadataCopy[k] = v
+		result = append(result, runtimeSession(meta))
+	}
+	return result, nil
+}
+
+func runtimeSession(meta *store.SessionMeta) daemon.RuntimeSession {
+	metadataCopy := map[string]string(nil)
+	if len(meta.Metadata) > 0 {
+		metadataCopy = make(map[string]string, len(meta.Metadata))
+		for k, v := range meta.Metadata {
+			metadataCopy[k] = v
+		}
+	}
+	return daemon.RuntimeSession{
+		ID:        meta.ID,
+		Title:     meta.Title,
+		Status:    meta.Status,
+		CreatedAt: meta.CreatedAt,
+		UpdatedAt: meta.UpdatedAt,
+		Metadata:  metadataCopy,
+	}
+}
+
+func (c *DaemonRuntimeComponent) CreateSession(ctx context.Context, sess daemon.RuntimeSession) (daemon.RuntimeSession, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeSession{}, fmt.Errorf("store worker not initialized")
+	}
+
+	id := strings.TrimSpace(sess.ID)
+	if id == "" {
+		id = "sess_" + ulid.Make().String()
+	} else if strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return daemon.RuntimeSession{}, heikeErrors.InvalidInput(fmt.Sprintf("invalid session id %q", id))
+	}
+	existing, err := r.StoreWorker.GetSession(id)
+	if err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	if existing != nil {
+		return daemon.RuntimeSession{}, fmt.Errorf("session %s already exists: %w", id, heikeErrors.ErrConflict)
+	}
+
+	metadata := make(map[string]string, len(sess.Metadata))
+	for k, v := range sess.Metadata {
+		metadata[k] = v
+	}
+	if requested, ok := metadata[policy.ToolsAllowMetadataKey]; ok {
+		metadata[policy.ToolsAllowMetadataKey] = policy.NarrowToolAllowlist(nil, requested)
+	}
+	title := strings.TrimSpace(sess.Title)
+	if title == "" {
+		title = "New Session"
+	}
+	now := time.Now()
+	meta := &store.SessionMeta{
+		ID:        id,
+		Title:     title,
+		Status:    "active",
+		CreatedAt: now,
+		UpdatedAt: now,
+		Metadata:  metadata,
+	}
+	if err := r.StoreWorker.SaveSession(meta); err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	return runtimeSession(meta), nil
+}
+
+// UpdateSession renames a session or changes its metadata. As with ingress,
+// tools_allow can only be narrowed, never widened or removed.
+func (c *DaemonRuntimeComponent) UpdateSession(ctx context.Context, sessionID string, update daemon.RuntimeSessionUpdate) (daemon.RuntimeSession, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeSession{}, fmt.Errorf("store worker not initialized")
+	}
+	existing, err := r.StoreWorker.GetSession(sessionID)
+	if err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	if existing == nil {
+		return daemon.RuntimeSession{}, heikeErrors.NotFound(fmt.Sprintf("session %s", sessionID))
+	}
+
+	// The metadata map is shared with the store index; update a copy.
+	updated := *existing
+	updated.Metadata = make(map[string]string, len(existing.Metadata)+len(update.Metadata))
+	for k, v := range existing.Metadata {
+		updated.Metadata[k] = v
+	}
+	if update.Title != nil {
+		title := strings.TrimSpace(*update.Title)
+		if title == "" {
+			return daemon.RuntimeSession{}, heikeErrors.InvalidInput("title cannot be empty")
+		}
+		updated.Title = title
+	}
+	for key, value := range update.Metadata {
+		if key == policy.ToolsAllowMetadataKey {
+			if value == nil {
+				return daemon.RuntimeSession{}, heikeErrors.PermissionDenied("tools_allow cannot be removed; it can only be narrowed")
 			}
+			updated.Metadata[key] = policy.NarrowToolAllowlist(existing.Metadata, *value)
+			continue
+		}
+		if value == nil {
+			delete(updated.Metadata, key)
+			continue
+		}
+		updated.Metadata[key] = *value
+	}
+	updated.UpdatedAt = time.Now()
+	if err := r.StoreWorker.SaveSession(&updated); err != nil {
+		return daemon.RuntimeSession{}, err
+	}
+	return runtimeSession(&updated), nil
+}
+
+func (c *DaemonRuntimeComponent) DeleteSession(ctx context.Context, sessionID string, reset bool) error {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return err
+	}
+	if r.StoreWorker == nil {
+		return fmt.Errorf("store worker not initialized")
+	}
+	existing, err := r.StoreWorker.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		ids, err := r.StoreWorker.ListSessions()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(ids, sessionID) {
+			return heikeErrors.NotFound(fmt.Sprintf("session %s", sessionID))
 		}
+	}
 
-		result = append(result, daemon.RuntimeSession{
-			ID:        meta.ID,
-			Title:     meta.Title,
-			Status:    meta.Status,
-			CreatedAt: meta.CreatedAt,
-			UpdatedAt: meta.UpdatedAt,
-			Metadata:  metadataCopy,
-		})
+	if err := r.StoreWorker.ResetSession(sessionID); err != nil {
+		return err
 	}
-	return result, nil
+	if !reset || existing == nil {
+		return nil
+	}
+	kept := *existing
+	kept.UpdatedAt = time.Now()
+	return r.StoreWorker.SaveSession(&kept)
+}
+
+func (c *DaemonRuntimeComponent) MergeSession(ctx context.Context, sessionID, sourceID string) (daemon.RuntimeSessionMerge, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeSessionMerge{}, err
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeSessionMerge{}, fmt.Errorf("store worker not initialized")
+	}
+	sessionID, sourceID = strings.TrimSpace(sessionID), strings.TrimSpace(sourceID)
+	if sessionID == "" || sourceID == "" {
+		return daemon.RuntimeSessionMerge{}, heikeErrors.InvalidInput("session and source ids are required")
+	}
+
+	// Wait for running turns so no event lands in the source after it is
+	// copied. Locks are taken in order to avoid deadlocking a concurrent merge.
+	if r.Locks != nil && sessionID != sourceID {
+		first, second := sessionID, sourceID
+		if second < first {
+			first, second = second, first
+		}
+		r.Locks.Lock(first)
+		defer r.Locks.Unlock(first)
+		r.Locks.Lock(second)
+		defer r.Locks.Unlock(second)
+	}
+
+	result, err := session.Merge(r.StoreWorker, sessionID, sourceID)
+	if err != nil {
+		return daemon.RuntimeSessionMerge{}, err
+	}
+	merged := daemon.RuntimeSessionMerge{Session: runtimeSession(result.Target), Source: sourceID, Events: result.Events}
+	if mover, ok := r.Orchestrator.(interface {
+		MoveSessionVectors(sourceID, targetID string) (int, error)
+	}); ok {
+		moved, err := mover.MoveSessionVectors(sourceID, sessionID)
+		merged.Vectors = moved
+		if err != nil {
+			return merged, fmt.Errorf("session merged but moving vectors failed: %w", err)
+		}
+	}
+	return merged, nil
+}
+
+func (c *DaemonRuntimeComponent) CancelSession(ctx context.Context, sessionID string) error {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return heikeErrors.InvalidInput("session id is required")
+	}
+	canceller, ok := r.Orchestrator.(interface {
+		CancelSession(sessionID string) int
+	})
+	if !ok {
+		return fmt.Errorf("cancellation not supported by orchestrator")
+	}
+	if canceller.CancelSession(sessionID) == 0 {
+		return heikeErrors.NotFound(fmt.Sprintf("running task for session %s", sessionID))
+	}
+	return nil
 }
 
 func (c *DaemonRuntimeComponent) ReadTranscript(ctx context.Context, sessionID string, limit int) ([]string, error) {
@@ -292,7 +550,102 @@ func (c *DaemonRuntimeComponent) ReadTranscript(ctx context.Context, sessionID s
 	return r.StoreWorker.ReadTranscript(sessionID, limit)
 }
 
+func (c *DaemonRuntimeComponent) WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan daemon.RuntimeTranscriptLine, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return nil, err
+	}
+	if r.StoreWorker == nil {
+		return nil, fmt.Errorf("store worker not initialized")
+	}
+	lines, watch, err := r.StoreWorker.WatchTranscript(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan daemon.RuntimeTranscriptLine)
+	go func() {
+		defer close(out)
+		for followTranscript(ctx, out, lines, watch, &from) {
+			// The watch fell behind; catch up from the file and keep
+			// following.
+			if !r.StoreWorker.IsRunning() {
+				return
+			}
+			lines, watch, err = r.StoreWorker.WatchTranscript(sessionID)
+			if err != nil {
+				slog.Warn("Transcript watch ended", "session", sessionID, "error", err)
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// followTranscript sends lines after *from and then the watched lines,
+// advancing *from. It reports whether the watch ended before ctx was done.
+func followTranscript(ctx context.Context, out chan<- daemon.RuntimeTranscriptLine, lines []string, watch *store.TranscriptWatch, from *int) bool {
+	defer watch.Close()
+	send := func(id int, data string) bool {
+		select {
+		case out <- daemon.RuntimeTranscriptLine{ID: id, Data: data}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if *from > len(lines) {
+		*from = len(lines)
+	}
+	for ; *from < len(lines); *from++ {
+		if !send(*from+1, lines[*from]) {
+			return false
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case line, ok := <-watch.Lines():
+			if !ok {
+				return ctx.Err() == nil
+			}
+			if !send(line.Seq, line.Data) {
+				return false
+			}
+			*from = line.Seq
+		}
+	}
+}
+
+func (c *DaemonRuntimeComponent) EventStatus(ctx context.Context, eventID string) (daemon.RuntimeEventStatus, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeEventStatus{}, err
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeEventStatus{}, fmt.Errorf("store worker not initialized")
+	}
+	status, ok := r.StoreWorker.EventStatus(eventID)
+	if !ok {
+		return daemon.RuntimeEventStatus{}, heikeErrors.NotFound(fmt.Sprintf("event %s", eventID))
+	}
+	return daemon.RuntimeEventStatus{
+		ID:          status.ID,
+		Status:      string(status.Status),
+		SessionID:   status.SessionID,
+		Error:       status.Error,
+		QueuedAt:    status.QueuedAt,
+		StartedAt:   status.StartedAt,
+		CompletedAt: status.CompletedAt,
+	}, nil
+}
+
 func (c *DaemonRuntimeComponent) ListPendingApprovals(ctx context.Context) ([]daemon.RuntimeApproval, error) {
+	return c.ListApprovals(ctx, string(policy.StatusPending))
+}
+
+func (c *DaemonRuntimeComponent) ListApprovals(ctx context.Context, statuses ...string) ([]daemon.RuntimeApproval, error) {
 	r, err := c.runtimeForAPI()
 	if err != nil {
 		return nil, err
@@ -301,11 +654,16 @@ func (c *DaemonRuntimeComponent) ListPendingApprovals(ctx context.Context) ([]da
 		return nil, fmt.Errorf("policy engine not initialized")
 	}
 
-	approvals := r.PolicyEngine.ListApprovals(policy.StatusPending)
+	filter := make([]policy.ApprovalStatus, 0, len(statuses))
+	for _, status := range statuses {
+		filter = append(filter, policy.ApprovalStatus(strings.ToUpper(status)))
+	}
+	approvals := r.PolicyEngine.ListApprovals(filter...)
 	result := make([]daemon.RuntimeApproval, 0, len(approvals))
 	for _, app := range approvals {
 		result = append(result, daemon.RuntimeApproval{
 			ID:        app.ID,
+			SessionID: app.SessionID,
 			Tool:      app.Tool,
 			Input:     app.Input,
 			Status:    string(app.Status),
@@ -343,3 +701,438 @@ func (c *DaemonRuntimeComponent) ZanshinStatus(ctx context.Context) map[string]i
 	}
 	return r.Zanshin.Status()
 }
+
+func (c *DaemonRuntimeComponent) StoreStats(ctx context.Context) (daemon.RuntimeStoreStats, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeStoreStats{}, err
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeStoreStats{}, fmt.Errorf("store worker not initialized")
+	}
+	stats, err := r.StoreWorker.Stats()
+	if err != nil {
+		return daemon.RuntimeStoreStats{}, fmt.Errorf("collect store stats: %w", err)
+	}
+	ops := make(map[string]daemon.RuntimeOpLatency, len(stats.Ops))
+	for name, op := range stats.Ops {
+		ops[name] = daemon.RuntimeOpLatency{Count: op.Count, AvgMS: op.AvgMS, MaxMS: op.MaxMS}
+	}
+	return daemon.RuntimeStoreStats{
+		WorkspaceID: stats.WorkspaceID,
+		Disk: daemon.RuntimeDiskUsage{
+			Sessions:       stats.Disk.Sessions,
+			Vectors:        stats.Disk.Vectors,
+			Sandbox:        stats.Disk.Sandbox,
+			RotatedBackups: stats.Disk.RotatedBackups,
+			Other:          stats.Disk.Other,
+			Total:          stats.Disk.Total,
+		},
+		InboxDepth:    stats.InboxDepth,
+		InboxCapacity: stats.InboxCapacity,
+		Ops:           ops,
+
+		TranscriptWatches:    stats.TranscriptWatches,
+		PendingVectorUpserts: stats.PendingVectorUpserts,
+		Warnings:             stats.Warnings,
+	}, nil
+}
+
+func (c *DaemonRuntimeComponent) SubmitBatch(ctx context.Context, goals []string, concurrency int) (daemon.RuntimeBatch, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeBatch{}, err
+	}
+	if r.Batch == nil {
+		return daemon.RuntimeBatch{}, fmt.Errorf("batch manager not initialized")
+	}
+	b, err := r.Batch.Submit(goals, concurrency)
+	if err != nil {
+		return daemon.RuntimeBatch{}, err
+	}
+	return runtimeBatch(b), nil
+}
+
+func (c *DaemonRuntimeComponent) GetBatch(ctx context.Context, batchID string) (daemon.RuntimeBatch, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeBatch{}, err
+	}
+	if r.Batch == nil {
+		return daemon.RuntimeBatch{}, fmt.Errorf("batch manager not initialized")
+	}
+	b, err := r.Batch.Get(batchID)
+	if err != nil {
+		return daemon.RuntimeBatch{}, err
+	}
+	return runtimeBatch(b), nil
+}
+
+func (c *DaemonRuntimeComponent) ListBatches(ctx context.Context) ([]daemon.RuntimeBatch, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return nil, err
+	}
+	if r.Batch == nil {
+		return nil, fmt.Errorf("batch manager not initialized")
+	}
+	batches := r.Batch.List()
+	result := make([]daemon.RuntimeBatch, 0, len(batches))
+	for _, b := range batches {
+		result = append(result, runtimeBatch(b))
+	}
+	return result, nil
+}
+
+func runtimeBatch(b batch.Batch) daemon.RuntimeBatch {
+	out := daemon.RuntimeBatch{
+		ID:          b.ID,
+		Status:      string(b.Status),
+		Concurrency: b.Concurrency,
+		CreatedAt:   b.CreatedAt,
+		CompletedAt: b.CompletedAt,
+		Progress: daemon.RuntimeBatchProgress{
+			Total:     b.Progress.Total,
+			Pending:   b.Progress.Pending,
+			Queued:    b.Progress.Queued,
+			Running:   b.Progress.Running,
+			Completed: b.Progress.Completed,
+			Failed:    b.Progress.Failed,
+		},
+	}
+	for _, item := range b.Items {
+		out.Items = append(out.Items, daemon.RuntimeBatchItem{
+			Index:       item.Index,
+			Goal:        item.Goal,
+			SessionID:   item.SessionID,
+			EventID:     item.EventID,
+			Status:      string(item.Status),
+			Result:      item.Result,
+			Error:       item.Error,
+			StartedAt:   item.StartedAt,
+			CompletedAt: item.CompletedAt,
+		})
+	}
+	return out
+}
+
+// InjectSessionContext embeds docs into the session's context collection so
+// memory recall surfaces them once a goal is submitted to sessionID.
+func (c *DaemonRuntimeComponent) InjectSessionContext(ctx context.Context, sessionID string, docs []daemon.RuntimeContextDocument) (daemon.RuntimeContextResult, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeContextResult{}, err
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return daemon.RuntimeContextResult{}, heikeErrors.InvalidInput("session id is required")
+	}
+	if len(docs) == 0 {
+		return daemon.RuntimeContextResult{}, heikeErrors.InvalidInput("documents are required")
+	}
+	contextDocs := make([]memory.ContextDocument, 0, len(docs))
+	for i, doc := range docs {
+		if strings.TrimSpace(doc.Text) == "" {
+			return daemon.RuntimeContextResult{}, heikeErrors.InvalidInput(fmt.Sprintf("document %d: text is required", i))
+		}
+		contextDocs = append(contextDocs, memory.ContextDocument{Text: doc.Text, Metadata: doc.Metadata})
+	}
+
+	injector, ok := r.Orchestrator.(interface {
+		InjectSessionContext(ctx context.Context, sessionID string, docs []memory.ContextDocument) (int, error)
+	})
+	if !ok {
+		return daemon.RuntimeContextResult{}, fmt.Errorf("session context not supported by orchestrator")
+	}
+	chunks, err := injector.InjectSessionContext(ctx, sessionID, contextDocs)
+	if err != nil {
+		return daemon.RuntimeContextResult{}, err
+	}
+	return daemon.RuntimeContextResult{SessionID: sessionID, Documents: len(docs), Chunks: chunks}, nil
+}
+
+func (c *DaemonRuntimeComponent) ToolSelection(ctx context.Context, sessionID string) (daemon.RuntimeToolSelection, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeToolSelection{}, err
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return daemon.RuntimeToolSelection{}, heikeErrors.InvalidInput("session id is required")
+	}
+	source, ok := r.Orchestrator.(interface {
+		LastToolSelection(sessionID string) (task.ToolSelection, bool)
+	})
+	if !ok {
+		return daemon.RuntimeToolSelection{}, fmt.Errorf("tool selection not supported by orchestrator")
+	}
+	sel, ok := source.LastToolSelection(sessionID)
+	if !ok {
+		return daemon.RuntimeToolSelection{}, heikeErrors.NotFound(fmt.Sprintf("tool selection for session %s", sessionID))
+	}
+	result := daemon.RuntimeToolSelection{
+		SessionID:  sessionID,
+		Goal:       sel.Goal,
+		Broker:     sel.Broker,
+		Available:  sel.Available,
+		Tools:      make([]daemon.RuntimeToolSelectionDetail, 0, len(sel.Details)),
+		SelectedAt: sel.SelectedAt,
+	}
+	for _, d := range sel.Details {
+		result.Tools = append(result.Tools, daemon.RuntimeToolSelectionDetail{Name: d.Name, Score: d.Score, Reasons: d.Reasons})
+	}
+	return result, nil
+}
+
+// StartReembed re-embeds a vector collection in the background. The job
+// runs on the runtime context, so it is cancelled when the daemon stops.
+func (c *DaemonRuntimeComponent) StartReembed(ctx context.Context, collection string) (daemon.RuntimeReembed, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeReembed{}, err
+	}
+	collection = strings.TrimSpace(collection)
+	if collection == "" {
+		return daemon.RuntimeReembed{}, heikeErrors.InvalidInput("collection is required")
+	}
+	if r.StoreWorker == nil {
+		return daemon.RuntimeReembed{}, fmt.Errorf("store worker not initialized")
+	}
+	if !r.StoreWorker.HasVectorCollection(collection) {
+		return daemon.RuntimeReembed{}, heikeErrors.NotFound(fmt.Sprintf("vector collection %s", collection))
+	}
+	reembedder, ok := r.Orchestrator.(collectionReembedder)
+	if !ok {
+		return daemon.RuntimeReembed{}, fmt.Errorf("re-embedding not supported by orchestrator")
+	}
+	return c.reembeds.start(r.Ctx, collection, reembedder)
+}
+
+func (c *DaemonRuntimeComponent) ReembedStatus(ctx context.Context, collection string) (daemon.RuntimeReembed, error) {
+	return c.reembeds.get(strings.TrimSpace(collection))
+}
+
+// StartMemoryBackfill embeds past transcripts into memory in the
+// background, on the runtime context like StartReembed.
+func (c *DaemonRuntimeComponent) StartMemoryBackfill(ctx context.Context, req daemon.RuntimeMemoryBackfillRequest) (daemon.RuntimeMemoryBackfill, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeMemoryBackfill{}, err
+	}
+	backfiller, ok := r.Orchestrator.(memoryBackfiller)
+	if !ok {
+		return daemon.RuntimeMemoryBackfill{}, fmt.Errorf("memory backfill not supported by orchestrator")
+	}
+	opts := memory.BackfillOptions{Source: strings.TrimSpace(req.Source)}
+	for _, sessionID := range req.Sessions {
+		if sessionID = strings.TrimSpace(sessionID); sessionID != "" {
+			opts.Sessions = append(opts.Sessions, sessionID)
+		}
+	}
+	if req.Since != nil {
+		opts.Since = *req.Since
+	}
+	return c.backfills.start(r.Ctx, opts, backfiller)
+}
+
+func (c *DaemonRuntimeComponent) MemoryBackfillStatus(ctx context.Context) (daemon.RuntimeMemoryBackfill, error) {
+	return c.backfills.get()
+}
+
+func (c *DaemonRuntimeComponent) Debug(ctx context.Context) (daemon.RuntimeDebug, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeDebug{}, err
+	}
+	info := daemon.RuntimeDebug{
+		Goroutines:    goruntime.NumGoroutine(),
+		IngressQueues: map[string]int{},
+	}
+	if r.StoreWorker != nil {
+		info.StoreInboxDepth, info.StoreInboxCapacity = r.StoreWorker.Inbox()
+	}
+	if r.Ingress != nil {
+		info.IngressQueues["interactive"] = len(r.Ingress.InteractiveQueue())
+		info.IngressQueues["background"] = len(r.Ingress.BackgroundQueue())
+	}
+	if r.Scheduler != nil {
+		status := r.Scheduler.Status()
+		info.Scheduler = daemon.RuntimeSchedulerStatus{
+			Running:  status.Running,
+			InFlight: status.InFlight,
+			Leases:   make([]daemon.RuntimeSchedulerLease, 0, len(status.Leases)),
+		}
+		now := time.Now()
+		for _, lease := range status.Leases {
+			info.Scheduler.Leases = append(info.Scheduler.Leases, daemon.RuntimeSchedulerLease{
+				TaskID:    lease.TaskID,
+				RunID:     lease.RunID,
+				Status:    string(lease.Status),
+				ExpiresAt: lease.ExpiresAt,
+				Expired:   now.After(lease.ExpiresAt),
+			})
+		}
+	}
+	return info, nil
+}
+
+// ModelCircuits reports the orchestrator router's circuit breaker states. It
+// returns nil when the breaker is disabled or the runtime is not ready.
+func (c *DaemonRuntimeComponent) ModelCircuits(ctx context.Context) map[string]daemon.RuntimeModelCircuit {
+	r, err := c.runtimeForAPI()
+	if err != nil || r.Orchestrator == nil {
+		return nil
+	}
+	source, ok := r.Orchestrator.(interface {
+		ModelCircuits() map[string]model.CircuitState
+	})
+	if !ok {
+		return nil
+	}
+	states := source.ModelCircuits()
+	if states == nil {
+		return nil
+	}
+	result := make(map[string]daemon.RuntimeModelCircuit, len(states))
+	for name, st := range states {
+		result[name] = daemon.RuntimeModelCircuit{
+			State:               st.State,
+			ConsecutiveFailures: st.ConsecutiveFailures,
+			OpenedAt:            st.OpenedAt,
+			RetryAt:             st.RetryAt,
+		}
+	}
+	return result
+}
+
+func (c *DaemonRuntimeComponent) AdapterStatuses(ctx context.Context) []daemon.RuntimeAdapterStatus {
+	r, err := c.runtimeForAPI()
+	if err != nil || r.AdapterMgr == nil {
+		return []daemon.RuntimeAdapterStatus{}
+	}
+
+	return runtimeAdapterStatuses(r.AdapterMgr.Statuses())
+}
+
+func (c *DaemonRuntimeComponent) ListAdapters(ctx context.Context) ([]daemon.RuntimeAdapterStatus, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return nil, err
+	}
+	if r.AdapterMgr == nil {
+		return nil, fmt.Errorf("adapters not initialized")
+	}
+	return runtimeAdapterStatuses(r.AdapterMgr.Adapters()), nil
+}
+
+func (c *DaemonRuntimeComponent) SetAdapterEnabled(ctx context.Context, name string, enabled bool) (daemon.RuntimeAdapterStatus, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeAdapterStatus{}, err
+	}
+	if r.AdapterMgr == nil {
+		return daemon.RuntimeAdapterStatus{}, fmt.Errorf("adapters not initialized")
+	}
+	name = strings.TrimSpace(name)
+	if enabled {
+		err = r.AdapterMgr.Enable(name)
+	} else {
+		err = r.AdapterMgr.Disable(ctx, name)
+	}
+	if err != nil {
+		return daemon.RuntimeAdapterStatus{}, err
+	}
+	for _, st := range runtimeAdapterStatuses(r.AdapterMgr.Adapters()) {
+		if st.Name == name {
+			return st, nil
+		}
+	}
+	return daemon.RuntimeAdapterStatus{}, heikeErrors.NotFound(fmt.Sprintf("adapter %s", name))
+}
+
+func (c *DaemonRuntimeComponent) ReloadAdapters(ctx context.Context) (daemon.RuntimeAdapterReload, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeAdapterReload{}, err
+	}
+	if r.AdapterMgr == nil {
+		return daemon.RuntimeAdapterReload{}, fmt.Errorf("adapters not initialized")
+	}
+	c.mu.RLock()
+	load := c.loadConfig
+	c.mu.RUnlock()
+	if load == nil {
+		return daemon.RuntimeAdapterReload{}, heikeErrors.InvalidInput("this runtime cannot reload its config")
+	}
+	cfg, err := load()
+	if err != nil {
+		return daemon.RuntimeAdapterReload{}, heikeErrors.InvalidInput(fmt.Sprintf("load config: %v", err))
+	}
+	changed, err := r.AdapterMgr.Reload(ctx, cfg.Adapters)
+	if err != nil {
+		return daemon.RuntimeAdapterReload{}, err
+	}
+	return daemon.RuntimeAdapterReload{
+		Changed:  changed,
+		Adapters: runtimeAdapterStatuses(r.AdapterMgr.Adapters()),
+	}, nil
+}
+
+func runtimeAdapterStatuses(statuses []adapter.Status) []daemon.RuntimeAdapterStatus {
+	result := make([]daemon.RuntimeAdapterStatus, 0, len(statuses))
+	for _, st := range statuses {
+		result = append(result, daemon.RuntimeAdapterStatus{
+			Name:              st.Name,
+			State:             string(st.State),
+			LastError:         st.LastError,
+			LastErrorAt:       st.LastErrorAt,
+			ReconnectAttempts: st.ReconnectAttempts,
+			ConnectedAt:       st.ConnectedAt,
+		})
+	}
+	return result
+}
+
+func (c *DaemonRuntimeComponent) SendAlert(ctx context.Context, adapterName, target, content string) error {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return err
+	}
+	if r.Egress == nil {
+		return fmt.Errorf("egress not initialized")
+	}
+	for _, out := range r.Egress.ListAdapters() {
+		if out.Name() == adapterName {
+			return out.Send(ctx, target, content)
+		}
+	}
+	return heikeErrors.NotFound(fmt.Sprintf("output adapter %s", adapterName))
+}
+
+func (c *DaemonRuntimeComponent) FeatureFlags(ctx context.Context) ([]daemon.RuntimeFeatureFlag, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return nil, err
+	}
+	flags := r.Features.List()
+	out := make([]daemon.RuntimeFeatureFlag, 0, len(flags))
+	for _, flag := range flags {
+		out = append(out, daemon.RuntimeFeatureFlag(flag))
+	}
+	return out, nil
+}
+
+func (c *DaemonRuntimeComponent) SetFeatureFlag(ctx context.Context, name string, enabled *bool) (daemon.RuntimeFeatureFlag, error) {
+	r, err := c.runtimeForAPI()
+	if err != nil {
+		return daemon.RuntimeFeatureFlag{}, err
+	}
+	var flag featureflag.Flag
+	if enabled == nil {
+		flag, err = r.Features.Reset(name)
+	} else {
+		flag, err = r.Features.Override(name, *enabled)
+	}
+	if err != nil {
+		return daemon.RuntimeFeatureFlag{}, err
+	}
+	return daemon.RuntimeFeatureFlag(flag), nil
+}
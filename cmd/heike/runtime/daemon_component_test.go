@@ -2,10 +2,17 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/harunnryd/heike/internal/concurrency"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/store"
 )
 
 func setupDaemonComponentTestEnv(t *testing.T) {
@@ -82,3 +89,144 @@ func TestDaemonRuntimeComponent_Lifecycle(t *testing.T) {
 		t.Fatal("component should report unhealthy after stop")
 	}
 }
+
+func TestDaemonRuntimeComponent_SessionCRUD(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	worker, err := store.NewWorker("test-sessions", "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("create store worker: %v", err)
+	}
+	worker.Start()
+	defer worker.Stop()
+
+	comp := &DaemonRuntimeComponent{
+		runtime:     &RuntimeComponents{StoreWorker: worker},
+		initialized: true,
+		started:     true,
+	}
+	ctx := context.Background()
+
+	sess, err := comp.CreateSession(ctx, daemon.RuntimeSession{
+		Title:    "Inbox",
+		Metadata: map[string]string{"tools_allow": "exec_command,read_file", "team": "ops"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sess.ID == "" || sess.Title != "Inbox" || sess.Status != "active" {
+		t.Fatalf("created = %+v", sess)
+	}
+	if _, err := comp.CreateSession(ctx, daemon.RuntimeSession{ID: sess.ID}); !errors.Is(err, heikeErrors.ErrConflict) {
+		t.Fatalf("duplicate create err = %v, want conflict", err)
+	}
+	ids, err := worker.ListSessions()
+	if err != nil || !slices.Contains(ids, sess.ID) {
+		t.Fatalf("list = %v, %v; want %s", ids, err, sess.ID)
+	}
+
+	title := "Triage"
+	widened := "exec_command,write_file"
+	updated, err := comp.UpdateSession(ctx, sess.ID, daemon.RuntimeSessionUpdate{
+		Title:    &title,
+		Metadata: map[string]*string{"tools_allow": &widened, "team": nil},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Title != "Triage" || updated.Metadata["tools_allow"] != "exec_command" {
+		t.Fatalf("updated = %+v", updated)
+	}
+	if _, ok := updated.Metadata["team"]; ok {
+		t.Fatalf("team metadata not removed: %+v", updated.Metadata)
+	}
+	if _, err := comp.UpdateSession(ctx, sess.ID, daemon.RuntimeSessionUpdate{Metadata: map[string]*string{"tools_allow": nil}}); !errors.Is(err, heikeErrors.ErrPermissionDenied) {
+		t.Fatalf("remove tools_allow err = %v, want permission denied", err)
+	}
+	if _, err := comp.UpdateSession(ctx, "missing", daemon.RuntimeSessionUpdate{Title: &title}); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("update missing err = %v, want not found", err)
+	}
+
+	if err := comp.DeleteSession(ctx, sess.ID, true); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	kept, err := worker.GetSession(sess.ID)
+	if err != nil || kept == nil || kept.Title != "Triage" {
+		t.Fatalf("after reset = %+v, %v", kept, err)
+	}
+	if err := comp.DeleteSession(ctx, sess.ID, false); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if gone, _ := worker.GetSession(sess.ID); gone != nil {
+		t.Fatalf("session still present after delete: %+v", gone)
+	}
+	if err := comp.DeleteSession(ctx, sess.ID, false); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("delete missing err = %v, want not found", err)
+	}
+}
+
+func TestDaemonRuntimeComponent_MergeSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	worker, err := store.NewWorker("test-merge", "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("create store worker: %v", err)
+	}
+	worker.Start()
+	defer worker.Stop()
+
+	comp := &DaemonRuntimeComponent{
+		runtime:     &RuntimeComponents{StoreWorker: worker, Locks: concurrency.NewSimpleSessionLockManager()},
+		initialized: true,
+		started:     true,
+	}
+	ctx := context.Background()
+
+	thread, err := comp.CreateSession(ctx, daemon.RuntimeSession{ID: "C1", Title: "Thread", Metadata: map[string]string{"source": "slack", "tools_allow": "exec_command,read_file"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm, err := comp.CreateSession(ctx, daemon.RuntimeSession{ID: "D1", Title: "DM", Metadata: map[string]string{"source": "slack", "tools_allow": "read_file", "team": "ops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`{"id":"e1","type":"user","role":"user","content":"check the logs"}`,
+		`{"id":"e2","type":"assistant","role":"assistant","content":"on it"}`,
+	} {
+		if err := worker.WriteTranscript(dm.ID, []byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	merged, err := comp.MergeSession(ctx, thread.ID, dm.ID)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if merged.Events != 2 || merged.Source != "D1" {
+		t.Fatalf("merged = %+v", merged)
+	}
+	if meta := merged.Session.Metadata; meta["tools_allow"] != "read_file" || meta["team"] != "ops" || meta["merged_from"] != "D1" {
+		t.Fatalf("merged metadata = %+v", meta)
+	}
+
+	lines, err := worker.ReadTranscript(thread.ID, 0)
+	if err != nil || len(lines) != 3 {
+		t.Fatalf("target transcript = %v, %v", lines, err)
+	}
+	if !strings.Contains(lines[0], `"state":"merged"`) || !strings.Contains(lines[1], `"id":"e1"`) || !strings.Contains(lines[2], `"merged_from":"D1"`) {
+		t.Fatalf("target transcript = %v", lines)
+	}
+	archived, err := worker.GetSession(dm.ID)
+	if err != nil || archived.Status != "archived" || archived.Metadata["merged_into"] != "C1" {
+		t.Fatalf("source after merge = %+v, %v", archived, err)
+	}
+
+	if _, err := comp.MergeSession(ctx, thread.ID, dm.ID); !errors.Is(err, heikeErrors.ErrConflict) {
+		t.Fatalf("second merge err = %v, want conflict", err)
+	}
+	if _, err := comp.MergeSession(ctx, thread.ID, thread.ID); !errors.Is(err, heikeErrors.ErrInvalidInput) {
+		t.Fatalf("self merge err = %v, want invalid input", err)
+	}
+	if _, err := comp.MergeSession(ctx, thread.ID, "missing"); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("missing source err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,49 @@
+package initializers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/redis"
+)
+
+const governanceBackendRedis = "redis"
+
+// governanceRedis returns a Redis client for the shared governance backend,
+// or nil when governance state stays in per-workspace files.
+func governanceRedis(cfg config.GovernanceConfig, workspaceID string) (*redis.Client, string, time.Duration, error) {
+	backend := strings.ToLower(strings.TrimSpace(cfg.Backend))
+	switch backend {
+	case "", config.DefaultGovernanceBackend:
+		return nil, "", 0, nil
+	case governanceBackendRedis:
+	default:
+		return nil, "", 0, fmt.Errorf("unsupported governance backend %q", cfg.Backend)
+	}
+
+	timeout, err := config.DurationOrDefault(cfg.Redis.Timeout, config.DefaultGovernanceRedisTimeout)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("parse governance redis timeout: %w", err)
+	}
+	addr := strings.TrimSpace(cfg.Redis.Addr)
+	if addr == "" {
+		addr = config.DefaultGovernanceRedisAddr
+	}
+	client, err := redis.NewClient(redis.Config{
+		Addr:        addr,
+		Password:    cfg.Redis.Password,
+		DB:          cfg.Redis.DB,
+		DialTimeout: timeout,
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	prefix := strings.TrimSpace(cfg.Redis.KeyPrefix)
+	if prefix == "" {
+		prefix = config.DefaultGovernanceRedisKeyPrefix
+	}
+	return client, prefix + ":" + workspaceID + ":", timeout, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/egress"
+	"github.com/harunnryd/heike/internal/model"
 	"github.com/harunnryd/heike/internal/orchestrator"
 	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/skill"
@@ -69,6 +70,21 @@ func (oi *OrchestratorInitializer) Initialize(ctx context.Context, cfg *config.C
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kernel: %w", err)
 	}
+	if cfg.Models.WireLog.Enabled {
+		dir, err := store.GetWireLogDir(workspaceID, cfg.Daemon.WorkspacePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve wire log dir: %w", err)
+		}
+		secrets := make([]string, 0, len(cfg.Models.Registry))
+		for _, entry := range cfg.Models.Registry {
+			secrets = append(secrets, entry.APIKey)
+		}
+		wire, err := model.NewWireLog(dir, secrets)
+		if err != nil {
+			return nil, err
+		}
+		orch.SetWireLog(wire)
+	}
 	if err := orch.Init(ctx); err != nil {
 		return nil, fmt.Errorf("failed to initialize kernel: %w", err)
 	}
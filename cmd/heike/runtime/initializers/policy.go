@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/counter"
 	"github.com/harunnryd/heike/internal/policy"
 )
 
@@ -34,5 +35,13 @@ func (pi *PolicyInitializer) Initialize(ctx context.Context, cfg *config.Config,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create policy engine: %w", err)
 	}
+
+	client, prefix, timeout, err := governanceRedis(cfg.Governance, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		engine.SetCounterStore(counter.NewRedisStore(client, prefix), timeout)
+	}
 	return engine, nil
 }
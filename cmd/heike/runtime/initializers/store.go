@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/idempotency"
 	"github.com/harunnryd/heike/internal/store"
 )
 
@@ -49,6 +50,31 @@ func (si *StoreInitializer) Initialize(ctx context.Context, cfg *config.Config,
 	if transcriptRotateMaxBytes <= 0 {
 		transcriptRotateMaxBytes = config.DefaultStoreTranscriptRotateMaxBytes
 	}
+	eventStatusMaxEntries := cfg.Store.EventStatusMaxEntries
+	if eventStatusMaxEntries <= 0 {
+		eventStatusMaxEntries = config.DefaultStoreEventStatusMaxEntries
+	}
+	sandboxRetention, err := config.DurationOrDefault(cfg.Store.SandboxRetention, config.DefaultStoreSandboxRetention)
+	if err != nil {
+		return nil, fmt.Errorf("parse store sandbox retention: %w", err)
+	}
+	vectorRetryBackoff, err := config.DurationOrDefault(cfg.Store.VectorRetryBackoff, config.DefaultStoreVectorRetryBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("parse store vector retry backoff: %w", err)
+	}
+	vectorRetryMaxEntries := cfg.Store.VectorRetryMaxEntries
+	if vectorRetryMaxEntries <= 0 {
+		vectorRetryMaxEntries = config.DefaultStoreVectorRetryMaxEntries
+	}
+
+	var idemChecker idempotency.Checker
+	client, prefix, timeout, err := governanceRedis(cfg.Governance, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if client != nil {
+		idemChecker = idempotency.NewRedisStore(client, prefix+"idem:", timeout)
+	}
 
 	worker, err := store.NewWorker(workspaceID, workspaceRootPath, store.RuntimeConfig{
 		LockTimeout:              lockTimeout,
@@ -56,6 +82,15 @@ func (si *StoreInitializer) Initialize(ctx context.Context, cfg *config.Config,
 		LockMaxRetry:             lockMaxRetry,
 		InboxSize:                inboxSize,
 		TranscriptRotateMaxBytes: transcriptRotateMaxBytes,
+		EventStatusMaxEntries:    eventStatusMaxEntries,
+		SandboxRetention:         sandboxRetention,
+		VectorRetryBackoff:       vectorRetryBackoff,
+		VectorRetryMaxEntries:    vectorRetryMaxEntries,
+		Migration: store.MigrationOptions{
+			DryRun: cfg.Store.Migration.DryRun,
+			Backup: cfg.Store.Migration.Backup,
+		},
+		Idempotency: idemChecker,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create store worker: %w", err)
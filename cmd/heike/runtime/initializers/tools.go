@@ -48,12 +48,23 @@ func (ti *ToolsInitializer) Initialize(ctx context.Context, cfg *config.Config,
 	if err != nil {
 		return nil, fmt.Errorf("build tooling: %w", err)
 	}
+	toolingComponents.Runner.SetSandboxResolver(ti.storeWorker.SessionSandboxPath)
+	toolingComponents.Runner.SetAllowlistResolver(func(sessionID string) ([]string, bool, error) {
+		sess, err := ti.storeWorker.GetSession(sessionID)
+		if err != nil || sess == nil {
+			return nil, false, err
+		}
+		allow, restricted := policy.SessionToolAllowlist(sess.Metadata)
+		return allow, restricted, nil
+	})
 
 	return struct {
 		Registry *tool.Registry
 		Runner   *tool.Runner
+		Close    func()
 	}{
 		Registry: toolingComponents.Registry,
 		Runner:   toolingComponents.Runner,
+		Close:    toolingComponents.Close,
 	}, nil
 }
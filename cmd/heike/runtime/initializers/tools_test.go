@@ -123,6 +123,7 @@ func TestToolsInitializer_InitializeLoadsExecutableCustomTools(t *testing.T) {
 	tools := component.(struct {
 		Registry *tool.Registry
 		Runner   *tool.Runner
+		Close    func()
 	})
 
 	result, err := tools.Runner.Execute(ctx, "custom.echo", json.RawMessage(`{}`), "")
@@ -6,9 +6,11 @@ import (
 
 	"github.com/harunnryd/heike/internal/concurrency"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/ingress"
 	"github.com/harunnryd/heike/internal/orchestrator"
 	"github.com/harunnryd/heike/internal/store"
+	"github.com/harunnryd/heike/internal/webhook"
 	"github.com/harunnryd/heike/internal/worker"
 )
 
@@ -67,6 +69,11 @@ func (wi *WorkersInitializer) Initialize(ctx context.Context, cfg *config.Config
 		return nil, fmt.Errorf("parse worker shutdown timeout: %w", err)
 	}
 
+	webhooks, err := newWebhookNotifier(cfg.Server.Callback, cfg.HTTP, wi.storeWorker)
+	if err != nil {
+		return nil, err
+	}
+
 	if wi.ingress == nil {
 		wi.ingress = ingress.NewIngress(
 			interactiveQueueSize,
@@ -93,7 +100,7 @@ func (wi *WorkersInitializer) Initialize(ctx context.Context, cfg *config.Config
 		wi.storeWorker,
 		wi.orchestrator,
 		locks,
-		worker.RuntimeConfig{ShutdownTimeout: workerShutdownTimeout},
+		worker.RuntimeConfig{ShutdownTimeout: workerShutdownTimeout, OnFinish: webhooks.Finished},
 	)
 
 	backgroundWorker := worker.NewWorker(
@@ -102,7 +109,7 @@ func (wi *WorkersInitializer) Initialize(ctx context.Context, cfg *config.Config
 		wi.storeWorker,
 		wi.orchestrator,
 		locks,
-		worker.RuntimeConfig{ShutdownTimeout: workerShutdownTimeout},
+		worker.RuntimeConfig{ShutdownTimeout: workerShutdownTimeout, OnFinish: webhooks.Finished},
 	)
 
 	return struct {
@@ -110,10 +117,43 @@ func (wi *WorkersInitializer) Initialize(ctx context.Context, cfg *config.Config
 		InteractiveWorker *worker.Worker
 		BackgroundWorker  *worker.Worker
 		Locks             *concurrency.SimpleSessionLockManager
+		Webhooks          *webhook.Notifier
 	}{
 		Ingress:           wi.ingress,
 		InteractiveWorker: interactiveWorker,
 		BackgroundWorker:  backgroundWorker,
 		Locks:             locks,
+		Webhooks:          webhooks,
 	}, nil
 }
+
+func newWebhookNotifier(cfg config.ServerCallbackConfig, httpCfg config.HTTPConfig, storeWorker *store.Worker) (*webhook.Notifier, error) {
+	timeout, err := config.DurationOrDefault(cfg.Timeout, config.DefaultServerCallbackTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse server callback timeout: %w", err)
+	}
+	backoff, err := config.DurationOrDefault(cfg.Backoff, config.DefaultServerCallbackBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("parse server callback backoff: %w", err)
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = config.DefaultServerCallbackMaxAttempts
+	}
+	httpClients, err := httpclient.ForConfig(httpCfg)
+	if err != nil {
+		return nil, fmt.Errorf("create http clients: %w", err)
+	}
+	var transcripts webhook.TranscriptReader
+	if storeWorker != nil {
+		transcripts = storeWorker
+	}
+	return webhook.NewNotifier(webhook.Config{
+		Secret:       cfg.Secret,
+		Timeout:      timeout,
+		MaxAttempts:  maxAttempts,
+		Backoff:      backoff,
+		AllowedHosts: cfg.AllowedHosts,
+		HTTPClients:  httpClients,
+	}, transcripts), nil
+}
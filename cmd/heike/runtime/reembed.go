@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/daemon"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	reembedRunning   = "running"
+	reembedCompleted = "completed"
+	reembedFailed    = "failed"
+)
+
+type collectionReembedder interface {
+	ReembedCollection(ctx context.Context, collection string, progress func(done, total int)) (int, error)
+}
+
+// reembedJobs tracks background re-embeds, at most one running per
+// collection. Finished jobs are kept until the collection is re-embedded
+// again.
+type reembedJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*daemon.RuntimeReembed
+	now  func() time.Time
+}
+
+func newReembedJobs() *reembedJobs {
+	return &reembedJobs{jobs: make(map[string]*daemon.RuntimeReembed), now: time.Now}
+}
+
+func (j *reembedJobs) start(ctx context.Context, collection string, reembedder collectionReembedder) (daemon.RuntimeReembed, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if job, ok := j.jobs[collection]; ok && job.Status == reembedRunning {
+		return *job, fmt.Errorf("collection %s is already being re-embedded: %w", collection, heikeErrors.ErrConflict)
+	}
+	job := &daemon.RuntimeReembed{Collection: collection, Status: reembedRunning, StartedAt: j.now()}
+	j.jobs[collection] = job
+
+	go func() {
+		_, err := reembedder.ReembedCollection(ctx, collection, func(done, total int) {
+			j.mu.Lock()
+			job.Done, job.Total = done, total
+			j.mu.Unlock()
+		})
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		completedAt := j.now()
+		job.CompletedAt = &completedAt
+		if err != nil {
+			slog.Error("Vector re-embed failed", "collection", collection, "error", err)
+			job.Status = reembedFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = reembedCompleted
+	}()
+	return *job, nil
+}
+
+func (j *reembedJobs) get(collection string) (daemon.RuntimeReembed, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[collection]
+	if !ok {
+		return daemon.RuntimeReembed{}, heikeErrors.NotFound(fmt.Sprintf("no re-embed for collection %s", collection))
+	}
+	return *job, nil
+}
@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+type fakeReembedder struct {
+	release chan struct{}
+	err     error
+}
+
+func (f *fakeReembedder) ReembedCollection(ctx context.Context, collection string, progress func(done, total int)) (int, error) {
+	progress(0, 2)
+	<-f.release
+	progress(2, 2)
+	return 2, f.err
+}
+
+func waitReembed(t *testing.T, jobs *reembedJobs, collection, status string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if job, err := jobs.get(collection); err == nil && job.Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	job, _ := jobs.get(collection)
+	t.Fatalf("job status = %+v, want %s", job, status)
+}
+
+func TestReembedJobs_OneRunningPerCollection(t *testing.T) {
+	jobs := newReembedJobs()
+	reembedder := &fakeReembedder{release: make(chan struct{})}
+
+	job, err := jobs.start(context.Background(), "memories", reembedder)
+	if err != nil || job.Status != reembedRunning {
+		t.Fatalf("start = %+v, %v", job, err)
+	}
+	if _, err := jobs.start(context.Background(), "memories", reembedder); !errors.Is(err, heikeErrors.ErrConflict) {
+		t.Fatalf("second start error = %v, want conflict", err)
+	}
+
+	close(reembedder.release)
+	waitReembed(t, jobs, "memories", reembedCompleted)
+	job, _ = jobs.get("memories")
+	if job.Done != 2 || job.Total != 2 || job.CompletedAt == nil {
+		t.Fatalf("completed job = %+v", job)
+	}
+
+	if _, err := jobs.get("docs"); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("unknown job error = %v, want not found", err)
+	}
+}
+
+func TestReembedJobs_RecordsFailure(t *testing.T) {
+	jobs := newReembedJobs()
+	reembedder := &fakeReembedder{release: make(chan struct{}), err: errors.New("embedding model unavailable")}
+	close(reembedder.release)
+
+	if _, err := jobs.start(context.Background(), "docs", reembedder); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitReembed(t, jobs, "docs", reembedFailed)
+	if job, _ := jobs.get("docs"); job.Error != "embedding model unavailable" {
+		t.Fatalf("failed job = %+v", job)
+	}
+}
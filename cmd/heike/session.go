@@ -1,13 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/harunnryd/heike/cmd/heike/runtime"
 
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
 	"github.com/harunnryd/heike/internal/store"
 
 	"github.com/gofrs/flock"
@@ -17,7 +26,7 @@ import (
 var sessionCmd = &cobra.Command{
 	Use:   "session",
 	Short: "Manage sessions",
-	Long:  `List and reset interactive sessions in the workspace.`,
+	Long:  `List, reset, merge, trace and report on interactive sessions in the workspace.`,
 }
 
 var sessionLsCmd = &cobra.Command{
@@ -108,14 +117,245 @@ var sessionResetCmd = &cobra.Command{
 			return fmt.Errorf("failed to delete transcript: %w", err)
 		}
 
+		wireDir, err := store.GetWireLogDir(workspaceID, workspaceRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to get wire log directory: %w", err)
+		}
+		if wirePath, err := model.WireLogPath(wireDir, sessionID); err == nil {
+			if err := os.Remove(wirePath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to delete wire log: %w", err)
+			}
+		}
+
 		fmt.Printf("✓ Session '%s' reset successfully.\n", sessionID)
 		return nil
 	},
 }
 
+var sessionMergeCmd = &cobra.Command{
+	Use:   "merge [target] [source]",
+	Short: "Merge one session into another",
+	Long: `Append the source session's transcript to the target on a running daemon, with
+provenance markers, merge their metadata, move the source's context and memory
+vectors to the target, and archive the source.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, source := strings.TrimSpace(args[0]), strings.TrimSpace(args[1])
+		body, err := json.Marshal(map[string]string{"source": source})
+		if err != nil {
+			return err
+		}
+		var merged daemon.RuntimeSessionMerge
+		mergeURL := daemonURL(cmd) + "/api/v1/sessions/" + url.PathEscape(target) + "/merge"
+		if err := daemonRequest(http.MethodPost, mergeURL, bytes.NewReader(body), &merged); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Merged '%s' into '%s': %d events, %d vectors moved. '%s' is archived.\n", source, target, merged.Events, merged.Vectors, source)
+		return nil
+	},
+}
+
+var sessionTraceCmd = &cobra.Command{
+	Use:   "trace [id]",
+	Short: "Replay a session's model traffic",
+	Long: `Print the prompts, tool calls and provider responses of a session in the
+order they were exchanged. Requires models.wire_log.enabled.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := readSessionWireLog(cmd, args[0])
+		if err != nil {
+			return err
+		}
+
+		full, _ := cmd.Flags().GetBool("full")
+		writeWireTrace(cmd.OutOrStdout(), entries, full)
+		return nil
+	},
+}
+
+var sessionReportCmd = &cobra.Command{
+	Use:   "report [id]",
+	Short: "Show where a session's goals spent time and money",
+	Long: `Print the execution report of each goal in a session: turns, tool calls and
+their durations, tokens and cost per model, and retries. Requires
+orchestrator.execution_report.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lines, err := readSessionTranscript(cmd, args[0])
+		if err != nil {
+			return err
+		}
+		reports := session.ExecutionReports(lines)
+		if len(reports) == 0 {
+			return fmt.Errorf("no execution reports for session %s (enable orchestrator.execution_report and run a goal)", args[0])
+		}
+		if last, _ := cmd.Flags().GetInt("last"); last > 0 && last < len(reports) {
+			reports = reports[len(reports)-last:]
+		}
+		writeExecutionReports(cmd.OutOrStdout(), reports)
+		return nil
+	},
+}
+
+// readSessionTranscript reads the transcript of a session in the target
+// workspace.
+func readSessionTranscript(cmd *cobra.Command, sessionID string) ([]string, error) {
+	workspaceID := runtime.ResolveWorkspaceID(cmd)
+	workspaceRootPath := ""
+	if cfg != nil {
+		workspaceRootPath = cfg.Daemon.WorkspacePath
+	}
+
+	sessionsDir, err := store.GetSessionsDir(workspaceID, workspaceRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions directory: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(sessionsDir, sessionID+".jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %s not found", sessionID)
+		}
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n"), nil
+}
+
+func writeExecutionReports(w io.Writer, reports []session.ExecutionReport) {
+	for _, r := range reports {
+		fmt.Fprintf(w, "=== %s %s (%s) %s\n", r.StartedAt.Local().Format(time.RFC3339), r.Status, formatMS(r.DurationMS), traceText(r.Goal, false))
+		fmt.Fprintf(w, "turns %d, retries %d, tokens %d in / %d out, cost $%.4f\n", r.Turns, r.Retries, r.PromptTokens, r.CompletionTokens, r.CostUSD)
+
+		// Tools are summed by name, in order of first use.
+		type toolTotal struct {
+			calls, failed int
+			durationMS    int64
+		}
+		var names []string
+		totals := make(map[string]*toolTotal)
+		for _, call := range r.Tools {
+			total, ok := totals[call.Name]
+			if !ok {
+				total = &toolTotal{}
+				totals[call.Name] = total
+				names = append(names, call.Name)
+			}
+			total.calls++
+			total.durationMS += call.DurationMS
+			if call.Error != "" {
+				total.failed++
+			}
+		}
+		for _, name := range names {
+			total := totals[name]
+			fmt.Fprintf(w, "  tool  %-20s %3dx %8s", name, total.calls, formatMS(total.durationMS))
+			if total.failed > 0 {
+				fmt.Fprintf(w, "  %d failed", total.failed)
+			}
+			fmt.Fprintln(w)
+		}
+		for _, m := range r.Models {
+			fmt.Fprintf(w, "  model %-20s %3dx %8d in / %d out  $%.4f\n", m.Model, m.Completions, m.PromptTokens, m.CompletionTokens, m.CostUSD)
+		}
+	}
+	fmt.Fprintf(w, "\nTotal: %d goal(s)\n", len(reports))
+}
+
+func formatMS(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).String()
+}
+
+// readSessionWireLog reads the wire log of a session in the target workspace.
+func readSessionWireLog(cmd *cobra.Command, sessionID string) ([]model.WireEntry, error) {
+	workspaceID := runtime.ResolveWorkspaceID(cmd)
+	workspaceRootPath := ""
+	if cfg != nil {
+		workspaceRootPath = cfg.Daemon.WorkspacePath
+	}
+
+	wireDir, err := store.GetWireLogDir(workspaceID, workspaceRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wire log directory: %w", err)
+	}
+	entries, err := model.ReadWireLog(wireDir, sessionID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no wire log for session %s (enable models.wire_log.enabled and run the session again)", sessionID)
+		}
+		return nil, fmt.Errorf("failed to read wire log: %w", err)
+	}
+	return entries, nil
+}
+
+// traceTextLimit caps printed message content unless --full is given.
+const traceTextLimit = 400
+
+func writeWireTrace(w io.Writer, entries []model.WireEntry, full bool) {
+	for i, entry := range entries {
+		kind := "completion"
+		if entry.Stream {
+			kind = "stream"
+		}
+		fmt.Fprintf(w, "=== #%d %s %s %s (%dms)", i+1, entry.Time.Local().Format(time.RFC3339), kind, entry.Model, entry.DurationMS)
+		if entry.TraceID != "" {
+			fmt.Fprintf(w, " trace=%s", entry.TraceID)
+		}
+		fmt.Fprintln(w)
+
+		if len(entry.Request.Tools) > 0 {
+			names := make([]string, 0, len(entry.Request.Tools))
+			for _, t := range entry.Request.Tools {
+				names = append(names, t.Name)
+			}
+			fmt.Fprintf(w, "tools: %s\n", strings.Join(names, ", "))
+		}
+		for _, msg := range entry.Request.Messages {
+			label := msg.Role
+			if msg.ToolCallID != "" {
+				label += " (" + msg.ToolCallID + ")"
+			}
+			fmt.Fprintf(w, "> %s: %s\n", label, traceText(msg.Content, full))
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(w, "  > call %s %s %s\n", tc.ID, tc.Name, traceText(tc.Input, full))
+			}
+		}
+
+		if entry.Error != "" {
+			fmt.Fprintf(w, "< error: %s\n", entry.Error)
+		}
+		if resp := entry.Response; resp != nil {
+			if resp.Content != "" || len(resp.ToolCalls) == 0 {
+				fmt.Fprintf(w, "< assistant: %s\n", traceText(resp.Content, full))
+			}
+			for _, tc := range resp.ToolCalls {
+				fmt.Fprintf(w, "< call %s %s %s\n", tc.ID, tc.Name, traceText(tc.Input, full))
+			}
+			if resp.Usage != nil {
+				fmt.Fprintf(w, "< usage: %d prompt, %d completion tokens\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Total: %d exchange(s)\n", len(entries))
+}
+
+func traceText(text string, full bool) string {
+	if !full {
+		if runes := []rune(text); len(runes) > traceTextLimit {
+			text = string(runes[:traceTextLimit]) + fmt.Sprintf("... (%d more chars, use --full)", len(runes)-traceTextLimit)
+		}
+	}
+	return strings.ReplaceAll(text, "\n", "\n    ")
+}
+
 func init() {
 	sessionCmd.AddCommand(sessionLsCmd)
 	sessionCmd.AddCommand(sessionResetCmd)
+	sessionMergeCmd.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+	sessionCmd.AddCommand(sessionMergeCmd)
+	sessionTraceCmd.Flags().Bool("full", false, "Print message contents without truncation")
+	sessionCmd.AddCommand(sessionTraceCmd)
+	sessionReportCmd.Flags().Int("last", 0, "Show only the last N goals")
+	sessionCmd.AddCommand(sessionReportCmd)
 	sessionCmd.PersistentFlags().StringP("workspace", "w", "", "Target workspace ID")
 	rootCmd.AddCommand(sessionCmd)
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -93,3 +95,102 @@ func TestSessionResetCmd(t *testing.T) {
 		t.Error("Session file should be deleted after reset")
 	}
 }
+
+func TestSessionTraceCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	home := os.Getenv("HOME")
+	defer func() {
+		if home != "" {
+			os.Setenv("HOME", home)
+		}
+	}()
+	os.Setenv("HOME", tmpDir)
+
+	wireDir := filepath.Join(tmpDir, ".heike", "workspaces", "test-workspace-"+t.Name(), "wire")
+	if err := os.MkdirAll(wireDir, 0755); err != nil {
+		t.Fatalf("Failed to create wire dir: %v", err)
+	}
+	entry := `{"time":"2026-03-01T12:00:00Z","session_id":"test-session","model":"gpt","request":{"model":"","messages":[{"role":"user","content":"what time is it?"}],"tools":[{"name":"time","description":""}]},"response":{"content":"","tool_calls":[{"id":"c1","name":"time","input":"{}"}]},"duration_ms":12}` + "\n" +
+		`{"time":"2026-03-01T12:00:01Z","session_id":"test-session","model":"gpt","request":{"model":"","messages":[{"role":"tool","content":"12:00","tool_call_id":"c1"}]},"response":{"content":"It is noon."},"duration_ms":8}` + "\n"
+	if err := os.WriteFile(filepath.Join(wireDir, "test-session.jsonl"), []byte(entry), 0644); err != nil {
+		t.Fatalf("Failed to write wire log: %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
+	cmd.Flags().Bool("full", false, "")
+	_ = cmd.Flags().Set("workspace", "test-workspace-"+t.Name())
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := sessionTraceCmd.RunE(cmd, []string{"test-session"}); err != nil {
+		t.Fatalf("Session trace failed: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"tools: time", "> user: what time is it?", "< call c1 time {}", "> tool (c1): 12:00", "< assistant: It is noon.", "Total: 2 exchange(s)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace output missing %q:\n%s", want, got)
+		}
+	}
+
+	if err := sessionTraceCmd.RunE(cmd, []string{"other-session"}); err == nil {
+		t.Error("expected error for a session without a wire log")
+	}
+}
+
+func TestSessionReportCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	home := os.Getenv("HOME")
+	defer func() {
+		if home != "" {
+			os.Setenv("HOME", home)
+		}
+	}()
+	os.Setenv("HOME", tmpDir)
+
+	sessionsDir := filepath.Join(tmpDir, ".heike", "workspaces", "test-workspace-"+t.Name(), "sessions")
+	if err := os.MkdirAll(sessionsDir, 0755); err != nil {
+		t.Fatalf("Failed to create sessions dir: %v", err)
+	}
+	report := func(goal string) string {
+		return `{"id":"r-` + goal + `","type":"execution_report","role":"system","content":"` + goal + `","metadata":{"report":{"goal":"` + goal + `","status":"completed","started_at":"2026-03-01T12:00:00Z","duration_ms":2500,"turns":3,"retries":1,` +
+			`"tools":[{"name":"web_fetch","duration_ms":400},{"name":"web_fetch","duration_ms":600,"error":"timeout"}],` +
+			`"models":[{"model":"gpt","completions":3,"prompt_tokens":900,"completion_tokens":120,"cost_usd":0.0123}],` +
+			`"prompt_tokens":900,"completion_tokens":120,"cost_usd":0.0123}}}` + "\n"
+	}
+	transcript := `{"id":"u1","type":"user","role":"user","content":"first"}` + "\n" + report("first") + report("second")
+	if err := os.WriteFile(filepath.Join(sessionsDir, "test-session.jsonl"), []byte(transcript), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sessionsDir, "quiet-session.jsonl"), []byte(`{"id":"u1","type":"user","role":"user","content":"hi"}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write transcript: %v", err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
+	cmd.Flags().Int("last", 0, "")
+	_ = cmd.Flags().Set("workspace", "test-workspace-"+t.Name())
+	_ = cmd.Flags().Set("last", "1")
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := sessionReportCmd.RunE(cmd, []string{"test-session"}); err != nil {
+		t.Fatalf("Session report failed: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{"completed (2.5s) second", "turns 3, retries 1, tokens 900 in / 120 out, cost $0.0123", "web_fetch", "2x", "1 failed", "model gpt", "Total: 1 goal(s)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "first") {
+		t.Errorf("--last 1 should only show the latest goal:\n%s", got)
+	}
+
+	if err := sessionReportCmd.RunE(cmd, []string{"quiet-session"}); err == nil {
+		t.Error("expected error for a session without execution reports")
+	}
+	if err := sessionReportCmd.RunE(cmd, []string{"missing-session"}); err == nil {
+		t.Error("expected error for a missing session")
+	}
+}
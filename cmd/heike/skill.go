@@ -112,7 +112,7 @@ var skillUninstallCmd = &cobra.Command{
 		}
 		skillPath := filepath.Join(projectSkillsRoot, skillName)
 		if _, err := os.Stat(skillPath); os.IsNotExist(err) {
-			return fmt.Errorf("skill not found: %s", skillName)
+			return notFoundError(fmt.Errorf("skill not found: %s", skillName))
 		}
 
 		if err := os.RemoveAll(skillPath); err != nil {
@@ -132,7 +132,7 @@ var skillSearchCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.ToLower(strings.TrimSpace(args[0]))
 		if query == "" {
-			return fmt.Errorf("query cannot be empty")
+			return usageError(fmt.Errorf("query cannot be empty"))
 		}
 
 		wd, err := os.Getwd()
@@ -187,7 +187,7 @@ var skillShowCmd = &cobra.Command{
 		}
 		skillPath, err := resolveRuntimeSkillPath(skillName, skillSources)
 		if err != nil {
-			return fmt.Errorf("skill not found: %s", skillName)
+			return notFoundError(fmt.Errorf("skill not found: %s", skillName))
 		}
 
 		fmt.Printf("=== Skill Details: %s ===\n", skillName)
@@ -245,7 +245,7 @@ var skillTestCmd = &cobra.Command{
 		}
 		skillPath, err := resolveRuntimeSkillPath(skillName, skillSources)
 		if err != nil {
-			return fmt.Errorf("skill not found: %s", skillName)
+			return notFoundError(fmt.Errorf("skill not found: %s", skillName))
 		}
 
 		fmt.Printf("Testing skill at: %s\n", skillPath)
@@ -269,7 +269,7 @@ var skillTestCmd = &cobra.Command{
 
 		loadedSkill, err := skillRegistry.Get(skillName)
 		if err != nil {
-			return fmt.Errorf("skill '%s' not found: %w", skillName, err)
+			return notFoundError(fmt.Errorf("skill '%s' not found: %w", skillName, err))
 		}
 		if loadedSkill == nil || loadedSkill.Name != skillName {
 			return fmt.Errorf("skill name mismatch: expected %s, got %s", skillName, loadedSkill.Name)
@@ -324,6 +324,45 @@ var skillLsCmd = &cobra.Command{
 	},
 }
 
+var skillLibraryCmd = &cobra.Command{
+	Use:   "library",
+	Short: "Show the bundled skill library version and changes",
+	Long:  `Show the bundled skill library embedded in this binary, the version in use (see discovery.bundled_version), and the skills that differ between them or changed since the last run.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		status, err := skillmodel.InstallLibrary(runtimeBundledVersion())
+		if status == nil {
+			return fmt.Errorf("install bundled skill library: %w", err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "Embedded: %s\n", status.Embedded)
+		fmt.Fprintf(out, "In use:   %s (%s)\n", status.Version, status.Path)
+		if status.Previous != "" {
+			fmt.Fprintf(out, "\nChanged from %s:\n", status.Previous)
+			printLibraryChanges(out, status.Changes)
+		}
+		if status.Version != status.Embedded {
+			fmt.Fprintf(out, "\nUnpinning would change:\n")
+			printLibraryChanges(out, status.Pending)
+		}
+		return nil
+	},
+}
+
+func printLibraryChanges(out io.Writer, changes []skillmodel.LibraryChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "  (no skill changes)")
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintf(out, "  %-8s %s\n", change.Change, change.Name)
+	}
+}
+
 func resolveSkillSource(rawPath string) (string, string, error) {
 	clean := filepath.Clean(strings.TrimSpace(rawPath))
 	if clean == "" {
@@ -427,6 +466,7 @@ func init() {
 	skillCmd.AddCommand(skillShowCmd)
 	skillCmd.AddCommand(skillTestCmd)
 	skillCmd.AddCommand(skillLsCmd)
+	skillCmd.AddCommand(skillLibraryCmd)
 	rootCmd.AddCommand(skillCmd)
 }
 
@@ -451,13 +491,24 @@ func runtimeProjectPath() string {
 	return cfg.Discovery.ProjectPath
 }
 
+func runtimeBundledVersion() string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Discovery.BundledVersion
+}
+
 func resolveRuntimeSkillSources(workspacePath string) ([]discovery.SourceDescriptor, error) {
+	if _, err := skillmodel.InstallLibrary(runtimeBundledVersion()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 	return discovery.ResolveSkillSources(discovery.ResolveOptions{
 		Order:             runtimeSkillDiscoveryOrder(),
 		WorkspaceID:       config.DefaultWorkspaceID,
 		WorkspaceRootPath: runtimeWorkspaceRootPath(),
 		WorkspacePath:     workspacePath,
 		ProjectPath:       runtimeProjectPath(),
+		BundledVersion:    runtimeBundledVersion(),
 	})
 }
 
@@ -489,7 +540,7 @@ func resolveRuntimeSkillPath(skillName string, sources []discovery.SourceDescrip
 		}
 	}
 	if resolved == "" {
-		return "", fmt.Errorf("skill not found: %s", name)
+		return "", notFoundError(fmt.Errorf("skill not found: %s", name))
 	}
 	return resolved, nil
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/harunnryd/heike/cmd/heike/runtime"
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/store"
+
+	"github.com/gofrs/flock"
+	"github.com/spf13/cobra"
+)
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Inspect the workspace store",
+	Long:  `Inspect and maintain the workspace store.`,
+}
+
+var storeStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show disk usage and store worker statistics",
+	Long:  `Query the daemon for workspace disk usage by area, store inbox depth, and per-operation latencies.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		if strings.TrimSpace(addr) == "" {
+			port := 0
+			if cfg != nil {
+				port = cfg.Server.Port
+			}
+			addr = fmt.Sprintf("http://127.0.0.1:%d", port)
+		}
+
+		stats, err := fetchStoreStats(strings.TrimRight(addr, "/"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Workspace: %s\n", stats.WorkspaceID)
+		fmt.Printf("Inbox:     %d/%d\n", stats.InboxDepth, stats.InboxCapacity)
+		fmt.Printf("Streams:   %d\n", stats.TranscriptWatches)
+		fmt.Printf("Pending:   %d vector upserts\n\n", stats.PendingVectorUpserts)
+		for _, warning := range stats.Warnings {
+			fmt.Fprintf(os.Stderr, "WARN store: %s\n", warning)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "AREA\tSIZE")
+		fmt.Fprintf(w, "sessions\t%s\n", formatBytes(stats.Disk.Sessions))
+		fmt.Fprintf(w, "rotated backups\t%s\n", formatBytes(stats.Disk.RotatedBackups))
+		fmt.Fprintf(w, "vectors\t%s\n", formatBytes(stats.Disk.Vectors))
+		fmt.Fprintf(w, "sandbox\t%s\n", formatBytes(stats.Disk.Sandbox))
+		fmt.Fprintf(w, "other\t%s\n", formatBytes(stats.Disk.Other))
+		fmt.Fprintf(w, "total\t%s\n", formatBytes(stats.Disk.Total))
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+
+		if len(stats.Ops) == 0 {
+			return nil
+		}
+		names := make([]string, 0, len(stats.Ops))
+		for name := range stats.Ops {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "OPERATION\tCOUNT\tAVG MS\tMAX MS")
+		for _, name := range names {
+			op := stats.Ops[name]
+			fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f\n", name, op.Count, op.AvgMS, op.MaxMS)
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	},
+}
+
+var storeMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade workspace data to the current schema version",
+	Long: `Apply pending workspace data migrations. The daemon runs the same migrations on
+startup; use this to preview them with --dry-run or to migrate a stopped workspace.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		noBackup, _ := cmd.Flags().GetBool("no-backup")
+		workspaceID := runtime.ResolveWorkspaceID(cmd)
+		workspaceRootPath := ""
+		if cfg != nil {
+			workspaceRootPath = cfg.Daemon.WorkspacePath
+		}
+
+		basePath, err := store.GetWorkspacePath(workspaceID, workspaceRootPath)
+		if err != nil {
+			return fmt.Errorf("failed to get workspace path: %w", err)
+		}
+		if _, err := os.Stat(basePath); os.IsNotExist(err) {
+			return notFoundError(fmt.Errorf("workspace %s not found at %s", workspaceID, basePath))
+		}
+
+		if !dryRun {
+			lockPath, err := store.GetLockPath(workspaceID, workspaceRootPath)
+			if err != nil {
+				return fmt.Errorf("failed to get lock path: %w", err)
+			}
+			fileLock := flock.New(lockPath)
+			locked, err := fileLock.TryLock()
+			if err != nil {
+				return fmt.Errorf("failed to acquire lock: %w", err)
+			}
+			if !locked {
+				return fmt.Errorf("workspace is locked by another Heike instance; stop the daemon first")
+			}
+			defer fileLock.Unlock()
+		}
+
+		report, err := store.MigrateWorkspace(basePath, store.MigrationOptions{DryRun: dryRun, Backup: !noBackup})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Workspace: %s (schema v%d, current v%d)\n", workspaceID, report.FromVersion, report.ToVersion)
+		if len(report.Pending) == 0 {
+			fmt.Println("✓ Up to date")
+			return nil
+		}
+		if dryRun {
+			fmt.Println("Pending migrations:")
+			for _, name := range report.Pending {
+				fmt.Printf("- %s\n", name)
+			}
+			return nil
+		}
+		if report.BackupPath != "" {
+			fmt.Printf("Backup: %s\n", report.BackupPath)
+		}
+		for _, name := range report.Applied {
+			fmt.Printf("✓ Applied %s\n", name)
+		}
+		return nil
+	},
+}
+
+func fetchStoreStats(baseURL string) (daemon.RuntimeStoreStats, error) {
+	req, err := http.NewRequest(http.MethodGet, baseURL+"/api/v1/store/stats", nil)
+	if err != nil {
+		return daemon.RuntimeStoreStats{}, err
+	}
+	setDaemonAuth(req)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return daemon.RuntimeStoreStats{}, daemonUnreachableError(fmt.Errorf("failed to reach daemon at %s: %w", baseURL, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return daemon.RuntimeStoreStats{}, fmt.Errorf("daemon store stats returned status %d", resp.StatusCode)
+	}
+
+	var stats daemon.RuntimeStoreStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return daemon.RuntimeStoreStats{}, fmt.Errorf("failed to decode store stats: %w", err)
+	}
+	return stats, nil
+}
+
+// formatBytes renders n with a binary unit, e.g. 1.5 MiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	storeStatsCmd.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+	storeMigrateCmd.Flags().Bool("dry-run", false, "List pending migrations without changing data")
+	storeMigrateCmd.Flags().Bool("no-backup", false, "Skip the workspace backup taken before migrating")
+	storeMigrateCmd.Flags().StringP("workspace", "w", "", "Target workspace ID")
+	storeCmd.AddCommand(storeStatsCmd)
+	storeCmd.AddCommand(storeMigrateCmd)
+	rootCmd.AddCommand(storeCmd)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/daemon"
+
+	"github.com/spf13/cobra"
+)
+
+var vectorsCmd = &cobra.Command{
+	Use:   "vectors",
+	Short: "Maintain vector collections",
+	Long:  `Maintain the vector collections behind memory, knowledge sync, and session context.`,
+}
+
+var vectorsReembedCmd = &cobra.Command{
+	Use:   "reembed",
+	Short: "Re-embed a collection with the current embedding model",
+	Long: `Re-embed every document of a collection with models.embedding on a running daemon.
+Run this after changing the embedding model: vectors of the old dimension cannot be searched
+or extended. The daemon re-embeds in the background and swaps the collection in when done.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		collection, _ := cmd.Flags().GetString("collection")
+		collection = strings.TrimSpace(collection)
+		if collection == "" {
+			return usageError(fmt.Errorf("--collection is required"))
+		}
+		wait, _ := cmd.Flags().GetBool("wait")
+		baseURL := daemonURL(cmd)
+
+		body, err := json.Marshal(map[string]string{"collection": collection})
+		if err != nil {
+			return err
+		}
+		var job daemon.RuntimeReembed
+		if err := daemonRequest(http.MethodPost, baseURL+"/api/v1/vectors/reembed", bytes.NewReader(body), &job); err != nil {
+			return err
+		}
+		fmt.Printf("Re-embedding %s in the background\n", collection)
+		if !wait {
+			return nil
+		}
+
+		statusURL := baseURL + "/api/v1/vectors/reembed?collection=" + url.QueryEscape(collection)
+		for job.Status == "running" {
+			time.Sleep(2 * time.Second)
+			if err := daemonRequest(http.MethodGet, statusURL, nil, &job); err != nil {
+				return err
+			}
+			fmt.Printf("%d/%d documents\n", job.Done, job.Total)
+		}
+		if job.Status == "failed" {
+			return fmt.Errorf("re-embed of %s failed: %s", collection, job.Error)
+		}
+		fmt.Printf("✓ Re-embedded %d documents in %s\n", job.Total, collection)
+		return nil
+	},
+}
+
+var vectorsBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Embed past session transcripts into memory",
+	Long: `Chunk and embed the user and assistant messages of existing session transcripts into
+the memory collection on a running daemon, so conversations from before memory was in use
+can be recalled. Chunks are keyed by session, so running it again replaces them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessions, _ := cmd.Flags().GetStringSlice("session")
+		source, _ := cmd.Flags().GetString("source")
+		since, _ := cmd.Flags().GetString("since")
+		wait, _ := cmd.Flags().GetBool("wait")
+		baseURL := daemonURL(cmd)
+
+		req := daemon.RuntimeMemoryBackfillRequest{Sessions: sessions, Source: strings.TrimSpace(source)}
+		if since = strings.TrimSpace(since); since != "" {
+			sinceTime, err := parseBackfillSince(since, time.Now())
+			if err != nil {
+				return usageError(err)
+			}
+			req.Since = &sinceTime
+		}
+		body, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		var job daemon.RuntimeMemoryBackfill
+		if err := daemonRequest(http.MethodPost, baseURL+"/api/v1/vectors/backfill", bytes.NewReader(body), &job); err != nil {
+			return err
+		}
+		fmt.Println("Backfilling memory from transcripts in the background")
+		if !wait {
+			return nil
+		}
+
+		for job.Status == "running" {
+			time.Sleep(2 * time.Second)
+			if err := daemonRequest(http.MethodGet, baseURL+"/api/v1/vectors/backfill", nil, &job); err != nil {
+				return err
+			}
+			fmt.Printf("%d/%d sessions\n", job.Done, job.Total)
+		}
+		if job.Status == "failed" {
+			return fmt.Errorf("memory backfill failed: %s", job.Error)
+		}
+		fmt.Printf("✓ Embedded %d chunks from %d sessions\n", job.Chunks, job.Sessions)
+		return nil
+	},
+}
+
+// parseBackfillSince reads --since as a duration before now (720h) or an
+// RFC 3339 time.
+func parseBackfillSince(raw string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(raw); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: want a duration such as 720h or an RFC 3339 time", raw)
+	}
+	return t, nil
+}
+
+func init() {
+	vectorsReembedCmd.Flags().String("collection", "", "Collection to re-embed, e.g. memories")
+	vectorsReembedCmd.Flags().Bool("wait", false, "Wait for the re-embed to finish, printing progress")
+	vectorsReembedCmd.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+	vectorsCmd.AddCommand(vectorsReembedCmd)
+	vectorsBackfillCmd.Flags().StringSlice("session", nil, "Session to backfill (repeatable; default all sessions)")
+	vectorsBackfillCmd.Flags().String("source", "", "Only backfill sessions from this source adapter, e.g. slack")
+	vectorsBackfillCmd.Flags().String("since", "", "Skip messages older than this: a duration such as 720h or an RFC 3339 time")
+	vectorsBackfillCmd.Flags().Bool("wait", false, "Wait for the backfill to finish, printing progress")
+	vectorsBackfillCmd.Flags().String("addr", "", "Daemon base URL (default http://127.0.0.1:<server.port>)")
+	vectorsCmd.AddCommand(vectorsBackfillCmd)
+	rootCmd.AddCommand(vectorsCmd)
+}
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"context"
+	"strings"
 )
 
 // EventHandler is a callback function for handling events from adapters
@@ -36,3 +37,26 @@ type OutputAdapter interface {
 	// Health checks if the adapter is healthy and can send messages.
 	Health(ctx context.Context) error
 }
+
+// ApprovalNotifier is implemented by output adapters that can ask for a
+// pending approval interactively, e.g. with buttons.
+type ApprovalNotifier interface {
+	// NotifyApproval asks the session's chat to approve or deny approvalID.
+	NotifyApproval(ctx context.Context, sessionID, approvalID, tool string) error
+}
+
+// splitMessage splits content into chunks of at most maxChars runes,
+// preferring to break at newlines.
+func splitMessage(content string, maxChars int) []string {
+	runes := []rune(content)
+	var chunks []string
+	for len(runes) > maxChars {
+		cut := maxChars
+		if i := strings.LastIndex(string(runes[:cut]), "\n"); i > 0 {
+			cut = len([]rune(string(runes[:cut])[:i])) + 1
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(chunks, string(runes))
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const desktopNotificationMaxChars = 240
+
+// DesktopAdapter pops local desktop notifications using osascript (macOS)
+// or notify-send (Linux). A custom command receives "<title> <body>" args.
+type DesktopAdapter struct {
+	name    string
+	title   string
+	command string
+	goos    string
+	run     func(ctx context.Context, name string, args ...string) error
+}
+
+func NewDesktopAdapter(name, title, command string) *DesktopAdapter {
+	if strings.TrimSpace(name) == "" {
+		name = "desktop"
+	}
+	if strings.TrimSpace(title) == "" {
+		title = "Heike"
+	}
+	return &DesktopAdapter{
+		name:    name,
+		title:   title,
+		command: strings.TrimSpace(command),
+		goos:    runtime.GOOS,
+		run: func(ctx context.Context, name string, args ...string) error {
+			out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+			}
+			return nil
+		},
+	}
+}
+
+func (d *DesktopAdapter) Name() string {
+	return d.name
+}
+
+func (d *DesktopAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	body := strings.TrimSpace(content)
+	if body == "" {
+		return nil
+	}
+	if runes := []rune(body); len(runes) > desktopNotificationMaxChars {
+		body = string(runes[:desktopNotificationMaxChars-3]) + "..."
+	}
+
+	bin, args := d.commandFor(body)
+	if err := d.run(ctx, bin, args...); err != nil {
+		return errors.Wrap(err, "failed to send desktop notification")
+	}
+	slog.Debug("Desktop notification sent", "adapter", d.name, "session", sessionID)
+	return nil
+}
+
+func (d *DesktopAdapter) Health(ctx context.Context) error {
+	bin, _ := d.commandFor("")
+	if _, err := exec.LookPath(bin); err != nil {
+		return errors.Transient("desktop notifier not available: " + bin)
+	}
+	return nil
+}
+
+func (d *DesktopAdapter) commandFor(body string) (string, []string) {
+	if d.command != "" {
+		return d.command, []string{d.title, body}
+	}
+	if d.goos == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(body), appleScriptQuote(d.title))
+		return "osascript", []string{"-e", script}
+	}
+	return "notify-send", []string{d.title, body}
+}
+
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
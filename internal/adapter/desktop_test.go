@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDesktopAdapter_CommandPerPlatform(t *testing.T) {
+	adapter := NewDesktopAdapter("", "", "")
+	if adapter.Name() != "desktop" {
+		t.Fatalf("default name = %q, want desktop", adapter.Name())
+	}
+
+	adapter.goos = "linux"
+	bin, args := adapter.commandFor("done")
+	if bin != "notify-send" || len(args) != 2 || args[0] != "Heike" || args[1] != "done" {
+		t.Fatalf("linux command = %s %v", bin, args)
+	}
+
+	adapter.goos = "darwin"
+	bin, args = adapter.commandFor(`say "hi"`)
+	if bin != "osascript" {
+		t.Fatalf("darwin binary = %q, want osascript", bin)
+	}
+	want := `display notification "say \"hi\"" with title "Heike"`
+	if len(args) != 2 || args[1] != want {
+		t.Fatalf("darwin args = %v, want script %q", args, want)
+	}
+
+	custom := NewDesktopAdapter("desktop", "Ops", "/usr/local/bin/notify")
+	bin, args = custom.commandFor("body")
+	if bin != "/usr/local/bin/notify" || args[0] != "Ops" || args[1] != "body" {
+		t.Fatalf("custom command = %s %v", bin, args)
+	}
+}
+
+func TestDesktopAdapter_SendTruncatesAndSkipsEmpty(t *testing.T) {
+	adapter := NewDesktopAdapter("scheduler", "Heike", "")
+	adapter.goos = "linux"
+
+	var calls [][]string
+	adapter.run = func(ctx context.Context, name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+
+	if err := adapter.Send(context.Background(), "s1", "   "); err != nil {
+		t.Fatalf("send empty: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected no notification for empty content, got %d", len(calls))
+	}
+
+	if err := adapter.Send(context.Background(), "s1", strings.Repeat("x", 500)); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d, want 1", len(calls))
+	}
+	body := calls[0][2]
+	if len([]rune(body)) != desktopNotificationMaxChars || !strings.HasSuffix(body, "...") {
+		t.Fatalf("body was not truncated: len=%d", len(body))
+	}
+}
@@ -0,0 +1,109 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	DigestPeriodDaily  = "daily"
+	DigestPeriodWeekly = "weekly"
+)
+
+// DigestAdapter appends output to a rolling markdown digest instead of
+// emitting a message per run. One file is kept per daily or ISO-week period.
+type DigestAdapter struct {
+	name   string
+	dir    string
+	period string
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+func NewDigestAdapter(name, dir, period string) (*DigestAdapter, error) {
+	if strings.TrimSpace(name) == "" {
+		name = "digest"
+	}
+	if strings.TrimSpace(dir) == "" {
+		return nil, errors.InvalidInput("digest directory is required")
+	}
+	period = strings.ToLower(strings.TrimSpace(period))
+	if period == "" {
+		period = DigestPeriodDaily
+	}
+	if period != DigestPeriodDaily && period != DigestPeriodWeekly {
+		return nil, errors.InvalidInput("unsupported digest period: " + period)
+	}
+	return &DigestAdapter{
+		name:   name,
+		dir:    dir,
+		period: period,
+		now:    time.Now,
+	}, nil
+}
+
+func (d *DigestAdapter) Name() string {
+	return d.name
+}
+
+func (d *DigestAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	body := strings.TrimSpace(content)
+	if body == "" {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create digest directory")
+	}
+
+	path := d.Path(now)
+	var b strings.Builder
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Fprintf(&b, "# Digest %s\n\n", d.periodKey(now))
+	}
+	fmt.Fprintf(&b, "## %s · %s\n\n%s\n\n", now.Format(time.RFC3339), sessionID, body)
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open digest")
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		return errors.Wrap(err, "failed to append digest entry")
+	}
+
+	slog.Debug("Digest entry appended", "adapter", d.name, "session", sessionID, "path", path)
+	return nil
+}
+
+func (d *DigestAdapter) Health(ctx context.Context) error {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return errors.Transient("digest directory not writable: " + d.dir)
+	}
+	return nil
+}
+
+// Path returns the digest file that covers t.
+func (d *DigestAdapter) Path(t time.Time) string {
+	return filepath.Join(d.dir, "digest-"+d.periodKey(t)+".md")
+}
+
+func (d *DigestAdapter) periodKey(t time.Time) string {
+	if d.period == DigestPeriodWeekly {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01-02")
+}
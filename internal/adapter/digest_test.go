@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestAdapter_AppendsToDailyFile(t *testing.T) {
+	dir := t.TempDir()
+	digest, err := NewDigestAdapter("scheduler", dir, "")
+	if err != nil {
+		t.Fatalf("new digest adapter: %v", err)
+	}
+	now := time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC)
+	digest.now = func() time.Time { return now }
+
+	for _, content := range []string{"first run", "  ", "second run"} {
+		if err := digest.Send(context.Background(), "scheduler", content); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	path := filepath.Join(dir, "digest-2026-03-04.md")
+	if digest.Path(now) != path {
+		t.Fatalf("path = %q, want %q", digest.Path(now), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read digest: %v", err)
+	}
+	content := string(data)
+	if strings.Count(content, "# Digest 2026-03-04") != 1 {
+		t.Fatalf("expected a single header, got:\n%s", content)
+	}
+	if strings.Count(content, "## ") != 2 || !strings.Contains(content, "first run") || !strings.Contains(content, "second run") {
+		t.Fatalf("unexpected digest content:\n%s", content)
+	}
+}
+
+func TestDigestAdapter_WeeklyPeriod(t *testing.T) {
+	digest, err := NewDigestAdapter("", t.TempDir(), "weekly")
+	if err != nil {
+		t.Fatalf("new digest adapter: %v", err)
+	}
+	if digest.Name() != "digest" {
+		t.Fatalf("default name = %q, want digest", digest.Name())
+	}
+	got := filepath.Base(digest.Path(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if got != "digest-2026-W01.md" {
+		t.Fatalf("weekly file = %q", got)
+	}
+
+	if _, err := NewDigestAdapter("", t.TempDir(), "hourly"); err == nil {
+		t.Fatal("expected error for unsupported period")
+	}
+}
@@ -0,0 +1,607 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	discordAPIBase    = "https://discord.com/api/v10"
+	discordGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+
+	// discordIntents subscribes to guild messages, direct messages and
+	// message content. Message content is a privileged intent and must be
+	// enabled for the bot in the developer portal.
+	discordIntents = 1<<9 | 1<<12 | 1<<15
+
+	// discordMaxMessageChars is the longest message Discord accepts.
+	discordMaxMessageChars = 2000
+)
+
+// discordDefaultFormat applies where adapters.discord.format is unset.
+var discordDefaultFormat = FormatProfile{Style: FormatMarkdown, MaxChars: discordMaxMessageChars}
+
+// Gateway opcodes.
+const (
+	discordOpDispatch       = 0
+	discordOpHeartbeat      = 1
+	discordOpIdentify       = 2
+	discordOpReconnect      = 7
+	discordOpInvalidSession = 9
+	discordOpHello          = 10
+)
+
+// Interaction and interaction callback types.
+const (
+	discordInteractionCommand    = 2
+	discordInteractionComponent  = 3
+	discordCallbackMessage       = 4
+	discordCallbackUpdateMessage = 7
+	discordMessageFlagEphemeral  = 1 << 6
+)
+
+// custom_id prefixes of the approval buttons.
+const (
+	discordApprovePrefix = "heike_approve:"
+	discordDenyPrefix    = "heike_deny:"
+)
+
+// DiscordOptions restricts where the Discord adapter accepts messages.
+type DiscordOptions struct {
+	// Guilds and Channels are allowlists of IDs; an empty list allows all.
+	// Direct messages have no guild, so a guild allowlist excludes them.
+	Guilds   []string
+	Channels []string
+	// SlashCommands registers Heike's slash commands once connected: in
+	// each allowlisted guild, or globally when Guilds is empty.
+	SlashCommands bool
+	// Format shapes replies; the zero value uses discordDefaultFormat.
+	Format FormatProfile
+}
+
+// DiscordAdapter receives messages and interactions over the Discord gateway
+// and replies through the REST API. A session is a Discord channel ID.
+type DiscordAdapter struct {
+	token        string
+	opts         DiscordOptions
+	eventHandler EventHandler
+	client       *http.Client
+	apiBase      string
+	gatewayURL   string
+
+	mu        sync.Mutex
+	writeMu   sync.Mutex
+	conn      *websocket.Conn
+	cancel    context.CancelFunc
+	connected bool
+}
+
+func NewDiscordAdapter(token string, eventHandler EventHandler, opts DiscordOptions) *DiscordAdapter {
+	if opts.Format.Style == "" {
+		opts.Format = discordDefaultFormat
+	}
+	return &DiscordAdapter{
+		token:        token,
+		opts:         opts,
+		eventHandler: eventHandler,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		apiBase:      discordAPIBase,
+		gatewayURL:   discordGatewayURL,
+	}
+}
+
+func (d *DiscordAdapter) Name() string {
+	return "discord"
+}
+
+// Start runs a gateway connection until ctx is done or the connection
+// fails; the runtime manager restarts it with backoff. Reconnects requested
+// by Discord are handled here and do not count as failures.
+func (d *DiscordAdapter) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+	defer cancel()
+
+	for {
+		reconnect, err := d.session(runCtx)
+		if runCtx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !reconnect {
+			return nil
+		}
+		slog.Info("Discord gateway reconnecting")
+	}
+}
+
+type discordPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+// session identifies on a new gateway connection and dispatches events until
+// the connection ends. It reports whether Discord asked for a reconnect.
+func (d *DiscordAdapter) session(ctx context.Context) (bool, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, d.gatewayURL, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to connect to discord gateway")
+	}
+	d.mu.Lock()
+	d.conn = conn
+	d.mu.Unlock()
+	defer d.disconnect(conn)
+
+	// Closing the connection unblocks the read below.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	var hello discordPayload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return false, errors.Wrap(err, "failed to read discord hello")
+	}
+	var helloData struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	if hello.Op != discordOpHello || json.Unmarshal(hello.D, &helloData) != nil || helloData.HeartbeatInterval <= 0 {
+		return false, errors.Transient(fmt.Sprintf("unexpected discord gateway payload (op %d)", hello.Op))
+	}
+
+	identify := map[string]interface{}{
+		"token":   d.token,
+		"intents": discordIntents,
+		"properties": map[string]string{
+			"os":      "linux",
+			"browser": "heike",
+			"device":  "heike",
+		},
+	}
+	if err := d.write(conn, discordOpIdentify, identify); err != nil {
+		return false, errors.Wrap(err, "failed to identify with discord gateway")
+	}
+
+	var seqMu sync.Mutex
+	var seq *int64
+	lastSeq := func() *int64 {
+		seqMu.Lock()
+		defer seqMu.Unlock()
+		return seq
+	}
+
+	hbCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go func() {
+		ticker := time.NewTicker(time.Duration(helloData.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hbCtx.Done():
+				return
+			case <-ticker.C:
+				if err := d.write(conn, discordOpHeartbeat, lastSeq()); err != nil {
+					slog.Warn("Discord heartbeat failed", "error", err)
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var p discordPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return false, errors.Wrap(err, "discord gateway connection lost")
+		}
+		if p.S != nil {
+			seqMu.Lock()
+			seq = p.S
+			seqMu.Unlock()
+		}
+		switch p.Op {
+		case discordOpDispatch:
+			d.handleDispatch(ctx, p.T, p.D)
+		case discordOpHeartbeat:
+			if err := d.write(conn, discordOpHeartbeat, lastSeq()); err != nil {
+				return false, errors.Wrap(err, "failed to send discord heartbeat")
+			}
+		case discordOpReconnect, discordOpInvalidSession:
+			return true, nil
+		}
+	}
+}
+
+func (d *DiscordAdapter) write(conn *websocket.Conn, op int, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	return conn.WriteJSON(discordPayload{Op: op, D: raw})
+}
+
+func (d *DiscordAdapter) disconnect(conn *websocket.Conn) {
+	_ = conn.Close()
+	d.mu.Lock()
+	if d.conn == conn {
+		d.conn = nil
+		d.connected = false
+	}
+	d.mu.Unlock()
+}
+
+func (d *DiscordAdapter) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+func (d *DiscordAdapter) Health(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn == nil || !d.connected {
+		return errors.Transient("Discord gateway not connected")
+	}
+	return nil
+}
+
+type discordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot"`
+}
+
+type discordMessage struct {
+	ID        string      `json:"id"`
+	ChannelID string      `json:"channel_id"`
+	GuildID   string      `json:"guild_id"`
+	Content   string      `json:"content"`
+	Author    discordUser `json:"author"`
+}
+
+type discordInteraction struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	Type      int    `json:"type"`
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		User discordUser `json:"user"`
+	} `json:"member"`
+	User *discordUser `json:"user"`
+	Data struct {
+		Name     string `json:"name"`
+		CustomID string `json:"custom_id"`
+		Options  []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Message *discordMessage `json:"message"`
+}
+
+// user returns the invoking user: member.user in guilds, user in DMs.
+func (i discordInteraction) user() discordUser {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	if i.User != nil {
+		return *i.User
+	}
+	return discordUser{}
+}
+
+func (d *DiscordAdapter) handleDispatch(ctx context.Context, event string, data json.RawMessage) {
+	switch event {
+	case "READY":
+		var ready struct {
+			Application struct {
+				ID string `json:"id"`
+			} `json:"application"`
+			User discordUser `json:"user"`
+		}
+		if err := json.Unmarshal(data, &ready); err != nil {
+			slog.Warn("Failed to decode Discord ready event", "error", err)
+			return
+		}
+		d.mu.Lock()
+		d.connected = true
+		d.mu.Unlock()
+		slog.Info("Discord Adapter connected", "user", ready.User.Username)
+		if d.opts.SlashCommands && ready.Application.ID != "" {
+			go d.registerCommands(ctx, ready.Application.ID)
+		}
+	case "MESSAGE_CREATE":
+		var msg discordMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			slog.Warn("Failed to decode Discord message", "error", err)
+			return
+		}
+		d.handleMessage(ctx, msg)
+	case "INTERACTION_CREATE":
+		var interaction discordInteraction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			slog.Warn("Failed to decode Discord interaction", "error", err)
+			return
+		}
+		d.handleInteraction(ctx, interaction)
+	}
+}
+
+// allowed applies the guild and channel allowlists.
+func (d *DiscordAdapter) allowed(guildID, channelID string) bool {
+	if len(d.opts.Guilds) > 0 && !slices.Contains(d.opts.Guilds, guildID) {
+		return false
+	}
+	if len(d.opts.Channels) > 0 && !slices.Contains(d.opts.Channels, channelID) {
+		return false
+	}
+	return true
+}
+
+func (d *DiscordAdapter) handleMessage(ctx context.Context, msg discordMessage) {
+	// Ignore bot messages, including our own replies
+	if msg.Author.Bot || strings.TrimSpace(msg.Content) == "" || !d.allowed(msg.GuildID, msg.ChannelID) {
+		return
+	}
+	metadata := map[string]string{
+		"user_id":   msg.Author.ID,
+		"user_name": msg.Author.Username,
+		"msg_id":    msg.ID,
+	}
+	if msg.GuildID != "" {
+		metadata["guild_id"] = msg.GuildID
+	}
+	d.emit(ctx, msg.ChannelID, msg.Content, metadata)
+}
+
+// handleInteraction turns a slash command or an approval button click into
+// a message for the channel's session. Slash commands map to the matching
+// Heike command, and /ask to a plain message.
+func (d *DiscordAdapter) handleInteraction(ctx context.Context, interaction discordInteraction) {
+	if !d.allowed(interaction.GuildID, interaction.ChannelID) {
+		d.respond(ctx, interaction, discordCallbackMessage, map[string]interface{}{
+			"content": "Heike is not enabled in this channel.",
+			"flags":   discordMessageFlagEphemeral,
+		})
+		return
+	}
+
+	user := interaction.user()
+	var content string
+	switch interaction.Type {
+	case discordInteractionCommand:
+		content = discordCommandContent(interaction)
+		if content == "" {
+			return
+		}
+		d.respond(ctx, interaction, discordCallbackMessage, map[string]interface{}{
+			"content":          truncateDiscordMessage(content),
+			"allowed_mentions": map[string]interface{}{"parse": []string{}},
+		})
+	case discordInteractionComponent:
+		var verdict string
+		switch id := interaction.Data.CustomID; {
+		case strings.HasPrefix(id, discordApprovePrefix):
+			content = "/approve " + strings.TrimPrefix(id, discordApprovePrefix)
+			verdict = "Approved"
+		case strings.HasPrefix(id, discordDenyPrefix):
+			content = "/deny " + strings.TrimPrefix(id, discordDenyPrefix)
+			verdict = "Denied"
+		default:
+			return
+		}
+		original := ""
+		if interaction.Message != nil {
+			original = interaction.Message.Content
+		}
+		// Replace the buttons with the outcome so they cannot be clicked twice.
+		d.respond(ctx, interaction, discordCallbackUpdateMessage, map[string]interface{}{
+			"content":    truncateDiscordMessage(fmt.Sprintf("%s\n%s by %s.", original, verdict, user.Username)),
+			"components": []interface{}{},
+		})
+	default:
+		return
+	}
+
+	metadata := map[string]string{
+		"user_id":        user.ID,
+		"user_name":      user.Username,
+		"interaction_id": interaction.ID,
+	}
+	if interaction.GuildID != "" {
+		metadata["guild_id"] = interaction.GuildID
+	}
+	d.emit(ctx, interaction.ChannelID, content, metadata)
+}
+
+// discordCommandContent renders a slash command as message content, e.g.
+// "/history 5". It returns "" for commands Heike did not register.
+func discordCommandContent(interaction discordInteraction) string {
+	name := interaction.Data.Name
+	if !slices.ContainsFunc(discordSlashCommands, func(cmd discordCommand) bool { return cmd.Name == name }) {
+		return ""
+	}
+	args := make([]string, 0, len(interaction.Data.Options))
+	for _, opt := range interaction.Data.Options {
+		var s string
+		if json.Unmarshal(opt.Value, &s) != nil {
+			s = string(opt.Value)
+		}
+		args = append(args, s)
+	}
+	if name == "ask" {
+		return strings.Join(args, " ")
+	}
+	return strings.TrimSpace("/" + name + " " + strings.Join(args, " "))
+}
+
+func (d *DiscordAdapter) emit(ctx context.Context, channelID, content string, metadata map[string]string) {
+	// Call event handler instead of submitting directly to ingress
+	// This fixes circular dependency
+	if d.eventHandler != nil {
+		if err := d.eventHandler(ctx, "discord", "user_message", channelID, content, metadata); err != nil {
+			slog.Error("Failed to handle Discord event", "error", err)
+		}
+	}
+}
+
+func (d *DiscordAdapter) respond(ctx context.Context, interaction discordInteraction, callbackType int, data map[string]interface{}) {
+	path := fmt.Sprintf("/interactions/%s/%s/callback", url.PathEscape(interaction.ID), url.PathEscape(interaction.Token))
+	body := map[string]interface{}{"type": callbackType, "data": data}
+	if err := d.request(ctx, http.MethodPost, path, body); err != nil {
+		slog.Warn("Failed to answer Discord interaction", "interaction", interaction.ID, "error", err)
+	}
+}
+
+type discordCommandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type discordCommand struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Options     []discordCommandOption `json:"options,omitempty"`
+}
+
+// Application command option types.
+const (
+	discordOptionString  = 3
+	discordOptionInteger = 4
+)
+
+// discordSlashCommands are registered when DiscordOptions.SlashCommands is
+// set. Except for /ask, each maps to the Heike command of the same name.
+var discordSlashCommands = []discordCommand{
+	{Name: "ask", Description: "Send a goal to Heike", Options: []discordCommandOption{
+		{Type: discordOptionString, Name: "prompt", Description: "What Heike should do", Required: true},
+	}},
+	{Name: "new", Description: "Archive this conversation and start a new one"},
+	{Name: "reset", Description: "Clear this conversation"},
+	{Name: "history", Description: "Show the last messages of this conversation", Options: []discordCommandOption{
+		{Type: discordOptionInteger, Name: "n", Description: "Number of messages"},
+	}},
+	{Name: "session", Description: "Show the session ID of this channel"},
+	{Name: "approve", Description: "Approve a pending tool call", Options: []discordCommandOption{
+		{Type: discordOptionString, Name: "id", Description: "Approval ID", Required: true},
+	}},
+	{Name: "deny", Description: "Deny a pending tool call", Options: []discordCommandOption{
+		{Type: discordOptionString, Name: "id", Description: "Approval ID", Required: true},
+	}},
+}
+
+// registerCommands overwrites the bot's slash commands with
+// discordSlashCommands. Failures are only logged.
+func (d *DiscordAdapter) registerCommands(ctx context.Context, appID string) {
+	paths := []string{fmt.Sprintf("/applications/%s/commands", url.PathEscape(appID))}
+	if len(d.opts.Guilds) > 0 {
+		paths = paths[:0]
+		for _, guild := range d.opts.Guilds {
+			paths = append(paths, fmt.Sprintf("/applications/%s/guilds/%s/commands", url.PathEscape(appID), url.PathEscape(guild)))
+		}
+	}
+	for _, path := range paths {
+		if err := d.request(ctx, http.MethodPut, path, discordSlashCommands); err != nil {
+			slog.Warn("Failed to register Discord slash commands", "path", path, "error", err)
+		}
+	}
+}
+
+// Send posts content to the channel sessionID, rendered and split by the
+// adapter's format profile. Mentions in replies never ping anyone.
+func (d *DiscordAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	if strings.TrimSpace(sessionID) == "" {
+		return errors.InvalidInput("discord session ID is empty")
+	}
+	for _, message := range d.opts.Format.Render(content) {
+		body := map[string]interface{}{
+			"content":          message,
+			"allowed_mentions": map[string]interface{}{"parse": []string{}},
+		}
+		if err := d.request(ctx, http.MethodPost, "/channels/"+url.PathEscape(sessionID)+"/messages", body); err != nil {
+			return errors.Wrap(err, "failed to send discord message")
+		}
+	}
+	slog.Debug("Discord message sent", "channel", sessionID)
+	return nil
+}
+
+// NotifyApproval posts an approval request with Approve and Deny buttons to
+// the channel sessionID. A click resolves it like /approve or /deny.
+func (d *DiscordAdapter) NotifyApproval(ctx context.Context, sessionID, approvalID, tool string) error {
+	body := map[string]interface{}{
+		"content":          fmt.Sprintf("Approval required for %s (id %s)", tool, approvalID),
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+		"components": []interface{}{
+			map[string]interface{}{
+				"type": 1,
+				"components": []interface{}{
+					map[string]interface{}{"type": 2, "style": 3, "label": "Approve", "custom_id": discordApprovePrefix + approvalID},
+					map[string]interface{}{"type": 2, "style": 4, "label": "Deny", "custom_id": discordDenyPrefix + approvalID},
+				},
+			},
+		},
+	}
+	if err := d.request(ctx, http.MethodPost, "/channels/"+url.PathEscape(sessionID)+"/messages", body); err != nil {
+		return errors.Wrap(err, "failed to send discord approval request")
+	}
+	return nil
+}
+
+func (d *DiscordAdapter) request(ctx context.Context, method, path string, body interface{}) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.apiBase+path, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+d.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("discord %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func truncateDiscordMessage(content string) string {
+	runes := []rune(content)
+	if len(runes) <= discordMaxMessageChars {
+		return content
+	}
+	return string(runes[:discordMaxMessageChars-3]) + "..."
+}
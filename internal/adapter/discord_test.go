@@ -0,0 +1,149 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDiscordAdapter_GatewayEvents(t *testing.T) {
+	var mu sync.Mutex
+	var rest []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot test-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		mu.Lock()
+		rest = append(rest, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+
+	identified := make(chan map[string]interface{}, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteJSON(map[string]interface{}{"op": discordOpHello, "d": map[string]int{"heartbeat_interval": 60000}})
+		var identify struct {
+			Op int                    `json:"op"`
+			D  map[string]interface{} `json:"d"`
+		}
+		if err := conn.ReadJSON(&identify); err != nil || identify.Op != discordOpIdentify {
+			t.Errorf("identify = %+v, %v", identify, err)
+			return
+		}
+		identified <- identify.D
+		dispatch := func(event, data string) {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"op":0,"s":1,"t":"`+event+`","d":`+data+`}`))
+		}
+		dispatch("READY", `{"application":{"id":"app1"},"user":{"id":"bot1","username":"heike"}}`)
+		dispatch("MESSAGE_CREATE", `{"id":"m1","channel_id":"c1","guild_id":"g1","content":"hello","author":{"id":"u1","username":"ann"}}`)
+		dispatch("MESSAGE_CREATE", `{"id":"m2","channel_id":"c1","guild_id":"g1","content":"echo","author":{"id":"bot1","bot":true}}`)
+		dispatch("MESSAGE_CREATE", `{"id":"m3","channel_id":"c9","guild_id":"g1","content":"elsewhere","author":{"id":"u1","username":"ann"}}`)
+		dispatch("INTERACTION_CREATE", `{"id":"i1","token":"t1","type":2,"guild_id":"g1","channel_id":"c1","member":{"user":{"id":"u1","username":"ann"}},"data":{"name":"history","options":[{"name":"n","type":4,"value":5}]}}`)
+		dispatch("INTERACTION_CREATE", `{"id":"i2","token":"t2","type":3,"guild_id":"g1","channel_id":"c1","member":{"user":{"id":"u1","username":"ann"}},"data":{"custom_id":"heike_approve:ap1"},"message":{"content":"Approval required"}}`)
+		// Keep the connection open until the client goes away.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer gateway.Close()
+
+	events := make(chan string, 10)
+	adapter := NewDiscordAdapter("test-token", func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		if source != "discord" || eventType != "user_message" {
+			t.Errorf("event = %s/%s", source, eventType)
+		}
+		events <- sessionID + " " + content
+		return nil
+	}, DiscordOptions{Guilds: []string{"g1"}, Channels: []string{"c1"}, SlashCommands: true})
+	adapter.apiBase = api.URL
+	adapter.gatewayURL = "ws" + strings.TrimPrefix(gateway.URL, "http")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- adapter.Start(ctx) }()
+
+	select {
+	case d := <-identified:
+		if d["token"] != "test-token" || d["intents"] != float64(discordIntents) {
+			t.Fatalf("identify = %v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("adapter did not identify")
+	}
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events = %v", got)
+		}
+	}
+	if want := "c1 hello,c1 /history 5,c1 /approve ap1"; strings.Join(got, ",") != want {
+		t.Fatalf("events = %s, want %s", strings.Join(got, ","), want)
+	}
+	if err := adapter.Health(ctx); err != nil {
+		t.Fatalf("health: %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("start returned %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	joined := strings.Join(rest, ",")
+	for _, want := range []string{
+		"PUT /applications/app1/guilds/g1/commands",
+		"POST /interactions/i1/t1/callback",
+		"POST /interactions/i2/t2/callback",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("REST calls = %s, missing %s", joined, want)
+		}
+	}
+}
+
+func TestDiscordAdapter_SendSplitsLongMessages(t *testing.T) {
+	var bodies []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channels/c1/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body struct {
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body.Content)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	adapter := NewDiscordAdapter("test-token", nil, DiscordOptions{})
+	adapter.apiBase = api.URL
+
+	content := strings.Repeat("a", 1500) + "\n" + strings.Repeat("b", 1500)
+	if err := adapter.Send(context.Background(), "c1", content); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] != strings.Repeat("a", 1500)+"\n" || bodies[1] != strings.Repeat("b", 1500) {
+		t.Fatalf("sent %d messages", len(bodies))
+	}
+}
@@ -0,0 +1,536 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/errors"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// EmailOptions configures the email adapter.
+type EmailOptions struct {
+	// Address is the agent's mailbox. Only unseen mail sent to it is read,
+	// and replies are sent from it.
+	Address string
+	// AllowedSenders lists addresses, or "@domain" for a whole domain, whose
+	// mail is accepted. Other mail is marked seen and ignored.
+	AllowedSenders []string
+	Mailbox        string
+	PollInterval   time.Duration
+	IMAP           EmailServer
+	SMTP           EmailServer
+}
+
+// EmailServer is an IMAP or SMTP endpoint and its login.
+type EmailServer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func (s EmailServer) addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// emailThread is what a reply to a session needs from the latest mail in it.
+type emailThread struct {
+	to         string
+	subject    string
+	messageID  string
+	references string
+}
+
+// EmailAdapter polls an IMAP mailbox for mail to the agent and submits each
+// message as a background "email" event. Replies go out over SMTP in the
+// same thread. Threads are remembered in memory, so a reply to a thread
+// started before a restart has nowhere to go.
+type EmailAdapter struct {
+	opts         EmailOptions
+	eventHandler EventHandler
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	gate         pauseGate
+
+	mu      sync.Mutex
+	threads map[string]emailThread
+	lastErr error
+
+	// dialIMAP and sendMail are replaced in tests.
+	dialIMAP func(ctx context.Context) (net.Conn, error)
+	sendMail func(from string, to []string, msg []byte) error
+}
+
+func NewEmailAdapter(eventHandler EventHandler, opts EmailOptions) *EmailAdapter {
+	if opts.Mailbox == "" {
+		opts.Mailbox = config.DefaultEmailMailbox
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+	if opts.IMAP.Port == 0 {
+		opts.IMAP.Port = config.DefaultEmailIMAPPort
+	}
+	if opts.SMTP.Port == 0 {
+		opts.SMTP.Port = config.DefaultEmailSMTPPort
+	}
+	e := &EmailAdapter{
+		opts:         opts,
+		eventHandler: eventHandler,
+		threads:      make(map[string]emailThread),
+	}
+	e.dialIMAP = e.dialIMAPTLS
+	e.sendMail = e.sendSMTP
+	return e
+}
+
+func (e *EmailAdapter) Name() string {
+	return "email"
+}
+
+// Start checks the IMAP login once, so bad credentials fail the adapter,
+// and then polls in the background.
+func (e *EmailAdapter) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	e.cancel = cancel
+
+	client, err := e.connect(runCtx)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to connect to imap server")
+	}
+	_ = client.Logout()
+	_ = client.Close()
+
+	slog.Info("Email Adapter started", "address", e.opts.Address, "mailbox", e.opts.Mailbox, "poll_interval", e.opts.PollInterval)
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.poll(runCtx)
+	}()
+	return nil
+}
+
+// poll checks the mailbox every PollInterval. While paused, mail stays
+// unseen on the server.
+func (e *EmailAdapter) poll(ctx context.Context) {
+	ticker := time.NewTicker(e.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := e.gate.Wait(ctx); err != nil {
+			return
+		}
+		err := e.fetchNew(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Email poll failed", "error", err)
+		}
+		e.mu.Lock()
+		e.lastErr = err
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchNew submits every unseen message to the agent. A message is marked
+// seen once it is submitted or rejected, so a failed submit is retried on
+// the next poll.
+func (e *EmailAdapter) fetchNew(ctx context.Context) error {
+	client, err := e.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	uids, err := client.SearchUnseen(e.opts.Address)
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		if ctx.Err() != nil {
+			break
+		}
+		raw, err := client.Fetch(uid)
+		if err != nil {
+			return err
+		}
+		if err := e.handleMessage(ctx, raw); err != nil {
+			slog.Error("Failed to handle email", "uid", uid, "error", err)
+			continue
+		}
+		if err := client.MarkSeen(uid); err != nil {
+			return err
+		}
+	}
+	return client.Logout()
+}
+
+func (e *EmailAdapter) connect(ctx context.Context) (*imapClient, error) {
+	conn, err := e.dialIMAP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newIMAPClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := client.Login(e.opts.IMAP.Username, e.opts.IMAP.Password); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	if err := client.Select(e.opts.Mailbox); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (e *EmailAdapter) dialIMAPTLS(ctx context.Context) (net.Conn, error) {
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: e.opts.IMAP.Host}}
+	return dialer.DialContext(ctx, "tcp", e.opts.IMAP.addr())
+}
+
+// handleMessage submits one message. Mail that should not reach the agent
+// returns nil so it is marked seen and not fetched again.
+func (e *EmailAdapter) handleMessage(ctx context.Context, raw []byte) error {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		slog.Warn("Skipping unparsable email", "error", err)
+		return nil
+	}
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		slog.Warn("Skipping email without a valid sender", "from", msg.Header.Get("From"))
+		return nil
+	}
+	sender := strings.ToLower(from.Address)
+	if strings.EqualFold(sender, e.opts.Address) {
+		return nil
+	}
+	if !e.senderAllowed(sender) {
+		slog.Warn("Ignoring email from sender not in allowed_senders", "from", sender)
+		return nil
+	}
+
+	body, err := plainTextBody(msg.Header, msg.Body)
+	if err != nil {
+		slog.Warn("Skipping email without a readable text part", "from", sender, "error", err)
+		return nil
+	}
+	body = stripQuotedReply(body)
+	subject := decodeHeader(msg.Header.Get("Subject"))
+	messageID := strings.TrimSpace(msg.Header.Get("Message-ID"))
+	inReplyTo := strings.TrimSpace(msg.Header.Get("In-Reply-To"))
+	references := strings.TrimSpace(msg.Header.Get("References"))
+
+	content := body
+	if inReplyTo == "" && subject != "" {
+		content = strings.TrimSpace(subject + "\n\n" + body)
+	}
+	if content == "" {
+		return nil
+	}
+
+	sessionID := emailSessionID(messageID, inReplyTo, references)
+	e.mu.Lock()
+	e.threads[sessionID] = emailThread{
+		to:         from.Address,
+		subject:    subject,
+		messageID:  messageID,
+		references: references,
+	}
+	e.mu.Unlock()
+
+	metadata := map[string]string{
+		"user_id":       sender,
+		"user_name":     from.Name,
+		"email_subject": subject,
+		"msg_id":        messageID,
+	}
+	if e.eventHandler == nil {
+		return nil
+	}
+	return e.eventHandler(ctx, "email", "email", sessionID, content, metadata)
+}
+
+func (e *EmailAdapter) senderAllowed(sender string) bool {
+	for _, allowed := range e.opts.AllowedSenders {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == "" {
+			continue
+		}
+		if strings.HasPrefix(allowed, "@") {
+			if strings.HasSuffix(sender, allowed) {
+				return true
+			}
+			continue
+		}
+		if sender == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// emailSessionID keys a session by the first message of its thread.
+func emailSessionID(messageID, inReplyTo, references string) string {
+	root := messageID
+	if refs := strings.Fields(references); len(refs) > 0 {
+		root = refs[0]
+	} else if inReplyTo != "" {
+		root = inReplyTo
+	}
+	sum := sha256.Sum256([]byte(root))
+	return "email:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// plainTextBody returns the first text/plain part of a message.
+func plainTextBody(header mail.Header, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	encoding := header.Get("Content-Transfer-Encoding")
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return "", fmt.Errorf("no text/plain part")
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := plainTextBody(mail.Header(part.Header), part)
+			if err == nil {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", fmt.Errorf("unsupported content type %s", mediaType)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(data), "\r\n", "\n"), nil
+}
+
+// newlineStripper drops line breaks from wrapped base64.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (s *newlineStripper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	out := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			out = append(out, b)
+		}
+	}
+	return len(out), err
+}
+
+// stripQuotedReply drops quoted lines and the "On ... wrote:" line that
+// introduces them, leaving only what the sender wrote.
+func stripQuotedReply(body string) string {
+	lines := strings.Split(body, "\n")
+	kept := lines[:0]
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "On ") && strings.HasSuffix(trimmed, "wrote:") && i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// Send replies to the latest mail in the session's thread.
+func (e *EmailAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	e.mu.Lock()
+	thread, ok := e.threads[sessionID]
+	e.mu.Unlock()
+	if !ok {
+		return errors.NotFound("no email thread for session " + sessionID)
+	}
+
+	msg, err := e.composeReply(thread, content)
+	if err != nil {
+		return errors.Wrap(err, "failed to compose email reply")
+	}
+	if err := e.sendMail(e.opts.Address, []string{thread.to}, msg); err != nil {
+		return errors.Wrap(err, "failed to send email reply")
+	}
+	slog.Debug("Email reply sent", "session", sessionID, "to", thread.to)
+	return nil
+}
+
+func (e *EmailAdapter) composeReply(thread emailThread, content string) ([]byte, error) {
+	subject := thread.subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = strings.TrimSpace("Re: " + subject)
+	}
+	references := strings.TrimSpace(thread.references + " " + thread.messageID)
+	domain := "heike.local"
+	if at := strings.LastIndexByte(e.opts.Address, '@'); at >= 0 {
+		domain = e.opts.Address[at+1:]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.opts.Address)
+	fmt.Fprintf(&buf, "To: %s\r\n", thread.to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", strings.ToLower(ulid.Make().String()), domain)
+	if thread.messageID != "" {
+		fmt.Fprintf(&buf, "In-Reply-To: %s\r\n", thread.messageID)
+	}
+	if references != "" {
+		fmt.Fprintf(&buf, "References: %s\r\n", references)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendSMTP delivers msg with STARTTLS, or over implicit TLS on port 465.
+func (e *EmailAdapter) sendSMTP(from string, to []string, msg []byte) error {
+	server := e.opts.SMTP
+	var auth smtp.Auth
+	if server.Username != "" {
+		auth = smtp.PlainAuth("", server.Username, server.Password, server.Host)
+	}
+	if server.Port != 465 {
+		return smtp.SendMail(server.addr(), auth, from, to, msg)
+	}
+
+	conn, err := tls.Dial("tcp", server.addr(), &tls.Config{ServerName: server.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, server.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// Pause stops polling the mailbox until Resume is called.
+func (e *EmailAdapter) Pause() {
+	e.gate.Pause()
+}
+
+// Resume continues polling after Pause.
+func (e *EmailAdapter) Resume() {
+	e.gate.Resume()
+}
+
+func (e *EmailAdapter) Stop(ctx context.Context) error {
+	if e.cancel != nil {
+		e.cancel()
+	}
+
+	waitDone := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(waitDone)
+	}()
+
+	select {
+	case <-waitDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *EmailAdapter) Health(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lastErr != nil {
+		return errors.Transient("Email poll failed: " + e.lastErr.Error())
+	}
+	return nil
+}
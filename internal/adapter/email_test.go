@@ -0,0 +1,205 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIMAPServer serves a fixed set of messages and records which were
+// marked seen.
+type fakeIMAPServer struct {
+	ln       net.Listener
+	messages map[uint32]string
+
+	mu   sync.Mutex
+	seen map[uint32]bool
+}
+
+func newFakeIMAPServer(t *testing.T, messages map[uint32]string) *fakeIMAPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeIMAPServer{ln: ln, messages: messages, seen: make(map[uint32]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake imap ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		var uid uint32
+		switch {
+		case strings.HasPrefix(cmd, "LOGIN "):
+			if cmd != `LOGIN "agent" "secret"` {
+				fmt.Fprintf(conn, "%s NO bad credentials\r\n", tag)
+				continue
+			}
+		case strings.HasPrefix(cmd, "UID SEARCH "):
+			s.mu.Lock()
+			var uids []string
+			for id := uint32(1); int(id) <= len(s.messages); id++ {
+				if !s.seen[id] {
+					uids = append(uids, fmt.Sprint(id))
+				}
+			}
+			s.mu.Unlock()
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+		case strings.HasPrefix(cmd, "UID FETCH "):
+			fmt.Sscanf(cmd, "UID FETCH %d", &uid)
+			msg := s.messages[uid]
+			fmt.Fprintf(conn, "* 1 FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, len(msg), msg)
+		case strings.HasPrefix(cmd, "UID STORE "):
+			fmt.Sscanf(cmd, "UID STORE %d", &uid)
+			s.mu.Lock()
+			s.seen[uid] = true
+			s.mu.Unlock()
+		case cmd == "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+			return
+		}
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+func (s *fakeIMAPServer) allSeen() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen) == len(s.messages)
+}
+
+func TestEmailAdapter_PollSubmitsAndReplies(t *testing.T) {
+	server := newFakeIMAPServer(t, map[uint32]string{
+		1: "From: Ann <ann@example.com>\r\nTo: agent@example.com\r\nSubject: Research task\r\n" +
+			"Message-ID: <root@example.com>\r\nContent-Type: text/plain; charset=utf-8\r\n" +
+			"Content-Transfer-Encoding: quoted-printable\r\n\r\nCompare the three =\r\nlibraries.\r\n",
+		2: "From: mallory@evil.test\r\nTo: agent@example.com\r\nSubject: Hi\r\nMessage-ID: <x@evil.test>\r\n\r\nrm -rf\r\n",
+		3: "From: bob@team.example.com\r\nTo: agent@example.com\r\nSubject: Re: Research task\r\n" +
+			"Message-ID: <reply@team.example.com>\r\nIn-Reply-To: <answer@example.com>\r\n" +
+			"References: <root@example.com> <answer@example.com>\r\n" +
+			"Content-Type: multipart/alternative; boundary=b1\r\n\r\n" +
+			"--b1\r\nContent-Type: text/html\r\n\r\n<p>ignored</p>\r\n" +
+			"--b1\r\nContent-Type: text/plain\r\n\r\nAdd benchmarks too.\r\n\r\nOn Mon, Heike wrote:\r\n> Done.\r\n--b1--\r\n",
+	})
+
+	type submitted struct {
+		eventType, sessionID, content, from string
+	}
+	events := make(chan submitted, 10)
+	adapter := NewEmailAdapter(func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		if source != "email" {
+			t.Errorf("source = %s", source)
+		}
+		events <- submitted{eventType, sessionID, content, metadata["user_id"]}
+		return nil
+	}, EmailOptions{
+		Address:        "agent@example.com",
+		AllowedSenders: []string{"ann@example.com", "@team.example.com"},
+		PollInterval:   time.Hour,
+		IMAP:           EmailServer{Host: "imap.test", Username: "agent", Password: "secret"},
+	})
+	adapter.dialIMAP = func(ctx context.Context) (net.Conn, error) {
+		return net.Dial("tcp", server.ln.Addr().String())
+	}
+	var sentTo []string
+	var sent []byte
+	adapter.sendMail = func(from string, to []string, msg []byte) error {
+		sentTo, sent = to, msg
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := adapter.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer adapter.Stop(context.Background())
+
+	got := map[string]submitted{}
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			got[e.from] = e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events = %v", got)
+		}
+	}
+	ann, bob := got["ann@example.com"], got["bob@team.example.com"]
+	if ann.eventType != "email" || ann.content != "Research task\n\nCompare the three libraries." {
+		t.Fatalf("first message = %+v", ann)
+	}
+	if bob.content != "Add benchmarks too." {
+		t.Fatalf("reply content = %q", bob.content)
+	}
+	if ann.sessionID != bob.sessionID || !strings.HasPrefix(ann.sessionID, "email:") {
+		t.Fatalf("thread sessions = %s, %s", ann.sessionID, bob.sessionID)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.allSeen() {
+		if time.Now().After(deadline) {
+			t.Fatal("messages were not all marked seen")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := adapter.Health(ctx); err != nil {
+		t.Fatalf("health: %v", err)
+	}
+
+	if err := adapter.Send(ctx, bob.sessionID, "Here are the results."); err != nil {
+		t.Fatal(err)
+	}
+	if len(sentTo) != 1 || sentTo[0] != "bob@team.example.com" {
+		t.Fatalf("sent to %v", sentTo)
+	}
+	reply, err := mail.ReadMessage(strings.NewReader(string(sent)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Header.Get("Subject") != "Re: Research task" ||
+		reply.Header.Get("In-Reply-To") != "<reply@team.example.com>" ||
+		reply.Header.Get("References") != "<root@example.com> <answer@example.com> <reply@team.example.com>" {
+		t.Fatalf("reply headers = %v", reply.Header)
+	}
+
+	if err := adapter.Send(ctx, "email:unknown", "lost"); err == nil {
+		t.Fatal("expected error for unknown thread")
+	}
+}
+
+func TestEmailAdapter_StartFailsOnBadLogin(t *testing.T) {
+	server := newFakeIMAPServer(t, nil)
+	adapter := NewEmailAdapter(nil, EmailOptions{
+		Address: "agent@example.com",
+		IMAP:    EmailServer{Host: "imap.test", Username: "agent", Password: "wrong"},
+	})
+	adapter.dialIMAP = func(ctx context.Context) (net.Conn, error) {
+		return net.Dial("tcp", server.ln.Addr().String())
+	}
+	if err := adapter.Start(context.Background()); err == nil {
+		t.Fatal("expected login failure")
+	}
+}
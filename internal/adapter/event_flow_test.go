@@ -35,7 +35,7 @@ func TestTelegramAdapter_EventFlow(t *testing.T) {
 			metadata:  metadata,
 		}
 		return nil
-	}, 1)
+	}, TelegramOptions{UpdateTimeout: 1})
 
 	adapter.handleUpdate(context.Background(), tgbotapi.Update{
 		UpdateID: 99,
@@ -0,0 +1,380 @@
+package adapter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/harunnryd/heike/internal/config"
+)
+
+// Styles an output adapter renders assistant replies in. Replies are
+// written in Markdown; the other styles are converted from it.
+const (
+	FormatMarkdown     = "markdown"
+	FormatSlack        = "slack"
+	FormatTelegramHTML = "telegram_html"
+	FormatPlain        = "plain"
+)
+
+// formatTruncatedNotice ends the last message of a reply cut at MaxChunks.
+const formatTruncatedNotice = "\n\n[Reply truncated: it was too long to send in full.]"
+
+// minFencedChunk is the smallest chunk that closes and reopens code blocks
+// at its edges; smaller chunks are cut without regard to Markdown.
+const minFencedChunk = 64
+
+// FormatProfile is how an output adapter shapes assistant replies: the
+// style they are rendered in and how long replies are split.
+type FormatProfile struct {
+	Style string
+	// MaxChars is the longest message sent, counted in runes of the
+	// rendered text; longer replies are split, between lines where
+	// possible. 0 sends replies whole.
+	MaxChars int
+	// MaxChunks caps the messages one reply is split into. The rest of the
+	// reply is dropped and the last message says so. 0 is unlimited.
+	MaxChunks int
+}
+
+// formatProfileFromConfig applies the adapters.<name>.format section over
+// def, the adapter's default style and message size limit.
+func formatProfileFromConfig(name string, cfg config.MessageFormatConfig, def FormatProfile) (FormatProfile, error) {
+	profile := def
+	if style := strings.ToLower(strings.TrimSpace(cfg.Style)); style != "" {
+		profile.Style = style
+	}
+	switch profile.Style {
+	case FormatMarkdown, FormatSlack, FormatTelegramHTML, FormatPlain:
+	default:
+		return FormatProfile{}, fmt.Errorf("adapters.%s.format.style must be %q, %q, %q or %q", name, FormatMarkdown, FormatSlack, FormatTelegramHTML, FormatPlain)
+	}
+	if cfg.MaxChars < 0 || cfg.MaxChars > def.MaxChars {
+		return FormatProfile{}, fmt.Errorf("adapters.%s.format.max_chars must be between 1 and %d", name, def.MaxChars)
+	}
+	if cfg.MaxChars > 0 {
+		profile.MaxChars = cfg.MaxChars
+	}
+	if cfg.MaxChunks < 0 {
+		return FormatProfile{}, fmt.Errorf("adapters.%s.format.max_chunks must not be negative", name)
+	}
+	profile.MaxChunks = cfg.MaxChunks
+	return profile, nil
+}
+
+// Render converts a Markdown reply to the profile's style and splits it
+// into messages that fit MaxChars. Blank messages are dropped.
+func (p FormatProfile) Render(content string) []string {
+	if p.MaxChars <= 0 {
+		if strings.TrimSpace(content) == "" {
+			return nil
+		}
+		return []string{p.convert(content)}
+	}
+
+	chunks := p.split(content, p.MaxChars, p.MaxChars)
+	if p.MaxChunks > 0 && len(chunks) > p.MaxChunks {
+		limit := p.MaxChars - utf8.RuneCountInString(formatTruncatedNotice)
+		last := ""
+		if limit > 0 {
+			if cut := p.split(chunks[p.MaxChunks-1].source, limit, limit); len(cut) > 0 {
+				last = cut[0].text
+			}
+		}
+		chunks = append(chunks[:p.MaxChunks-1], renderedChunk{text: last + formatTruncatedNotice})
+	}
+
+	messages := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		messages = append(messages, chunk.text)
+	}
+	return messages
+}
+
+type renderedChunk struct {
+	source string
+	text   string
+}
+
+// split chunks content at budget runes of Markdown and renders each chunk.
+// A chunk that grows past limit when rendered, e.g. through HTML escaping,
+// is split again with a smaller budget.
+func (p FormatProfile) split(content string, budget, limit int) []renderedChunk {
+	var chunks []renderedChunk
+	for _, source := range chunkMarkdown(content, budget) {
+		if strings.TrimSpace(source) == "" {
+			continue
+		}
+		text := p.convert(source)
+		if n := utf8.RuneCountInString(text); n > limit && budget > 1 {
+			smaller := budget * limit / n
+			if smaller >= budget {
+				smaller = budget - 1
+			}
+			if smaller < 1 {
+				smaller = 1
+			}
+			chunks = append(chunks, p.split(source, smaller, limit)...)
+			continue
+		}
+		chunks = append(chunks, renderedChunk{source: source, text: text})
+	}
+	return chunks
+}
+
+// chunkMarkdown splits content into chunks of at most maxChars runes,
+// breaking between lines where it can. A code block cut in two is closed at
+// the end of one chunk and reopened at the start of the next, so each chunk
+// renders on its own.
+func chunkMarkdown(content string, maxChars int) []string {
+	if maxChars < minFencedChunk {
+		return splitMessage(content, maxChars)
+	}
+	const closing = "\n```"
+
+	var (
+		chunks []string
+		cur    strings.Builder
+		n      int
+		// base is the length of the reopened fence cur starts with.
+		base  int
+		fence string
+	)
+	emit := func() {
+		text := cur.String()
+		if fence != "" {
+			text = strings.TrimSuffix(text, "\n") + closing
+		}
+		chunks = append(chunks, text)
+		cur.Reset()
+		n, base = 0, 0
+		if fence != "" {
+			cur.WriteString(fence + "\n")
+			n = utf8.RuneCountInString(fence) + 1
+			base = n
+		}
+	}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		reserve := 0
+		if fence != "" {
+			reserve = len(closing)
+		}
+		runes := []rune(line)
+		for len(runes) > 0 {
+			room := maxChars - n - reserve
+			if len(runes) <= room {
+				cur.WriteString(string(runes))
+				n += len(runes)
+				break
+			}
+			if n > base {
+				emit()
+				continue
+			}
+			// The line does not fit in an empty chunk: cut it.
+			if room < 1 {
+				room = 1
+			}
+			cur.WriteString(string(runes[:room]))
+			n += room
+			runes = runes[room:]
+			emit()
+		}
+
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") {
+			if fence == "" {
+				fence = trimmed
+			} else {
+				fence = ""
+			}
+		}
+	}
+	if n > base {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+func (p FormatProfile) convert(markdown string) string {
+	switch p.Style {
+	case FormatSlack:
+		return slackMarkup.convert(markdown)
+	case FormatTelegramHTML:
+		return telegramHTMLMarkup.convert(markdown)
+	case FormatPlain:
+		return plainMarkup.convert(markdown)
+	}
+	return markdown
+}
+
+// markup is a target syntax for the Markdown subset replies use: emphasis,
+// inline code, code blocks, links, headings and bullet lists.
+type markup struct {
+	escape    func(string) string
+	bold      func(string) string
+	italic    func(string) string
+	strike    func(string) string
+	code      func(string) string
+	codeBlock func(lang, body string) string
+	link      func(text, url string) string
+	heading   func(string) string
+	// bullet replaces list markers; empty keeps them.
+	bullet string
+}
+
+var (
+	mdHeading  = regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*\s*$`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdCodeSpan = regexp.MustCompile("`([^`]+)`")
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold     = regexp.MustCompile(`\*\*([^*]+?)\*\*|__([^_]+?)__`)
+	mdStrike   = regexp.MustCompile(`~~([^~]+?)~~`)
+	mdItalic   = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	mdItalicU  = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\s](?:[^_]*[^_\s])?)_([^\p{L}\p{N}_]|$)`)
+)
+
+func (m markup) convert(markdown string) string {
+	var b strings.Builder
+	var block []string
+	lang, inBlock := "", false
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		body := strings.TrimSuffix(line, "\n")
+		newline := line[len(body):]
+		if trimmed := strings.TrimSpace(body); strings.HasPrefix(trimmed, "```") {
+			if !inBlock {
+				inBlock, lang, block = true, strings.TrimSpace(strings.TrimPrefix(trimmed, "```")), nil
+				continue
+			}
+			inBlock = false
+			b.WriteString(m.codeBlock(lang, m.escape(strings.Join(block, "\n"))))
+			b.WriteString(newline)
+			continue
+		}
+		if inBlock {
+			block = append(block, body)
+			continue
+		}
+		b.WriteString(m.line(body))
+		b.WriteString(newline)
+	}
+	if inBlock {
+		b.WriteString(m.codeBlock(lang, m.escape(strings.Join(block, "\n"))))
+	}
+	return b.String()
+}
+
+func (m markup) line(line string) string {
+	if match := mdHeading.FindStringSubmatch(line); match != nil {
+		return m.heading(m.inline(match[1]))
+	}
+	if match := mdBullet.FindStringSubmatch(line); match != nil && m.bullet != "" {
+		return match[1] + m.bullet + m.inline(match[2])
+	}
+	return m.inline(line)
+}
+
+// inline converts the spans of one line. Converted spans are held out of
+// the text until the end, so their markup is not converted again.
+func (m markup) inline(text string) string {
+	var held []string
+	hold := func(s string) string {
+		held = append(held, s)
+		return "\x00" + strconv.Itoa(len(held)-1) + "\x00"
+	}
+
+	s := m.escape(text)
+	s = mdCodeSpan.ReplaceAllStringFunc(s, func(span string) string {
+		return hold(m.code(mdCodeSpan.FindStringSubmatch(span)[1]))
+	})
+	s = mdLink.ReplaceAllStringFunc(s, func(span string) string {
+		match := mdLink.FindStringSubmatch(span)
+		return hold(m.link(match[1], match[2]))
+	})
+	s = mdBold.ReplaceAllStringFunc(s, func(span string) string {
+		match := mdBold.FindStringSubmatch(span)
+		return hold(m.bold(match[1] + match[2]))
+	})
+	s = mdStrike.ReplaceAllStringFunc(s, func(span string) string {
+		return hold(m.strike(mdStrike.FindStringSubmatch(span)[1]))
+	})
+	s = mdItalic.ReplaceAllStringFunc(s, func(span string) string {
+		return hold(m.italic(mdItalic.FindStringSubmatch(span)[1]))
+	})
+	s = mdItalicU.ReplaceAllStringFunc(s, func(span string) string {
+		match := mdItalicU.FindStringSubmatch(span)
+		return match[1] + hold(m.italic(match[2])) + match[3]
+	})
+
+	// Later spans may hold earlier ones, so restore from the last.
+	for i := len(held) - 1; i >= 0; i-- {
+		s = strings.Replace(s, "\x00"+strconv.Itoa(i)+"\x00", held[i], 1)
+	}
+	return s
+}
+
+func keepText(s string) string { return s }
+
+func wrapWith(open, close string) func(string) string {
+	return func(s string) string { return open + s + close }
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackMarkup renders Slack mrkdwn.
+var slackMarkup = markup{
+	escape: slackEscaper.Replace,
+	bold:   wrapWith("*", "*"),
+	italic: wrapWith("_", "_"),
+	strike: wrapWith("~", "~"),
+	code:   wrapWith("`", "`"),
+	codeBlock: func(lang, body string) string {
+		return "```\n" + body + "\n```"
+	},
+	link: func(text, url string) string {
+		return "<" + url + "|" + text + ">"
+	},
+	heading: wrapWith("*", "*"),
+	bullet:  "• ",
+}
+
+// telegramHTMLMarkup renders the HTML subset of Telegram's HTML parse mode.
+var telegramHTMLMarkup = markup{
+	escape: slackEscaper.Replace,
+	bold:   wrapWith("<b>", "</b>"),
+	italic: wrapWith("<i>", "</i>"),
+	strike: wrapWith("<s>", "</s>"),
+	code:   wrapWith("<code>", "</code>"),
+	codeBlock: func(lang, body string) string {
+		if lang == "" {
+			return "<pre>" + body + "</pre>"
+		}
+		return `<pre><code class="language-` + strings.ReplaceAll(lang, `"`, "&quot;") + `">` + body + "</code></pre>"
+	},
+	link: func(text, url string) string {
+		return `<a href="` + strings.ReplaceAll(url, `"`, "&quot;") + `">` + text + "</a>"
+	},
+	heading: wrapWith("<b>", "</b>"),
+	bullet:  "• ",
+}
+
+// plainMarkup drops Markdown syntax and keeps link targets as text.
+var plainMarkup = markup{
+	escape: keepText,
+	bold:   keepText,
+	italic: keepText,
+	strike: keepText,
+	code:   keepText,
+	codeBlock: func(lang, body string) string {
+		return body
+	},
+	link: func(text, url string) string {
+		if text == url {
+			return url
+		}
+		return text + " (" + url + ")"
+	},
+	heading: keepText,
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/harunnryd/heike/internal/config"
+)
+
+func TestFormatProfile_Convert(t *testing.T) {
+	reply := "## Summary\n" +
+		"Use **bold**, *italic*, ~~old~~ and `a<b>` with [docs](https://example.com/a?x=1&y=2).\n" +
+		"- first_item stays\n" +
+		"```go\nif a < b {}\n```"
+
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{FormatMarkdown, reply},
+		{FormatSlack, "*Summary*\n" +
+			"Use *bold*, _italic_, ~old~ and `a&lt;b&gt;` with <https://example.com/a?x=1&amp;y=2|docs>.\n" +
+			"• first_item stays\n" +
+			"```\nif a &lt; b {}\n```"},
+		{FormatTelegramHTML, "<b>Summary</b>\n" +
+			`Use <b>bold</b>, <i>italic</i>, <s>old</s> and <code>a&lt;b&gt;</code> with <a href="https://example.com/a?x=1&amp;y=2">docs</a>.` + "\n" +
+			"• first_item stays\n" +
+			`<pre><code class="language-go">if a &lt; b {}</code></pre>`},
+		{FormatPlain, "Summary\n" +
+			"Use bold, italic, old and a<b> with docs (https://example.com/a?x=1&y=2).\n" +
+			"- first_item stays\n" +
+			"if a < b {}"},
+	}
+	for _, tt := range tests {
+		got := FormatProfile{Style: tt.style}.Render(reply)
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestFormatProfile_SplitsLongReplies(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Intro line\n```\n")
+	for i := 0; i < 40; i++ {
+		b.WriteString("code line <with> markup to escape\n")
+	}
+	b.WriteString("```\nOutro line")
+
+	profile := FormatProfile{Style: FormatTelegramHTML, MaxChars: 300}
+	messages := profile.Render(b.String())
+	if len(messages) < 5 {
+		t.Fatalf("messages = %d, want the reply split", len(messages))
+	}
+	for i, msg := range messages {
+		if n := utf8.RuneCountInString(msg); n > profile.MaxChars {
+			t.Errorf("message %d has %d chars", i, n)
+		}
+		if strings.Count(msg, "<pre>") != strings.Count(msg, "</pre>") {
+			t.Errorf("message %d leaves a code block open: %q", i, msg)
+		}
+	}
+	if !strings.HasPrefix(messages[0], "Intro line") || !strings.HasSuffix(messages[len(messages)-1], "Outro line") {
+		t.Fatalf("first/last = %q / %q", messages[0], messages[len(messages)-1])
+	}
+
+	profile.MaxChunks = 2
+	truncated := profile.Render(b.String())
+	if len(truncated) != 2 || !strings.HasSuffix(truncated[1], formatTruncatedNotice) {
+		t.Fatalf("truncated = %q", truncated)
+	}
+	if n := utf8.RuneCountInString(truncated[1]); n > profile.MaxChars {
+		t.Fatalf("last message has %d chars", n)
+	}
+
+	if got := profile.Render(" \n "); len(got) != 0 {
+		t.Fatalf("blank reply = %q", got)
+	}
+}
+
+func TestFormatProfileFromConfig(t *testing.T) {
+	got, err := formatProfileFromConfig("discord", config.MessageFormatConfig{Style: "Plain", MaxChunks: 3}, discordDefaultFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (FormatProfile{Style: FormatPlain, MaxChars: discordMaxMessageChars, MaxChunks: 3}); got != want {
+		t.Fatalf("profile = %+v, want %+v", got, want)
+	}
+
+	for _, cfg := range []config.MessageFormatConfig{
+		{Style: "html"},
+		{MaxChars: discordMaxMessageChars + 1},
+		{MaxChunks: -1},
+	} {
+		if _, err := formatProfileFromConfig("discord", cfg, discordDefaultFormat); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package adapter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// imapClient speaks the small subset of IMAP4rev1 (RFC 3501) the email
+// adapter needs: login, select, search, fetch and flag.
+type imapClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// imapLine is one untagged response line. A literal ({n}) in the line is
+// returned in literal, and text holds the line with the rest appended.
+type imapLine struct {
+	text    string
+	literal []byte
+}
+
+// imapCommandTimeout bounds each command round trip.
+const imapCommandTimeout = time.Minute
+
+func newIMAPClient(conn net.Conn) (*imapClient, error) {
+	c := &imapClient{conn: conn, r: bufio.NewReader(conn)}
+	_ = conn.SetReadDeadline(time.Now().Add(imapCommandTimeout))
+	greeting, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read imap greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected imap greeting: %s", strings.TrimSpace(greeting))
+	}
+	return c, nil
+}
+
+func (c *imapClient) Close() error {
+	return c.conn.Close()
+}
+
+// Command sends one command and returns its untagged responses. A NO or BAD
+// completion is returned as an error.
+func (c *imapClient) Command(format string, args ...interface{}) ([]imapLine, error) {
+	c.tag++
+	tag := "h" + strconv.Itoa(c.tag)
+	_ = c.conn.SetDeadline(time.Now().Add(imapCommandTimeout))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var lines []imapLine
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(line.text, tag+" "); ok {
+			if strings.HasPrefix(rest, "OK") {
+				return lines, nil
+			}
+			return nil, fmt.Errorf("imap: %s", rest)
+		}
+		lines = append(lines, line)
+	}
+}
+
+func (c *imapClient) readLine() (imapLine, error) {
+	var line imapLine
+	for {
+		s, err := c.r.ReadString('\n')
+		if err != nil {
+			return imapLine{}, err
+		}
+		s = strings.TrimRight(s, "\r\n")
+		line.text += s
+		n, ok := literalSize(s)
+		if !ok {
+			return line, nil
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return imapLine{}, err
+		}
+		line.literal = append(line.literal, buf...)
+	}
+}
+
+// literalSize parses a trailing literal marker such as {123}.
+func literalSize(s string) (int, bool) {
+	if !strings.HasSuffix(s, "}") {
+		return 0, false
+	}
+	open := strings.LastIndexByte(s, '{')
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[open+1 : len(s)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func (c *imapClient) Login(username, password string) error {
+	_, err := c.Command("LOGIN %s %s", imapQuote(username), imapQuote(password))
+	return err
+}
+
+func (c *imapClient) Select(mailbox string) error {
+	_, err := c.Command("SELECT %s", imapQuote(mailbox))
+	return err
+}
+
+// SearchUnseen returns the UIDs of unseen messages, limited to those
+// addressed to to when it is set.
+func (c *imapClient) SearchUnseen(to string) ([]uint32, error) {
+	criteria := "UNSEEN"
+	if to != "" {
+		criteria += " TO " + imapQuote(to)
+	}
+	lines, err := c.Command("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, line := range lines {
+		rest, ok := strings.CutPrefix(line.text, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, field := range strings.Fields(rest) {
+			if uid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// Fetch returns the raw RFC 5322 message without setting \Seen.
+func (c *imapClient) Fetch(uid uint32) ([]byte, error) {
+	lines, err := c.Command("UID FETCH %d (BODY.PEEK[])", uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if strings.Contains(line.text, " FETCH ") && line.literal != nil {
+			return line.literal, nil
+		}
+	}
+	return nil, fmt.Errorf("imap: message %d not found", uid)
+}
+
+func (c *imapClient) MarkSeen(uid uint32) error {
+	_, err := c.Command("UID STORE %d +FLAGS.SILENT (\\Seen)", uid)
+	return err
+}
+
+func (c *imapClient) Logout() error {
+	_, err := c.Command("LOGOUT")
+	return err
+}
+
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,217 @@
+package adapter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PausableAdapter is implemented by polling input adapters that can stop
+// fetching new events while the runtime is overloaded. Adapters that do not
+// implement it are treated as push adapters.
+type PausableAdapter interface {
+	Pause()
+	Resume()
+}
+
+// OverloadPolicy controls when polling adapters are paused. Load is the
+// fullest ingress queue as a fraction of its capacity.
+type OverloadPolicy struct {
+	HighWaterMark float64
+	LowWaterMark  float64
+	CheckInterval time.Duration
+	// BusyMessage is sent to push adapter sessions whose message was queued
+	// while overloaded. Empty disables the reply.
+	BusyMessage string
+}
+
+// LoadFunc reports current queue utilisation in [0,1].
+type LoadFunc func() float64
+
+// WatchLoad samples load every policy.CheckInterval until ctx is done,
+// pausing polling adapters above the high-water mark and resuming them once
+// load falls to the low-water mark.
+func (m *RuntimeManager) WatchLoad(ctx context.Context, load LoadFunc, policy OverloadPolicy) {
+	if load == nil || policy.HighWaterMark <= 0 {
+		return
+	}
+	if policy.LowWaterMark <= 0 || policy.LowWaterMark > policy.HighWaterMark {
+		policy.LowWaterMark = policy.HighWaterMark
+	}
+	if policy.CheckInterval <= 0 {
+		policy.CheckInterval = time.Second
+	}
+
+	m.mu.Lock()
+	m.overloadPolicy = policy
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(policy.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.setOverloaded(false)
+				return
+			case <-ticker.C:
+				m.evaluateLoad(load())
+			}
+		}
+	}()
+}
+
+// Overloaded reports whether polling adapters are currently paused for load.
+func (m *RuntimeManager) Overloaded() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.overloaded
+}
+
+func (m *RuntimeManager) evaluateLoad(usage float64) {
+	m.mu.RLock()
+	policy := m.overloadPolicy
+	overloaded := m.overloaded
+	m.mu.RUnlock()
+
+	switch {
+	case !overloaded && usage >= policy.HighWaterMark:
+		slog.Warn("Ingress overloaded, pausing polling adapters", "usage", usage, "high_water_mark", policy.HighWaterMark)
+		m.setOverloaded(true)
+	case overloaded && usage <= policy.LowWaterMark:
+		slog.Info("Ingress drained, resuming polling adapters", "usage", usage, "low_water_mark", policy.LowWaterMark)
+		m.setOverloaded(false)
+	}
+}
+
+func (m *RuntimeManager) setOverloaded(overloaded bool) {
+	m.mu.Lock()
+	if m.overloaded == overloaded {
+		m.mu.Unlock()
+		return
+	}
+	m.overloaded = overloaded
+	m.busyNotified = make(map[string]struct{})
+	inputs := make([]InputAdapter, len(m.inputs))
+	copy(inputs, m.inputs)
+	m.mu.Unlock()
+
+	for _, input := range inputs {
+		pausable, ok := input.(PausableAdapter)
+		if !ok {
+			continue
+		}
+		if overloaded {
+			pausable.Pause()
+			m.updateStatus(input.Name(), func(st *Status) { st.Paused = true })
+		} else {
+			pausable.Resume()
+			m.updateStatus(input.Name(), func(st *Status) { st.Paused = false })
+		}
+	}
+}
+
+// handleEvent forwards adapter events to the runtime and, while overloaded,
+// tells push adapter users their message is queued. Each session is told
+// once per overload episode.
+func (m *RuntimeManager) handleEvent(ctx context.Context, source string, eventType string, sessionID string, content string, metadata map[string]string) error {
+	if m.eventHandler == nil {
+		return nil
+	}
+	if err := m.eventHandler(ctx, source, eventType, sessionID, content, metadata); err != nil {
+		return err
+	}
+	if eventType == "user_message" {
+		m.replyBusy(ctx, source, sessionID)
+	}
+	return nil
+}
+
+func (m *RuntimeManager) replyBusy(ctx context.Context, source string, sessionID string) {
+	m.mu.Lock()
+	message := m.overloadPolicy.BusyMessage
+	if !m.overloaded || message == "" {
+		m.mu.Unlock()
+		return
+	}
+	var output OutputAdapter
+	for _, input := range m.inputs {
+		if input.Name() != source {
+			continue
+		}
+		if _, pausable := input.(PausableAdapter); pausable {
+			m.mu.Unlock()
+			return
+		}
+	}
+	for _, candidate := range m.outputs {
+		if candidate.Name() == source {
+			output = candidate
+			break
+		}
+	}
+	key := source + ":" + sessionID
+	if _, done := m.busyNotified[key]; done || output == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.busyNotified[key] = struct{}{}
+	m.mu.Unlock()
+
+	if err := output.Send(ctx, sessionID, message); err != nil {
+		slog.Warn("Failed to send busy reply", "adapter", source, "session", sessionID, "error", err)
+	}
+}
+
+// pauseGate blocks a polling loop while paused.
+type pauseGate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+func (g *pauseGate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return
+	}
+	g.paused = true
+	g.resume = make(chan struct{})
+}
+
+func (g *pauseGate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return
+	}
+	g.paused = false
+	close(g.resume)
+}
+
+func (g *pauseGate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// Wait returns immediately unless paused, in which case it blocks until
+// Resume or ctx is done.
+func (g *pauseGate) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.paused {
+		g.mu.Unlock()
+		return nil
+	}
+	resume := g.resume
+	g.mu.Unlock()
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
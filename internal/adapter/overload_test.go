@@ -0,0 +1,128 @@
+package adapter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type pollingInputAdapter struct {
+	flakyInputAdapter
+	mu     sync.Mutex
+	paused bool
+}
+
+func (a *pollingInputAdapter) Name() string { return "poller" }
+func (a *pollingInputAdapter) Pause()       { a.mu.Lock(); a.paused = true; a.mu.Unlock() }
+func (a *pollingInputAdapter) Resume()      { a.mu.Lock(); a.paused = false; a.mu.Unlock() }
+func (a *pollingInputAdapter) isPaused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.paused
+}
+
+type pushAdapter struct {
+	flakyInputAdapter
+	mu   sync.Mutex
+	sent []string
+}
+
+func (a *pushAdapter) Name() string { return "push" }
+func (a *pushAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent = append(a.sent, sessionID+":"+content)
+	return nil
+}
+
+func newOverloadTestManager(inputs []InputAdapter, outputs []OutputAdapter) *RuntimeManager {
+	m := &RuntimeManager{
+		inputs:   inputs,
+		outputs:  outputs,
+		statuses: make(map[string]*Status),
+		eventHandler: func(ctx context.Context, source string, eventType string, sessionID string, content string, metadata map[string]string) error {
+			return nil
+		},
+		overloadPolicy: OverloadPolicy{HighWaterMark: 0.8, LowWaterMark: 0.5, BusyMessage: "busy, queued"},
+	}
+	for _, input := range inputs {
+		m.statuses[input.Name()] = &Status{Name: input.Name(), State: StateConnected}
+	}
+	return m
+}
+
+func TestRuntimeManager_EvaluateLoadPausesWithHysteresis(t *testing.T) {
+	poller := &pollingInputAdapter{}
+	m := newOverloadTestManager([]InputAdapter{poller}, nil)
+
+	m.evaluateLoad(0.9)
+	if !poller.isPaused() || !m.Overloaded() {
+		t.Fatal("expected polling adapter to pause above high-water mark")
+	}
+	if st := m.Statuses()[0]; !st.Paused {
+		t.Fatalf("expected paused status, got %+v", st)
+	}
+
+	m.evaluateLoad(0.6)
+	if !poller.isPaused() {
+		t.Fatal("expected adapter to stay paused between water marks")
+	}
+
+	m.evaluateLoad(0.5)
+	if poller.isPaused() || m.Overloaded() {
+		t.Fatal("expected adapter to resume at low-water mark")
+	}
+}
+
+func TestRuntimeManager_BusyReplyOncePerSession(t *testing.T) {
+	push := &pushAdapter{}
+	poller := &pollingInputAdapter{}
+	m := newOverloadTestManager([]InputAdapter{push, poller}, []OutputAdapter{push})
+	ctx := context.Background()
+
+	if err := m.handleEvent(ctx, "push", "user_message", "c1", "hi", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(push.sent) != 0 {
+		t.Fatalf("expected no busy reply while healthy, got %v", push.sent)
+	}
+
+	m.evaluateLoad(1)
+	for i := 0; i < 2; i++ {
+		if err := m.handleEvent(ctx, "push", "user_message", "c1", "hi", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = m.handleEvent(ctx, "push", "user_message", "c2", "hi", nil)
+	_ = m.handleEvent(ctx, "poller", "user_message", "c3", "hi", nil)
+
+	want := []string{"c1:busy, queued", "c2:busy, queued"}
+	if len(push.sent) != len(want) || push.sent[0] != want[0] || push.sent[1] != want[1] {
+		t.Fatalf("busy replies = %v, want %v", push.sent, want)
+	}
+}
+
+func TestPauseGate_WaitBlocksUntilResume(t *testing.T) {
+	var gate pauseGate
+	gate.Pause()
+
+	done := make(chan struct{})
+	go func() {
+		_ = gate.Wait(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Wait to block while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	gate.Resume()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Wait to return after Resume")
+	}
+}
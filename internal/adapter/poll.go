@@ -0,0 +1,52 @@
+package adapter
+
+import "time"
+
+// initialIdlePollPause is the first pause between polls once a pull adapter
+// goes idle; it doubles on every empty poll after that.
+const initialIdlePollPause = time.Second
+
+// PollPolicy adapts how often a pull adapter polls. While a conversation is
+// active it polls back to back, so replies are picked up without delay. Once
+// nothing has arrived for ActiveWindow, the pause between polls doubles up to
+// MaxIdleInterval to save API calls. A zero MaxIdleInterval always polls back
+// to back.
+type PollPolicy struct {
+	ActiveWindow    time.Duration
+	MaxIdleInterval time.Duration
+}
+
+type pollSchedule struct {
+	policy       PollPolicy
+	lastActivity time.Time
+	pause        time.Duration
+}
+
+func newPollSchedule(policy PollPolicy, now time.Time) *pollSchedule {
+	return &pollSchedule{policy: policy, lastActivity: now}
+}
+
+// Observe records the result of one poll.
+func (s *pollSchedule) Observe(updates int, now time.Time) {
+	if updates > 0 {
+		s.lastActivity = now
+		s.pause = 0
+	}
+}
+
+// Next returns how long to wait before the next poll.
+func (s *pollSchedule) Next(now time.Time) time.Duration {
+	if s.policy.MaxIdleInterval <= 0 || now.Sub(s.lastActivity) < s.policy.ActiveWindow {
+		s.pause = 0
+		return 0
+	}
+	if s.pause == 0 {
+		s.pause = initialIdlePollPause
+	} else {
+		s.pause *= 2
+	}
+	if s.pause > s.policy.MaxIdleInterval {
+		s.pause = s.policy.MaxIdleInterval
+	}
+	return s.pause
+}
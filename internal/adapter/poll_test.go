@@ -0,0 +1,41 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollSchedule_BacksOffWhenIdle(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newPollSchedule(PollPolicy{ActiveWindow: time.Minute, MaxIdleInterval: 5 * time.Second}, start)
+
+	if got := s.Next(start.Add(30 * time.Second)); got != 0 {
+		t.Fatalf("active pause = %v, want 0", got)
+	}
+
+	idle := start.Add(2 * time.Minute)
+	var pauses []time.Duration
+	for i := 0; i < 5; i++ {
+		s.Observe(0, idle)
+		pauses = append(pauses, s.Next(idle))
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range want {
+		if pauses[i] != want[i] {
+			t.Fatalf("idle pauses = %v, want %v", pauses, want)
+		}
+	}
+
+	s.Observe(1, idle)
+	if got := s.Next(idle.Add(time.Second)); got != 0 {
+		t.Fatalf("pause after activity = %v, want 0", got)
+	}
+}
+
+func TestPollSchedule_ZeroMaxIdleIntervalNeverPauses(t *testing.T) {
+	start := time.Now()
+	s := newPollSchedule(PollPolicy{ActiveWindow: time.Second}, start)
+	if got := s.Next(start.Add(time.Hour)); got != 0 {
+		t.Fatalf("pause = %v, want 0", got)
+	}
+}
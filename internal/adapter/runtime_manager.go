@@ -5,37 +5,127 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/errors"
 )
 
 type RuntimeAdapterOptions struct {
 	IncludeCLI          bool
 	IncludeSystemNull   bool
 	RequireSlackSecrets bool
+	// AttachmentsDir keeps files received by chat adapters, such as voice
+	// notes for adapters.transcription.
+	AttachmentsDir string
 }
 
 type RuntimeManager struct {
-	mu      sync.RWMutex
-	inputs  []InputAdapter
-	outputs []OutputAdapter
-	started bool
+	mu           sync.RWMutex
+	inputs       []InputAdapter
+	outputs      []OutputAdapter
+	statuses     map[string]*Status
+	policy       ReconnectPolicy
+	eventHandler EventHandler
+	started      bool
+
+	cfg      config.AdaptersConfig
+	opts     RuntimeAdapterOptions
+	runCtx   context.Context
+	running  map[string]supervisedInput
+	registry OutputRegistry
+	// hotMu serializes Enable, Disable and Reload.
+	hotMu sync.Mutex
+
+	overloadPolicy OverloadPolicy
+	overloaded     bool
+	busyNotified   map[string]struct{}
+}
+
+// OutputRegistry receives output adapters added or removed at runtime, so
+// replies reach an adapter enabled after startup. egress.Egress satisfies it.
+type OutputRegistry interface {
+	Register(adapter OutputAdapter) error
+	Unregister(name string) error
+}
+
+// hotAdapters lists the adapters that can be enabled, disabled and reloaded
+// while the runtime runs, in start order.
+var hotAdapters = []string{"slack", "telegram", "discord", "teams", "email", "webhook"}
+
+// duplexAdapter is a platform adapter that both receives and sends.
+type duplexAdapter interface {
+	InputAdapter
+	OutputAdapter
+}
+
+type supervisedInput struct {
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 func NewRuntimeManager(cfg config.AdaptersConfig, eventHandler EventHandler, opts RuntimeAdapterOptions) (*RuntimeManager, error) {
-	m := &RuntimeManager{}
+	policy, err := reconnectPolicyFromConfig(cfg.Reconnect)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &RuntimeManager{
+		statuses:     make(map[string]*Status),
+		policy:       policy,
+		eventHandler: eventHandler,
+		cfg:          cfg,
+		opts:         opts,
+	}
 
 	if opts.IncludeCLI {
 		m.outputs = append(m.outputs, NewCLIAdapter())
 	}
 	if opts.IncludeSystemNull {
-		m.outputs = append(m.outputs, NewNullAdapter("scheduler"), NewNullAdapter("system"))
+		// Batch goals are read back from their transcripts, so replies are dropped.
+		m.outputs = append(m.outputs, NewNullAdapter("scheduler"), NewNullAdapter("system"), NewNullAdapter("batch"))
 	}
 
-	if cfg.Slack.Enabled {
-		if opts.RequireSlackSecrets {
+	for _, name := range hotAdapters {
+		if !adapterEnabled(cfg, name) {
+			continue
+		}
+		platform, err := m.newHotAdapter(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		m.inputs = append(m.inputs, platform)
+		m.outputs = append(m.outputs, platform)
+	}
+
+	if cfg.Desktop.Enabled {
+		// Desktop notifications also take over the background "scheduler" and
+		// "system" outputs so background completions surface on the workstation.
+		m.outputs = append(m.outputs,
+			NewDesktopAdapter("desktop", cfg.Desktop.Title, cfg.Desktop.Command),
+			NewDesktopAdapter("scheduler", cfg.Desktop.Title, cfg.Desktop.Command),
+			NewDesktopAdapter("system", cfg.Desktop.Title, cfg.Desktop.Command),
+		)
+	}
+
+	m.outputs = dedupeOutputAdapters(m.outputs)
+	for _, input := range m.inputs {
+		m.statuses[input.Name()] = &Status{Name: input.Name(), State: StateStopped}
+	}
+	return m, nil
+}
+
+// newHotAdapter builds the named adapter from cfg, whether or not cfg
+// enables it.
+func (m *RuntimeManager) newHotAdapter(cfg config.AdaptersConfig, name string) (duplexAdapter, error) {
+	switch name {
+	case "slack":
+		if m.opts.RequireSlackSecrets {
 			if strings.TrimSpace(cfg.Slack.SigningSecret) == "" && strings.TrimSpace(os.Getenv("SLACK_SIGNING_SECRET")) == "" {
 				return nil, fmt.Errorf("adapters.slack.signing_secret is required when slack adapter is enabled")
 			}
@@ -43,25 +133,312 @@ func NewRuntimeManager(cfg config.AdaptersConfig, eventHandler EventHandler, opt
 		if strings.TrimSpace(cfg.Slack.BotToken) == "" && strings.TrimSpace(os.Getenv("SLACK_BOT_TOKEN")) == "" {
 			return nil, fmt.Errorf("adapters.slack.bot_token is required when slack adapter is enabled")
 		}
+		format, err := formatProfileFromConfig("slack", cfg.Slack.Format, slackDefaultFormat)
+		if err != nil {
+			return nil, err
+		}
+		voice, err := voiceNotesFromConfig(cfg.Transcription, m.opts.AttachmentsDir)
+		if err != nil {
+			return nil, err
+		}
+		slackAdapter := NewSlackAdapter(cfg.Slack.Port, cfg.Slack.SigningSecret, cfg.Slack.BotToken, m.handleEvent)
+		slackAdapter.format = format
+		slackAdapter.voice = voice
+		return slackAdapter, nil
 
-		slackAdapter := NewSlackAdapter(cfg.Slack.Port, cfg.Slack.SigningSecret, cfg.Slack.BotToken, eventHandler)
-		m.inputs = append(m.inputs, slackAdapter)
-		m.outputs = append(m.outputs, slackAdapter)
-	}
-
-	if cfg.Telegram.Enabled {
+	case "telegram":
 		token := strings.TrimSpace(cfg.Telegram.BotToken)
 		if token == "" {
 			return nil, fmt.Errorf("adapters.telegram.bot_token is required when telegram adapter is enabled")
 		}
+		opts, err := telegramOptionsFromConfig(cfg.Telegram)
+		if err != nil {
+			return nil, err
+		}
+		voice, err := voiceNotesFromConfig(cfg.Transcription, m.opts.AttachmentsDir)
+		if err != nil {
+			return nil, err
+		}
+		telegramAdapter := NewTelegramAdapter(token, m.handleEvent, opts)
+		telegramAdapter.voice = voice
+		return telegramAdapter, nil
+
+	case "discord":
+		token := strings.TrimSpace(cfg.Discord.BotToken)
+		if token == "" {
+			token = strings.TrimSpace(os.Getenv("DISCORD_BOT_TOKEN"))
+		}
+		if token == "" {
+			return nil, fmt.Errorf("adapters.discord.bot_token is required when discord adapter is enabled")
+		}
+		format, err := formatProfileFromConfig("discord", cfg.Discord.Format, discordDefaultFormat)
+		if err != nil {
+			return nil, err
+		}
+		return NewDiscordAdapter(token, m.handleEvent, DiscordOptions{
+			Guilds:        cfg.Discord.Guilds,
+			Channels:      cfg.Discord.Channels,
+			SlashCommands: cfg.Discord.SlashCommands,
+			Format:        format,
+		}), nil
+
+	case "teams":
+		opts, err := teamsOptionsFromConfig(cfg.Teams)
+		if err != nil {
+			return nil, err
+		}
+		return NewTeamsAdapter(m.handleEvent, opts), nil
+
+	case "email":
+		opts, err := emailOptionsFromConfig(cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+		return NewEmailAdapter(m.handleEvent, opts), nil
 
-		telegramAdapter := NewTelegramAdapter(token, eventHandler, cfg.Telegram.UpdateTimeout)
-		m.inputs = append(m.inputs, telegramAdapter)
-		m.outputs = append(m.outputs, telegramAdapter)
+	case "webhook":
+		routes, err := webhookRoutesFromConfig(cfg.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		port := cfg.Webhook.Port
+		if port <= 0 {
+			port = config.DefaultWebhookPort
+		}
+		return NewWebhookAdapter(port, routes, m.handleEvent)
 	}
+	return nil, errors.NotFound("adapter " + name)
+}
 
-	m.outputs = dedupeOutputAdapters(m.outputs)
-	return m, nil
+func adapterEnabled(cfg config.AdaptersConfig, name string) bool {
+	switch name {
+	case "slack":
+		return cfg.Slack.Enabled
+	case "telegram":
+		return cfg.Telegram.Enabled
+	case "discord":
+		return cfg.Discord.Enabled
+	case "teams":
+		return cfg.Teams.Enabled
+	case "email":
+		return cfg.Email.Enabled
+	case "webhook":
+		return cfg.Webhook.Enabled
+	}
+	return false
+}
+
+// adapterSection returns the config section of the named adapter, for
+// detecting changes on reload. Slack and Telegram also depend on
+// adapters.transcription.
+func adapterSection(cfg config.AdaptersConfig, name string) interface{} {
+	switch name {
+	case "slack":
+		return []interface{}{cfg.Slack, cfg.Transcription}
+	case "telegram":
+		return []interface{}{cfg.Telegram, cfg.Transcription}
+	case "discord":
+		return cfg.Discord
+	case "teams":
+		return cfg.Teams
+	case "email":
+		return cfg.Email
+	case "webhook":
+		return cfg.Webhook
+	}
+	return nil
+}
+
+func isHotAdapter(name string) bool {
+	for _, hot := range hotAdapters {
+		if hot == name {
+			return true
+		}
+	}
+	return false
+}
+
+func telegramOptionsFromConfig(cfg config.TelegramConfig) (TelegramOptions, error) {
+	opts := TelegramOptions{
+		UpdateTimeout: cfg.UpdateTimeout,
+		Mode:          strings.ToLower(strings.TrimSpace(cfg.Mode)),
+		Webhook: TelegramWebhook{
+			URL:         strings.TrimSpace(cfg.Webhook.URL),
+			Port:        cfg.Webhook.Port,
+			SecretToken: strings.TrimSpace(cfg.Webhook.SecretToken),
+		},
+	}
+	switch opts.Mode {
+	case "", TelegramModePolling:
+		opts.Mode = TelegramModePolling
+	case TelegramModeWebhook:
+		if opts.Webhook.URL == "" {
+			return TelegramOptions{}, fmt.Errorf("adapters.telegram.webhook.url is required in webhook mode")
+		}
+		if opts.Webhook.SecretToken == "" {
+			return TelegramOptions{}, fmt.Errorf("adapters.telegram.webhook.secret_token is required in webhook mode")
+		}
+		if opts.Webhook.Port <= 0 {
+			opts.Webhook.Port = config.DefaultTelegramWebhookPort
+		}
+	default:
+		return TelegramOptions{}, fmt.Errorf("adapters.telegram.mode must be %q or %q", TelegramModePolling, TelegramModeWebhook)
+	}
+
+	var err error
+	opts.Poll.ActiveWindow, err = config.DurationOrDefault(cfg.Poll.ActiveWindow, config.DefaultPollActiveWindow)
+	if err != nil {
+		return TelegramOptions{}, fmt.Errorf("parse adapters.telegram.poll.active_window: %w", err)
+	}
+	opts.Poll.MaxIdleInterval, err = config.DurationOrDefault(cfg.Poll.MaxIdleInterval, config.DefaultPollMaxIdleInterval)
+	if err != nil {
+		return TelegramOptions{}, fmt.Errorf("parse adapters.telegram.poll.max_idle_interval: %w", err)
+	}
+	opts.Format, err = formatProfileFromConfig("telegram", cfg.Format, telegramDefaultFormat)
+	if err != nil {
+		return TelegramOptions{}, err
+	}
+	return opts, nil
+}
+
+func teamsOptionsFromConfig(cfg config.TeamsConfig) (TeamsOptions, error) {
+	opts := TeamsOptions{
+		AppID:       strings.TrimSpace(cfg.AppID),
+		AppPassword: cfg.AppPassword,
+		TenantID:    strings.TrimSpace(cfg.TenantID),
+		Port:        cfg.Port,
+	}
+	if opts.AppID == "" {
+		opts.AppID = strings.TrimSpace(os.Getenv("MICROSOFT_APP_ID"))
+	}
+	if strings.TrimSpace(opts.AppPassword) == "" {
+		opts.AppPassword = os.Getenv("MICROSOFT_APP_PASSWORD")
+	}
+	if opts.AppID == "" {
+		return TeamsOptions{}, fmt.Errorf("adapters.teams.app_id is required when teams adapter is enabled")
+	}
+	if strings.TrimSpace(opts.AppPassword) == "" {
+		return TeamsOptions{}, fmt.Errorf("adapters.teams.app_password is required when teams adapter is enabled")
+	}
+	if opts.Port <= 0 {
+		opts.Port = config.DefaultTeamsPort
+	}
+	format, err := formatProfileFromConfig("teams", cfg.Format, teamsDefaultFormat)
+	if err != nil {
+		return TeamsOptions{}, err
+	}
+	opts.Format = format
+	return opts, nil
+}
+
+func emailOptionsFromConfig(cfg config.EmailConfig) (EmailOptions, error) {
+	opts := EmailOptions{
+		Address: strings.TrimSpace(cfg.Address),
+		Mailbox: strings.TrimSpace(cfg.Mailbox),
+		IMAP: EmailServer{
+			Host:     strings.TrimSpace(cfg.IMAP.Host),
+			Port:     cfg.IMAP.Port,
+			Username: strings.TrimSpace(cfg.IMAP.Username),
+			Password: cfg.IMAP.Password,
+		},
+		SMTP: EmailServer{
+			Host:     strings.TrimSpace(cfg.SMTP.Host),
+			Port:     cfg.SMTP.Port,
+			Username: strings.TrimSpace(cfg.SMTP.Username),
+			Password: cfg.SMTP.Password,
+		},
+	}
+	for _, sender := range cfg.AllowedSenders {
+		if sender = strings.TrimSpace(sender); sender != "" {
+			opts.AllowedSenders = append(opts.AllowedSenders, sender)
+		}
+	}
+	if opts.Address == "" {
+		return EmailOptions{}, fmt.Errorf("adapters.email.address is required when email adapter is enabled")
+	}
+	if len(opts.AllowedSenders) == 0 {
+		return EmailOptions{}, fmt.Errorf("adapters.email.allowed_senders is required when email adapter is enabled")
+	}
+	if opts.IMAP.Host == "" {
+		return EmailOptions{}, fmt.Errorf("adapters.email.imap.host is required when email adapter is enabled")
+	}
+	if opts.SMTP.Host == "" {
+		return EmailOptions{}, fmt.Errorf("adapters.email.smtp.host is required when email adapter is enabled")
+	}
+
+	var err error
+	opts.PollInterval, err = config.DurationOrDefault(cfg.PollInterval, config.DefaultEmailPollInterval)
+	if err != nil {
+		return EmailOptions{}, fmt.Errorf("parse adapters.email.poll_interval: %w", err)
+	}
+	if opts.PollInterval <= 0 {
+		return EmailOptions{}, fmt.Errorf("adapters.email.poll_interval must be positive")
+	}
+	return opts, nil
+}
+
+func webhookRoutesFromConfig(cfg config.WebhookConfig) ([]WebhookRoute, error) {
+	if len(cfg.Routes) == 0 {
+		return nil, fmt.Errorf("adapters.webhook.routes is required when webhook adapter is enabled")
+	}
+	names := make(map[string]struct{}, len(cfg.Routes))
+	paths := make(map[string]struct{}, len(cfg.Routes))
+	routes := make([]WebhookRoute, 0, len(cfg.Routes))
+	for i, rc := range cfg.Routes {
+		route := WebhookRoute{
+			Name:            strings.TrimSpace(rc.Name),
+			Path:            strings.TrimSpace(rc.Path),
+			Secret:          strings.TrimSpace(rc.Secret),
+			SignatureHeader: strings.TrimSpace(rc.SignatureHeader),
+			Session:         rc.Session,
+			Template:        rc.Template,
+		}
+		if route.Name == "" {
+			return nil, fmt.Errorf("adapters.webhook.routes[%d].name is required", i)
+		}
+		if route.Path == "" {
+			route.Path = "/webhooks/" + route.Name
+		}
+		if !strings.HasPrefix(route.Path, "/") {
+			return nil, fmt.Errorf("adapters.webhook.routes[%d].path must start with /", i)
+		}
+		if route.Secret == "" {
+			return nil, fmt.Errorf("adapters.webhook.routes[%d].secret is required", i)
+		}
+		if _, dup := names[route.Name]; dup {
+			return nil, fmt.Errorf("adapters.webhook.routes: duplicate name %q", route.Name)
+		}
+		if _, dup := paths[route.Path]; dup {
+			return nil, fmt.Errorf("adapters.webhook.routes: duplicate path %q", route.Path)
+		}
+		names[route.Name] = struct{}{}
+		paths[route.Path] = struct{}{}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func reconnectPolicyFromConfig(cfg config.AdapterReconnectConfig) (ReconnectPolicy, error) {
+	initial, err := config.DurationOrDefault(cfg.InitialBackoff, config.DefaultAdapterReconnectInitialBackoff)
+	if err != nil {
+		return ReconnectPolicy{}, fmt.Errorf("parse adapters.reconnect.initial_backoff: %w", err)
+	}
+	maxBackoff, err := config.DurationOrDefault(cfg.MaxBackoff, config.DefaultAdapterReconnectMaxBackoff)
+	if err != nil {
+		return ReconnectPolicy{}, fmt.Errorf("parse adapters.reconnect.max_backoff: %w", err)
+	}
+	if maxBackoff < initial {
+		return ReconnectPolicy{}, fmt.Errorf("adapters.reconnect.max_backoff must be >= initial_backoff")
+	}
+	threshold := cfg.CircuitThreshold
+	if threshold <= 0 {
+		threshold = config.DefaultAdapterReconnectCircuitThresh
+	}
+	return ReconnectPolicy{
+		InitialBackoff:   initial,
+		MaxBackoff:       maxBackoff,
+		CircuitThreshold: threshold,
+	}, nil
 }
 
 func (m *RuntimeManager) OutputAdapters() []OutputAdapter {
@@ -72,6 +449,18 @@ func (m *RuntimeManager) OutputAdapters() []OutputAdapter {
 	return out
 }
 
+// OutputAdapter returns the output adapter registered under name.
+func (m *RuntimeManager) OutputAdapter(name string) (OutputAdapter, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, output := range m.outputs {
+		if output.Name() == name {
+			return output, true
+		}
+	}
+	return nil, false
+}
+
 func (m *RuntimeManager) Start(ctx context.Context) {
 	m.mu.Lock()
 	if m.started {
@@ -79,18 +468,151 @@ func (m *RuntimeManager) Start(ctx context.Context) {
 		return
 	}
 	m.started = true
-	inputs := make([]InputAdapter, len(m.inputs))
-	copy(inputs, m.inputs)
+	m.runCtx = ctx
+	for _, input := range m.inputs {
+		m.startInputLocked(input)
+	}
 	m.mu.Unlock()
+}
 
-	for _, input := range inputs {
-		adapter := input
-		go func() {
-			slog.Info("Starting input adapter", "adapter", adapter.Name())
-			if err := adapter.Start(ctx); err != nil && ctx.Err() == nil {
-				slog.Error("Input adapter stopped with error", "adapter", adapter.Name(), "error", err)
+// startInputLocked supervises input under its own context so Disable can
+// stop it alone. The caller holds m.mu.
+func (m *RuntimeManager) startInputLocked(input InputAdapter) {
+	if m.running == nil {
+		m.running = make(map[string]supervisedInput)
+	}
+	ctx, cancel := context.WithCancel(m.runCtx)
+	run := supervisedInput{cancel: cancel, done: make(chan struct{})}
+	m.running[input.Name()] = run
+	go func() {
+		defer close(run.done)
+		m.supervise(ctx, input)
+	}()
+}
+
+// supervise starts an input adapter and restarts it with exponential backoff
+// whenever Start fails. Adapters whose Start returns nil own their run loop.
+func (m *RuntimeManager) supervise(ctx context.Context, input InputAdapter) {
+	name := input.Name()
+	attempts := 0
+	for {
+		m.updateStatus(name, func(st *Status) {
+			if st.State != StateCircuitOpen {
+				st.State = StateConnecting
 			}
-		}()
+		})
+
+		slog.Info("Starting input adapter", "adapter", name, "attempt", attempts+1)
+		err := input.Start(ctx)
+		if ctx.Err() != nil {
+			m.updateStatus(name, func(st *Status) { st.State = StateStopped })
+			return
+		}
+		if err == nil {
+			m.markConnected(name)
+			return
+		}
+
+		attempts++
+		opened := m.recordFailure(name, err, attempts)
+		slog.Error("Input adapter stopped with error", "adapter", name, "attempt", attempts, "error", err)
+		if opened {
+			m.notifyCircuitOpen(ctx, name, attempts, err)
+		}
+
+		m.mu.RLock()
+		backoff := m.policy.Backoff(attempts)
+		m.mu.RUnlock()
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			m.updateStatus(name, func(st *Status) { st.State = StateStopped })
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Statuses returns a snapshot of all input adapter connection states sorted by name.
+func (m *RuntimeManager) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]Status, 0, len(m.statuses))
+	for _, st := range m.statuses {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (m *RuntimeManager) updateStatus(name string, fn func(st *Status)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.statuses[name]
+	if !ok {
+		st = &Status{Name: name}
+		m.statuses[name] = st
+	}
+	fn(st)
+}
+
+func (m *RuntimeManager) markConnected(name string) {
+	m.updateStatus(name, func(st *Status) {
+		if st.State != StateConnected {
+			st.ConnectedAt = time.Now()
+		}
+		if st.State == StateCircuitOpen {
+			slog.Info("Input adapter recovered, closing circuit", "adapter", name)
+		}
+		st.State = StateConnected
+		st.ReconnectAttempts = 0
+	})
+}
+
+// markHealthy promotes an adapter to connected after a successful health probe.
+// Adapters waiting out a reconnect backoff are left to the supervisor.
+func (m *RuntimeManager) markHealthy(name string) {
+	m.mu.RLock()
+	st, ok := m.statuses[name]
+	promote := ok && st.ReconnectAttempts == 0 && (st.State == StateConnecting || st.State == StateDisconnected)
+	m.mu.RUnlock()
+	if promote {
+		m.markConnected(name)
+	}
+}
+
+// recordFailure stores the failure and reports whether this failure opened the circuit.
+func (m *RuntimeManager) recordFailure(name string, err error, attempts int) bool {
+	opened := false
+	m.updateStatus(name, func(st *Status) {
+		st.LastError = err.Error()
+		st.LastErrorAt = time.Now()
+		st.ReconnectAttempts = attempts
+		if attempts >= m.policy.CircuitThreshold {
+			opened = st.State != StateCircuitOpen
+			st.State = StateCircuitOpen
+			return
+		}
+		st.State = StateDisconnected
+	})
+	return opened
+}
+
+func (m *RuntimeManager) notifyCircuitOpen(ctx context.Context, name string, attempts int, cause error) {
+	if m.eventHandler == nil {
+		return
+	}
+	content := fmt.Sprintf("Adapter %s is down after %d reconnect attempts: %v", name, attempts, cause)
+	metadata := map[string]string{
+		"adapter":            name,
+		"state":              string(StateCircuitOpen),
+		"reconnect_attempts": strconv.Itoa(attempts),
+		"last_error":         cause.Error(),
+	}
+	if err := m.eventHandler(ctx, "system", "system_event", "", content, metadata); err != nil {
+		slog.Warn("Failed to emit adapter circuit-open event", "adapter", name, "error", err)
 	}
 }
 
@@ -103,6 +625,10 @@ func (m *RuntimeManager) Stop(ctx context.Context) error {
 	m.started = false
 	inputs := make([]InputAdapter, len(m.inputs))
 	copy(inputs, m.inputs)
+	for name, run := range m.running {
+		run.cancel()
+		delete(m.running, name)
+	}
 	m.mu.Unlock()
 
 	var errs []string
@@ -117,6 +643,238 @@ func (m *RuntimeManager) Stop(ctx context.Context) error {
 	return nil
 }
 
+// SetOutputRegistry sets where Enable, Disable and Reload register and
+// unregister output adapters. Adapters built by NewRuntimeManager are
+// registered by the caller.
+func (m *RuntimeManager) SetOutputRegistry(registry OutputRegistry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.registry = registry
+}
+
+// Adapters returns Statuses plus a disabled entry for each adapter that
+// could be enabled at runtime but is not running.
+func (m *RuntimeManager) Adapters() []Status {
+	out := m.Statuses()
+	seen := make(map[string]struct{}, len(out))
+	for _, st := range out {
+		seen[st.Name] = struct{}{}
+	}
+	for _, name := range hotAdapters {
+		if _, ok := seen[name]; !ok {
+			out = append(out, Status{Name: name, State: StateDisabled})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Enable builds the named adapter from the current adapters config and, if
+// the manager is started, starts it. The adapter's enabled flag is ignored,
+// so an operator can turn on an adapter that is configured but off.
+func (m *RuntimeManager) Enable(name string) error {
+	if !isHotAdapter(name) {
+		return errors.NotFound("adapter " + name)
+	}
+	m.hotMu.Lock()
+	defer m.hotMu.Unlock()
+
+	m.mu.RLock()
+	cfg := m.cfg
+	_, running := m.inputLocked(name)
+	m.mu.RUnlock()
+	if running {
+		return nil
+	}
+
+	platform, err := m.newHotAdapter(cfg, name)
+	if err != nil {
+		return errors.InvalidInput(err.Error())
+	}
+	return m.add(platform)
+}
+
+// Disable stops the named adapter and removes it from the runtime until it
+// is enabled again. Disabling an adapter that is not running is a no-op.
+func (m *RuntimeManager) Disable(ctx context.Context, name string) error {
+	if !isHotAdapter(name) {
+		return errors.NotFound("adapter " + name)
+	}
+	m.hotMu.Lock()
+	defer m.hotMu.Unlock()
+	return m.remove(ctx, name)
+}
+
+// Reload applies cfg: adapters whose enabled flag or settings changed are
+// stopped and, if still enabled, rebuilt and started, e.g. to rotate a bot
+// token. Other adapters keep running. Every replacement is built before
+// anything is stopped, so an invalid config leaves the runtime unchanged.
+// Reload returns the names of the adapters it restarted or stopped.
+func (m *RuntimeManager) Reload(ctx context.Context, cfg config.AdaptersConfig) ([]string, error) {
+	policy, err := reconnectPolicyFromConfig(cfg.Reconnect)
+	if err != nil {
+		return nil, errors.InvalidInput(err.Error())
+	}
+	m.hotMu.Lock()
+	defer m.hotMu.Unlock()
+
+	m.mu.RLock()
+	current := m.cfg
+	running := make(map[string]bool, len(m.inputs))
+	for _, input := range m.inputs {
+		running[input.Name()] = true
+	}
+	m.mu.RUnlock()
+
+	type change struct {
+		name string
+		next duplexAdapter
+	}
+	var changes []change
+	for _, name := range hotAdapters {
+		enabled := adapterEnabled(cfg, name)
+		if enabled == running[name] && (!enabled || reflect.DeepEqual(adapterSection(current, name), adapterSection(cfg, name))) {
+			continue
+		}
+		c := change{name: name}
+		if enabled {
+			next, err := m.newHotAdapter(cfg, name)
+			if err != nil {
+				return nil, errors.InvalidInput(err.Error())
+			}
+			c.next = next
+		}
+		changes = append(changes, c)
+	}
+
+	m.mu.Lock()
+	m.cfg = cfg
+	m.policy = policy
+	m.mu.Unlock()
+
+	changed := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if err := m.remove(ctx, c.name); err != nil {
+			return changed, err
+		}
+		if c.next != nil {
+			if err := m.add(c.next); err != nil {
+				return changed, err
+			}
+		}
+		changed = append(changed, c.name)
+	}
+	slog.Info("Adapters reloaded", "changed", changed)
+	return changed, nil
+}
+
+// add registers platform for output and starts it if the manager runs.
+func (m *RuntimeManager) add(platform duplexAdapter) error {
+	name := platform.Name()
+	m.mu.RLock()
+	registry := m.registry
+	m.mu.RUnlock()
+	if registry != nil {
+		err := registry.Register(platform)
+		if errors.IsCategory(err, errors.ErrConflict) {
+			// A stale registration from before the adapter was disabled.
+			_ = registry.Unregister(name)
+			err = registry.Register(platform)
+		}
+		if err != nil {
+			return fmt.Errorf("register output adapter %s: %w", name, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inputs = append(m.inputs, platform)
+	m.outputs = dedupeOutputAdapters(append(m.outputs, platform))
+	st := &Status{Name: name, State: StateStopped}
+	m.statuses[name] = st
+	if pausable, ok := platform.(PausableAdapter); ok && m.overloaded {
+		pausable.Pause()
+		st.Paused = true
+	}
+	if m.started {
+		m.startInputLocked(platform)
+	}
+	slog.Info("Input adapter enabled", "adapter", name, "started", m.started)
+	return nil
+}
+
+// remove stops the named adapter and waits for its supervisor to exit.
+func (m *RuntimeManager) remove(ctx context.Context, name string) error {
+	m.mu.Lock()
+	input, ok := m.inputLocked(name)
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	m.inputs = removeInput(m.inputs, name)
+	m.outputs = removeOutput(m.outputs, name)
+	run, supervised := m.running[name]
+	delete(m.running, name)
+	registry := m.registry
+	m.mu.Unlock()
+
+	if registry != nil {
+		if err := registry.Unregister(name); err != nil && !errors.IsCategory(err, errors.ErrNotFound) {
+			slog.Warn("Failed to unregister output adapter", "adapter", name, "error", err)
+		}
+	}
+	if supervised {
+		run.cancel()
+	}
+	stopErr := input.Stop(ctx)
+	if supervised {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// The supervisor has exited, so it cannot recreate the status.
+	m.mu.Lock()
+	delete(m.statuses, name)
+	m.mu.Unlock()
+	slog.Info("Input adapter disabled", "adapter", name)
+	if stopErr != nil {
+		return fmt.Errorf("stop adapter %s: %w", name, stopErr)
+	}
+	return nil
+}
+
+func (m *RuntimeManager) inputLocked(name string) (InputAdapter, bool) {
+	for _, input := range m.inputs {
+		if input.Name() == name {
+			return input, true
+		}
+	}
+	return nil, false
+}
+
+func removeInput(inputs []InputAdapter, name string) []InputAdapter {
+	out := make([]InputAdapter, 0, len(inputs))
+	for _, input := range inputs {
+		if input.Name() != name {
+			out = append(out, input)
+		}
+	}
+	return out
+}
+
+func removeOutput(outputs []OutputAdapter, name string) []OutputAdapter {
+	out := make([]OutputAdapter, 0, len(outputs))
+	for _, output := range outputs {
+		if output.Name() != name {
+			out = append(out, output)
+		}
+	}
+	return out
+}
+
 func (m *RuntimeManager) Health(ctx context.Context) error {
 	m.mu.RLock()
 	inputs := make([]InputAdapter, len(m.inputs))
@@ -125,10 +883,25 @@ func (m *RuntimeManager) Health(ctx context.Context) error {
 	copy(outputs, m.outputs)
 	m.mu.RUnlock()
 
+	var firstErr error
 	for _, input := range inputs {
 		if err := input.Health(ctx); err != nil {
-			return fmt.Errorf("input adapter %s unhealthy: %w", input.Name(), err)
+			m.updateStatus(input.Name(), func(st *Status) {
+				st.LastError = err.Error()
+				st.LastErrorAt = time.Now()
+				if st.State == StateConnected {
+					st.State = StateDisconnected
+				}
+			})
+			if firstErr == nil {
+				firstErr = fmt.Errorf("input adapter %s unhealthy: %w", input.Name(), err)
+			}
+			continue
 		}
+		m.markHealthy(input.Name())
+	}
+	if firstErr != nil {
+		return firstErr
 	}
 	for _, output := range outputs {
 		if err := output.Health(ctx); err != nil {
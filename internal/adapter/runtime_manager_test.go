@@ -0,0 +1,296 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+type flakyInputAdapter struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (a *flakyInputAdapter) Name() string { return "flaky" }
+
+func (a *flakyInputAdapter) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if a.calls <= a.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (a *flakyInputAdapter) Stop(ctx context.Context) error   { return nil }
+func (a *flakyInputAdapter) Health(ctx context.Context) error { return nil }
+
+func TestReconnectPolicy_Backoff(t *testing.T) {
+	policy := ReconnectPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	cases := map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 5 * time.Second,
+		9: 5 * time.Second,
+	}
+	for attempt, want := range cases {
+		if got := policy.Backoff(attempt); got != want {
+			t.Fatalf("Backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestRuntimeManager_SuperviseOpensCircuitAndRecovers(t *testing.T) {
+	input := &flakyInputAdapter{failures: 3}
+
+	var mu sync.Mutex
+	var events []capturedEvent
+	m := &RuntimeManager{
+		inputs:   []InputAdapter{input},
+		statuses: map[string]*Status{"flaky": {Name: "flaky", State: StateStopped}},
+		policy:   ReconnectPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, CircuitThreshold: 2},
+		eventHandler: func(ctx context.Context, source string, eventType string, sessionID string, content string, metadata map[string]string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, capturedEvent{source: source, eventType: eventType, content: content, metadata: metadata})
+			return nil
+		},
+	}
+
+	m.supervise(context.Background(), input)
+
+	statuses := m.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("statuses length = %d, want 1", len(statuses))
+	}
+	st := statuses[0]
+	if st.State != StateConnected {
+		t.Fatalf("state = %s, want %s", st.State, StateConnected)
+	}
+	if st.ReconnectAttempts != 0 {
+		t.Fatalf("reconnect attempts = %d, want 0 after recovery", st.ReconnectAttempts)
+	}
+	if st.LastError != "connection refused" {
+		t.Fatalf("last error = %q, want %q", st.LastError, "connection refused")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("circuit-open events = %d, want 1", len(events))
+	}
+	if events[0].eventType != "system_event" || events[0].source != "system" {
+		t.Fatalf("unexpected event %+v", events[0])
+	}
+	if events[0].metadata["adapter"] != "flaky" || events[0].metadata["state"] != string(StateCircuitOpen) {
+		t.Fatalf("unexpected event metadata %+v", events[0].metadata)
+	}
+}
+
+func TestRuntimeManager_SuperviseStopsOnCancel(t *testing.T) {
+	input := &flakyInputAdapter{failures: 100}
+	m := &RuntimeManager{
+		inputs:   []InputAdapter{input},
+		statuses: map[string]*Status{},
+		policy:   ReconnectPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Hour, CircuitThreshold: 5},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.supervise(ctx, input)
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervise did not return after cancel")
+	}
+
+	if got := m.Statuses()[0].State; got != StateStopped {
+		t.Fatalf("state = %s, want %s", got, StateStopped)
+	}
+}
+
+type recordingRegistry struct {
+	mu         sync.Mutex
+	registered map[string]OutputAdapter
+}
+
+func (r *recordingRegistry) Register(adapter OutputAdapter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.registered == nil {
+		r.registered = make(map[string]OutputAdapter)
+	}
+	r.registered[adapter.Name()] = adapter
+	return nil
+}
+
+func (r *recordingRegistry) Unregister(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.registered, name)
+	return nil
+}
+
+func (r *recordingRegistry) get(name string) OutputAdapter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.registered[name]
+}
+
+func adapterState(m *RuntimeManager, name string) ConnectionState {
+	for _, st := range m.Adapters() {
+		if st.Name == name {
+			return st.State
+		}
+	}
+	return ""
+}
+
+func TestRuntimeManager_EnableDisableReload(t *testing.T) {
+	m, err := NewRuntimeManager(config.AdaptersConfig{}, nil, RuntimeAdapterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &recordingRegistry{}
+	m.SetOutputRegistry(registry)
+	ctx := context.Background()
+
+	if got := len(m.Adapters()); got != len(hotAdapters) {
+		t.Fatalf("adapters = %d, want %d", got, len(hotAdapters))
+	}
+	if err := m.Enable("irc"); !heikeErrors.IsCategory(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("enable unknown adapter error = %v", err)
+	}
+	if err := m.Enable("webhook"); !heikeErrors.IsCategory(err, heikeErrors.ErrInvalidInput) {
+		t.Fatalf("enable unconfigured adapter error = %v", err)
+	}
+
+	cfg := config.AdaptersConfig{Webhook: config.WebhookConfig{
+		Enabled: true,
+		Routes:  []config.WebhookRouteConfig{{Name: "deploy", Secret: "s1"}},
+	}}
+	changed, err := m.Reload(ctx, cfg)
+	if err != nil || len(changed) != 1 || changed[0] != "webhook" {
+		t.Fatalf("reload = %v, %v", changed, err)
+	}
+	first := registry.get("webhook")
+	if first == nil || adapterState(m, "webhook") != StateStopped {
+		t.Fatalf("webhook not added: registered=%v state=%s", first, adapterState(m, "webhook"))
+	}
+	if changed, err := m.Reload(ctx, cfg); err != nil || len(changed) != 0 {
+		t.Fatalf("reload unchanged config = %v, %v", changed, err)
+	}
+
+	if err := m.Disable(ctx, "webhook"); err != nil {
+		t.Fatal(err)
+	}
+	if registry.get("webhook") != nil || adapterState(m, "webhook") != StateDisabled {
+		t.Fatalf("webhook still present after disable: state=%s", adapterState(m, "webhook"))
+	}
+	if _, ok := m.OutputAdapter("webhook"); ok {
+		t.Fatal("webhook still an output adapter after disable")
+	}
+	if err := m.Enable("webhook"); err != nil {
+		t.Fatal(err)
+	}
+	if adapterState(m, "webhook") != StateStopped {
+		t.Fatalf("state after enable = %s", adapterState(m, "webhook"))
+	}
+
+	invalid := cfg
+	invalid.Webhook.Routes = []config.WebhookRouteConfig{{Name: "deploy"}}
+	if _, err := m.Reload(ctx, invalid); !heikeErrors.IsCategory(err, heikeErrors.ErrInvalidInput) {
+		t.Fatalf("reload invalid config error = %v", err)
+	}
+	before := registry.get("webhook")
+	if before == nil {
+		t.Fatal("invalid reload removed webhook")
+	}
+
+	rotated := cfg
+	rotated.Webhook.Routes = []config.WebhookRouteConfig{{Name: "deploy", Secret: "s2"}}
+	if changed, err := m.Reload(ctx, rotated); err != nil || len(changed) != 1 {
+		t.Fatalf("reload rotated secret = %v, %v", changed, err)
+	}
+	if after := registry.get("webhook"); after == nil || after == before {
+		t.Fatal("webhook not rebuilt after its config changed")
+	}
+}
+
+type blockingInputAdapter struct {
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (a *blockingInputAdapter) Name() string { return "slack" }
+
+func (a *blockingInputAdapter) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (a *blockingInputAdapter) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stopped = true
+	return nil
+}
+
+func (a *blockingInputAdapter) Send(ctx context.Context, sessionID, content string) error { return nil }
+func (a *blockingInputAdapter) Health(ctx context.Context) error                          { return nil }
+
+func TestRuntimeManager_DisableStopsRunningAdapter(t *testing.T) {
+	m := &RuntimeManager{
+		statuses: map[string]*Status{},
+		policy:   ReconnectPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Hour, CircuitThreshold: 5},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+
+	input := &blockingInputAdapter{}
+	if err := m.add(input); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for adapterState(m, "slack") != StateConnecting {
+		if time.Now().After(deadline) {
+			t.Fatalf("state = %s, want %s", adapterState(m, "slack"), StateConnecting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), time.Second)
+	defer stopCancel()
+	if err := m.Disable(stopCtx, "slack"); err != nil {
+		t.Fatal(err)
+	}
+	input.mu.Lock()
+	stopped := input.stopped
+	input.mu.Unlock()
+	if !stopped {
+		t.Fatal("adapter not stopped")
+	}
+	if got := adapterState(m, "slack"); got != StateDisabled {
+		t.Fatalf("state = %s, want %s", got, StateDisabled)
+	}
+	if len(m.Statuses()) != 0 {
+		t.Fatalf("statuses = %+v, want none", m.Statuses())
+	}
+}
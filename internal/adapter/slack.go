@@ -7,7 +7,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/harunnryd/heike/internal/errors"
 
@@ -15,6 +17,18 @@ import (
 	"github.com/slack-go/slack/slackevents"
 )
 
+// action_id of the approval buttons; the button value is the approval ID.
+const (
+	slackApproveAction = "heike_approve"
+	slackDenyAction    = "heike_deny"
+)
+
+// slackMaxMessageChars is the longest message text Slack posts in full.
+const slackMaxMessageChars = 40000
+
+// slackDefaultFormat applies where adapters.slack.format is unset.
+var slackDefaultFormat = FormatProfile{Style: FormatSlack, MaxChars: slackMaxMessageChars}
+
 type SlackAdapter struct {
 	signingSecret string
 	botToken      string
@@ -22,6 +36,10 @@ type SlackAdapter struct {
 	server        *http.Server
 	port          int
 	client        *slack.Client
+	format        FormatProfile
+	// voice transcribes audio files shared in messages; nil leaves them
+	// unhandled.
+	voice *voiceNotes
 }
 
 func NewSlackAdapter(port int, signingSecret, botToken string, eventHandler EventHandler) *SlackAdapter {
@@ -37,6 +55,7 @@ func NewSlackAdapter(port int, signingSecret, botToken string, eventHandler Even
 		eventHandler:  eventHandler,
 		port:          port,
 		client:        slack.New(botToken),
+		format:        slackDefaultFormat,
 	}
 }
 
@@ -47,21 +66,27 @@ func (s *SlackAdapter) Name() string {
 func (s *SlackAdapter) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/slack/events", s.handleEvents)
+	mux.HandleFunc("/slack/interactions", s.handleInteractions)
 
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.port),
 		Handler: mux,
 	}
 
+	serveErr := make(chan error, 1)
 	go func() {
 		slog.Info("Slack Adapter listening", "port", s.port)
 		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("Slack server failed", "error", err)
+			serveErr <- err
 		}
 	}()
 
-	<-ctx.Done()
-	return s.server.Shutdown(context.Background())
+	select {
+	case <-ctx.Done():
+		return s.server.Shutdown(context.Background())
+	case err := <-serveErr:
+		return errors.Wrap(err, "slack server failed")
+	}
 }
 
 func (s *SlackAdapter) Stop(ctx context.Context) error {
@@ -71,16 +96,35 @@ func (s *SlackAdapter) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
+// Send posts content to the channel sessionID, rendered and split by the
+// adapter's format profile.
 func (s *SlackAdapter) Send(ctx context.Context, sessionID string, content string) error {
-	// sessionID maps to channel ID for Slack
-	_, _, err := s.client.PostMessageContext(ctx, sessionID, slack.MsgOptionText(content, false))
-	if err != nil {
-		return errors.Wrap(err, "failed to send Slack message")
+	for _, message := range s.format.Render(content) {
+		if _, _, err := s.client.PostMessageContext(ctx, sessionID, slack.MsgOptionText(message, false)); err != nil {
+			return errors.Wrap(err, "failed to send Slack message")
+		}
 	}
 	slog.Debug("Slack message sent", "channel", sessionID)
 	return nil
 }
 
+// NotifyApproval posts an approval request with Approve and Deny buttons to
+// the channel sessionID. A click resolves it like /approve or /deny.
+func (s *SlackAdapter) NotifyApproval(ctx context.Context, sessionID, approvalID, tool string) error {
+	text := fmt.Sprintf("Approval required for %s (id %s)", tool, approvalID)
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.PlainTextType, text, false, false), nil, nil),
+		slack.NewActionBlock("heike_approval",
+			slack.NewButtonBlockElement(slackApproveAction, approvalID, slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false)).WithStyle(slack.StylePrimary),
+			slack.NewButtonBlockElement(slackDenyAction, approvalID, slack.NewTextBlockObject(slack.PlainTextType, "Deny", false, false)).WithStyle(slack.StyleDanger),
+		),
+	}
+	if _, _, err := s.client.PostMessageContext(ctx, sessionID, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...)); err != nil {
+		return errors.Wrap(err, "failed to send Slack approval request")
+	}
+	return nil
+}
+
 func (s *SlackAdapter) Health(ctx context.Context) error {
 	if s.server == nil {
 		return errors.Transient("Slack server not started")
@@ -99,24 +143,34 @@ func (s *SlackAdapter) Health(ctx context.Context) error {
 	return nil
 }
 
-func (s *SlackAdapter) handleEvents(w http.ResponseWriter, r *http.Request) {
+// verifiedBody reads the request body and checks its Slack signature. On
+// failure it writes the error status and returns false.
+func (s *SlackAdapter) verifiedBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	sv, err := slack.NewSecretsVerifier(r.Header, s.signingSecret)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil, false
 	}
 	if _, err := sv.Write(body); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil, false
 	}
 	if err := sv.Ensure(); err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+func (s *SlackAdapter) handleEvents(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verifiedBody(w, r)
+	if !ok {
 		return
 	}
 
@@ -152,15 +206,111 @@ func (s *SlackAdapter) handleEvents(w http.ResponseWriter, r *http.Request) {
 				"ts":      ev.TimeStamp,
 			}
 
-			// Call event handler instead of submitting directly to ingress
-			// This fixes circular dependency
-			if s.eventHandler != nil {
-				if err := s.eventHandler(r.Context(), "slack", "user_message", ev.Channel, ev.Text, metadata); err != nil {
-					slog.Error("Failed to handle Slack event", "error", err)
-				}
+			if note, ok := s.voiceNote(ev); ok {
+				// Slack expects an answer within 3 seconds, so the audio
+				// is transcribed after responding.
+				go func() {
+					ctx := context.Background()
+					s.submit(ctx, ev.Channel, s.voice.content(ctx, "slack", note, ev.Text, metadata), metadata)
+				}()
+				break
 			}
+			s.submit(r.Context(), ev.Channel, ev.Text, metadata)
 		}
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func (s *SlackAdapter) submit(ctx context.Context, channel, content string, metadata map[string]string) {
+	// Call event handler instead of submitting directly to ingress
+	// This fixes circular dependency
+	if s.eventHandler != nil {
+		if err := s.eventHandler(ctx, "slack", "user_message", channel, content, metadata); err != nil {
+			slog.Error("Failed to handle Slack event", "error", err)
+		}
+	}
+}
+
+// voiceNote returns the first audio file shared in ev, such as a voice
+// clip, when transcription is enabled.
+func (s *SlackAdapter) voiceNote(ev *slackevents.MessageEvent) (voiceNote, bool) {
+	if s.voice == nil || ev.Message == nil {
+		return voiceNote{}, false
+	}
+	for _, file := range ev.Message.Files {
+		if !strings.HasPrefix(file.Mimetype, "audio/") {
+			continue
+		}
+		location := file.URLPrivateDownload
+		if location == "" {
+			location = file.URLPrivate
+		}
+		header := http.Header{}
+		header.Set("Authorization", "Bearer "+s.botToken)
+		return voiceNote{
+			name:     file.Name,
+			mimeType: file.Mimetype,
+			location: func() (string, error) { return location, nil },
+			header:   header,
+			size:     int64(file.Size),
+		}, true
+	}
+	return voiceNote{}, false
+}
+
+// handleInteractions receives clicks on the approval buttons. The clicked
+// message is rewritten without buttons, so they cannot be clicked twice,
+// and the verdict goes to the channel's session as /approve or /deny.
+func (s *SlackAdapter) handleInteractions(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verifiedBody(w, r)
+	if !ok {
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if callback.Type != slack.InteractionTypeBlockActions {
+		return
+	}
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		var content, verdict string
+		switch action.ActionID {
+		case slackApproveAction:
+			content, verdict = "/approve "+action.Value, "Approved"
+		case slackDenyAction:
+			content, verdict = "/deny "+action.Value, "Denied"
+		default:
+			continue
+		}
+
+		channelID := callback.Channel.ID
+		text := fmt.Sprintf("%s\n%s by <@%s>.", callback.Message.Text, verdict, callback.User.ID)
+		if _, _, _, err := s.client.UpdateMessageContext(r.Context(), channelID, callback.Message.Timestamp,
+			slack.MsgOptionText(text, false),
+			slack.MsgOptionBlocks(slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)),
+		); err != nil {
+			slog.Warn("Failed to update Slack approval message", "channel", channelID, "error", err)
+		}
+
+		metadata := map[string]string{
+			"user_id":   callback.User.ID,
+			"user_name": callback.User.Name,
+			"ts":        callback.Message.Timestamp,
+		}
+		if s.eventHandler != nil {
+			if err := s.eventHandler(r.Context(), "slack", "user_message", channelID, content, metadata); err != nil {
+				slog.Error("Failed to handle Slack approval action", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSlackAdapter_ApprovalButtons(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = map[string]url.Values{}
+	)
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		calls[strings.TrimPrefix(r.URL.Path, "/")] = r.Form
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "channel": "C1", "ts": "1700000000.000100"})
+	}))
+	defer fake.Close()
+
+	var got []string
+	adapter := NewSlackAdapter(0, "signing-secret", "xoxb-test", func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		got = append(got, sessionID+" "+content)
+		return nil
+	})
+	adapter.client = slack.New("xoxb-test", slack.OptionAPIURL(fake.URL+"/"))
+
+	if err := adapter.NotifyApproval(context.Background(), "C1", "01ABC", "exec_command"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	blocks := calls["chat.postMessage"].Get("blocks")
+	mu.Unlock()
+	for _, want := range []string{`"action_id":"heike_approve"`, `"action_id":"heike_deny"`, `"value":"01ABC"`} {
+		if !strings.Contains(blocks, want) {
+			t.Fatalf("blocks missing %s: %s", want, blocks)
+		}
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":    "block_actions",
+		"user":    map[string]string{"id": "U1", "name": "ada"},
+		"channel": map[string]string{"id": "C1"},
+		"message": map[string]string{"ts": "1700000000.000100", "text": "Approval required for exec_command (id 01ABC)"},
+		"actions": []map[string]string{{"type": "button", "block_id": "heike_approval", "action_id": "heike_approve", "value": "01ABC"}},
+	})
+	post := func(signature string) int {
+		body := url.Values{"payload": {string(payload)}}.Encode()
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		if signature == "" {
+			mac := hmac.New(sha256.New, []byte("signing-secret"))
+			mac.Write([]byte("v0:" + ts + ":" + body))
+			signature = "v0=" + hex.EncodeToString(mac.Sum(nil))
+		}
+		req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", signature)
+		rec := httptest.NewRecorder()
+		adapter.handleInteractions(rec, req)
+		return rec.Code
+	}
+
+	if code := post("v0=00"); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature = %d", code)
+	}
+	if code := post(""); code != http.StatusOK {
+		t.Fatalf("signed interaction = %d", code)
+	}
+	if len(got) != 1 || got[0] != "C1 /approve 01ABC" {
+		t.Fatalf("events = %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	update := calls["chat.update"]
+	if update.Get("ts") != "1700000000.000100" || !strings.Contains(update.Get("text"), "Approved by <@U1>.") || strings.Contains(update.Get("blocks"), "heike_approve") {
+		t.Fatalf("update = %v", update)
+	}
+}
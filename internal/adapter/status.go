@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"time"
+)
+
+// ConnectionState describes the lifecycle state of an input adapter connection.
+type ConnectionState string
+
+const (
+	StateConnecting   ConnectionState = "connecting"
+	StateConnected    ConnectionState = "connected"
+	StateDisconnected ConnectionState = "disconnected"
+	StateCircuitOpen  ConnectionState = "circuit_open"
+	StateStopped      ConnectionState = "stopped"
+	// StateDisabled marks an adapter that is configured off or was disabled
+	// at runtime.
+	StateDisabled ConnectionState = "disabled"
+)
+
+// Status is a point-in-time snapshot of an input adapter connection.
+type Status struct {
+	Name              string          `json:"name"`
+	State             ConnectionState `json:"state"`
+	LastError         string          `json:"last_error,omitempty"`
+	LastErrorAt       time.Time       `json:"last_error_at,omitempty"`
+	ReconnectAttempts int             `json:"reconnect_attempts"`
+	ConnectedAt       time.Time       `json:"connected_at,omitempty"`
+	// Paused is set while a polling adapter is held back by ingress overload.
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ReconnectPolicy controls exponential backoff between adapter restart attempts.
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// CircuitThreshold is the number of consecutive failures after which the
+	// circuit opens and a system event is emitted.
+	CircuitThreshold int
+}
+
+// Backoff returns the wait duration before the given (1-based) reconnect attempt.
+func (p ReconnectPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := p.InitialBackoff
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
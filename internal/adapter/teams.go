@@ -0,0 +1,569 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	teamsOpenIDConfigURL = "https://login.botframework.com/v1/.well-known/openidconfiguration"
+	teamsTokenIssuer     = "https://api.botframework.com"
+	teamsTokenURLFormat  = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	teamsTokenScope      = "https://api.botframework.com/.default"
+	teamsDefaultTenant   = "botframework.com"
+
+	// teamsKeyRefresh is how long Bot Framework signing keys are cached.
+	teamsKeyRefresh = 24 * time.Hour
+	// teamsClockSkew is the leeway allowed on token expiry and not-before.
+	teamsClockSkew = 5 * time.Minute
+	// teamsMaxMessageChars keeps a message under Teams' ~28 KB activity limit.
+	teamsMaxMessageChars = 20000
+	// maxTeamsActivityBytes caps an inbound activity.
+	maxTeamsActivityBytes = 1 << 20
+)
+
+// teamsDefaultFormat applies where adapters.teams.format is unset.
+var teamsDefaultFormat = FormatProfile{Style: FormatMarkdown, MaxChars: teamsMaxMessageChars}
+
+// teamsActionKey marks Adaptive Card submit data sent by the approval card.
+const teamsActionKey = "heike_action"
+
+var teamsMentionPattern = regexp.MustCompile(`<at>[^<]*</at>`)
+
+// TeamsOptions configures the Teams bot.
+type TeamsOptions struct {
+	// AppID and AppPassword are the Azure Bot registration's app ID and
+	// client secret.
+	AppID       string
+	AppPassword string
+	// TenantID is set for single-tenant bots; empty uses the multi-tenant
+	// Bot Framework tenant.
+	TenantID string
+	Port     int
+	// Format shapes replies; the zero value uses teamsDefaultFormat.
+	Format FormatProfile
+}
+
+// TeamsAdapter receives Bot Framework activities on /api/messages and
+// replies through the Bot Connector API. A session is a Teams conversation
+// ID. Replies go to the service URL of the conversation's latest activity,
+// which is kept in memory, so a session cannot be answered after a restart
+// until its next message arrives.
+type TeamsAdapter struct {
+	opts         TeamsOptions
+	eventHandler EventHandler
+	client       *http.Client
+	server       *http.Server
+	openIDURL    string
+	tokenURL     string
+
+	mu            sync.Mutex
+	serviceURLs   map[string]string
+	keys          map[string]teamsSigningKey
+	keysFetchedAt time.Time
+	token         string
+	tokenExpiry   time.Time
+}
+
+type teamsSigningKey struct {
+	key          *rsa.PublicKey
+	endorsements []string
+}
+
+func NewTeamsAdapter(eventHandler EventHandler, opts TeamsOptions) *TeamsAdapter {
+	tenant := opts.TenantID
+	if tenant == "" {
+		tenant = teamsDefaultTenant
+	}
+	if opts.Format.Style == "" {
+		opts.Format = teamsDefaultFormat
+	}
+	return &TeamsAdapter{
+		opts:         opts,
+		eventHandler: eventHandler,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		openIDURL:    teamsOpenIDConfigURL,
+		tokenURL:     fmt.Sprintf(teamsTokenURLFormat, url.PathEscape(tenant)),
+		serviceURLs:  make(map[string]string),
+	}
+}
+
+func (t *TeamsAdapter) Name() string {
+	return "teams"
+}
+
+func (t *TeamsAdapter) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/messages", t.handleActivity)
+
+	t.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", t.opts.Port),
+		Handler: mux,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Teams Adapter listening", "port", t.opts.Port)
+		if err := t.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return t.server.Shutdown(context.Background())
+	case err := <-serveErr:
+		return errors.Wrap(err, "teams server failed")
+	}
+}
+
+func (t *TeamsAdapter) Stop(ctx context.Context) error {
+	if t.server == nil {
+		return nil
+	}
+	return t.server.Shutdown(ctx)
+}
+
+func (t *TeamsAdapter) Health(ctx context.Context) error {
+	if t.server == nil {
+		return errors.Transient("Teams server not started")
+	}
+	return nil
+}
+
+// teamsActivity is the subset of a Bot Framework activity the adapter reads.
+type teamsActivity struct {
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	Text         string `json:"text"`
+	ServiceURL   string `json:"serviceUrl"`
+	ChannelID    string `json:"channelId"`
+	Conversation struct {
+		ID string `json:"id"`
+	} `json:"conversation"`
+	From struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		AADObjectID string `json:"aadObjectId"`
+	} `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (t *TeamsAdapter) handleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTeamsActivityBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var activity teamsActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := t.verifyRequest(r.Context(), r.Header.Get("Authorization"), activity); err != nil {
+		slog.Warn("Rejected Teams activity", "error", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if activity.Conversation.ID != "" && activity.ServiceURL != "" {
+		t.mu.Lock()
+		t.serviceURLs[activity.Conversation.ID] = activity.ServiceURL
+		t.mu.Unlock()
+	}
+	if activity.Type == "message" {
+		t.handleMessage(r.Context(), activity)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t *TeamsAdapter) handleMessage(ctx context.Context, activity teamsActivity) {
+	content := strings.TrimSpace(teamsMentionPattern.ReplaceAllString(activity.Text, ""))
+	if command := teamsCardCommand(activity.Value); command != "" {
+		content = command
+	}
+	if content == "" || activity.Conversation.ID == "" {
+		return
+	}
+
+	userID := activity.From.AADObjectID
+	if userID == "" {
+		userID = activity.From.ID
+	}
+	metadata := map[string]string{
+		"user_id":   userID,
+		"user_name": activity.From.Name,
+		"msg_id":    activity.ID,
+	}
+	if t.eventHandler != nil {
+		if err := t.eventHandler(ctx, "teams", "user_message", activity.Conversation.ID, content, metadata); err != nil {
+			slog.Error("Failed to handle Teams event", "error", err)
+		}
+	}
+}
+
+// teamsCardCommand maps the submit data of an approval card button to
+// /approve or /deny.
+func teamsCardCommand(value json.RawMessage) string {
+	if len(value) == 0 {
+		return ""
+	}
+	var data map[string]string
+	if err := json.Unmarshal(value, &data); err != nil {
+		return ""
+	}
+	approvalID := strings.TrimSpace(data["approval_id"])
+	if approvalID == "" {
+		return ""
+	}
+	switch data[teamsActionKey] {
+	case "approve":
+		return "/approve " + approvalID
+	case "deny":
+		return "/deny " + approvalID
+	}
+	return ""
+}
+
+// teamsClaims are the JWT claims checked on inbound requests.
+type teamsClaims struct {
+	Issuer     string          `json:"iss"`
+	Audience   json.RawMessage `json:"aud"`
+	Expiry     int64           `json:"exp"`
+	NotBefore  int64           `json:"nbf"`
+	ServiceURL string          `json:"serviceurl"`
+}
+
+// verifyRequest checks the Bot Framework JWT in authorization: an RS256
+// signature by a current Bot Framework key endorsed for the activity's
+// channel, the issuer, the bot's app ID as audience, the validity window,
+// and the service URL the activity claims.
+func (t *TeamsAdapter) verifyRequest(ctx context.Context, authorization string, activity teamsActivity) error {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return fmt.Errorf("missing bearer token")
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+	key, err := t.signingKey(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	if len(key.endorsements) > 0 && !slices.Contains(key.endorsements, activity.ChannelID) {
+		return fmt.Errorf("signing key not endorsed for channel %q", activity.ChannelID)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed token signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key.key, crypto.SHA256, digest[:], sig); err != nil {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	var claims teamsClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	now := time.Now()
+	if claims.Issuer != teamsTokenIssuer {
+		return fmt.Errorf("unexpected token issuer %q", claims.Issuer)
+	}
+	if !jwtAudienceContains(claims.Audience, t.opts.AppID) {
+		return fmt.Errorf("token audience does not match app id")
+	}
+	if claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(teamsClockSkew)) {
+		return fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != 0 && now.Add(teamsClockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("token not yet valid")
+	}
+	if claims.ServiceURL != "" && claims.ServiceURL != activity.ServiceURL {
+		return fmt.Errorf("token service url does not match activity")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	return nil
+}
+
+func jwtAudienceContains(raw json.RawMessage, want string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == want
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		return slices.Contains(many, want)
+	}
+	return false
+}
+
+// signingKey returns the Bot Framework key kid, refreshing the cached keys
+// daily or when kid is unknown.
+func (t *TeamsAdapter) signingKey(ctx context.Context, kid string) (teamsSigningKey, error) {
+	t.mu.Lock()
+	key, ok := t.keys[kid]
+	fresh := time.Since(t.keysFetchedAt) < teamsKeyRefresh
+	t.mu.Unlock()
+	if ok && fresh {
+		return key, nil
+	}
+
+	keys, err := t.fetchSigningKeys(ctx)
+	if err != nil {
+		return teamsSigningKey{}, fmt.Errorf("fetch bot framework keys: %w", err)
+	}
+	t.mu.Lock()
+	t.keys = keys
+	t.keysFetchedAt = time.Now()
+	t.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return teamsSigningKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (t *TeamsAdapter) fetchSigningKeys(ctx context.Context) (map[string]teamsSigningKey, error) {
+	var metadata struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := t.getJSON(ctx, t.openIDURL, &metadata); err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty          string   `json:"kty"`
+			Kid          string   `json:"kid"`
+			N            string   `json:"n"`
+			E            string   `json:"e"`
+			Endorsements []string `json:"endorsements"`
+		} `json:"keys"`
+	}
+	if err := t.getJSON(ctx, metadata.JWKSURI, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]teamsSigningKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = teamsSigningKey{
+			key:          &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+			endorsements: k.Endorsements,
+		}
+	}
+	return keys, nil
+}
+
+func (t *TeamsAdapter) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// accessToken returns a Bot Connector token from the client credentials
+// grant, cached until shortly before it expires.
+func (t *TeamsAdapter) accessToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	if t.token != "" && time.Now().Before(t.tokenExpiry) {
+		token := t.token
+		t.mu.Unlock()
+		return token, nil
+	}
+	t.mu.Unlock()
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {t.opts.AppID},
+		"client_secret": {t.opts.AppPassword},
+		"scope":         {teamsTokenScope},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("teams token request: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	t.token = token.AccessToken
+	t.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	t.mu.Unlock()
+	return token.AccessToken, nil
+}
+
+// Send posts content to the conversation sessionID, rendered and split by
+// the adapter's format profile.
+func (t *TeamsAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	textFormat := "markdown"
+	if t.opts.Format.Style == FormatPlain {
+		textFormat = "plain"
+	}
+	for _, message := range t.opts.Format.Render(content) {
+		activity := map[string]interface{}{
+			"type":       "message",
+			"text":       message,
+			"textFormat": textFormat,
+		}
+		if err := t.post(ctx, sessionID, activity); err != nil {
+			return errors.Wrap(err, "failed to send teams message")
+		}
+	}
+	slog.Debug("Teams message sent", "conversation", sessionID)
+	return nil
+}
+
+// NotifyApproval posts an Adaptive Card with Approve and Deny buttons to
+// the conversation sessionID. A click resolves it like /approve or /deny.
+func (t *TeamsAdapter) NotifyApproval(ctx context.Context, sessionID, approvalID, tool string) error {
+	activity := map[string]interface{}{
+		"type":        "message",
+		"attachments": []interface{}{teamsApprovalCard(approvalID, tool)},
+	}
+	if err := t.post(ctx, sessionID, activity); err != nil {
+		return errors.Wrap(err, "failed to send teams approval request")
+	}
+	return nil
+}
+
+func teamsApprovalCard(approvalID, tool string) map[string]interface{} {
+	action := func(title, verb, style string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":  "Action.Submit",
+			"title": title,
+			"style": style,
+			"data":  map[string]string{teamsActionKey: verb, "approval_id": approvalID},
+		}
+	}
+	return map[string]interface{}{
+		"contentType": "application/vnd.microsoft.card.adaptive",
+		"content": map[string]interface{}{
+			"type":    "AdaptiveCard",
+			"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+			"version": "1.4",
+			"body": []interface{}{
+				map[string]interface{}{"type": "TextBlock", "text": "Approval required", "weight": "Bolder", "size": "Medium"},
+				map[string]interface{}{"type": "FactSet", "facts": []interface{}{
+					map[string]string{"title": "Tool", "value": tool},
+					map[string]string{"title": "ID", "value": approvalID},
+				}},
+			},
+			"actions": []interface{}{
+				action("Approve", "approve", "positive"),
+				action("Deny", "deny", "destructive"),
+			},
+		},
+	}
+}
+
+func (t *TeamsAdapter) post(ctx context.Context, conversationID string, activity map[string]interface{}) error {
+	t.mu.Lock()
+	serviceURL, ok := t.serviceURLs[conversationID]
+	t.mu.Unlock()
+	if !ok {
+		return errors.NotFound("no teams service url for conversation " + conversationID)
+	}
+
+	token, err := t.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	target := strings.TrimRight(serviceURL, "/") + "/v3/conversations/" + url.PathEscape(conversationID) + "/activities"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("teams POST %s: %s: %s", target, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
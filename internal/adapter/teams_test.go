@@ -0,0 +1,221 @@
+package adapter
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type teamsFakeServer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	posted   []map[string]interface{}
+	tokenReq int
+}
+
+func newTeamsFakeServer(t *testing.T) *teamsFakeServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &teamsFakeServer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openid", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"jwks_uri": f.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{map[string]interface{}{
+			"kty":          "RSA",
+			"kid":          "k1",
+			"n":            base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":            base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			"endorsements": []string{"msteams"},
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.tokenReq++
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "bot-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/svc/v3/conversations/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer bot-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var activity map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&activity)
+		activity["path"] = r.URL.Path
+		f.mu.Lock()
+		f.posted = append(f.posted, activity)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *teamsFakeServer) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		raw, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signed := enc(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestTeamsAdapter_VerifiesActivitiesAndReplies(t *testing.T) {
+	fake := newTeamsFakeServer(t)
+
+	type submitted struct{ sessionID, content string }
+	var got []submitted
+	adapter := NewTeamsAdapter(func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		if source != "teams" || eventType != "user_message" {
+			t.Errorf("event = %s/%s", source, eventType)
+		}
+		got = append(got, submitted{sessionID, content})
+		return nil
+	}, TeamsOptions{AppID: "app", AppPassword: "secret"})
+	adapter.client = fake.Client()
+	adapter.openIDURL = fake.URL + "/openid"
+	adapter.tokenURL = fake.URL + "/token"
+
+	serviceURL := fake.URL + "/svc/"
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":        teamsTokenIssuer,
+			"aud":        "app",
+			"exp":        time.Now().Add(time.Hour).Unix(),
+			"nbf":        time.Now().Add(-time.Minute).Unix(),
+			"serviceurl": serviceURL,
+		}
+	}
+	post := func(token string, activity map[string]interface{}) int {
+		raw, _ := json.Marshal(activity)
+		req := httptest.NewRequest(http.MethodPost, "/api/messages", strings.NewReader(string(raw)))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		adapter.handleActivity(rec, req)
+		return rec.Code
+	}
+	message := func(text string, value interface{}) map[string]interface{} {
+		activity := map[string]interface{}{
+			"type":         "message",
+			"id":           "m1",
+			"text":         text,
+			"serviceUrl":   serviceURL,
+			"channelId":    "msteams",
+			"conversation": map[string]string{"id": "conv-1"},
+			"from":         map[string]string{"id": "29:1", "name": "Ada", "aadObjectId": "aad-1"},
+		}
+		if value != nil {
+			activity["value"] = value
+		}
+		return activity
+	}
+
+	if code := post(fake.sign(t, validClaims()), message("<at>Heike</at> summarize the logs", nil)); code != http.StatusOK {
+		t.Fatalf("signed activity = %d", code)
+	}
+	if code := post("", message("hi", nil)); code != http.StatusUnauthorized {
+		t.Fatalf("missing token = %d", code)
+	}
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other"
+	if code := post(fake.sign(t, wrongAudience), message("hi", nil)); code != http.StatusUnauthorized {
+		t.Fatalf("wrong audience = %d", code)
+	}
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	if code := post(fake.sign(t, expired), message("hi", nil)); code != http.StatusUnauthorized {
+		t.Fatalf("expired token = %d", code)
+	}
+	otherChannel := message("hi", nil)
+	otherChannel["channelId"] = "webchat"
+	if code := post(fake.sign(t, validClaims()), otherChannel); code != http.StatusUnauthorized {
+		t.Fatalf("unendorsed channel = %d", code)
+	}
+	tampered := fake.sign(t, validClaims())
+	if code := post(tampered[:len(tampered)-4]+"AAAA", message("hi", nil)); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature = %d", code)
+	}
+	approve := map[string]string{teamsActionKey: "approve", "approval_id": "ap-1"}
+	if code := post(fake.sign(t, validClaims()), message("", approve)); code != http.StatusOK {
+		t.Fatalf("card submit = %d", code)
+	}
+
+	want := []submitted{{"conv-1", "summarize the logs"}, {"conv-1", "/approve ap-1"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("events = %+v", got)
+	}
+
+	ctx := context.Background()
+	if err := adapter.Send(ctx, "conv-1", "done"); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.NotifyApproval(ctx, "conv-1", "ap-2", "exec_command"); err != nil {
+		t.Fatal(err)
+	}
+	if err := adapter.Send(ctx, "unknown", "done"); err == nil {
+		t.Fatal("expected error for an unknown conversation")
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.tokenReq != 1 {
+		t.Fatalf("token requests = %d, want 1", fake.tokenReq)
+	}
+	if len(fake.posted) != 2 {
+		t.Fatalf("posted = %+v", fake.posted)
+	}
+	if fake.posted[0]["text"] != "done" || fake.posted[0]["path"] != "/svc/v3/conversations/conv-1/activities" {
+		t.Fatalf("reply = %+v", fake.posted[0])
+	}
+	card, _ := json.Marshal(fake.posted[1]["attachments"])
+	for _, want := range []string{"application/vnd.microsoft.card.adaptive", `"approval_id":"ap-2"`, `"heike_action":"deny"`, "exec_command"} {
+		if !strings.Contains(string(card), want) {
+			t.Fatalf("approval card missing %s: %s", want, card)
+		}
+	}
+}
+
+func TestTeamsCardCommand(t *testing.T) {
+	for raw, want := range map[string]string{
+		`{"heike_action":"deny","approval_id":"a1"}`:  "/deny a1",
+		`{"heike_action":"approve"}`:                  "",
+		`{"heike_action":"other","approval_id":"a1"}`: "",
+		`"text"`: "",
+	} {
+		if got := teamsCardCommand(json.RawMessage(raw)); got != want {
+			t.Errorf("teamsCardCommand(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -2,10 +2,17 @@ package adapter
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/errors"
@@ -13,24 +20,79 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Telegram update modes.
+const (
+	TelegramModePolling = "polling"
+	TelegramModeWebhook = "webhook"
+)
+
+// telegramPollErrorPause is the wait after a failed getUpdates call.
+const telegramPollErrorPause = 3 * time.Second
+
+// callback_data prefixes of the approval buttons.
+const (
+	telegramApprovePrefix = "heike_approve:"
+	telegramDenyPrefix    = "heike_deny:"
+)
+
+// telegramMaxMessageChars is the longest message Telegram accepts.
+const telegramMaxMessageChars = 4096
+
+// telegramDefaultFormat applies where adapters.telegram.format is unset.
+var telegramDefaultFormat = FormatProfile{Style: FormatTelegramHTML, MaxChars: telegramMaxMessageChars}
+
+// TelegramOptions selects how the Telegram adapter receives updates.
+type TelegramOptions struct {
+	// UpdateTimeout is the long-poll timeout in seconds.
+	UpdateTimeout int
+	Poll          PollPolicy
+	// Mode is TelegramModePolling (default) or TelegramModeWebhook.
+	Mode    string
+	Webhook TelegramWebhook
+	// Format shapes replies; the zero value uses telegramDefaultFormat.
+	Format FormatProfile
+}
+
+// TelegramWebhook is where Telegram delivers updates in webhook mode. The
+// adapter listens on Port and serves the path of URL, which must route to it.
+type TelegramWebhook struct {
+	URL         string
+	Port        int
+	SecretToken string
+}
+
 type TelegramAdapter struct {
-	token         string
-	updateTimeout int
-	eventHandler  EventHandler
-	bot           *tgbotapi.BotAPI
-	updates       tgbotapi.UpdatesChannel
-	cancel        context.CancelFunc
-	wg            sync.WaitGroup
+	token        string
+	opts         TelegramOptions
+	eventHandler EventHandler
+	bot          *tgbotapi.BotAPI
+	server       *http.Server
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	gate         pauseGate
+	// voice transcribes voice messages and audio files; nil leaves them
+	// unhandled.
+	voice *voiceNotes
+	// fileEndpoint formats the download URL of a file from the bot token
+	// and the file path.
+	fileEndpoint string
 }
 
-func NewTelegramAdapter(token string, eventHandler EventHandler, updateTimeout int) *TelegramAdapter {
-	if updateTimeout <= 0 {
-		updateTimeout = config.DefaultTelegramUpdateTimeout
+func NewTelegramAdapter(token string, eventHandler EventHandler, opts TelegramOptions) *TelegramAdapter {
+	if opts.UpdateTimeout <= 0 {
+		opts.UpdateTimeout = config.DefaultTelegramUpdateTimeout
+	}
+	if opts.Mode == "" {
+		opts.Mode = TelegramModePolling
+	}
+	if opts.Format.Style == "" {
+		opts.Format = telegramDefaultFormat
 	}
 	return &TelegramAdapter{
-		token:         token,
-		updateTimeout: updateTimeout,
-		eventHandler:  eventHandler,
+		token:        token,
+		opts:         opts,
+		eventHandler: eventHandler,
+		fileEndpoint: tgbotapi.FileEndpoint,
 	}
 }
 
@@ -48,38 +110,167 @@ func (t *TelegramAdapter) Start(ctx context.Context) error {
 		return errors.Wrap(err, "failed to init telegram bot")
 	}
 
-	slog.Info("Telegram Adapter started", "user", t.bot.Self.UserName)
+	slog.Info("Telegram Adapter started", "user", t.bot.Self.UserName, "mode", t.opts.Mode)
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = t.updateTimeout
+	if t.opts.Mode == TelegramModeWebhook {
+		return t.startWebhook()
+	}
 
-	t.updates = t.bot.GetUpdatesChan(u)
+	// getUpdates is rejected while a webhook is registered, e.g. after
+	// switching back from webhook mode. Pending updates are kept.
+	if info, err := t.bot.GetWebhookInfo(); err == nil && info.IsSet() {
+		if _, err := t.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			return errors.Wrap(err, "failed to remove telegram webhook")
+		}
+		slog.Info("Removed Telegram webhook for polling", "url", info.URL)
+	}
 
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
-		for {
-			select {
-			case <-runCtx.Done():
-				return
-			case update, ok := <-t.updates:
-				if !ok {
-					return
+		t.poll(runCtx)
+	}()
+
+	return nil
+}
+
+// poll long-polls getUpdates, pausing between polls as the PollPolicy
+// decides. While paused, no updates are fetched and Telegram keeps the
+// backlog.
+func (t *TelegramAdapter) poll(ctx context.Context) {
+	schedule := newPollSchedule(t.opts.Poll, time.Now())
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = t.opts.UpdateTimeout
+
+	type pollResult struct {
+		updates []tgbotapi.Update
+		err     error
+	}
+	for {
+		if err := t.gate.Wait(ctx); err != nil {
+			return
+		}
+		// getUpdates cannot be cancelled, so wait for it alongside ctx. An
+		// abandoned call's updates are not acknowledged and are fetched again.
+		results := make(chan pollResult, 1)
+		go func(cfg tgbotapi.UpdateConfig) {
+			updates, err := t.bot.GetUpdates(cfg)
+			results <- pollResult{updates: updates, err: err}
+		}(u)
+
+		var res pollResult
+		select {
+		case <-ctx.Done():
+			return
+		case res = <-results:
+		}
+
+		wait := telegramPollErrorPause
+		if res.err != nil {
+			slog.Warn("Telegram getUpdates failed", "error", res.err)
+		} else {
+			now := time.Now()
+			schedule.Observe(len(res.updates), now)
+			for _, update := range res.updates {
+				if update.UpdateID >= u.Offset {
+					u.Offset = update.UpdateID + 1
 				}
-				t.handleUpdate(runCtx, update)
+				t.handleUpdate(ctx, update)
 			}
+			wait = schedule.Next(now)
+		}
+		if wait <= 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// startWebhook serves the webhook and then registers it with Telegram.
+func (t *TelegramAdapter) startWebhook() error {
+	hook, err := url.Parse(t.opts.Webhook.URL)
+	if err != nil || hook.Scheme != "https" || hook.Host == "" {
+		return errors.InvalidInput(fmt.Sprintf("invalid telegram webhook url %q: must be an https URL", t.opts.Webhook.URL))
+	}
+	path := hook.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, t.handleWebhook)
+
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", t.opts.Webhook.Port))
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for telegram webhook")
+	}
+	t.server = &http.Server{Handler: mux}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		slog.Info("Telegram webhook listening", "port", t.opts.Webhook.Port, "path", path)
+		if err := t.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			slog.Error("Telegram webhook server failed", "error", err)
 		}
 	}()
 
+	params := tgbotapi.Params{"url": hook.String()}
+	params.AddNonEmpty("secret_token", t.opts.Webhook.SecretToken)
+	if _, err := t.bot.MakeRequest("setWebhook", params); err != nil {
+		_ = t.server.Close()
+		return errors.Wrap(err, "failed to register telegram webhook")
+	}
 	return nil
 }
 
+// handleWebhook accepts one update pushed by Telegram. While paused it
+// answers 503, so Telegram keeps the update and retries later.
+func (t *TelegramAdapter) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	secret := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(t.opts.Webhook.SecretToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if t.gate.Paused() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	t.handleUpdate(r.Context(), update)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Pause stops consuming updates until Resume is called.
+func (t *TelegramAdapter) Pause() {
+	t.gate.Pause()
+}
+
+// Resume continues consuming updates after Pause.
+func (t *TelegramAdapter) Resume() {
+	t.gate.Resume()
+}
+
 func (t *TelegramAdapter) Stop(ctx context.Context) error {
 	if t.cancel != nil {
 		t.cancel()
 	}
-	if t.bot != nil {
-		t.bot.StopReceivingUpdates()
+	if t.server != nil {
+		if err := t.server.Shutdown(ctx); err != nil {
+			return err
+		}
 	}
 
 	waitDone := make(chan struct{})
@@ -97,6 +288,10 @@ func (t *TelegramAdapter) Stop(ctx context.Context) error {
 }
 
 func (t *TelegramAdapter) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	if update.CallbackQuery != nil {
+		t.handleCallbackQuery(ctx, update.CallbackQuery)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
@@ -117,32 +312,147 @@ func (t *TelegramAdapter) handleUpdate(ctx context.Context, update tgbotapi.Upda
 		"msg_id":    fmt.Sprintf("%d", msg.MessageID),
 	}
 
+	content := msg.Text
+	if note, ok := t.voiceNote(msg); ok {
+		content = t.voice.content(ctx, "telegram", note, msg.Caption, metadata)
+	}
+
 	// Call event handler instead of submitting directly to ingress
 	// This fixes circular dependency
 	if t.eventHandler != nil {
-		if err := t.eventHandler(ctx, "telegram", "user_message", sessionID, msg.Text, metadata); err != nil {
+		if err := t.eventHandler(ctx, "telegram", "user_message", sessionID, content, metadata); err != nil {
 			slog.Error("Failed to handle Telegram event", "error", err)
 		}
 	}
 }
 
-// Send sends a reply back to Telegram
+// voiceNote returns the voice message or audio file of msg when
+// transcription is enabled.
+func (t *TelegramAdapter) voiceNote(msg *tgbotapi.Message) (voiceNote, bool) {
+	if t.voice == nil {
+		return voiceNote{}, false
+	}
+	var fileID string
+	var note voiceNote
+	switch {
+	case msg.Voice != nil:
+		fileID = msg.Voice.FileID
+		note = voiceNote{
+			name:     fmt.Sprintf("voice-%d.ogg", msg.MessageID),
+			mimeType: msg.Voice.MimeType,
+			size:     int64(msg.Voice.FileSize),
+			duration: msg.Voice.Duration,
+		}
+	case msg.Audio != nil:
+		fileID = msg.Audio.FileID
+		note = voiceNote{
+			name:     msg.Audio.FileName,
+			mimeType: msg.Audio.MimeType,
+			size:     int64(msg.Audio.FileSize),
+			duration: msg.Audio.Duration,
+		}
+		if note.name == "" {
+			note.name = fmt.Sprintf("audio-%d", msg.MessageID)
+		}
+	default:
+		return voiceNote{}, false
+	}
+	note.location = func() (string, error) {
+		file, err := t.bot.GetFile(tgbotapi.FileConfig{FileID: fileID})
+		if err != nil {
+			return "", fmt.Errorf("get telegram file: %w", err)
+		}
+		return fmt.Sprintf(t.fileEndpoint, t.token, file.FilePath), nil
+	}
+	return note, true
+}
+
+// handleCallbackQuery turns a click on an approval button into /approve or
+// /deny for the chat's session. The buttons are removed from the message so
+// they cannot be clicked twice.
+func (t *TelegramAdapter) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	var content, verdict string
+	switch data := query.Data; {
+	case strings.HasPrefix(data, telegramApprovePrefix):
+		content, verdict = "/approve "+strings.TrimPrefix(data, telegramApprovePrefix), "Approved"
+	case strings.HasPrefix(data, telegramDenyPrefix):
+		content, verdict = "/deny "+strings.TrimPrefix(data, telegramDenyPrefix), "Denied"
+	default:
+		return
+	}
+	if query.Message == nil || query.From == nil {
+		return
+	}
+
+	msg := query.Message
+	if _, err := t.bot.Request(tgbotapi.NewCallback(query.ID, verdict)); err != nil {
+		slog.Warn("Failed to answer Telegram callback query", "error", err)
+	}
+	edit := tgbotapi.NewEditMessageText(msg.Chat.ID, msg.MessageID, fmt.Sprintf("%s\n%s by %s.", msg.Text, verdict, telegramUserName(query.From)))
+	if _, err := t.bot.Request(edit); err != nil {
+		slog.Warn("Failed to update Telegram approval message", "chat_id", msg.Chat.ID, "error", err)
+	}
+
+	metadata := map[string]string{
+		"user_id":   fmt.Sprintf("%d", query.From.ID),
+		"user_name": query.From.UserName,
+		"msg_id":    fmt.Sprintf("%d", msg.MessageID),
+	}
+	if t.eventHandler != nil {
+		if err := t.eventHandler(ctx, "telegram", "user_message", fmt.Sprintf("%d", msg.Chat.ID), content, metadata); err != nil {
+			slog.Error("Failed to handle Telegram approval action", "error", err)
+		}
+	}
+}
+
+func telegramUserName(user *tgbotapi.User) string {
+	if user.UserName != "" {
+		return "@" + user.UserName
+	}
+	return user.FirstName
+}
+
+// Send sends a reply back to Telegram, rendered and split by the adapter's
+// format profile.
 func (t *TelegramAdapter) Send(ctx context.Context, sessionID string, content string) error {
 	chatID, err := strconv.ParseInt(sessionID, 10, 64)
 	if err != nil {
 		return errors.InvalidInput("invalid telegram session ID: " + err.Error())
 	}
 
-	msg := tgbotapi.NewMessage(chatID, content)
-	_, err = t.bot.Send(msg)
-	if err != nil {
-		return errors.Wrap(err, "failed to send telegram message")
+	for _, message := range t.opts.Format.Render(content) {
+		msg := tgbotapi.NewMessage(chatID, message)
+		if t.opts.Format.Style == FormatTelegramHTML {
+			msg.ParseMode = tgbotapi.ModeHTML
+		}
+		if _, err := t.bot.Send(msg); err != nil {
+			return errors.Wrap(err, "failed to send telegram message")
+		}
 	}
 
 	slog.Debug("Telegram message sent", "chat_id", sessionID)
 	return nil
 }
 
+// NotifyApproval posts an approval request with Approve and Deny buttons to
+// the chat sessionID. A click resolves it like /approve or /deny.
+func (t *TelegramAdapter) NotifyApproval(ctx context.Context, sessionID, approvalID, tool string) error {
+	chatID, err := strconv.ParseInt(sessionID, 10, 64)
+	if err != nil {
+		return errors.InvalidInput("invalid telegram session ID: " + err.Error())
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Approval required for %s (id %s)", tool, approvalID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Approve", telegramApprovePrefix+approvalID),
+		tgbotapi.NewInlineKeyboardButtonData("Deny", telegramDenyPrefix+approvalID),
+	))
+	if _, err := t.bot.Send(msg); err != nil {
+		return errors.Wrap(err, "failed to send telegram approval request")
+	}
+	return nil
+}
+
 func (t *TelegramAdapter) Health(ctx context.Context) error {
 	if t.bot == nil {
 		return errors.Transient("Telegram bot not initialized")
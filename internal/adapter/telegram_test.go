@@ -0,0 +1,133 @@
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestTelegramAdapter_Webhook(t *testing.T) {
+	var sessions []string
+	adapter := NewTelegramAdapter("test-token", func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		sessions = append(sessions, sessionID)
+		return nil
+	}, TelegramOptions{Mode: TelegramModeWebhook, Webhook: TelegramWebhook{SecretToken: "s3cret"}})
+
+	post := func(secret string) int {
+		body := `{"update_id":7,"message":{"message_id":1,"text":"hi","chat":{"id":42},"from":{"id":9,"username":"bob"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/telegram", strings.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		rec := httptest.NewRecorder()
+		adapter.handleWebhook(rec, req)
+		return rec.Code
+	}
+
+	if code := post("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong secret status = %d, want 401", code)
+	}
+	adapter.Pause()
+	if code := post("s3cret"); code != http.StatusServiceUnavailable {
+		t.Fatalf("paused status = %d, want 503", code)
+	}
+	adapter.Resume()
+	if code := post("s3cret"); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(sessions) != 1 || sessions[0] != "42" {
+		t.Fatalf("sessions = %v, want [42]", sessions)
+	}
+}
+
+func TestTelegramOptionsFromConfig(t *testing.T) {
+	opts, err := telegramOptionsFromConfig(config.TelegramConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Mode != TelegramModePolling || opts.Poll.MaxIdleInterval.String() != "30s" {
+		t.Fatalf("defaults = %+v", opts)
+	}
+
+	if _, err := telegramOptionsFromConfig(config.TelegramConfig{Mode: "webhook", Webhook: config.TelegramWebhookConfig{URL: "https://bot.example.com/tg"}}); err == nil {
+		t.Fatal("webhook mode without secret_token should fail")
+	}
+	opts, err = telegramOptionsFromConfig(config.TelegramConfig{Mode: "Webhook", Webhook: config.TelegramWebhookConfig{URL: "https://bot.example.com/tg", SecretToken: "s"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Mode != TelegramModeWebhook || opts.Webhook.Port != config.DefaultTelegramWebhookPort {
+		t.Fatalf("webhook options = %+v", opts)
+	}
+	if _, err := telegramOptionsFromConfig(config.TelegramConfig{Mode: "push"}); err == nil {
+		t.Fatal("unknown mode should fail")
+	}
+}
+
+func TestTelegramAdapter_ApprovalButtons(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = map[string]url.Values{}
+	)
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		mu.Lock()
+		calls[method] = r.Form
+		mu.Unlock()
+		result := `true`
+		switch method {
+		case "getMe":
+			result = `{"id":1,"is_bot":true,"username":"heike_bot"}`
+		case "sendMessage", "editMessageText":
+			result = `{"message_id":5,"chat":{"id":42},"text":"ok"}`
+		}
+		w.Write([]byte(`{"ok":true,"result":` + result + `}`))
+	}))
+	defer fake.Close()
+
+	var got []string
+	adapter := NewTelegramAdapter("test-token", func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		got = append(got, sessionID+" "+content)
+		return nil
+	}, TelegramOptions{})
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", fake.URL+"/bot%s/%s", fake.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter.bot = bot
+
+	if err := adapter.NotifyApproval(context.Background(), "42", "01ABC", "exec_command"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	markup := calls["sendMessage"].Get("reply_markup")
+	mu.Unlock()
+	if !strings.Contains(markup, `"callback_data":"heike_approve:01ABC"`) || !strings.Contains(markup, `"callback_data":"heike_deny:01ABC"`) {
+		t.Fatalf("reply_markup = %s", markup)
+	}
+
+	adapter.handleUpdate(context.Background(), tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb1",
+		From:    &tgbotapi.User{ID: 9, UserName: "bob"},
+		Message: &tgbotapi.Message{MessageID: 5, Chat: &tgbotapi.Chat{ID: 42}, Text: "Approval required for exec_command (id 01ABC)"},
+		Data:    "heike_deny:01ABC",
+	}})
+	if len(got) != 1 || got[0] != "42 /deny 01ABC" {
+		t.Fatalf("events = %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["answerCallbackQuery"].Get("callback_query_id") != "cb1" {
+		t.Fatalf("callback not answered: %v", calls["answerCallbackQuery"])
+	}
+	if edit := calls["editMessageText"]; !strings.HasSuffix(edit.Get("text"), "Denied by @bob.") || edit.Get("reply_markup") != "" {
+		t.Fatalf("edit = %v", edit)
+	}
+}
@@ -0,0 +1,288 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+)
+
+// voiceNoteUntranscribed is submitted in place of a transcript that could
+// not be produced, so the agent can tell the user.
+const voiceNoteUntranscribed = "[Voice note could not be transcribed]"
+
+// Transcriber turns an audio file into text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, path string) (string, error)
+}
+
+// commandTranscriber runs <command> <file> and reads the transcript from
+// stdout.
+type commandTranscriber struct {
+	args []string
+}
+
+func (c commandTranscriber) Transcribe(ctx context.Context, path string) (string, error) {
+	args := append(append([]string{}, c.args[1:]...), path)
+	cmd := exec.CommandContext(ctx, c.args[0], args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("transcription command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// openAITranscriber posts audio to an OpenAI-compatible
+// /audio/transcriptions endpoint.
+type openAITranscriber struct {
+	baseURL  string
+	apiKey   string
+	model    string
+	language string
+	client   *http.Client
+}
+
+func (o openAITranscriber) Transcribe(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", err
+	}
+	_ = form.WriteField("model", o.model)
+	_ = form.WriteField("response_format", "json")
+	if o.language != "" {
+		_ = form.WriteField("language", o.language)
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("transcription request: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode transcription: %w", err)
+	}
+	return result.Text, nil
+}
+
+// voiceNotes downloads audio attached to chat messages into the workspace
+// and transcribes it.
+type voiceNotes struct {
+	transcriber Transcriber
+	// dir keeps downloaded audio, one subdirectory per adapter.
+	dir      string
+	maxBytes int64
+	timeout  time.Duration
+	client   *http.Client
+}
+
+// voiceNote is an audio attachment of an incoming message.
+type voiceNote struct {
+	name     string
+	mimeType string
+	// location returns the download URL.
+	location func() (string, error)
+	// header authorizes the download, e.g. with a bot token.
+	header http.Header
+	size   int64
+	// duration is in seconds; 0 when the platform does not say.
+	duration int
+}
+
+// voiceNotesFromConfig builds the transcription pipeline, or returns nil
+// when transcription is disabled.
+func voiceNotesFromConfig(cfg config.TranscriptionConfig, dir string) (*voiceNotes, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("adapters.transcription needs a workspace attachments directory")
+	}
+	timeout, err := config.DurationOrDefault(cfg.Timeout, config.DefaultTranscriptionTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse adapters.transcription.timeout: %w", err)
+	}
+	v := &voiceNotes{
+		dir:      dir,
+		maxBytes: cfg.MaxBytes,
+		timeout:  timeout,
+		client:   &http.Client{},
+	}
+	if v.maxBytes <= 0 {
+		v.maxBytes = config.DefaultTranscriptionMaxBytes
+	}
+
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	switch provider {
+	case "", "openai":
+		baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+		if baseURL == "" {
+			baseURL = config.DefaultTranscriptionBaseURL
+		}
+		apiKey := strings.TrimSpace(cfg.APIKey)
+		if apiKey == "" {
+			apiKey = strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
+		}
+		model := strings.TrimSpace(cfg.Model)
+		if model == "" {
+			model = config.DefaultTranscriptionModel
+		}
+		v.transcriber = openAITranscriber{
+			baseURL:  baseURL,
+			apiKey:   apiKey,
+			model:    model,
+			language: strings.TrimSpace(cfg.Language),
+			client:   v.client,
+		}
+	case "command":
+		args := strings.Fields(cfg.Command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("adapters.transcription.command is required for the command provider")
+		}
+		v.transcriber = commandTranscriber{args: args}
+	default:
+		return nil, fmt.Errorf("adapters.transcription.provider must be %q or %q", "openai", "command")
+	}
+	return v, nil
+}
+
+// content returns what to submit for a message carrying note: the caption,
+// if any, followed by the transcript. The saved file and any transcription
+// error are recorded in metadata.
+func (v *voiceNotes) content(ctx context.Context, source string, note voiceNote, caption string, metadata map[string]string) string {
+	transcript, err := v.transcribe(ctx, source, note, metadata)
+	if err != nil {
+		slog.Warn("Voice note transcription failed", "source", source, "file", note.name, "error", err)
+		metadata["transcription_error"] = err.Error()
+		transcript = voiceNoteUntranscribed
+	}
+	if caption = strings.TrimSpace(caption); caption != "" {
+		return caption + "\n\n" + transcript
+	}
+	return transcript
+}
+
+func (v *voiceNotes) transcribe(ctx context.Context, source string, note voiceNote, metadata map[string]string) (string, error) {
+	if note.size > v.maxBytes {
+		return "", fmt.Errorf("audio is %d bytes, over the %d byte limit", note.size, v.maxBytes)
+	}
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	path, err := v.download(ctx, source, note)
+	if err != nil {
+		return "", err
+	}
+	metadata["audio_file"] = path
+	if note.mimeType != "" {
+		metadata["audio_mime_type"] = note.mimeType
+	}
+	if note.duration > 0 {
+		metadata["audio_duration"] = strconv.Itoa(note.duration)
+	}
+
+	transcript, err := v.transcriber.Transcribe(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if transcript = strings.TrimSpace(transcript); transcript == "" {
+		return "", fmt.Errorf("transcript is empty")
+	}
+	return transcript, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// download saves note under dir/source and returns the file path.
+func (v *voiceNotes) download(ctx context.Context, source string, note voiceNote) (string, error) {
+	location, err := note.location()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", fmt.Errorf("download audio: invalid URL")
+	}
+	for key, values := range note.header {
+		req.Header[key] = values
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		// The URL may carry a bot token, so only the cause is reported.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("download audio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download audio: %s", resp.Status)
+	}
+
+	dir := filepath.Join(v.dir, source)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := unsafeFileChars.ReplaceAllString(note.name, "_")
+	path := filepath.Join(dir, time.Now().UTC().Format("20060102T150405.000")+"-"+name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(f, io.LimitReader(resp.Body, v.maxBytes+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > v.maxBytes {
+		err = fmt.Errorf("audio is over the %d byte limit", v.maxBytes)
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("download audio: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,176 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/harunnryd/heike/internal/config"
+)
+
+type fakeTranscriber struct {
+	text  string
+	paths []string
+}
+
+func (f *fakeTranscriber) Transcribe(ctx context.Context, path string) (string, error) {
+	f.paths = append(f.paths, path)
+	return f.text, nil
+}
+
+func TestVoiceNotes_OpenAIProvider(t *testing.T) {
+	var gotModel, gotAudio string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/clip.webm":
+			if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte("audio-bytes"))
+		case "/v1/audio/transcriptions":
+			if r.Header.Get("Authorization") != "Bearer sk-test" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("form file: %v", err)
+				return
+			}
+			raw, _ := io.ReadAll(file)
+			gotModel, gotAudio = r.FormValue("model"), string(raw)
+			w.Write([]byte(`{"text":" deploy the staging branch "}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	voice, err := voiceNotesFromConfig(config.TranscriptionConfig{Enabled: true, BaseURL: server.URL + "/v1/", APIKey: "sk-test"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer xoxb-test")
+	note := voiceNote{
+		name:     "clip.webm",
+		mimeType: "audio/webm",
+		location: func() (string, error) { return server.URL + "/files/clip.webm", nil },
+		header:   header,
+	}
+
+	metadata := map[string]string{}
+	if got := voice.content(context.Background(), "slack", note, "from the car", metadata); got != "from the car\n\ndeploy the staging branch" {
+		t.Fatalf("content = %q", got)
+	}
+	if gotModel != config.DefaultTranscriptionModel || gotAudio != "audio-bytes" {
+		t.Fatalf("request model/audio = %q/%q", gotModel, gotAudio)
+	}
+	saved, err := os.ReadFile(metadata["audio_file"])
+	if err != nil || string(saved) != "audio-bytes" {
+		t.Fatalf("saved audio = %q, %v (metadata %v)", saved, err, metadata)
+	}
+	if metadata["audio_mime_type"] != "audio/webm" || metadata["transcription_error"] != "" {
+		t.Fatalf("metadata = %v", metadata)
+	}
+
+	note.size = voice.maxBytes + 1
+	metadata = map[string]string{}
+	if got := voice.content(context.Background(), "slack", note, "", metadata); got != voiceNoteUntranscribed {
+		t.Fatalf("content for oversized audio = %q", got)
+	}
+	if !strings.Contains(metadata["transcription_error"], "limit") {
+		t.Fatalf("metadata = %v", metadata)
+	}
+}
+
+func TestVoiceNotesFromConfig(t *testing.T) {
+	if voice, err := voiceNotesFromConfig(config.TranscriptionConfig{}, ""); voice != nil || err != nil {
+		t.Fatalf("disabled = %v, %v", voice, err)
+	}
+	voice, err := voiceNotesFromConfig(config.TranscriptionConfig{Enabled: true, Provider: "command", Command: "cat"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := voice.dir + "/note.txt"
+	if err := os.WriteFile(path, []byte("hello from a file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := voice.transcriber.Transcribe(context.Background(), path); err != nil || got != "hello from a file" {
+		t.Fatalf("command transcript = %q, %v", got, err)
+	}
+
+	for _, cfg := range []config.TranscriptionConfig{
+		{Enabled: true, Provider: "whisper"},
+		{Enabled: true, Provider: "command"},
+		{Enabled: true, Timeout: "soon"},
+	} {
+		if _, err := voiceNotesFromConfig(cfg, t.TempDir()); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+	if _, err := voiceNotesFromConfig(config.TranscriptionConfig{Enabled: true}, ""); err == nil {
+		t.Error("expected error without an attachments directory")
+	}
+}
+
+func TestTelegramAdapter_TranscribesVoiceMessages(t *testing.T) {
+	fake := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/file/bottest-token/voice/file_1.oga":
+			w.Write([]byte("ogg-bytes"))
+			return
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"username":"heike_bot"}}`))
+		case strings.HasSuffix(r.URL.Path, "/getFile"):
+			w.Write([]byte(`{"ok":true,"result":{"file_id":"f1","file_path":"voice/file_1.oga"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fake.Close()
+
+	type submitted struct {
+		content  string
+		metadata map[string]string
+	}
+	var got []submitted
+	adapter := NewTelegramAdapter("test-token", func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		got = append(got, submitted{content, metadata})
+		return nil
+	}, TelegramOptions{})
+	bot, err := tgbotapi.NewBotAPIWithClient("test-token", fake.URL+"/bot%s/%s", fake.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter.bot = bot
+	adapter.fileEndpoint = fake.URL + "/file/bot%s/%s"
+	transcriber := &fakeTranscriber{text: "remind me at five"}
+	adapter.voice = &voiceNotes{transcriber: transcriber, dir: t.TempDir(), maxBytes: 1 << 20, timeout: time.Minute, client: fake.Client()}
+
+	adapter.handleUpdate(context.Background(), tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID: 7,
+		From:      &tgbotapi.User{ID: 9, UserName: "bob"},
+		Chat:      &tgbotapi.Chat{ID: 42},
+		Voice:     &tgbotapi.Voice{FileID: "f1", Duration: 3, MimeType: "audio/ogg", FileSize: 9},
+	}})
+
+	if len(got) != 1 || got[0].content != "remind me at five" {
+		t.Fatalf("events = %+v", got)
+	}
+	md := got[0].metadata
+	if md["audio_duration"] != "3" || md["audio_mime_type"] != "audio/ogg" || !strings.HasSuffix(md["audio_file"], "-voice-7.ogg") {
+		t.Fatalf("metadata = %v", md)
+	}
+	if saved, err := os.ReadFile(md["audio_file"]); err != nil || string(saved) != "ogg-bytes" {
+		t.Fatalf("saved audio = %q, %v", saved, err)
+	}
+}
@@ -0,0 +1,238 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+// maxWebhookBodyBytes caps inbound webhook payloads.
+const maxWebhookBodyBytes = 1 << 20
+
+// WebhookRoute is one inbound webhook. Requests must carry Secret either as
+// an HMAC-SHA256 signature of the body in SignatureHeader or as a bearer
+// token.
+type WebhookRoute struct {
+	Name            string
+	Path            string
+	Secret          string
+	SignatureHeader string
+	// Session and Template are text/template sources rendered against the
+	// decoded JSON payload. Session defaults to "webhook:<name>" and
+	// Template to the raw body.
+	Session  string
+	Template string
+}
+
+type webhookRoute struct {
+	WebhookRoute
+	session  *template.Template
+	template *template.Template
+}
+
+// WebhookAdapter serves authenticated webhook routes and turns each payload
+// into a background "webhook" event. Replies are dropped; callers read the
+// session transcript or register a callback through the API.
+type WebhookAdapter struct {
+	port         int
+	routes       []*webhookRoute
+	eventHandler EventHandler
+	server       *http.Server
+}
+
+func NewWebhookAdapter(port int, routes []WebhookRoute, eventHandler EventHandler) (*WebhookAdapter, error) {
+	a := &WebhookAdapter{port: port, eventHandler: eventHandler}
+	for _, route := range routes {
+		compiled := &webhookRoute{WebhookRoute: route}
+		if compiled.SignatureHeader == "" {
+			compiled.SignatureHeader = "X-Hub-Signature-256"
+		}
+		var err error
+		if route.Session != "" {
+			if compiled.session, err = parseWebhookTemplate(route.Name+".session", route.Session); err != nil {
+				return nil, err
+			}
+		}
+		if route.Template != "" {
+			if compiled.template, err = parseWebhookTemplate(route.Name+".template", route.Template); err != nil {
+				return nil, err
+			}
+		}
+		a.routes = append(a.routes, compiled)
+	}
+	return a, nil
+}
+
+func parseWebhookTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		// header is replaced per request.
+		"header": func(string) string { return "" },
+	}).Parse(text)
+	if err != nil {
+		return nil, errors.InvalidInput(fmt.Sprintf("invalid webhook template %s: %v", name, err))
+	}
+	return tmpl, nil
+}
+
+func (a *WebhookAdapter) Name() string {
+	return "webhook"
+}
+
+func (a *WebhookAdapter) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	for _, route := range a.routes {
+		route := route
+		mux.HandleFunc(route.Path, func(w http.ResponseWriter, r *http.Request) {
+			a.handle(w, r, route)
+		})
+	}
+
+	a.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", a.port),
+		Handler: mux,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("Webhook Adapter listening", "port", a.port, "routes", len(a.routes))
+		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return a.server.Shutdown(context.Background())
+	case err := <-serveErr:
+		return errors.Wrap(err, "webhook server failed")
+	}
+}
+
+func (a *WebhookAdapter) Stop(ctx context.Context) error {
+	if a.server == nil {
+		return nil
+	}
+	return a.server.Shutdown(ctx)
+}
+
+// Send drops replies: webhook senders do not read a response channel.
+func (a *WebhookAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	slog.Debug("Dropping webhook session reply", "session", sessionID, "content_length", len(content))
+	return nil
+}
+
+func (a *WebhookAdapter) Health(ctx context.Context) error {
+	if a.server == nil {
+		return errors.Transient("Webhook server not started")
+	}
+	return nil
+}
+
+func (a *WebhookAdapter) handle(w http.ResponseWriter, r *http.Request, route *webhookRoute) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxWebhookBodyBytes {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !route.authorized(r.Header, body) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var payload interface{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "payload must be JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	sessionID := "webhook:" + route.Name
+	if route.session != nil {
+		rendered, err := renderWebhookTemplate(route.session, r.Header, payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if rendered = strings.TrimSpace(rendered); rendered != "" && !strings.Contains(rendered, "<no value>") {
+			sessionID = rendered
+		}
+	}
+	content := string(body)
+	if route.template != nil {
+		if content, err = renderWebhookTemplate(route.template, r.Header, payload); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	metadata := map[string]string{
+		"webhook_route": route.Name,
+	}
+	if a.eventHandler != nil {
+		if err := a.eventHandler(r.Context(), "webhook", "webhook", sessionID, content, metadata); err != nil {
+			slog.Error("Failed to handle webhook event", "route", route.Name, "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "accepted", "session_id": sessionID})
+}
+
+// authorized accepts a "sha256=<hex>" (or bare hex) HMAC of the body in the
+// route's signature header, or the secret as a bearer token.
+func (r *webhookRoute) authorized(header http.Header, body []byte) bool {
+	if sig := strings.TrimSpace(header.Get(r.SignatureHeader)); sig != "" {
+		mac := hmac.New(sha256.New, []byte(r.Secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		sig = strings.TrimPrefix(sig, "sha256=")
+		return subtle.ConstantTimeCompare([]byte(strings.ToLower(sig)), []byte(expected)) == 1
+	}
+	token, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(r.Secret)) == 1
+}
+
+func renderWebhookTemplate(tmpl *template.Template, header http.Header, payload interface{}) (string, error) {
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return "", err
+	}
+	clone.Funcs(template.FuncMap{"header": header.Get})
+	var buf bytes.Buffer
+	if err := clone.Execute(&buf, payload); err != nil {
+		return "", fmt.Errorf("render %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
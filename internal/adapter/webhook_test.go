@@ -0,0 +1,102 @@
+package adapter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+)
+
+func TestWebhookAdapter_RoutesPayloadThroughTemplates(t *testing.T) {
+	type submitted struct {
+		eventType, sessionID, content string
+	}
+	var got []submitted
+	adapter, err := NewWebhookAdapter(0, []WebhookRoute{{
+		Name:     "ci",
+		Path:     "/hooks/ci",
+		Secret:   "s3cret",
+		Session:  "ci:{{.repository.name}}",
+		Template: "Build {{.build.status}} on {{.repository.name}} ({{header \"X-Event\"}}): {{json .build.failed}}",
+	}, {
+		Name:   "raw",
+		Path:   "/hooks/raw",
+		Secret: "token",
+	}}, func(ctx context.Context, source, eventType, sessionID, content string, metadata map[string]string) error {
+		if source != "webhook" {
+			t.Errorf("source = %s", source)
+		}
+		got = append(got, submitted{eventType, sessionID, content})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"repository":{"name":"heike"},"build":{"status":"failed","failed":["lint"]}}`
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(body))
+
+	post := func(route int, body string, header map[string]string) int {
+		req := httptest.NewRequest(http.MethodPost, adapter.routes[route].Path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		adapter.handle(rec, req, adapter.routes[route])
+		return rec.Code
+	}
+
+	if code := post(0, body, map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil)), "X-Event": "push"}); code != http.StatusAccepted {
+		t.Fatalf("signed request = %d", code)
+	}
+	if code := post(0, body, map[string]string{"X-Hub-Signature-256": "sha256=00"}); code != http.StatusUnauthorized {
+		t.Fatalf("bad signature = %d", code)
+	}
+	if code := post(1, body, map[string]string{"Authorization": "Bearer wrong"}); code != http.StatusUnauthorized {
+		t.Fatalf("bad token = %d", code)
+	}
+	if code := post(1, "not json", map[string]string{"Authorization": "Bearer token"}); code != http.StatusBadRequest {
+		t.Fatalf("invalid json = %d", code)
+	}
+	if code := post(1, body, map[string]string{"Authorization": "Bearer token"}); code != http.StatusAccepted {
+		t.Fatalf("bearer request = %d", code)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("events = %+v", got)
+	}
+	if got[0] != (submitted{"webhook", "ci:heike", `Build failed on heike (push): ["lint"]`}) {
+		t.Fatalf("templated event = %+v", got[0])
+	}
+	if got[1] != (submitted{"webhook", "webhook:raw", body}) {
+		t.Fatalf("raw event = %+v", got[1])
+	}
+}
+
+func TestWebhookRoutesFromConfig_Validates(t *testing.T) {
+	routes, err := webhookRoutesFromConfig(config.WebhookConfig{Routes: []config.WebhookRouteConfig{{Name: "ci", Secret: "x"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if routes[0].Path != "/webhooks/ci" {
+		t.Fatalf("default path = %s", routes[0].Path)
+	}
+
+	for _, cfg := range []config.WebhookConfig{
+		{},
+		{Routes: []config.WebhookRouteConfig{{Name: "ci"}}},
+		{Routes: []config.WebhookRouteConfig{{Name: "ci", Path: "hooks", Secret: "x"}}},
+		{Routes: []config.WebhookRouteConfig{{Name: "a", Path: "/h", Secret: "x"}, {Name: "b", Path: "/h", Secret: "y"}}},
+	} {
+		if _, err := webhookRoutesFromConfig(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -250,22 +251,125 @@ func openBrowser(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
+// codexTokenEndpoint is the OAuth token URL; tests point it at a fake server.
+var codexTokenEndpoint = codexOAuthTokenURL
+
+// RefreshCodexToken exchanges a refresh token for a new access token. Fields
+// the response omits (refresh token, account ID) are kept from current.
+func RefreshCodexToken(ctx context.Context, current *CodexToken) (*CodexToken, error) {
+	if current == nil || strings.TrimSpace(current.RefreshToken) == "" {
+		return nil, fmt.Errorf("no refresh token stored, run 'heike provider login openai-codex'")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", codexOAuthClientID)
+	form.Set("refresh_token", current.RefreshToken)
+	form.Set("scope", codexOAuthScope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, codexTokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, fmt.Errorf("token refresh failed (http %d): %s", resp.StatusCode, string(body))
+	}
+
+	var token CodexToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token refresh returned no access token")
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = current.RefreshToken
+	}
+	if token.IDToken == "" {
+		token.IDToken = current.IDToken
+	}
+	if token.AccountID == "" {
+		token.AccountID = current.AccountID
+	}
+	return &token, nil
+}
+
+// CodexSecretName is the secret store name of the OpenAI Codex OAuth token.
+const CodexSecretName = "codex"
+
+// SaveToken stores the token in the configured secret store. With the file
+// backend it is written to tokenPath (default ~/.heike/auth/codex.json)
+// through a temp file and a rename, so a crash or a concurrent reader never
+// sees a partial file.
 func SaveToken(token *CodexToken, tokenPath string) error {
-	path, err := ResolveTokenPath(tokenPath)
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	st, err := openSecretStore(CodexSecretName, tokenPath)
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+	return st.Set(CodexSecretName, append(data, '\n'))
+}
+
+// LoadToken reads the token saved by SaveToken.
+func LoadToken(tokenPath string) (*CodexToken, error) {
+	st, err := openSecretStore(CodexSecretName, tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := st.Get(CodexSecretName)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, fmt.Errorf("no codex token in %s, run 'heike provider login openai-codex'", st.Location(CodexSecretName))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var token CodexToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("parse codex token: %w", err)
+	}
+	return &token, nil
+}
+
+// writeFileAtomic writes data to a 0600 temp file next to path and renames it
+// into place.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return err
 	}
 
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
 	if err != nil {
 		return err
 	}
-	defer f.Close()
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
 
-	return json.NewEncoder(f).Encode(token)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
 }
 
 func ResolveTokenPath(tokenPath string) (string, error) {
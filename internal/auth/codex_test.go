@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -71,3 +74,55 @@ func TestResolveTokenPath_ExpandsHomeShortcut(t *testing.T) {
 		t.Fatalf("path mismatch: got %q want %q", got, want)
 	}
 }
+
+func TestRefreshCodexToken_KeepsOmittedFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("parse form: %v", err)
+		}
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "rt-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"at-2","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	original := codexTokenEndpoint
+	codexTokenEndpoint = server.URL
+	defer func() { codexTokenEndpoint = original }()
+
+	token, err := RefreshCodexToken(context.Background(), &CodexToken{AccessToken: "at-1", RefreshToken: "rt-1", AccountID: "acct"})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if token.AccessToken != "at-2" || token.RefreshToken != "rt-1" || token.AccountID != "acct" {
+		t.Fatalf("unexpected refreshed token: %+v", token)
+	}
+
+	if _, err := RefreshCodexToken(context.Background(), &CodexToken{AccessToken: "at-1"}); err == nil {
+		t.Fatal("expected error without refresh token")
+	}
+}
+
+func TestSaveToken_ReplacesFileAtomically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth", "codex.json")
+	if err := SaveToken(&CodexToken{AccessToken: "one"}, path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := SaveToken(&CodexToken{AccessToken: "two"}, path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected 0600 token file, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temp files left behind, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringNotFoundCode is the exit status security(1) uses for a missing item;
+// the Windows script below exits with it too.
+const keyringNotFoundCode = 44
+
+// windowsVaultPrelude loads the Credential Manager password vault. The
+// service and account come from the environment to avoid quoting.
+const windowsVaultPrelude = `$ErrorActionPreference = 'Stop'
+[void][Windows.Security.Credentials.PasswordVault, Windows.Security.Credentials, ContentType = WindowsRuntime]
+$vault = New-Object Windows.Security.Credentials.PasswordVault
+$service = $env:HEIKE_KEYRING_SERVICE
+$account = $env:HEIKE_KEYRING_ACCOUNT
+`
+
+// KeyringSecretStore keeps secrets in the OS credential store: the macOS
+// Keychain through security(1), the Secret Service (GNOME Keyring, KWallet)
+// through secret-tool(1), and the Windows Credential Manager through
+// PowerShell. Secrets are base64-encoded so every backend stores plain text.
+type KeyringSecretStore struct {
+	service string
+	goos    string
+	run     commandRunner
+}
+
+type commandResult struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// commandRunner runs name with args, extra environment and stdin. A non-zero
+// exit is reported in the result, not as an error.
+type commandRunner func(name string, args, env []string, stdin string) (commandResult, error)
+
+func NewKeyringSecretStore(service string) *KeyringSecretStore {
+	return &KeyringSecretStore{service: service, goos: runtime.GOOS, run: runCommand}
+}
+
+func (s *KeyringSecretStore) Get(name string) ([]byte, error) {
+	var res commandResult
+	var err error
+	switch s.goos {
+	case "darwin":
+		res, err = s.exec("security", []string{"find-generic-password", "-s", s.service, "-a", name, "-w"}, nil, "")
+		if err == nil && res.code == keyringNotFoundCode {
+			return nil, fmt.Errorf("keychain item %s: %w", s.Location(name), ErrSecretNotFound)
+		}
+	case "windows":
+		res, err = s.powershell(name, `try { $cred = $vault.Retrieve($service, $account) } catch { exit 44 }
+$cred.RetrievePassword()
+[Console]::Out.Write($cred.Password)`, "")
+		if err == nil && res.code == keyringNotFoundCode {
+			return nil, fmt.Errorf("credential %s: %w", s.Location(name), ErrSecretNotFound)
+		}
+	default:
+		// secret-tool exits 1 without output when nothing matches.
+		res, err = s.exec("secret-tool", []string{"lookup", "service", s.service, "account", name}, nil, "")
+		if err == nil && res.code == 1 && strings.TrimSpace(res.stderr) == "" {
+			return nil, fmt.Errorf("secret %s: %w", s.Location(name), ErrSecretNotFound)
+		}
+	}
+	if err := keyringResultError("read", name, res, err); err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(res.stdout))
+	if err != nil {
+		return nil, fmt.Errorf("decode keyring secret %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (s *KeyringSecretStore) Set(name string, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var res commandResult
+	var err error
+	switch s.goos {
+	case "darwin":
+		// Interactive mode reads the command from stdin, keeping the secret
+		// out of the process list.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", s.service, name, encoded)
+		res, err = s.exec("security", []string{"-i"}, nil, command)
+	case "windows":
+		res, err = s.powershell(name, `$password = [Console]::In.ReadToEnd()
+try { $vault.Remove($vault.Retrieve($service, $account)) } catch {}
+$vault.Add((New-Object Windows.Security.Credentials.PasswordCredential($service, $account, $password)))`, encoded)
+	default:
+		res, err = s.exec("secret-tool", []string{"store", "--label", s.service + " " + name, "service", s.service, "account", name}, nil, encoded)
+	}
+	return keyringResultError("save", name, res, err)
+}
+
+func (s *KeyringSecretStore) Delete(name string) error {
+	var res commandResult
+	var err error
+	switch s.goos {
+	case "darwin":
+		res, err = s.exec("security", []string{"delete-generic-password", "-s", s.service, "-a", name}, nil, "")
+		if err == nil && res.code == keyringNotFoundCode {
+			return nil
+		}
+	case "windows":
+		res, err = s.powershell(name, `try { $vault.Remove($vault.Retrieve($service, $account)) } catch {}`, "")
+	default:
+		res, err = s.exec("secret-tool", []string{"clear", "service", s.service, "account", name}, nil, "")
+	}
+	return keyringResultError("delete", name, res, err)
+}
+
+func (s *KeyringSecretStore) Location(name string) string {
+	switch s.goos {
+	case "darwin":
+		return fmt.Sprintf("macOS Keychain (service %s, account %s)", s.service, name)
+	case "windows":
+		return fmt.Sprintf("Windows Credential Manager (resource %s, user %s)", s.service, name)
+	default:
+		return fmt.Sprintf("Secret Service keyring (service %s, account %s)", s.service, name)
+	}
+}
+
+func (s *KeyringSecretStore) exec(name string, args, env []string, stdin string) (commandResult, error) {
+	res, err := s.run(name, args, env, stdin)
+	if errors.Is(err, exec.ErrNotFound) {
+		return res, fmt.Errorf("%s not found, install it or set auth.secret_store: file", name)
+	}
+	return res, err
+}
+
+func (s *KeyringSecretStore) powershell(name, script, stdin string) (commandResult, error) {
+	env := []string{"HEIKE_KEYRING_SERVICE=" + s.service, "HEIKE_KEYRING_ACCOUNT=" + name}
+	return s.exec("powershell", []string{"-NoProfile", "-NonInteractive", "-Command", windowsVaultPrelude + script}, env, stdin)
+}
+
+func keyringResultError(op, name string, res commandResult, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s keyring secret %s: %w", op, name, err)
+	}
+	if res.code != 0 {
+		return fmt.Errorf("%s keyring secret %s: exit status %d: %s", op, name, res.code, strings.TrimSpace(res.stderr))
+	}
+	return nil
+}
+
+func runCommand(name string, args, env []string, stdin string) (commandResult, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	res := commandResult{stdout: stdout.String(), stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.code = exitErr.ExitCode()
+		return res, nil
+	}
+	return res, err
+}
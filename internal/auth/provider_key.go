@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyProviders are the providers whose API key 'heike provider login' can
+// store. Neither offers an OAuth flow for third-party API clients, so login
+// saves a verified API key instead of a token.
+var APIKeyProviders = []string{"anthropic", "gemini"}
+
+// ProviderCredential is an API key saved by 'heike provider login'.
+type ProviderCredential struct {
+	Provider  string    `json:"provider"`
+	APIKey    string    `json:"api_key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsAPIKeyProvider reports whether provider stores its API key in an auth file.
+func IsAPIKeyProvider(provider string) bool {
+	for _, name := range APIKeyProviders {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveProviderAPIKey stores apiKey for provider in the configured secret
+// store and returns where it went. With the file backend it is written to
+// authFile (or the default path) with owner-only permissions.
+func SaveProviderAPIKey(provider, apiKey, authFile string) (string, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return "", fmt.Errorf("api key is empty")
+	}
+	cred := ProviderCredential{Provider: provider, APIKey: apiKey, CreatedAt: time.Now().UTC()}
+	data, err := json.Marshal(cred)
+	if err != nil {
+		return "", err
+	}
+	st, err := openSecretStore(provider, authFile)
+	if err != nil {
+		return "", err
+	}
+	if err := st.Set(provider, append(data, '\n')); err != nil {
+		return "", err
+	}
+	return st.Location(provider), nil
+}
+
+// LoadProviderAPIKey reads the API key saved for provider. A missing default
+// entry yields an empty key; a missing explicit authFile is an error.
+func LoadProviderAPIKey(provider, authFile string) (string, error) {
+	st, err := openSecretStore(provider, authFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := st.Get(provider)
+	if errors.Is(err, ErrSecretNotFound) && (strings.TrimSpace(authFile) == "" || SecretBackend() != SecretStoreFile) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read %s auth file: %w", provider, err)
+	}
+
+	var cred ProviderCredential
+	if err := json.Unmarshal(data, &cred); err != nil {
+		return "", fmt.Errorf("parse %s credential in %s: %w", provider, st.Location(provider), err)
+	}
+	if cred.Provider != "" && cred.Provider != provider {
+		return "", fmt.Errorf("%s holds a %s credential, not %s", st.Location(provider), cred.Provider, provider)
+	}
+	return strings.TrimSpace(cred.APIKey), nil
+}
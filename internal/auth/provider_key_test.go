@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderAPIKey_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth", "anthropic.json")
+
+	saved, err := SaveProviderAPIKey("anthropic", "  sk-ant-test\n", path)
+	if err != nil {
+		t.Fatalf("SaveProviderAPIKey: %v", err)
+	}
+	if saved != path {
+		t.Fatalf("saved path = %s, want %s", saved, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("auth file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	key, err := LoadProviderAPIKey("anthropic", path)
+	if err != nil {
+		t.Fatalf("LoadProviderAPIKey: %v", err)
+	}
+	if key != "sk-ant-test" {
+		t.Fatalf("key = %q", key)
+	}
+
+	if _, err := LoadProviderAPIKey("gemini", path); err == nil {
+		t.Fatal("expected error loading another provider's credential")
+	}
+}
+
+func TestLoadProviderAPIKey_MissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	key, err := LoadProviderAPIKey("gemini", "")
+	if err != nil || key != "" {
+		t.Fatalf("missing default file should yield no key, got %q, %v", key, err)
+	}
+	if _, err := LoadProviderAPIKey("gemini", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing explicit auth file")
+	}
+}
+
+func TestSaveProviderAPIKey_RejectsEmptyKey(t *testing.T) {
+	if _, err := SaveProviderAPIKey("gemini", " ", filepath.Join(t.TempDir(), "gemini.json")); err == nil {
+		t.Fatal("expected error for empty key")
+	}
+}
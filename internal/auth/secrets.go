@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/pathutil"
+)
+
+// Secret store backends selectable with auth.secret_store.
+const (
+	SecretStoreFile    = "file"
+	SecretStoreKeyring = "keyring"
+)
+
+// KeyringService is the service name heike secrets are filed under in the OS
+// credential store.
+const KeyringService = "heike"
+
+// ErrSecretNotFound is returned by SecretStore.Get when nothing is stored
+// under the name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore keeps provider credentials (API keys and OAuth tokens) as
+// opaque blobs, one per name: "codex" for the OpenAI Codex token and the
+// provider name for API keys.
+type SecretStore interface {
+	Get(name string) ([]byte, error)
+	Set(name string, data []byte) error
+	Delete(name string) error
+	// Location describes where name is kept, for user-facing messages.
+	Location(name string) string
+}
+
+// FileSecretStore keeps each secret in a 0600 JSON file, <Dir>/<name>.json
+// unless Paths overrides the file for that name.
+type FileSecretStore struct {
+	Dir   string
+	Paths map[string]string
+}
+
+func (s *FileSecretStore) path(name string) (string, error) {
+	if path := strings.TrimSpace(s.Paths[name]); path != "" {
+		return pathutil.Expand(path)
+	}
+	dir := s.Dir
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".heike", "auth")
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+func (s *FileSecretStore) Get(name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", path, ErrSecretNotFound)
+	}
+	return data, err
+}
+
+func (s *FileSecretStore) Set(name string, data []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func (s *FileSecretStore) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileSecretStore) Location(name string) string {
+	path, err := s.path(name)
+	if err != nil {
+		return name + ".json"
+	}
+	return path
+}
+
+// NewSecretStore returns the store for backend ("" selects file).
+func NewSecretStore(backend string) (SecretStore, error) {
+	switch strings.TrimSpace(backend) {
+	case "", SecretStoreFile:
+		return &FileSecretStore{}, nil
+	case SecretStoreKeyring:
+		return NewKeyringSecretStore(KeyringService), nil
+	default:
+		return nil, heikeErrors.InvalidInput(fmt.Sprintf("unsupported auth.secret_store %q (use file or keyring)", backend))
+	}
+}
+
+var (
+	secretBackendMu sync.RWMutex
+	secretBackend   = SecretStoreFile
+)
+
+// SetSecretBackend selects where login saves credentials and where providers
+// read them. It is set once from auth.secret_store when the config loads.
+func SetSecretBackend(backend string) error {
+	if _, err := NewSecretStore(backend); err != nil {
+		return err
+	}
+	if backend == "" {
+		backend = SecretStoreFile
+	}
+	secretBackendMu.Lock()
+	defer secretBackendMu.Unlock()
+	secretBackend = backend
+	return nil
+}
+
+// SecretBackend returns the selected backend.
+func SecretBackend() string {
+	secretBackendMu.RLock()
+	defer secretBackendMu.RUnlock()
+	return secretBackend
+}
+
+// openSecretStore returns the selected store. With the file backend, path
+// (a configured token_path or auth_file) overrides the file of name; the
+// keyring ignores it.
+func openSecretStore(name, path string) (SecretStore, error) {
+	st, err := NewSecretStore(SecretBackend())
+	if err != nil {
+		return nil, err
+	}
+	if fileStore, ok := st.(*FileSecretStore); ok && strings.TrimSpace(path) != "" {
+		fileStore.Paths = map[string]string{name: path}
+	}
+	return st, nil
+}
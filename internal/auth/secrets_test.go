@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring emulates security(1), secret-tool(1) and the PowerShell vault
+// scripts over an in-memory map keyed by account.
+type fakeKeyring struct {
+	items map[string]string
+	calls []string
+}
+
+func (f *fakeKeyring) run(name string, args, env []string, stdin string) (commandResult, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	switch name {
+	case "security":
+		if args[0] == "-i" {
+			fields := strings.Fields(stdin)
+			f.items[fields[len(fields)-3]] = fields[len(fields)-1]
+			return commandResult{}, nil
+		}
+		account := args[4]
+		value, ok := f.items[account]
+		if !ok {
+			return commandResult{code: keyringNotFoundCode}, nil
+		}
+		if args[0] == "delete-generic-password" {
+			delete(f.items, account)
+			return commandResult{}, nil
+		}
+		return commandResult{stdout: value + "\n"}, nil
+	case "secret-tool":
+		account := args[len(args)-1]
+		switch args[0] {
+		case "store":
+			f.items[account] = stdin
+		case "clear":
+			delete(f.items, account)
+		case "lookup":
+			value, ok := f.items[account]
+			if !ok {
+				return commandResult{code: 1}, nil
+			}
+			return commandResult{stdout: value}, nil
+		}
+		return commandResult{}, nil
+	case "powershell":
+		account := strings.TrimPrefix(env[1], "HEIKE_KEYRING_ACCOUNT=")
+		script := args[len(args)-1]
+		switch {
+		case strings.Contains(script, "ReadToEnd"):
+			f.items[account] = stdin
+		case strings.Contains(script, "RetrievePassword"):
+			value, ok := f.items[account]
+			if !ok {
+				return commandResult{code: keyringNotFoundCode}, nil
+			}
+			return commandResult{stdout: value}, nil
+		default:
+			delete(f.items, account)
+		}
+		return commandResult{}, nil
+	}
+	return commandResult{}, exec.ErrNotFound
+}
+
+func TestKeyringSecretStore_RoundTrip(t *testing.T) {
+	for _, goos := range []string{"darwin", "linux", "windows"} {
+		t.Run(goos, func(t *testing.T) {
+			fake := &fakeKeyring{items: make(map[string]string)}
+			st := &KeyringSecretStore{service: KeyringService, goos: goos, run: fake.run}
+
+			if _, err := st.Get("anthropic"); !errors.Is(err, ErrSecretNotFound) {
+				t.Fatalf("Get before Set error = %v, want ErrSecretNotFound", err)
+			}
+			secret := []byte("{\"api_key\":\"sk-ant-test\"}\n")
+			if err := st.Set("anthropic", secret); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			got, err := st.Get("anthropic")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if string(got) != string(secret) {
+				t.Fatalf("Get = %q, want %q", got, secret)
+			}
+			if err := st.Delete("anthropic"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := st.Get("anthropic"); !errors.Is(err, ErrSecretNotFound) {
+				t.Fatalf("Get after Delete error = %v, want ErrSecretNotFound", err)
+			}
+			for _, call := range fake.calls {
+				if strings.Contains(call, "sk-ant-test") {
+					t.Fatalf("secret leaked into command arguments: %s", call)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyringSecretStore_ReportsMissingTool(t *testing.T) {
+	st := &KeyringSecretStore{service: KeyringService, goos: "freebsd", run: func(string, []string, []string, string) (commandResult, error) {
+		return commandResult{}, exec.ErrNotFound
+	}}
+	err := st.Set("gemini", []byte("x"))
+	if err == nil || !strings.Contains(err.Error(), "auth.secret_store: file") {
+		t.Fatalf("expected hint to use the file store, got %v", err)
+	}
+}
+
+func TestKeyringSecretStore_ReportsCommandFailure(t *testing.T) {
+	st := &KeyringSecretStore{service: KeyringService, goos: "linux", run: func(string, []string, []string, string) (commandResult, error) {
+		return commandResult{code: 1, stderr: "Cannot autolaunch D-Bus"}, nil
+	}}
+	_, err := st.Get("gemini")
+	if err == nil || errors.Is(err, ErrSecretNotFound) || !strings.Contains(err.Error(), "D-Bus") {
+		t.Fatalf("expected command failure, got %v", err)
+	}
+}
+
+func TestFileSecretStore_PathOverride(t *testing.T) {
+	dir := t.TempDir()
+	override := dir + "/custom.json"
+	st := &FileSecretStore{Dir: dir, Paths: map[string]string{"codex": override}}
+
+	if err := st.Set("codex", []byte("token")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if st.Location("codex") != override || st.Location("gemini") != dir+"/gemini.json" {
+		t.Fatalf("unexpected locations: %s, %s", st.Location("codex"), st.Location("gemini"))
+	}
+	if _, err := st.Get("gemini"); !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("Get missing error = %v, want ErrSecretNotFound", err)
+	}
+	if err := st.Delete("codex"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := st.Delete("codex"); err != nil {
+		t.Fatalf("Delete of a missing secret should succeed: %v", err)
+	}
+}
+
+func TestSetSecretBackend(t *testing.T) {
+	t.Cleanup(func() { _ = SetSecretBackend(SecretStoreFile) })
+
+	if err := SetSecretBackend("vault"); err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+	if err := SetSecretBackend(SecretStoreKeyring); err != nil {
+		t.Fatalf("SetSecretBackend: %v", err)
+	}
+	if SecretBackend() != SecretStoreKeyring {
+		t.Fatalf("SecretBackend() = %s", SecretBackend())
+	}
+	if err := SetSecretBackend(""); err != nil || SecretBackend() != SecretStoreFile {
+		t.Fatalf("empty backend should select file, got %s (%v)", SecretBackend(), err)
+	}
+}
@@ -0,0 +1,340 @@
+// Package backup copies workspace state to object storage: periodic workspace
+// snapshots, rotated transcripts, and large artifacts, with retention rules so
+// the bucket does not grow without bound.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/objectstore"
+)
+
+const (
+	snapshotsDir   = "snapshots"
+	transcriptsDir = "transcripts"
+	artifactsDir   = "artifacts"
+
+	// rotatedTranscriptSuffix matches transcripts renamed by the store worker
+	// when they exceed store.transcript_rotate_max_bytes.
+	rotatedTranscriptSuffix = ".bak"
+	lockFileName            = "workspace.lock"
+	// migrationBackupsDir holds local copies taken before schema migrations.
+	migrationBackupsDir = "migration-backups"
+)
+
+// Config controls what is copied and how long remote copies are kept.
+type Config struct {
+	WorkspaceID   string
+	WorkspacePath string
+	Interval      time.Duration
+
+	Transcripts      bool
+	Artifacts        bool
+	ArtifactMinBytes int64
+
+	// KeepLast snapshots are always kept; older ones are deleted once they
+	// exceed SnapshotMaxAge. Zero values disable the respective rule.
+	KeepLast       int
+	SnapshotMaxAge time.Duration
+	// ArchiveMaxAge expires uploaded transcripts and artifacts. Zero keeps
+	// them forever.
+	ArchiveMaxAge time.Duration
+}
+
+// Manager runs backup passes against a bucket location.
+type Manager struct {
+	cfg    Config
+	bucket objectstore.Bucket
+	loc    objectstore.Location
+	now    func() time.Time
+
+	mu      sync.Mutex
+	started bool
+}
+
+func NewManager(cfg Config, bucket objectstore.Bucket, loc objectstore.Location) (*Manager, error) {
+	if bucket == nil {
+		return nil, errors.InvalidInput("backup bucket is required")
+	}
+	if cfg.WorkspaceID == "" || cfg.WorkspacePath == "" {
+		return nil, errors.InvalidInput("backup workspace is required")
+	}
+	if cfg.Interval <= 0 {
+		return nil, errors.InvalidInput("backup interval must be positive")
+	}
+	return &Manager{cfg: cfg, bucket: bucket, loc: loc, now: time.Now}, nil
+}
+
+// Start runs a backup pass on every interval until ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return
+	}
+	m.started = true
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.mu.Lock()
+				m.started = false
+				m.mu.Unlock()
+				return
+			case <-ticker.C:
+				if err := m.Run(ctx); err != nil && ctx.Err() == nil {
+					slog.Warn("Backup pass failed", "destination", m.loc.String(), "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Run takes a snapshot, archives transcripts and artifacts when enabled, and
+// applies retention. Each step runs even if an earlier one fails.
+func (m *Manager) Run(ctx context.Context) error {
+	var errs []error
+	if key, err := m.Snapshot(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("snapshot: %w", err))
+	} else {
+		slog.Info("Workspace snapshot uploaded", "workspace", m.cfg.WorkspaceID, "key", key)
+	}
+	if m.cfg.Transcripts {
+		if err := m.archive(ctx, transcriptsDir, filepath.Join(m.cfg.WorkspacePath, "sessions"), m.rotatedTranscripts); err != nil {
+			errs = append(errs, fmt.Errorf("transcripts: %w", err))
+		}
+	}
+	if m.cfg.Artifacts {
+		if err := m.archive(ctx, artifactsDir, filepath.Join(m.cfg.WorkspacePath, artifactsDir), m.largeArtifacts); err != nil {
+			errs = append(errs, fmt.Errorf("artifacts: %w", err))
+		}
+	}
+	if err := m.applyRetention(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("retention: %w", err))
+	}
+	return stdErrors.Join(errs...)
+}
+
+// Snapshot uploads a tar.gz of the workspace. Rotated transcripts and the
+// artifacts directory are left out since they are archived separately.
+func (m *Manager) Snapshot(ctx context.Context) (string, error) {
+	tmp, err := os.CreateTemp("", "heike-snapshot-*.tar.gz")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create snapshot file")
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := m.writeSnapshot(tmp); err != nil {
+		return "", err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", errors.Wrap(err, "failed to rewind snapshot")
+	}
+
+	key := m.key(snapshotsDir, m.now().UTC().Format("20060102T150405Z")+".tar.gz")
+	if err := m.bucket.Put(ctx, key, tmp); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (m *Manager) writeSnapshot(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(m.cfg.WorkspacePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.cfg.WorkspacePath, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == artifactsDir || rel == migrationBackupsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || rel == lockFileName || strings.HasSuffix(rel, rotatedTranscriptSuffix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.CopyN(tw, f, header.Size)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to write workspace snapshot")
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish snapshot archive")
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish snapshot compression")
+	}
+	return nil
+}
+
+// archive uploads files under localRoot that are not yet present under dir in
+// the bucket. Keys mirror the path relative to localRoot.
+func (m *Manager) archive(ctx context.Context, dir, localRoot string, collect func(root string) ([]string, error)) error {
+	files, err := collect(localRoot)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	remote, err := m.bucket.List(ctx, m.key(dir)+"/")
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(remote))
+	for _, obj := range remote {
+		existing[obj.Key] = true
+	}
+
+	var errs []error
+	for _, rel := range files {
+		key := m.key(dir, rel)
+		if existing[key] {
+			continue
+		}
+		if err := m.upload(ctx, key, filepath.Join(localRoot, filepath.FromSlash(rel))); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rel, err))
+			continue
+		}
+		slog.Info("Archived file to object storage", "workspace", m.cfg.WorkspaceID, "key", key)
+	}
+	return stdErrors.Join(errs...)
+}
+
+func (m *Manager) upload(ctx context.Context, key, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to open file for upload")
+	}
+	defer f.Close()
+	return m.bucket.Put(ctx, key, f)
+}
+
+func (m *Manager) rotatedTranscripts(root string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, "*"+rotatedTranscriptSuffix))
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		files = append(files, filepath.Base(match))
+	}
+	return files, nil
+}
+
+func (m *Manager) largeArtifacts(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || d.IsDir() || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < m.cfg.ArtifactMinBytes {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+// applyRetention deletes expired snapshots and archives.
+func (m *Manager) applyRetention(ctx context.Context) error {
+	now := m.now()
+	var expired []string
+
+	snapshots, err := m.bucket.List(ctx, m.key(snapshotsDir)+"/")
+	if err != nil {
+		return err
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Key > snapshots[j].Key })
+	for i, obj := range snapshots {
+		if m.cfg.KeepLast > 0 && i < m.cfg.KeepLast {
+			continue
+		}
+		if m.cfg.SnapshotMaxAge > 0 && now.Sub(obj.LastModified) > m.cfg.SnapshotMaxAge {
+			expired = append(expired, obj.Key)
+		}
+	}
+
+	if m.cfg.ArchiveMaxAge > 0 {
+		for _, dir := range []string{transcriptsDir, artifactsDir} {
+			objects, err := m.bucket.List(ctx, m.key(dir)+"/")
+			if err != nil {
+				return err
+			}
+			for _, obj := range objects {
+				if now.Sub(obj.LastModified) > m.cfg.ArchiveMaxAge {
+					expired = append(expired, obj.Key)
+				}
+			}
+		}
+	}
+
+	var errs []error
+	for _, key := range expired {
+		if err := m.bucket.Delete(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		slog.Info("Expired object storage backup", "key", key)
+	}
+	return stdErrors.Join(errs...)
+}
+
+func (m *Manager) key(parts ...string) string {
+	return m.loc.Key(append([]string{m.cfg.WorkspaceID}, parts...)...)
+}
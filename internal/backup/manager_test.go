@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/objectstore"
+)
+
+type memoryBucket struct {
+	objects map[string][]byte
+	times   map[string]time.Time
+	now     func() time.Time
+}
+
+func newMemoryBucket(now func() time.Time) *memoryBucket {
+	return &memoryBucket{objects: map[string][]byte{}, times: map[string]time.Time{}, now: now}
+}
+
+func (b *memoryBucket) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	b.objects[key] = data
+	b.times[key] = b.now()
+	return nil
+}
+
+func (b *memoryBucket) List(ctx context.Context, prefix string) ([]objectstore.Object, error) {
+	var out []objectstore.Object
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, objectstore.Object{Key: key, Size: int64(len(data)), LastModified: b.times[key]})
+		}
+	}
+	return out, nil
+}
+
+func (b *memoryBucket) Delete(ctx context.Context, key string) error {
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *memoryBucket) keys(prefix string) []string {
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager_RunUploadsSnapshotTranscriptsAndArtifacts(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "sessions", "s1.jsonl"), 10)
+	writeFile(t, filepath.Join(ws, "sessions", "s1.jsonl.20260101000000.bak"), 20)
+	writeFile(t, filepath.Join(ws, "workspace.lock"), 1)
+	writeFile(t, filepath.Join(ws, "artifacts", "report.pdf"), 2048)
+	writeFile(t, filepath.Join(ws, "artifacts", "small.txt"), 10)
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	bucket := newMemoryBucket(func() time.Time { return now })
+	loc, _ := objectstore.ParseURI("s3://bucket/heike")
+	manager, err := NewManager(Config{
+		WorkspaceID:      "default",
+		WorkspacePath:    ws,
+		Interval:         time.Hour,
+		Transcripts:      true,
+		Artifacts:        true,
+		ArtifactMinBytes: 1024,
+	}, bucket, loc)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	manager.now = func() time.Time { return now }
+
+	if err := manager.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := []string{
+		"heike/default/artifacts/report.pdf",
+		"heike/default/snapshots/20260501T120000Z.tar.gz",
+		"heike/default/transcripts/s1.jsonl.20260101000000.bak",
+	}
+	if got := bucket.keys("heike/"); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("uploaded keys = %v, want %v", got, want)
+	}
+
+	entries := snapshotEntries(t, bucket.objects[want[1]])
+	if strings.Join(entries, ",") != "sessions/s1.jsonl" {
+		t.Fatalf("snapshot entries = %v", entries)
+	}
+}
+
+func TestManager_RetentionKeepsLatestSnapshots(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "sessions", "s1.jsonl"), 10)
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	bucket := newMemoryBucket(clock)
+	loc, _ := objectstore.ParseURI("gs://bucket")
+	manager, err := NewManager(Config{
+		WorkspaceID:    "default",
+		WorkspacePath:  ws,
+		Interval:       time.Hour,
+		KeepLast:       2,
+		SnapshotMaxAge: 48 * time.Hour,
+	}, bucket, loc)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	manager.now = clock
+
+	for day := 0; day < 5; day++ {
+		now = time.Date(2026, 5, 1+day, 0, 0, 0, 0, time.UTC)
+		if err := manager.Run(context.Background()); err != nil {
+			t.Fatalf("run day %d: %v", day, err)
+		}
+	}
+
+	// Snapshots older than 48h are expired except for the two newest.
+	want := []string{
+		"default/snapshots/20260503T000000Z.tar.gz",
+		"default/snapshots/20260504T000000Z.tar.gz",
+		"default/snapshots/20260505T000000Z.tar.gz",
+	}
+	if got := bucket.keys("default/snapshots/"); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("snapshots = %v, want %v", got, want)
+	}
+}
+
+func snapshotEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	return names
+}
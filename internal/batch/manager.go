@@ -0,0 +1,406 @@
+// Package batch runs a list of goals as background sessions, keeping at most
+// a fixed number in flight, and collects each session's final answer so the
+// whole batch can be exported once it finishes.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/store"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Status is the lifecycle stage of a batch.
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+)
+
+// ItemState is the lifecycle stage of one goal in a batch.
+type ItemState string
+
+const (
+	ItemPending   ItemState = "pending"
+	ItemQueued    ItemState = "queued"
+	ItemRunning   ItemState = "running"
+	ItemCompleted ItemState = "completed"
+	ItemFailed    ItemState = "failed"
+)
+
+// Item is one goal and, once it finishes, its result.
+type Item struct {
+	Index       int        `json:"index"`
+	Goal        string     `json:"goal"`
+	SessionID   string     `json:"session_id"`
+	EventID     string     `json:"event_id,omitempty"`
+	Status      ItemState  `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Progress counts the items of a batch by state.
+type Progress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Queued    int `json:"queued"`
+	Running   int `json:"running"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Batch is a snapshot of a submitted batch. Items is nil in List results.
+type Batch struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	Concurrency int        `json:"concurrency"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Progress    Progress   `json:"progress"`
+	Items       []Item     `json:"items,omitempty"`
+}
+
+// SubmitFunc queues goal as a background event for sessionID and returns the
+// event ID. A transient error means the queue is full and is retried later.
+type SubmitFunc func(ctx context.Context, sessionID, goal string) (string, error)
+
+// Store is the part of the store worker used to follow submitted events.
+type Store interface {
+	EventStatus(id string) (store.EventStatus, bool)
+	ReadTranscript(sessionID string, limit int) ([]string, error)
+}
+
+// Config bounds batch size and concurrency.
+type Config struct {
+	MaxGoals           int
+	DefaultConcurrency int
+	MaxConcurrency     int
+	PollInterval       time.Duration
+	// MaxBatches is how many batches are kept in memory; the oldest finished
+	// batches are dropped first.
+	MaxBatches int
+}
+
+// Manager runs batches. Batches live in memory only and are lost on restart.
+type Manager struct {
+	cfg    Config
+	submit SubmitFunc
+	store  Store
+	now    func() time.Time
+
+	mu      sync.Mutex
+	ctx     context.Context
+	batches map[string]*Batch
+}
+
+func NewManager(cfg Config, submit SubmitFunc, st Store) (*Manager, error) {
+	if submit == nil || st == nil {
+		return nil, errors.InvalidInput("batch submitter and store are required")
+	}
+	if cfg.MaxGoals <= 0 || cfg.MaxConcurrency <= 0 || cfg.MaxBatches <= 0 {
+		return nil, errors.InvalidInput("batch limits must be positive")
+	}
+	if cfg.PollInterval <= 0 {
+		return nil, errors.InvalidInput("batch poll interval must be positive")
+	}
+	if cfg.DefaultConcurrency <= 0 || cfg.DefaultConcurrency > cfg.MaxConcurrency {
+		cfg.DefaultConcurrency = cfg.MaxConcurrency
+	}
+	return &Manager{
+		cfg:     cfg,
+		submit:  submit,
+		store:   st,
+		now:     time.Now,
+		batches: make(map[string]*Batch),
+	}, nil
+}
+
+// Start enables Submit. Running batches stop dispatching when ctx is done.
+func (m *Manager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ctx = ctx
+}
+
+// Submit starts a batch for goals. Blank goals are skipped. concurrency <= 0
+// uses the configured default; larger values are capped at the maximum.
+func (m *Manager) Submit(goals []string, concurrency int) (Batch, error) {
+	cleaned := make([]string, 0, len(goals))
+	for _, goal := range goals {
+		if goal = strings.TrimSpace(goal); goal != "" {
+			cleaned = append(cleaned, goal)
+		}
+	}
+	if len(cleaned) == 0 {
+		return Batch{}, errors.InvalidInput("batch has no goals")
+	}
+	if len(cleaned) > m.cfg.MaxGoals {
+		return Batch{}, errors.InvalidInput(fmt.Sprintf("batch exceeds %d goals", m.cfg.MaxGoals))
+	}
+	if concurrency <= 0 {
+		concurrency = m.cfg.DefaultConcurrency
+	}
+	if concurrency > m.cfg.MaxConcurrency {
+		concurrency = m.cfg.MaxConcurrency
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil {
+		return Batch{}, errors.Transient("batch manager not started")
+	}
+
+	id := strings.ToLower(ulid.Make().String())
+	b := &Batch{
+		ID:          id,
+		Status:      StatusRunning,
+		Concurrency: concurrency,
+		CreatedAt:   m.now(),
+		Items:       make([]Item, len(cleaned)),
+	}
+	for i, goal := range cleaned {
+		b.Items[i] = Item{
+			Index:     i,
+			Goal:      goal,
+			SessionID: fmt.Sprintf("batch-%s-%d", id, i),
+			Status:    ItemPending,
+		}
+	}
+	m.batches[id] = b
+	m.evictLocked()
+
+	go m.run(m.ctx, b)
+	slog.Info("Batch submitted", "batch", id, "goals", len(cleaned), "concurrency", concurrency)
+	return snapshot(b, true), nil
+}
+
+// Get returns the batch with its items.
+func (m *Manager) Get(id string) (Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.batches[id]
+	if !ok {
+		return Batch{}, errors.NotFound(fmt.Sprintf("batch %s", id))
+	}
+	return snapshot(b, true), nil
+}
+
+// List returns all batches without items, newest first.
+func (m *Manager) List() []Batch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]Batch, 0, len(m.batches))
+	for _, b := range m.batches {
+		result = append(result, snapshot(b, false))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// run dispatches and follows the items of b until all of them finish. Only
+// this goroutine writes to b, always under m.mu.
+func (m *Manager) run(ctx context.Context, b *Batch) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		m.poll(b)
+		m.dispatch(ctx, b)
+		if m.finish(b) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch submits pending items until the concurrency cap is reached or the
+// background queue pushes back.
+func (m *Manager) dispatch(ctx context.Context, b *Batch) {
+	inFlight := 0
+	for _, item := range b.Items {
+		if item.Status == ItemQueued || item.Status == ItemRunning {
+			inFlight++
+		}
+	}
+	for i := range b.Items {
+		if inFlight >= b.Concurrency || ctx.Err() != nil {
+			return
+		}
+		if b.Items[i].Status != ItemPending {
+			continue
+		}
+		eventID, err := m.submit(ctx, b.Items[i].SessionID, b.Items[i].Goal)
+		if stdErrors.Is(err, errors.ErrTransient) {
+			return
+		}
+
+		m.mu.Lock()
+		if err != nil {
+			m.failLocked(&b.Items[i], err.Error())
+		} else {
+			now := m.now()
+			b.Items[i].EventID = eventID
+			b.Items[i].Status = ItemQueued
+			b.Items[i].StartedAt = &now
+			inFlight++
+		}
+		m.mu.Unlock()
+	}
+}
+
+// poll refreshes in-flight items from the store's event tracker.
+func (m *Manager) poll(b *Batch) {
+	for i := range b.Items {
+		item := b.Items[i]
+		if item.Status != ItemQueued && item.Status != ItemRunning {
+			continue
+		}
+		status, ok := m.store.EventStatus(item.EventID)
+		if !ok {
+			m.mu.Lock()
+			m.failLocked(&b.Items[i], "event status no longer tracked")
+			m.mu.Unlock()
+			continue
+		}
+
+		switch status.Status {
+		case store.EventProcessing:
+			m.mu.Lock()
+			b.Items[i].Status = ItemRunning
+			m.mu.Unlock()
+		case store.EventCompleted:
+			result, err := m.lastAnswer(item.SessionID)
+			m.mu.Lock()
+			if err != nil {
+				m.failLocked(&b.Items[i], fmt.Sprintf("read result: %v", err))
+			} else {
+				now := m.now()
+				b.Items[i].Status = ItemCompleted
+				b.Items[i].Result = result
+				b.Items[i].CompletedAt = &now
+			}
+			m.mu.Unlock()
+		case store.EventFailed, store.EventDeadLettered, store.EventCancelled:
+			errMsg := status.Error
+			if errMsg == "" {
+				errMsg = string(status.Status)
+			}
+			m.mu.Lock()
+			m.failLocked(&b.Items[i], errMsg)
+			m.mu.Unlock()
+		}
+	}
+}
+
+// finish marks b completed once no item is pending or in flight.
+func (m *Manager) finish(b *Batch) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	progress := countItems(b.Items)
+	if progress.Completed+progress.Failed < progress.Total {
+		return false
+	}
+	now := m.now()
+	b.Status = StatusCompleted
+	b.CompletedAt = &now
+	slog.Info("Batch completed", "batch", b.ID, "completed", progress.Completed, "failed", progress.Failed)
+	return true
+}
+
+func (m *Manager) failLocked(item *Item, errMsg string) {
+	now := m.now()
+	item.Status = ItemFailed
+	item.Error = errMsg
+	item.CompletedAt = &now
+}
+
+// evictLocked drops the oldest finished batches beyond MaxBatches.
+func (m *Manager) evictLocked() {
+	if len(m.batches) <= m.cfg.MaxBatches {
+		return
+	}
+	finished := make([]*Batch, 0, len(m.batches))
+	for _, b := range m.batches {
+		if b.Status == StatusCompleted {
+			finished = append(finished, b)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+	for _, b := range finished {
+		if len(m.batches) <= m.cfg.MaxBatches {
+			return
+		}
+		delete(m.batches, b.ID)
+	}
+}
+
+// lastAnswer returns the content of the last assistant message in the
+// session transcript.
+func (m *Manager) lastAnswer(sessionID string) (string, error) {
+	lines, err := m.store.ReadTranscript(sessionID, 0)
+	if err != nil {
+		return "", err
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		var event struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}
+		if json.Unmarshal([]byte(lines[i]), &event) != nil {
+			continue
+		}
+		if event.Role == "assistant" {
+			return event.Content, nil
+		}
+	}
+	return "", nil
+}
+
+func snapshot(b *Batch, withItems bool) Batch {
+	out := *b
+	out.Progress = countItems(b.Items)
+	out.Items = nil
+	if withItems {
+		out.Items = append([]Item(nil), b.Items...)
+	}
+	return out
+}
+
+func countItems(items []Item) Progress {
+	progress := Progress{Total: len(items)}
+	for _, item := range items {
+		switch item.Status {
+		case ItemPending:
+			progress.Pending++
+		case ItemQueued:
+			progress.Queued++
+		case ItemRunning:
+			progress.Running++
+		case ItemCompleted:
+			progress.Completed++
+		case ItemFailed:
+			progress.Failed++
+		}
+	}
+	return progress
+}
@@ -0,0 +1,211 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/store"
+)
+
+// fakeRuntime completes or fails submitted goals when the test says so.
+type fakeRuntime struct {
+	mu        sync.Mutex
+	events    map[string]store.EventStatus
+	sessions  map[string]string
+	inFlight  int
+	maxFlight int
+	rejectN   int
+}
+
+func newFakeRuntime() *fakeRuntime {
+	return &fakeRuntime{events: make(map[string]store.EventStatus), sessions: make(map[string]string)}
+}
+
+func (f *fakeRuntime) submit(ctx context.Context, sessionID, goal string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rejectN > 0 {
+		f.rejectN--
+		return "", heikeErrors.ErrTransient
+	}
+	if goal == "invalid" {
+		return "", fmt.Errorf("event content is required")
+	}
+	id := fmt.Sprintf("evt-%d", len(f.events))
+	f.events[id] = store.EventStatus{ID: id, Status: store.EventQueued, SessionID: sessionID}
+	f.sessions[id] = goal
+	f.inFlight++
+	if f.inFlight > f.maxFlight {
+		f.maxFlight = f.inFlight
+	}
+	return id, nil
+}
+
+// settle finishes every queued event; goals named "boom" fail.
+func (f *fakeRuntime) settle() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, status := range f.events {
+		if status.Status != store.EventQueued {
+			continue
+		}
+		if f.sessions[id] == "boom" {
+			status.Status = store.EventFailed
+			status.Error = "task failed"
+		} else {
+			status.Status = store.EventCompleted
+		}
+		f.events[id] = status
+		f.inFlight--
+	}
+}
+
+func (f *fakeRuntime) EventStatus(id string) (store.EventStatus, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status, ok := f.events[id]
+	return status, ok
+}
+
+func (f *fakeRuntime) ReadTranscript(sessionID string, limit int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, status := range f.events {
+		if status.SessionID != sessionID {
+			continue
+		}
+		user, _ := json.Marshal(map[string]string{"role": "user", "content": f.sessions[id]})
+		answer, _ := json.Marshal(map[string]string{"role": "assistant", "content": "answer: " + f.sessions[id]})
+		return []string{string(user), string(answer)}, nil
+	}
+	return nil, nil
+}
+
+func newTestManager(t *testing.T, rt *fakeRuntime) *Manager {
+	t.Helper()
+	m, err := NewManager(Config{
+		MaxGoals:           10,
+		DefaultConcurrency: 2,
+		MaxConcurrency:     3,
+		PollInterval:       5 * time.Millisecond,
+		MaxBatches:         2,
+	}, rt.submit, rt)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m.Start(ctx)
+	return m
+}
+
+// waitFor settles events until the batch completes.
+func waitFor(t *testing.T, m *Manager, rt *fakeRuntime, id string) Batch {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if b.Status == StatusCompleted {
+			return b
+		}
+		rt.settle()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not complete", id)
+	return Batch{}
+}
+
+func TestManager_RunsGoalsAndCollectsResults(t *testing.T) {
+	rt := newFakeRuntime()
+	m := newTestManager(t, rt)
+
+	b, err := m.Submit([]string{"classify a", "", "boom", "invalid", "summarize b"}, 0)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if b.Concurrency != 2 || b.Progress.Total != 4 {
+		t.Fatalf("unexpected batch: %+v", b)
+	}
+
+	done := waitFor(t, m, rt, b.ID)
+	if done.Progress.Completed != 2 || done.Progress.Failed != 2 {
+		t.Fatalf("unexpected progress: %+v", done.Progress)
+	}
+	if done.Items[0].Result != "answer: classify a" || done.Items[3].Result != "answer: summarize b" {
+		t.Fatalf("unexpected results: %+v", done.Items)
+	}
+	if done.Items[1].Error != "task failed" || done.Items[2].Error == "" {
+		t.Fatalf("expected failures on items 1 and 2: %+v", done.Items)
+	}
+	if done.Items[0].SessionID == done.Items[3].SessionID {
+		t.Fatalf("goals should run in separate sessions")
+	}
+	if rt.maxFlight > 2 {
+		t.Fatalf("in-flight goals = %d, want at most 2", rt.maxFlight)
+	}
+}
+
+func TestManager_RetriesWhenQueueFull(t *testing.T) {
+	rt := newFakeRuntime()
+	rt.rejectN = 2
+	m := newTestManager(t, rt)
+
+	b, err := m.Submit([]string{"a"}, 10)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if b.Concurrency != 3 {
+		t.Fatalf("concurrency = %d, want capped at 3", b.Concurrency)
+	}
+	done := waitFor(t, m, rt, b.ID)
+	if done.Progress.Completed != 1 {
+		t.Fatalf("queue-full rejections should be retried: %+v", done.Items)
+	}
+}
+
+func TestManager_SubmitValidation(t *testing.T) {
+	m := newTestManager(t, newFakeRuntime())
+
+	if _, err := m.Submit([]string{" ", ""}, 0); err == nil {
+		t.Fatal("expected error for empty batch")
+	}
+	goals := make([]string, 11)
+	for i := range goals {
+		goals[i] = "goal"
+	}
+	if _, err := m.Submit(goals, 0); err == nil {
+		t.Fatal("expected error above max goals")
+	}
+	if _, err := m.Get("missing"); err == nil {
+		t.Fatal("expected not found")
+	}
+}
+
+func TestManager_EvictsOldestFinishedBatches(t *testing.T) {
+	rt := newFakeRuntime()
+	m := newTestManager(t, rt)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		b, err := m.Submit([]string{"goal"}, 0)
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		waitFor(t, m, rt, b.ID)
+		ids = append(ids, b.ID)
+	}
+	if _, err := m.Get(ids[0]); err == nil {
+		t.Fatal("oldest batch should be evicted")
+	}
+	if got := len(m.List()); got != 2 {
+		t.Fatalf("List() returned %d batches, want 2", got)
+	}
+}
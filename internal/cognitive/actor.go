@@ -12,6 +12,15 @@ type ToolExecutor interface {
 	Execute(ctx context.Context, name string, args json.RawMessage, input string) (json.RawMessage, error)
 }
 
+type toolCallIDKey struct{}
+
+// ToolCallIDFromContext returns the ID of the tool call a ToolExecutor is
+// running, or "".
+func ToolCallIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(toolCallIDKey{}).(string)
+	return id
+}
+
 type UnifiedActor struct {
 	toolExecutor ToolExecutor
 }
@@ -35,7 +44,11 @@ func (a *UnifiedActor) Execute(ctx context.Context, action *Action) (*ExecutionR
 			slog.Info("Executing tool", "tool", tc.Name)
 			slog.Debug("Tool input", "tool", tc.Name, "input", tc.Input)
 
-			res, err := a.toolExecutor.Execute(ctx, tc.Name, json.RawMessage(tc.Input), "")
+			callCtx := ctx
+			if tc.ID != "" {
+				callCtx = context.WithValue(ctx, toolCallIDKey{}, tc.ID)
+			}
+			res, err := a.toolExecutor.Execute(callCtx, tc.Name, json.RawMessage(tc.Input), "")
 			outputStr := ""
 			if err != nil {
 				slog.Error("Tool execution failed", "tool", tc.Name, "error", err)
@@ -50,6 +63,7 @@ func (a *UnifiedActor) Execute(ctx context.Context, action *Action) (*ExecutionR
 				CallID: tc.ID,
 				Name:   tc.Name,
 				Output: outputStr,
+				Error:  err,
 			})
 		}
 
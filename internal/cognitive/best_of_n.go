@@ -0,0 +1,206 @@
+package cognitive
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// HighStakesMetadataKey flags a goal for best-of-N sampling when set to "true"
+// in the cognitive context metadata.
+const HighStakesMetadataKey = "high_stakes"
+
+// BestOfNConfig controls self-consistency sampling for high-stakes goals.
+type BestOfNConfig struct {
+	// Samples is the total number of candidates, including the original.
+	Samples int
+	// Judge picks the best candidate. Without a judge, candidates are
+	// selected by majority vote.
+	Judge LLMClient
+	// MaxSampleTokens caps the estimated tokens spent on sampling; sampling
+	// is skipped when Samples x context estimate would exceed it.
+	MaxSampleTokens int
+}
+
+var judgeChoicePattern = regexp.MustCompile(`\d+`)
+
+// bestOfNTemperature is the sampling temperature of the extra candidates, so
+// they differ from the first even on providers whose default output is close
+// to deterministic.
+const bestOfNTemperature = 0.8
+
+// Sampling overrides the provider's sampling settings for one LLM call.
+type Sampling struct {
+	Temperature float64
+	Seed        int64
+}
+
+type samplingContextKey struct{}
+
+// WithSampling asks the LLM client to apply s to calls made with ctx.
+func WithSampling(ctx context.Context, s Sampling) context.Context {
+	return context.WithValue(ctx, samplingContextKey{}, s)
+}
+
+// SamplingFromContext returns the overrides set by WithSampling.
+func SamplingFromContext(ctx context.Context) (Sampling, bool) {
+	s, ok := ctx.Value(samplingContextKey{}).(Sampling)
+	return s, ok
+}
+
+// sampleContext marks a best-of-N candidate call; each candidate gets its
+// own seed so providers that honor seeds do not repeat one sample.
+func sampleContext(ctx context.Context, sample int) context.Context {
+	return WithSampling(ctx, Sampling{Temperature: bestOfNTemperature, Seed: int64(sample)})
+}
+
+// SetBestOfN enables best-of-N sampling for plans and final answers.
+func (e *DefaultCognitiveEngine) SetBestOfN(cfg BestOfNConfig) {
+	if cfg.Samples < 2 {
+		e.bestOfN = nil
+		return
+	}
+	e.bestOfN = &cfg
+}
+
+// shouldSample reports whether the goal is flagged and fits the cost guardrail.
+func (e *DefaultCognitiveEngine) shouldSample(goal string, c *CognitiveContext) bool {
+	if e.bestOfN == nil || c == nil || c.Metadata[HighStakesMetadataKey] != "true" {
+		return false
+	}
+	if e.bestOfN.MaxSampleTokens <= 0 {
+		return true
+	}
+
+	chars := len(goal)
+	for _, msg := range c.History {
+		chars += len(msg.Content)
+	}
+	if c.CurrentPlan != nil {
+		chars += len(c.CurrentPlan.Raw)
+	}
+	estimate := (chars / 4) * e.bestOfN.Samples
+	if estimate > e.bestOfN.MaxSampleTokens {
+		slog.Warn("Best-of-N sampling skipped by cost guardrail",
+			"estimated_tokens", estimate,
+			"max_sample_tokens", e.bestOfN.MaxSampleTokens)
+		return false
+	}
+	return true
+}
+
+// selectPlan samples additional plans and returns the winning candidate.
+func (e *DefaultCognitiveEngine) selectPlan(ctx context.Context, goal string, c *CognitiveContext, first *Plan) *Plan {
+	candidates := []*Plan{first}
+	for i := 1; i < e.bestOfN.Samples; i++ {
+		plan, err := e.planner.Plan(sampleContext(ctx, i), goal, c)
+		if err != nil {
+			slog.Warn("Best-of-N plan sample failed", "sample", i+1, "error", err)
+			continue
+		}
+		candidates = append(candidates, plan)
+	}
+
+	texts := make([]string, len(candidates))
+	for i, plan := range candidates {
+		texts[i] = formatPlanDebug(plan)
+	}
+	winner := e.pickCandidate(ctx, goal, "plan", texts)
+	c.emitDebug(DebugStagePlan, fmt.Sprintf("best-of-%d selected plan %d", len(candidates), winner+1))
+	return candidates[winner]
+}
+
+// selectAnswer samples additional final answers and returns the winning content.
+// The first answer is already appended to c.History, so samples are drawn from
+// the history that preceded it.
+func (e *DefaultCognitiveEngine) selectAnswer(ctx context.Context, goal string, c *CognitiveContext, first string) string {
+	sampleCtx := *c
+	if n := len(c.History); n > 0 {
+		sampleCtx.History = c.History[:n-1]
+	}
+
+	candidates := []string{first}
+	for i := 1; i < e.bestOfN.Samples; i++ {
+		thought, err := e.thinker.Think(sampleContext(ctx, i), goal, sampleCtx.CurrentPlan, &sampleCtx)
+		if err != nil {
+			slog.Warn("Best-of-N answer sample failed", "sample", i+1, "error", err)
+			continue
+		}
+		if !thought.IsFinalAnswer() || strings.TrimSpace(thought.Content) == "" {
+			continue
+		}
+		candidates = append(candidates, thought.Content)
+	}
+
+	winner := e.pickCandidate(ctx, goal, "answer", candidates)
+	return candidates[winner]
+}
+
+// pickCandidate asks the judge for the best candidate, falling back to a
+// majority vote when no judge is configured or its reply is unusable.
+func (e *DefaultCognitiveEngine) pickCandidate(ctx context.Context, goal, kind string, candidates []string) int {
+	if len(candidates) < 2 {
+		return 0
+	}
+
+	if e.bestOfN.Judge != nil {
+		reply, err := e.bestOfN.Judge.Complete(ctx, buildJudgePrompt(goal, kind, candidates))
+		if err == nil {
+			if idx, ok := parseJudgeChoice(reply, len(candidates)); ok {
+				slog.Info("Best-of-N judge selected candidate", "kind", kind, "candidate", idx+1, "candidates", len(candidates))
+				return idx
+			}
+			slog.Warn("Best-of-N judge reply unusable, falling back to vote", "kind", kind)
+		} else {
+			slog.Warn("Best-of-N judge failed, falling back to vote", "kind", kind, "error", err)
+		}
+	}
+
+	return majorityVote(candidates)
+}
+
+func buildJudgePrompt(goal, kind string, candidates []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "You are judging candidate %ss for the goal below. Pick the most correct, complete, and safe candidate.\n\n", kind)
+	fmt.Fprintf(&sb, "GOAL:\n%s\n\n", goal)
+	for i, candidate := range candidates {
+		fmt.Fprintf(&sb, "CANDIDATE %d:\n%s\n\n", i+1, candidate)
+	}
+	sb.WriteString("Reply with only the number of the best candidate.")
+	return sb.String()
+}
+
+func parseJudgeChoice(reply string, count int) (int, bool) {
+	match := judgeChoicePattern.FindString(reply)
+	if match == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match)
+	if err != nil || n < 1 || n > count {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// majorityVote returns the index of the first candidate with the most
+// identical (whitespace- and case-normalized) peers.
+func majorityVote(candidates []string) int {
+	counts := make(map[string]int, len(candidates))
+	for _, candidate := range candidates {
+		counts[normalizeCandidate(candidate)]++
+	}
+	best, bestCount := 0, 0
+	for i, candidate := range candidates {
+		if n := counts[normalizeCandidate(candidate)]; n > bestCount {
+			best, bestCount = i, n
+		}
+	}
+	return best
+}
+
+func normalizeCandidate(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
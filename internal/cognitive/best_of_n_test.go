@@ -0,0 +1,132 @@
+package cognitive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type sequencePlanner struct {
+	plans []*Plan
+	calls int
+}
+
+func (p *sequencePlanner) Plan(ctx context.Context, goal string, c *CognitiveContext) (*Plan, error) {
+	plan := p.plans[p.calls%len(p.plans)]
+	p.calls++
+	return plan, nil
+}
+
+type sequenceThinker struct {
+	answers []string
+	calls   int
+}
+
+func (t *sequenceThinker) Think(ctx context.Context, goal string, plan *Plan, c *CognitiveContext) (*Thought, error) {
+	answer := t.answers[t.calls%len(t.answers)]
+	t.calls++
+	return &Thought{Content: answer, Action: &Action{Type: ActionTypeAnswer, Content: answer}}, nil
+}
+
+func newBestOfNEngine(planner Planner, thinker Thinker) *DefaultCognitiveEngine {
+	return NewEngine(planner, thinker, nil, nil, nil, 3, 8000)
+}
+
+func highStakes(c *CognitiveContext) {
+	c.Metadata[HighStakesMetadataKey] = "true"
+}
+
+func TestBestOfN_MajorityVoteSelectsConsistentAnswer(t *testing.T) {
+	planner := &sequencePlanner{plans: []*Plan{{Raw: "p", Steps: []PlanStep{{ID: 1, Description: "answer"}}}}}
+	thinker := &sequenceThinker{answers: []string{"42", "41", "42"}}
+	engine := newBestOfNEngine(planner, thinker)
+	engine.SetBestOfN(BestOfNConfig{Samples: 3})
+
+	result, err := engine.Run(context.Background(), "compute", highStakes)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", result.Content)
+	assert.Equal(t, 3, planner.calls)
+	assert.Equal(t, 3, thinker.calls)
+}
+
+func TestBestOfN_JudgeSelectsCandidate(t *testing.T) {
+	planner := &sequencePlanner{plans: []*Plan{{Raw: "p", Steps: []PlanStep{{ID: 1, Description: "answer"}}}}}
+	thinker := &sequenceThinker{answers: []string{"short", "thorough"}}
+	judge := new(MockLLMClient)
+	judge.On("Complete", mock.Anything, mock.Anything).Return("Candidate 2 is best", nil)
+
+	engine := newBestOfNEngine(planner, thinker)
+	engine.SetBestOfN(BestOfNConfig{Samples: 2, Judge: judge})
+
+	result, err := engine.Run(context.Background(), "explain", highStakes)
+	assert.NoError(t, err)
+	assert.Equal(t, "thorough", result.Content)
+}
+
+// deterministicThinker answers like a provider that returns the same output
+// for the same request unless its sampling settings change.
+type deterministicThinker struct {
+	samplings []Sampling
+}
+
+func (t *deterministicThinker) Think(ctx context.Context, goal string, plan *Plan, c *CognitiveContext) (*Thought, error) {
+	answer := "default"
+	if s, ok := SamplingFromContext(ctx); ok {
+		t.samplings = append(t.samplings, s)
+		answer = fmt.Sprintf("t=%.1f seed=%d", s.Temperature, s.Seed)
+	}
+	return &Thought{Content: answer, Action: &Action{Type: ActionTypeAnswer, Content: answer}}, nil
+}
+
+func TestBestOfN_SamplesWithDistinctSettings(t *testing.T) {
+	planner := &sequencePlanner{plans: []*Plan{{Raw: "p", Steps: []PlanStep{{ID: 1, Description: "answer"}}}}}
+	thinker := &deterministicThinker{}
+	judge := new(MockLLMClient)
+	var prompt string
+	judge.On("Complete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.String(1)
+	}).Return("3", nil)
+
+	engine := newBestOfNEngine(planner, thinker)
+	engine.SetBestOfN(BestOfNConfig{Samples: 3, Judge: judge})
+
+	result, err := engine.Run(context.Background(), "explain", highStakes)
+	assert.NoError(t, err)
+	assert.Equal(t, []Sampling{{Temperature: bestOfNTemperature, Seed: 1}, {Temperature: bestOfNTemperature, Seed: 2}}, thinker.samplings)
+	for _, candidate := range []string{"default", "t=0.8 seed=1", "t=0.8 seed=2"} {
+		assert.True(t, strings.Contains(prompt, candidate), "judge should see candidate %q", candidate)
+	}
+	assert.Equal(t, "t=0.8 seed=2", result.Content)
+}
+
+func TestBestOfN_SkipsUnflaggedAndOverBudgetGoals(t *testing.T) {
+	planner := &sequencePlanner{plans: []*Plan{{Raw: "p", Steps: []PlanStep{{ID: 1, Description: "answer"}}}}}
+	thinker := &sequenceThinker{answers: []string{"a", "b"}}
+	engine := newBestOfNEngine(planner, thinker)
+	engine.SetBestOfN(BestOfNConfig{Samples: 3})
+
+	result, err := engine.Run(context.Background(), "casual")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", result.Content)
+	assert.Equal(t, 1, thinker.calls)
+
+	engine.SetBestOfN(BestOfNConfig{Samples: 3, MaxSampleTokens: 1})
+	_, err = engine.Run(context.Background(), "a goal long enough to exceed the guardrail", highStakes)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, thinker.calls)
+}
+
+func TestParseJudgeChoice(t *testing.T) {
+	idx, ok := parseJudgeChoice("3", 3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, idx)
+
+	_, ok = parseJudgeChoice("candidate 7", 3)
+	assert.False(t, ok)
+	_, ok = parseJudgeChoice("none", 3)
+	assert.False(t, ok)
+}
@@ -30,8 +30,28 @@ type CognitiveContext struct {
 	// Token Management
 	TokenBudget int // Max tokens allowed for context
 	TokenUsage  int // Current estimated usage
+
+	// Debug receives internal loop steps when verbose mode is enabled
+	Debug func(stage, content string)
+}
+
+// Debug stages emitted by the cognitive loop
+const (
+	DebugStagePlan       = "plan"
+	DebugStageToolCalls  = "tool_calls"
+	DebugStageReflection = "reflection"
+)
+
+func (c *CognitiveContext) emitDebug(stage, content string) {
+	if c == nil || c.Debug == nil {
+		return
+	}
+	c.Debug(stage, content)
 }
 
+// CharsPerToken is the naive characters-per-token ratio used for budgeting.
+const CharsPerToken = 4
+
 // Prune optimizes context to fit within TokenBudget
 // This is a naive implementation; a real one would use a tokenizer
 func (c *CognitiveContext) Prune() {
@@ -39,10 +59,12 @@ func (c *CognitiveContext) Prune() {
 		return
 	}
 
-	// Naive estimation: 1 char ~= 0.25 tokens (4 chars/token)
-	estimate := func(s string) int { return len(s) / 4 }
+	estimate := func(s string) int { return len(s) / CharsPerToken }
 
-	currentTokens := 0
+	// Injected skill guidance is fixed for the turn and sized by the task
+	// manager, so it is charged first.
+	fixedTokens := estimate(c.Metadata["skills_context"])
+	currentTokens := fixedTokens
 
 	// Always keep plan and scratchpad (high priority)
 	if c.CurrentPlan != nil {
@@ -57,7 +79,7 @@ func (c *CognitiveContext) Prune() {
 	if remaining < 0 {
 		// Critical: context is already full with internal state.
 		// Keep only the latest scratchpad entries that still fit.
-		budgetForScratchpad := c.TokenBudget
+		budgetForScratchpad := c.TokenBudget - fixedTokens
 		if c.CurrentPlan != nil {
 			budgetForScratchpad -= estimate(c.CurrentPlan.Raw)
 		}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/model/contract"
@@ -24,6 +25,8 @@ type CognitiveError struct {
 	Type    ErrorType
 	Message string
 	Cause   error
+	// Attempts lists the tool calls made before the failure
+	Attempts []Attempt
 }
 
 func (e *CognitiveError) Error() string {
@@ -33,6 +36,10 @@ func (e *CognitiveError) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Type, e.Message)
 }
 
+func (e *CognitiveError) Unwrap() error {
+	return e.Cause
+}
+
 // DefaultCognitiveEngine implements the OODA loop
 type DefaultCognitiveEngine struct {
 	planner     Planner
@@ -42,6 +49,8 @@ type DefaultCognitiveEngine struct {
 	memory      MemoryManager
 	maxTurns    int
 	tokenBudget int
+	bestOfN     *BestOfNConfig
+	toolImages  bool
 }
 
 func NewEngine(
@@ -83,6 +92,12 @@ func (e *DefaultCognitiveEngine) SetTokenBudget(n int) {
 	}
 }
 
+// SetToolImages controls whether images referenced by image tool outputs
+// are attached to the history for the next turn.
+func (e *DefaultCognitiveEngine) SetToolImages(enabled bool) {
+	e.toolImages = enabled
+}
+
 func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...ExecutionOption) (*Result, error) {
 	// Initialize Context
 	cCtx := &CognitiveContext{
@@ -107,8 +122,15 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 	}
 	cCtx.CurrentPlan = plan
 	slog.Debug("Plan generated", "steps", len(plan.Steps))
+	cCtx.emitDebug(DebugStagePlan, formatPlanDebug(plan))
+
+	sampling := e.shouldSample(goal, cCtx)
+	if sampling {
+		cCtx.CurrentPlan = e.selectPlan(ctx, goal, cCtx, plan)
+	}
 
 	// Cognitive Loop (Decide & Act)
+	var attempts []Attempt
 	retryCount := 0
 	for i := 0; i < e.maxTurns; i++ {
 		// Check for cancellation
@@ -117,11 +139,12 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 		}
 
 		slog.Debug("Cognitive loop turn", "turn", i+1, "max", e.maxTurns)
+		notifyTurn(ctx)
 
 		// Think (Decide)
 		thought, err := e.thinker.Think(ctx, goal, cCtx.CurrentPlan, cCtx)
 		if err != nil {
-			return nil, &CognitiveError{Type: ErrLogic, Message: "Thinking failed", Cause: err}
+			return nil, &CognitiveError{Type: ErrLogic, Message: "Thinking failed", Cause: err, Attempts: attempts}
 		}
 
 		// Append Assistant Thought to History
@@ -137,17 +160,32 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 		// Final Answer Check
 		if thought.IsFinalAnswer() {
 			slog.Info("Final answer reached", "turn", i+1)
+			content := thought.Content
+			if sampling {
+				content = e.selectAnswer(ctx, goal, cCtx, content)
+			}
 			return &Result{
-				Content: thought.Content,
+				Content: content,
 				Meta:    map[string]interface{}{"turns": i + 1},
 			}, nil
 		}
 
+		if thought.Action.Type == ActionTypeToolCall {
+			cCtx.emitDebug(DebugStageToolCalls, formatToolCallsDebug(thought.Action.ToolCalls))
+		}
+
 		// Act
 		result, err := e.actor.Execute(ctx, thought.Action)
 		if err != nil {
 			slog.Error("Action execution failed", "error", err)
-			return nil, &CognitiveError{Type: ErrFatal, Message: "Action execution failed", Cause: err}
+			return nil, &CognitiveError{Type: ErrFatal, Message: "Action execution failed", Cause: err, Attempts: attempts}
+		}
+		for _, toolOut := range result.ToolOutputs {
+			attempt := Attempt{Turn: i + 1, Tool: toolOut.Name}
+			if toolOut.Error != nil {
+				attempt.Error = toolOut.Error.Error()
+			}
+			attempts = append(attempts, attempt)
 		}
 
 		// Append Tool Outputs to History
@@ -159,6 +197,11 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 					ToolCallID: toolOut.CallID,
 				})
 			}
+			if e.toolImages {
+				if imageMsg, ok := toolImageMessage(result.ToolOutputs); ok {
+					cCtx.History = append(cCtx.History, imageMsg)
+				}
+			}
 		}
 
 		// Auto-prune history if needed
@@ -170,6 +213,7 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 			slog.Warn("Reflection failed", "error", err)
 		} else {
 			cCtx.Update(reflection)
+			cCtx.emitDebug(DebugStageReflection, fmt.Sprintf("[%s] %s", reflection.NextAction, reflection.Content))
 
 			// Handle Control Signals
 			switch reflection.NextAction {
@@ -180,6 +224,7 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 					continue
 				}
 				retryCount++
+				NotifyRetry(ctx)
 				slog.Info("Reflector requested retry", "turn", i+1, "retry", retryCount, "max_retries", maxRetriesPerTurn)
 				i--
 				continue
@@ -189,6 +234,7 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 				newPlan, err := e.planner.Plan(ctx, goal, cCtx)
 				if err == nil {
 					cCtx.CurrentPlan = newPlan
+					cCtx.emitDebug(DebugStagePlan, formatPlanDebug(newPlan))
 				}
 			case SignalStop:
 				retryCount = 0
@@ -214,5 +260,30 @@ func (e *DefaultCognitiveEngine) Run(ctx context.Context, goal string, opts ...E
 		}
 	}
 
-	return nil, &CognitiveError{Type: ErrMaxTurns, Message: "Max cognitive turns reached"}
+	return nil, &CognitiveError{Type: ErrMaxTurns, Message: "Max cognitive turns reached", Attempts: attempts}
+}
+
+func formatPlanDebug(plan *Plan) string {
+	if plan == nil {
+		return ""
+	}
+	if len(plan.Steps) == 0 {
+		return plan.Raw
+	}
+	var sb strings.Builder
+	for i, step := range plan.Steps {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, step.Description)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func formatToolCallsDebug(calls []*contract.ToolCall) string {
+	lines := make([]string, 0, len(calls))
+	for _, call := range calls {
+		if call == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", call.Name, call.Input))
+	}
+	return strings.Join(lines, "\n")
 }
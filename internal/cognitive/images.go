@@ -0,0 +1,149 @@
+package cognitive
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+const (
+	// maxToolImages caps images forwarded to the model per turn.
+	maxToolImages = 4
+	// maxInlineImageBytes caps local image files embedded as base64.
+	maxInlineImageBytes = 4 << 20
+)
+
+// imageTools lists tools whose output references images worth showing to
+// the model. Other tools' "url" fields are usually web pages.
+var imageTools = map[string]bool{
+	"screenshot":  true,
+	"image_query": true,
+	"view_image":  true,
+}
+
+// toolImageMessage collects images referenced by image tool outputs into a
+// user message. Tool-role messages cannot carry images on every provider, so
+// images follow the tool results as a separate turn. It returns false when
+// no images were found.
+func toolImageMessage(outputs []ToolOutput) (contract.Message, bool) {
+	var images []contract.ImagePart
+	var names []string
+	for _, out := range outputs {
+		if out.Error != nil || !imageTools[out.Name] {
+			continue
+		}
+		found := imagesFromToolOutput(out.Output, maxToolImages-len(images))
+		if len(found) == 0 {
+			continue
+		}
+		images = append(images, found...)
+		names = append(names, out.Name)
+		if len(images) >= maxToolImages {
+			break
+		}
+	}
+	if len(images) == 0 {
+		return contract.Message{}, false
+	}
+	return contract.Message{
+		Role:    "user",
+		Content: fmt.Sprintf("Images returned by %s:", strings.Join(names, ", ")),
+		Images:  images,
+	}, true
+}
+
+// imagesFromToolOutput walks a JSON tool output for image references: local
+// files (file_path or path with an image mime_type) are embedded, remote
+// images (thumbnail_url, else url) are passed by URL.
+func imagesFromToolOutput(output string, limit int) []contract.ImagePart {
+	if limit <= 0 {
+		return nil
+	}
+	var root interface{}
+	if err := json.Unmarshal([]byte(output), &root); err != nil {
+		return nil
+	}
+
+	var images []contract.ImagePart
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		if len(images) >= limit {
+			return
+		}
+		switch node := v.(type) {
+		case []interface{}:
+			for _, item := range node {
+				walk(item)
+			}
+		case map[string]interface{}:
+			if img, ok := imageFromObject(node); ok {
+				images = append(images, img)
+				return
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+	return images
+}
+
+func imageFromObject(obj map[string]interface{}) (contract.ImagePart, bool) {
+	str := func(key string) string {
+		s, _ := obj[key].(string)
+		return strings.TrimSpace(s)
+	}
+
+	mimeType := str("mime_type")
+	if filePath := firstNonEmpty(str("file_path"), str("path")); filePath != "" && strings.HasPrefix(mimeType, "image/") {
+		data, err := readInlineImage(filePath)
+		if err != nil {
+			slog.Warn("Skipping tool image", "path", filePath, "error", err)
+			return contract.ImagePart{}, false
+		}
+		return contract.ImagePart{Data: data, MIMEType: mimeType}, true
+	}
+
+	if _, isImageResult := obj["thumbnail_url"]; !isImageResult {
+		return contract.ImagePart{}, false
+	}
+	imageURL := firstNonEmpty(str("thumbnail_url"), str("url"))
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return contract.ImagePart{}, false
+	}
+	return contract.ImagePart{URL: imageURL, MIMEType: mime.TypeByExtension(path.Ext(parsed.Path))}, true
+}
+
+func readInlineImage(filePath string) (string, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxInlineImageBytes {
+		return "", fmt.Errorf("image is %d bytes, limit is %d", info.Size(), maxInlineImageBytes)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
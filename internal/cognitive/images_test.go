@@ -0,0 +1,60 @@
+package cognitive
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolImageMessage_ScreenshotAndImageQuery(t *testing.T) {
+	pngPath := filepath.Join(t.TempDir(), "page.png")
+	assert.NoError(t, os.WriteFile(pngPath, []byte("fake-png"), 0o644))
+
+	screenshot, _ := json.Marshal(map[string]interface{}{"file_path": pngPath, "mime_type": "image/png"})
+	imageQuery, _ := json.Marshal(map[string]interface{}{
+		"results": []map[string]interface{}{
+			{"query": "cat", "results": []map[string]interface{}{
+				{"url": "https://upload.example/cat.jpg", "thumbnail_url": "https://upload.example/thumb/cat.jpg"},
+			}},
+		},
+	})
+
+	msg, ok := toolImageMessage([]ToolOutput{
+		{Name: "screenshot", Output: string(screenshot)},
+		{Name: "image_query", Output: string(imageQuery)},
+		{Name: "open", Output: `{"url":"https://example.com","thumbnail_url":"https://example.com/x.png"}`},
+	})
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "user", msg.Role)
+	if assert.Len(t, msg.Images, 2) {
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("fake-png")), msg.Images[0].Data)
+		assert.Equal(t, "image/png", msg.Images[0].MIMEType)
+		assert.Equal(t, "https://upload.example/thumb/cat.jpg", msg.Images[1].URL)
+		assert.Equal(t, "image/jpeg", msg.Images[1].MIMEType)
+	}
+}
+
+func TestToolImageMessage_SkipsFailuresAndCapsCount(t *testing.T) {
+	var results []map[string]interface{}
+	for i := 0; i < maxToolImages+3; i++ {
+		results = append(results, map[string]interface{}{"thumbnail_url": "https://upload.example/a.png"})
+	}
+	output, _ := json.Marshal(map[string]interface{}{"results": results})
+
+	msg, ok := toolImageMessage([]ToolOutput{
+		{Name: "image_query", Output: string(output)},
+		{Name: "screenshot", Output: `{"file_path":"/missing.png","mime_type":"image/png"}`, Error: errors.New("render failed")},
+	})
+	assert.True(t, ok)
+	assert.Len(t, msg.Images, maxToolImages)
+
+	_, ok = toolImageMessage([]ToolOutput{{Name: "view_image", Output: "not json"}})
+	assert.False(t, ok)
+}
@@ -49,6 +49,13 @@ type MemoryManager interface {
 	Remember(ctx context.Context, fact string) error
 }
 
+// SessionMemoryManager is a MemoryManager that can also recall context
+// injected for a single session.
+type SessionMemoryManager interface {
+	MemoryManager
+	RetrieveForSession(ctx context.Context, sessionID, query string) ([]string, error)
+}
+
 // Plan represents a structured plan
 type Plan struct {
 	Steps []PlanStep
@@ -101,6 +108,14 @@ type ToolOutput struct {
 	CallID string
 	Name   string
 	Output string
+	Error  error
+}
+
+// Attempt records one tool invocation made during a run
+type Attempt struct {
+	Turn  int
+	Tool  string
+	Error string
 }
 
 // Reflection represents the analysis of an execution
@@ -134,3 +149,18 @@ type LLMClient interface {
 	// ChatComplete sends a list of messages to the LLM
 	ChatComplete(ctx context.Context, messages []contract.Message, tools []contract.ToolDef) (string, []*contract.ToolCall, error)
 }
+
+// StructuredLLMClient is implemented by clients that can request
+// schema-constrained output. Callers still parse the result defensively.
+type StructuredLLMClient interface {
+	CompleteStructured(ctx context.Context, prompt string, format contract.ResponseFormat) (string, error)
+}
+
+// CompleteJSON requests output constrained by format when llm supports it
+// and falls back to a plain completion otherwise.
+func CompleteJSON(ctx context.Context, llm LLMClient, prompt string, format contract.ResponseFormat) (string, error) {
+	if structured, ok := llm.(StructuredLLMClient); ok {
+		return structured.CompleteStructured(ctx, prompt, format)
+	}
+	return llm.Complete(ctx, prompt)
+}
@@ -0,0 +1,35 @@
+package cognitive
+
+import "context"
+
+// LoopObserver is told about the cognitive loop turns and retries of runs
+// made with its context. Sub-tasks run in parallel, so implementations must
+// be safe for concurrent use.
+type LoopObserver interface {
+	// ObserveTurn is called at the start of each loop turn, including turns
+	// repeated at the reflector's request.
+	ObserveTurn()
+	// ObserveRetry is called when work is repeated after a failure.
+	ObserveRetry()
+}
+
+type loopObserverKey struct{}
+
+// WithLoopObserver returns a context whose runs report to o.
+func WithLoopObserver(ctx context.Context, o LoopObserver) context.Context {
+	return context.WithValue(ctx, loopObserverKey{}, o)
+}
+
+// NotifyRetry reports a retry made outside the loop, such as a re-run
+// sub-task, to the observer of ctx.
+func NotifyRetry(ctx context.Context) {
+	if o, ok := ctx.Value(loopObserverKey{}).(LoopObserver); ok {
+		o.ObserveRetry()
+	}
+}
+
+func notifyTurn(ctx context.Context) {
+	if o, ok := ctx.Value(loopObserverKey{}).(LoopObserver); ok {
+		o.ObserveTurn()
+	}
+}
@@ -45,7 +45,7 @@ func (r *UnifiedReflector) Reflect(ctx context.Context, goal string, action *Act
 	prompt := r.buildPrompt(goal, action, result)
 
 	for attempt := 0; attempt <= r.structuredRetryMax; attempt++ {
-		response, err := r.llm.Complete(ctx, prompt)
+		response, err := CompleteJSON(ctx, r.llm, prompt, reflectionResponseFormat)
 		if err != nil {
 			return nil, fmt.Errorf("reflection failed: %w", err)
 		}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,3 +27,31 @@ func TestUnifiedReflector_InvalidStructuredOutputReturnsTypedError(t *testing.T)
 	assert.True(t, stdErrors.Is(err, heikeErrors.ErrInvalidModelOutput))
 	mockLLM.AssertExpectations(t)
 }
+
+type structuredMockLLMClient struct {
+	MockLLMClient
+}
+
+func (m *structuredMockLLMClient) CompleteStructured(ctx context.Context, prompt string, format contract.ResponseFormat) (string, error) {
+	args := m.Called(ctx, prompt, format)
+	return args.String(0), args.Error(1)
+}
+
+func TestUnifiedReflector_RequestsSchemaWhenSupported(t *testing.T) {
+	mockLLM := new(structuredMockLLMClient)
+	reflector := NewReflector(mockLLM, ReflectorPromptConfig{}, 0)
+
+	ctx := context.Background()
+	action := &Action{Type: ActionTypeAnswer, Content: "done"}
+	result := &ExecutionResult{Success: true, Output: "done"}
+
+	mockLLM.On("CompleteStructured", ctx, mock.Anything, mock.MatchedBy(func(f contract.ResponseFormat) bool {
+		return f.Type == contract.ResponseFormatJSONSchema && f.Schema != nil
+	})).Return(`{"analysis":"ok","next_action":"stop"}`, nil).Once()
+
+	reflection, err := reflector.Reflect(ctx, "Finish task", action, result)
+	assert.NoError(t, err)
+	assert.Equal(t, SignalStop, reflection.NextAction)
+	mockLLM.AssertExpectations(t)
+	mockLLM.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+}
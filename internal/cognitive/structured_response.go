@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/harunnryd/heike/internal/model/contract"
 )
 
 type plannerParseMode string
@@ -27,6 +29,27 @@ type reflectionPayload struct {
 	NewMemories []string `json:"new_memories"`
 }
 
+// reflectionResponseFormat constrains reflector output to reflectionPayload.
+var reflectionResponseFormat = contract.ResponseFormat{
+	Type: contract.ResponseFormatJSONSchema,
+	Name: "reflection",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"analysis": map[string]interface{}{"type": "string"},
+			"next_action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{string(SignalContinue), string(SignalRetry), string(SignalReplan), string(SignalStop)},
+			},
+			"new_memories": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+		"required": []string{"analysis", "next_action"},
+	},
+}
+
 type plannerPayload struct {
 	Steps []PlanStep `json:"steps"`
 	Plan  []PlanStep `json:"plan"`
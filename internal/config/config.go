@@ -24,6 +24,7 @@ type Config struct {
 	Adapters     AdaptersConfig     `koanf:"adapters"`
 	Discovery    DiscoveryConfig    `koanf:"discovery"`
 	Tools        ToolsConfig        `koanf:"tools"`
+	HTTP         HTTPConfig         `koanf:"http"`
 	Ingress      IngressConfig      `koanf:"ingress"`
 	Prompts      PromptsConfig      `koanf:"prompts"`
 	Store        StoreConfig        `koanf:"store"`
@@ -32,13 +33,20 @@ type Config struct {
 	Scheduler    SchedulerConfig    `koanf:"scheduler"`
 	Zanshin      ZanshinConfig      `koanf:"zanshin"`
 	Daemon       DaemonConfig       `koanf:"daemon"`
+	Knowledge    KnowledgeConfig    `koanf:"knowledge"`
+	Backup       BackupConfig       `koanf:"backup"`
+	Batch        BatchConfig        `koanf:"batch"`
+	// Features sets experimental behaviors on or off for the workspace; the
+	// admin API can override them at runtime.
+	Features map[string]bool `koanf:"features"`
 }
 
 type PromptsConfig struct {
-	Planner    PlannerPromptConfig    `koanf:"planner"`
-	Thinker    ThinkerPromptConfig    `koanf:"thinker"`
-	Reflector  ReflectorPromptConfig  `koanf:"reflector"`
-	Decomposer DecomposerPromptConfig `koanf:"decomposer"`
+	Planner     PlannerPromptConfig     `koanf:"planner"`
+	Thinker     ThinkerPromptConfig     `koanf:"thinker"`
+	Reflector   ReflectorPromptConfig   `koanf:"reflector"`
+	Decomposer  DecomposerPromptConfig  `koanf:"decomposer"`
+	Synthesizer SynthesizerPromptConfig `koanf:"synthesizer"`
 }
 
 type PlannerPromptConfig struct {
@@ -61,12 +69,29 @@ type DecomposerPromptConfig struct {
 	Requirements string `koanf:"requirements"`
 }
 
+type SynthesizerPromptConfig struct {
+	System string `koanf:"system"`
+}
+
 type StoreConfig struct {
 	LockTimeout              string `koanf:"lock_timeout"`
 	LockRetry                string `koanf:"lock_retry"`
 	LockMaxRetry             int    `koanf:"lock_max_retry"`
 	InboxSize                int    `koanf:"inbox_size"`
 	TranscriptRotateMaxBytes int64  `koanf:"transcript_rotate_max_bytes"`
+	EventStatusMaxEntries    int    `koanf:"event_status_max_entries"`
+	SandboxRetention         string `koanf:"sandbox_retention"`
+	// VectorRetryBackoff is the first delay before retrying a failed vector
+	// upsert; VectorRetryMaxEntries caps the queued upserts.
+	VectorRetryBackoff    string `koanf:"vector_retry_backoff"`
+	VectorRetryMaxEntries int    `koanf:"vector_retry_max_entries"`
+	// Migration controls the workspace schema upgrade run on startup.
+	Migration StoreMigrationConfig `koanf:"migration"`
+}
+
+type StoreMigrationConfig struct {
+	DryRun bool `koanf:"dry_run"`
+	Backup bool `koanf:"backup"`
 }
 
 type WorkerConfig struct {
@@ -74,21 +99,47 @@ type WorkerConfig struct {
 }
 
 type SchedulerConfig struct {
-	TickInterval         string `koanf:"tick_interval"`
-	ShutdownTimeout      string `koanf:"shutdown_timeout"`
-	LeaseDuration        string `koanf:"lease_duration"`
-	MaxCatchupRuns       int    `koanf:"max_catchup_runs"`
-	InFlightPollInterval string `koanf:"in_flight_poll_interval"`
-	HeartbeatWorkspaceID string `koanf:"heartbeat_workspace_id"`
+	TickInterval         string                `koanf:"tick_interval"`
+	ShutdownTimeout      string                `koanf:"shutdown_timeout"`
+	LeaseDuration        string                `koanf:"lease_duration"`
+	MaxCatchupRuns       int                   `koanf:"max_catchup_runs"`
+	InFlightPollInterval string                `koanf:"in_flight_poll_interval"`
+	HeartbeatWorkspaceID string                `koanf:"heartbeat_workspace_id"`
+	Digest               SchedulerDigestConfig `koanf:"digest"`
+}
+
+type SchedulerDigestConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Period  string `koanf:"period"`
+	Dir     string `koanf:"dir"`
 }
 
 type DaemonConfig struct {
-	ShutdownTimeout        string `koanf:"shutdown_timeout"`
-	HealthCheckInterval    string `koanf:"health_check_interval"`
-	StartupShutdownTimeout string `koanf:"startup_shutdown_timeout"`
-	PreflightTimeout       string `koanf:"preflight_timeout"`
-	StaleLockTTL           string `koanf:"stale_lock_ttl"`
-	WorkspacePath          string `koanf:"workspace_path"`
+	ShutdownTimeout        string       `koanf:"shutdown_timeout"`
+	HealthCheckInterval    string       `koanf:"health_check_interval"`
+	StartupShutdownTimeout string       `koanf:"startup_shutdown_timeout"`
+	PreflightTimeout       string       `koanf:"preflight_timeout"`
+	StaleLockTTL           string       `koanf:"stale_lock_ttl"`
+	WorkspacePath          string       `koanf:"workspace_path"`
+	Alerts                 AlertsConfig `koanf:"alerts"`
+}
+
+// AlertsConfig sends operator alerts through an output adapter when daemon
+// components, model providers or workspace disk usage stay unhealthy.
+type AlertsConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Adapter is the registered output adapter name (e.g. slack) and Target
+	// the platform destination it sends to, such as a channel ID.
+	Adapter string `koanf:"adapter"`
+	Target  string `koanf:"target"`
+	// FailureThreshold is the number of consecutive unhealthy checks, at
+	// daemon.health_check_interval, before an alert is sent.
+	FailureThreshold int    `koanf:"failure_threshold"`
+	RepeatInterval   string `koanf:"repeat_interval"`
+	MaxPerHour       int    `koanf:"max_per_hour"`
+	// MaxWorkspaceBytes raises a disk pressure alert when the workspace grows
+	// beyond it; 0 disables the check.
+	MaxWorkspaceBytes int64 `koanf:"max_workspace_bytes"`
 }
 
 type ZanshinConfig struct {
@@ -100,13 +151,90 @@ type ZanshinConfig struct {
 	MaxIdleTime       string  `koanf:"max_idle_time"`
 }
 
+type KnowledgeConfig struct {
+	Enabled        bool                       `koanf:"enabled"`
+	Collection     string                     `koanf:"collection"`
+	SyncInterval   string                     `koanf:"sync_interval"`
+	RequestTimeout string                     `koanf:"request_timeout"`
+	ChunkSize      int                        `koanf:"chunk_size"`
+	ChunkOverlap   int                        `koanf:"chunk_overlap"`
+	Notion         NotionKnowledgeConfig      `koanf:"notion"`
+	Confluence     ConfluenceKnowledgeConfig  `koanf:"confluence"`
+	GoogleDrive    GoogleDriveKnowledgeConfig `koanf:"gdrive"`
+}
+
+type NotionKnowledgeConfig struct {
+	Enabled bool     `koanf:"enabled"`
+	BaseURL string   `koanf:"base_url"`
+	Token   string   `koanf:"token"`
+	PageIDs []string `koanf:"page_ids"`
+}
+
+type ConfluenceKnowledgeConfig struct {
+	Enabled   bool     `koanf:"enabled"`
+	BaseURL   string   `koanf:"base_url"`
+	Email     string   `koanf:"email"`
+	APIToken  string   `koanf:"api_token"`
+	SpaceKeys []string `koanf:"space_keys"`
+}
+
+type GoogleDriveKnowledgeConfig struct {
+	Enabled     bool     `koanf:"enabled"`
+	BaseURL     string   `koanf:"base_url"`
+	AccessToken string   `koanf:"access_token"`
+	FolderIDs   []string `koanf:"folder_ids"`
+}
+
+type BackupConfig struct {
+	Enabled          bool                  `koanf:"enabled"`
+	URI              string                `koanf:"uri"`
+	Interval         string                `koanf:"interval"`
+	RequestTimeout   string                `koanf:"request_timeout"`
+	Transcripts      bool                  `koanf:"transcripts"`
+	Artifacts        bool                  `koanf:"artifacts"`
+	ArtifactMinBytes int64                 `koanf:"artifact_min_bytes"`
+	Lifecycle        BackupLifecycleConfig `koanf:"lifecycle"`
+}
+
+type BackupLifecycleConfig struct {
+	KeepLast       int    `koanf:"keep_last"`
+	SnapshotMaxAge string `koanf:"snapshot_max_age"`
+	ArchiveMaxAge  string `koanf:"archive_max_age"`
+}
+
+type BatchConfig struct {
+	MaxGoals       int    `koanf:"max_goals"`
+	Concurrency    int    `koanf:"concurrency"`
+	MaxConcurrency int    `koanf:"max_concurrency"`
+	PollInterval   string `koanf:"poll_interval"`
+	MaxBatches     int    `koanf:"max_batches"`
+}
+
 type AdaptersConfig struct {
-	Slack    SlackConfig    `koanf:"slack"`
-	Telegram TelegramConfig `koanf:"telegram"`
+	Reconnect AdapterReconnectConfig `koanf:"reconnect"`
+	Slack     SlackConfig            `koanf:"slack"`
+	Telegram  TelegramConfig         `koanf:"telegram"`
+	Discord   DiscordConfig          `koanf:"discord"`
+	Teams     TeamsConfig            `koanf:"teams"`
+	Email     EmailConfig            `koanf:"email"`
+	Webhook   WebhookConfig          `koanf:"webhook"`
+	Desktop   DesktopConfig          `koanf:"desktop"`
+	// Transcription turns voice notes sent to the Slack and Telegram
+	// adapters into text.
+	Transcription TranscriptionConfig `koanf:"transcription"`
+}
+
+type AdapterReconnectConfig struct {
+	InitialBackoff   string `koanf:"initial_backoff"`
+	MaxBackoff       string `koanf:"max_backoff"`
+	CircuitThreshold int    `koanf:"circuit_threshold"`
 }
 
 type AuthConfig struct {
-	Codex CodexAuthConfig `koanf:"codex"`
+	// SecretStore selects where provider credentials are kept: "file" (JSON
+	// under ~/.heike/auth) or "keyring" (the OS credential store).
+	SecretStore string          `koanf:"secret_store"`
+	Codex       CodexAuthConfig `koanf:"codex"`
 }
 
 type CodexAuthConfig struct {
@@ -120,22 +248,58 @@ type DiscoveryConfig struct {
 	ProjectPath  string   `koanf:"project_path"`
 	SkillSources []string `koanf:"skill_sources"`
 	ToolSources  []string `koanf:"tool_sources"`
+	// BundledVersion pins the bundled skill library to a version installed by
+	// an earlier release; empty follows the binary.
+	BundledVersion string `koanf:"bundled_version"`
+}
+
+// HTTPConfig sets the connection pool and egress policy shared by the
+// outbound HTTP clients of tools and model providers.
+type HTTPConfig struct {
+	MaxIdleConns        int    `koanf:"max_idle_conns"`
+	MaxIdleConnsPerHost int    `koanf:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int    `koanf:"max_conns_per_host"`
+	IdleConnTimeout     string `koanf:"idle_conn_timeout"`
+	DialTimeout         string `koanf:"dial_timeout"`
+	TLSHandshakeTimeout string `koanf:"tls_handshake_timeout"`
+	// Proxy is a proxy URL; empty reads the proxy environment variables and
+	// "direct" disables proxies.
+	Proxy string `koanf:"proxy"`
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile        string `koanf:"ca_file"`
+	TLSMinVersion string `koanf:"tls_min_version"`
 }
 
 type ToolsConfig struct {
-	Web        WebToolConfig        `koanf:"web"`
-	Weather    WeatherToolConfig    `koanf:"weather"`
-	Finance    FinanceToolConfig    `koanf:"finance"`
-	Sports     SportsToolConfig     `koanf:"sports"`
-	ImageQuery ImageQueryToolConfig `koanf:"image_query"`
-	Screenshot ScreenshotToolConfig `koanf:"screenshot"`
-	ApplyPatch ApplyPatchToolConfig `koanf:"apply_patch"`
+	Web        WebToolConfig         `koanf:"web"`
+	Weather    WeatherToolConfig     `koanf:"weather"`
+	Finance    FinanceToolConfig     `koanf:"finance"`
+	Sports     SportsToolConfig      `koanf:"sports"`
+	ImageQuery ImageQueryToolConfig  `koanf:"image_query"`
+	Screenshot ScreenshotToolConfig  `koanf:"screenshot"`
+	ApplyPatch ApplyPatchToolConfig  `koanf:"apply_patch"`
+	Files      FilesToolConfig       `koanf:"files"`
+	HTTP       HTTPRequestToolConfig `koanf:"http"`
+	SQL        SQLToolConfig         `koanf:"sql"`
+	GitHub     GitHubToolConfig      `koanf:"github"`
+	Calendar   CalendarToolConfig    `koanf:"calendar"`
+	News       NewsToolConfig        `koanf:"news"`
+	Convert    ConvertToolConfig     `koanf:"convert"`
+	Email      EmailToolConfig       `koanf:"email"`
+	Python     PythonToolConfig      `koanf:"python"`
+	MCP        MCPToolConfig         `koanf:"mcp"`
+	Cache      ToolCacheConfig       `koanf:"cache"`
 }
 
+// WebToolConfig configures search_query and open. Engine picks the search
+// backend (bing, brave, searxng or duckduckgo); BaseURL overrides its
+// endpoint and APIKey authenticates to engines that need one.
 type WebToolConfig struct {
 	BaseURL          string `koanf:"base_url"`
 	Timeout          string `koanf:"timeout"`
 	MaxContentLength int    `koanf:"max_content_length"`
+	Engine           string `koanf:"engine"`
+	APIKey           string `koanf:"api_key"`
 }
 
 type WeatherToolConfig struct {
@@ -143,9 +307,17 @@ type WeatherToolConfig struct {
 	Timeout string `koanf:"timeout"`
 }
 
+// FinanceToolConfig configures the finance tool. Quotes and history come
+// from Yahoo Finance, except crypto, which uses the CoinGecko API at
+// CryptoBaseURL.
 type FinanceToolConfig struct {
-	BaseURL string `koanf:"base_url"`
-	Timeout string `koanf:"timeout"`
+	BaseURL      string `koanf:"base_url"`
+	Timeout      string `koanf:"timeout"`
+	ChartBaseURL string `koanf:"chart_base_url"`
+	// CryptoBaseURL is a CoinGecko API root; CryptoAPIKey is its optional
+	// demo or pro key.
+	CryptoBaseURL string `koanf:"crypto_base_url"`
+	CryptoAPIKey  string `koanf:"crypto_api_key"`
 }
 
 type SportsToolConfig struct {
@@ -158,34 +330,344 @@ type ImageQueryToolConfig struct {
 	Timeout string `koanf:"timeout"`
 }
 
+// ScreenshotToolConfig configures the screenshot tool. Renderer is the
+// pdftoppm binary for PDFs, or "chrome" to also capture web pages with
+// headless Chrome at ChromePath (searched in PATH when empty).
 type ScreenshotToolConfig struct {
-	Timeout  string `koanf:"timeout"`
-	Renderer string `koanf:"renderer"`
+	Timeout    string `koanf:"timeout"`
+	Renderer   string `koanf:"renderer"`
+	ChromePath string `koanf:"chrome_path"`
 }
 
 type ApplyPatchToolConfig struct {
 	Command string `koanf:"command"`
 }
 
+// FilesToolConfig limits read_file, write_file and list_dir, which only
+// reach the calling session's sandbox.
+type FilesToolConfig struct {
+	MaxReadBytes  int64 `koanf:"max_read_bytes"`
+	MaxWriteBytes int64 `koanf:"max_write_bytes"`
+	// AllowedExtensions, when set, are the only file extensions the tools
+	// read or write; DeniedExtensions are always refused.
+	AllowedExtensions []string `koanf:"allowed_extensions"`
+	DeniedExtensions  []string `koanf:"denied_extensions"`
+}
+
+// HTTPRequestToolConfig governs the http_request tool. Hosts match a
+// domain entry when they equal it or are subdomains of it.
+type HTTPRequestToolConfig struct {
+	Timeout string `koanf:"timeout"`
+	// AllowedDomains, when set, are the only hosts requests may reach;
+	// DeniedDomains are refused even when allowed.
+	AllowedDomains   []string `koanf:"allowed_domains"`
+	DeniedDomains    []string `koanf:"denied_domains"`
+	MaxRequestBytes  int64    `koanf:"max_request_bytes"`
+	MaxResponseBytes int64    `koanf:"max_response_bytes"`
+	// AllowPrivateNetworks permits hosts that resolve to loopback, private
+	// or link-local addresses.
+	AllowPrivateNetworks bool `koanf:"allow_private_networks"`
+}
+
+// SQLToolConfig configures sql_query. Queries run through the sqlite3 and
+// psql command-line clients.
+type SQLToolConfig struct {
+	Timeout string `koanf:"timeout"`
+	// MaxRows caps the rows returned; larger results are truncated and
+	// summarized.
+	MaxRows        int   `koanf:"max_rows"`
+	MaxOutputBytes int64 `koanf:"max_output_bytes"`
+	// ReadOnly refuses anything but a single read statement and opens
+	// databases read-only.
+	ReadOnly        bool                `koanf:"read_only"`
+	SQLiteCommand   string              `koanf:"sqlite_command"`
+	PostgresCommand string              `koanf:"postgres_command"`
+	Databases       []SQLDatabaseConfig `koanf:"databases"`
+}
+
+// SQLDatabaseConfig is one database sql_query can reach by name.
+type SQLDatabaseConfig struct {
+	Name string `koanf:"name"`
+	// Driver is sqlite or postgres; empty infers it from DSN.
+	Driver string `koanf:"driver"`
+	// DSN is a file path for sqlite and a connection URI or keyword
+	// string for postgres.
+	DSN string `koanf:"dsn"`
+}
+
+// GitHubToolConfig configures the github tool. Token is a personal access
+// token; GITHUB_TOKEN is used when it is empty.
+type GitHubToolConfig struct {
+	BaseURL string `koanf:"base_url"`
+	Timeout string `koanf:"timeout"`
+	Token   string `koanf:"token"`
+}
+
+// CalendarToolConfig configures the calendar tool against one CalDAV or
+// Google calendar.
+type CalendarToolConfig struct {
+	// Provider is caldav or google; empty picks whichever is configured.
+	Provider string `koanf:"provider"`
+	Timeout  string `koanf:"timeout"`
+	// Timezone interprets times given without an offset; empty uses the
+	// system zone.
+	Timezone string               `koanf:"timezone"`
+	CalDAV   CalDAVCalendarConfig `koanf:"caldav"`
+	Google   GoogleCalendarConfig `koanf:"google"`
+}
+
+// CalDAVCalendarConfig reaches a CalDAV calendar collection with basic auth.
+type CalDAVCalendarConfig struct {
+	URL      string `koanf:"url"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+}
+
+// GoogleCalendarConfig reaches the Google Calendar API. A refresh token
+// with ClientID and ClientSecret keeps access working; AccessToken alone
+// expires after an hour.
+type GoogleCalendarConfig struct {
+	BaseURL      string `koanf:"base_url"`
+	TokenURL     string `koanf:"token_url"`
+	CalendarID   string `koanf:"calendar_id"`
+	AccessToken  string `koanf:"access_token"`
+	ClientID     string `koanf:"client_id"`
+	ClientSecret string `koanf:"client_secret"`
+	RefreshToken string `koanf:"refresh_token"`
+}
+
+// NewsToolConfig configures the news tool. Feeds are fetched at most once
+// per CacheTTL; later calls reuse the cached copy.
+type NewsToolConfig struct {
+	Timeout  string `koanf:"timeout"`
+	CacheTTL string `koanf:"cache_ttl"`
+	// MaxItems is the per-feed item count when a call does not set one.
+	MaxItems int              `koanf:"max_items"`
+	Feeds    []NewsFeedConfig `koanf:"feeds"`
+}
+
+// NewsFeedConfig is one RSS or Atom feed the news tool can read by name.
+type NewsFeedConfig struct {
+	Name string `koanf:"name"`
+	URL  string `koanf:"url"`
+}
+
+// ConvertToolConfig configures the convert tool. BaseURL serves exchange
+// rates as the ECB reference-rate XML or as JSON with base and rates;
+// rates are fetched at most once per CacheTTL.
+type ConvertToolConfig struct {
+	BaseURL  string `koanf:"base_url"`
+	Timeout  string `koanf:"timeout"`
+	CacheTTL string `koanf:"cache_ttl"`
+}
+
+// EmailToolConfig configures send_email. An empty SMTP host falls back to
+// adapters.email.smtp, and an empty From to adapters.email.address.
+type EmailToolConfig struct {
+	From    string `koanf:"from"`
+	Timeout string `koanf:"timeout"`
+	// AllowedRecipients, when set, are the only addresses mail may go to;
+	// "@domain" entries cover a whole domain.
+	AllowedRecipients []string `koanf:"allowed_recipients"`
+	// MaxAttachmentBytes caps the combined size of a message's attachments.
+	MaxAttachmentBytes int64             `koanf:"max_attachment_bytes"`
+	SMTP               EmailServerConfig `koanf:"smtp"`
+}
+
+// PythonToolConfig configures python_exec. Snippets run in a fresh
+// interpreter process with the session sandbox as working directory.
+type PythonToolConfig struct {
+	// Command is the interpreter used when Venv is empty, and to create
+	// Venv when it does not exist yet.
+	Command string `koanf:"command"`
+	// Venv is a virtual environment directory whose interpreter runs the
+	// snippets, so packages can be installed apart from the system Python.
+	Venv    string `koanf:"venv"`
+	Timeout string `koanf:"timeout"`
+	// MaxMemoryBytes caps the interpreter's address space.
+	MaxMemoryBytes int64 `koanf:"max_memory_bytes"`
+	// MaxOutputBytes caps stdout and stderr each.
+	MaxOutputBytes int64 `koanf:"max_output_bytes"`
+}
+
+// ToolCacheConfig controls the tool runner's in-memory result cache. Only
+// tools listed in TTLs are cached, each for its own duration; results are
+// reused within a session only.
+type ToolCacheConfig struct {
+	Enabled    bool              `koanf:"enabled"`
+	MaxEntries int               `koanf:"max_entries"`
+	TTLs       map[string]string `koanf:"ttls"`
+}
+
+// MCPToolConfig lists Model Context Protocol servers whose tools are
+// registered alongside the built-ins.
+type MCPToolConfig struct {
+	// Timeout bounds the handshake and each request of servers that do not
+	// set their own.
+	Timeout string            `koanf:"timeout"`
+	Servers []MCPServerConfig `koanf:"servers"`
+}
+
+// MCPServerConfig is one MCP server. Its tools are named <name>_<tool>.
+type MCPServerConfig struct {
+	Name      string `koanf:"name"`
+	Transport string `koanf:"transport"`
+	// Command, Args and Env start a stdio server.
+	Command string            `koanf:"command"`
+	Args    []string          `koanf:"args"`
+	Env     map[string]string `koanf:"env"`
+	// URL and Headers reach an sse server.
+	URL     string            `koanf:"url"`
+	Headers map[string]string `koanf:"headers"`
+	Timeout string            `koanf:"timeout"`
+}
+
 type IngressConfig struct {
 	InteractiveQueueSize     int    `koanf:"interactive_queue_size"`
 	BackgroundQueueSize      int    `koanf:"background_queue_size"`
 	InteractiveSubmitTimeout string `koanf:"interactive_submit_timeout"`
 	DrainTimeout             string `koanf:"drain_timeout"`
 	DrainPollInterval        string `koanf:"drain_poll_interval"`
+	// Overload thresholds are queue utilisation fractions (0-1). Above the
+	// high-water mark polling adapters pause; they resume at the low-water mark.
+	HighWaterMark         float64 `koanf:"high_water_mark"`
+	LowWaterMark          float64 `koanf:"low_water_mark"`
+	OverloadCheckInterval string  `koanf:"overload_check_interval"`
+	BusyMessage           string  `koanf:"busy_message"`
 }
 
 type SlackConfig struct {
-	Enabled       bool   `koanf:"enabled"`
-	Port          int    `koanf:"port"`
-	SigningSecret string `koanf:"signing_secret"`
-	BotToken      string `koanf:"bot_token"`
+	Enabled       bool                `koanf:"enabled"`
+	Port          int                 `koanf:"port"`
+	SigningSecret string              `koanf:"signing_secret"`
+	BotToken      string              `koanf:"bot_token"`
+	Format        MessageFormatConfig `koanf:"format"`
 }
 
 type TelegramConfig struct {
 	Enabled       bool   `koanf:"enabled"`
 	BotToken      string `koanf:"bot_token"`
 	UpdateTimeout int    `koanf:"update_timeout"`
+	// Mode is "polling" (default) or "webhook".
+	Mode    string                `koanf:"mode"`
+	Poll    PollConfig            `koanf:"poll"`
+	Webhook TelegramWebhookConfig `koanf:"webhook"`
+	Format  MessageFormatConfig   `koanf:"format"`
+}
+
+type DiscordConfig struct {
+	Enabled  bool   `koanf:"enabled"`
+	BotToken string `koanf:"bot_token"`
+	// Guilds and Channels allowlist where messages are accepted; empty
+	// allows all.
+	Guilds   []string `koanf:"guilds"`
+	Channels []string `koanf:"channels"`
+	// SlashCommands registers /ask, /new, /reset, /history, /session,
+	// /approve and /deny when the bot connects.
+	SlashCommands bool                `koanf:"slash_commands"`
+	Format        MessageFormatConfig `koanf:"format"`
+}
+
+type TeamsConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// AppID and AppPassword are the Azure Bot registration's Microsoft app
+	// ID and client secret.
+	AppID       string `koanf:"app_id"`
+	AppPassword string `koanf:"app_password"`
+	// TenantID is set for single-tenant bots.
+	TenantID string              `koanf:"tenant_id"`
+	Port     int                 `koanf:"port"`
+	Format   MessageFormatConfig `koanf:"format"`
+}
+
+// MessageFormatConfig shapes the assistant replies a chat adapter sends.
+type MessageFormatConfig struct {
+	// Style is markdown, slack (mrkdwn), telegram_html or plain.
+	Style string `koanf:"style"`
+	// MaxChars is the longest message sent; longer replies are split. It
+	// cannot exceed the platform's own limit.
+	MaxChars int `koanf:"max_chars"`
+	// MaxChunks caps the messages one reply is split into; the rest is
+	// dropped with a notice. 0 is unlimited.
+	MaxChunks int `koanf:"max_chunks"`
+}
+
+type EmailConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Address is the agent's mailbox; unseen mail to it is read and replies
+	// are sent from it.
+	Address string `koanf:"address"`
+	// AllowedSenders lists accepted sender addresses, or "@domain" for a
+	// whole domain. It is required so strangers cannot start tasks.
+	AllowedSenders []string          `koanf:"allowed_senders"`
+	Mailbox        string            `koanf:"mailbox"`
+	PollInterval   string            `koanf:"poll_interval"`
+	IMAP           EmailServerConfig `koanf:"imap"`
+	SMTP           EmailServerConfig `koanf:"smtp"`
+}
+
+type EmailServerConfig struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+}
+
+type WebhookConfig struct {
+	Enabled bool                 `koanf:"enabled"`
+	Port    int                  `koanf:"port"`
+	Routes  []WebhookRouteConfig `koanf:"routes"`
+}
+
+// WebhookRouteConfig is one inbound webhook route.
+type WebhookRouteConfig struct {
+	Name   string `koanf:"name"`
+	Path   string `koanf:"path"`
+	Secret string `koanf:"secret"`
+	// SignatureHeader carries "sha256=<hex>" HMAC-SHA256 of the body keyed
+	// with Secret; requests without it must send "Authorization: Bearer <secret>".
+	SignatureHeader string `koanf:"signature_header"`
+	// Session and Template are Go templates over the JSON payload for the
+	// session ID and the goal text.
+	Session  string `koanf:"session"`
+	Template string `koanf:"template"`
+}
+
+// PollConfig adapts the polling interval of pull adapters to activity.
+type PollConfig struct {
+	// ActiveWindow is how long after the last update polls run back to back.
+	ActiveWindow string `koanf:"active_window"`
+	// MaxIdleInterval caps the pause between polls once idle; "0s" disables
+	// the pause.
+	MaxIdleInterval string `koanf:"max_idle_interval"`
+}
+
+type TelegramWebhookConfig struct {
+	URL         string `koanf:"url"`
+	Port        int    `koanf:"port"`
+	SecretToken string `koanf:"secret_token"`
+}
+
+type TranscriptionConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Provider is "openai" (an OpenAI-compatible /audio/transcriptions
+	// endpoint) or "command".
+	Provider string `koanf:"provider"`
+	// Command runs as <command> <file> and prints the transcript.
+	Command  string `koanf:"command"`
+	BaseURL  string `koanf:"base_url"`
+	APIKey   string `koanf:"api_key"`
+	Model    string `koanf:"model"`
+	Language string `koanf:"language"`
+	Timeout  string `koanf:"timeout"`
+	// MaxBytes skips larger audio files.
+	MaxBytes int64 `koanf:"max_bytes"`
+}
+
+type DesktopConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Title   string `koanf:"title"`
+	Command string `koanf:"command"`
 }
 
 type ServerConfig struct {
@@ -195,14 +677,106 @@ type ServerConfig struct {
 	WriteTimeout    string `koanf:"write_timeout"`
 	IdleTimeout     string `koanf:"idle_timeout"`
 	ShutdownTimeout string `koanf:"shutdown_timeout"`
+	MaxBatchEvents  int    `koanf:"max_batch_events"`
+	// MaxContextDocuments caps the documents in one session context request.
+	MaxContextDocuments int `koanf:"max_context_documents"`
+	// Callback controls result webhooks for events submitted with a callback_url.
+	Callback ServerCallbackConfig `koanf:"callback"`
+	// GRPC serves the runtime API over gRPC next to the HTTP API.
+	GRPC ServerGRPCConfig `koanf:"grpc"`
+	// Auth requires credentials on the runtime API once any key or JWT
+	// secret is configured.
+	Auth ServerAuthConfig `koanf:"auth"`
+	// Debug serves /debug/pprof and /api/v1/debug/runtime.
+	Debug bool `koanf:"debug"`
+}
+
+type ServerAuthConfig struct {
+	APIKeys []ServerAPIKey  `koanf:"api_keys"`
+	JWT     ServerJWTConfig `koanf:"jwt"`
+}
+
+// ServerAPIKey is a static key sent as a bearer token or X-API-Key header.
+// Scopes are read, submit, and approve.
+type ServerAPIKey struct {
+	Name   string   `koanf:"name"`
+	Key    string   `koanf:"key"`
+	Scopes []string `koanf:"scopes"`
+}
+
+// ServerJWTConfig accepts HS256 bearer tokens signed with Secret. Scopes are
+// taken from the token's scope claim.
+type ServerJWTConfig struct {
+	Secret   string `koanf:"secret"`
+	Issuer   string `koanf:"issuer"`
+	Audience string `koanf:"audience"`
+}
+
+type ServerGRPCConfig struct {
+	Enabled bool `koanf:"enabled"`
+	Port    int  `koanf:"port"`
+}
+
+type ServerCallbackConfig struct {
+	Secret       string   `koanf:"secret"`
+	Timeout      string   `koanf:"timeout"`
+	MaxAttempts  int      `koanf:"max_attempts"`
+	Backoff      string   `koanf:"backoff"`
+	AllowedHosts []string `koanf:"allowed_hosts"`
 }
 
 type ModelsConfig struct {
-	Default             string          `koanf:"default"`
-	Fallback            string          `koanf:"fallback"`
-	Embedding           string          `koanf:"embedding"`
-	MaxFallbackAttempts int             `koanf:"max_fallback_attempts"`
-	Registry            []ModelRegistry `koanf:"registry"`
+	Default             string `koanf:"default"`
+	Fallback            string `koanf:"fallback"`
+	Embedding           string `koanf:"embedding"`
+	MaxFallbackAttempts int    `koanf:"max_fallback_attempts"`
+	HealthTimeout       string `koanf:"health_timeout"`
+	// HealthProbeCompletions lets providers without a free endpoint check
+	// health with a minimal, billed completion.
+	HealthProbeCompletions bool                 `koanf:"health_probe_completions"`
+	Registry               []ModelRegistry      `koanf:"registry"`
+	Pricing                []ModelPricing       `koanf:"pricing"`
+	Cache                  ModelCacheConfig     `koanf:"cache"`
+	CircuitBreaker         CircuitBreakerConfig `koanf:"circuit_breaker"`
+	Retry                  ModelRetryConfig     `koanf:"retry"`
+	WireLog                WireLogConfig        `koanf:"wire_log"`
+}
+
+// WireLogConfig controls recording of raw completion traffic to per-session
+// JSONL files in the workspace for `heike session trace`.
+type WireLogConfig struct {
+	Enabled bool `koanf:"enabled"`
+}
+
+// ModelRetryConfig controls retries of a model before the router switches to
+// the fallback model.
+type ModelRetryConfig struct {
+	MaxAttempts int      `koanf:"max_attempts"`
+	BackoffBase string   `koanf:"backoff_base"`
+	RetryOn     []string `koanf:"retry_on"`
+}
+
+// CircuitBreakerConfig controls per-model circuit breaking in the router.
+type CircuitBreakerConfig struct {
+	Enabled          bool   `koanf:"enabled"`
+	FailureThreshold int    `koanf:"failure_threshold"`
+	Cooldown         string `koanf:"cooldown"`
+}
+
+// ModelCacheConfig controls the router's in-memory completion cache.
+type ModelCacheConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	TTL        string `koanf:"ttl"`
+	MaxEntries int    `koanf:"max_entries"`
+}
+
+// ModelPricing is the USD price per 1,000 tokens for a registry model.
+type ModelPricing struct {
+	Model       string  `koanf:"model"`
+	InputPer1K  float64 `koanf:"input_per_1k"`
+	OutputPer1K float64 `koanf:"output_per_1k"`
+	// CachedInputPer1K prices prompt-cache reads; zero uses InputPer1K.
+	CachedInputPer1K float64 `koanf:"cached_input_per_1k"`
 }
 
 type ModelRegistry struct {
@@ -213,27 +787,111 @@ type ModelRegistry struct {
 	AuthFile               string `koanf:"auth_file"`
 	RequestTimeout         string `koanf:"request_timeout"`
 	EmbeddingInputMaxChars int    `koanf:"embedding_input_max_chars"`
+	PromptCache            bool   `koanf:"prompt_cache"`
+	// MaxEventBytes, MaxResponseBytes and MaxToolArgBytes bound the memory
+	// of one openai-codex streamed response.
+	MaxEventBytes    int `koanf:"max_event_bytes"`
+	MaxResponseBytes int `koanf:"max_response_bytes"`
+	MaxToolArgBytes  int `koanf:"max_tool_arg_bytes"`
+	// KeepAlive, NumCtx and PullMissing apply to ollama-native models only.
+	KeepAlive   string `koanf:"keep_alive"`
+	NumCtx      int    `koanf:"num_ctx"`
+	PullMissing bool   `koanf:"pull_missing"`
+	// Fixture is the YAML file of canned responses for mock models.
+	Fixture string `koanf:"fixture"`
+	// Tags are capability labels (e.g. fast, cheap, reasoning) that callers
+	// can route to as "tag:<name>" instead of naming a model.
+	Tags []string `koanf:"tags"`
+	// Weight is this model's share of traffic within a tag group; <= 0 means 1.
+	Weight int `koanf:"weight"`
 }
 
 type GovernanceConfig struct {
-	RequireApproval []string `koanf:"require_approval"`
-	AutoAllow       []string `koanf:"auto_allow"`
-	IdempotencyTTL  string   `koanf:"idempotency_ttl"`
-	DailyToolLimit  int      `koanf:"daily_tool_limit"`
+	RequireApproval    []string    `koanf:"require_approval"`
+	AutoAllow          []string    `koanf:"auto_allow"`
+	IdempotencyTTL     string      `koanf:"idempotency_ttl"`
+	DailyToolLimit     int         `koanf:"daily_tool_limit"`
+	RateLimitPerMinute int         `koanf:"rate_limit_per_minute"`
+	DailyCostLimitUSD  float64     `koanf:"daily_cost_limit_usd"`
+	Backend            string      `koanf:"backend"`
+	Redis              RedisConfig `koanf:"redis"`
+	// ToolLimits throttles tools by name in this daemon: calls beyond a
+	// limit wait for their turn instead of failing.
+	ToolLimits map[string]ToolLimitConfig `koanf:"tool_limits"`
+}
+
+// ToolLimitConfig caps one tool's call rate and how many of its calls run
+// at once. Zero leaves either unlimited.
+type ToolLimitConfig struct {
+	QPS           float64 `koanf:"qps"`
+	MaxConcurrent int     `koanf:"max_concurrent"`
+}
+
+// RedisConfig configures the shared governance backend used when
+// governance.backend is "redis".
+type RedisConfig struct {
+	Addr      string `koanf:"addr"`
+	Password  string `koanf:"password"`
+	DB        int    `koanf:"db"`
+	KeyPrefix string `koanf:"key_prefix"`
+	Timeout   string `koanf:"timeout"`
 }
 
 type OrchestratorConfig struct {
-	Verbose                bool   `koanf:"verbose"`
-	MaxSubTasks            int    `koanf:"max_sub_tasks"`
-	MaxParallelSubTasks    int    `koanf:"max_parallel_subtasks"`
-	MaxToolsPerTurn        int    `koanf:"max_tools_per_turn"`
-	MaxTurns               int    `koanf:"max_turns"`
-	TokenBudget            int    `koanf:"token_budget"`
-	DecomposeWordThreshold int    `koanf:"decompose_word_threshold"`
-	SessionHistoryLimit    int    `koanf:"session_history_limit"`
-	StructuredRetryMax     int    `koanf:"structured_retry_max"`
-	SubTaskRetryMax        int    `koanf:"subtask_retry_max"`
-	SubTaskRetryBackoff    string `koanf:"subtask_retry_backoff"`
+	Verbose                bool                `koanf:"verbose"`
+	MaxSubTasks            int                 `koanf:"max_sub_tasks"`
+	MaxParallelSubTasks    int                 `koanf:"max_parallel_subtasks"`
+	MaxToolsPerTurn        int                 `koanf:"max_tools_per_turn"`
+	MaxTurns               int                 `koanf:"max_turns"`
+	TokenBudget            int                 `koanf:"token_budget"`
+	DecomposeWordThreshold int                 `koanf:"decompose_word_threshold"`
+	SessionHistoryLimit    int                 `koanf:"session_history_limit"`
+	StructuredRetryMax     int                 `koanf:"structured_retry_max"`
+	SubTaskRetryMax        int                 `koanf:"subtask_retry_max"`
+	SubTaskRetryBackoff    string              `koanf:"subtask_retry_backoff"`
+	ToolImages             bool                `koanf:"tool_images"`
+	ExecutionReport        bool                `koanf:"execution_report"`
+	BestOfN                BestOfNConfig       `koanf:"best_of_n"`
+	PostMortem             PostMortemConfig    `koanf:"postmortem"`
+	QuotaRetry             QuotaRetryConfig    `koanf:"quota_retry"`
+	Synthesis              SynthesisConfig     `koanf:"synthesis"`
+	Skills                 SkillsContextConfig `koanf:"skills"`
+}
+
+// SkillsContextConfig limits the skills injected into a turn's context.
+// MaxChars caps each skill's guidance; the whole block is also held to a
+// quarter of TokenBudget.
+type SkillsContextConfig struct {
+	MaxSelected int `koanf:"max_selected"`
+	MaxChars    int `koanf:"max_chars"`
+}
+
+// SynthesisConfig controls the final call that writes the reply to a
+// decomposed goal from its sub-task results. Model defaults to the default
+// model.
+type SynthesisConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Model   string `koanf:"model"`
+}
+
+// QuotaRetryConfig controls how turns that hit a provider rate limit or
+// exhausted quota are retried later instead of failing.
+type QuotaRetryConfig struct {
+	Enabled    bool   `koanf:"enabled"`
+	Backoff    string `koanf:"backoff"`
+	MaxRetries int    `koanf:"max_retries"`
+}
+
+type PostMortemConfig struct {
+	Enabled  bool `koanf:"enabled"`
+	Remember bool `koanf:"remember"`
+}
+
+type BestOfNConfig struct {
+	Enabled         bool   `koanf:"enabled"`
+	Samples         int    `koanf:"samples"`
+	JudgeModel      string `koanf:"judge_model"`
+	MaxSampleTokens int    `koanf:"max_sample_tokens"`
 }
 
 const (
@@ -244,35 +902,57 @@ const (
 	DefaultServerWriteTimeout              = "10s"
 	DefaultServerIdleTimeout               = "60s"
 	DefaultServerShutdownTimeout           = "5s"
+	DefaultServerMaxBatchEvents            = 100
+	DefaultServerMaxContextDocuments       = 50
+	DefaultServerCallbackTimeout           = "10s"
+	DefaultServerCallbackMaxAttempts       = 3
+	DefaultServerCallbackBackoff           = "1s"
+	DefaultServerGRPCPort                  = 9090
 	DefaultModelDefault                    = "gpt-4-turbo"
 	DefaultModelFallback                   = "claude-3-haiku"
 	DefaultModelEmbedding                  = "nomic-embed-text"
 	DefaultModelMaxFallbackAttempts        = 2
+	DefaultModelCacheTTL                   = "10m"
+	DefaultModelCacheMaxEntries            = 256
+	DefaultModelCircuitBreakerThreshold    = 5
+	DefaultModelCircuitBreakerCooldown     = "60s"
+	DefaultModelHealthTimeout              = "5s"
+	DefaultModelRetryMaxAttempts           = 3
+	DefaultModelRetryBackoffBase           = "500ms"
 	DefaultOpenAIBaseURL                   = "https://api.openai.com/v1"
 	DefaultOllamaBaseURL                   = "http://localhost:11434/v1"
 	DefaultOllamaAPIKey                    = "ollama"
+	DefaultOllamaRequestTimeout            = "120s"
 	DefaultCodexBaseURL                    = "https://chatgpt.com/backend-api"
+	DefaultProviderRequestTimeout          = "60s"
 	DefaultGovernanceIdempotencyTTL        = "24h"
 	DefaultGovernanceDailyToolLimit        = 100
+	DefaultGovernanceBackend               = "file"
+	DefaultGovernanceRedisAddr             = "localhost:6379"
+	DefaultGovernanceRedisKeyPrefix        = "heike"
+	DefaultGovernanceRedisTimeout          = "5s"
+	DefaultGovernanceSearchQueryQPS        = 1.0
+	DefaultGovernanceSearchQueryConcurrent = 2
+	DefaultAuthSecretStore                 = "file"
 	DefaultCodexAuthCallbackAddr           = "localhost:1455"
 	DefaultCodexAuthRedirectURI            = "http://localhost:1455/auth/callback"
 	DefaultCodexAuthOAuthTimeout           = "5m"
 	DefaultCodexRequestTimeout             = "120s"
 	DefaultCodexEmbeddingInputMaxChars     = 8000
+	DefaultCodexMaxEventBytes              = 4 << 20
+	DefaultCodexMaxResponseBytes           = 2 << 20
+	DefaultCodexMaxToolArgBytes            = 1 << 20
 	DefaultDiscoveryProjectPath            = ""
-	DefaultPlannerSystemPrompt             = "You are a strategic planning agent. Create a concise, step-by-step plan to achieve the goal."
-	DefaultPlannerOutputPrompt             = "Output the plan as a JSON array of objects with 'id' and 'description' fields. Do not include other text."
-	DefaultThinkerSystemPrompt             = "You are Heike, an intelligent agent executing a task."
-	DefaultThinkerInstructionPrompt        = "Think step-by-step. If you need to use a tool, do so. If you have the final answer, provide it clearly."
-	DefaultReflectorSystemPrompt           = "You are a reflective agent. Analyze the last action and its result."
-	DefaultReflectorGuidelinesPrompt       = "Analyze what happened. Did it succeed? What did we learn? What should be the next step?\n\nReturn a JSON object with:\n- \"analysis\": string (your reasoning)\n- \"next_action\": string (\"continue\", \"retry\", \"replan\", \"stop\")\n- \"new_memories\": array of strings (facts to remember)\n\nGuidelines:\n- \"retry\": if the tool failed transiently.\n- \"replan\": if the current plan is impossible or invalid.\n- \"stop\": if the goal is achieved or impossible.\n- \"continue\": otherwise."
-	DefaultDecomposerSystemPrompt          = "You are a task decomposition expert. Break down the following high-level goal into a list of specific, executable sub-tasks."
-	DefaultDecomposerRequirementsPrompt    = "Requirements:\n1. Each sub-task must be clear and actionable.\n2. Return the result as a JSON array of objects with:\n   - 'id' (string): unique identifier\n   - 'description' (string): actionable instruction\n   - 'priority' (int): 1 (high) to 5 (low)\n   - 'dependencies' (array of strings): list of IDs that must be completed BEFORE this task can start.\n3. Analyze dependencies carefully. If Task B requires output from Task A, Task B must list Task A's ID in 'dependencies'.\n4. Do not include markdown formatting or explanations, just the raw JSON."
+	DefaultDiscoveryBundledVersion         = ""
 	DefaultStoreLockTimeout                = "30s"
 	DefaultStoreLockRetry                  = "100ms"
 	DefaultStoreLockMaxRetry               = 300
 	DefaultStoreInboxSize                  = 100
 	DefaultStoreTranscriptRotateMaxBytes   = 10 * 1024 * 1024
+	DefaultStoreEventStatusMaxEntries      = 4096
+	DefaultStoreSandboxRetention           = "168h"
+	DefaultStoreVectorRetryBackoff         = "5s"
+	DefaultStoreVectorRetryMaxEntries      = 1000
 	DefaultOrchestratorVerbose             = false
 	DefaultOrchestratorMaxSubTasks         = 10
 	DefaultOrchestratorMaxParallelSubTasks = 4
@@ -284,20 +964,75 @@ const (
 	DefaultOrchestratorStructuredRetryMax  = 1
 	DefaultOrchestratorSubTaskRetryMax     = 3
 	DefaultOrchestratorSubTaskRetryBackoff = "1s"
+	DefaultOrchestratorToolImages          = true
+	DefaultOrchestratorExecutionReport     = false
+	DefaultOrchestratorBestOfNSamples      = 3
+	DefaultOrchestratorBestOfNMaxTokens    = 32000
+	DefaultOrchestratorPostMortemEnabled   = true
+	DefaultOrchestratorQuotaRetryEnabled   = true
+	DefaultOrchestratorQuotaRetryBackoff   = "30s"
+	DefaultOrchestratorQuotaRetryMax       = 3
+	DefaultOrchestratorSynthesisEnabled    = true
+	DefaultOrchestratorSkillsMaxSelected   = 4
+	DefaultOrchestratorSkillsMaxChars      = 600
+	DefaultAdapterReconnectInitialBackoff  = "1s"
+	DefaultAdapterReconnectMaxBackoff      = "5m"
+	DefaultAdapterReconnectCircuitThresh   = 5
 	DefaultSlackPort                       = 3000
+	DefaultSlackFormatStyle                = "slack"
+	DefaultSlackFormatMaxChars             = 4000
 	DefaultTelegramUpdateTimeout           = 60
+	DefaultTelegramMode                    = "polling"
+	DefaultTelegramWebhookPort             = 8443
+	DefaultTelegramFormatStyle             = "telegram_html"
+	DefaultTelegramFormatMaxChars          = 4096
+	DefaultDiscordSlashCommands            = true
+	DefaultDiscordFormatStyle              = "markdown"
+	DefaultDiscordFormatMaxChars           = 2000
+	DefaultTeamsPort                       = 3978
+	DefaultTeamsFormatStyle                = "markdown"
+	DefaultTeamsFormatMaxChars             = 20000
+	DefaultAdapterFormatMaxChunks          = 10
+	DefaultEmailMailbox                    = "INBOX"
+	DefaultEmailPollInterval               = "1m"
+	DefaultEmailIMAPPort                   = 993
+	DefaultEmailSMTPPort                   = 587
+	DefaultWebhookPort                     = 8088
+	DefaultPollActiveWindow                = "2m"
+	DefaultPollMaxIdleInterval             = "30s"
+	DefaultDesktopNotificationTitle        = "Heike"
+	DefaultTranscriptionProvider           = "openai"
+	DefaultTranscriptionBaseURL            = "https://api.openai.com/v1"
+	DefaultTranscriptionModel              = "whisper-1"
+	DefaultTranscriptionTimeout            = "2m"
+	DefaultTranscriptionMaxBytes           = 25 << 20
 	DefaultIngressInteractiveQueue         = 100
 	DefaultIngressBackgroundQueue          = 1000
 	DefaultIngressInteractiveSubmitTimeout = "500ms"
 	DefaultIngressDrainTimeout             = "5s"
 	DefaultIngressDrainPollInterval        = "100ms"
+	DefaultIngressHighWaterMark            = 0.8
+	DefaultIngressLowWaterMark             = 0.5
+	DefaultIngressOverloadCheckInterval    = "1s"
+	DefaultIngressBusyMessage              = "I'm handling a lot of requests right now. Your message is queued and I'll reply as soon as I can."
+	DefaultHTTPMaxIdleConns                = 100
+	DefaultHTTPMaxIdleConnsPerHost         = 10
+	DefaultHTTPIdleConnTimeout             = "90s"
+	DefaultHTTPDialTimeout                 = "15s"
+	DefaultHTTPTLSHandshakeTimeout         = "10s"
+	DefaultHTTPTLSMinVersion               = "1.2"
 	DefaultWebToolTimeout                  = "10s"
 	DefaultWebToolBaseURL                  = "https://www.bing.com/search"
 	DefaultWebToolMaxContentLength         = 5000
+	DefaultWebToolEngine                   = "bing"
+	DefaultWebToolBraveBaseURL             = "https://api.search.brave.com/res/v1/web/search"
+	DefaultWebToolDuckDuckGoBaseURL        = "https://html.duckduckgo.com/html/"
 	DefaultWeatherToolBaseURL              = "https://wttr.in"
 	DefaultWeatherToolTimeout              = "10s"
 	DefaultFinanceToolBaseURL              = "https://query1.finance.yahoo.com/v7/finance/quote"
 	DefaultFinanceToolTimeout              = "10s"
+	DefaultFinanceToolChartBaseURL         = "https://query1.finance.yahoo.com/v8/finance/chart"
+	DefaultFinanceToolCryptoBaseURL        = "https://api.coingecko.com/api/v3"
 	DefaultSportsToolBaseURL               = "https://site.api.espn.com/apis/v2/sports"
 	DefaultSportsToolTimeout               = "10s"
 	DefaultImageQueryToolBaseURL           = "https://commons.wikimedia.org/w/api.php"
@@ -305,6 +1040,37 @@ const (
 	DefaultScreenshotToolTimeout           = "20s"
 	DefaultScreenshotToolRenderer          = "pdftoppm"
 	DefaultApplyPatchToolCommand           = "apply_patch"
+	DefaultFilesToolMaxReadBytes           = 1 << 20
+	DefaultFilesToolMaxWriteBytes          = 1 << 20
+	DefaultHTTPRequestToolTimeout          = "30s"
+	DefaultHTTPRequestToolMaxRequestBytes  = 1 << 20
+	DefaultHTTPRequestToolMaxResponseBytes = 256 << 10
+	DefaultSQLToolTimeout                  = "30s"
+	DefaultSQLToolMaxRows                  = 200
+	DefaultSQLToolMaxOutputBytes           = 1 << 20
+	DefaultSQLToolReadOnly                 = true
+	DefaultSQLToolSQLiteCommand            = "sqlite3"
+	DefaultSQLToolPostgresCommand          = "psql"
+	DefaultGitHubToolBaseURL               = "https://api.github.com"
+	DefaultGitHubToolTimeout               = "15s"
+	DefaultCalendarToolTimeout             = "15s"
+	DefaultGoogleCalendarBaseURL           = "https://www.googleapis.com/calendar/v3"
+	DefaultGoogleCalendarTokenURL          = "https://oauth2.googleapis.com/token"
+	DefaultGoogleCalendarID                = "primary"
+	DefaultNewsToolTimeout                 = "15s"
+	DefaultNewsToolCacheTTL                = "15m"
+	DefaultNewsToolMaxItems                = 10
+	DefaultConvertToolBaseURL              = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	DefaultConvertToolTimeout              = "10s"
+	DefaultConvertToolCacheTTL             = "1h"
+	DefaultEmailToolTimeout                = "30s"
+	DefaultEmailToolMaxAttachmentBytes     = 10 << 20
+	DefaultPythonToolCommand               = "python3"
+	DefaultPythonToolTimeout               = "60s"
+	DefaultPythonToolMaxMemoryBytes        = 1 << 30
+	DefaultPythonToolMaxOutputBytes        = 64 << 10
+	DefaultMCPToolTimeout                  = "30s"
+	DefaultToolCacheMaxEntries             = 512
 	DefaultWorkerShutdownTimeout           = "30s"
 	DefaultSchedulerTickInterval           = "1m"
 	DefaultSchedulerShutdownTimeout        = "30s"
@@ -312,117 +1078,47 @@ const (
 	DefaultSchedulerMaxCatchupRuns         = 1
 	DefaultSchedulerInFlightPollInterval   = "100ms"
 	DefaultSchedulerHeartbeatWorkspaceID   = DefaultWorkspaceID
+	DefaultSchedulerDigestPeriod           = "daily"
+	DefaultSchedulerDigestDir              = "reports"
 	DefaultDaemonShutdownTimeout           = "30s"
 	DefaultDaemonHealthCheckInterval       = "30s"
 	DefaultDaemonStartupShutdownTimeout    = "10s"
 	DefaultDaemonPreflightTimeout          = "10s"
 	DefaultDaemonStaleLockTTL              = "15m"
+	DefaultAlertsFailureThreshold          = 3
+	DefaultAlertsRepeatInterval            = "1h"
+	DefaultAlertsMaxPerHour                = 10
 	DefaultZanshinEnabled                  = true
 	DefaultZanshinTriggerThreshold         = 0.5
 	DefaultZanshinPruneThreshold           = 0.3
 	DefaultZanshinSimilarityEpsilon        = 0.85
 	DefaultZanshinClusterCount             = 10
 	DefaultZanshinMaxIdleTime              = "30m"
+	DefaultKnowledgeCollection             = "knowledge"
+	DefaultKnowledgeSyncInterval           = "1h"
+	DefaultKnowledgeRequestTimeout         = "30s"
+	DefaultKnowledgeChunkSize              = 1000
+	DefaultKnowledgeChunkOverlap           = 100
+	DefaultBackupInterval                  = "24h"
+	DefaultBackupRequestTimeout            = "5m"
+	DefaultBackupTranscripts               = true
+	DefaultBackupArtifacts                 = true
+	DefaultBackupArtifactMinBytes          = 1024 * 1024
+	DefaultBackupKeepLast                  = 7
+	DefaultBackupSnapshotMaxAge            = "720h"
+	DefaultBackupArchiveMaxAge             = "0s"
+	DefaultBatchMaxGoals                   = 1000
+	DefaultBatchConcurrency                = 4
+	DefaultBatchMaxConcurrency             = 16
+	DefaultBatchPollInterval               = "1s"
+	DefaultBatchMaxBatches                 = 50
 )
 
 func Load(cmd *cobra.Command) (*Config, error) {
 	k := koanf.New(".")
 
-	// Hardcoded Defaults
-	defaults := map[string]interface{}{
-		"server.port":                  DefaultServerPort,
-		"server.log_level":             DefaultServerLogLevel,
-		"server.read_timeout":          DefaultServerReadTimeout,
-		"server.write_timeout":         DefaultServerWriteTimeout,
-		"server.idle_timeout":          DefaultServerIdleTimeout,
-		"server.shutdown_timeout":      DefaultServerShutdownTimeout,
-		"models.default":               DefaultModelDefault,
-		"models.fallback":              DefaultModelFallback,
-		"models.embedding":             DefaultModelEmbedding,
-		"models.max_fallback_attempts": DefaultModelMaxFallbackAttempts,
-		"models.registry": []ModelRegistry{
-			{Name: DefaultModelDefault, Provider: "openai"},
-			{Name: DefaultModelFallback, Provider: "anthropic"}, // Not implemented yet, will be skipped
-			{Name: "local-llama", Provider: "ollama", BaseURL: DefaultOllamaBaseURL},
-		},
-		"governance.require_approval":           []string{"exec_command", "write_stdin", "apply_patch"},
-		"governance.auto_allow":                 []string{"time", "search_query", "open", "click", "find", "weather", "finance", "sports", "image_query", "screenshot"},
-		"governance.idempotency_ttl":            DefaultGovernanceIdempotencyTTL,
-		"governance.daily_tool_limit":           DefaultGovernanceDailyToolLimit,
-		"auth.codex.callback_addr":              DefaultCodexAuthCallbackAddr,
-		"auth.codex.redirect_uri":               DefaultCodexAuthRedirectURI,
-		"auth.codex.oauth_timeout":              DefaultCodexAuthOAuthTimeout,
-		"auth.codex.token_path":                 filepath.Join(os.Getenv("HOME"), ".heike", "auth", "codex.json"),
-		"discovery.project_path":                DefaultDiscoveryProjectPath,
-		"discovery.skill_sources":               []string{"bundled", "global", "workspace", "project"},
-		"discovery.tool_sources":                []string{"global", "bundled", "workspace", "project"},
-		"prompts.planner.system":                DefaultPlannerSystemPrompt,
-		"prompts.planner.output":                DefaultPlannerOutputPrompt,
-		"prompts.thinker.system":                DefaultThinkerSystemPrompt,
-		"prompts.thinker.instruction":           DefaultThinkerInstructionPrompt,
-		"prompts.reflector.system":              DefaultReflectorSystemPrompt,
-		"prompts.reflector.guidelines":          DefaultReflectorGuidelinesPrompt,
-		"prompts.decomposer.system":             DefaultDecomposerSystemPrompt,
-		"prompts.decomposer.requirements":       DefaultDecomposerRequirementsPrompt,
-		"store.lock_timeout":                    DefaultStoreLockTimeout,
-		"store.lock_retry":                      DefaultStoreLockRetry,
-		"store.lock_max_retry":                  DefaultStoreLockMaxRetry,
-		"store.inbox_size":                      DefaultStoreInboxSize,
-		"store.transcript_rotate_max_bytes":     DefaultStoreTranscriptRotateMaxBytes,
-		"tools.web.base_url":                    DefaultWebToolBaseURL,
-		"tools.web.timeout":                     DefaultWebToolTimeout,
-		"tools.web.max_content_length":          DefaultWebToolMaxContentLength,
-		"tools.weather.base_url":                DefaultWeatherToolBaseURL,
-		"tools.weather.timeout":                 DefaultWeatherToolTimeout,
-		"tools.finance.base_url":                DefaultFinanceToolBaseURL,
-		"tools.finance.timeout":                 DefaultFinanceToolTimeout,
-		"tools.sports.base_url":                 DefaultSportsToolBaseURL,
-		"tools.sports.timeout":                  DefaultSportsToolTimeout,
-		"tools.image_query.base_url":            DefaultImageQueryToolBaseURL,
-		"tools.image_query.timeout":             DefaultImageQueryToolTimeout,
-		"tools.screenshot.timeout":              DefaultScreenshotToolTimeout,
-		"tools.screenshot.renderer":             DefaultScreenshotToolRenderer,
-		"tools.apply_patch.command":             DefaultApplyPatchToolCommand,
-		"orchestrator.verbose":                  DefaultOrchestratorVerbose,
-		"orchestrator.max_sub_tasks":            DefaultOrchestratorMaxSubTasks,
-		"orchestrator.max_parallel_subtasks":    DefaultOrchestratorMaxParallelSubTasks,
-		"orchestrator.max_tools_per_turn":       DefaultOrchestratorMaxToolsPerTurn,
-		"orchestrator.max_turns":                DefaultOrchestratorMaxTurns,
-		"orchestrator.token_budget":             DefaultOrchestratorTokenBudget,
-		"orchestrator.decompose_word_threshold": DefaultOrchestratorDecomposeWordThresh,
-		"orchestrator.session_history_limit":    DefaultOrchestratorSessionHistoryLimit,
-		"orchestrator.structured_retry_max":     DefaultOrchestratorStructuredRetryMax,
-		"orchestrator.subtask_retry_max":        DefaultOrchestratorSubTaskRetryMax,
-		"orchestrator.subtask_retry_backoff":    DefaultOrchestratorSubTaskRetryBackoff,
-		"adapters.slack.port":                   DefaultSlackPort,
-		"adapters.telegram.update_timeout":      DefaultTelegramUpdateTimeout,
-		"ingress.interactive_queue_size":        DefaultIngressInteractiveQueue,
-		"ingress.background_queue_size":         DefaultIngressBackgroundQueue,
-		"ingress.interactive_submit_timeout":    DefaultIngressInteractiveSubmitTimeout,
-		"ingress.drain_timeout":                 DefaultIngressDrainTimeout,
-		"ingress.drain_poll_interval":           DefaultIngressDrainPollInterval,
-		"worker.shutdown_timeout":               DefaultWorkerShutdownTimeout,
-		"scheduler.tick_interval":               DefaultSchedulerTickInterval,
-		"scheduler.shutdown_timeout":            DefaultSchedulerShutdownTimeout,
-		"scheduler.lease_duration":              DefaultSchedulerLeaseDuration,
-		"scheduler.max_catchup_runs":            DefaultSchedulerMaxCatchupRuns,
-		"scheduler.in_flight_poll_interval":     DefaultSchedulerInFlightPollInterval,
-		"scheduler.heartbeat_workspace_id":      DefaultSchedulerHeartbeatWorkspaceID,
-		"daemon.shutdown_timeout":               DefaultDaemonShutdownTimeout,
-		"daemon.health_check_interval":          DefaultDaemonHealthCheckInterval,
-		"daemon.startup_shutdown_timeout":       DefaultDaemonStartupShutdownTimeout,
-		"daemon.preflight_timeout":              DefaultDaemonPreflightTimeout,
-		"daemon.stale_lock_ttl":                 DefaultDaemonStaleLockTTL,
-		"daemon.workspace_path":                 filepath.Join(os.Getenv("HOME"), ".heike", "workspaces"),
-		"zanshin.enabled":                       DefaultZanshinEnabled,
-		"zanshin.trigger_threshold":             DefaultZanshinTriggerThreshold,
-		"zanshin.prune_threshold":               DefaultZanshinPruneThreshold,
-		"zanshin.similarity_epsilon":            DefaultZanshinSimilarityEpsilon,
-		"zanshin.cluster_count":                 DefaultZanshinClusterCount,
-		"zanshin.max_idle_time":                 DefaultZanshinMaxIdleTime,
-	}
-	for key, value := range defaults {
-		k.Set(key, value)
+	if err := loadDefaults(k); err != nil {
+		return nil, err
 	}
 
 	// Config file loading
@@ -494,6 +1190,20 @@ func Load(cmd *cobra.Command) (*Config, error) {
 			}
 		}
 	}
+	if key := os.Getenv("GROQ_API_KEY"); key != "" {
+		for i, m := range cfg.Models.Registry {
+			if m.Provider == "groq" && m.APIKey == "" {
+				cfg.Models.Registry[i].APIKey = key
+			}
+		}
+	}
+	if key := os.Getenv("OPENROUTER_API_KEY"); key != "" {
+		for i, m := range cfg.Models.Registry {
+			if m.Provider == "openrouter" && m.APIKey == "" {
+				cfg.Models.Registry[i].APIKey = key
+			}
+		}
+	}
 	if key := os.Getenv("ZAI_API_KEY"); key != "" {
 		for i, m := range cfg.Models.Registry {
 			if m.Provider == "zai" && m.APIKey == "" {
@@ -502,6 +1212,10 @@ func Load(cmd *cobra.Command) (*Config, error) {
 		}
 	}
 
+	if cfg.Tools.GitHub.Token == "" {
+		cfg.Tools.GitHub.Token = os.Getenv("GITHUB_TOKEN")
+	}
+
 	return &cfg, nil
 }
 
@@ -534,6 +1248,14 @@ func normalizePathFields(cfg *Config) error {
 		cfg.Auth.Codex.TokenPath = tokenPath
 	}
 
+	pythonVenv, err := expandConfiguredPath(cfg.Tools.Python.Venv)
+	if err != nil {
+		return err
+	}
+	if pythonVenv != "" {
+		cfg.Tools.Python.Venv = pythonVenv
+	}
+
 	for i := range cfg.Models.Registry {
 		authFile, err := expandConfiguredPath(cfg.Models.Registry[i].AuthFile)
 		if err != nil {
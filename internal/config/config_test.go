@@ -160,6 +160,39 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.Adapters.Telegram.UpdateTimeout != DefaultTelegramUpdateTimeout {
 		t.Errorf("Expected default telegram update timeout %d, got %d", DefaultTelegramUpdateTimeout, cfg.Adapters.Telegram.UpdateTimeout)
 	}
+	if cfg.Adapters.Reconnect.MaxBackoff != DefaultAdapterReconnectMaxBackoff {
+		t.Errorf("Expected default adapter reconnect max backoff %s, got %s", DefaultAdapterReconnectMaxBackoff, cfg.Adapters.Reconnect.MaxBackoff)
+	}
+	if cfg.Adapters.Reconnect.CircuitThreshold != DefaultAdapterReconnectCircuitThresh {
+		t.Errorf("Expected default adapter circuit threshold %d, got %d", DefaultAdapterReconnectCircuitThresh, cfg.Adapters.Reconnect.CircuitThreshold)
+	}
+	if cfg.Orchestrator.BestOfN.Samples != DefaultOrchestratorBestOfNSamples {
+		t.Errorf("Expected default best-of-n samples %d, got %d", DefaultOrchestratorBestOfNSamples, cfg.Orchestrator.BestOfN.Samples)
+	}
+	if cfg.Orchestrator.BestOfN.MaxSampleTokens != DefaultOrchestratorBestOfNMaxTokens {
+		t.Errorf("Expected default best-of-n max sample tokens %d, got %d", DefaultOrchestratorBestOfNMaxTokens, cfg.Orchestrator.BestOfN.MaxSampleTokens)
+	}
+	if cfg.Orchestrator.PostMortem.Enabled != DefaultOrchestratorPostMortemEnabled {
+		t.Errorf("Expected default post-mortem enabled %v, got %v", DefaultOrchestratorPostMortemEnabled, cfg.Orchestrator.PostMortem.Enabled)
+	}
+	if cfg.Scheduler.Digest.Period != DefaultSchedulerDigestPeriod {
+		t.Errorf("Expected default scheduler digest period %s, got %s", DefaultSchedulerDigestPeriod, cfg.Scheduler.Digest.Period)
+	}
+	if cfg.Scheduler.Digest.Dir != DefaultSchedulerDigestDir {
+		t.Errorf("Expected default scheduler digest dir %s, got %s", DefaultSchedulerDigestDir, cfg.Scheduler.Digest.Dir)
+	}
+	if cfg.Knowledge.Collection != DefaultKnowledgeCollection {
+		t.Errorf("Expected default knowledge collection %s, got %s", DefaultKnowledgeCollection, cfg.Knowledge.Collection)
+	}
+	if cfg.Knowledge.ChunkSize != DefaultKnowledgeChunkSize {
+		t.Errorf("Expected default knowledge chunk size %d, got %d", DefaultKnowledgeChunkSize, cfg.Knowledge.ChunkSize)
+	}
+	if cfg.Backup.Lifecycle.KeepLast != DefaultBackupKeepLast {
+		t.Errorf("Expected default backup keep_last %d, got %d", DefaultBackupKeepLast, cfg.Backup.Lifecycle.KeepLast)
+	}
+	if !cfg.Backup.Transcripts {
+		t.Error("Expected rotated transcripts to be backed up by default")
+	}
 }
 
 func TestLoadWithConfigFlag(t *testing.T) {
@@ -219,6 +252,9 @@ daemon:
 auth:
   codex:
     token_path: ~/.heike/auth/codex.json
+tools:
+  python:
+    venv: ~/.heike/python-venv
 models:
   registry:
     - name: gpt-5.2-codex
@@ -253,6 +289,10 @@ models:
 	if cfg.Auth.Codex.TokenPath != wantTokenPath {
 		t.Fatalf("token path = %q, want %q", cfg.Auth.Codex.TokenPath, wantTokenPath)
 	}
+	wantVenv := filepath.Join(tmpDir, ".heike", "python-venv")
+	if cfg.Tools.Python.Venv != wantVenv {
+		t.Fatalf("python venv = %q, want %q", cfg.Tools.Python.Venv, wantVenv)
+	}
 	if len(cfg.Models.Registry) != 1 {
 		t.Fatalf("expected 1 model registry, got %d", len(cfg.Models.Registry))
 	}
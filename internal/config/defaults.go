@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+)
+
+// defaultsFS holds the built-in defaults and prompts as YAML, so changing a
+// default is reviewed as data rather than as Go code.
+//
+//go:embed defaults/*.yaml
+var defaultsFS embed.FS
+
+// Default prompts, read from defaults/prompts.yaml. Components fall back to
+// them when the matching prompts field is empty.
+var (
+	DefaultPlannerSystemPrompt          = defaultPrompt("planner.system")
+	DefaultPlannerOutputPrompt          = defaultPrompt("planner.output")
+	DefaultThinkerSystemPrompt          = defaultPrompt("thinker.system")
+	DefaultThinkerInstructionPrompt     = defaultPrompt("thinker.instruction")
+	DefaultReflectorSystemPrompt        = defaultPrompt("reflector.system")
+	DefaultReflectorGuidelinesPrompt    = defaultPrompt("reflector.guidelines")
+	DefaultDecomposerSystemPrompt       = defaultPrompt("decomposer.system")
+	DefaultDecomposerRequirementsPrompt = defaultPrompt("decomposer.requirements")
+	DefaultSynthesizerSystemPrompt      = defaultPrompt("synthesizer.system")
+)
+
+var embeddedPrompts = mustLoadEmbedded("defaults/prompts.yaml")
+
+func defaultPrompt(key string) string {
+	return embeddedPrompts.String("prompts." + key)
+}
+
+// DefaultsYAML returns the embedded defaults files, in the order they are
+// loaded. It is what `heike config defaults` prints.
+func DefaultsYAML() ([]byte, error) {
+	names, err := fs.Glob(defaultsFS, "defaults/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, name := range names {
+		data, err := defaultsFS.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.Write(bytes.TrimSpace(data))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// loadDefaults loads the embedded defaults into k as its first layer.
+func loadDefaults(k *koanf.Koanf) error {
+	names, err := fs.Glob(defaultsFS, "defaults/*.yaml")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		data, err := defaultsFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := k.Load(embeddedFile(data), yaml.Parser()); err != nil {
+			return fmt.Errorf("load embedded %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// mustLoadEmbedded parses one embedded defaults file. The files are compiled
+// in, so a parse error is a build defect.
+func mustLoadEmbedded(name string) *koanf.Koanf {
+	k := koanf.New(".")
+	data, err := defaultsFS.ReadFile(name)
+	if err == nil {
+		err = k.Load(embeddedFile(data), yaml.Parser())
+	}
+	if err != nil {
+		panic(fmt.Sprintf("config: load embedded %s: %v", name, err))
+	}
+	return k
+}
+
+// embeddedFile is a koanf provider for an embedded file's bytes.
+type embeddedFile []byte
+
+func (f embeddedFile) ReadBytes() ([]byte, error) {
+	return f, nil
+}
+
+func (f embeddedFile) Read() (map[string]interface{}, error) {
+	return nil, errors.New("embedded file provider requires a parser")
+}
@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// TestEmbeddedDefaults keeps defaults/defaults.yaml in sync with the Default*
+// constants that code falls back to.
+func TestEmbeddedDefaults(t *testing.T) {
+	k := koanf.New(".")
+	if err := loadDefaults(k); err != nil {
+		t.Fatalf("load defaults: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"server.port":                              DefaultServerPort,
+		"server.log_level":                         DefaultServerLogLevel,
+		"server.read_timeout":                      DefaultServerReadTimeout,
+		"server.write_timeout":                     DefaultServerWriteTimeout,
+		"server.idle_timeout":                      DefaultServerIdleTimeout,
+		"server.shutdown_timeout":                  DefaultServerShutdownTimeout,
+		"server.max_batch_events":                  DefaultServerMaxBatchEvents,
+		"server.max_context_documents":             DefaultServerMaxContextDocuments,
+		"server.callback.timeout":                  DefaultServerCallbackTimeout,
+		"server.callback.max_attempts":             DefaultServerCallbackMaxAttempts,
+		"server.callback.backoff":                  DefaultServerCallbackBackoff,
+		"server.grpc.enabled":                      false,
+		"server.grpc.port":                         DefaultServerGRPCPort,
+		"models.default":                           DefaultModelDefault,
+		"models.fallback":                          DefaultModelFallback,
+		"models.embedding":                         DefaultModelEmbedding,
+		"models.max_fallback_attempts":             DefaultModelMaxFallbackAttempts,
+		"models.health_timeout":                    DefaultModelHealthTimeout,
+		"models.health_probe_completions":          false,
+		"models.cache.enabled":                     false,
+		"models.cache.ttl":                         DefaultModelCacheTTL,
+		"models.cache.max_entries":                 DefaultModelCacheMaxEntries,
+		"models.wire_log.enabled":                  false,
+		"models.circuit_breaker.enabled":           true,
+		"models.circuit_breaker.failure_threshold": DefaultModelCircuitBreakerThreshold,
+		"models.circuit_breaker.cooldown":          DefaultModelCircuitBreakerCooldown,
+		"models.retry.max_attempts":                DefaultModelRetryMaxAttempts,
+		"models.retry.backoff_base":                DefaultModelRetryBackoffBase,
+		"models.retry.retry_on":                    []string{"rate_limit", "server_error", "timeout"},
+		"governance.require_approval":              []string{"exec_command", "write_stdin", "apply_patch", "github:create_issue", "github:comment", "calendar:create_event", "send_email", "python_exec"},
+		"governance.auto_allow":                    []string{"time", "search_query", "open", "click", "find", "weather", "finance", "sports", "image_query", "screenshot", "news", "convert"},
+		"governance.idempotency_ttl":               DefaultGovernanceIdempotencyTTL,
+		"governance.daily_tool_limit":              DefaultGovernanceDailyToolLimit,
+		"governance.rate_limit_per_minute":         0,
+		"governance.daily_cost_limit_usd":          0,
+		"governance.backend":                       DefaultGovernanceBackend,
+		"governance.redis.addr":                    DefaultGovernanceRedisAddr,
+		"governance.redis.db":                      0,
+		"governance.redis.key_prefix":              DefaultGovernanceRedisKeyPrefix,
+		"governance.redis.timeout":                 DefaultGovernanceRedisTimeout,
+		"auth.secret_store":                        DefaultAuthSecretStore,
+		"auth.codex.callback_addr":                 DefaultCodexAuthCallbackAddr,
+		"auth.codex.redirect_uri":                  DefaultCodexAuthRedirectURI,
+		"auth.codex.oauth_timeout":                 DefaultCodexAuthOAuthTimeout,
+		"discovery.project_path":                   DefaultDiscoveryProjectPath,
+		"discovery.skill_sources":                  []string{"bundled", "global", "workspace", "project"},
+		"discovery.tool_sources":                   []string{"global", "bundled", "workspace", "project"},
+		"discovery.bundled_version":                DefaultDiscoveryBundledVersion,
+		"store.lock_timeout":                       DefaultStoreLockTimeout,
+		"store.lock_retry":                         DefaultStoreLockRetry,
+		"store.lock_max_retry":                     DefaultStoreLockMaxRetry,
+		"store.inbox_size":                         DefaultStoreInboxSize,
+		"store.transcript_rotate_max_bytes":        DefaultStoreTranscriptRotateMaxBytes,
+		"store.event_status_max_entries":           DefaultStoreEventStatusMaxEntries,
+		"store.sandbox_retention":                  DefaultStoreSandboxRetention,
+		"store.vector_retry_backoff":               DefaultStoreVectorRetryBackoff,
+		"store.vector_retry_max_entries":           DefaultStoreVectorRetryMaxEntries,
+		"store.migration.dry_run":                  false,
+		"store.migration.backup":                   true,
+		"tools.web.base_url":                       DefaultWebToolBaseURL,
+		"tools.web.timeout":                        DefaultWebToolTimeout,
+		"tools.web.max_content_length":             DefaultWebToolMaxContentLength,
+		"tools.web.engine":                         DefaultWebToolEngine,
+		"tools.weather.base_url":                   DefaultWeatherToolBaseURL,
+		"tools.weather.timeout":                    DefaultWeatherToolTimeout,
+		"tools.finance.base_url":                   DefaultFinanceToolBaseURL,
+		"tools.finance.timeout":                    DefaultFinanceToolTimeout,
+		"tools.finance.chart_base_url":             DefaultFinanceToolChartBaseURL,
+		"tools.finance.crypto_base_url":            DefaultFinanceToolCryptoBaseURL,
+		"tools.sports.base_url":                    DefaultSportsToolBaseURL,
+		"tools.sports.timeout":                     DefaultSportsToolTimeout,
+		"tools.image_query.base_url":               DefaultImageQueryToolBaseURL,
+		"tools.image_query.timeout":                DefaultImageQueryToolTimeout,
+		"tools.screenshot.timeout":                 DefaultScreenshotToolTimeout,
+		"tools.screenshot.renderer":                DefaultScreenshotToolRenderer,
+		"tools.apply_patch.command":                DefaultApplyPatchToolCommand,
+		"tools.files.max_read_bytes":               DefaultFilesToolMaxReadBytes,
+		"tools.files.max_write_bytes":              DefaultFilesToolMaxWriteBytes,
+		"tools.http.timeout":                       DefaultHTTPRequestToolTimeout,
+		"tools.http.max_request_bytes":             DefaultHTTPRequestToolMaxRequestBytes,
+		"tools.http.max_response_bytes":            DefaultHTTPRequestToolMaxResponseBytes,
+		"tools.http.allow_private_networks":        false,
+		"tools.sql.timeout":                        DefaultSQLToolTimeout,
+		"tools.sql.max_rows":                       DefaultSQLToolMaxRows,
+		"tools.sql.max_output_bytes":               DefaultSQLToolMaxOutputBytes,
+		"tools.sql.read_only":                      DefaultSQLToolReadOnly,
+		"tools.sql.sqlite_command":                 DefaultSQLToolSQLiteCommand,
+		"tools.sql.postgres_command":               DefaultSQLToolPostgresCommand,
+		"tools.github.base_url":                    DefaultGitHubToolBaseURL,
+		"tools.github.timeout":                     DefaultGitHubToolTimeout,
+		"tools.calendar.timeout":                   DefaultCalendarToolTimeout,
+		"tools.calendar.google.base_url":           DefaultGoogleCalendarBaseURL,
+		"tools.calendar.google.token_url":          DefaultGoogleCalendarTokenURL,
+		"tools.calendar.google.calendar_id":        DefaultGoogleCalendarID,
+		"tools.news.timeout":                       DefaultNewsToolTimeout,
+		"tools.news.cache_ttl":                     DefaultNewsToolCacheTTL,
+		"tools.news.max_items":                     DefaultNewsToolMaxItems,
+		"tools.convert.base_url":                   DefaultConvertToolBaseURL,
+		"tools.convert.timeout":                    DefaultConvertToolTimeout,
+		"tools.convert.cache_ttl":                  DefaultConvertToolCacheTTL,
+		"tools.email.timeout":                      DefaultEmailToolTimeout,
+		"tools.email.max_attachment_bytes":         DefaultEmailToolMaxAttachmentBytes,
+		"tools.email.smtp.port":                    DefaultEmailSMTPPort,
+		"tools.python.command":                     DefaultPythonToolCommand,
+		"tools.python.timeout":                     DefaultPythonToolTimeout,
+		"tools.python.max_memory_bytes":            DefaultPythonToolMaxMemoryBytes,
+		"tools.python.max_output_bytes":            DefaultPythonToolMaxOutputBytes,
+		"tools.cache.enabled":                      false,
+		"tools.cache.max_entries":                  DefaultToolCacheMaxEntries,
+		"tools.mcp.timeout":                        DefaultMCPToolTimeout,
+		"http.max_idle_conns":                      DefaultHTTPMaxIdleConns,
+		"http.max_idle_conns_per_host":             DefaultHTTPMaxIdleConnsPerHost,
+		"http.idle_conn_timeout":                   DefaultHTTPIdleConnTimeout,
+		"http.dial_timeout":                        DefaultHTTPDialTimeout,
+		"http.tls_handshake_timeout":               DefaultHTTPTLSHandshakeTimeout,
+		"http.tls_min_version":                     DefaultHTTPTLSMinVersion,
+		"orchestrator.verbose":                     DefaultOrchestratorVerbose,
+		"orchestrator.max_sub_tasks":               DefaultOrchestratorMaxSubTasks,
+		"orchestrator.max_parallel_subtasks":       DefaultOrchestratorMaxParallelSubTasks,
+		"orchestrator.max_tools_per_turn":          DefaultOrchestratorMaxToolsPerTurn,
+		"orchestrator.max_turns":                   DefaultOrchestratorMaxTurns,
+		"orchestrator.token_budget":                DefaultOrchestratorTokenBudget,
+		"orchestrator.decompose_word_threshold":    DefaultOrchestratorDecomposeWordThresh,
+		"orchestrator.session_history_limit":       DefaultOrchestratorSessionHistoryLimit,
+		"orchestrator.structured_retry_max":        DefaultOrchestratorStructuredRetryMax,
+		"orchestrator.subtask_retry_max":           DefaultOrchestratorSubTaskRetryMax,
+		"orchestrator.subtask_retry_backoff":       DefaultOrchestratorSubTaskRetryBackoff,
+		"orchestrator.tool_images":                 DefaultOrchestratorToolImages,
+		"orchestrator.execution_report":            DefaultOrchestratorExecutionReport,
+		"orchestrator.best_of_n.samples":           DefaultOrchestratorBestOfNSamples,
+		"orchestrator.best_of_n.max_sample_tokens": DefaultOrchestratorBestOfNMaxTokens,
+		"orchestrator.postmortem.enabled":          DefaultOrchestratorPostMortemEnabled,
+		"orchestrator.quota_retry.enabled":         DefaultOrchestratorQuotaRetryEnabled,
+		"orchestrator.quota_retry.backoff":         DefaultOrchestratorQuotaRetryBackoff,
+		"orchestrator.quota_retry.max_retries":     DefaultOrchestratorQuotaRetryMax,
+		"orchestrator.synthesis.enabled":           DefaultOrchestratorSynthesisEnabled,
+		"orchestrator.skills.max_selected":         DefaultOrchestratorSkillsMaxSelected,
+		"orchestrator.skills.max_chars":            DefaultOrchestratorSkillsMaxChars,
+		"adapters.reconnect.initial_backoff":       DefaultAdapterReconnectInitialBackoff,
+		"adapters.reconnect.max_backoff":           DefaultAdapterReconnectMaxBackoff,
+		"adapters.reconnect.circuit_threshold":     DefaultAdapterReconnectCircuitThresh,
+		"adapters.slack.port":                      DefaultSlackPort,
+		"adapters.slack.format.style":              DefaultSlackFormatStyle,
+		"adapters.slack.format.max_chars":          DefaultSlackFormatMaxChars,
+		"adapters.slack.format.max_chunks":         DefaultAdapterFormatMaxChunks,
+		"adapters.telegram.update_timeout":         DefaultTelegramUpdateTimeout,
+		"adapters.telegram.mode":                   DefaultTelegramMode,
+		"adapters.telegram.poll.active_window":     DefaultPollActiveWindow,
+		"adapters.telegram.poll.max_idle_interval": DefaultPollMaxIdleInterval,
+		"adapters.telegram.webhook.port":           DefaultTelegramWebhookPort,
+		"adapters.telegram.format.style":           DefaultTelegramFormatStyle,
+		"adapters.telegram.format.max_chars":       DefaultTelegramFormatMaxChars,
+		"adapters.telegram.format.max_chunks":      DefaultAdapterFormatMaxChunks,
+		"adapters.discord.slash_commands":          DefaultDiscordSlashCommands,
+		"adapters.discord.format.style":            DefaultDiscordFormatStyle,
+		"adapters.discord.format.max_chars":        DefaultDiscordFormatMaxChars,
+		"adapters.discord.format.max_chunks":       DefaultAdapterFormatMaxChunks,
+		"adapters.teams.port":                      DefaultTeamsPort,
+		"adapters.teams.format.style":              DefaultTeamsFormatStyle,
+		"adapters.teams.format.max_chars":          DefaultTeamsFormatMaxChars,
+		"adapters.teams.format.max_chunks":         DefaultAdapterFormatMaxChunks,
+		"adapters.email.mailbox":                   DefaultEmailMailbox,
+		"adapters.email.poll_interval":             DefaultEmailPollInterval,
+		"adapters.email.imap.port":                 DefaultEmailIMAPPort,
+		"adapters.email.smtp.port":                 DefaultEmailSMTPPort,
+		"adapters.webhook.port":                    DefaultWebhookPort,
+		"adapters.desktop.title":                   DefaultDesktopNotificationTitle,
+		"adapters.transcription.provider":          DefaultTranscriptionProvider,
+		"adapters.transcription.base_url":          DefaultTranscriptionBaseURL,
+		"adapters.transcription.model":             DefaultTranscriptionModel,
+		"adapters.transcription.timeout":           DefaultTranscriptionTimeout,
+		"adapters.transcription.max_bytes":         DefaultTranscriptionMaxBytes,
+		"ingress.interactive_queue_size":           DefaultIngressInteractiveQueue,
+		"ingress.background_queue_size":            DefaultIngressBackgroundQueue,
+		"ingress.interactive_submit_timeout":       DefaultIngressInteractiveSubmitTimeout,
+		"ingress.drain_timeout":                    DefaultIngressDrainTimeout,
+		"ingress.drain_poll_interval":              DefaultIngressDrainPollInterval,
+		"ingress.high_water_mark":                  DefaultIngressHighWaterMark,
+		"ingress.low_water_mark":                   DefaultIngressLowWaterMark,
+		"ingress.overload_check_interval":          DefaultIngressOverloadCheckInterval,
+		"ingress.busy_message":                     DefaultIngressBusyMessage,
+		"worker.shutdown_timeout":                  DefaultWorkerShutdownTimeout,
+		"scheduler.tick_interval":                  DefaultSchedulerTickInterval,
+		"scheduler.shutdown_timeout":               DefaultSchedulerShutdownTimeout,
+		"scheduler.lease_duration":                 DefaultSchedulerLeaseDuration,
+		"scheduler.max_catchup_runs":               DefaultSchedulerMaxCatchupRuns,
+		"scheduler.in_flight_poll_interval":        DefaultSchedulerInFlightPollInterval,
+		"scheduler.heartbeat_workspace_id":         DefaultSchedulerHeartbeatWorkspaceID,
+		"scheduler.digest.period":                  DefaultSchedulerDigestPeriod,
+		"scheduler.digest.dir":                     DefaultSchedulerDigestDir,
+		"daemon.shutdown_timeout":                  DefaultDaemonShutdownTimeout,
+		"daemon.health_check_interval":             DefaultDaemonHealthCheckInterval,
+		"daemon.startup_shutdown_timeout":          DefaultDaemonStartupShutdownTimeout,
+		"daemon.preflight_timeout":                 DefaultDaemonPreflightTimeout,
+		"daemon.stale_lock_ttl":                    DefaultDaemonStaleLockTTL,
+		"daemon.alerts.enabled":                    false,
+		"daemon.alerts.failure_threshold":          DefaultAlertsFailureThreshold,
+		"daemon.alerts.repeat_interval":            DefaultAlertsRepeatInterval,
+		"daemon.alerts.max_per_hour":               DefaultAlertsMaxPerHour,
+		"zanshin.enabled":                          DefaultZanshinEnabled,
+		"zanshin.trigger_threshold":                DefaultZanshinTriggerThreshold,
+		"zanshin.prune_threshold":                  DefaultZanshinPruneThreshold,
+		"zanshin.similarity_epsilon":               DefaultZanshinSimilarityEpsilon,
+		"zanshin.cluster_count":                    DefaultZanshinClusterCount,
+		"zanshin.max_idle_time":                    DefaultZanshinMaxIdleTime,
+		"knowledge.collection":                     DefaultKnowledgeCollection,
+		"knowledge.sync_interval":                  DefaultKnowledgeSyncInterval,
+		"knowledge.request_timeout":                DefaultKnowledgeRequestTimeout,
+		"knowledge.chunk_size":                     DefaultKnowledgeChunkSize,
+		"knowledge.chunk_overlap":                  DefaultKnowledgeChunkOverlap,
+		"backup.interval":                          DefaultBackupInterval,
+		"backup.request_timeout":                   DefaultBackupRequestTimeout,
+		"backup.transcripts":                       DefaultBackupTranscripts,
+		"backup.artifacts":                         DefaultBackupArtifacts,
+		"backup.artifact_min_bytes":                DefaultBackupArtifactMinBytes,
+		"backup.lifecycle.keep_last":               DefaultBackupKeepLast,
+		"backup.lifecycle.snapshot_max_age":        DefaultBackupSnapshotMaxAge,
+		"backup.lifecycle.archive_max_age":         DefaultBackupArchiveMaxAge,
+		"batch.max_goals":                          DefaultBatchMaxGoals,
+		"batch.concurrency":                        DefaultBatchConcurrency,
+		"batch.max_concurrency":                    DefaultBatchMaxConcurrency,
+		"batch.poll_interval":                      DefaultBatchPollInterval,
+		"batch.max_batches":                        DefaultBatchMaxBatches,
+	}
+	// Per-tool limits shipped for search_query.
+	want["governance.tool_limits.search_query.qps"] = DefaultGovernanceSearchQueryQPS
+	want["governance.tool_limits.search_query.max_concurrent"] = DefaultGovernanceSearchQueryConcurrent
+	for key, value := range want {
+		if !k.Exists(key) {
+			t.Errorf("%s: missing from embedded defaults", key)
+			continue
+		}
+		if got := fmt.Sprint(k.Get(key)); got != fmt.Sprint(value) {
+			t.Errorf("%s = %s, want %v", key, got, value)
+		}
+	}
+
+	for _, key := range []string{"planner.system", "thinker.instruction", "reflector.guidelines", "decomposer.requirements", "synthesizer.system"} {
+		if defaultPrompt(key) == "" {
+			t.Errorf("prompt %s is empty", key)
+		}
+	}
+	if !strings.Contains(DefaultDecomposerRequirementsPrompt, "\n   - 'id' (string)") {
+		t.Errorf("block scalar indentation lost: %q", DefaultDecomposerRequirementsPrompt)
+	}
+}
+
+func TestLoad_FileOverridesEmbeddedDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".heike"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configYAML := "prompts:\n  thinker:\n    system: custom\nserver:\n  port: 9999\n"
+	if err := os.WriteFile(filepath.Join(home, ".heike", "config.yaml"), []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Prompts.Thinker.System != "custom" || cfg.Server.Port != 9999 {
+		t.Fatalf("overrides not applied: thinker=%q port=%d", cfg.Prompts.Thinker.System, cfg.Server.Port)
+	}
+	if cfg.Prompts.Planner.System != DefaultPlannerSystemPrompt || cfg.Server.ReadTimeout != DefaultServerReadTimeout {
+		t.Fatalf("sibling defaults lost: planner=%q read_timeout=%q", cfg.Prompts.Planner.System, cfg.Server.ReadTimeout)
+	}
+	if len(cfg.Models.Registry) != 3 || cfg.Models.Registry[2].BaseURL != DefaultOllamaBaseURL {
+		t.Fatalf("registry = %+v", cfg.Models.Registry)
+	}
+	if want := filepath.Join(home, ".heike", "workspaces"); cfg.Daemon.WorkspacePath != want {
+		t.Fatalf("workspace path = %q, want %q", cfg.Daemon.WorkspacePath, want)
+	}
+}
+
+func TestDefaultsYAML(t *testing.T) {
+	data, err := DefaultsYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\nserver:\n", "\nprompts:\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("defaults output missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package counter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/redis"
+
+	"github.com/natefinch/atomic"
+)
+
+// Store keeps expiring counters used for quotas and rate limits.
+type Store interface {
+	// Get returns the current value of key, or zero when it is unset or expired.
+	Get(ctx context.Context, key string) (int64, error)
+	// Incr increments key and returns the new value. A key created by Incr
+	// expires after ttl.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// IncrBy adds delta to key and returns the new value, like Incr.
+	IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+}
+
+type fileEntry struct {
+	Count     int64 `json:"count"`
+	ExpiresAt int64 `json:"expires_at"` // Unix timestamp
+}
+
+// FileStore persists counters to a JSON file. It is only safe for a single
+// process per workspace, which the workspace lock already guarantees.
+type FileStore struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]fileEntry
+	now     func() time.Time
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:    path,
+		entries: make(map[string]fileEntry),
+		now:     time.Now,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *FileStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.ExpiresAt <= s.now().Unix() {
+		return 0, nil
+	}
+	return entry.Count, nil
+}
+
+func (s *FileStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return s.IncrBy(ctx, key, 1, ttl)
+}
+
+func (s *FileStore) IncrBy(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().Unix()
+	entry, ok := s.entries[key]
+	if !ok || entry.ExpiresAt <= now {
+		entry = fileEntry{ExpiresAt: now + int64(ttl.Seconds())}
+	}
+	entry.Count += delta
+	s.entries[key] = entry
+
+	for k, e := range s.entries {
+		if e.ExpiresAt <= now {
+			delete(s.entries, k)
+		}
+	}
+	return entry.Count, s.save()
+}
+
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(s.path, bytes.NewReader(data))
+}
+
+// RedisStore keeps counters in Redis so replicas share quotas.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := redis.Int(s.client.Do(ctx, "GET", s.prefix+key))
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	full := s.prefix + key
+	n, err := redis.Int(s.client.Do(ctx, "INCR", full))
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if _, err := s.client.Do(ctx, "PEXPIRE", full, ttlMillis(ttl)); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (s *RedisStore) IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	full := s.prefix + key
+	n, err := redis.Int(s.client.Do(ctx, "INCRBY", full, strconv.FormatInt(delta, 10)))
+	if err != nil {
+		return 0, err
+	}
+	if n == delta {
+		if _, err := s.client.Do(ctx, "PEXPIRE", full, ttlMillis(ttl)); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// MemoryStore keeps counters in memory, for callers without a workspace.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]fileEntry
+	now     func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]fileEntry), now: time.Now}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.ExpiresAt <= s.now().Unix() {
+		return 0, nil
+	}
+	return entry.Count, nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return s.IncrBy(ctx, key, 1, ttl)
+}
+
+func (s *MemoryStore) IncrBy(_ context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().Unix()
+	for k, e := range s.entries {
+		if e.ExpiresAt <= now {
+			delete(s.entries, k)
+		}
+	}
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = fileEntry{ExpiresAt: now + int64(ttl.Seconds())}
+	}
+	entry.Count += delta
+	s.entries[key] = entry
+	return entry.Count, nil
+}
+
+func ttlMillis(ttl time.Duration) string {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
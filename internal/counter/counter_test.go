@@ -0,0 +1,124 @@
+package counter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/redis"
+	"github.com/harunnryd/heike/internal/redis/redistest"
+)
+
+func TestFileStore_IncrPersistsAndExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Incr(ctx, "quota:exec", time.Minute); err != nil {
+			t.Fatalf("incr: %v", err)
+		}
+	}
+
+	reloaded, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	reloaded.now = store.now
+	if got, _ := reloaded.Get(ctx, "quota:exec"); got != 2 {
+		t.Fatalf("expected persisted count 2, got %d", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if got, _ := reloaded.Get(ctx, "quota:exec"); got != 0 {
+		t.Fatalf("expected expired count 0, got %d", got)
+	}
+	if got, _ := reloaded.Incr(ctx, "quota:exec", time.Minute); got != 1 {
+		t.Fatalf("expected counter to restart at 1, got %d", got)
+	}
+}
+
+func TestRedisStore_IncrSetsExpiry(t *testing.T) {
+	srv := redistest.NewServer(t)
+	client, err := redis.NewClient(redis.Config{Addr: srv.Addr})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	defer client.Close()
+
+	store := NewRedisStore(client, "heike:ws:")
+	ctx := context.Background()
+
+	if got, err := store.Get(ctx, "quota:exec"); err != nil || got != 0 {
+		t.Fatalf("Get on missing key = %d, %v", got, err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := store.Incr(ctx, "quota:exec", time.Hour); err != nil {
+			t.Fatalf("incr: %v", err)
+		}
+	}
+	if got, err := store.Get(ctx, "quota:exec"); err != nil || got != 3 {
+		t.Fatalf("Get = %d, %v; want 3", got, err)
+	}
+	if ttl := srv.TTL("heike:ws:quota:exec"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected key expiry within an hour, got %v", ttl)
+	}
+}
+
+func TestStores_IncrByAddsDelta(t *testing.T) {
+	srv := redistest.NewServer(t)
+	client, err := redis.NewClient(redis.Config{Addr: srv.Addr})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	defer client.Close()
+	file, err := NewFileStore(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+
+	stores := map[string]Store{
+		"file":   file,
+		"redis":  NewRedisStore(client, "heike:ws:"),
+		"memory": NewMemoryStore(),
+	}
+	ctx := context.Background()
+	for name, store := range stores {
+		if _, err := store.IncrBy(ctx, "cost:2026-03-01", 2500, time.Hour); err != nil {
+			t.Fatalf("%s: incr by: %v", name, err)
+		}
+		if got, err := store.IncrBy(ctx, "cost:2026-03-01", 500, time.Hour); err != nil || got != 3000 {
+			t.Fatalf("%s: IncrBy = %d, %v; want 3000", name, got, err)
+		}
+		if got, err := store.Get(ctx, "cost:2026-03-01"); err != nil || got != 3000 {
+			t.Fatalf("%s: Get = %d, %v; want 3000", name, got, err)
+		}
+	}
+	if ttl := srv.TTL("heike:ws:cost:2026-03-01"); ttl <= 0 || ttl > time.Hour {
+		t.Fatalf("expected key expiry within an hour, got %v", ttl)
+	}
+}
+
+func TestMemoryStore_Expires(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := store.Incr(ctx, "quota:exec", time.Minute); err != nil {
+		t.Fatalf("incr: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if got, _ := store.Get(ctx, "quota:exec"); got != 0 {
+		t.Fatalf("expected expired count 0, got %d", got)
+	}
+	if got, _ := store.Incr(ctx, "quota:exec", time.Minute); got != 1 {
+		t.Fatalf("expected counter to restart at 1, got %d", got)
+	}
+}
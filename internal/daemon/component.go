@@ -35,6 +35,14 @@ type RuntimeEvent struct {
 	SessionID string
 	Content   string
 	Metadata  map[string]string
+	// IdempotencyKey, when set, makes resubmissions within the idempotency
+	// TTL return the original event ID instead of queueing again.
+	IdempotencyKey string
+	// CallbackURL, when set, receives the signed result of the turn.
+	CallbackURL string
+	// NotifyURL, when set, becomes the session's URL for approval-required
+	// notifications.
+	NotifyURL string
 }
 
 type RuntimeSession struct {
@@ -46,19 +54,290 @@ type RuntimeSession struct {
 	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
+// RuntimeSessionUpdate changes a session's title and metadata. A nil Title
+// keeps the title; a metadata key mapped to nil is removed.
+type RuntimeSessionUpdate struct {
+	Title    *string
+	Metadata map[string]*string
+}
+
+// RuntimeSessionMerge is the result of merging a session into another.
+type RuntimeSessionMerge struct {
+	Session RuntimeSession `json:"session"`
+	// Source is the merged session, now archived.
+	Source string `json:"source"`
+	// Events counts the transcript lines copied from the source.
+	Events int `json:"events"`
+	// Vectors counts the context and memory vectors moved to the session.
+	Vectors int `json:"vectors"`
+}
+
 type RuntimeApproval struct {
 	ID        string    `json:"id"`
+	SessionID string    `json:"session_id,omitempty"`
 	Tool      string    `json:"tool"`
 	Input     string    `json:"input"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type RuntimeEventStatus struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	SessionID   string     `json:"session_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	QueuedAt    *time.Time `json:"queued_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type RuntimeAdapterStatus struct {
+	Name              string    `json:"name"`
+	State             string    `json:"state"`
+	LastError         string    `json:"last_error,omitempty"`
+	LastErrorAt       time.Time `json:"last_error_at,omitempty"`
+	ReconnectAttempts int       `json:"reconnect_attempts"`
+	ConnectedAt       time.Time `json:"connected_at,omitempty"`
+}
+
+// RuntimeAdapterReload is the result of reloading the adapters config.
+type RuntimeAdapterReload struct {
+	// Changed names the adapters that were restarted or stopped.
+	Changed  []string               `json:"changed"`
+	Adapters []RuntimeAdapterStatus `json:"adapters"`
+}
+
+type RuntimeModelCircuit struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+type RuntimeDiskUsage struct {
+	Sessions       int64 `json:"sessions_bytes"`
+	Vectors        int64 `json:"vectors_bytes"`
+	Sandbox        int64 `json:"sandbox_bytes"`
+	RotatedBackups int64 `json:"rotated_backups_bytes"`
+	Other          int64 `json:"other_bytes"`
+	Total          int64 `json:"total_bytes"`
+}
+
+type RuntimeOpLatency struct {
+	Count int64   `json:"count"`
+	AvgMS float64 `json:"avg_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+type RuntimeStoreStats struct {
+	WorkspaceID   string                      `json:"workspace_id"`
+	Disk          RuntimeDiskUsage            `json:"disk"`
+	InboxDepth    int                         `json:"inbox_depth"`
+	InboxCapacity int                         `json:"inbox_capacity"`
+	Ops           map[string]RuntimeOpLatency `json:"ops"`
+	// TranscriptWatches is the number of open session stream subscriptions.
+	TranscriptWatches int `json:"transcript_watches"`
+	// PendingVectorUpserts counts failed vector writes waiting for a retry.
+	PendingVectorUpserts int      `json:"pending_vector_upserts"`
+	Warnings             []string `json:"warnings,omitempty"`
+}
+
+type RuntimeBatchItem struct {
+	Index       int        `json:"index"`
+	Goal        string     `json:"goal"`
+	SessionID   string     `json:"session_id"`
+	EventID     string     `json:"event_id,omitempty"`
+	Status      string     `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type RuntimeBatchProgress struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Queued    int `json:"queued"`
+	Running   int `json:"running"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+type RuntimeBatch struct {
+	ID          string               `json:"id"`
+	Status      string               `json:"status"`
+	Concurrency int                  `json:"concurrency"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty"`
+	Progress    RuntimeBatchProgress `json:"progress"`
+	Items       []RuntimeBatchItem   `json:"items,omitempty"`
+}
+
+type RuntimeContextDocument struct {
+	Text     string            `json:"text"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type RuntimeContextResult struct {
+	SessionID string `json:"session_id"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+}
+
+// RuntimeToolSelection explains which tools a session's last turn offered
+// the model.
+type RuntimeToolSelection struct {
+	SessionID string `json:"session_id"`
+	Goal      string `json:"goal"`
+	// Broker is llm, heuristic or none.
+	Broker     string                       `json:"broker"`
+	Available  int                          `json:"available"`
+	Tools      []RuntimeToolSelectionDetail `json:"tools"`
+	SelectedAt time.Time                    `json:"selected_at"`
+}
+
+type RuntimeToolSelectionDetail struct {
+	Name    string   `json:"name"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type RuntimeFeatureFlag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// Source is default, config or override.
+	Source string `json:"source"`
+}
+
+// RuntimeReembed is the progress of re-embedding one vector collection.
+type RuntimeReembed struct {
+	Collection string `json:"collection"`
+	// Status is running, completed or failed.
+	Status      string     `json:"status"`
+	Done        int        `json:"done"`
+	Total       int        `json:"total"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RuntimeMemoryBackfillRequest selects the session transcripts to embed
+// into memory. Empty fields select every session of the workspace.
+type RuntimeMemoryBackfillRequest struct {
+	Sessions []string `json:"sessions,omitempty"`
+	// Source matches the session's source adapter, such as slack.
+	Source string `json:"source,omitempty"`
+	// Since skips messages written before it.
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// RuntimeMemoryBackfill is the progress of embedding past transcripts into
+// memory. Done and Total count sessions read.
+type RuntimeMemoryBackfill struct {
+	// Status is running, completed or failed.
+	Status string `json:"status"`
+	Done   int    `json:"done"`
+	Total  int    `json:"total"`
+	// Sessions and Chunks count what was embedded once the job finishes.
+	Sessions    int        `json:"sessions"`
+	Chunks      int        `json:"chunks"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RuntimeDebug is a snapshot of the runtime for diagnosing stuck workers.
+type RuntimeDebug struct {
+	Goroutines         int `json:"goroutines"`
+	StoreInboxDepth    int `json:"store_inbox_depth"`
+	StoreInboxCapacity int `json:"store_inbox_capacity"`
+	// IngressQueues holds the events waiting per worker lane.
+	IngressQueues map[string]int         `json:"ingress_queues"`
+	Scheduler     RuntimeSchedulerStatus `json:"scheduler"`
+}
+
+type RuntimeSchedulerStatus struct {
+	Running  bool                    `json:"running"`
+	InFlight int                     `json:"in_flight"`
+	Leases   []RuntimeSchedulerLease `json:"leases"`
+}
+
+type RuntimeSchedulerLease struct {
+	TaskID    string    `json:"task_id"`
+	RunID     string    `json:"run_id"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Expired   bool      `json:"expired"`
+}
+
+// RuntimeTranscriptLine is a transcript line with its 1-based line number.
+type RuntimeTranscriptLine struct {
+	ID   int
+	Data string
+}
+
 type RuntimeAPI interface {
 	SubmitEvent(ctx context.Context, evt RuntimeEvent) (string, error)
 	ListSessions(ctx context.Context) ([]RuntimeSession, error)
+	// CreateSession registers an empty session. An empty ID is generated.
+	CreateSession(ctx context.Context, sess RuntimeSession) (RuntimeSession, error)
+	UpdateSession(ctx context.Context, sessionID string, update RuntimeSessionUpdate) (RuntimeSession, error)
+	// DeleteSession removes a session and its transcript. With reset, the
+	// transcript is cleared but the session, its title and metadata remain.
+	DeleteSession(ctx context.Context, sessionID string, reset bool) error
+	// MergeSession appends the transcript of sourceID to sessionID, merges
+	// their metadata, moves the source's vectors and archives the source.
+	MergeSession(ctx context.Context, sessionID, sourceID string) (RuntimeSessionMerge, error)
+	// CancelSession cancels the task running in the session. It returns a
+	// not found error when none is running.
+	CancelSession(ctx context.Context, sessionID string) error
 	ReadTranscript(ctx context.Context, sessionID string, limit int) ([]string, error)
+	// WatchTranscript sends the session's transcript lines after line from,
+	// then each line as it is written. The channel is closed when ctx is
+	// done or the transcript can no longer be followed.
+	WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan RuntimeTranscriptLine, error)
 	ListPendingApprovals(ctx context.Context) ([]RuntimeApproval, error)
+	// ListApprovals returns approvals in any of statuses (all when empty),
+	// newest first.
+	ListApprovals(ctx context.Context, statuses ...string) ([]RuntimeApproval, error)
 	ResolveApproval(ctx context.Context, approvalID string, approve bool) error
 	ZanshinStatus(ctx context.Context) map[string]interface{}
+	AdapterStatuses(ctx context.Context) []RuntimeAdapterStatus
+	// ListAdapters returns the input adapters, including the ones that can
+	// be enabled at runtime, which are listed as disabled.
+	ListAdapters(ctx context.Context) ([]RuntimeAdapterStatus, error)
+	// SetAdapterEnabled starts or stops an adapter without restarting the
+	// daemon. Enabling builds it from the adapters config last loaded.
+	SetAdapterEnabled(ctx context.Context, name string, enabled bool) (RuntimeAdapterStatus, error)
+	// ReloadAdapters reads the config again and restarts the adapters whose
+	// settings changed.
+	ReloadAdapters(ctx context.Context) (RuntimeAdapterReload, error)
+	EventStatus(ctx context.Context, eventID string) (RuntimeEventStatus, error)
+	ModelCircuits(ctx context.Context) map[string]RuntimeModelCircuit
+	StoreStats(ctx context.Context) (RuntimeStoreStats, error)
+	SubmitBatch(ctx context.Context, goals []string, concurrency int) (RuntimeBatch, error)
+	GetBatch(ctx context.Context, batchID string) (RuntimeBatch, error)
+	ListBatches(ctx context.Context) ([]RuntimeBatch, error)
+	InjectSessionContext(ctx context.Context, sessionID string, docs []RuntimeContextDocument) (RuntimeContextResult, error)
+	// ToolSelection returns the last turn's tool selection for sessionID;
+	// selections are kept in memory since the daemon started.
+	ToolSelection(ctx context.Context, sessionID string) (RuntimeToolSelection, error)
+	// SendAlert delivers an operator message through the named output
+	// adapter to target, such as a Slack channel ID.
+	SendAlert(ctx context.Context, adapterName, target, content string) error
+	FeatureFlags(ctx context.Context) ([]RuntimeFeatureFlag, error)
+	// SetFeatureFlag overrides a flag for the workspace; a nil enabled
+	// removes the override.
+	SetFeatureFlag(ctx context.Context, name string, enabled *bool) (RuntimeFeatureFlag, error)
+	// StartReembed re-embeds a vector collection with the current embedding
+	// model in the background.
+	StartReembed(ctx context.Context, collection string) (RuntimeReembed, error)
+	ReembedStatus(ctx context.Context, collection string) (RuntimeReembed, error)
+	// StartMemoryBackfill embeds past session transcripts into the memory
+	// collection in the background; one backfill runs at a time.
+	StartMemoryBackfill(ctx context.Context, req RuntimeMemoryBackfillRequest) (RuntimeMemoryBackfill, error)
+	MemoryBackfillStatus(ctx context.Context) (RuntimeMemoryBackfill, error)
+	// Debug reads queue depths and leases without going through the store
+	// worker, so it answers while workers are stuck.
+	Debug(ctx context.Context) (RuntimeDebug, error)
 }
@@ -0,0 +1,308 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+// AlertsComponent watches daemon health and sends operator alerts through an
+// output adapter when a component, model provider or the workspace disk stays
+// unhealthy, and a recovery notice once it is healthy again.
+type AlertsComponent struct {
+	daemon        *daemon.Daemon
+	runtime       daemon.RuntimeAPI
+	cfg           config.AlertsConfig
+	checkInterval string
+	interval      time.Duration
+	deps          []string
+	tracker       *alertTracker
+
+	mu      sync.Mutex
+	started bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewAlertsComponent(d *daemon.Daemon, cfg *config.DaemonConfig, deps []string) *AlertsComponent {
+	depList := make([]string, len(deps))
+	copy(depList, deps)
+	c := &AlertsComponent{daemon: d, deps: depList}
+	if cfg != nil {
+		c.cfg = cfg.Alerts
+		c.checkInterval = cfg.HealthCheckInterval
+	}
+	return c
+}
+
+func (a *AlertsComponent) Name() string {
+	return "Alerts"
+}
+
+func (a *AlertsComponent) Dependencies() []string {
+	deps := make([]string, len(a.deps))
+	copy(deps, a.deps)
+	return deps
+}
+
+func (a *AlertsComponent) Init(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.cfg.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(a.cfg.Adapter) == "" || strings.TrimSpace(a.cfg.Target) == "" {
+		return fmt.Errorf("daemon.alerts.adapter and daemon.alerts.target are required when alerts are enabled")
+	}
+	if a.daemon == nil {
+		return fmt.Errorf("daemon manager not configured")
+	}
+	runtimeAPI, ok := a.daemon.Component("Runtime").(daemon.RuntimeAPI)
+	if !ok {
+		return fmt.Errorf("runtime component does not implement daemon runtime api")
+	}
+	a.runtime = runtimeAPI
+
+	interval, err := config.DurationOrDefault(a.checkInterval, config.DefaultDaemonHealthCheckInterval)
+	if err != nil {
+		return fmt.Errorf("parse daemon health check interval: %w", err)
+	}
+	a.interval = interval
+	repeat, err := config.DurationOrDefault(a.cfg.RepeatInterval, config.DefaultAlertsRepeatInterval)
+	if err != nil {
+		return fmt.Errorf("parse daemon.alerts.repeat_interval: %w", err)
+	}
+	threshold := a.cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = config.DefaultAlertsFailureThreshold
+	}
+	maxPerHour := a.cfg.MaxPerHour
+	if maxPerHour <= 0 {
+		maxPerHour = config.DefaultAlertsMaxPerHour
+	}
+	a.tracker = newAlertTracker(threshold, repeat, maxPerHour)
+	slog.Info("Alerts initialized", "component", a.Name(), "adapter", a.cfg.Adapter, "threshold", threshold)
+	return nil
+}
+
+func (a *AlertsComponent) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.cfg.Enabled {
+		return nil
+	}
+	loopCtx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+	a.started = true
+	go a.loop(loopCtx, a.done)
+	slog.Info("Alerts started", "component", a.Name(), "interval", a.interval)
+	return nil
+}
+
+func (a *AlertsComponent) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.started {
+		a.mu.Unlock()
+		return nil
+	}
+	a.cancel()
+	done := a.done
+	a.started = false
+	a.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	slog.Info("Alerts stopped", "component", a.Name())
+	return nil
+}
+
+func (a *AlertsComponent) Health(ctx context.Context) (*daemon.ComponentHealth, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cfg.Enabled && !a.started {
+		return &daemon.ComponentHealth{Name: a.Name(), Healthy: false, Error: fmt.Errorf("not started")}, nil
+	}
+	return &daemon.ComponentHealth{Name: a.Name(), Healthy: true}, nil
+}
+
+func (a *AlertsComponent) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.check(ctx)
+		}
+	}
+}
+
+// check runs one round of probes and sends the resulting alerts.
+func (a *AlertsComponent) check(ctx context.Context) {
+	for _, message := range a.tracker.observe(a.probe(ctx)) {
+		if err := a.runtime.SendAlert(ctx, a.cfg.Adapter, a.cfg.Target, message); err != nil {
+			slog.Warn("Failed to send alert", "adapter", a.cfg.Adapter, "error", err)
+			metrics.Inc("alerts_failed_total")
+			continue
+		}
+		metrics.Inc("alerts_sent_total")
+	}
+}
+
+// probe returns the currently unhealthy conditions.
+func (a *AlertsComponent) probe(ctx context.Context) []alertCondition {
+	var unhealthy []alertCondition
+	for name, health := range a.daemon.ComponentHealth() {
+		if name == a.Name() || health == nil || health.Healthy {
+			continue
+		}
+		detail := "unhealthy"
+		if health.Error != nil {
+			detail = health.Error.Error()
+		}
+		unhealthy = append(unhealthy, alertCondition{Key: "component " + name, Detail: detail})
+	}
+
+	// Half-open circuits are still failing until a probe request succeeds.
+	for name, circuit := range a.runtime.ModelCircuits(ctx) {
+		if circuit.State == "closed" {
+			continue
+		}
+		unhealthy = append(unhealthy, alertCondition{
+			Key:    "model " + name,
+			Detail: fmt.Sprintf("circuit %s after %d consecutive failures", circuit.State, circuit.ConsecutiveFailures),
+		})
+	}
+
+	if limit := a.cfg.MaxWorkspaceBytes; limit > 0 {
+		stats, err := a.runtime.StoreStats(ctx)
+		if err != nil {
+			slog.Warn("Alerts could not read store stats", "error", err)
+		} else if stats.Disk.Total >= limit {
+			unhealthy = append(unhealthy, alertCondition{
+				Key:    "disk",
+				Detail: fmt.Sprintf("workspace uses %d bytes, limit %d", stats.Disk.Total, limit),
+			})
+		}
+	}
+	return unhealthy
+}
+
+// alertCondition is one unhealthy check result. Key identifies it across
+// checks; Detail is shown to the operator.
+type alertCondition struct {
+	Key    string
+	Detail string
+}
+
+type alertState struct {
+	failures  int
+	since     time.Time
+	alertedAt time.Time
+}
+
+// alertTracker turns check results into alert and recovery messages. A
+// condition alerts after threshold consecutive unhealthy checks and repeats
+// every repeat while it lasts; recoveries are sent only for conditions that
+// alerted. At most maxPerHour messages are sent.
+type alertTracker struct {
+	threshold  int
+	repeat     time.Duration
+	maxPerHour int
+	now        func() time.Time
+
+	states map[string]*alertState
+	sent   []time.Time
+}
+
+func newAlertTracker(threshold int, repeat time.Duration, maxPerHour int) *alertTracker {
+	return &alertTracker{
+		threshold:  threshold,
+		repeat:     repeat,
+		maxPerHour: maxPerHour,
+		now:        time.Now,
+		states:     make(map[string]*alertState),
+	}
+}
+
+// observe records one round of checks and returns the messages to send.
+func (t *alertTracker) observe(unhealthy []alertCondition) []string {
+	now := t.now()
+	var messages []string
+
+	current := make(map[string]bool, len(unhealthy))
+	for _, cond := range unhealthy {
+		current[cond.Key] = true
+	}
+	recovered := make([]string, 0)
+	for key := range t.states {
+		if !current[key] {
+			recovered = append(recovered, key)
+		}
+	}
+	sort.Strings(recovered)
+	for _, key := range recovered {
+		state := t.states[key]
+		delete(t.states, key)
+		if state.alertedAt.IsZero() || !t.allow(now) {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("[heike] RECOVERED %s (degraded for %s)", key, now.Sub(state.since).Round(time.Second)))
+	}
+
+	sort.Slice(unhealthy, func(i, j int) bool { return unhealthy[i].Key < unhealthy[j].Key })
+	for _, cond := range unhealthy {
+		state, ok := t.states[cond.Key]
+		if !ok {
+			state = &alertState{since: now}
+			t.states[cond.Key] = state
+		}
+		state.failures++
+		if state.failures < t.threshold {
+			continue
+		}
+		if !state.alertedAt.IsZero() && now.Sub(state.alertedAt) < t.repeat {
+			continue
+		}
+		if !t.allow(now) {
+			slog.Warn("Alert rate limit reached, dropping alert", "condition", cond.Key)
+			continue
+		}
+		state.alertedAt = now
+		messages = append(messages, fmt.Sprintf("[heike] ALERT %s: %s (unhealthy for %d checks)", cond.Key, cond.Detail, state.failures))
+	}
+	return messages
+}
+
+// allow reserves a slot in the hourly message budget.
+func (t *alertTracker) allow(now time.Time) bool {
+	kept := t.sent[:0]
+	for _, at := range t.sent {
+		if now.Sub(at) < time.Hour {
+			kept = append(kept, at)
+		}
+	}
+	t.sent = kept
+	if len(t.sent) >= t.maxPerHour {
+		return false
+	}
+	t.sent = append(t.sent, now)
+	return true
+}
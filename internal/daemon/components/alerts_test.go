@@ -0,0 +1,60 @@
+package components
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertTracker_ThresholdRepeatAndRecovery(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newAlertTracker(2, time.Hour, 10)
+	tracker.now = func() time.Time { return now }
+	down := []alertCondition{{Key: "model gpt", Detail: "circuit open"}}
+
+	if msgs := tracker.observe(down); len(msgs) != 0 {
+		t.Fatalf("expected no alert below threshold, got %v", msgs)
+	}
+	now = now.Add(time.Minute)
+	msgs := tracker.observe(down)
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "ALERT model gpt: circuit open") {
+		t.Fatalf("expected alert at threshold, got %v", msgs)
+	}
+	now = now.Add(time.Minute)
+	if msgs := tracker.observe(down); len(msgs) != 0 {
+		t.Fatalf("expected no repeat within repeat interval, got %v", msgs)
+	}
+	now = now.Add(time.Hour)
+	if msgs := tracker.observe(down); len(msgs) != 1 {
+		t.Fatalf("expected repeated alert, got %v", msgs)
+	}
+	now = now.Add(time.Minute)
+	msgs = tracker.observe(nil)
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "RECOVERED model gpt") {
+		t.Fatalf("expected recovery, got %v", msgs)
+	}
+}
+
+func TestAlertTracker_SkipsRecoveryForUnalertedConditions(t *testing.T) {
+	tracker := newAlertTracker(3, time.Hour, 10)
+	tracker.observe([]alertCondition{{Key: "disk"}})
+	if msgs := tracker.observe(nil); len(msgs) != 0 {
+		t.Fatalf("expected no recovery for a blip, got %v", msgs)
+	}
+}
+
+func TestAlertTracker_RateLimitsPerHour(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newAlertTracker(1, time.Hour, 2)
+	tracker.now = func() time.Time { return now }
+
+	msgs := tracker.observe([]alertCondition{{Key: "a"}, {Key: "b"}, {Key: "c"}})
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 alerts under the hourly cap, got %v", msgs)
+	}
+	now = now.Add(time.Hour + time.Minute)
+	msgs = tracker.observe([]alertCondition{{Key: "a"}, {Key: "b"}, {Key: "c"}})
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "ALERT a") || !strings.Contains(msgs[1], "ALERT b") {
+		t.Fatalf("expected budget to refill after an hour, got %v", msgs)
+	}
+}
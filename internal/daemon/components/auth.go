@@ -0,0 +1,282 @@
+package components
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/metrics"
+	"github.com/harunnryd/heike/internal/orchestrator/command"
+)
+
+// API scopes granted to keys and tokens. read covers every GET, submit every
+// other call except resolving approvals, which needs approve.
+const (
+	scopeRead    = "read"
+	scopeSubmit  = "submit"
+	scopeApprove = "approve"
+)
+
+var errUnauthenticated = errors.New("invalid or missing credentials")
+
+// apiPrincipal is the caller behind an authenticated request.
+type apiPrincipal struct {
+	Name   string
+	Scopes map[string]bool
+}
+
+type principalContextKey struct{}
+
+func withPrincipal(ctx context.Context, principal *apiPrincipal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// eventContentScope returns the scope an event's content needs beyond
+// submit, or "" when submit is enough. /approve and /deny sent as events
+// resolve approvals just like /api/v1/approvals, so they need approve.
+func eventContentScope(ctx context.Context, content string) string {
+	principal, ok := ctx.Value(principalContextKey{}).(*apiPrincipal)
+	if !ok || principal == nil {
+		return ""
+	}
+	if command.ResolvesApproval(content) && !principal.Scopes[scopeApprove] {
+		slog.Warn("API event denied", "principal", principal.Name, "scope", scopeApprove)
+		metrics.Inc("api_auth_failures_total")
+		return scopeApprove
+	}
+	return ""
+}
+
+type apiKey struct {
+	name   string
+	hash   [sha256.Size]byte
+	scopes map[string]bool
+}
+
+// apiAuthenticator checks credentials against server.auth. Keys are kept
+// hashed so they compare in constant time regardless of length.
+type apiAuthenticator struct {
+	keys        []apiKey
+	jwtSecret   []byte
+	jwtIssuer   string
+	jwtAudience string
+}
+
+// newAPIAuthenticator returns nil when server.auth configures no keys and no
+// JWT secret, leaving the API open.
+func newAPIAuthenticator(cfg config.ServerAuthConfig) (*apiAuthenticator, error) {
+	a := &apiAuthenticator{
+		jwtSecret:   []byte(strings.TrimSpace(cfg.JWT.Secret)),
+		jwtIssuer:   strings.TrimSpace(cfg.JWT.Issuer),
+		jwtAudience: strings.TrimSpace(cfg.JWT.Audience),
+	}
+	for i, k := range cfg.APIKeys {
+		name := strings.TrimSpace(k.Name)
+		if name == "" {
+			name = fmt.Sprintf("key-%d", i+1)
+		}
+		key := strings.TrimSpace(k.Key)
+		if key == "" {
+			return nil, fmt.Errorf("server.auth.api_keys %s: key is empty", name)
+		}
+		scopes, err := parseScopes(k.Scopes)
+		if err != nil {
+			return nil, fmt.Errorf("server.auth.api_keys %s: %w", name, err)
+		}
+		a.keys = append(a.keys, apiKey{name: name, hash: sha256.Sum256([]byte(key)), scopes: scopes})
+	}
+	if len(a.keys) == 0 && len(a.jwtSecret) == 0 {
+		return nil, nil
+	}
+	return a, nil
+}
+
+func parseScopes(names []string) (map[string]bool, error) {
+	scopes := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case scopeRead, scopeSubmit, scopeApprove:
+			scopes[name] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown scope %q", name)
+		}
+	}
+	return scopes, nil
+}
+
+// authenticate resolves a bearer token or API key. Tokens that look like a
+// JWT are only checked as one when a JWT secret is configured.
+func (a *apiAuthenticator) authenticate(token string) (*apiPrincipal, error) {
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+	hash := sha256.Sum256([]byte(token))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			return &apiPrincipal{Name: k.name, Scopes: k.scopes}, nil
+		}
+	}
+	if len(a.jwtSecret) > 0 && strings.Count(token, ".") == 2 {
+		return a.verifyJWT(token, time.Now())
+	}
+	return nil, errUnauthenticated
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	// Scope is space-separated as in OAuth 2.0, or a list.
+	Scope json.RawMessage `json:"scope"`
+}
+
+// verifyJWT checks an HS256 token's signature, expiry, issuer, and audience.
+func (a *apiAuthenticator) verifyJWT(token string, now time.Time) (*apiPrincipal, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errUnauthenticated
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errUnauthenticated
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errUnauthenticated
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, errUnauthenticated
+	}
+	unix := float64(now.Unix())
+	if claims.ExpiresAt == nil || unix >= *claims.ExpiresAt {
+		return nil, errUnauthenticated
+	}
+	if claims.NotBefore != nil && unix < *claims.NotBefore {
+		return nil, errUnauthenticated
+	}
+	if a.jwtIssuer != "" && claims.Issuer != a.jwtIssuer {
+		return nil, errUnauthenticated
+	}
+	if a.jwtAudience != "" && !containsClaim(claims.Audience, a.jwtAudience) {
+		return nil, errUnauthenticated
+	}
+
+	var names []string
+	var scope string
+	if err := json.Unmarshal(claims.Scope, &scope); err == nil {
+		names = strings.Fields(scope)
+	} else {
+		_ = json.Unmarshal(claims.Scope, &names)
+	}
+	// Scopes this server does not know are ignored, since tokens are often
+	// shared with other services.
+	scopes := make(map[string]bool, len(names))
+	for _, name := range names {
+		if known, err := parseScopes([]string{name}); err == nil {
+			for s := range known {
+				scopes[s] = true
+			}
+		}
+	}
+	name := claims.Subject
+	if name == "" {
+		name = "jwt"
+	}
+	return &apiPrincipal{Name: name, Scopes: scopes}, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// containsClaim reports whether a string-or-list claim holds want.
+func containsClaim(raw json.RawMessage, want string) bool {
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return one == want
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return false
+	}
+	for _, v := range many {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialFromHeaders takes a bearer token from authorization, falling
+// back to apiKey.
+func credentialFromHeaders(authorization, apiKey string) string {
+	if scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(apiKey)
+}
+
+// httpRequiredScope maps a request to the scope it needs.
+func httpRequiredScope(r *http.Request) string {
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return scopeRead
+	case strings.HasPrefix(r.URL.Path, "/api/v1/approvals"):
+		return scopeApprove
+	default:
+		return scopeSubmit
+	}
+}
+
+// requireAuth guards /api/v1 and /debug routes when server.auth is
+// configured. /health stays open for load balancers.
+func (h *HTTPServerComponent) requireAuth(next http.Handler) http.Handler {
+	if h.auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/debug/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := h.auth.authenticate(credentialFromHeaders(r.Header.Get("Authorization"), r.Header.Get("X-API-Key")))
+		if err != nil {
+			metrics.Inc("api_auth_failures_total")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="heike"`)
+			writeError(w, http.StatusUnauthorized, errCodeUnauthenticated, err.Error())
+			return
+		}
+		scope := httpRequiredScope(r)
+		if !principal.Scopes[scope] {
+			metrics.Inc("api_auth_failures_total")
+			slog.Warn("API request denied", "principal", principal.Name, "scope", scope, "path", r.URL.Path)
+			writeErrorDetails(w, http.StatusForbidden, errCodePermissionDenied, fmt.Sprintf("%s scope required", scope), map[string]interface{}{"scope": scope})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
+	})
+}
@@ -0,0 +1,215 @@
+package components
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon/rpc/heikev1"
+)
+
+func signJWT(secret, claims string) string {
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewAPIAuthenticator_DisabledAndInvalid(t *testing.T) {
+	if auth, err := newAPIAuthenticator(config.ServerAuthConfig{}); auth != nil || err != nil {
+		t.Fatalf("empty config = %v, %v; want no authenticator", auth, err)
+	}
+	if _, err := newAPIAuthenticator(config.ServerAuthConfig{APIKeys: []config.ServerAPIKey{{Name: "ci", Key: "k", Scopes: []string{"admin"}}}}); err == nil {
+		t.Fatal("expected unknown scope to be rejected")
+	}
+	if _, err := newAPIAuthenticator(config.ServerAuthConfig{APIKeys: []config.ServerAPIKey{{Name: "ci"}}}); err == nil {
+		t.Fatal("expected empty key to be rejected")
+	}
+}
+
+func TestRequireAuth_Scopes(t *testing.T) {
+	auth, err := newAPIAuthenticator(config.ServerAuthConfig{
+		APIKeys: []config.ServerAPIKey{
+			{Name: "viewer", Key: "read-key", Scopes: []string{"read"}},
+			{Name: "operator", Key: "approve-key", Scopes: []string{"read", "approve"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &HTTPServerComponent{auth: auth}
+	handler := h.requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		method, path, header, value string
+		want                        int
+	}{
+		{http.MethodGet, "/health", "", "", http.StatusNoContent},
+		{http.MethodGet, "/api/v1/approvals", "", "", http.StatusUnauthorized},
+		{http.MethodGet, "/debug/pprof/", "", "", http.StatusUnauthorized},
+		{http.MethodGet, "/debug/pprof/", "X-API-Key", "read-key", http.StatusNoContent},
+		{http.MethodGet, "/api/v1/approvals", "Authorization", "Bearer wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/approvals", "Authorization", "Bearer read-key", http.StatusNoContent},
+		{http.MethodGet, "/api/v1/approvals", "X-API-Key", "read-key", http.StatusNoContent},
+		{http.MethodPost, "/api/v1/events", "X-API-Key", "read-key", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/approvals/a1/resolve", "X-API-Key", "read-key", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/approvals/a1/resolve", "Authorization", "bearer approve-key", http.StatusNoContent},
+		{http.MethodPost, "/api/v1/events", "X-API-Key", "approve-key", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s %s with %s=%q: status = %d, want %d", tc.method, tc.path, tc.header, tc.value, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestRequireAuth_ApprovalCommandsNeedApproveScope(t *testing.T) {
+	auth, err := newAPIAuthenticator(config.ServerAuthConfig{
+		APIKeys: []config.ServerAPIKey{
+			{Name: "bot", Key: "submit-key", Scopes: []string{"submit"}},
+			{Name: "operator", Key: "operator-key", Scopes: []string{"submit", "approve"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := &batchRuntime{}
+	h := &HTTPServerComponent{auth: auth, runtime: runtime, cfg: &config.ServerConfig{}}
+	events := h.requireAuth(http.HandlerFunc(h.handleEvents))
+	batch := h.requireAuth(http.HandlerFunc(h.handleEventBatch))
+
+	post := func(handler http.Handler, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, content := range []string{"/approve a1", "/deny a1", `\"/approve\" a1`} {
+		rec := post(events, "/api/v1/events", "submit-key", `{"source":"api","content":"`+content+`"}`)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("submit key sending %s: status = %d, want %d", content, rec.Code, http.StatusForbidden)
+		}
+	}
+	rec := post(batch, "/api/v1/events/batch", "submit-key", `{"events":[{"source":"api","content":"hello"},{"source":"api","content":"/deny a1"}]}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"rejected":1`) || !strings.Contains(rec.Body.String(), errCodePermissionDenied) {
+		t.Fatalf("batch with approval command: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(runtime.submitted) != 1 || runtime.submitted[0].Content != "hello" {
+		t.Fatalf("submitted = %+v, want only the plain message", runtime.submitted)
+	}
+
+	if rec := post(events, "/api/v1/events", "operator-key", `{"source":"api","content":"/approve a1"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("approve key sending /approve: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if rec := post(events, "/api/v1/events", "submit-key", `{"source":"api","content":"/history"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("submit key sending /history: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPIAuthenticator_JWT(t *testing.T) {
+	auth, err := newAPIAuthenticator(config.ServerAuthConfig{JWT: config.ServerJWTConfig{Secret: "s3cret", Issuer: "idp", Audience: "heike"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_000_000, 0)
+
+	principal, err := auth.verifyJWT(signJWT("s3cret", `{"sub":"alice","iss":"idp","aud":["heike"],"exp":1000100,"scope":"read submit other"}`), now)
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if principal.Name != "alice" || !principal.Scopes[scopeRead] || !principal.Scopes[scopeSubmit] || principal.Scopes[scopeApprove] {
+		t.Fatalf("principal = %+v", principal)
+	}
+
+	rejected := map[string]string{
+		"bad signature":  signJWT("other", `{"iss":"idp","aud":"heike","exp":1000100}`),
+		"expired":        signJWT("s3cret", `{"iss":"idp","aud":"heike","exp":999999}`),
+		"no expiry":      signJWT("s3cret", `{"iss":"idp","aud":"heike"}`),
+		"not yet valid":  signJWT("s3cret", `{"iss":"idp","aud":"heike","exp":1000100,"nbf":1000050}`),
+		"wrong issuer":   signJWT("s3cret", `{"iss":"other","aud":"heike","exp":1000100}`),
+		"wrong audience": signJWT("s3cret", `{"iss":"idp","aud":"other","exp":1000100}`),
+	}
+	for name, token := range rejected {
+		if _, err := auth.verifyJWT(token, now); err == nil {
+			t.Errorf("%s: expected token to be rejected", name)
+		}
+	}
+}
+
+func TestGRPCAuthOptions_Scopes(t *testing.T) {
+	auth, err := newAPIAuthenticator(config.ServerAuthConfig{
+		APIKeys: []config.ServerAPIKey{{Name: "viewer", Key: "read-key", Scopes: []string{"read"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newGRPCTestClient(t, &grpcRuntime{lines: []string{`{"n":1}`}, resolved: map[string]bool{}}, grpcAuthOptions(auth)...)
+	withKey := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "read-key")
+
+	if _, err := client.ListSessions(context.Background(), &heikev1.ListSessionsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("no key = %v, want Unauthenticated", status.Code(err))
+	}
+	if _, err := client.ListSessions(withKey, &heikev1.ListSessionsRequest{}); err != nil {
+		t.Fatalf("read key listing: %v", err)
+	}
+	if _, err := client.ResolveApproval(withKey, &heikev1.ResolveApprovalRequest{Id: "a1", Approve: true}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("read key resolving = %v, want PermissionDenied", status.Code(err))
+	}
+
+	stream, err := client.StreamTranscript(context.Background(), &heikev1.StreamTranscriptRequest{SessionId: "sess-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("stream without key = %v, want Unauthenticated", status.Code(err))
+	}
+}
+
+func TestGRPCAuthOptions_ApprovalCommandsNeedApproveScope(t *testing.T) {
+	auth, err := newAPIAuthenticator(config.ServerAuthConfig{
+		APIKeys: []config.ServerAPIKey{
+			{Name: "bot", Key: "submit-key", Scopes: []string{"submit"}},
+			{Name: "operator", Key: "operator-key", Scopes: []string{"submit", "approve"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := &grpcRuntime{resolved: map[string]bool{}}
+	client := newGRPCTestClient(t, runtime, grpcAuthOptions(auth)...)
+
+	submitOnly := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "submit-key")
+	if _, err := client.SubmitEvent(submitOnly, &heikev1.SubmitEventRequest{Source: "api", Content: "/approve a1"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("submit key sending /approve = %v, want PermissionDenied", status.Code(err))
+	}
+	if len(runtime.submitted) != 0 {
+		t.Fatalf("submitted = %+v, want none", runtime.submitted)
+	}
+
+	operator := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "operator-key")
+	if _, err := client.SubmitEvent(operator, &heikev1.SubmitEventRequest{Source: "api", Content: "/approve a1"}); err != nil {
+		t.Fatalf("approve key sending /approve: %v", err)
+	}
+}
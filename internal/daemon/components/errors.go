@@ -0,0 +1,83 @@
+package components
+
+import (
+	"errors"
+	"net/http"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// API error codes. Clients should switch on these rather than on messages.
+const (
+	errCodeInvalidInput     = "invalid_input"
+	errCodeNotFound         = "not_found"
+	errCodeMethodNotAllowed = "method_not_allowed"
+	errCodeUnauthenticated  = "unauthenticated"
+	errCodePermissionDenied = "permission_denied"
+	errCodeConflict         = "conflict"
+	errCodePayloadTooLarge  = "payload_too_large"
+	errCodeQueueFull        = "queue_full"
+	errCodeRateLimited      = "rate_limited"
+	errCodeUnavailable      = "unavailable"
+	errCodeInternal         = "internal"
+)
+
+// retryableErrCodes are the codes a client may retry unchanged after a
+// backoff.
+var retryableErrCodes = map[string]bool{
+	errCodeConflict:    true,
+	errCodeQueueFull:   true,
+	errCodeRateLimited: true,
+	errCodeUnavailable: true,
+}
+
+// apiError is the body of every HTTP API error response, under "error".
+type apiError struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Retryable bool                   `json:"retryable"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeErrorDetails(w, status, code, message, nil)
+}
+
+func writeErrorDetails(w http.ResponseWriter, status int, code, message string, details map[string]interface{}) {
+	writeJSON(w, status, map[string]interface{}{"error": apiError{
+		Code:      code,
+		Message:   message,
+		Retryable: retryableErrCodes[code],
+		Details:   details,
+	}})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "method not allowed")
+}
+
+// writeRuntimeError reports an error returned by the runtime API, mapping its
+// heike error category to a status and code. Uncategorized errors are
+// internal.
+func writeRuntimeError(w http.ResponseWriter, err error) {
+	status, code := errorStatus(err)
+	writeError(w, status, code, err.Error())
+}
+
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, heikeErrors.ErrInvalidInput):
+		return http.StatusBadRequest, errCodeInvalidInput
+	case errors.Is(err, heikeErrors.ErrNotFound):
+		return http.StatusNotFound, errCodeNotFound
+	case errors.Is(err, heikeErrors.ErrPermissionDenied):
+		return http.StatusForbidden, errCodePermissionDenied
+	case errors.Is(err, heikeErrors.ErrConflict), errors.Is(err, heikeErrors.ErrDuplicateEvent):
+		return http.StatusConflict, errCodeConflict
+	case errors.Is(err, heikeErrors.ErrRateLimited):
+		return http.StatusTooManyRequests, errCodeRateLimited
+	case errors.Is(err, heikeErrors.ErrTransient):
+		return http.StatusServiceUnavailable, errCodeUnavailable
+	}
+	return http.StatusInternalServerError, errCodeInternal
+}
@@ -0,0 +1,362 @@
+package components
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/daemon/rpc/heikev1"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+// GRPCServerComponent serves the runtime API over gRPC on server.grpc.port,
+// so services can submit events and follow transcripts without the SSE
+// endpoint. It does nothing unless server.grpc.enabled is set.
+type GRPCServerComponent struct {
+	daemon      *daemon.Daemon
+	cfg         *config.ServerConfig
+	deps        []string
+	server      *grpc.Server
+	shutdownTTL time.Duration
+	initialized bool
+	started     bool
+	mu          sync.RWMutex
+}
+
+func NewGRPCServerComponent(d *daemon.Daemon, cfg *config.ServerConfig, deps []string) *GRPCServerComponent {
+	depList := make([]string, len(deps))
+	copy(depList, deps)
+	return &GRPCServerComponent{
+		daemon: d,
+		cfg:    cfg,
+		deps:   depList,
+	}
+}
+
+func (g *GRPCServerComponent) Name() string {
+	return "GRPCServer"
+}
+
+func (g *GRPCServerComponent) Dependencies() []string {
+	deps := make([]string, len(g.deps))
+	copy(deps, g.deps)
+	return deps
+}
+
+func (g *GRPCServerComponent) enabled() bool {
+	return g.cfg != nil && g.cfg.GRPC.Enabled
+}
+
+func (g *GRPCServerComponent) Init(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.enabled() {
+		return nil
+	}
+	if g.daemon == nil {
+		return fmt.Errorf("daemon manager not configured")
+	}
+	runtimeAPI, ok := g.daemon.Component("Runtime").(daemon.RuntimeAPI)
+	if !ok {
+		return fmt.Errorf("runtime component does not implement daemon runtime api")
+	}
+	shutdownTimeout, err := config.DurationOrDefault(g.cfg.ShutdownTimeout, config.DefaultServerShutdownTimeout)
+	if err != nil {
+		return fmt.Errorf("parse server shutdown timeout: %w", err)
+	}
+
+	auth, err := newAPIAuthenticator(g.cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("configure server auth: %w", err)
+	}
+
+	g.server = grpc.NewServer(grpcAuthOptions(auth)...)
+	heikev1.RegisterRuntimeServiceServer(g.server, &grpcRuntimeService{runtime: runtimeAPI})
+	g.shutdownTTL = shutdownTimeout
+	g.initialized = true
+	slog.Info("GRPCServer initialized", "component", g.Name(), "port", g.cfg.GRPC.Port, "auth", auth != nil)
+	return nil
+}
+
+func (g *GRPCServerComponent) Start(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.enabled() {
+		return nil
+	}
+	if !g.initialized {
+		return fmt.Errorf("GRPCServer not initialized")
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", g.cfg.GRPC.Port))
+	if err != nil {
+		return fmt.Errorf("listen grpc: %w", err)
+	}
+	go func() {
+		slog.Info("gRPC server listening", "component", g.Name(), "addr", lis.Addr().String())
+		if err := g.server.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Error("gRPC server failed", "component", g.Name(), "error", err)
+		}
+	}()
+
+	g.started = true
+	slog.Info("GRPCServer started", "component", g.Name())
+	return nil
+}
+
+func (g *GRPCServerComponent) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.started {
+		return nil
+	}
+
+	slog.Info("Stopping GRPCServer...", "component", g.Name())
+	// Transcript streams only end when clients disconnect, so graceful
+	// shutdown falls back to closing connections after the timeout.
+	done := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(done)
+	}()
+	timer := time.NewTimer(g.shutdownTTL)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		g.server.Stop()
+	case <-ctx.Done():
+		g.server.Stop()
+	}
+
+	g.started = false
+	slog.Info("GRPCServer stopped", "component", g.Name())
+	return nil
+}
+
+func (g *GRPCServerComponent) Health(ctx context.Context) (*daemon.ComponentHealth, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if !g.enabled() {
+		return &daemon.ComponentHealth{Name: g.Name(), Healthy: true}, nil
+	}
+	if !g.initialized {
+		return &daemon.ComponentHealth{Name: g.Name(), Healthy: false, Error: fmt.Errorf("not initialized")}, nil
+	}
+	if !g.started {
+		return &daemon.ComponentHealth{Name: g.Name(), Healthy: false, Error: fmt.Errorf("not started")}, nil
+	}
+	return &daemon.ComponentHealth{Name: g.Name(), Healthy: true}, nil
+}
+
+// grpcAuthOptions applies server.auth to every RPC, reading credentials
+// from the authorization or x-api-key metadata.
+func grpcAuthOptions(auth *apiAuthenticator) []grpc.ServerOption {
+	if auth == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			principal, err := auth.authorizeGRPC(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(withPrincipal(ctx, principal), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, err := auth.authorizeGRPC(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func (a *apiAuthenticator) authorizeGRPC(ctx context.Context, method string) (*apiPrincipal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	principal, err := a.authenticate(credentialFromHeaders(first("authorization"), first("x-api-key")))
+	if err != nil {
+		metrics.Inc("api_auth_failures_total")
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	scope := grpcRequiredScope(method)
+	if !principal.Scopes[scope] {
+		metrics.Inc("api_auth_failures_total")
+		slog.Warn("gRPC call denied", "principal", principal.Name, "scope", scope, "method", method)
+		return nil, status.Errorf(codes.PermissionDenied, "%s scope required", scope)
+	}
+	return principal, nil
+}
+
+func grpcRequiredScope(method string) string {
+	switch method {
+	case heikev1.RuntimeService_SubmitEvent_FullMethodName:
+		return scopeSubmit
+	case heikev1.RuntimeService_ResolveApproval_FullMethodName:
+		return scopeApprove
+	default:
+		return scopeRead
+	}
+}
+
+// grpcRuntimeService adapts daemon.RuntimeAPI to the generated service.
+type grpcRuntimeService struct {
+	heikev1.UnimplementedRuntimeServiceServer
+	runtime daemon.RuntimeAPI
+}
+
+func (s *grpcRuntimeService) SubmitEvent(ctx context.Context, req *heikev1.SubmitEventRequest) (*heikev1.SubmitEventResponse, error) {
+	idempotencyKey := strings.TrimSpace(req.GetIdempotencyKey())
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency_key exceeds %d characters", maxIdempotencyKeyLength)
+	}
+	evt := eventRequest{
+		Source:      req.GetSource(),
+		Type:        req.GetType(),
+		SessionID:   req.GetSessionId(),
+		Content:     req.GetContent(),
+		Metadata:    req.GetMetadata(),
+		CallbackURL: req.GetCallbackUrl(),
+		NotifyURL:   req.GetNotifyUrl(),
+	}.runtimeEvent(idempotencyKey)
+	if scope := eventContentScope(ctx, evt.Content); scope != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "%s scope required", scope)
+	}
+
+	id, err := s.runtime.SubmitEvent(ctx, evt)
+	if err != nil {
+		if errors.Is(err, heikeErrors.ErrDuplicateEvent) {
+			return &heikev1.SubmitEventResponse{Id: id, Duplicate: true}, nil
+		}
+		if errors.Is(err, heikeErrors.ErrTransient) {
+			return nil, status.Error(codes.ResourceExhausted, "queue full")
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &heikev1.SubmitEventResponse{Id: id}, nil
+}
+
+func (s *grpcRuntimeService) ListSessions(ctx context.Context, req *heikev1.ListSessionsRequest) (*heikev1.ListSessionsResponse, error) {
+	sessions, err := s.runtime.ListSessions(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &heikev1.ListSessionsResponse{Sessions: make([]*heikev1.Session, 0, len(sessions))}
+	for _, sess := range sessions {
+		resp.Sessions = append(resp.Sessions, &heikev1.Session{
+			Id:        sess.ID,
+			Title:     sess.Title,
+			Status:    sess.Status,
+			CreatedAt: grpcTimestamp(sess.CreatedAt),
+			UpdatedAt: grpcTimestamp(sess.UpdatedAt),
+			Metadata:  sess.Metadata,
+		})
+	}
+	return resp, nil
+}
+
+// StreamTranscript follows the transcript like the SSE session stream: event
+// IDs are 1-based line numbers and from resumes after the given ID.
+func (s *grpcRuntimeService) StreamTranscript(req *heikev1.StreamTranscriptRequest, stream heikev1.RuntimeService_StreamTranscriptServer) error {
+	sessionID := strings.TrimSpace(req.GetSessionId())
+	if sessionID == "" {
+		return status.Error(codes.InvalidArgument, "session id is required")
+	}
+	if req.GetFrom() < 0 {
+		return status.Error(codes.InvalidArgument, "invalid from")
+	}
+	lines, err := s.runtime.WatchTranscript(stream.Context(), sessionID, int(req.GetFrom()))
+	if err != nil {
+		return grpcError(err)
+	}
+	for line := range lines {
+		if err := stream.Send(&heikev1.TranscriptEvent{
+			Id:    int64(line.ID),
+			Event: sseEventName(line.Data),
+			Data:  line.Data,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *grpcRuntimeService) ListApprovals(ctx context.Context, req *heikev1.ListApprovalsRequest) (*heikev1.ListApprovalsResponse, error) {
+	approvals, err := s.runtime.ListPendingApprovals(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &heikev1.ListApprovalsResponse{Approvals: make([]*heikev1.Approval, 0, len(approvals))}
+	for _, a := range approvals {
+		resp.Approvals = append(resp.Approvals, &heikev1.Approval{
+			Id:        a.ID,
+			SessionId: a.SessionID,
+			Tool:      a.Tool,
+			Input:     a.Input,
+			Status:    a.Status,
+			CreatedAt: grpcTimestamp(a.CreatedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (s *grpcRuntimeService) ResolveApproval(ctx context.Context, req *heikev1.ResolveApprovalRequest) (*heikev1.ResolveApprovalResponse, error) {
+	approvalID := strings.TrimSpace(req.GetId())
+	if approvalID == "" {
+		return nil, status.Error(codes.InvalidArgument, "approval id is required")
+	}
+	if err := s.runtime.ResolveApproval(ctx, approvalID, req.GetApprove()); err != nil {
+		// Unknown and already resolved approvals are caller errors, as in
+		// the HTTP API.
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &heikev1.ResolveApprovalResponse{}, nil
+}
+
+// grpcError maps heike error kinds to gRPC status codes.
+func grpcError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, heikeErrors.ErrInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, heikeErrors.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, heikeErrors.ErrPermissionDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, heikeErrors.ErrConflict):
+		code = codes.FailedPrecondition
+	case errors.Is(err, heikeErrors.ErrTransient):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+func grpcTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,164 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/daemon/rpc/heikev1"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+type grpcRuntime struct {
+	daemon.RuntimeAPI
+	submitted []daemon.RuntimeEvent
+	lines     []string
+	resolved  map[string]bool
+}
+
+func (r *grpcRuntime) SubmitEvent(ctx context.Context, evt daemon.RuntimeEvent) (string, error) {
+	r.submitted = append(r.submitted, evt)
+	switch evt.Content {
+	case "dup":
+		return "evt_original", heikeErrors.ErrDuplicateEvent
+	case "full":
+		return "", heikeErrors.ErrTransient
+	}
+	return fmt.Sprintf("evt_%d", len(r.submitted)), nil
+}
+
+func (r *grpcRuntime) ListSessions(ctx context.Context) ([]daemon.RuntimeSession, error) {
+	return []daemon.RuntimeSession{{ID: "sess-1", Title: "weather", CreatedAt: time.Unix(100, 0)}}, nil
+}
+
+func (r *grpcRuntime) WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan daemon.RuntimeTranscriptLine, error) {
+	return watchLines(ctx, r.lines, from), nil
+}
+
+func (r *grpcRuntime) ListPendingApprovals(ctx context.Context) ([]daemon.RuntimeApproval, error) {
+	return []daemon.RuntimeApproval{{ID: "a1", SessionID: "sess-1", Tool: "exec_command", Status: "pending"}}, nil
+}
+
+func (r *grpcRuntime) ResolveApproval(ctx context.Context, approvalID string, approve bool) error {
+	if approvalID != "a1" {
+		return fmt.Errorf("approval request not found: %s", approvalID)
+	}
+	r.resolved[approvalID] = approve
+	return nil
+}
+
+func newGRPCTestClient(t *testing.T, runtime daemon.RuntimeAPI, opts ...grpc.ServerOption) heikev1.RuntimeServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	heikev1.RegisterRuntimeServiceServer(server, &grpcRuntimeService{runtime: runtime})
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return heikev1.NewRuntimeServiceClient(conn)
+}
+
+func TestGRPCRuntimeService_SubmitEvent(t *testing.T) {
+	runtime := &grpcRuntime{}
+	client := newGRPCTestClient(t, runtime)
+	ctx := context.Background()
+
+	resp, err := client.SubmitEvent(ctx, &heikev1.SubmitEventRequest{Source: " ci ", Content: "hello", IdempotencyKey: "k1", Metadata: map[string]string{"team": "ops"}})
+	if err != nil || resp.GetId() != "evt_1" || resp.GetDuplicate() {
+		t.Fatalf("SubmitEvent = %+v, %v", resp, err)
+	}
+	if got := runtime.submitted[0]; got.Source != "ci" || got.IdempotencyKey != "k1" || got.Metadata["team"] != "ops" {
+		t.Fatalf("unexpected runtime event: %+v", got)
+	}
+
+	resp, err = client.SubmitEvent(ctx, &heikev1.SubmitEventRequest{Content: "dup"})
+	if err != nil || resp.GetId() != "evt_original" || !resp.GetDuplicate() {
+		t.Fatalf("duplicate SubmitEvent = %+v, %v", resp, err)
+	}
+	if _, err := client.SubmitEvent(ctx, &heikev1.SubmitEventRequest{Content: "full"}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("queue full code = %v, want ResourceExhausted", status.Code(err))
+	}
+}
+
+func TestGRPCRuntimeService_SessionsAndApprovals(t *testing.T) {
+	runtime := &grpcRuntime{resolved: map[string]bool{}}
+	client := newGRPCTestClient(t, runtime)
+	ctx := context.Background()
+
+	sessions, err := client.ListSessions(ctx, &heikev1.ListSessionsRequest{})
+	if err != nil || len(sessions.GetSessions()) != 1 {
+		t.Fatalf("ListSessions = %+v, %v", sessions, err)
+	}
+	sess := sessions.GetSessions()[0]
+	if sess.GetId() != "sess-1" || sess.GetCreatedAt().AsTime().Unix() != 100 || sess.GetUpdatedAt() != nil {
+		t.Fatalf("unexpected session: %+v", sess)
+	}
+
+	approvals, err := client.ListApprovals(ctx, &heikev1.ListApprovalsRequest{})
+	if err != nil || len(approvals.GetApprovals()) != 1 || approvals.GetApprovals()[0].GetTool() != "exec_command" {
+		t.Fatalf("ListApprovals = %+v, %v", approvals, err)
+	}
+	if _, err := client.ResolveApproval(ctx, &heikev1.ResolveApprovalRequest{Id: "a1", Approve: true}); err != nil {
+		t.Fatalf("ResolveApproval: %v", err)
+	}
+	if !runtime.resolved["a1"] {
+		t.Fatal("approval was not resolved")
+	}
+	if _, err := client.ResolveApproval(ctx, &heikev1.ResolveApprovalRequest{Id: "missing"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("unknown approval code = %v, want InvalidArgument", status.Code(err))
+	}
+}
+
+func TestGRPCRuntimeService_StreamTranscriptResumes(t *testing.T) {
+	runtime := &grpcRuntime{lines: []string{
+		`{"type":"user","role":"user","content":"weather?"}`,
+		`{"type":"tool_call","role":"system","tool_calls":[{"id":"c1","name":"weather","input":"{}"}]}`,
+		`{"type":"done","role":"system","metadata":{"state":"completed"}}`,
+	}}
+	client := newGRPCTestClient(t, runtime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamTranscript(ctx, &heikev1.StreamTranscriptRequest{SessionId: "sess-1", From: 1})
+	if err != nil {
+		t.Fatalf("StreamTranscript: %v", err)
+	}
+	want := []struct {
+		id    int64
+		event string
+	}{{2, "tool_call"}, {3, "done"}}
+	for _, w := range want {
+		evt, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if evt.GetId() != w.id || evt.GetEvent() != w.event || evt.GetData() != runtime.lines[w.id-1] {
+			t.Fatalf("event = %+v, want id %d event %s", evt, w.id, w.event)
+		}
+	}
+
+	stream, err = client.StreamTranscript(ctx, &heikev1.StreamTranscriptRequest{})
+	if err != nil {
+		t.Fatalf("StreamTranscript: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("missing session code = %v, want InvalidArgument", status.Code(err))
+	}
+}
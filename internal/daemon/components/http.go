@@ -2,11 +2,14 @@ package components
 
 import (
 	"context"
+	_ "embed"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/http/pprof"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,12 +18,19 @@ import (
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/daemon"
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/metrics"
 )
 
+// openAPISpec documents the events, sessions, approvals and Zanshin routes.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
 type HTTPServerComponent struct {
 	daemon      *daemon.Daemon
 	runtime     daemon.RuntimeAPI
 	cfg         *config.ServerConfig
+	auth        *apiAuthenticator
 	deps        []string
 	server      *http.Server
 	shutdownTTL time.Duration
@@ -28,6 +38,9 @@ type HTTPServerComponent struct {
 	started     bool
 	mu          sync.RWMutex
 	startTime   time.Time
+	// streamCtx is cancelled on shutdown to end WebSocket streams, which
+	// the server does not track once hijacked.
+	streamCtx context.Context
 }
 
 func NewHTTPServerComponent(d *daemon.Daemon, cfg *config.ServerConfig) *HTTPServerComponent {
@@ -74,15 +87,11 @@ func (h *HTTPServerComponent) Init(ctx context.Context) error {
 		return fmt.Errorf("runtime component does not implement daemon runtime api")
 	}
 	h.runtime = runtimeAPI
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/health", h.handleHealth)
-	mux.HandleFunc("/api/v1/events", h.handleEvents)
-	mux.HandleFunc("/api/v1/sessions", h.handleSessions)
-	mux.HandleFunc("/api/v1/sessions/", h.handleSessions)
-	mux.HandleFunc("/api/v1/approvals", h.handleApprovals)
-	mux.HandleFunc("/api/v1/approvals/", h.handleApprovals)
-	mux.HandleFunc("/api/v1/zanshin/status", h.handleZanshinStatus)
+	auth, err := newAPIAuthenticator(h.cfg.Auth)
+	if err != nil {
+		return fmt.Errorf("configure server auth: %w", err)
+	}
+	h.auth = auth
 
 	readTimeout, err := config.DurationOrDefault(h.cfg.ReadTimeout, config.DefaultServerReadTimeout)
 	if err != nil {
@@ -103,18 +112,56 @@ func (h *HTTPServerComponent) Init(ctx context.Context) error {
 
 	h.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", h.cfg.Port),
-		Handler:      mux,
+		Handler:      h.requireAuth(h.routes()),
 		ReadTimeout:  readTimeout,
 		WriteTimeout: writeTimeout,
 		IdleTimeout:  idleTimeout,
 	}
 	h.shutdownTTL = shutdownTimeout
+	streamCtx, stopStreams := context.WithCancel(context.Background())
+	h.streamCtx = streamCtx
+	h.server.RegisterOnShutdown(stopStreams)
 
 	h.initialized = true
-	slog.Info("HTTPServer initialized", "component", h.Name(), "port", h.cfg.Port)
+	slog.Info("HTTPServer initialized", "component", h.Name(), "port", h.cfg.Port, "auth", h.auth != nil)
 	return nil
 }
 
+// routes builds the API mux. Paths documented in openapi.json must be
+// registered here.
+func (h *HTTPServerComponent) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", h.handleHealth)
+	mux.HandleFunc("/api/v1/openapi.json", h.handleOpenAPI)
+	mux.HandleFunc("/api/v1/events", h.handleEvents)
+	mux.HandleFunc("/api/v1/events/batch", h.handleEventBatch)
+	mux.HandleFunc("/api/v1/events/", h.handleEventStatus)
+	mux.HandleFunc("/api/v1/sessions", h.handleSessions)
+	mux.HandleFunc("/api/v1/sessions/", h.handleSessions)
+	mux.HandleFunc("/api/v1/approvals", h.handleApprovals)
+	mux.HandleFunc("/api/v1/approvals/", h.handleApprovals)
+	mux.HandleFunc("/api/v1/zanshin/status", h.handleZanshinStatus)
+	mux.HandleFunc("/api/v1/metrics", h.handleMetrics)
+	mux.HandleFunc("/api/v1/store/stats", h.handleStoreStats)
+	mux.HandleFunc("/api/v1/batches", h.handleBatches)
+	mux.HandleFunc("/api/v1/batches/", h.handleBatch)
+	mux.HandleFunc("/api/v1/adapters", h.handleAdapters)
+	mux.HandleFunc("/api/v1/adapters/", h.handleAdapters)
+	mux.HandleFunc("/api/v1/features", h.handleFeatures)
+	mux.HandleFunc("/api/v1/features/", h.handleFeatures)
+	mux.HandleFunc("/api/v1/vectors/reembed", h.handleReembed)
+	mux.HandleFunc("/api/v1/vectors/backfill", h.handleMemoryBackfill)
+	if h.cfg.Debug {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.HandleFunc("/api/v1/debug/runtime", h.handleDebugRuntime)
+	}
+	return mux
+}
+
 func (h *HTTPServerComponent) Start(ctx context.Context) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -188,7 +235,7 @@ func (h *HTTPServerComponent) Health(ctx context.Context) (*daemon.ComponentHeal
 
 func (h *HTTPServerComponent) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		writeMethodNotAllowed(w)
 		return
 	}
 
@@ -208,95 +255,532 @@ func (h *HTTPServerComponent) handleHealth(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	if h.runtime != nil {
+		if circuits := h.runtime.ModelCircuits(r.Context()); circuits != nil {
+			componentHealthMap["ModelRouter"] = modelRouterHealth(circuits)
+		}
+	}
+
 	healthResponse["components"] = componentHealthMap
+	if h.runtime != nil {
+		healthResponse["adapters"] = h.runtime.AdapterStatuses(r.Context())
+	}
 	writeJSON(w, http.StatusOK, healthResponse)
 }
 
+// modelRouterHealth reports the router as unhealthy only when every model's
+// circuit is open, since a single open circuit still leaves a fallback.
+func modelRouterHealth(circuits map[string]daemon.RuntimeModelCircuit) map[string]interface{} {
+	healthy := len(circuits) == 0
+	for _, c := range circuits {
+		if c.State != "open" {
+			healthy = true
+			break
+		}
+	}
+	entry := map[string]interface{}{
+		"healthy":  healthy,
+		"circuits": circuits,
+	}
+	if !healthy {
+		entry["error"] = "all model circuits open"
+	}
+	return entry
+}
+
 type eventRequest struct {
 	Source    string            `json:"source"`
 	Type      string            `json:"type"`
 	SessionID string            `json:"session_id"`
 	Content   string            `json:"content"`
 	Metadata  map[string]string `json:"metadata"`
+	// CallbackURL receives the signed turn result once the event finishes.
+	CallbackURL string `json:"callback_url"`
+	// NotifyURL receives the session's approval-required notifications.
+	NotifyURL string `json:"notify_url"`
 }
 
+func (req eventRequest) runtimeEvent(idempotencyKey string) daemon.RuntimeEvent {
+	return daemon.RuntimeEvent{
+		Source:         strings.TrimSpace(req.Source),
+		Type:           strings.TrimSpace(req.Type),
+		SessionID:      strings.TrimSpace(req.SessionID),
+		Content:        req.Content,
+		Metadata:       req.Metadata,
+		IdempotencyKey: idempotencyKey,
+		CallbackURL:    strings.TrimSpace(req.CallbackURL),
+		NotifyURL:      strings.TrimSpace(req.NotifyURL),
+	}
+}
+
+const maxIdempotencyKeyLength = 255
+
 func (h *HTTPServerComponent) handleEvents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		writeMethodNotAllowed(w)
+		return
+	}
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		writeErrorDetails(w, http.StatusBadRequest, errCodeInvalidInput, fmt.Sprintf("Idempotency-Key exceeds %d characters", maxIdempotencyKeyLength), map[string]interface{}{"max_length": maxIdempotencyKeyLength})
 		return
 	}
 	var req eventRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid request body"})
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
 		return
 	}
-	id, err := h.runtime.SubmitEvent(r.Context(), daemon.RuntimeEvent{
-		Source:    strings.TrimSpace(req.Source),
-		Type:      strings.TrimSpace(req.Type),
-		SessionID: strings.TrimSpace(req.SessionID),
-		Content:   req.Content,
-		Metadata:  req.Metadata,
-	})
+	if scope := eventContentScope(r.Context(), req.Content); scope != "" {
+		writeErrorDetails(w, http.StatusForbidden, errCodePermissionDenied, fmt.Sprintf("%s scope required", scope), map[string]interface{}{"scope": scope})
+		return
+	}
+	id, err := h.runtime.SubmitEvent(r.Context(), req.runtimeEvent(idempotencyKey))
 	if err != nil {
 		switch {
 		case errors.Is(err, heikeErrors.ErrDuplicateEvent):
 			writeJSON(w, http.StatusOK, map[string]interface{}{"status": "duplicate", "id": id})
 		case errors.Is(err, heikeErrors.ErrTransient):
-			writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{"error": "queue full"})
+			writeError(w, http.StatusTooManyRequests, errCodeQueueFull, "queue full")
 		default:
-			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, err.Error())
 		}
 		return
 	}
 	writeJSON(w, http.StatusAccepted, map[string]interface{}{"status": "accepted", "id": id})
 }
 
+type batchEventRequest struct {
+	eventRequest
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+type batchEventResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	// Code and Error describe a rejection, as in an error response.
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Retryable marks rejections caused by queue backpressure.
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// handleEventBatch submits each event independently, so one rejected item
+// (invalid, or dropped because its queue is full) does not fail the batch.
+func (h *HTTPServerComponent) handleEventBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	var req struct {
+		Events []batchEventRequest `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+		return
+	}
+	maxEvents := h.cfg.MaxBatchEvents
+	if maxEvents <= 0 {
+		maxEvents = config.DefaultServerMaxBatchEvents
+	}
+	if len(req.Events) == 0 {
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "events are required")
+		return
+	}
+	if len(req.Events) > maxEvents {
+		writeErrorDetails(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, fmt.Sprintf("batch exceeds %d events", maxEvents), map[string]interface{}{"max_events": maxEvents})
+		return
+	}
+
+	results := make([]batchEventResult, len(req.Events))
+	counts := map[string]int{"accepted": 0, "duplicate": 0, "rejected": 0}
+	for i, item := range req.Events {
+		results[i] = h.submitBatchEvent(r.Context(), i, item)
+		counts[results[i].Status]++
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"accepted":  counts["accepted"],
+		"duplicate": counts["duplicate"],
+		"rejected":  counts["rejected"],
+		"results":   results,
+	})
+}
+
+func (h *HTTPServerComponent) submitBatchEvent(ctx context.Context, index int, item batchEventRequest) batchEventResult {
+	result := batchEventResult{Index: index}
+	key := strings.TrimSpace(item.IdempotencyKey)
+	if len(key) > maxIdempotencyKeyLength {
+		result.Status = "rejected"
+		result.Code = errCodeInvalidInput
+		result.Error = fmt.Sprintf("idempotency_key exceeds %d characters", maxIdempotencyKeyLength)
+		return result
+	}
+	if scope := eventContentScope(ctx, item.Content); scope != "" {
+		result.Status = "rejected"
+		result.Code = errCodePermissionDenied
+		result.Error = fmt.Sprintf("%s scope required", scope)
+		return result
+	}
+
+	id, err := h.runtime.SubmitEvent(ctx, item.runtimeEvent(key))
+	switch {
+	case err == nil:
+		result.Status = "accepted"
+		result.ID = id
+	case errors.Is(err, heikeErrors.ErrDuplicateEvent):
+		result.Status = "duplicate"
+		result.ID = id
+	case errors.Is(err, heikeErrors.ErrTransient):
+		result.Status = "rejected"
+		result.Code = errCodeQueueFull
+		result.Error = "queue full"
+		result.Retryable = true
+	default:
+		result.Status = "rejected"
+		result.Code = errCodeInvalidInput
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// /api/v1/events/{id}
+func (h *HTTPServerComponent) handleEventStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	eventID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/events/"), "/")
+	if eventID == "" || strings.Contains(eventID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	status, err := h.runtime.EventStatus(r.Context(), eventID)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
 func (h *HTTPServerComponent) handleSessions(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/api/v1/sessions" {
+		if r.Method == http.MethodPost {
+			h.createSession(w, r)
+			return
+		}
 		if r.Method != http.MethodGet {
-			writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+			writeMethodNotAllowed(w)
+			return
+		}
+		lq, err := parseListQuery(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, err.Error())
 			return
 		}
 		sessions, err := h.runtime.ListSessions(r.Context())
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+			writeRuntimeError(w, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+		matched := make([]daemon.RuntimeSession, 0, len(sessions))
+		for _, sess := range sessions {
+			if lq.matchStatus(sess.Status) && !sess.UpdatedAt.Before(lq.UpdatedSince) {
+				matched = append(matched, sess)
+			}
+		}
+		start, end, resp := lq.page(len(matched))
+		resp["sessions"] = matched[start:end]
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") && strings.HasSuffix(r.URL.Path, "/context") {
+		h.handleSessionContext(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") && strings.HasSuffix(r.URL.Path, "/tasks") {
+		h.handleSessionTasks(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") && strings.HasSuffix(r.URL.Path, "/tools") {
+		h.handleSessionTools(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") && strings.HasSuffix(r.URL.Path, "/cancel") {
+		h.handleSessionCancel(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") && strings.HasSuffix(r.URL.Path, "/merge") {
+		h.handleSessionMerge(w, r)
+		return
+	}
+
+	if id := strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"); id != "" && !strings.Contains(id, "/") {
+		h.handleSession(w, r, id)
 		return
 	}
 
-	// /api/v1/sessions/{id}/stream
-	if !strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") || !strings.HasSuffix(r.URL.Path, "/stream") {
-		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": "not found"})
+	// /api/v1/sessions/{id}/stream and /api/v1/sessions/{id}/ws
+	suffix := ""
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/stream"):
+		suffix = "/stream"
+	case strings.HasSuffix(r.URL.Path, "/ws"):
+		suffix = "/ws"
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sessions/") || suffix == "" {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 		return
 	}
 	if r.Method != http.MethodGet {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		writeMethodNotAllowed(w)
 		return
 	}
 	raw := strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/")
-	sessionID := strings.TrimSuffix(raw, "/stream")
+	sessionID := strings.TrimSuffix(raw, suffix)
 	sessionID = strings.Trim(sessionID, "/")
 	if sessionID == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "session id is required"})
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "session id is required")
+		return
+	}
+	if suffix == "/ws" {
+		h.streamSessionWebSocket(w, r, sessionID)
 		return
 	}
 	h.streamSession(w, r, sessionID)
 }
 
+// createSession registers an empty session for POST /api/v1/sessions. No
+// ingress event is emitted; the session is only written to the store.
+func (h *HTTPServerComponent) createSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID       string            `json:"id"`
+		Title    string            `json:"title"`
+		Metadata map[string]string `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+		return
+	}
+	sess, err := h.runtime.CreateSession(r.Context(), daemon.RuntimeSession{ID: req.ID, Title: req.Title, Metadata: req.Metadata})
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sess)
+}
+
+// /api/v1/sessions/{id} renames a session or changes its metadata (PATCH),
+// and removes it or clears its transcript (DELETE, ?reset=true).
+func (h *HTTPServerComponent) handleSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	switch r.Method {
+	case http.MethodPatch:
+		var req struct {
+			Title    *string            `json:"title"`
+			Metadata map[string]*string `json:"metadata"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+			return
+		}
+		sess, err := h.runtime.UpdateSession(r.Context(), sessionID, daemon.RuntimeSessionUpdate{Title: req.Title, Metadata: req.Metadata})
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, sess)
+	case http.MethodDelete:
+		reset := false
+		if raw := r.URL.Query().Get("reset"); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errCodeInvalidInput, "reset must be a boolean")
+				return
+			}
+			reset = v
+		}
+		if err := h.runtime.DeleteSession(r.Context(), sessionID, reset); err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		status := "deleted"
+		if reset {
+			status = "reset"
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": status, "id": sessionID})
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// handleSessionCancel stops the task running in a session for POST
+// /api/v1/sessions/{id}/cancel. Cancellation is asynchronous: the turn ends
+// with a done event in state cancelled.
+func (h *HTTPServerComponent) handleSessionCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	sessionID := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"), "/cancel"), "/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	if err := h.runtime.CancelSession(r.Context(), sessionID); err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "cancelling", "id": sessionID})
+}
+
+// handleSessionMerge merges the session named by {"source": id} into
+// /api/v1/sessions/{id}/merge and archives the source.
+func (h *HTTPServerComponent) handleSessionMerge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	sessionID := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"), "/merge"), "/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+		return
+	}
+	merged, err := h.runtime.MergeSession(r.Context(), sessionID, req.Source)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, merged)
+}
+
+// sessionTaskReport is a task_result event as returned by
+// /api/v1/sessions/{id}/tasks.
+type sessionTaskReport struct {
+	ID        string          `json:"id"`
+	Timestamp time.Time       `json:"ts"`
+	Report    json.RawMessage `json:"report"`
+}
+
+// /api/v1/sessions/{id}/tasks lists the sub-task reports of the session's
+// decomposed goals, oldest first.
+func (h *HTTPServerComponent) handleSessionTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	sessionID := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"), "/tasks"), "/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	lines, err := h.runtime.ReadTranscript(r.Context(), sessionID, 0)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	tasks := make([]sessionTaskReport, 0)
+	for _, line := range lines {
+		var evt struct {
+			ID        string    `json:"id"`
+			Timestamp time.Time `json:"ts"`
+			Type      string    `json:"type"`
+			Metadata  struct {
+				Report json.RawMessage `json:"report"`
+			} `json:"metadata"`
+		}
+		if json.Unmarshal([]byte(line), &evt) != nil || evt.Type != sseEventTaskResult || len(evt.Metadata.Report) == 0 {
+			continue
+		}
+		tasks = append(tasks, sessionTaskReport{ID: evt.ID, Timestamp: evt.Timestamp, Report: evt.Metadata.Report})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tasks": tasks})
+}
+
+// /api/v1/sessions/{id}/tools explains the tool selection of the session's
+// last turn, as /why-tools does in chat.
+func (h *HTTPServerComponent) handleSessionTools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	sessionID := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"), "/tools"), "/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	selection, err := h.runtime.ToolSelection(r.Context(), sessionID)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, selection)
+}
+
+// /api/v1/sessions/{id}/context primes a session with context documents that
+// memory recall surfaces once a goal is submitted to it.
+func (h *HTTPServerComponent) handleSessionContext(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	sessionID := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/sessions/"), "/context"), "/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	var req struct {
+		Documents []daemon.RuntimeContextDocument `json:"documents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+		return
+	}
+	maxDocs := h.cfg.MaxContextDocuments
+	if maxDocs <= 0 {
+		maxDocs = config.DefaultServerMaxContextDocuments
+	}
+	if len(req.Documents) > maxDocs {
+		writeErrorDetails(w, http.StatusRequestEntityTooLarge, errCodePayloadTooLarge, fmt.Sprintf("request exceeds %d documents", maxDocs), map[string]interface{}{"max_documents": maxDocs})
+		return
+	}
+
+	result, err := h.runtime.InjectSessionContext(r.Context(), sessionID, req.Documents)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *HTTPServerComponent) streamSession(w http.ResponseWriter, r *http.Request, sessionID string) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": "streaming is not supported"})
+		writeError(w, http.StatusInternalServerError, errCodeInternal, "streaming is not supported")
 		return
 	}
 
+	// Event IDs are 1-based transcript line numbers, so resuming after ID n
+	// starts at line index n.
 	from := 0
 	if raw := strings.TrimSpace(r.URL.Query().Get("from")); raw != "" {
 		n, err := strconv.Atoi(raw)
 		if err != nil || n < 0 {
-			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid from query"})
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid from query")
+			return
+		}
+		from = n
+	}
+	if raw := strings.TrimSpace(r.Header.Get("Last-Event-ID")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid Last-Event-ID header")
 			return
 		}
 		from = n
@@ -307,62 +791,140 @@ func (h *HTTPServerComponent) streamSession(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Connection", "keep-alive")
 	w.WriteHeader(http.StatusOK)
 
-	writeSSE(w, "connected")
+	lines, err := h.runtime.WatchTranscript(r.Context(), sessionID, from)
+	if err != nil {
+		writeSSEEvent(w, "", sseEventStatus, transcriptErrorStatus(err))
+		flusher.Flush()
+		return
+	}
+	writeSSEEvent(w, "", sseEventStatus, `{"state":"connected"}`)
 	flusher.Flush()
 
-	ticker := time.NewTicker(400 * time.Millisecond)
-	defer ticker.Stop()
+	for line := range lines {
+		writeSSEEvent(w, strconv.Itoa(line.ID), sseEventName(line.Data), line.Data)
+		flusher.Flush()
+	}
+}
 
-	for {
-		select {
-		case <-r.Context().Done():
+// handleReembed starts re-embedding a vector collection with POST
+// {"collection": name} and reports its progress with GET ?collection=name.
+func (h *HTTPServerComponent) handleReembed(w http.ResponseWriter, r *http.Request) {
+	var (
+		job    daemon.RuntimeReembed
+		err    error
+		status = http.StatusOK
+	)
+	switch r.Method {
+	case http.MethodGet:
+		job, err = h.runtime.ReembedStatus(r.Context(), r.URL.Query().Get("collection"))
+	case http.MethodPost:
+		var req struct {
+			Collection string `json:"collection"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
 			return
-		case <-ticker.C:
-			lines, err := h.runtime.ReadTranscript(r.Context(), sessionID, 0)
-			if err != nil {
-				writeSSE(w, fmt.Sprintf("{\"error\":%q}", err.Error()))
-				flusher.Flush()
-				return
-			}
-			if from > len(lines) {
-				from = len(lines)
-			}
-			for ; from < len(lines); from++ {
-				writeSSE(w, lines[from])
-			}
-			flusher.Flush()
 		}
+		job, err = h.runtime.StartReembed(r.Context(), req.Collection)
+		status = http.StatusAccepted
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, status, job)
+}
+
+// handleMemoryBackfill starts embedding past transcripts into memory with
+// POST {"sessions", "source", "since"} and reports its progress with GET.
+func (h *HTTPServerComponent) handleMemoryBackfill(w http.ResponseWriter, r *http.Request) {
+	var (
+		job    daemon.RuntimeMemoryBackfill
+		err    error
+		status = http.StatusOK
+	)
+	switch r.Method {
+	case http.MethodGet:
+		job, err = h.runtime.MemoryBackfillStatus(r.Context())
+	case http.MethodPost:
+		var req daemon.RuntimeMemoryBackfillRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+			return
+		}
+		job, err = h.runtime.StartMemoryBackfill(r.Context(), req)
+		status = http.StatusAccepted
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
 	}
+	writeJSON(w, status, job)
+}
+
+// transcriptErrorStatus is the status event payload sent when a session
+// stream cannot follow the transcript.
+func transcriptErrorStatus(err error) string {
+	payload, _ := json.Marshal(map[string]string{"state": "error", "error": err.Error()})
+	return string(payload)
 }
 
 func (h *HTTPServerComponent) handleApprovals(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/api/v1/approvals" {
 		if r.Method != http.MethodGet {
-			writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+			writeMethodNotAllowed(w)
 			return
 		}
-		approvals, err := h.runtime.ListPendingApprovals(r.Context())
+		lq, err := parseListQuery(r)
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, err.Error())
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]interface{}{"approvals": approvals})
+		// Pending approvals only, unless a status filter asks otherwise;
+		// status=all lists every status.
+		statuses := lq.Statuses
+		switch {
+		case len(statuses) == 0:
+			statuses = []string{"pending"}
+		case len(statuses) == 1 && statuses[0] == "all":
+			statuses = nil
+		}
+		approvals, err := h.runtime.ListApprovals(r.Context(), statuses...)
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		matched := make([]daemon.RuntimeApproval, 0, len(approvals))
+		for _, app := range approvals {
+			if !app.CreatedAt.Before(lq.UpdatedSince) {
+				matched = append(matched, app)
+			}
+		}
+		start, end, resp := lq.page(len(matched))
+		resp["approvals"] = matched[start:end]
+		writeJSON(w, http.StatusOK, resp)
 		return
 	}
 
 	if !strings.HasPrefix(r.URL.Path, "/api/v1/approvals/") || !strings.HasSuffix(r.URL.Path, "/resolve") {
-		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": "not found"})
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
 		return
 	}
 	if r.Method != http.MethodPost {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		writeMethodNotAllowed(w)
 		return
 	}
 	raw := strings.TrimPrefix(r.URL.Path, "/api/v1/approvals/")
 	approvalID := strings.TrimSuffix(raw, "/resolve")
 	approvalID = strings.Trim(approvalID, "/")
 	if approvalID == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "approval id is required"})
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "approval id is required")
 		return
 	}
 
@@ -370,11 +932,11 @@ func (h *HTTPServerComponent) handleApprovals(w http.ResponseWriter, r *http.Req
 		Approve bool `json:"approve"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid request body"})
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
 		return
 	}
 	if err := h.runtime.ResolveApproval(r.Context(), approvalID, req.Approve); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "resolved", "id": approvalID, "approve": req.Approve})
@@ -382,18 +944,280 @@ func (h *HTTPServerComponent) handleApprovals(w http.ResponseWriter, r *http.Req
 
 func (h *HTTPServerComponent) handleZanshinStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"error": "method not allowed"})
+		writeMethodNotAllowed(w)
 		return
 	}
 	writeJSON(w, http.StatusOK, h.runtime.ZanshinStatus(r.Context()))
 }
 
+// handleOpenAPI serves the OpenAPI document for the API.
+func (h *HTTPServerComponent) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
+
+func (h *HTTPServerComponent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"counters": metrics.Snapshot()})
+}
+
+// handleDebugRuntime reports goroutines, queue depths, and scheduler leases
+// when server.debug is enabled.
+func (h *HTTPServerComponent) handleDebugRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	info, err := h.runtime.Debug(r.Context())
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *HTTPServerComponent) handleStoreStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	stats, err := h.runtime.StoreStats(r.Context())
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+type batchRequest struct {
+	Goals       []string `json:"goals"`
+	Concurrency int      `json:"concurrency"`
+}
+
+// /api/v1/batches
+func (h *HTTPServerComponent) handleBatches(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		batches, err := h.runtime.ListBatches(r.Context())
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"batches": batches})
+	case http.MethodPost:
+		var req batchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid request body")
+			return
+		}
+		b, err := h.runtime.SubmitBatch(r.Context(), req.Goals, req.Concurrency)
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, b)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// /api/v1/batches/{id} and /api/v1/batches/{id}/results?format=jsonl|csv
+func (h *HTTPServerComponent) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	raw := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/batches/"), "/")
+	batchID, results := strings.CutSuffix(raw, "/results")
+	if batchID == "" || strings.Contains(batchID, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	b, err := h.runtime.GetBatch(r.Context(), batchID)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	if !results {
+		writeJSON(w, http.StatusOK, b)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		enc := json.NewEncoder(w)
+		for _, item := range b.Items {
+			_ = enc.Encode(item)
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"index", "goal", "status", "result", "error", "session_id"})
+		for _, item := range b.Items {
+			_ = cw.Write([]string{strconv.Itoa(item.Index), item.Goal, item.Status, item.Result, item.Error, item.SessionID})
+		}
+		cw.Flush()
+	default:
+		writeError(w, http.StatusBadRequest, errCodeInvalidInput, fmt.Sprintf("unsupported format %q (use jsonl or csv)", format))
+	}
+}
+
+// handleFeatures lists feature flags and overrides one at runtime:
+// PUT /api/v1/features/{name} with {"enabled": bool} sets an override and
+// DELETE removes it.
+// handleAdapters lists adapters and enables, disables or reloads them
+// without restarting the daemon.
+func (h *HTTPServerComponent) handleAdapters(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/adapters" {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w)
+			return
+		}
+		adapters, err := h.runtime.ListAdapters(r.Context())
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"adapters": adapters})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/adapters/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "reload":
+		result, err := h.runtime.ReloadAdapters(r.Context())
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	case len(parts) == 2 && parts[0] != "" && (parts[1] == "enable" || parts[1] == "disable"):
+		status, err := h.runtime.SetAdapterEnabled(r.Context(), parts[0], parts[1] == "enable")
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	default:
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+	}
+}
+
+func (h *HTTPServerComponent) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v1/features" {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w)
+			return
+		}
+		flags, err := h.runtime.FeatureFlags(r.Context())
+		if err != nil {
+			writeRuntimeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"features": flags})
+		return
+	}
+
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/features/"), "/")
+	if name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, errCodeNotFound, "not found")
+		return
+	}
+	var enabled *bool
+	switch r.Method {
+	case http.MethodPut:
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "request body must set enabled")
+			return
+		}
+		enabled = req.Enabled
+	case http.MethodDelete:
+	default:
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	flag, err := h.runtime.SetFeatureFlag(r.Context(), name, enabled)
+	if err != nil {
+		writeRuntimeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, flag)
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeSSE(w http.ResponseWriter, data string) {
-	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+// SSE event names sent on the session stream.
+const (
+	sseEventMessage          = "message"
+	sseEventToolCall         = "tool_call"
+	sseEventToolProgress     = "tool_progress"
+	sseEventToolResult       = "tool_result"
+	sseEventApprovalRequired = "approval_required"
+	sseEventStatus           = "status"
+	sseEventDone             = "done"
+	sseEventDelta            = "delta"
+	sseEventTaskResult       = "task_result"
+	sseEventExecutionReport  = "execution_report"
+)
+
+// sseEventName maps a transcript line to its SSE event name. Debug steps are
+// sent as status; lines that do not parse are sent as messages.
+func sseEventName(line string) string {
+	var evt struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal([]byte(line), &evt)
+	switch evt.Type {
+	case "tool_call":
+		return sseEventToolCall
+	case "tool_progress":
+		return sseEventToolProgress
+	case "tool", "tool_result":
+		return sseEventToolResult
+	case "approval_required":
+		return sseEventApprovalRequired
+	case "status", "debug":
+		return sseEventStatus
+	case "done":
+		return sseEventDone
+	case "delta":
+		return sseEventDelta
+	case "task_result":
+		return sseEventTaskResult
+	case "execution_report":
+		return sseEventExecutionReport
+	default:
+		return sseEventMessage
+	}
+}
+
+// writeSSEEvent writes one SSE event. An empty id leaves the client's last
+// event ID unchanged.
+func writeSSEEvent(w http.ResponseWriter, id, event, data string) {
+	if id != "" {
+		_, _ = fmt.Fprintf(w, "id: %s\n", id)
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
 }
@@ -1,9 +1,27 @@
 package components
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon"
+	"github.com/harunnryd/heike/internal/egress"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/orchestrator"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/skill"
+	"github.com/harunnryd/heike/internal/store"
+	"github.com/harunnryd/heike/internal/tool"
 )
 
 func TestNewHTTPServerComponent_DefaultDependencies(t *testing.T) {
@@ -40,3 +58,980 @@ func TestNewHTTPServerComponentWithDependencies_Copy(t *testing.T) {
 		t.Fatal("Dependencies() must return a copy")
 	}
 }
+
+type batchRuntime struct {
+	daemon.RuntimeAPI
+	submitted []daemon.RuntimeEvent
+}
+
+func (r *batchRuntime) SubmitEvent(ctx context.Context, evt daemon.RuntimeEvent) (string, error) {
+	r.submitted = append(r.submitted, evt)
+	switch evt.Content {
+	case "dup":
+		return "evt_original", heikeErrors.ErrDuplicateEvent
+	case "full":
+		return "", heikeErrors.ErrTransient
+	case "":
+		return "", fmt.Errorf("event content is required")
+	}
+	return fmt.Sprintf("evt_%d", len(r.submitted)), nil
+}
+
+func TestHandleEventBatch_PerItemResults(t *testing.T) {
+	runtime := &batchRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{MaxBatchEvents: 4}}
+
+	body := `{"events":[
+		{"source":"import","content":"hello","idempotency_key":"k1"},
+		{"source":"import","content":"dup"},
+		{"source":"import","content":"full"},
+		{"source":"import","content":""}
+	]}`
+	rec := httptest.NewRecorder()
+	h.handleEventBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/events/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Accepted  int                `json:"accepted"`
+		Duplicate int                `json:"duplicate"`
+		Rejected  int                `json:"rejected"`
+		Results   []batchEventResult `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Accepted != 1 || resp.Duplicate != 1 || resp.Rejected != 2 {
+		t.Fatalf("unexpected counts: %+v", resp)
+	}
+	want := []string{"accepted", "duplicate", "rejected", "rejected"}
+	for i, status := range want {
+		if resp.Results[i].Index != i || resp.Results[i].Status != status {
+			t.Fatalf("result[%d] = %+v, want status %s", i, resp.Results[i], status)
+		}
+	}
+	if !resp.Results[2].Retryable || resp.Results[3].Retryable {
+		t.Fatalf("only queue-full rejections should be retryable: %+v", resp.Results)
+	}
+	if resp.Results[1].ID != "evt_original" {
+		t.Fatalf("duplicate should report original id, got %q", resp.Results[1].ID)
+	}
+	if runtime.submitted[0].IdempotencyKey != "k1" {
+		t.Fatalf("idempotency key not forwarded: %+v", runtime.submitted[0])
+	}
+}
+
+func TestHandleEvents_ForwardsCallbackURL(t *testing.T) {
+	runtime := &batchRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	body := `{"source":"ci","content":"hello","callback_url":" https://hooks.example.com/heike ","notify_url":"https://hooks.example.com/approvals"}`
+	rec := httptest.NewRecorder()
+	h.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if got := runtime.submitted[0].CallbackURL; got != "https://hooks.example.com/heike" {
+		t.Fatalf("callback url = %q", got)
+	}
+	if got := runtime.submitted[0].NotifyURL; got != "https://hooks.example.com/approvals" {
+		t.Fatalf("notify url = %q", got)
+	}
+}
+
+func TestHandleEvents_ErrorEnvelope(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &batchRuntime{}, cfg: &config.ServerConfig{}}
+
+	tests := []struct {
+		content   string
+		status    int
+		code      string
+		retryable bool
+	}{
+		{content: "full", status: http.StatusTooManyRequests, code: errCodeQueueFull, retryable: true},
+		{content: "", status: http.StatusBadRequest, code: errCodeInvalidInput},
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"source":"ci","content":%q}`, tt.content)
+		rec := httptest.NewRecorder()
+		h.handleEvents(rec, httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body)))
+		if rec.Code != tt.status {
+			t.Fatalf("content %q: status = %d, want %d", tt.content, rec.Code, tt.status)
+		}
+		var resp struct {
+			Error apiError `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if resp.Error.Code != tt.code || resp.Error.Retryable != tt.retryable || resp.Error.Message == "" {
+			t.Fatalf("content %q: error = %+v", tt.content, resp.Error)
+		}
+	}
+}
+
+func TestErrorStatus_MapsCategories(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("wrap: %w", heikeErrors.ErrNotFound), http.StatusNotFound, errCodeNotFound},
+		{heikeErrors.ErrInvalidInput, http.StatusBadRequest, errCodeInvalidInput},
+		{heikeErrors.ErrRateLimited, http.StatusTooManyRequests, errCodeRateLimited},
+		{heikeErrors.ErrTransient, http.StatusServiceUnavailable, errCodeUnavailable},
+		{fmt.Errorf("boom"), http.StatusInternalServerError, errCodeInternal},
+	}
+	for _, tt := range tests {
+		status, code := errorStatus(tt.err)
+		if status != tt.status || code != tt.code {
+			t.Fatalf("errorStatus(%v) = %d %s, want %d %s", tt.err, status, code, tt.status, tt.code)
+		}
+	}
+}
+
+func TestHandleEventBatch_Limit(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &batchRuntime{}, cfg: &config.ServerConfig{MaxBatchEvents: 1}}
+
+	body := `{"events":[{"source":"a","content":"1"},{"source":"a","content":"2"}]}`
+	rec := httptest.NewRecorder()
+	h.handleEventBatch(rec, httptest.NewRequest(http.MethodPost, "/api/v1/events/batch", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+}
+
+type goalBatchRuntime struct {
+	daemon.RuntimeAPI
+	batch daemon.RuntimeBatch
+}
+
+func (r *goalBatchRuntime) SubmitBatch(ctx context.Context, goals []string, concurrency int) (daemon.RuntimeBatch, error) {
+	if len(goals) == 0 {
+		return daemon.RuntimeBatch{}, heikeErrors.InvalidInput("batch has no goals")
+	}
+	r.batch = daemon.RuntimeBatch{ID: "b1", Status: "running", Concurrency: concurrency}
+	for i, goal := range goals {
+		r.batch.Items = append(r.batch.Items, daemon.RuntimeBatchItem{Index: i, Goal: goal, Status: "pending"})
+	}
+	return r.batch, nil
+}
+
+func (r *goalBatchRuntime) GetBatch(ctx context.Context, batchID string) (daemon.RuntimeBatch, error) {
+	if batchID != r.batch.ID {
+		return daemon.RuntimeBatch{}, heikeErrors.NotFound("batch " + batchID)
+	}
+	return r.batch, nil
+}
+
+func TestHandleBatches_SubmitAndExport(t *testing.T) {
+	runtime := &goalBatchRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleBatches(rec, httptest.NewRequest(http.MethodPost, "/api/v1/batches", strings.NewReader(`{"goals":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty batch status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleBatches(rec, httptest.NewRequest(http.MethodPost, "/api/v1/batches", strings.NewReader(`{"goals":["a","b, \"quoted\""],"concurrency":2}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("submit status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	runtime.batch.Items[0].Status = "completed"
+	runtime.batch.Items[0].Result = "done"
+
+	rec = httptest.NewRecorder()
+	h.handleBatch(rec, httptest.NewRequest(http.MethodGet, "/api/v1/batches/b1/results?format=csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("csv status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	want := "index,goal,status,result,error,session_id\n0,a,completed,done,,\n1,\"b, \"\"quoted\"\"\",pending,,,\n"
+	if rec.Body.String() != want {
+		t.Fatalf("csv = %q, want %q", rec.Body.String(), want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleBatch(rec, httptest.NewRequest(http.MethodGet, "/api/v1/batches/b1/results", nil))
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 2 {
+		t.Fatalf("jsonl lines = %d, want 2: %s", len(lines), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleBatch(rec, httptest.NewRequest(http.MethodGet, "/api/v1/batches/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing batch status = %d, want 404", rec.Code)
+	}
+}
+
+type contextRuntime struct {
+	daemon.RuntimeAPI
+	sessionID string
+	docs      []daemon.RuntimeContextDocument
+}
+
+func (r *contextRuntime) InjectSessionContext(ctx context.Context, sessionID string, docs []daemon.RuntimeContextDocument) (daemon.RuntimeContextResult, error) {
+	if len(docs) == 0 {
+		return daemon.RuntimeContextResult{}, heikeErrors.InvalidInput("documents are required")
+	}
+	r.sessionID = sessionID
+	r.docs = docs
+	return daemon.RuntimeContextResult{SessionID: sessionID, Documents: len(docs), Chunks: 3}, nil
+}
+
+func TestHandleSessions_InjectContext(t *testing.T) {
+	runtime := &contextRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{MaxContextDocuments: 2}}
+
+	body := `{"documents":[{"text":"Ticket 42: checkout fails","metadata":{"title":"JIRA-42"}},{"text":"Plan: enterprise"}]}`
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/context", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if runtime.sessionID != "sess-1" || len(runtime.docs) != 2 || runtime.docs[0].Metadata["title"] != "JIRA-42" {
+		t.Fatalf("unexpected injection: %s %+v", runtime.sessionID, runtime.docs)
+	}
+	var result daemon.RuntimeContextResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Chunks != 3 {
+		t.Fatalf("unexpected response %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/context", strings.NewReader(`{"documents":[]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("empty documents status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	tooMany := `{"documents":[{"text":"a"},{"text":"b"},{"text":"c"}]}`
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/context", strings.NewReader(tooMany)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("too many documents status = %d, want 413", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/context", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status = %d, want 405", rec.Code)
+	}
+}
+
+type transcriptRuntime struct {
+	daemon.RuntimeAPI
+	lines []string
+}
+
+func (r *transcriptRuntime) WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan daemon.RuntimeTranscriptLine, error) {
+	return watchLines(ctx, r.lines, from), nil
+}
+
+func (r *transcriptRuntime) ReadTranscript(ctx context.Context, sessionID string, limit int) ([]string, error) {
+	return r.lines, nil
+}
+
+// watchLines follows a transcript that holds lines and is not written to.
+func watchLines(ctx context.Context, lines []string, from int) <-chan daemon.RuntimeTranscriptLine {
+	out := make(chan daemon.RuntimeTranscriptLine)
+	go func() {
+		defer close(out)
+		for i := from; i < len(lines); i++ {
+			select {
+			case out <- daemon.RuntimeTranscriptLine{ID: i + 1, Data: lines[i]}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		<-ctx.Done()
+	}()
+	return out
+}
+
+func TestStreamSession_TypedEventsResumeFromLastEventID(t *testing.T) {
+	runtime := &transcriptRuntime{lines: []string{
+		`{"type":"user","role":"user","content":"weather?"}`,
+		`{"type":"status","role":"system","metadata":{"state":"processing"}}`,
+		`{"type":"tool_call","role":"system","tool_calls":[{"id":"c1","name":"weather","input":"{}"}]}`,
+		`{"type":"tool_progress","role":"tool","content":"fetching forecast","tool_call_id":"c1","metadata":{"seq":1}}`,
+		`{"type":"tool_result","role":"tool","content":"sunny","tool_call_id":"c1"}`,
+		`{"type":"approval_required","role":"system","metadata":{"approval_id":"a1"}}`,
+		`{"type":"assistant","role":"assistant","content":"It is sunny."}`,
+		`{"type":"done","role":"system","metadata":{"state":"completed"}}`,
+	}}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/stream", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, req)
+
+	body := rec.Body.String()
+	want := []string{
+		"event: status\ndata: {\"state\":\"connected\"}\n\n",
+		"id: 2\nevent: status\n",
+		"id: 3\nevent: tool_call\n",
+		"id: 4\nevent: tool_progress\n",
+		"id: 5\nevent: tool_result\n",
+		"id: 6\nevent: approval_required\n",
+		"id: 7\nevent: message\n",
+		"id: 8\nevent: done\n",
+	}
+	last := -1
+	for _, w := range want {
+		idx := strings.Index(body, w)
+		if idx <= last {
+			t.Fatalf("missing or out of order %q in stream:\n%s", w, body)
+		}
+		last = idx
+	}
+	if strings.Contains(body, "id: 1\n") {
+		t.Fatalf("events up to Last-Event-ID must not be replayed:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/stream", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	h.handleSessions(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid Last-Event-ID status = %d, want 400", rec.Code)
+	}
+}
+
+// discardEgress drops replies; stream clients read them from the transcript.
+type discardEgress struct {
+	egress.Egress
+}
+
+func (discardEgress) Send(ctx context.Context, sessionID, content string) error {
+	return nil
+}
+
+// storeRuntime streams the transcripts of a store worker.
+type storeRuntime struct {
+	daemon.RuntimeAPI
+	store *store.Worker
+}
+
+func (r *storeRuntime) WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan daemon.RuntimeTranscriptLine, error) {
+	lines, err := r.store.ReadTranscript(sessionID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return watchLines(ctx, lines, from), nil
+}
+
+func TestStreamSession_StreamsTokenDeltas(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fixture := filepath.Join(t.TempDir(), "fixture.yaml")
+	if err := os.WriteFile(fixture, []byte(`
+responses:
+  - match: "strategic planning agent"
+    content: '[{"id":1,"description":"Answer the question"}]'
+    repeat: true
+  - content: "The answer is 42."
+`), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	cfg := config.Config{
+		Models: config.ModelsConfig{
+			Default:   "mock-model",
+			Embedding: "mock-model",
+			Registry:  []config.ModelRegistry{{Name: "mock-model", Provider: "mock", Fixture: fixture}},
+		},
+		Orchestrator: config.OrchestratorConfig{MaxSubTasks: 5},
+	}
+
+	st, err := store.NewWorker("stream-deltas", "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("create store worker: %v", err)
+	}
+	st.Start()
+	defer st.Stop()
+	pol, err := policy.NewEngine(config.GovernanceConfig{}, "stream-deltas", "")
+	if err != nil {
+		t.Fatalf("policy.NewEngine() failed: %v", err)
+	}
+	kernel, err := orchestrator.NewKernel(cfg, st, tool.NewRunner(tool.NewRegistry(), pol), pol, skill.NewRegistry(), discardEgress{})
+	if err != nil {
+		t.Fatalf("NewKernel() failed: %v", err)
+	}
+	if err := kernel.Init(context.Background()); err != nil {
+		t.Fatalf("kernel Init() failed: %v", err)
+	}
+	evt := &ingress.Event{ID: "evt-1", SessionID: "sess-1", Type: ingress.TypeUserMessage, Content: "What is the answer?"}
+	if err := kernel.Execute(context.Background(), evt); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	h := &HTTPServerComponent{runtime: &storeRuntime{store: st}, cfg: &config.ServerConfig{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/stream", nil).WithContext(ctx))
+
+	body := rec.Body.String()
+	want := []string{
+		"event: status\n",
+		"event: delta\ndata: {",
+		`"content":"The answer is 42."`,
+		"event: message\n",
+		"event: done\n",
+	}
+	last := -1
+	for _, w := range want {
+		idx := strings.Index(body[last+1:], w)
+		if idx < 0 {
+			t.Fatalf("missing or out of order %q in stream:\n%s", w, body)
+		}
+		last += idx + 1
+	}
+}
+
+func TestHandleSessionTasks_ListsReports(t *testing.T) {
+	runtime := &transcriptRuntime{lines: []string{
+		`{"type":"user","role":"user","content":"ship it"}`,
+		`{"id":"r1","ts":"2026-01-02T03:04:05Z","type":"task_result","role":"system","content":"ship it","metadata":{"report":{"goal":"ship it","status":"partial","sub_tasks":[{"id":"deploy","status":"failed","retries":1}]}}}`,
+		`{"type":"assistant","role":"assistant","content":"Deploy failed."}`,
+	}}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/tasks", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Tasks []struct {
+			ID     string `json:"id"`
+			Report struct {
+				Status   string `json:"status"`
+				SubTasks []struct {
+					ID      string `json:"id"`
+					Retries int    `json:"retries"`
+				} `json:"sub_tasks"`
+			} `json:"report"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Tasks) != 1 || resp.Tasks[0].ID != "r1" || resp.Tasks[0].Report.Status != "partial" {
+		t.Fatalf("tasks = %+v", resp.Tasks)
+	}
+	if sub := resp.Tasks[0].Report.SubTasks; len(sub) != 1 || sub[0].ID != "deploy" || sub[0].Retries != 1 {
+		t.Fatalf("sub-tasks = %+v", sub)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/tasks", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status = %d, want 405", rec.Code)
+	}
+}
+
+type listRuntime struct {
+	daemon.RuntimeAPI
+	approvalStatuses []string
+}
+
+func (r *listRuntime) ListSessions(ctx context.Context) ([]daemon.RuntimeSession, error) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []daemon.RuntimeSession{
+		{ID: "s1", Status: "active", UpdatedAt: base},
+		{ID: "s2", Status: "archived", UpdatedAt: base.Add(time.Hour)},
+		{ID: "s3", Status: "active", UpdatedAt: base.Add(2 * time.Hour)},
+		{ID: "s4", Status: "active", UpdatedAt: base.Add(3 * time.Hour)},
+	}, nil
+}
+
+func (r *listRuntime) ListApprovals(ctx context.Context, statuses ...string) ([]daemon.RuntimeApproval, error) {
+	r.approvalStatuses = statuses
+	return []daemon.RuntimeApproval{{ID: "a2", Status: "GRANTED"}, {ID: "a1", Status: "PENDING"}}, nil
+}
+
+func TestHandleSessions_PaginatesAndFilters(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &listRuntime{}, cfg: &config.ServerConfig{}}
+	list := func(query string) (int, map[string]interface{}, []string) {
+		rec := httptest.NewRecorder()
+		h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions"+query, nil))
+		var resp struct {
+			Sessions []daemon.RuntimeSession `json:"sessions"`
+		}
+		var meta map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		_ = json.Unmarshal(rec.Body.Bytes(), &meta)
+		ids := make([]string, 0, len(resp.Sessions))
+		for _, sess := range resp.Sessions {
+			ids = append(ids, sess.ID)
+		}
+		return rec.Code, meta, ids
+	}
+
+	code, meta, ids := list("")
+	if code != http.StatusOK || len(ids) != 4 || meta["total"] != float64(4) || meta["next_offset"] != nil {
+		t.Fatalf("unpaged: %d %v %v", code, meta, ids)
+	}
+
+	_, meta, ids = list("?status=active&limit=2")
+	if strings.Join(ids, ",") != "s1,s3" || meta["total"] != float64(3) || meta["next_offset"] != float64(2) {
+		t.Fatalf("first page: %v %v", meta, ids)
+	}
+	_, meta, ids = list("?status=active&limit=2&offset=2")
+	if strings.Join(ids, ",") != "s4" || meta["next_offset"] != nil {
+		t.Fatalf("second page: %v %v", meta, ids)
+	}
+
+	_, _, ids = list("?updated_since=2026-01-01T01:30:00Z")
+	if strings.Join(ids, ",") != "s3,s4" {
+		t.Fatalf("updated_since: %v", ids)
+	}
+
+	for _, query := range []string{"?limit=0", "?offset=-1", "?updated_since=yesterday"} {
+		if code, _, _ := list(query); code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", query, code)
+		}
+	}
+}
+
+func TestHandleApprovals_StatusFilter(t *testing.T) {
+	runtime := &listRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	for query, want := range map[string][]string{
+		"":                 {"pending"},
+		"&status=all":      nil,
+		"&status=granted":  {"granted"},
+		"&status=GRANTED,": {"granted"},
+	} {
+		rec := httptest.NewRecorder()
+		h.handleApprovals(rec, httptest.NewRequest(http.MethodGet, "/api/v1/approvals?limit=1"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, body = %s", query, rec.Code, rec.Body.String())
+		}
+		if strings.Join(runtime.approvalStatuses, ",") != strings.Join(want, ",") {
+			t.Fatalf("%q: statuses = %v, want %v", query, runtime.approvalStatuses, want)
+		}
+	}
+}
+
+type sessionCRUDRuntime struct {
+	daemon.RuntimeAPI
+	created daemon.RuntimeSession
+	update  daemon.RuntimeSessionUpdate
+	reset   bool
+}
+
+func (r *sessionCRUDRuntime) CreateSession(ctx context.Context, sess daemon.RuntimeSession) (daemon.RuntimeSession, error) {
+	r.created = sess
+	sess.ID = "sess-new"
+	return sess, nil
+}
+
+func (r *sessionCRUDRuntime) UpdateSession(ctx context.Context, sessionID string, update daemon.RuntimeSessionUpdate) (daemon.RuntimeSession, error) {
+	if sessionID != "sess-1" {
+		return daemon.RuntimeSession{}, heikeErrors.NotFound("session " + sessionID)
+	}
+	r.update = update
+	return daemon.RuntimeSession{ID: sessionID, Title: *update.Title}, nil
+}
+
+func (r *sessionCRUDRuntime) DeleteSession(ctx context.Context, sessionID string, reset bool) error {
+	r.reset = reset
+	return nil
+}
+
+func TestHandleSessions_CRUD(t *testing.T) {
+	runtime := &sessionCRUDRuntime{}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions", strings.NewReader(`{"title":"Inbox","metadata":{"team":"ops"}}`)))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"id":"sess-new"`) {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	if runtime.created.Title != "Inbox" || runtime.created.Metadata["team"] != "ops" {
+		t.Fatalf("created = %+v", runtime.created)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/sessions/sess-1", strings.NewReader(`{"title":"Triage","metadata":{"team":null}}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: %d %s", rec.Code, rec.Body.String())
+	}
+	if v, ok := runtime.update.Metadata["team"]; !ok || v != nil {
+		t.Fatalf("null metadata should request removal: %+v", runtime.update.Metadata)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPatch, "/api/v1/sessions/sess-2", strings.NewReader(`{"title":"x"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("patch unknown: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/sessions/sess-1?reset=true", nil))
+	if rec.Code != http.StatusOK || !runtime.reset || !strings.Contains(rec.Body.String(), `"status":"reset"`) {
+		t.Fatalf("reset: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET session: %d, want 405", rec.Code)
+	}
+}
+
+func (r *sessionCRUDRuntime) CancelSession(ctx context.Context, sessionID string) error {
+	if sessionID != "sess-1" {
+		return heikeErrors.NotFound("running task for session " + sessionID)
+	}
+	return nil
+}
+
+func TestHandleSessions_Cancel(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &sessionCRUDRuntime{}, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/cancel", nil))
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"status":"cancelling"`) {
+		t.Fatalf("cancel: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-2/cancel", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("cancel idle session: %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/cancel", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET cancel: %d, want 405", rec.Code)
+	}
+}
+
+func (r *sessionCRUDRuntime) MergeSession(ctx context.Context, sessionID, sourceID string) (daemon.RuntimeSessionMerge, error) {
+	if sourceID != "sess-2" {
+		return daemon.RuntimeSessionMerge{}, heikeErrors.NotFound("session " + sourceID)
+	}
+	return daemon.RuntimeSessionMerge{Session: daemon.RuntimeSession{ID: sessionID}, Source: sourceID, Events: 4}, nil
+}
+
+func TestHandleSessions_Merge(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &sessionCRUDRuntime{}, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/merge", strings.NewReader(`{"source":"sess-2"}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"events":4`) || !strings.Contains(rec.Body.String(), `"id":"sess-1"`) {
+		t.Fatalf("merge: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodPost, "/api/v1/sessions/sess-1/merge", strings.NewReader(`{"source":"sess-3"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("merge unknown source: %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/merge", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET merge: %d, want 405", rec.Code)
+	}
+}
+
+type toolSelectionRuntime struct {
+	daemon.RuntimeAPI
+}
+
+func (r *toolSelectionRuntime) ToolSelection(ctx context.Context, sessionID string) (daemon.RuntimeToolSelection, error) {
+	if sessionID != "sess-1" {
+		return daemon.RuntimeToolSelection{}, heikeErrors.NotFound("tool selection for session " + sessionID)
+	}
+	return daemon.RuntimeToolSelection{
+		SessionID: sessionID,
+		Broker:    "heuristic",
+		Available: 12,
+		Tools:     []daemon.RuntimeToolSelectionDetail{{Name: "exec_command", Score: 7, Reasons: []string{"name_match"}}},
+	}, nil
+}
+
+func TestHandleSessionTools(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &toolSelectionRuntime{}, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/tools", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var sel daemon.RuntimeToolSelection
+	if err := json.Unmarshal(rec.Body.Bytes(), &sel); err != nil {
+		t.Fatal(err)
+	}
+	if sel.Broker != "heuristic" || len(sel.Tools) != 1 || sel.Tools[0].Score != 7 {
+		t.Fatalf("selection = %+v", sel)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-2/tools", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown session status = %d, want 404", rec.Code)
+	}
+}
+
+type featureRuntime struct {
+	daemon.RuntimeAPI
+	flags map[string]daemon.RuntimeFeatureFlag
+}
+
+func (r *featureRuntime) FeatureFlags(ctx context.Context) ([]daemon.RuntimeFeatureFlag, error) {
+	return []daemon.RuntimeFeatureFlag{r.flags["llm_tool_selection"]}, nil
+}
+
+func (r *featureRuntime) SetFeatureFlag(ctx context.Context, name string, enabled *bool) (daemon.RuntimeFeatureFlag, error) {
+	flag, ok := r.flags[name]
+	if !ok {
+		return daemon.RuntimeFeatureFlag{}, heikeErrors.NotFound("unknown feature flag")
+	}
+	if enabled == nil {
+		flag.Enabled, flag.Source = false, "default"
+	} else {
+		flag.Enabled, flag.Source = *enabled, "override"
+	}
+	r.flags[name] = flag
+	return flag, nil
+}
+
+func TestHandleFeatures_OverrideAndReset(t *testing.T) {
+	runtime := &featureRuntime{flags: map[string]daemon.RuntimeFeatureFlag{
+		"llm_tool_selection": {Name: "llm_tool_selection", Source: "default"},
+	}}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleFeatures(rec, httptest.NewRequest(http.MethodPut, "/api/v1/features/llm_tool_selection", strings.NewReader(`{"enabled":true}`)))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"source":"override"`) {
+		t.Fatalf("override status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleFeatures(rec, httptest.NewRequest(http.MethodGet, "/api/v1/features", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Fatalf("list status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleFeatures(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/features/llm_tool_selection", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"source":"default"`) {
+		t.Fatalf("reset status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleFeatures(rec, httptest.NewRequest(http.MethodPut, "/api/v1/features/llm_tool_selection", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing enabled status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleFeatures(rec, httptest.NewRequest(http.MethodPut, "/api/v1/features/nope", strings.NewReader(`{"enabled":true}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown flag status = %d, want 404", rec.Code)
+	}
+}
+
+type adapterRuntime struct {
+	daemon.RuntimeAPI
+	states map[string]string
+}
+
+func (r *adapterRuntime) ListAdapters(ctx context.Context) ([]daemon.RuntimeAdapterStatus, error) {
+	var out []daemon.RuntimeAdapterStatus
+	for name, state := range r.states {
+		out = append(out, daemon.RuntimeAdapterStatus{Name: name, State: state})
+	}
+	return out, nil
+}
+
+func (r *adapterRuntime) SetAdapterEnabled(ctx context.Context, name string, enabled bool) (daemon.RuntimeAdapterStatus, error) {
+	if _, ok := r.states[name]; !ok {
+		return daemon.RuntimeAdapterStatus{}, heikeErrors.NotFound("adapter " + name)
+	}
+	r.states[name] = "disabled"
+	if enabled {
+		r.states[name] = "connecting"
+	}
+	return daemon.RuntimeAdapterStatus{Name: name, State: r.states[name]}, nil
+}
+
+func (r *adapterRuntime) ReloadAdapters(ctx context.Context) (daemon.RuntimeAdapterReload, error) {
+	return daemon.RuntimeAdapterReload{Changed: []string{"telegram"}}, nil
+}
+
+func TestHandleAdapters(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &adapterRuntime{states: map[string]string{"slack": "connected"}}, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodPost, "/api/v1/adapters/slack/disable", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"state":"disabled"`) {
+		t.Fatalf("disable: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodGet, "/api/v1/adapters", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"adapters":[{"name":"slack","state":"disabled"`) {
+		t.Fatalf("list: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodPost, "/api/v1/adapters/irc/enable", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("enable unknown adapter: %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodPost, "/api/v1/adapters/reload", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"changed":["telegram"]`) {
+		t.Fatalf("reload: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodGet, "/api/v1/adapters/slack/enable", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET enable: %d, want 405", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleAdapters(rec, httptest.NewRequest(http.MethodPost, "/api/v1/adapters/slack/restart", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown action: %d, want 404", rec.Code)
+	}
+}
+
+type reembedRuntime struct {
+	daemon.RuntimeAPI
+	jobs map[string]daemon.RuntimeReembed
+}
+
+func (r *reembedRuntime) StartReembed(ctx context.Context, collection string) (daemon.RuntimeReembed, error) {
+	if collection == "" {
+		return daemon.RuntimeReembed{}, heikeErrors.InvalidInput("collection is required")
+	}
+	if job, ok := r.jobs[collection]; ok && job.Status == "running" {
+		return job, fmt.Errorf("already running: %w", heikeErrors.ErrConflict)
+	}
+	job := daemon.RuntimeReembed{Collection: collection, Status: "running"}
+	r.jobs[collection] = job
+	return job, nil
+}
+
+func (r *reembedRuntime) ReembedStatus(ctx context.Context, collection string) (daemon.RuntimeReembed, error) {
+	job, ok := r.jobs[collection]
+	if !ok {
+		return daemon.RuntimeReembed{}, heikeErrors.NotFound("no re-embed")
+	}
+	return job, nil
+}
+
+func TestHandleReembed_StartAndStatus(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &reembedRuntime{jobs: map[string]daemon.RuntimeReembed{}}, cfg: &config.ServerConfig{}}
+
+	rec := httptest.NewRecorder()
+	h.handleReembed(rec, httptest.NewRequest(http.MethodPost, "/api/v1/vectors/reembed", strings.NewReader(`{"collection":"memories"}`)))
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"status":"running"`) {
+		t.Fatalf("start status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleReembed(rec, httptest.NewRequest(http.MethodPost, "/api/v1/vectors/reembed", strings.NewReader(`{"collection":"memories"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second start status = %d, want 409", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleReembed(rec, httptest.NewRequest(http.MethodGet, "/api/v1/vectors/reembed?collection=memories", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"collection":"memories"`) {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleReembed(rec, httptest.NewRequest(http.MethodGet, "/api/v1/vectors/reembed?collection=docs", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown collection status = %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleReembed(rec, httptest.NewRequest(http.MethodPost, "/api/v1/vectors/reembed", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing collection status = %d, want 400", rec.Code)
+	}
+}
+
+type debugRuntime struct {
+	daemon.RuntimeAPI
+}
+
+func (r *debugRuntime) Debug(ctx context.Context) (daemon.RuntimeDebug, error) {
+	return daemon.RuntimeDebug{
+		Goroutines:      42,
+		StoreInboxDepth: 100,
+		IngressQueues:   map[string]int{"interactive": 3},
+		Scheduler: daemon.RuntimeSchedulerStatus{
+			Running: true,
+			Leases:  []daemon.RuntimeSchedulerLease{{TaskID: "digest", RunID: "r1", Status: "LEASED", Expired: true}},
+		},
+	}, nil
+}
+
+func TestHandleDebugRuntime(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &debugRuntime{}, cfg: &config.ServerConfig{Debug: true}}
+
+	rec := httptest.NewRecorder()
+	h.handleDebugRuntime(rec, httptest.NewRequest(http.MethodGet, "/api/v1/debug/runtime", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var got daemon.RuntimeDebug
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Goroutines != 42 || got.StoreInboxDepth != 100 || got.IngressQueues["interactive"] != 3 {
+		t.Fatalf("debug = %+v", got)
+	}
+	if leases := got.Scheduler.Leases; len(leases) != 1 || leases[0].TaskID != "digest" || !leases[0].Expired {
+		t.Fatalf("leases = %+v", leases)
+	}
+}
+
+func TestHandleOpenAPI_DocumentedPathsAreRouted(t *testing.T) {
+	h := &HTTPServerComponent{cfg: &config.ServerConfig{}}
+	mux := h.routes()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || len(spec.Paths) == 0 {
+		t.Fatalf("openapi = %q with %d paths", spec.OpenAPI, len(spec.Paths))
+	}
+
+	for path, ops := range spec.Paths {
+		concrete := strings.NewReplacer("{id}", "x", "{name}", "x").Replace(path)
+		for method := range ops {
+			req := httptest.NewRequest(strings.ToUpper(method), concrete, nil)
+			if _, pattern := mux.Handler(req); pattern == "" {
+				t.Errorf("%s %s is documented but not routed", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
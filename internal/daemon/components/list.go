@@ -0,0 +1,86 @@
+package components
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListLimit caps the page size of list endpoints.
+const maxListLimit = 500
+
+// listQuery holds the pagination and filter parameters shared by list
+// endpoints: limit, offset, status and updated_since.
+type listQuery struct {
+	// Limit is the page size; zero returns every item from Offset.
+	Limit        int
+	Offset       int
+	Statuses     []string
+	UpdatedSince time.Time
+}
+
+func parseListQuery(r *http.Request) (listQuery, error) {
+	q := r.URL.Query()
+	var lq listQuery
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return lq, fmt.Errorf("limit must be a positive integer")
+		}
+		lq.Limit = min(n, maxListLimit)
+	}
+	if raw := q.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return lq, fmt.Errorf("offset must be a non-negative integer")
+		}
+		lq.Offset = n
+	}
+	for _, status := range strings.Split(q.Get("status"), ",") {
+		if status = strings.ToLower(strings.TrimSpace(status)); status != "" {
+			lq.Statuses = append(lq.Statuses, status)
+		}
+	}
+	if raw := q.Get("updated_since"); raw != "" {
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return lq, fmt.Errorf("updated_since must be an RFC 3339 timestamp")
+		}
+		lq.UpdatedSince = ts
+	}
+	return lq, nil
+}
+
+// matchStatus reports whether status passes the status filter.
+func (lq listQuery) matchStatus(status string) bool {
+	if len(lq.Statuses) == 0 {
+		return true
+	}
+	status = strings.ToLower(status)
+	for _, want := range lq.Statuses {
+		if want == status {
+			return true
+		}
+	}
+	return false
+}
+
+// page returns the [start, end) bounds of the requested page of n items and
+// the page metadata to merge into the response.
+func (lq listQuery) page(n int) (int, int, map[string]interface{}) {
+	start := min(lq.Offset, n)
+	end := n
+	if lq.Limit > 0 {
+		end = min(start+lq.Limit, n)
+	}
+	meta := map[string]interface{}{"total": n, "offset": start}
+	if lq.Limit > 0 {
+		meta["limit"] = lq.Limit
+	}
+	if end < n {
+		meta["next_offset"] = end
+	}
+	return start, end, meta
+}
@@ -0,0 +1,135 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteTimeout bounds each frame written to a WebSocket client.
+	wsWriteTimeout = 10 * time.Second
+	// wsPongWait is how long a client may stay silent before the stream is
+	// closed; pings are sent well within it.
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+	// wsReadLimit caps client frames; clients only send control frames.
+	wsReadLimit = 4096
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// wsTranscriptEvent is one WebSocket text frame on the session stream. ID
+// and Event match the SSE stream; Data is the transcript line itself.
+type wsTranscriptEvent struct {
+	ID    int             `json:"id,omitempty"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// streamSessionWebSocket serves /api/v1/sessions/{id}/ws. Transcript lines
+// are pushed as they are written; the from query resumes after an event ID.
+func (h *HTTPServerComponent) streamSessionWebSocket(w http.ResponseWriter, r *http.Request, sessionID string) {
+	from := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("from")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errCodeInvalidInput, "invalid from query")
+			return
+		}
+		from = n
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error.
+		slog.Debug("WebSocket upgrade failed", "session", sessionID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Hijacked connections outlive server shutdown, so the stream also ends
+	// when the server stops.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	if h.streamCtx != nil {
+		stop := context.AfterFunc(h.streamCtx, cancel)
+		defer stop()
+	}
+
+	// Reading handles pongs and close frames and notices when the client
+	// goes away.
+	conn.SetReadLimit(wsReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	defer func() {
+		msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+	}()
+
+	lines, err := h.runtime.WatchTranscript(ctx, sessionID, from)
+	if err != nil {
+		_ = writeWSEvent(conn, wsTranscriptEvent{Event: sseEventStatus, Data: json.RawMessage(transcriptErrorStatus(err))})
+		return
+	}
+	if err := writeWSEvent(conn, wsTranscriptEvent{Event: sseEventStatus, Data: json.RawMessage(`{"state":"connected"}`)}); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			evt := wsTranscriptEvent{ID: line.ID, Event: sseEventName(line.Data), Data: wsEventData(line.Data)}
+			if err := writeWSEvent(conn, evt); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeWSEvent(conn *websocket.Conn, evt wsTranscriptEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(evt)
+}
+
+// wsEventData embeds a transcript line as JSON, quoting lines that do not
+// parse.
+func wsEventData(line string) json.RawMessage {
+	if json.Valid([]byte(line)) {
+		return json.RawMessage(line)
+	}
+	quoted, _ := json.Marshal(line)
+	return quoted
+}
@@ -0,0 +1,77 @@
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/daemon"
+)
+
+type pushRuntime struct {
+	daemon.RuntimeAPI
+	from  chan int
+	lines chan daemon.RuntimeTranscriptLine
+}
+
+func (r *pushRuntime) WatchTranscript(ctx context.Context, sessionID string, from int) (<-chan daemon.RuntimeTranscriptLine, error) {
+	r.from <- from
+	return r.lines, nil
+}
+
+func TestStreamSessionWebSocket_PushesTranscriptLines(t *testing.T) {
+	runtime := &pushRuntime{from: make(chan int, 1), lines: make(chan daemon.RuntimeTranscriptLine)}
+	h := &HTTPServerComponent{runtime: runtime, cfg: &config.ServerConfig{}}
+	server := httptest.NewServer(http.HandlerFunc(h.handleSessions))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/sessions/sess-1/ws?from=3"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if from := <-runtime.from; from != 3 {
+		t.Fatalf("from = %d, want 3", from)
+	}
+	var evt struct {
+		ID    int             `json:"id"`
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := conn.ReadJSON(&evt); err != nil || evt.Event != "status" || string(evt.Data) != `{"state":"connected"}` {
+		t.Fatalf("connected event = %+v, %v", evt, err)
+	}
+
+	runtime.lines <- daemon.RuntimeTranscriptLine{ID: 4, Data: `{"type":"tool_call","role":"system"}`}
+	if err := conn.ReadJSON(&evt); err != nil || evt.ID != 4 || evt.Event != "tool_call" || string(evt.Data) != `{"type":"tool_call","role":"system"}` {
+		t.Fatalf("tool_call event = %+v, %v", evt, err)
+	}
+	runtime.lines <- daemon.RuntimeTranscriptLine{ID: 5, Data: "not json"}
+	if err := conn.ReadJSON(&evt); err != nil || evt.Event != "message" || string(evt.Data) != `"not json"` {
+		t.Fatalf("raw line event = %+v, %v", evt, err)
+	}
+
+	close(runtime.lines)
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected normal close, got %v", err)
+	}
+}
+
+func TestStreamSessionWebSocket_RejectsInvalidFrom(t *testing.T) {
+	h := &HTTPServerComponent{runtime: &pushRuntime{}, cfg: &config.ServerConfig{}}
+	rec := httptest.NewRecorder()
+	h.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions/sess-1/ws?from=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,5 @@
+// Package heikev1 holds the generated gRPC bindings for the daemon runtime
+// API defined in proto/heike/v1/runtime.proto.
+package heikev1
+
+//go:generate protoc -I ../../../../proto --go_out=../../../.. --go_opt=module=github.com/harunnryd/heike --go-grpc_out=../../../.. --go-grpc_opt=module=github.com/harunnryd/heike heike/v1/runtime.proto
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/harunnryd/heike/internal/adapter"
 	"github.com/harunnryd/heike/internal/errors"
@@ -18,9 +19,22 @@ type Egress interface {
 	// Unregister removes an output adapter
 	Unregister(name string) error
 
-	// Send sends content to appropriate adapter based on session metadata
+	// Send sends content to the session source adapter and any additional
+	// egress targets registered in session metadata
 	Send(ctx context.Context, sessionID string, content string) error
 
+	// Targets returns the additional egress targets registered for a session
+	Targets(sessionID string) ([]Target, error)
+
+	// SetTargets replaces the additional egress targets for a session
+	SetTargets(sessionID string, targets []Target) error
+
+	// AddTarget registers an additional egress target for a session
+	AddTarget(sessionID string, target Target) error
+
+	// RemoveTarget removes all egress targets for the named adapter from a session
+	RemoveTarget(sessionID string, adapterName string) error
+
 	// Health checks egress health and all registered adapters
 	Health(ctx context.Context) error
 
@@ -105,9 +119,39 @@ func (e *DefaultEgress) Send(ctx context.Context, sessionID string, content stri
 	}
 
 	slog.Debug("Response sent", "session", sessionID, "source", source, "content_length", len(content))
+
+	e.fanOut(ctx, sessionID, sess.Metadata, content)
 	return nil
 }
 
+// fanOut delivers content to additional session targets. Failures are logged
+// and never fail the primary reply.
+func (e *DefaultEgress) fanOut(ctx context.Context, sessionID string, metadata map[string]string, content string) {
+	targets, err := TargetsFromMetadata(metadata)
+	if err != nil {
+		slog.Warn("Ignoring invalid egress targets", "session", sessionID, "error", err)
+		return
+	}
+
+	sentAt := time.Now()
+	for _, target := range targets {
+		adapter, err := e.getAdapter(target.Adapter)
+		if err != nil {
+			slog.Warn("Egress target adapter not registered", "session", sessionID, "adapter", target.Adapter)
+			continue
+		}
+		destination := target.Destination
+		if destination == "" {
+			destination = sessionID
+		}
+		if err := adapter.Send(ctx, destination, formatForTarget(target.Format, sessionID, content, sentAt)); err != nil {
+			slog.Warn("Failed to send to egress target", "session", sessionID, "adapter", target.Adapter, "error", err)
+			continue
+		}
+		slog.Debug("Response fanned out", "session", sessionID, "adapter", target.Adapter, "format", target.Format)
+	}
+}
+
 func (e *DefaultEgress) getAdapter(name string) (adapter.OutputAdapter, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
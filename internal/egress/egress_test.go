@@ -0,0 +1,160 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/store"
+)
+
+type recordingAdapter struct {
+	name    string
+	sendErr error
+	mu      sync.Mutex
+	sent    map[string]string
+}
+
+func newRecordingAdapter(name string) *recordingAdapter {
+	return &recordingAdapter{name: name, sent: make(map[string]string)}
+}
+
+func (a *recordingAdapter) Name() string { return a.name }
+
+func (a *recordingAdapter) Send(ctx context.Context, sessionID string, content string) error {
+	if a.sendErr != nil {
+		return a.sendErr
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent[sessionID] = content
+	return nil
+}
+
+func (a *recordingAdapter) Health(ctx context.Context) error { return nil }
+
+func setupWorker(t *testing.T) *store.Worker {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	worker, err := store.NewWorker("test", "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("create store worker: %v", err)
+	}
+	worker.Start()
+	t.Cleanup(worker.Stop)
+	return worker
+}
+
+func saveSession(t *testing.T, worker *store.Worker, id, source string) {
+	t.Helper()
+	if err := worker.SaveSession(&store.SessionMeta{
+		ID:        id,
+		Status:    "active",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Metadata:  map[string]string{"source": source},
+	}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+}
+
+func TestEgress_SendFansOutToTargets(t *testing.T) {
+	worker := setupWorker(t)
+	saveSession(t, worker, "C123", "slack")
+
+	slack := newRecordingAdapter("slack")
+	email := newRecordingAdapter("email")
+	eg := NewEgress(worker)
+	for _, a := range []*recordingAdapter{slack, email} {
+		if err := eg.Register(a); err != nil {
+			t.Fatalf("register %s: %v", a.name, err)
+		}
+	}
+
+	if err := eg.AddTarget("C123", Target{Adapter: "email", Destination: "ops@example.com", Format: "digest"}); err != nil {
+		t.Fatalf("add target: %v", err)
+	}
+
+	if err := eg.Send(context.Background(), "C123", "**Done**"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if got := slack.sent["C123"]; got != "**Done**" {
+		t.Fatalf("primary content = %q, want raw content", got)
+	}
+	digest := email.sent["ops@example.com"]
+	if !strings.Contains(digest, "session C123") || !strings.Contains(digest, "**Done**") {
+		t.Fatalf("digest content = %q", digest)
+	}
+}
+
+func TestEgress_TargetFailureDoesNotFailPrimary(t *testing.T) {
+	worker := setupWorker(t)
+	saveSession(t, worker, "s1", "cli")
+
+	cli := newRecordingAdapter("cli")
+	broken := newRecordingAdapter("broken")
+	broken.sendErr = errors.New("smtp down")
+	eg := NewEgress(worker)
+	_ = eg.Register(cli)
+	_ = eg.Register(broken)
+
+	if err := eg.SetTargets("s1", []Target{{Adapter: "broken"}, {Adapter: "missing"}}); err != nil {
+		t.Fatalf("set targets: %v", err)
+	}
+	if err := eg.Send(context.Background(), "s1", "hello"); err != nil {
+		t.Fatalf("send returned error: %v", err)
+	}
+	if cli.sent["s1"] != "hello" {
+		t.Fatalf("primary adapter did not receive content")
+	}
+}
+
+func TestEgress_TargetManagement(t *testing.T) {
+	worker := setupWorker(t)
+	saveSession(t, worker, "s1", "cli")
+	eg := NewEgress(worker)
+
+	if err := eg.AddTarget("s1", Target{Adapter: "slack", Format: "bogus"}); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+	if err := eg.AddTarget("s1", Target{Adapter: "slack", Format: "plain"}); err != nil {
+		t.Fatalf("add target: %v", err)
+	}
+	if err := eg.AddTarget("s1", Target{Adapter: "slack", Format: "plain"}); err != nil {
+		t.Fatalf("add duplicate target: %v", err)
+	}
+
+	targets, err := eg.Targets("s1")
+	if err != nil {
+		t.Fatalf("targets: %v", err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf("targets length = %d, want 1 after dedupe", len(targets))
+	}
+
+	if err := eg.RemoveTarget("s1", "slack"); err != nil {
+		t.Fatalf("remove target: %v", err)
+	}
+	sess, err := worker.GetSession("s1")
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if _, ok := sess.Metadata[TargetsMetadataKey]; ok {
+		t.Fatal("expected egress_targets metadata to be removed")
+	}
+	if sess.Metadata["source"] != "cli" {
+		t.Fatalf("source metadata = %q, want cli", sess.Metadata["source"])
+	}
+}
+
+func TestFormatForTarget_Plain(t *testing.T) {
+	got := formatForTarget(FormatPlain, "s1", "# Title\n**bold** and [docs](https://x.dev) `code`", time.Now())
+	want := "Title\nbold and docs (https://x.dev) code"
+	if got != want {
+		t.Fatalf("plain format = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package egress
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+// TargetsMetadataKey is the session metadata key holding additional egress targets as JSON.
+const TargetsMetadataKey = "egress_targets"
+
+const (
+	FormatRaw    = "raw"
+	FormatPlain  = "plain"
+	FormatDigest = "digest"
+)
+
+// Target is an additional delivery destination for a session's responses.
+// The session "source" adapter remains the primary target.
+type Target struct {
+	Adapter string `json:"adapter"`
+	// Destination is the adapter-specific recipient (channel, chat, address).
+	// When empty, the session ID is used.
+	Destination string `json:"destination,omitempty"`
+	Format      string `json:"format,omitempty"`
+}
+
+func (t Target) normalized() Target {
+	t.Adapter = strings.TrimSpace(t.Adapter)
+	t.Destination = strings.TrimSpace(t.Destination)
+	t.Format = strings.ToLower(strings.TrimSpace(t.Format))
+	if t.Format == "" {
+		t.Format = FormatRaw
+	}
+	return t
+}
+
+func (t Target) validate() error {
+	if t.Adapter == "" {
+		return errors.InvalidInput("egress target adapter is required")
+	}
+	switch t.Format {
+	case FormatRaw, FormatPlain, FormatDigest:
+		return nil
+	default:
+		return errors.InvalidInput("unsupported egress target format: " + t.Format)
+	}
+}
+
+func (t Target) key() string {
+	return t.Adapter + "\x00" + t.Destination
+}
+
+// TargetsFromMetadata decodes the egress targets stored in session metadata.
+func TargetsFromMetadata(metadata map[string]string) ([]Target, error) {
+	raw := strings.TrimSpace(metadata[TargetsMetadataKey])
+	if raw == "" {
+		return nil, nil
+	}
+	var targets []Target
+	if err := json.Unmarshal([]byte(raw), &targets); err != nil {
+		return nil, errors.InvalidInput("invalid egress_targets metadata: " + err.Error())
+	}
+	for i := range targets {
+		targets[i] = targets[i].normalized()
+	}
+	return targets, nil
+}
+
+func encodeTargets(targets []Target) (string, error) {
+	if len(targets) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(targets)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (e *DefaultEgress) Targets(sessionID string) ([]Target, error) {
+	sess, err := e.store.GetSession(sessionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get session")
+	}
+	if sess == nil {
+		return nil, errors.NotFound("session not found: " + sessionID)
+	}
+	return TargetsFromMetadata(sess.Metadata)
+}
+
+func (e *DefaultEgress) SetTargets(sessionID string, targets []Target) error {
+	sess, err := e.store.GetSession(sessionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get session")
+	}
+	if sess == nil {
+		return errors.NotFound("session not found: " + sessionID)
+	}
+
+	seen := make(map[string]struct{}, len(targets))
+	deduped := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		target = target.normalized()
+		if err := target.validate(); err != nil {
+			return err
+		}
+		if _, exists := seen[target.key()]; exists {
+			continue
+		}
+		seen[target.key()] = struct{}{}
+		deduped = append(deduped, target)
+	}
+
+	encoded, err := encodeTargets(deduped)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode egress targets")
+	}
+	if sess.Metadata == nil {
+		sess.Metadata = make(map[string]string)
+	}
+	if encoded == "" {
+		delete(sess.Metadata, TargetsMetadataKey)
+	} else {
+		sess.Metadata[TargetsMetadataKey] = encoded
+	}
+	sess.UpdatedAt = time.Now()
+	return e.store.SaveSession(sess)
+}
+
+func (e *DefaultEgress) AddTarget(sessionID string, target Target) error {
+	targets, err := e.Targets(sessionID)
+	if err != nil {
+		return err
+	}
+	return e.SetTargets(sessionID, append(targets, target))
+}
+
+func (e *DefaultEgress) RemoveTarget(sessionID string, adapterName string) error {
+	targets, err := e.Targets(sessionID)
+	if err != nil {
+		return err
+	}
+	kept := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		if target.Adapter == strings.TrimSpace(adapterName) {
+			continue
+		}
+		kept = append(kept, target)
+	}
+	if len(kept) == len(targets) {
+		return errors.NotFound("egress target not found: " + adapterName)
+	}
+	return e.SetTargets(sessionID, kept)
+}
+
+var (
+	markdownEmphasis = regexp.MustCompile("(\\*\\*|__|`{1,3})")
+	markdownHeading  = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+func formatForTarget(format, sessionID, content string, sentAt time.Time) string {
+	switch format {
+	case FormatPlain:
+		out := markdownLink.ReplaceAllString(content, "$1 ($2)")
+		out = markdownHeading.ReplaceAllString(out, "")
+		return markdownEmphasis.ReplaceAllString(out, "")
+	case FormatDigest:
+		return fmt.Sprintf("[%s] session %s\n%s\n", sentAt.UTC().Format(time.RFC3339), sessionID, strings.TrimSpace(content))
+	default:
+		return content
+	}
+}
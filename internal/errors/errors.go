@@ -29,6 +29,10 @@ var (
 	// ErrTransient - transient error (show retry hint in interactive, retry with backoff in background)
 	ErrTransient = errors.New("transient error")
 
+	// ErrRateLimited - provider rate limit or quota exhausted (show backoff notice and retry the turn later).
+	// It is also an ErrTransient, so IsRetryable reports true.
+	ErrRateLimited = fmt.Errorf("provider rate limited: %w", ErrTransient)
+
 	// ErrInvalidModelOutput - model returned malformed structured output
 	ErrInvalidModelOutput = errors.New("invalid model output")
 
@@ -77,6 +81,10 @@ func Internal(message string) error {
 	return fmt.Errorf("%s: %w", message, ErrInternal)
 }
 
+func RateLimited(message string) error {
+	return fmt.Errorf("%s: %w", message, ErrRateLimited)
+}
+
 func InvalidModelOutput(message string) error {
 	return fmt.Errorf("%s: %w", message, ErrInvalidModelOutput)
 }
@@ -0,0 +1,202 @@
+// Package featureflag toggles experimental runtime behaviors per workspace.
+// A flag's value is its runtime override if one is set, else the configured
+// value under `features`, else its built-in default.
+package featureflag
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// Known flags.
+const (
+	// Streaming forwards model token deltas to callers that attach a stream
+	// handler.
+	Streaming = "streaming"
+	// LLMToolSelection asks the model which tools a goal needs instead of
+	// scoring tools by keyword overlap.
+	LLMToolSelection = "llm_tool_selection"
+)
+
+// Defaults holds every known flag and its built-in value.
+var Defaults = map[string]bool{
+	Streaming:        true,
+	LLMToolSelection: false,
+}
+
+// Flag sources, from lowest to highest precedence.
+const (
+	SourceDefault  = "default"
+	SourceConfig   = "config"
+	SourceOverride = "override"
+)
+
+// Flag is the effective state of one flag.
+type Flag struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// Flags resolves flag values. Overrides are persisted to a workspace file so
+// they survive restarts. A nil *Flags reports built-in defaults.
+type Flags struct {
+	path       string
+	configured map[string]bool
+
+	mu        sync.RWMutex
+	overrides map[string]bool
+}
+
+// New returns flags with the configured values and no persistence.
+func New(configured map[string]bool) *Flags {
+	f := &Flags{configured: make(map[string]bool), overrides: make(map[string]bool)}
+	for name, enabled := range configured {
+		if _, ok := Defaults[name]; !ok {
+			slog.Warn("Ignoring unknown feature flag in config", "flag", name)
+			continue
+		}
+		f.configured[name] = enabled
+	}
+	return f
+}
+
+// Load returns flags with the configured values and the overrides stored at
+// path. A missing file means no overrides.
+func Load(path string, configured map[string]bool) (*Flags, error) {
+	f := New(configured)
+	f.path = path
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read feature flags: %w", err)
+	}
+	var overrides map[string]bool
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("parse feature flags %s: %w", path, err)
+	}
+	for name, enabled := range overrides {
+		if _, ok := Defaults[name]; ok {
+			f.overrides[name] = enabled
+		}
+	}
+	return f, nil
+}
+
+// Enabled reports whether the named flag is on. Unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	return f.get(name).Enabled
+}
+
+func (f *Flags) get(name string) Flag {
+	flag := Flag{Name: name, Enabled: Defaults[name], Source: SourceDefault}
+	if f == nil {
+		return flag
+	}
+	if enabled, ok := f.configured[name]; ok {
+		flag.Enabled, flag.Source = enabled, SourceConfig
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if enabled, ok := f.overrides[name]; ok {
+		flag.Enabled, flag.Source = enabled, SourceOverride
+	}
+	return flag
+}
+
+// List returns every known flag sorted by name.
+func (f *Flags) List() []Flag {
+	names := make([]string, 0, len(Defaults))
+	for name := range Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	flags := make([]Flag, 0, len(names))
+	for _, name := range names {
+		flags = append(flags, f.get(name))
+	}
+	return flags
+}
+
+// Override sets a runtime value for the named flag and persists it.
+func (f *Flags) Override(name string, enabled bool) (Flag, error) {
+	if err := f.checkName(name); err != nil {
+		return Flag{}, err
+	}
+	f.mu.Lock()
+	previous, had := f.overrides[name]
+	f.overrides[name] = enabled
+	if err := f.saveLocked(); err != nil {
+		if had {
+			f.overrides[name] = previous
+		} else {
+			delete(f.overrides, name)
+		}
+		f.mu.Unlock()
+		return Flag{}, err
+	}
+	f.mu.Unlock()
+	slog.Info("Feature flag overridden", "flag", name, "enabled", enabled)
+	return f.get(name), nil
+}
+
+// Reset removes the runtime override of the named flag, returning it to its
+// configured or default value.
+func (f *Flags) Reset(name string) (Flag, error) {
+	if err := f.checkName(name); err != nil {
+		return Flag{}, err
+	}
+	f.mu.Lock()
+	previous, had := f.overrides[name]
+	delete(f.overrides, name)
+	if err := f.saveLocked(); err != nil {
+		if had {
+			f.overrides[name] = previous
+		}
+		f.mu.Unlock()
+		return Flag{}, err
+	}
+	f.mu.Unlock()
+	slog.Info("Feature flag override removed", "flag", name)
+	return f.get(name), nil
+}
+
+func (f *Flags) checkName(name string) error {
+	if f == nil {
+		return heikeErrors.InvalidInput("feature flags are not configured")
+	}
+	if _, ok := Defaults[name]; !ok {
+		return heikeErrors.NotFound(fmt.Sprintf("unknown feature flag %q", name))
+	}
+	return nil
+}
+
+func (f *Flags) saveLocked() error {
+	if f.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(f.overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode feature flags: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("create feature flags dir: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write feature flags: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("write feature flags: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package featureflag
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+func TestFlags_Precedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feature_flags.json")
+	flags, err := Load(path, map[string]bool{LLMToolSelection: true, "unknown": true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !flags.Enabled(Streaming) || !flags.Enabled(LLMToolSelection) {
+		t.Fatalf("unexpected initial flags: %+v", flags.List())
+	}
+	if flags.Enabled("unknown") {
+		t.Fatal("unknown flags must be off")
+	}
+
+	flag, err := flags.Override(LLMToolSelection, false)
+	if err != nil || flag.Enabled || flag.Source != SourceOverride {
+		t.Fatalf("Override = %+v, %v", flag, err)
+	}
+
+	// Overrides survive a reload.
+	reloaded, err := Load(path, map[string]bool{LLMToolSelection: true})
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if reloaded.Enabled(LLMToolSelection) {
+		t.Fatal("override should persist across reloads")
+	}
+	flag, err = reloaded.Reset(LLMToolSelection)
+	if err != nil || !flag.Enabled || flag.Source != SourceConfig {
+		t.Fatalf("Reset = %+v, %v", flag, err)
+	}
+}
+
+func TestFlags_RejectsUnknownAndNil(t *testing.T) {
+	if _, err := New(nil).Override("nope", true); !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	var flags *Flags
+	if !flags.Enabled(Streaming) || flags.Enabled(LLMToolSelection) {
+		t.Fatal("nil flags should report defaults")
+	}
+	if len(flags.List()) != len(Defaults) {
+		t.Fatalf("List = %+v", flags.List())
+	}
+}
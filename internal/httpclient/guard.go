@@ -0,0 +1,137 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ErrPrivateAddress is returned when a public-only client would connect to
+// an address outside the public internet.
+var ErrPrivateAddress = errors.New("private network address")
+
+// nonPublicNetworks are ranges net.IP has no predicate for: "this network",
+// carrier-grade NAT, IETF protocol assignments, benchmarking, reserved, and
+// NAT64 prefixes that can embed any IPv4 address.
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// IsPublicAddress reports whether ip is reachable on the public internet,
+// i.e. not loopback, private, link-local, multicast, unspecified, CGNAT or
+// otherwise reserved.
+func IsPublicAddress(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicOnly returns a client with its own pool that refuses to connect to
+// non-public addresses. The check runs on the address being dialed, after
+// DNS resolution, so a host that resolves to a public address when a caller
+// validates it and to a private one when the client connects (DNS
+// rebinding) is still refused; redirects are covered the same way. A
+// configured proxy is trusted and dialed as usual, since it resolves the
+// target itself.
+func (f *Factory) PublicOnly(name string, timeout time.Duration) *http.Client {
+	if f == nil {
+		f = Default()
+	}
+	transport := f.Dedicated(name, func(t *http.Transport) {
+		t.DialContext = publicDialContext(f.dialTimeout, f.proxyAddrs)
+	})
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+func publicDialContext(timeout time.Duration, proxyAddrs map[string]bool) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	direct := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	guarded := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second, Control: denyNonPublic}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if proxyAddrs[strings.ToLower(addr)] {
+			return direct.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// denyNonPublic runs after resolution with the IP about to be connected.
+func denyNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); !IsPublicAddress(ip) {
+		return fmt.Errorf("connect to %s: %w", host, ErrPrivateAddress)
+	}
+	return nil
+}
+
+// proxyAddrs returns the host:port of the proxies raw selects, as the
+// transport dials them.
+func proxyAddrs(raw string) map[string]bool {
+	var proxies []string
+	switch raw = strings.TrimSpace(raw); raw {
+	case "direct":
+	case "":
+		env := httpproxy.FromEnvironment()
+		proxies = []string{env.HTTPProxy, env.HTTPSProxy}
+	default:
+		proxies = []string{raw}
+	}
+
+	addrs := map[string]bool{}
+	for _, proxy := range proxies {
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"https": "443", "socks5": "1080"}[u.Scheme]
+			if port == "" {
+				port = "80"
+			}
+		}
+		addrs[strings.ToLower(net.JoinHostPort(u.Hostname(), port))] = true
+	}
+	return addrs
+}
@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":         true,
+		"2606:4700:4700::1111":  true,
+		"127.0.0.1":             false,
+		"10.1.2.3":              false,
+		"172.16.0.1":            false,
+		"192.168.1.1":           false,
+		"169.254.169.254":       false,
+		"100.64.0.1":            false,
+		"100.127.255.254":       false,
+		"0.1.2.3":               false,
+		"198.18.0.1":            false,
+		"224.0.0.1":             false,
+		"::1":                   false,
+		"fd00::1":               false,
+		"fe80::1":               false,
+		"::ffff:127.0.0.1":      false,
+		"64:ff9b::a00:1":        false,
+		"2001:db8::1":           true,
+		"100.63.255.255":        true,
+		"::":                    false,
+		"255.255.255.255":       false,
+		"ff02::1":               false,
+		"::ffff:93.184.216.34":  true,
+		"2002:c000:0204::1":     true,
+		"fc00::1":               false,
+		"203.0.113.1":           true,
+		"100.128.0.1":           true,
+		"192.0.0.8":             false,
+		"240.0.0.1":             false,
+		"64:ff9b:1::1":          false,
+		"2600:1f18:4c5:4a00::1": true,
+	} {
+		if got := IsPublicAddress(net.ParseIP(addr)); got != want {
+			t.Errorf("IsPublicAddress(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestFactory_PublicOnlyRefusesPrivateDials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	f, err := New(Options{Proxy: "direct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	// localhost passes any check on the name; the dial sees 127.0.0.1.
+	for _, target := range []string{server.URL, "http://localhost:" + port} {
+		_, err := f.PublicOnly("test_public", 0).Get(target)
+		if !errors.Is(err, ErrPrivateAddress) {
+			t.Fatalf("GET %s error = %v, want ErrPrivateAddress", target, err)
+		}
+	}
+	if resp, err := f.Client("test_shared", 0).Get(server.URL); err != nil {
+		t.Fatalf("shared client should be unaffected: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+}
+
+func TestFactory_PublicOnlyTrustsConfiguredProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("via proxy " + r.URL.Host))
+	}))
+	defer proxy.Close()
+
+	f, err := New(Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := f.PublicOnly("test_proxy", 0).Get("http://example.com/")
+	if err != nil {
+		t.Fatalf("GET through proxy: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "via proxy example.com" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestProxyAddrs(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "proxy.corp:3128")
+	t.Setenv("HTTPS_PROXY", "https://Secure.Corp")
+	got := proxyAddrs("")
+	if len(got) != 2 || !got["proxy.corp:3128"] || !got["secure.corp:443"] {
+		t.Fatalf("environment proxies = %v", got)
+	}
+	if got := proxyAddrs("direct"); len(got) != 0 {
+		t.Fatalf("direct = %v, want none", got)
+	}
+	if got := proxyAddrs("http://10.0.0.1"); !got["10.0.0.1:80"] {
+		t.Fatalf("explicit proxy = %v", got)
+	}
+}
@@ -0,0 +1,290 @@
+// Package httpclient builds the outbound HTTP clients used by tools and model
+// providers. Clients from one Factory share a connection pool and the egress
+// policy (proxy, TLS, pool limits), and count requests and new connections
+// per client name.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+// Options configures the shared transport. Zero values use the defaults in
+// the config package.
+type Options struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps dialing, in-flight and idle connections per host;
+	// 0 is unlimited.
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	// Proxy is the proxy URL for all requests; empty uses HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY from the environment, "direct" disables proxies.
+	Proxy string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// TLSMinVersion is "1.2" or "1.3".
+	TLSMinVersion string
+}
+
+// Factory hands out clients backed by one shared transport.
+type Factory struct {
+	transport *http.Transport
+	// dialTimeout and proxyAddrs let PublicOnly rebuild the dialer.
+	dialTimeout time.Duration
+	proxyAddrs  map[string]bool
+}
+
+// New builds a factory from opts.
+func New(opts Options) (*Factory, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	dialTimeout := opts.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = mustDuration(config.DefaultHTTPDialTimeout)
+	}
+	return &Factory{transport: transport, dialTimeout: dialTimeout, proxyAddrs: proxyAddrs(opts.Proxy)}, nil
+}
+
+// FromConfig converts cfg to Options and builds a factory.
+func FromConfig(cfg config.HTTPConfig) (*Factory, error) {
+	idleConnTimeout, err := config.DurationOrDefault(cfg.IdleConnTimeout, config.DefaultHTTPIdleConnTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse http.idle_conn_timeout: %w", err)
+	}
+	dialTimeout, err := config.DurationOrDefault(cfg.DialTimeout, config.DefaultHTTPDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse http.dial_timeout: %w", err)
+	}
+	tlsHandshakeTimeout, err := config.DurationOrDefault(cfg.TLSHandshakeTimeout, config.DefaultHTTPTLSHandshakeTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse http.tls_handshake_timeout: %w", err)
+	}
+	return New(Options{
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+		DialTimeout:         dialTimeout,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		Proxy:               cfg.Proxy,
+		CAFile:              cfg.CAFile,
+		TLSMinVersion:       cfg.TLSMinVersion,
+	})
+}
+
+var shared = struct {
+	mu        sync.Mutex
+	factories map[config.HTTPConfig]*Factory
+}{factories: make(map[config.HTTPConfig]*Factory)}
+
+// ForConfig returns the process-wide factory for cfg, so the kernel, tools
+// and knowledge sync of a workspace reuse the same connections.
+func ForConfig(cfg config.HTTPConfig) (*Factory, error) {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if f, ok := shared.factories[cfg]; ok {
+		return f, nil
+	}
+	f, err := FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	shared.factories[cfg] = f
+	return f, nil
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultFactory *Factory
+)
+
+// Default returns a factory with default options, for callers built without
+// one. A nil *Factory behaves like Default.
+func Default() *Factory {
+	defaultOnce.Do(func() {
+		f, err := New(Options{})
+		if err != nil {
+			// Default options read no files, so this cannot fail.
+			panic(err)
+		}
+		defaultFactory = f
+	})
+	return defaultFactory
+}
+
+// Client returns a client on the shared pool. name labels its metrics;
+// timeout bounds each request, 0 leaves requests unbounded (for streams).
+func (f *Factory) Client(name string, timeout time.Duration) *http.Client {
+	return &http.Client{Transport: f.Transport(name), Timeout: timeout}
+}
+
+// Transport returns the shared pool as a round tripper labelled name, for
+// callers that wrap it in their own transport.
+func (f *Factory) Transport(name string) http.RoundTripper {
+	if f == nil {
+		f = Default()
+	}
+	return &instrumented{name: name, next: f.transport}
+}
+
+// Dedicated returns a transport with its own pool, configured by configure
+// on top of the factory's egress policy, for callers that need
+// transport-level settings such as a response header timeout.
+func (f *Factory) Dedicated(name string, configure func(*http.Transport)) http.RoundTripper {
+	if f == nil {
+		f = Default()
+	}
+	t := f.transport.Clone()
+	if configure != nil {
+		configure(t)
+	}
+	return &instrumented{name: name, next: t}
+}
+
+// CloseIdleConnections closes idle connections in the shared pool.
+func (f *Factory) CloseIdleConnections() {
+	if f == nil {
+		return
+	}
+	f.transport.CloseIdleConnections()
+}
+
+func newTransport(opts Options) (*http.Transport, error) {
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = config.DefaultHTTPMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = config.DefaultHTTPMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout <= 0 {
+		opts.IdleConnTimeout = mustDuration(config.DefaultHTTPIdleConnTimeout)
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = mustDuration(config.DefaultHTTPDialTimeout)
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = mustDuration(config.DefaultHTTPTLSHandshakeTimeout)
+	}
+
+	proxy, err := proxyFunc(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := tlsConfig(opts.CAFile, opts.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}, nil
+}
+
+func proxyFunc(raw string) (func(*http.Request) (*url.URL, error), error) {
+	raw = strings.TrimSpace(raw)
+	switch raw {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "direct":
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid http.proxy %q", raw)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+func tlsConfig(caFile, minVersion string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch strings.TrimSpace(minVersion) {
+	case "", "1.2":
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("invalid http.tls_min_version %q: want 1.2 or 1.3", minVersion)
+	}
+
+	if caFile = strings.TrimSpace(caFile); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read http.ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("http.ca_file %s contains no certificates", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+func mustDuration(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// instrumented counts requests by status class and connections by reuse.
+type instrumented struct {
+	name string
+	next http.RoundTripper
+}
+
+func (t *instrumented) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.Inc("http_client_connections_total", "client", t.name, "reused", strconv.FormatBool(info.Reused))
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		metrics.Inc("http_client_requests_total", "client", t.name, "status", "error")
+		return nil, err
+	}
+	metrics.Inc("http_client_requests_total", "client", t.name, "status", strconv.Itoa(resp.StatusCode/100)+"xx")
+	return resp, nil
+}
+
+// Unwrap returns the underlying transport.
+func (t *instrumented) Unwrap() http.RoundTripper {
+	return t.next
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+func TestFactory_ClientsShareConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	f, err := New(Options{Proxy: "direct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.CloseIdleConnections()
+
+	get := func(client *http.Client) {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	newConns := metrics.Value("http_client_connections_total", "client", "test_b", "reused", "false")
+	get(f.Client("test_a", 0))
+	get(f.Client("test_b", 0))
+
+	if got := metrics.Value("http_client_connections_total", "client", "test_b", "reused", "false"); got != newConns {
+		t.Fatalf("second client dialed %d new connections, want reuse", got-newConns)
+	}
+	if got := metrics.Value("http_client_connections_total", "client", "test_b", "reused", "true"); got == 0 {
+		t.Fatal("second client did not reuse the pooled connection")
+	}
+	if got := metrics.Value("http_client_requests_total", "client", "test_a", "status", "2xx"); got == 0 {
+		t.Fatal("request not counted")
+	}
+}
+
+func TestFactory_DedicatedAppliesConfiguration(t *testing.T) {
+	var nilFactory *Factory
+	rt := nilFactory.Dedicated("test", func(t *http.Transport) { t.MaxConnsPerHost = 3 })
+	transport, ok := rt.(*instrumented).Unwrap().(*http.Transport)
+	if !ok || transport.MaxConnsPerHost != 3 {
+		t.Fatalf("dedicated transport = %#v", rt)
+	}
+	if transport == Default().transport {
+		t.Fatal("dedicated transport shares the pool")
+	}
+}
+
+func TestFromConfig_Validates(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []config.HTTPConfig{
+		{Proxy: "://bad"},
+		{TLSMinVersion: "1.0"},
+		{IdleConnTimeout: "soon"},
+		{CAFile: caFile},
+	} {
+		if _, err := FromConfig(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+
+	cfg := config.HTTPConfig{Proxy: "http://proxy.internal:3128", TLSMinVersion: "1.3", MaxConnsPerHost: 4}
+	a, err := ForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ForConfig(cfg)
+	if a != b {
+		t.Fatal("ForConfig built a second factory for the same config")
+	}
+	if a.transport.MaxConnsPerHost != 4 || a.transport.TLSClientConfig.MinVersion != 0x0304 {
+		t.Fatalf("transport = %+v", a.transport)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if proxyURL, _ := a.transport.Proxy(req); proxyURL == nil || proxyURL.Host != "proxy.internal:3128" {
+		t.Fatalf("proxy = %v", proxyURL)
+	}
+}
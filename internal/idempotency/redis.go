@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/harunnryd/heike/internal/redis"
+)
+
+// Checker records processed keys so duplicate events can be dropped.
+type Checker interface {
+	// CheckAndMark reports whether key was already seen and marks it otherwise.
+	CheckAndMark(key string, ttl time.Duration) bool
+	// Forget unmarks key so an event that was rejected can be resubmitted.
+	Forget(key string)
+	Save() error
+	Prune() int
+}
+
+// RedisStore shares processed keys across replicas through Redis.
+type RedisStore struct {
+	client  *redis.Client
+	prefix  string
+	timeout time.Duration
+}
+
+func NewRedisStore(client *redis.Client, prefix string, timeout time.Duration) *RedisStore {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &RedisStore{client: client, prefix: prefix, timeout: timeout}
+}
+
+// CheckAndMark uses SET NX so only the first replica to see a key processes it.
+// If Redis is unreachable the key is treated as new: a duplicate is preferable
+// to silently dropping an event.
+func (s *RedisStore) CheckAndMark(key string, ttl time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	_, err := s.client.Do(ctx, "SET", s.prefix+key, "1", "NX", "PX", strconv.FormatInt(ms, 10))
+	if errors.Is(err, redis.Nil) {
+		return true
+	}
+	if err != nil {
+		slog.Warn("Idempotency check failed, processing event", "key", key, "error", err)
+	}
+	return false
+}
+
+func (s *RedisStore) Forget(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	if _, err := s.client.Do(ctx, "DEL", s.prefix+key); err != nil {
+		slog.Warn("Idempotency forget failed", "key", key, "error", err)
+	}
+}
+
+// Save is a no-op; Redis persists keys as they are marked.
+func (s *RedisStore) Save() error {
+	return nil
+}
+
+// Prune is a no-op; Redis expires keys on its own.
+func (s *RedisStore) Prune() int {
+	return 0
+}
@@ -85,6 +85,12 @@ func (s *Store) CheckAndMark(key string, ttl time.Duration) bool {
 	return false
 }
 
+func (s *Store) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.state.Keys, key)
+}
+
 func (s *Store) Prune() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -15,8 +15,16 @@ const (
 	TypeSystemEvent EventType = "system_event"
 	TypeCommand     EventType = "command" // Slash command
 	TypeCron        EventType = "cron"    // Cron job execution
+	TypeBatch       EventType = "batch"   // Goal submitted through batch mode
+	TypeEmail       EventType = "email"   // Mail read by the email adapter
+	TypeWebhook     EventType = "webhook" // Payload posted to a webhook route
 )
 
+// NotifyURLMetadataKey is the session metadata key holding the URL that
+// approval-required notifications for the session are posted to. The latest
+// event carrying it replaces the session's URL; an empty value removes it.
+const NotifyURLMetadataKey = "notify_url"
+
 // Event is the normalized data structure for all inputs.
 type Event struct {
 	// Identity
@@ -36,6 +44,9 @@ type Event struct {
 	// Context
 	Metadata  map[string]string `json:"metadata"` // e.g. "user_id": "U123"
 	CreatedAt time.Time         `json:"created_at"`
+
+	// CallbackURL receives the outcome once a worker finishes the event.
+	CallbackURL string `json:"callback_url,omitempty"`
 }
 
 // NewEvent creates a normalized event with a fresh ULID.
@@ -56,6 +67,12 @@ func GenerateIdempotencyKey(source, externalID string) string {
 	return fmt.Sprintf("%s:%s", source, externalID)
 }
 
+// EventIDForKey derives a stable event ID from a client-supplied idempotency
+// key, so resubmissions share an ID and are caught by duplicate detection.
+func EventIDForKey(source, key string) string {
+	return "idem_" + HashKey(GenerateIdempotencyKey(source, key))[:32]
+}
+
 // HashKey returns a SHA256 hash of the idempotency key for storage efficiency/safety.
 func HashKey(key string) string {
 	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
@@ -3,6 +3,7 @@ package ingress
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/harunnryd/heike/internal/config"
@@ -27,6 +28,11 @@ type Ingress struct {
 	drainTimeout             time.Duration
 	drainPollInterval        time.Duration
 	idempotencyTTL           time.Duration
+
+	// Delayed events waiting to be submitted; dropped on Close.
+	delayMu sync.Mutex
+	closed  bool
+	delayed map[*time.Timer]struct{}
 }
 
 func NewIngress(interactiveSize, backgroundSize int, runtimeCfg RuntimeConfig, store *store.Worker) *Ingress {
@@ -72,6 +78,7 @@ func NewIngress(interactiveSize, backgroundSize int, runtimeCfg RuntimeConfig, s
 		drainTimeout:             runtimeCfg.DrainTimeout,
 		drainPollInterval:        runtimeCfg.DrainPollInterval,
 		idempotencyTTL:           runtimeCfg.IdempotencyTTL,
+		delayed:                  make(map[*time.Timer]struct{}),
 	}
 }
 
@@ -99,6 +106,15 @@ func (i *Ingress) Submit(ctx context.Context, evt *Event) error {
 		return errors.ErrDuplicateEvent
 	}
 
+	// A rejected event was never processed, so its key must not block a retry.
+	if err := i.route(ctx, evt); err != nil {
+		i.store.ForgetKey(key)
+		return err
+	}
+	return nil
+}
+
+func (i *Ingress) route(ctx context.Context, evt *Event) error {
 	dest := i.router.Route(ctx, evt)
 	switch dest.Type {
 	case DestDrop:
@@ -106,9 +122,14 @@ func (i *Ingress) Submit(ctx context.Context, evt *Event) error {
 		return nil
 	case DestCommand:
 		slog.Info("Handling as command", "id", evt.ID)
-		if dest.Handler != nil {
-			return dest.Handler(ctx, evt)
+		if dest.Handler == nil {
+			return nil
 		}
+		if err := dest.Handler(ctx, evt); err != nil {
+			i.store.TrackEvent(evt.ID, evt.SessionID, store.EventFailed, err.Error())
+			return err
+		}
+		i.store.TrackEvent(evt.ID, evt.SessionID, store.EventCompleted, "")
 		return nil
 	case DestPipeline:
 		// Continue to Resolvers -> Queue
@@ -128,6 +149,16 @@ func (i *Ingress) Submit(ctx context.Context, evt *Event) error {
 	}
 	evt.SessionID = sess
 
+	// Tracked before enqueueing so a fast worker cannot be overwritten.
+	i.store.TrackEvent(evt.ID, evt.SessionID, store.EventQueued, "")
+	if err := i.enqueue(ctx, evt); err != nil {
+		i.store.TrackEvent(evt.ID, evt.SessionID, store.EventFailed, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (i *Ingress) enqueue(ctx context.Context, evt *Event) error {
 	if evt.Type == TypeUserMessage || evt.Type == TypeCommand {
 		select {
 		case i.interactiveQueue <- evt:
@@ -151,6 +182,37 @@ func (i *Ingress) Submit(ctx context.Context, evt *Event) error {
 	}
 }
 
+// SubmitAfter submits evt once delay has elapsed. The submission runs with a
+// background context, so it outlives the caller's request; events still
+// waiting when ingress closes are dropped.
+func (i *Ingress) SubmitAfter(evt *Event, delay time.Duration) error {
+	if evt == nil {
+		return errors.InvalidInput("event is nil")
+	}
+
+	i.delayMu.Lock()
+	defer i.delayMu.Unlock()
+	if i.closed {
+		return errors.Transient("ingress is closed")
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		i.delayMu.Lock()
+		defer i.delayMu.Unlock()
+		if _, pending := i.delayed[timer]; !pending {
+			return
+		}
+		delete(i.delayed, timer)
+		if err := i.Submit(context.Background(), evt); err != nil {
+			slog.Warn("Delayed event submit failed", "id", evt.ID, "session", evt.SessionID, "error", err)
+		}
+	})
+	i.delayed[timer] = struct{}{}
+	slog.Info("Event scheduled", "id", evt.ID, "session", evt.SessionID, "delay", delay)
+	return nil
+}
+
 func (i *Ingress) InteractiveQueue() <-chan *Event {
 	return i.interactiveQueue
 }
@@ -159,10 +221,34 @@ func (i *Ingress) BackgroundQueue() <-chan *Event {
 	return i.backgroundQueue
 }
 
+// Load returns the utilisation of the fullest queue as a fraction of its capacity.
+func (i *Ingress) Load() float64 {
+	if i.interactiveQueue == nil || i.backgroundQueue == nil {
+		return 0
+	}
+	interactive := float64(len(i.interactiveQueue)) / float64(cap(i.interactiveQueue))
+	background := float64(len(i.backgroundQueue)) / float64(cap(i.backgroundQueue))
+	if interactive > background {
+		return interactive
+	}
+	return background
+}
+
 // Close gracefully shuts down ingress by draining queues and closing them.
 func (i *Ingress) Close() error {
 	slog.Info("Ingress shutting down, draining queues")
 
+	i.delayMu.Lock()
+	i.closed = true
+	for timer := range i.delayed {
+		timer.Stop()
+	}
+	if dropped := len(i.delayed); dropped > 0 {
+		slog.Warn("Dropping delayed events", "count", dropped)
+	}
+	i.delayed = make(map[*time.Timer]struct{})
+	i.delayMu.Unlock()
+
 	drainStart := time.Now()
 
 	drainQueue := func(ch chan *Event, name string) {
@@ -177,7 +263,11 @@ func (i *Ingress) Close() error {
 		stalled := false
 		for remaining > 0 && time.Since(drainStart) < i.drainTimeout {
 			select {
-			case <-ch:
+			case evt := <-ch:
+				// Drained events are never processed.
+				if evt != nil && i.store != nil {
+					i.store.TrackEvent(evt.ID, evt.SessionID, store.EventDeadLettered, "dropped during shutdown")
+				}
 				remaining--
 			case <-time.After(i.drainPollInterval):
 				if remaining == len(ch) {
@@ -2,10 +2,13 @@ package ingress
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
 	"github.com/harunnryd/heike/internal/store"
 )
 
@@ -76,6 +79,69 @@ func TestIngress_DuplicateDetection(t *testing.T) {
 	}
 }
 
+func TestIngress_RejectedEventKeyCanBeRetried(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	ingress := NewIngress(10, 1, RuntimeConfig{}, worker)
+
+	filler := NewEvent("api", TypeSystemEvent, "session1", "filler", nil)
+	if err := ingress.Submit(context.Background(), &filler); err != nil {
+		t.Fatalf("filler submit failed: %v", err)
+	}
+
+	evt := NewEvent("api", TypeSystemEvent, "session1", "keyed", nil)
+	evt.ID = EventIDForKey("api", "client-key-1")
+	if err := ingress.Submit(context.Background(), &evt); !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected queue full error, got %v", err)
+	}
+
+	<-ingress.BackgroundQueue()
+	if err := ingress.Submit(context.Background(), &evt); err != nil {
+		t.Fatalf("retry after rejection should be accepted, got %v", err)
+	}
+
+	retry := NewEvent("api", TypeSystemEvent, "session1", "keyed", nil)
+	retry.ID = EventIDForKey("api", "client-key-1")
+	if err := ingress.Submit(context.Background(), &retry); !errors.Is(err, heikeErrors.ErrDuplicateEvent) {
+		t.Fatalf("expected duplicate for reused key, got %v", err)
+	}
+	if retry.ID != evt.ID || EventIDForKey("other", "client-key-1") == evt.ID {
+		t.Fatal("event id should be stable per source and key")
+	}
+}
+
+func TestIngress_TracksEventStatus(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	ingress := NewIngress(10, 1, RuntimeConfig{}, worker)
+
+	queued := NewEvent("api", TypeSystemEvent, "session1", "first", nil)
+	if err := ingress.Submit(context.Background(), &queued); err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	status, ok := worker.EventStatus(queued.ID)
+	if !ok || status.Status != store.EventQueued || status.SessionID != queued.SessionID || status.QueuedAt == nil {
+		t.Fatalf("expected queued status, got %+v (found=%v)", status, ok)
+	}
+
+	rejected := NewEvent("api", TypeSystemEvent, "session1", "second", nil)
+	if err := ingress.Submit(context.Background(), &rejected); !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected queue full error, got %v", err)
+	}
+	if status, _ := worker.EventStatus(rejected.ID); status.Status != store.EventFailed {
+		t.Fatalf("expected failed status for rejected event, got %q", status.Status)
+	}
+
+	if err := ingress.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if status, _ := worker.EventStatus(queued.ID); status.Status != store.EventDeadLettered {
+		t.Fatalf("expected drained event to be dead-lettered, got %q", status.Status)
+	}
+}
+
 func TestIngress_Close(t *testing.T) {
 	worker := setupWorker(t)
 	defer worker.Stop()
@@ -256,6 +322,67 @@ func TestResolver_UnknownSourceGeneratesSession(t *testing.T) {
 	}
 }
 
+func TestResolver_ToolAllowlistOnlyNarrows(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	resolver := NewStandardResolver(worker)
+	resolve := func(allow string) string {
+		t.Helper()
+		evt := NewEvent("api", TypeUserMessage, "untrusted", "hi", map[string]string{"tools_allow": allow})
+		if _, err := resolver.ResolveSession(context.Background(), &evt); err != nil {
+			t.Fatalf("ResolveSession failed: %v", err)
+		}
+		sess, err := worker.GetSession("untrusted")
+		if err != nil || sess == nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return sess.Metadata["tools_allow"]
+	}
+
+	if got := resolve(" search_query, open ,search_query"); got != "search_query,open" {
+		t.Fatalf("initial allowlist = %q", got)
+	}
+	if got := resolve("open,exec_command"); got != "open" {
+		t.Fatalf("later events must not widen the allowlist, got %q", got)
+	}
+	if got := resolve(""); got != "" {
+		t.Fatalf("empty allowlist should remove every tool, got %q", got)
+	}
+}
+
+func TestResolver_NotifyURLFollowsLatestEvent(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	resolver := NewStandardResolver(worker)
+	resolve := func(metadata map[string]string) map[string]string {
+		t.Helper()
+		evt := NewEvent("api", TypeUserMessage, "embedded", "hi", metadata)
+		if _, err := resolver.ResolveSession(context.Background(), &evt); err != nil {
+			t.Fatalf("ResolveSession failed: %v", err)
+		}
+		sess, err := worker.GetSession("embedded")
+		if err != nil || sess == nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		return sess.Metadata
+	}
+
+	if got := resolve(map[string]string{NotifyURLMetadataKey: "https://a.example.com/hook"}); got[NotifyURLMetadataKey] != "https://a.example.com/hook" {
+		t.Fatalf("initial notify url = %q", got[NotifyURLMetadataKey])
+	}
+	if got := resolve(nil); got[NotifyURLMetadataKey] != "https://a.example.com/hook" {
+		t.Fatalf("events without notify_url must keep it, got %q", got[NotifyURLMetadataKey])
+	}
+	if got := resolve(map[string]string{NotifyURLMetadataKey: "https://b.example.com/hook"}); got[NotifyURLMetadataKey] != "https://b.example.com/hook" {
+		t.Fatalf("notify url should be replaced, got %q", got[NotifyURLMetadataKey])
+	}
+	if got := resolve(map[string]string{NotifyURLMetadataKey: ""}); len(got[NotifyURLMetadataKey]) != 0 {
+		t.Fatalf("empty notify url should remove it, got %v", got)
+	}
+}
+
 func TestIngress_SubmitNilEvent(t *testing.T) {
 	worker := setupWorker(t)
 	defer worker.Stop()
@@ -265,3 +392,38 @@ func TestIngress_SubmitNilEvent(t *testing.T) {
 		t.Fatal("expected error for nil event")
 	}
 }
+
+func TestIngress_SubmitAfter(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	ingress := NewIngress(10, 10, RuntimeConfig{}, worker)
+
+	evt := NewEvent("test", TypeUserMessage, "session1", "retry me", nil)
+	if err := ingress.SubmitAfter(&evt, 10*time.Millisecond); err != nil {
+		t.Fatalf("SubmitAfter failed: %v", err)
+	}
+	if len(ingress.interactiveQueue) != 0 {
+		t.Fatal("expected event to wait for its delay")
+	}
+
+	select {
+	case got := <-ingress.InteractiveQueue():
+		if got.ID != evt.ID {
+			t.Fatalf("unexpected event %s", got.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("delayed event was not submitted")
+	}
+
+	dropped := NewEvent("test", TypeUserMessage, "session1", "too late", nil)
+	if err := ingress.SubmitAfter(&dropped, time.Hour); err != nil {
+		t.Fatalf("SubmitAfter failed: %v", err)
+	}
+	if err := ingress.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := ingress.SubmitAfter(&dropped, time.Millisecond); err == nil {
+		t.Fatal("expected SubmitAfter to fail after Close")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/store"
 
 	"github.com/oklog/ulid/v2"
@@ -104,7 +106,10 @@ func (r *StandardResolver) ensureSession(sessionID string, metadata map[string]s
 		return err
 	}
 	if sess != nil {
-		return nil
+		return r.updateSession(sess, metadata)
+	}
+	if requested, ok := metadata[policy.ToolsAllowMetadataKey]; ok {
+		metadata[policy.ToolsAllowMetadataKey] = policy.NarrowToolAllowlist(nil, requested)
 	}
 	return r.store.SaveSession(&store.SessionMeta{
 		ID:        sessionID,
@@ -115,3 +120,42 @@ func (r *StandardResolver) ensureSession(sessionID string, metadata map[string]s
 		Metadata:  metadata,
 	})
 }
+
+// updateSession applies an event's tools_allow, skills_exclude and
+// notify_url to an existing session. Later events can only remove tools,
+// never widen the session's allowlist.
+func (r *StandardResolver) updateSession(sess *store.SessionMeta, metadata map[string]string) error {
+	changes := make(map[string]string)
+	if requested, ok := metadata[policy.ToolsAllowMetadataKey]; ok {
+		narrowed := policy.NarrowToolAllowlist(sess.Metadata, requested)
+		if current, restricted := sess.Metadata[policy.ToolsAllowMetadataKey]; !restricted || current != narrowed {
+			changes[policy.ToolsAllowMetadataKey] = narrowed
+		}
+	}
+	if exclude, ok := metadata[skill.ExcludeMetadataKey]; ok && sess.Metadata[skill.ExcludeMetadataKey] != exclude {
+		changes[skill.ExcludeMetadataKey] = exclude
+	}
+	if notifyURL, ok := metadata[NotifyURLMetadataKey]; ok && sess.Metadata[NotifyURLMetadataKey] != notifyURL {
+		changes[NotifyURLMetadataKey] = notifyURL
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// The metadata map is shared with the store index; update a copy.
+	updated := make(map[string]string, len(sess.Metadata)+len(changes))
+	for key, value := range sess.Metadata {
+		updated[key] = value
+	}
+	for key, value := range changes {
+		updated[key] = value
+	}
+	for _, key := range []string{NotifyURLMetadataKey, skill.ExcludeMetadataKey} {
+		if updated[key] == "" {
+			delete(updated, key)
+		}
+	}
+	sess.Metadata = updated
+	sess.UpdatedAt = time.Now()
+	return r.store.SaveSession(sess)
+}
@@ -0,0 +1,57 @@
+package knowledge
+
+import "strings"
+
+// Chunk splits text into pieces of at most size runes, preferring paragraph
+// and line boundaries. Consecutive chunks share up to overlap runes.
+func Chunk(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		return []string{text}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = appendChunk(chunks, runes[start:])
+			break
+		}
+		end = splitPoint(runes, start, end)
+		chunks = appendChunk(chunks, runes[start:end])
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// splitPoint moves end back to the last paragraph, line, or word break in the
+// second half of the window so chunks do not cut words in half.
+func splitPoint(runes []rune, start, end int) int {
+	min := start + (end-start)/2
+	for _, sep := range []string{"\n\n", "\n", " "} {
+		window := string(runes[min:end])
+		if idx := strings.LastIndex(window, sep); idx >= 0 {
+			return min + len([]rune(window[:idx])) + len([]rune(sep))
+		}
+	}
+	return end
+}
+
+func appendChunk(chunks []string, runes []rune) []string {
+	if chunk := strings.TrimSpace(string(runes)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,127 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/base64"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const confluencePageLimit = 50
+
+var (
+	confluenceBreakPattern = regexp.MustCompile(`(?i)</(p|h[1-6]|li|tr|div|pre|blockquote)>|<br\s*/?>`)
+	confluenceTagPattern   = regexp.MustCompile(`<[^>]+>`)
+	blankLinesPattern      = regexp.MustCompile(`\n{3,}`)
+)
+
+// ConfluenceConnector syncs every page in the configured Confluence spaces.
+type ConfluenceConnector struct {
+	baseURL   string
+	auth      string
+	spaceKeys []string
+	client    *http.Client
+}
+
+// NewConfluenceConnector expects baseURL to point at the wiki root, e.g.
+// https://example.atlassian.net/wiki.
+func NewConfluenceConnector(baseURL, email, apiToken string, spaceKeys []string, timeout time.Duration) (*ConfluenceConnector, error) {
+	if strings.TrimSpace(baseURL) == "" {
+		return nil, errors.InvalidInput("confluence base_url is required")
+	}
+	if strings.TrimSpace(email) == "" || strings.TrimSpace(apiToken) == "" {
+		return nil, errors.InvalidInput("confluence email and api_token are required")
+	}
+	if len(spaceKeys) == 0 {
+		return nil, errors.InvalidInput("confluence space_keys is required")
+	}
+	return &ConfluenceConnector{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		auth:      "Basic " + base64.StdEncoding.EncodeToString([]byte(email+":"+apiToken)),
+		spaceKeys: spaceKeys,
+		client:    &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (c *ConfluenceConnector) Name() string {
+	return "confluence"
+}
+
+type confluenceContentList struct {
+	Results []struct {
+		ID      string `json:"id"`
+		Title   string `json:"title"`
+		Version struct {
+			When time.Time `json:"when"`
+		} `json:"version"`
+		Body struct {
+			Storage struct {
+				Value string `json:"value"`
+			} `json:"storage"`
+		} `json:"body"`
+		Links struct {
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	} `json:"results"`
+	Size  int `json:"size"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"_links"`
+}
+
+func (c *ConfluenceConnector) Fetch(ctx context.Context) ([]Document, error) {
+	var docs []Document
+	for _, spaceKey := range c.spaceKeys {
+		for start := 0; ; start += confluencePageLimit {
+			query := url.Values{
+				"spaceKey": {strings.TrimSpace(spaceKey)},
+				"type":     {"page"},
+				"expand":   {"body.storage,version"},
+				"limit":    {strconv.Itoa(confluencePageLimit)},
+				"start":    {strconv.Itoa(start)},
+			}
+			var list confluenceContentList
+			headers := map[string]string{"Authorization": c.auth}
+			if err := getJSON(ctx, c.client, c.baseURL+"/rest/api/content?"+query.Encode(), headers, &list); err != nil {
+				return nil, err
+			}
+			for _, page := range list.Results {
+				docs = append(docs, Document{
+					ID:        page.ID,
+					Title:     page.Title,
+					URL:       c.pageURL(page.Links.WebUI),
+					Content:   confluenceStorageText(page.Body.Storage.Value),
+					UpdatedAt: page.Version.When,
+				})
+			}
+			if list.Links.Next == "" || len(list.Results) == 0 {
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+func (c *ConfluenceConnector) pageURL(webUI string) string {
+	if webUI == "" {
+		return ""
+	}
+	return c.baseURL + webUI
+}
+
+// confluenceStorageText flattens Confluence storage-format XHTML to plain text,
+// keeping block boundaries as line breaks.
+func confluenceStorageText(storage string) string {
+	text := confluenceBreakPattern.ReplaceAllString(storage, "\n")
+	text = confluenceTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
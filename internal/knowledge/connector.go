@@ -0,0 +1,21 @@
+package knowledge
+
+import (
+	"context"
+	"time"
+)
+
+// Document is a page pulled from an external knowledge source.
+type Document struct {
+	ID        string
+	Title     string
+	URL       string
+	Content   string
+	UpdatedAt time.Time
+}
+
+// Connector fetches documents from an external knowledge source.
+type Connector interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Document, error)
+}
@@ -0,0 +1,120 @@
+package knowledge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotionConnector_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v1/pages/abc":
+			w.Write([]byte(`{"id":"abc","url":"https://notion.so/abc","last_edited_time":"2026-05-01T10:00:00Z","properties":{"Name":{"type":"title","title":[{"plain_text":"On-call"}]}}}`))
+		case r.URL.Path == "/v1/blocks/abc/children" && r.URL.Query().Get("start_cursor") == "":
+			w.Write([]byte(`{"results":[{"type":"heading_1","heading_1":{"rich_text":[{"plain_text":"Escalation"}]}},{"type":"divider","divider":{}}],"has_more":true,"next_cursor":"c2"}`))
+		case r.URL.Path == "/v1/blocks/abc/children":
+			w.Write([]byte(`{"results":[{"type":"paragraph","paragraph":{"rich_text":[{"plain_text":"Page the "},{"plain_text":"lead."}]}}],"has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	connector, err := NewNotionConnector(server.URL, "secret", []string{"abc"}, time.Second)
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	docs, err := connector.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got %d", len(docs))
+	}
+	doc := docs[0]
+	if doc.Title != "On-call" || doc.Content != "Escalation\n\nPage the lead." || doc.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+}
+
+func TestConfluenceConnector_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "me@example.com" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("spaceKey") != "ENG" {
+			t.Errorf("unexpected space key: %s", r.URL.Query().Get("spaceKey"))
+		}
+		w.Write([]byte(`{"results":[{"id":"42","title":"Deploys","version":{"when":"2026-05-01T10:00:00.000Z"},"body":{"storage":{"value":"<h1>Deploys</h1><p>Run <code>make</code> &amp; ship.</p>"}},"_links":{"webui":"/spaces/ENG/pages/42"}}],"_links":{}}`))
+	}))
+	defer server.Close()
+
+	connector, err := NewConfluenceConnector(server.URL, "me@example.com", "token", []string{"ENG"}, time.Second)
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	docs, err := connector.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Content != "Deploys\nRun make & ship." || docs[0].URL != server.URL+"/spaces/ENG/pages/42" {
+		t.Fatalf("unexpected documents: %+v", docs)
+	}
+}
+
+func TestGoogleDriveConnector_FetchSkipsBinaryFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drive/v3/files":
+			if !strings.Contains(r.URL.Query().Get("q"), "'folder-1' in parents") {
+				t.Errorf("unexpected query: %s", r.URL.Query().Get("q"))
+			}
+			w.Write([]byte(`{"files":[
+				{"id":"doc","name":"Onboarding","mimeType":"application/vnd.google-apps.document","modifiedTime":"2026-05-01T10:00:00Z"},
+				{"id":"img","name":"Logo","mimeType":"image/png"},
+				{"id":"notes","name":"notes.md","mimeType":"text/markdown"}]}`))
+		case "/drive/v3/files/doc/export":
+			w.Write([]byte("Welcome aboard."))
+		case "/drive/v3/files/notes":
+			w.Write([]byte("# Notes"))
+		default:
+			t.Errorf("unexpected request: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	connector, err := NewGoogleDriveConnector(server.URL, "token", []string{"folder-1"}, time.Second)
+	if err != nil {
+		t.Fatalf("new connector: %v", err)
+	}
+	docs, err := connector.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Content != "Welcome aboard." || docs[1].Content != "# Notes" {
+		t.Fatalf("unexpected documents: %+v", docs)
+	}
+}
+
+func TestConnector_MapsAuthFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	connector, _ := NewNotionConnector(server.URL, "bad", []string{"abc"}, time.Second)
+	if _, err := connector.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission denied error, got %v", err)
+	}
+}
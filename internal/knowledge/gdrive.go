@@ -0,0 +1,133 @@
+package knowledge
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	DefaultGoogleDriveBaseURL = "https://www.googleapis.com"
+	googleDocMimeType         = "application/vnd.google-apps.document"
+)
+
+// GoogleDriveConnector syncs Google Docs and plain-text files stored directly
+// in the configured Drive folders.
+type GoogleDriveConnector struct {
+	baseURL     string
+	accessToken string
+	folderIDs   []string
+	client      *http.Client
+}
+
+func NewGoogleDriveConnector(baseURL, accessToken string, folderIDs []string, timeout time.Duration) (*GoogleDriveConnector, error) {
+	if strings.TrimSpace(accessToken) == "" {
+		return nil, errors.InvalidInput("google drive access_token is required")
+	}
+	if len(folderIDs) == 0 {
+		return nil, errors.InvalidInput("google drive folder_ids is required")
+	}
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = DefaultGoogleDriveBaseURL
+	}
+	return &GoogleDriveConnector{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accessToken: accessToken,
+		folderIDs:   folderIDs,
+		client:      &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (g *GoogleDriveConnector) Name() string {
+	return "gdrive"
+}
+
+type driveFileList struct {
+	Files []struct {
+		ID           string    `json:"id"`
+		Name         string    `json:"name"`
+		MimeType     string    `json:"mimeType"`
+		ModifiedTime time.Time `json:"modifiedTime"`
+		WebViewLink  string    `json:"webViewLink"`
+	} `json:"files"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+func (g *GoogleDriveConnector) Fetch(ctx context.Context) ([]Document, error) {
+	var docs []Document
+	for _, folderID := range g.folderIDs {
+		pageToken := ""
+		for {
+			query := url.Values{
+				"q":      {"'" + strings.TrimSpace(folderID) + "' in parents and trashed = false"},
+				"fields": {"nextPageToken,files(id,name,mimeType,modifiedTime,webViewLink)"},
+			}
+			if pageToken != "" {
+				query.Set("pageToken", pageToken)
+			}
+			var list driveFileList
+			if err := getJSON(ctx, g.client, g.baseURL+"/drive/v3/files?"+query.Encode(), g.headers(), &list); err != nil {
+				return nil, err
+			}
+			for _, file := range list.Files {
+				endpoint, ok := g.contentURL(file.ID, file.MimeType)
+				if !ok {
+					continue
+				}
+				content, err := g.download(ctx, endpoint)
+				if err != nil {
+					return nil, err
+				}
+				docs = append(docs, Document{
+					ID:        file.ID,
+					Title:     file.Name,
+					URL:       file.WebViewLink,
+					Content:   content,
+					UpdatedAt: file.ModifiedTime,
+				})
+			}
+			if list.NextPageToken == "" {
+				break
+			}
+			pageToken = list.NextPageToken
+		}
+	}
+	return docs, nil
+}
+
+// contentURL returns the download endpoint for files that can be read as
+// text. Google Docs are exported; binary formats are skipped.
+func (g *GoogleDriveConnector) contentURL(fileID, mimeType string) (string, bool) {
+	base := g.baseURL + "/drive/v3/files/" + url.PathEscape(fileID)
+	switch {
+	case mimeType == googleDocMimeType:
+		return base + "/export?" + url.Values{"mimeType": {"text/plain"}}.Encode(), true
+	case strings.HasPrefix(mimeType, "text/"):
+		return base + "?alt=media", true
+	default:
+		return "", false
+	}
+}
+
+func (g *GoogleDriveConnector) download(ctx context.Context, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to build google drive request")
+	}
+	for key, value := range g.headers() {
+		req.Header.Set(key, value)
+	}
+	body, err := doRequest(g.client, req)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (g *GoogleDriveConnector) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + g.accessToken}
+}
@@ -0,0 +1,67 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const maxErrorBodyBytes = 512
+
+// doRequest sends req and returns the response body, mapping HTTP failures to
+// the repo error categories so callers can tell auth problems from outages.
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.WrapWithCategory(err, "knowledge source request failed", errors.ErrTransient)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WrapWithCategory(err, "failed to read knowledge source response", errors.ErrTransient)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxErrorBodyBytes {
+			msg = msg[:maxErrorBodyBytes]
+		}
+		detail := fmt.Sprintf("%s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, msg)
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return nil, errors.PermissionDenied(detail)
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, errors.NotFound(detail)
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return nil, errors.Transient(detail)
+		default:
+			return nil, errors.InvalidInput(detail)
+		}
+	}
+	return body, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to build knowledge source request")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	body, err := doRequest(client, req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return errors.Wrap(err, "failed to decode knowledge source response")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	DefaultNotionBaseURL = "https://api.notion.com"
+	notionVersion        = "2022-06-28"
+)
+
+// NotionConnector syncs the top-level blocks of the configured Notion pages.
+type NotionConnector struct {
+	baseURL string
+	token   string
+	pageIDs []string
+	client  *http.Client
+}
+
+func NewNotionConnector(baseURL, token string, pageIDs []string, timeout time.Duration) (*NotionConnector, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, errors.InvalidInput("notion token is required")
+	}
+	if len(pageIDs) == 0 {
+		return nil, errors.InvalidInput("notion page_ids is required")
+	}
+	if strings.TrimSpace(baseURL) == "" {
+		baseURL = DefaultNotionBaseURL
+	}
+	return &NotionConnector{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		pageIDs: pageIDs,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (n *NotionConnector) Name() string {
+	return "notion"
+}
+
+type notionPage struct {
+	ID             string                     `json:"id"`
+	URL            string                     `json:"url"`
+	LastEditedTime time.Time                  `json:"last_edited_time"`
+	Properties     map[string]json.RawMessage `json:"properties"`
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+}
+
+type notionBlockList struct {
+	Results    []map[string]json.RawMessage `json:"results"`
+	HasMore    bool                         `json:"has_more"`
+	NextCursor string                       `json:"next_cursor"`
+}
+
+func (n *NotionConnector) Fetch(ctx context.Context) ([]Document, error) {
+	docs := make([]Document, 0, len(n.pageIDs))
+	for _, pageID := range n.pageIDs {
+		doc, err := n.fetchPage(ctx, strings.TrimSpace(pageID))
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (n *NotionConnector) fetchPage(ctx context.Context, pageID string) (Document, error) {
+	var page notionPage
+	if err := getJSON(ctx, n.client, n.baseURL+"/v1/pages/"+url.PathEscape(pageID), n.headers(), &page); err != nil {
+		return Document{}, err
+	}
+
+	var lines []string
+	cursor := ""
+	for {
+		query := url.Values{"page_size": {"100"}}
+		if cursor != "" {
+			query.Set("start_cursor", cursor)
+		}
+		var blocks notionBlockList
+		endpoint := n.baseURL + "/v1/blocks/" + url.PathEscape(pageID) + "/children?" + query.Encode()
+		if err := getJSON(ctx, n.client, endpoint, n.headers(), &blocks); err != nil {
+			return Document{}, err
+		}
+		for _, block := range blocks.Results {
+			if text := notionBlockText(block); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		if !blocks.HasMore || blocks.NextCursor == "" {
+			break
+		}
+		cursor = blocks.NextCursor
+	}
+
+	id := page.ID
+	if id == "" {
+		id = pageID
+	}
+	return Document{
+		ID:        id,
+		Title:     notionTitle(page.Properties),
+		URL:       page.URL,
+		Content:   strings.Join(lines, "\n\n"),
+		UpdatedAt: page.LastEditedTime,
+	}, nil
+}
+
+func (n *NotionConnector) headers() map[string]string {
+	return map[string]string{
+		"Authorization":  "Bearer " + n.token,
+		"Notion-Version": notionVersion,
+	}
+}
+
+// notionBlockText extracts the plain text of any block type that carries
+// rich_text (paragraphs, headings, list items, quotes, code, and so on).
+func notionBlockText(block map[string]json.RawMessage) string {
+	var blockType string
+	if err := json.Unmarshal(block["type"], &blockType); err != nil || blockType == "" {
+		return ""
+	}
+	var body struct {
+		RichText []notionRichText `json:"rich_text"`
+	}
+	if err := json.Unmarshal(block[blockType], &body); err != nil {
+		return ""
+	}
+	return joinRichText(body.RichText)
+}
+
+func notionTitle(properties map[string]json.RawMessage) string {
+	for _, raw := range properties {
+		var prop struct {
+			Type  string           `json:"type"`
+			Title []notionRichText `json:"title"`
+		}
+		if err := json.Unmarshal(raw, &prop); err == nil && prop.Type == "title" {
+			return joinRichText(prop.Title)
+		}
+	}
+	return ""
+}
+
+func joinRichText(parts []notionRichText) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(part.PlainText)
+	}
+	return strings.TrimSpace(sb.String())
+}
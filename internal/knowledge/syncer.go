@@ -0,0 +1,207 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+// Embedder turns a chunk of text into a vector.
+type Embedder func(ctx context.Context, text string) ([]float32, error)
+
+// VectorStore persists embedded chunks into a named collection.
+type VectorStore interface {
+	UpsertVector(collection, id string, vector []float32, metadata map[string]string, content string) error
+}
+
+// SyncerConfig controls how documents are chunked and where they are stored.
+type SyncerConfig struct {
+	Collection   string
+	Interval     time.Duration
+	ChunkSize    int
+	ChunkOverlap int
+	// StatePath records the last synced revision of each document so
+	// unchanged pages are not re-embedded. Empty keeps state in memory only.
+	StatePath string
+}
+
+// Syncer periodically pulls documents from connectors, chunks them, and
+// upserts the embedded chunks into the knowledge collection.
+type Syncer struct {
+	cfg        SyncerConfig
+	store      VectorStore
+	embed      Embedder
+	connectors []Connector
+
+	mu      sync.Mutex
+	started bool
+	synced  map[string]time.Time
+}
+
+func NewSyncer(cfg SyncerConfig, store VectorStore, embed Embedder, connectors ...Connector) (*Syncer, error) {
+	if cfg.Collection == "" {
+		return nil, errors.InvalidInput("knowledge collection is required")
+	}
+	if store == nil || embed == nil {
+		return nil, errors.InvalidInput("knowledge syncer requires a store and an embedder")
+	}
+	if cfg.Interval <= 0 {
+		return nil, errors.InvalidInput("knowledge sync interval must be positive")
+	}
+
+	s := &Syncer{
+		cfg:        cfg,
+		store:      store,
+		embed:      embed,
+		connectors: connectors,
+		synced:     make(map[string]time.Time),
+	}
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start syncs immediately and then on every interval until ctx is done.
+func (s *Syncer) Start(ctx context.Context) {
+	s.mu.Lock()
+	if s.started || len(s.connectors) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("Knowledge sync failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				s.mu.Lock()
+				s.started = false
+				s.mu.Unlock()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Sync runs one pass over every connector and returns the number of documents
+// that were (re-)ingested. A failing connector does not stop the others.
+func (s *Syncer) Sync(ctx context.Context) (int, error) {
+	var errs []error
+	ingested := 0
+	for _, connector := range s.connectors {
+		docs, err := connector.Fetch(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", connector.Name(), err))
+			continue
+		}
+		for _, doc := range docs {
+			changed, err := s.ingest(ctx, connector.Name(), doc)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s document %s: %w", connector.Name(), doc.ID, err))
+				continue
+			}
+			if changed {
+				ingested++
+			}
+		}
+		slog.Info("Knowledge source synced", "source", connector.Name(), "documents", len(docs))
+	}
+
+	if ingested > 0 {
+		if err := s.saveState(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return ingested, stdErrors.Join(errs...)
+}
+
+func (s *Syncer) ingest(ctx context.Context, source string, doc Document) (bool, error) {
+	key := source + ":" + doc.ID
+	s.mu.Lock()
+	last, seen := s.synced[key]
+	s.mu.Unlock()
+	if seen && !doc.UpdatedAt.IsZero() && !doc.UpdatedAt.After(last) {
+		return false, nil
+	}
+
+	for i, chunk := range Chunk(doc.Content, s.cfg.ChunkSize, s.cfg.ChunkOverlap) {
+		// The title is embedded with each chunk for context but kept out of
+		// the stored content, which carries it as metadata instead.
+		embedText := chunk
+		if doc.Title != "" {
+			embedText = doc.Title + "\n\n" + chunk
+		}
+		vector, err := s.embed(ctx, embedText)
+		if err != nil {
+			return false, fmt.Errorf("embed chunk %d: %w", i, err)
+		}
+		metadata := map[string]string{
+			"source": source,
+			"doc_id": doc.ID,
+			"title":  doc.Title,
+			"url":    doc.URL,
+			"chunk":  strconv.Itoa(i),
+		}
+		if err := s.store.UpsertVector(s.cfg.Collection, key+"#"+strconv.Itoa(i), vector, metadata, chunk); err != nil {
+			return false, fmt.Errorf("upsert chunk %d: %w", i, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.synced[key] = doc.UpdatedAt
+	s.mu.Unlock()
+	return true, nil
+}
+
+func (s *Syncer) loadState() error {
+	if s.cfg.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.cfg.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read knowledge sync state")
+	}
+	if err := json.Unmarshal(data, &s.synced); err != nil {
+		return errors.Wrap(err, "failed to parse knowledge sync state")
+	}
+	return nil
+}
+
+func (s *Syncer) saveState() error {
+	if s.cfg.StatePath == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.synced, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to encode knowledge sync state")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.cfg.StatePath), 0755); err != nil {
+		return errors.Wrap(err, "failed to create knowledge state directory")
+	}
+	if err := os.WriteFile(s.cfg.StatePath, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write knowledge sync state")
+	}
+	return nil
+}
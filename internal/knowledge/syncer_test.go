@@ -0,0 +1,126 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConnector struct {
+	name string
+	docs []Document
+	err  error
+}
+
+func (f *fakeConnector) Name() string { return f.name }
+
+func (f *fakeConnector) Fetch(ctx context.Context) ([]Document, error) {
+	return f.docs, f.err
+}
+
+type upsert struct {
+	collection string
+	id         string
+	metadata   map[string]string
+	content    string
+}
+
+type fakeVectorStore struct {
+	mu      sync.Mutex
+	upserts []upsert
+}
+
+func (f *fakeVectorStore) UpsertVector(collection, id string, vector []float32, metadata map[string]string, content string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.upserts = append(f.upserts, upsert{collection: collection, id: id, metadata: metadata, content: content})
+	return nil
+}
+
+func fakeEmbed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func TestChunk_SplitsOnBoundariesWithOverlap(t *testing.T) {
+	text := strings.Repeat("alpha beta gamma delta. ", 20)
+	chunks := Chunk(text, 100, 20)
+	if len(chunks) < 5 {
+		t.Fatalf("expected text to be split into several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if len([]rune(chunk)) > 100 {
+			t.Fatalf("chunk %d exceeds size: %d", i, len(chunk))
+		}
+		if strings.HasSuffix(chunk, "alph") || strings.HasPrefix(chunk, "lpha") {
+			t.Fatalf("chunk %d split a word: %q", i, chunk)
+		}
+	}
+	if got := Chunk("  ", 100, 10); got != nil {
+		t.Fatalf("expected no chunks for blank text, got %v", got)
+	}
+}
+
+func TestSyncer_IngestsChangedDocumentsOnly(t *testing.T) {
+	updated := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	connector := &fakeConnector{name: "notion", docs: []Document{
+		{ID: "page-1", Title: "Runbook", URL: "https://example.com/runbook", Content: "Restart the worker.\n\nThen check the queue.", UpdatedAt: updated},
+	}}
+	vectors := &fakeVectorStore{}
+	statePath := filepath.Join(t.TempDir(), "knowledge", "state.json")
+
+	syncer, err := NewSyncer(SyncerConfig{Collection: "team-docs", Interval: time.Hour, ChunkSize: 30, StatePath: statePath}, vectors, fakeEmbed, connector)
+	if err != nil {
+		t.Fatalf("new syncer: %v", err)
+	}
+
+	n, err := syncer.Sync(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("first sync = (%d, %v), want (1, nil)", n, err)
+	}
+	if len(vectors.upserts) != 2 {
+		t.Fatalf("expected 2 chunks upserted, got %d", len(vectors.upserts))
+	}
+	first := vectors.upserts[0]
+	if first.collection != "team-docs" || first.id != "notion:page-1#0" {
+		t.Fatalf("unexpected upsert target: %+v", first)
+	}
+	if first.metadata["title"] != "Runbook" || first.metadata["url"] != "https://example.com/runbook" {
+		t.Fatalf("unexpected metadata: %v", first.metadata)
+	}
+
+	// A fresh syncer reading the saved state skips the unchanged page.
+	restarted, err := NewSyncer(SyncerConfig{Collection: "team-docs", Interval: time.Hour, ChunkSize: 30, StatePath: statePath}, vectors, fakeEmbed, connector)
+	if err != nil {
+		t.Fatalf("restart syncer: %v", err)
+	}
+	if n, err := restarted.Sync(context.Background()); err != nil || n != 0 {
+		t.Fatalf("unchanged sync = (%d, %v), want (0, nil)", n, err)
+	}
+
+	connector.docs[0].UpdatedAt = updated.Add(time.Minute)
+	if n, err := restarted.Sync(context.Background()); err != nil || n != 1 {
+		t.Fatalf("edited sync = (%d, %v), want (1, nil)", n, err)
+	}
+}
+
+func TestSyncer_ContinuesPastFailingConnector(t *testing.T) {
+	broken := &fakeConnector{name: "confluence", err: fmt.Errorf("unauthorized")}
+	working := &fakeConnector{name: "gdrive", docs: []Document{{ID: "doc", Content: "hello"}}}
+	vectors := &fakeVectorStore{}
+
+	syncer, err := NewSyncer(SyncerConfig{Collection: "knowledge", Interval: time.Hour}, vectors, fakeEmbed, broken, working)
+	if err != nil {
+		t.Fatalf("new syncer: %v", err)
+	}
+	n, err := syncer.Sync(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "confluence") {
+		t.Fatalf("expected confluence error, got %v", err)
+	}
+	if n != 1 || len(vectors.upserts) != 1 {
+		t.Fatalf("expected working connector to sync, got n=%d upserts=%d", n, len(vectors.upserts))
+	}
+}
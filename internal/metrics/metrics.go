@@ -0,0 +1,59 @@
+// Package metrics keeps process-wide event counters. Counters are exposed as a
+// JSON snapshot on /api/v1/metrics.
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	mu       sync.Mutex
+	counters = make(map[string]int64)
+)
+
+// Inc increments the counter name. Labels are key/value pairs and produce a
+// distinct series, rendered as name{key="value",...}.
+func Inc(name string, labels ...string) {
+	Add(name, 1, labels...)
+}
+
+// Add increases the counter name by delta.
+func Add(name string, delta int64, labels ...string) {
+	key := seriesKey(name, labels)
+	mu.Lock()
+	defer mu.Unlock()
+	counters[key] += delta
+}
+
+// Value returns the current value of a counter series.
+func Value(name string, labels ...string) int64 {
+	key := seriesKey(name, labels)
+	mu.Lock()
+	defer mu.Unlock()
+	return counters[key]
+}
+
+// Snapshot returns a copy of all counters.
+func Snapshot() map[string]int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make(map[string]int64, len(counters))
+	for k, v := range counters {
+		out[k] = v
+	}
+	return out
+}
+
+func seriesKey(name string, labels []string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labels[i+1]+`"`)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import "testing"
+
+func TestInc_SeparatesLabelledSeries(t *testing.T) {
+	Inc("test_events_total", "model", "a")
+	Inc("test_events_total", "model", "a")
+	Add("test_events_total", 3, "model", "b")
+
+	if got := Value("test_events_total", "model", "a"); got != 2 {
+		t.Fatalf("model a = %d, want 2", got)
+	}
+	snapshot := Snapshot()
+	if got := snapshot[`test_events_total{model="b"}`]; got != 3 {
+		t.Fatalf("snapshot model b = %d, want 3", got)
+	}
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit states reported by CircuitStates.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitState is the breaker state of one model.
+type CircuitState struct {
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+// circuitBreaker trips a model after threshold consecutive failures. While
+// open, requests skip the model until cooldown elapses; then a single probe
+// request is let through and its outcome closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+	now       func() time.Time
+}
+
+type circuit struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a request may be sent to model. After the cooldown
+// it admits one probe at a time.
+func (b *circuitBreaker) allow(model string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[model]
+	if !ok || c.openedAt.IsZero() {
+		return true
+	}
+	if c.probing || b.now().Before(c.openedAt.Add(b.cooldown)) {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+func (b *circuitBreaker) recordSuccess(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, model)
+}
+
+// release ends a probe without an outcome, e.g. when the caller cancelled.
+func (b *circuitBreaker) release(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[model]; ok {
+		c.probing = false
+	}
+}
+
+// recordFailure counts a failure and reports whether it tripped the circuit.
+func (b *circuitBreaker) recordFailure(model string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[model]
+	if !ok {
+		c = &circuit{}
+		b.circuits[model] = c
+	}
+	c.failures++
+	wasOpen := !c.openedAt.IsZero()
+	if c.probing || c.failures >= b.threshold {
+		c.openedAt = b.now()
+		c.probing = false
+	}
+	return !wasOpen && !c.openedAt.IsZero()
+}
+
+func (b *circuitBreaker) states(models []string) map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	out := make(map[string]CircuitState, len(models))
+	for _, model := range models {
+		c, ok := b.circuits[model]
+		if !ok {
+			out[model] = CircuitState{State: CircuitClosed}
+			continue
+		}
+		state := CircuitState{State: CircuitClosed, ConsecutiveFailures: c.failures}
+		if !c.openedAt.IsZero() {
+			state.OpenedAt = c.openedAt
+			state.RetryAt = c.openedAt.Add(b.cooldown)
+			state.State = CircuitOpen
+			if c.probing || !now.Before(state.RetryAt) {
+				state.State = CircuitHalfOpen
+			}
+		}
+		out[model] = state
+	}
+	return out
+}
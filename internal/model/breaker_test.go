@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+type failingProvider struct {
+	countingProvider
+	fail bool
+}
+
+func (p *failingProvider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.calls++
+	if p.fail {
+		return nil, errors.New("upstream unavailable")
+	}
+	return &contract.CompletionResponse{Content: "primary"}, nil
+}
+
+func TestCircuitBreaker_OpensAndProbes(t *testing.T) {
+	breaker := newCircuitBreaker(2, time.Minute)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	if breaker.recordFailure("m") {
+		t.Fatal("expected first failure to leave circuit closed")
+	}
+	if !breaker.recordFailure("m") {
+		t.Fatal("expected threshold failure to open circuit")
+	}
+	if breaker.allow("m") {
+		t.Fatal("expected open circuit to reject requests")
+	}
+	if got := breaker.states([]string{"m"})["m"].State; got != CircuitOpen {
+		t.Fatalf("expected open state, got %s", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !breaker.allow("m") {
+		t.Fatal("expected probe after cooldown")
+	}
+	if breaker.allow("m") {
+		t.Fatal("expected only one concurrent probe")
+	}
+	if breaker.recordFailure("m") {
+		t.Fatal("expected failed probe to re-open without reporting a new trip")
+	}
+	if breaker.allow("m") {
+		t.Fatal("expected failed probe to restart cooldown")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !breaker.allow("m") {
+		t.Fatal("expected second probe after cooldown")
+	}
+	breaker.recordSuccess("m")
+	if got := breaker.states([]string{"m"})["m"]; got.State != CircuitClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed circuit after success, got %+v", got)
+	}
+}
+
+func TestRouter_CircuitRoutesToFallback(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{
+		Fallback:       "backup",
+		CircuitBreaker: config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 2, Cooldown: "1m"},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	primary := &failingProvider{fail: true}
+	backup := &countingProvider{}
+	router.providers["primary"] = primary
+	router.providers["backup"] = backup
+
+	req := contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "hi"}}}
+	for i := 0; i < 3; i++ {
+		resp, err := router.Route(context.Background(), "primary", req)
+		if err != nil || resp.Content != "answer" {
+			t.Fatalf("Route %d failed: %v %v", i, resp, err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Fatalf("expected open circuit to skip primary, got %d calls", primary.calls)
+	}
+	if backup.calls != 3 {
+		t.Fatalf("expected every request served by fallback, got %d calls", backup.calls)
+	}
+
+	states := router.CircuitStates()
+	if states["primary"].State != CircuitOpen {
+		t.Fatalf("expected primary circuit open, got %+v", states["primary"])
+	}
+	if states["backup"].State != CircuitClosed {
+		t.Fatalf("expected backup circuit closed, got %+v", states["backup"])
+	}
+}
+
+func TestRouter_CircuitStatesNilWhenDisabled(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	if states := router.CircuitStates(); states != nil {
+		t.Fatalf("expected nil states when breaker disabled, got %v", states)
+	}
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// CompletionCache is an in-memory LRU of completion responses keyed by a hash
+// of model, messages and tools. Entries expire after ttl.
+type CompletionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	key       string
+	resp      contract.CompletionResponse
+	expiresAt time.Time
+}
+
+// NewCompletionCache creates a cache holding at most maxEntries responses.
+func NewCompletionCache(ttl time.Duration, maxEntries int) *CompletionCache {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	return &CompletionCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// CacheKey hashes the parts of a request that determine its completion.
+func CacheKey(model string, req contract.CompletionRequest) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	enc := json.NewEncoder(h)
+	_ = enc.Encode(req.Messages)
+	_ = enc.Encode(req.Tools)
+	_ = enc.Encode(req.ResponseFormat)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheable reports whether req may be answered from the cache. Requests
+// with sampling overrides are best-of-N samples of one prompt; serving them
+// from the cache would turn N samples into N copies of the first.
+func cacheable(req contract.CompletionRequest) bool {
+	return req.Temperature == nil && req.Seed == nil
+}
+
+// Get returns a copy of the cached response for key, if present and fresh.
+func (c *CompletionCache) Get(key string) (*contract.CompletionResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return cloneResponse(&entry.resp), true
+}
+
+// Put stores a copy of resp under key, evicting the least recently used
+// entry when full.
+func (c *CompletionCache) Put(key string, resp *contract.CompletionResponse) {
+	if resp == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.resp = *cloneResponse(resp)
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, resp: *cloneResponse(resp), expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted.
+func (c *CompletionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *CompletionCache) removeLocked(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+func cloneResponse(resp *contract.CompletionResponse) *contract.CompletionResponse {
+	out := &contract.CompletionResponse{Content: resp.Content}
+	if len(resp.ToolCalls) > 0 {
+		out.ToolCalls = make([]*contract.ToolCall, len(resp.ToolCalls))
+		for i, call := range resp.ToolCalls {
+			if call == nil {
+				continue
+			}
+			copied := *call
+			out.ToolCalls[i] = &copied
+		}
+	}
+	if resp.Usage != nil {
+		usage := *resp.Usage
+		out.Usage = &usage
+	}
+	return out
+}
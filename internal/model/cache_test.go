@@ -0,0 +1,148 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.calls++
+	return &contract.CompletionResponse{Content: "answer"}, nil
+}
+
+func (p *countingProvider) GenerateStream(ctx context.Context, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	return nil, nil
+}
+
+func (p *countingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+func (p *countingProvider) Name() string                     { return "counting" }
+func (p *countingProvider) Type() string                     { return "test" }
+func (p *countingProvider) Health(ctx context.Context) error { return nil }
+
+func TestCacheKey(t *testing.T) {
+	req := contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "plan"}}}
+	withTools := req
+	withTools.Tools = []contract.ToolDef{{Name: "time"}}
+
+	if CacheKey("a", req) != CacheKey("a", req) {
+		t.Fatal("expected identical requests to share a key")
+	}
+	if CacheKey("a", req) == CacheKey("b", req) {
+		t.Fatal("expected model to change the key")
+	}
+	if CacheKey("a", req) == CacheKey("a", withTools) {
+		t.Fatal("expected tools to change the key")
+	}
+}
+
+func TestCompletionCache_ExpiryAndEviction(t *testing.T) {
+	cache := NewCompletionCache(time.Minute, 2)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("a", &contract.CompletionResponse{Content: "A"})
+	cache.Put("b", &contract.CompletionResponse{Content: "B"})
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.Put("c", &contract.CompletionResponse{Content: "C"})
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("expected least recently used entry b to be evicted")
+	}
+
+	resp, ok := cache.Get("a")
+	if !ok || resp.Content != "A" {
+		t.Fatalf("expected a hit for a, got %v %v", resp, ok)
+	}
+	resp.Content = "mutated"
+	if again, _ := cache.Get("a"); again.Content != "A" {
+		t.Fatalf("expected cached copy to be unaffected, got %q", again.Content)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("expected entry to expire after ttl")
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("expected expired entry removed, len=%d", cache.Len())
+	}
+}
+
+func TestRouter_RouteUsesCompletionCache(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{
+		Cache: config.ModelCacheConfig{Enabled: true, TTL: "1m", MaxEntries: 8},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	provider := &countingProvider{}
+	router.providers["m"] = provider
+
+	req := contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "reflect"}}}
+	for i := 0; i < 2; i++ {
+		resp, err := router.Route(context.Background(), "m", req)
+		if err != nil || resp.Content != "answer" {
+			t.Fatalf("Route failed: %v %v", resp, err)
+		}
+	}
+	if provider.calls != 1 {
+		t.Fatalf("expected one provider call, got %d", provider.calls)
+	}
+
+	req.Messages = append(req.Messages, contract.Message{Role: "user", Content: "again"})
+	if _, err := router.Route(context.Background(), "m", req); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if provider.calls != 2 {
+		t.Fatalf("expected cache miss for new messages, got %d calls", provider.calls)
+	}
+}
+
+func TestRouter_RouteSkipsCacheForBestOfNSamples(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{
+		Cache: config.ModelCacheConfig{Enabled: true, TTL: "1m", MaxEntries: 8},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	provider := &countingProvider{}
+	router.providers["m"] = provider
+
+	// Best-of-N sends the same prompt once with provider defaults and once
+	// per extra sample with its own temperature and seed.
+	req := contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "answer carefully"}}}
+	if _, err := router.Route(context.Background(), "m", req); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	for seed := int64(1); seed <= 2; seed++ {
+		sample := req
+		temperature, sampleSeed := 0.8, seed
+		sample.Temperature, sample.Seed = &temperature, &sampleSeed
+		for i := 0; i < 2; i++ {
+			if _, err := router.Route(context.Background(), "m", sample); err != nil {
+				t.Fatalf("Route failed: %v", err)
+			}
+		}
+	}
+	if provider.calls != 5 {
+		t.Fatalf("expected every sample to reach the provider, got %d calls", provider.calls)
+	}
+
+	if _, err := router.Route(context.Background(), "m", req); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if provider.calls != 5 {
+		t.Fatalf("expected the unsampled request to stay cached, got %d calls", provider.calls)
+	}
+}
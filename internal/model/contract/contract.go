@@ -5,12 +5,51 @@ type Message struct {
 	Content    string      `json:"content"`
 	ToolCallID string      `json:"tool_call_id,omitempty"`
 	ToolCalls  []*ToolCall `json:"tool_calls,omitempty"`
+	// Images are sent alongside Content on user messages. Providers without
+	// vision support drop them.
+	Images []ImagePart `json:"images,omitempty"`
+}
+
+// ImagePart is an image referenced by URL or embedded as base64 Data.
+// MIMEType is required with Data.
+type ImagePart struct {
+	URL      string `json:"url,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// DataURL returns URL, or Data encoded as a data: URL.
+func (p ImagePart) DataURL() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	return "data:" + p.MIMEType + ";base64," + p.Data
 }
 
 type CompletionRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Tools    []ToolDef `json:"tools,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Tools          []ToolDef       `json:"tools,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Temperature and Seed override the provider's sampling defaults when
+	// set. Providers without support ignore them.
+	Temperature *float64 `json:"temperature,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+}
+
+// Response format types. Providers without native support ignore the
+// format, so callers must still validate the returned content.
+const (
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat asks the provider to constrain output to JSON. Schema and
+// Name are used only with ResponseFormatJSONSchema.
+type ResponseFormat struct {
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name,omitempty"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
 }
 
 type ToolDef struct {
@@ -22,6 +61,19 @@ type ToolDef struct {
 type CompletionResponse struct {
 	Content   string      `json:"content"`
 	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+	Usage     *Usage      `json:"usage,omitempty"`
+	// Truncated is set when the provider cut the response at a size limit,
+	// dropping content, tool calls or stream events.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Usage is the token accounting reported by a provider for one completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// CachedPromptTokens is the part of PromptTokens served from the
+	// provider's prompt cache.
+	CachedPromptTokens int `json:"cached_prompt_tokens,omitempty"`
 }
 
 type ToolCall struct {
@@ -29,3 +81,13 @@ type ToolCall struct {
 	Name  string `json:"name"`
 	Input string `json:"input"`
 }
+
+// StreamChunk is one incremental piece of a streamed completion. Delta carries
+// new content text; the final chunk has Done set and carries any tool calls.
+// A chunk with Err set terminates the stream.
+type StreamChunk struct {
+	Delta     string      `json:"delta,omitempty"`
+	ToolCalls []*ToolCall `json:"tool_calls,omitempty"`
+	Done      bool        `json:"done,omitempty"`
+	Err       error       `json:"-"`
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/counter"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+const (
+	// maxTrackedSessions bounds the per-session totals kept in memory; the
+	// least recently charged sessions are dropped first.
+	maxTrackedSessions = 1024
+	// dailyCostTTL keeps a day's spend a little past midnight UTC.
+	dailyCostTTL = 25 * time.Hour
+	// microsPerUSD scales spend to the integer counters the store keeps.
+	microsPerUSD = 1e6
+)
+
+// CostTracker accumulates completion spend per session and per UTC day using
+// the per-1k-token prices from models.pricing. Daily spend lives in a
+// counter.Store so the budget survives restarts and, with Redis, is shared
+// across replicas.
+type CostTracker struct {
+	mu             sync.Mutex
+	prices         map[string]config.ModelPricing
+	dailyLimit     float64
+	spend          counter.Store
+	counterTimeout time.Duration
+	sessionOrder   *list.List
+	sessions       map[string]*list.Element
+	now            func() time.Time
+}
+
+type sessionCost struct {
+	id   string
+	cost float64
+}
+
+// NewCostTracker creates a tracker that keeps daily spend in memory until
+// SetCounterStore is called. A dailyLimitUSD of zero disables enforcement.
+func NewCostTracker(pricing []config.ModelPricing, dailyLimitUSD float64) *CostTracker {
+	prices := make(map[string]config.ModelPricing, len(pricing))
+	for _, p := range pricing {
+		if name := strings.TrimSpace(p.Model); name != "" {
+			prices[name] = p
+		}
+	}
+	return &CostTracker{
+		prices:         prices,
+		dailyLimit:     dailyLimitUSD,
+		spend:          counter.NewMemoryStore(),
+		counterTimeout: 5 * time.Second,
+		sessionOrder:   list.New(),
+		sessions:       make(map[string]*list.Element),
+		now:            time.Now,
+	}
+}
+
+// SetCounterStore keeps daily spend in store, such as the governance usage
+// file or Redis.
+func (t *CostTracker) SetCounterStore(store counter.Store, timeout time.Duration) {
+	if store == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spend = store
+	if timeout > 0 {
+		t.counterTimeout = timeout
+	}
+}
+
+// Cost returns the USD price of usage on model. Models without a pricing
+// entry are free.
+func (t *CostTracker) Cost(model string, usage contract.Usage) float64 {
+	price, ok := t.prices[model]
+	if !ok {
+		return 0
+	}
+	cached := usage.CachedPromptTokens
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	cachedPrice := price.CachedInputPer1K
+	if cachedPrice <= 0 {
+		cachedPrice = price.InputPer1K
+	}
+	return float64(usage.PromptTokens-cached)/1000*price.InputPer1K +
+		float64(cached)/1000*cachedPrice +
+		float64(usage.CompletionTokens)/1000*price.OutputPer1K
+}
+
+// Record adds the cost of usage to the session and daily totals and returns it.
+func (t *CostTracker) Record(sessionID string, model string, usage contract.Usage) float64 {
+	cost := t.Cost(model, usage)
+	if cost == 0 {
+		return 0
+	}
+
+	store, ctx, cancel := t.counter()
+	defer cancel()
+	if _, err := store.IncrBy(ctx, t.dailyKey(), int64(math.Round(cost*microsPerUSD)), dailyCostTTL); err != nil {
+		slog.Warn("Failed to record completion cost", "model", model, "cost_usd", cost, "error", err)
+	}
+	if sessionID != "" {
+		t.addSessionCost(sessionID, cost)
+	}
+	return cost
+}
+
+// CheckBudget fails once today's spend has reached the daily limit.
+func (t *CostTracker) CheckBudget() error {
+	if t.dailyLimit <= 0 {
+		return nil
+	}
+	daily, err := t.dailySpend()
+	if err != nil {
+		return heikeErrors.WrapWithCategory(err, "read daily cost", heikeErrors.ErrTransient)
+	}
+	if daily < t.dailyLimit {
+		return nil
+	}
+	return fmt.Errorf("daily cost limit of $%.2f reached (spent $%.4f): %w", t.dailyLimit, daily, heikeErrors.ErrPermissionDenied)
+}
+
+// DailySpend returns today's total spend in USD, or zero when the store
+// cannot be read.
+func (t *CostTracker) DailySpend() float64 {
+	daily, _ := t.dailySpend()
+	return daily
+}
+
+// SessionSpend returns the total spend for a session in USD.
+func (t *CostTracker) SessionSpend(sessionID string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.sessions[sessionID]; ok {
+		return elem.Value.(*sessionCost).cost
+	}
+	return 0
+}
+
+func (t *CostTracker) dailySpend() (float64, error) {
+	store, ctx, cancel := t.counter()
+	defer cancel()
+	micros, err := store.Get(ctx, t.dailyKey())
+	if err != nil {
+		return 0, err
+	}
+	return float64(micros) / microsPerUSD, nil
+}
+
+func (t *CostTracker) counter() (counter.Store, context.Context, context.CancelFunc) {
+	t.mu.Lock()
+	store, timeout := t.spend, t.counterTimeout
+	t.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return store, ctx, cancel
+}
+
+func (t *CostTracker) dailyKey() string {
+	return "cost:" + t.now().UTC().Format("2006-01-02")
+}
+
+func (t *CostTracker) addSessionCost(sessionID string, cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.sessions[sessionID]; ok {
+		elem.Value.(*sessionCost).cost += cost
+		t.sessionOrder.MoveToFront(elem)
+		return
+	}
+	t.sessions[sessionID] = t.sessionOrder.PushFront(&sessionCost{id: sessionID, cost: cost})
+	for t.sessionOrder.Len() > maxTrackedSessions {
+		oldest := t.sessionOrder.Back()
+		t.sessionOrder.Remove(oldest)
+		delete(t.sessions, oldest.Value.(*sessionCost).id)
+	}
+}
+
+type usageObserverKey struct{}
+
+// UsageObserver receives the usage of each completion routed with its
+// context, and its cost when models.pricing prices the model.
+type UsageObserver func(model string, usage contract.Usage, costUSD float64)
+
+// WithUsageObserver returns a context whose completions are reported to fn.
+func WithUsageObserver(ctx context.Context, fn UsageObserver) context.Context {
+	return context.WithValue(ctx, usageObserverKey{}, fn)
+}
+
+func observeUsage(ctx context.Context, model string, usage contract.Usage, costUSD float64) {
+	if fn, ok := ctx.Value(usageObserverKey{}).(UsageObserver); ok && fn != nil {
+		fn(model, usage, costUSD)
+	}
+}
+
+// estimateUsage approximates token counts (about four characters per token)
+// for providers that do not report usage.
+func estimateUsage(req contract.CompletionRequest, content string) contract.Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += len(m.Content)
+	}
+	return contract.Usage{
+		PromptTokens:     (prompt + 3) / 4,
+		CompletionTokens: (len(content) + 3) / 4,
+	}
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/counter"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+func TestCostTracker_RecordAndBudget(t *testing.T) {
+	tracker := NewCostTracker([]config.ModelPricing{
+		{Model: "gpt-4o", InputPer1K: 0.005, OutputPer1K: 0.015},
+	}, 0.05)
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	cost := tracker.Record("s1", "gpt-4o", contract.Usage{PromptTokens: 2000, CompletionTokens: 1000})
+	if math.Abs(cost-0.025) > 1e-9 {
+		t.Fatalf("expected cost 0.025, got %v", cost)
+	}
+	if got := tracker.Record("s2", "unpriced", contract.Usage{PromptTokens: 5000}); got != 0 {
+		t.Fatalf("expected unpriced model to be free, got %v", got)
+	}
+	if err := tracker.CheckBudget(); err != nil {
+		t.Fatalf("expected budget available, got %v", err)
+	}
+
+	tracker.Record("s2", "gpt-4o", contract.Usage{PromptTokens: 2000, CompletionTokens: 1000})
+	if got := tracker.SessionSpend("s1"); math.Abs(got-0.025) > 1e-9 {
+		t.Fatalf("expected s1 spend 0.025, got %v", got)
+	}
+	err := tracker.CheckBudget()
+	if !errors.Is(err, heikeErrors.ErrPermissionDenied) {
+		t.Fatalf("expected budget exceeded error, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if err := tracker.CheckBudget(); err != nil {
+		t.Fatalf("expected budget to reset on a new day, got %v", err)
+	}
+	if got := tracker.DailySpend(); got != 0 {
+		t.Fatalf("expected daily spend reset, got %v", got)
+	}
+}
+
+func TestCostTracker_NoLimit(t *testing.T) {
+	tracker := NewCostTracker([]config.ModelPricing{{Model: "m", InputPer1K: 100}}, 0)
+	tracker.Record("", "m", contract.Usage{PromptTokens: 1000})
+	if err := tracker.CheckBudget(); err != nil {
+		t.Fatalf("expected no enforcement without a limit, got %v", err)
+	}
+}
+
+func TestCostTracker_DailySpendSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	pricing := []config.ModelPricing{{Model: "gpt-4o", InputPer1K: 0.005, OutputPer1K: 0.015}}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newTracker := func() *CostTracker {
+		store, err := counter.NewFileStore(path)
+		if err != nil {
+			t.Fatalf("NewFileStore: %v", err)
+		}
+		tracker := NewCostTracker(pricing, 0.04)
+		tracker.SetCounterStore(store, time.Second)
+		tracker.now = func() time.Time { return now }
+		return tracker
+	}
+
+	newTracker().Record("s1", "gpt-4o", contract.Usage{PromptTokens: 2000, CompletionTokens: 1000})
+	newTracker().Record("s2", "gpt-4o", contract.Usage{PromptTokens: 2000, CompletionTokens: 1000})
+
+	restarted := newTracker()
+	if got := restarted.DailySpend(); math.Abs(got-0.05) > 1e-9 {
+		t.Fatalf("expected persisted daily spend 0.05, got %v", got)
+	}
+	if err := restarted.CheckBudget(); !errors.Is(err, heikeErrors.ErrPermissionDenied) {
+		t.Fatalf("expected budget exceeded after restart, got %v", err)
+	}
+}
+
+func TestCostTracker_BoundsSessionTotals(t *testing.T) {
+	tracker := NewCostTracker([]config.ModelPricing{{Model: "m", InputPer1K: 1}}, 0)
+	for i := 0; i <= maxTrackedSessions; i++ {
+		tracker.Record(fmt.Sprintf("s%d", i), "m", contract.Usage{PromptTokens: 1000})
+	}
+	if len(tracker.sessions) != maxTrackedSessions || tracker.sessionOrder.Len() != maxTrackedSessions {
+		t.Fatalf("tracked %d sessions, want %d", len(tracker.sessions), maxTrackedSessions)
+	}
+	if got := tracker.SessionSpend("s0"); got != 0 {
+		t.Fatalf("expected the oldest session to be dropped, got %v", got)
+	}
+	if got := tracker.SessionSpend(fmt.Sprintf("s%d", maxTrackedSessions)); got != 1 {
+		t.Fatalf("expected the newest session spend 1, got %v", got)
+	}
+}
+
+func TestEstimateUsage(t *testing.T) {
+	usage := estimateUsage(contract.CompletionRequest{
+		Messages: []contract.Message{{Content: "12345678"}},
+	}, "1234")
+	if usage.PromptTokens != 2 || usage.CompletionTokens != 1 {
+		t.Fatalf("unexpected estimate: %+v", usage)
+	}
+}
+
+func TestCostTracker_CachedPromptTokens(t *testing.T) {
+	tracker := NewCostTracker([]config.ModelPricing{
+		{Model: "claude", InputPer1K: 0.003, OutputPer1K: 0.015, CachedInputPer1K: 0.0003},
+		{Model: "gpt", InputPer1K: 0.002},
+	}, 0)
+
+	got := tracker.Cost("claude", contract.Usage{PromptTokens: 10000, CachedPromptTokens: 8000})
+	if math.Abs(got-(0.006+0.0024)) > 1e-9 {
+		t.Fatalf("expected cached tokens at the cache-read price, got %v", got)
+	}
+	if got := tracker.Cost("gpt", contract.Usage{PromptTokens: 1000, CachedPromptTokens: 1000}); math.Abs(got-0.002) > 1e-9 {
+		t.Fatalf("expected input price without cached_input_per_1k, got %v", got)
+	}
+}
+
+func TestRouter_ReportsUsageToObserver(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	router.providers["priced"] = &countingProvider{}
+	router.SetCostTracker(NewCostTracker([]config.ModelPricing{{Model: "priced", InputPer1K: 1, OutputPer1K: 1}}, 0))
+
+	var models []string
+	var cost float64
+	ctx := WithUsageObserver(context.Background(), func(model string, usage contract.Usage, costUSD float64) {
+		models = append(models, model)
+		cost += costUSD
+	})
+	req := contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "question"}}}
+	if _, err := router.Route(ctx, "priced", req); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if _, err := router.Route(context.Background(), "priced", req); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if len(models) != 1 || models[0] != "priced" || cost <= 0 {
+		t.Fatalf("observed models = %v, cost = %v", models, cost)
+	}
+}
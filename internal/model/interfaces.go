@@ -8,6 +8,7 @@ import (
 
 type ModelRouter interface {
 	Route(ctx context.Context, model string, req contract.CompletionRequest) (*contract.CompletionResponse, error)
+	RouteStream(ctx context.Context, model string, req contract.CompletionRequest) (<-chan contract.StreamChunk, error)
 	RouteEmbedding(ctx context.Context, model string, text string) ([]float32, error)
 	ListModels() []string
 	Health(ctx context.Context) error
@@ -15,6 +16,7 @@ type ModelRouter interface {
 
 type Provider interface {
 	Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error)
+	GenerateStream(ctx context.Context, req contract.CompletionRequest) (<-chan contract.StreamChunk, error)
 	Embed(ctx context.Context, text string) ([]float32, error)
 	Name() string
 	Type() string
@@ -8,7 +8,11 @@ import (
 	anthropicProvider "github.com/harunnryd/heike/internal/model/providers/anthropic"
 	codexProvider "github.com/harunnryd/heike/internal/model/providers/codex"
 	geminiProvider "github.com/harunnryd/heike/internal/model/providers/gemini"
+	groqProvider "github.com/harunnryd/heike/internal/model/providers/groq"
+	mockProvider "github.com/harunnryd/heike/internal/model/providers/mock"
+	ollamaProvider "github.com/harunnryd/heike/internal/model/providers/ollama"
 	openaiProvider "github.com/harunnryd/heike/internal/model/providers/openai"
+	openrouterProvider "github.com/harunnryd/heike/internal/model/providers/openrouter"
 	zaiProvider "github.com/harunnryd/heike/internal/model/providers/zai"
 )
 
@@ -29,6 +33,14 @@ func (a *ProviderAdapter) Generate(ctx context.Context, req contract.CompletionR
 		return p.Generate(ctx, req)
 	case *zaiProvider.Provider:
 		return p.Generate(ctx, req)
+	case *groqProvider.Provider:
+		return p.Generate(ctx, req)
+	case *openrouterProvider.Provider:
+		return p.Generate(ctx, req)
+	case *ollamaProvider.Provider:
+		return p.Generate(ctx, req)
+	case *mockProvider.Provider:
+		return p.Generate(ctx, req)
 	case *codexProvider.Provider:
 		return p.Generate(ctx, req)
 	default:
@@ -36,6 +48,20 @@ func (a *ProviderAdapter) Generate(ctx context.Context, req contract.CompletionR
 	}
 }
 
+// GenerateStream streams natively where the provider supports it and falls
+// back to a single buffered chunk otherwise.
+func (a *ProviderAdapter) GenerateStream(ctx context.Context, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	if p, ok := a.provider.(*codexProvider.Provider); ok {
+		return p.GenerateStream(ctx, req)
+	}
+
+	resp, err := a.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return bufferedStream(resp), nil
+}
+
 func (a *ProviderAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
 	switch p := a.provider.(type) {
 	case *openaiProvider.Provider:
@@ -46,6 +72,14 @@ func (a *ProviderAdapter) Embed(ctx context.Context, text string) ([]float32, er
 		return p.Embed(ctx, text)
 	case *zaiProvider.Provider:
 		return p.Embed(ctx, text)
+	case *groqProvider.Provider:
+		return p.Embed(ctx, text)
+	case *openrouterProvider.Provider:
+		return p.Embed(ctx, text)
+	case *ollamaProvider.Provider:
+		return p.Embed(ctx, text)
+	case *mockProvider.Provider:
+		return p.Embed(ctx, text)
 	case *codexProvider.Provider:
 		return p.Embed(ctx, text)
 	default:
@@ -62,5 +96,26 @@ func (a *ProviderAdapter) Type() string {
 }
 
 func (a *ProviderAdapter) Health(ctx context.Context) error {
-	return nil
+	switch p := a.provider.(type) {
+	case *openaiProvider.Provider:
+		return p.Health(ctx)
+	case *anthropicProvider.Provider:
+		return p.Health(ctx)
+	case *geminiProvider.Provider:
+		return p.Health(ctx)
+	case *zaiProvider.Provider:
+		return p.Health(ctx)
+	case *groqProvider.Provider:
+		return p.Health(ctx)
+	case *openrouterProvider.Provider:
+		return p.Health(ctx)
+	case *ollamaProvider.Provider:
+		return p.Health(ctx)
+	case *mockProvider.Provider:
+		return p.Health(ctx)
+	case *codexProvider.Provider:
+		return p.Health(ctx)
+	default:
+		return fmt.Errorf("unsupported provider type: %T", a.provider)
+	}
 }
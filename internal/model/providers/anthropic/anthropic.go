@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/harunnryd/heike/internal/model/contract"
@@ -13,15 +14,23 @@ import (
 )
 
 type Provider struct {
-	client anthropic.Client
+	client      anthropic.Client
+	promptCache bool
 }
 
-func New(apiKey string) *Provider {
+// New creates an Anthropic provider. With promptCache set, tools, system
+// prompts and the conversation prefix are marked with cache_control so
+// repeated prompts are billed at the cache-read rate. client carries the
+// requests; nil uses the SDK default.
+func New(apiKey string, promptCache bool, client *http.Client) *Provider {
 	if apiKey == "" {
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
 	}
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
-	return &Provider{client: client}
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if client != nil {
+		opts = append(opts, option.WithHTTPClient(client))
+	}
+	return &Provider{client: anthropic.NewClient(opts...), promptCache: promptCache}
 }
 
 func (p *Provider) Name() string {
@@ -29,9 +38,12 @@ func (p *Provider) Name() string {
 }
 
 func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	var system []anthropic.TextBlockParam
 	var messages []anthropic.MessageParam
 	for _, m := range req.Messages {
 		switch m.Role {
+		case "system":
+			system = append(system, anthropic.TextBlockParam{Text: m.Content})
 		case "user":
 			messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(m.Content)))
 		case "assistant":
@@ -63,17 +75,34 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		modelName = string(anthropic.ModelClaude3_7SonnetLatest)
 	}
 
-	msg, err := p.client.Messages.New(ctx, anthropic.MessageNewParams{
+	if p.promptCache {
+		markCacheBreakpoints(system, tools, messages)
+	}
+
+	params := anthropic.MessageNewParams{
 		Model:     anthropic.Model(modelName),
 		MaxTokens: 1024,
+		System:    system,
 		Messages:  messages,
 		Tools:     tools,
-	})
+	}
+	// The Messages API has no seed; temperature alone varies samples.
+	if req.Temperature != nil {
+		params.Temperature = anthropic.Float(*req.Temperature)
+	}
+	msg, err := p.client.Messages.New(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("anthropic request failed: %w", err)
 	}
 
-	resp := &contract.CompletionResponse{}
+	// InputTokens excludes cached tokens, so add them back for a full count.
+	resp := &contract.CompletionResponse{
+		Usage: &contract.Usage{
+			PromptTokens:       int(msg.Usage.InputTokens + msg.Usage.CacheCreationInputTokens + msg.Usage.CacheReadInputTokens),
+			CompletionTokens:   int(msg.Usage.OutputTokens),
+			CachedPromptTokens: int(msg.Usage.CacheReadInputTokens),
+		},
+	}
 	for _, block := range msg.Content {
 		switch b := block.AsAny().(type) {
 		case anthropic.TextBlock:
@@ -91,6 +120,36 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	return resp, nil
 }
 
+// markCacheBreakpoints sets cache_control on the last tool, the last system
+// block and the last block of the final message. The API caches the prompt
+// prefix up to each breakpoint (tools, then system, then messages) and allows
+// at most four.
+func markCacheBreakpoints(system []anthropic.TextBlockParam, tools []anthropic.ToolUnionParam, messages []anthropic.MessageParam) {
+	if n := len(tools); n > 0 {
+		if cc := tools[n-1].GetCacheControl(); cc != nil {
+			*cc = anthropic.NewCacheControlEphemeralParam()
+		}
+	}
+	if n := len(system); n > 0 {
+		system[n-1].CacheControl = anthropic.NewCacheControlEphemeralParam()
+	}
+	if n := len(messages); n > 0 {
+		if blocks := messages[n-1].Content; len(blocks) > 0 {
+			if cc := blocks[len(blocks)-1].GetCacheControl(); cc != nil {
+				*cc = anthropic.NewCacheControlEphemeralParam()
+			}
+		}
+	}
+}
+
+// Health lists a single model to verify the key without spending tokens.
+func (p *Provider) Health(ctx context.Context) error {
+	if _, err := p.client.Models.List(ctx, anthropic.ModelListParams{Limit: anthropic.Int(1)}); err != nil {
+		return fmt.Errorf("anthropic health check: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	return nil, fmt.Errorf("embedding not supported by anthropic provider")
 }
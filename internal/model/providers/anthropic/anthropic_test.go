@@ -0,0 +1,34 @@
+package anthropic
+
+import (
+	"testing"
+
+	anthropic "github.com/anthropics/anthropic-sdk-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkCacheBreakpoints(t *testing.T) {
+	system := []anthropic.TextBlockParam{{Text: "persona"}, {Text: "skills"}}
+	tools := []anthropic.ToolUnionParam{
+		{OfTool: &anthropic.ToolParam{Name: "a"}},
+		{OfTool: &anthropic.ToolParam{Name: "b"}},
+	}
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("first")),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("second")),
+	}
+
+	markCacheBreakpoints(system, tools, messages)
+
+	ephemeral := anthropic.NewCacheControlEphemeralParam()
+	assert.Equal(t, ephemeral, system[1].CacheControl)
+	assert.Zero(t, system[0].CacheControl)
+	assert.Equal(t, ephemeral, tools[1].OfTool.CacheControl)
+	assert.Zero(t, tools[0].OfTool.CacheControl)
+	assert.Equal(t, ephemeral, messages[1].Content[0].OfText.CacheControl)
+	assert.Zero(t, messages[0].Content[0].OfText.CacheControl)
+}
+
+func TestMarkCacheBreakpoints_Empty(t *testing.T) {
+	assert.NotPanics(t, func() { markCacheBreakpoints(nil, nil, nil) })
+}
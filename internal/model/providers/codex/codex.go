@@ -5,34 +5,51 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/harunnryd/heike/internal/auth"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/model/contract"
 
 	"github.com/sashabaranov/go-openai"
 )
 
 const (
-	defaultCodexBaseURL      = config.DefaultCodexBaseURL
-	defaultCodexModel        = "gpt-5.2"
-	defaultCodexInstructions = config.DefaultThinkerSystemPrompt
-	codexOAuthOriginator     = "codex_cli_rs"
+	defaultCodexBaseURL  = config.DefaultCodexBaseURL
+	defaultCodexModel    = "gpt-5.2"
+	codexOAuthOriginator = "codex_cli_rs"
 )
 
+var defaultCodexInstructions = config.DefaultThinkerSystemPrompt
+
 type RuntimeConfig struct {
 	RequestTimeout         time.Duration
 	EmbeddingInputMaxChars int
+	// Stream caps bound the memory one response can take. Larger SSE events
+	// are skipped, content is cut at MaxResponseBytes, and tool calls whose
+	// arguments exceed MaxToolArgBytes are dropped.
+	MaxEventBytes    int
+	MaxResponseBytes int
+	MaxToolArgBytes  int
+	// HTTPClients supplies the egress policy for the streaming transport;
+	// nil uses httpclient.Default.
+	HTTPClients *httpclient.Factory
+	// HealthProbe makes Health also send a minimal completion, which is
+	// billed.
+	HealthProbe bool
 }
 
 type Provider struct {
@@ -40,6 +57,11 @@ type Provider struct {
 	token       string
 	tokenPath   string
 	runtimeConf RuntimeConfig
+	client      *http.Client
+	// refresh exchanges the stored refresh token; tests replace it.
+	refresh func(ctx context.Context, current *auth.CodexToken) (*auth.CodexToken, error)
+	// refreshMu serialises refreshes so concurrent 401s refresh only once.
+	refreshMu sync.Mutex
 }
 
 func New(token, baseURL, tokenPath string, runtimeConf RuntimeConfig) *Provider {
@@ -55,12 +77,23 @@ func New(token, baseURL, tokenPath string, runtimeConf RuntimeConfig) *Provider
 	if runtimeConf.EmbeddingInputMaxChars <= 0 {
 		runtimeConf.EmbeddingInputMaxChars = config.DefaultCodexEmbeddingInputMaxChars
 	}
+	if runtimeConf.MaxEventBytes <= 0 {
+		runtimeConf.MaxEventBytes = config.DefaultCodexMaxEventBytes
+	}
+	if runtimeConf.MaxResponseBytes <= 0 {
+		runtimeConf.MaxResponseBytes = config.DefaultCodexMaxResponseBytes
+	}
+	if runtimeConf.MaxToolArgBytes <= 0 {
+		runtimeConf.MaxToolArgBytes = config.DefaultCodexMaxToolArgBytes
+	}
 
 	return &Provider{
 		baseURL:     baseURL,
 		token:       token,
 		tokenPath:   tokenPath,
 		runtimeConf: runtimeConf,
+		client:      newCodexStreamingHTTPClient(runtimeConf.RequestTimeout, runtimeConf.HTTPClients),
+		refresh:     auth.RefreshCodexToken,
 	}
 }
 
@@ -69,24 +102,60 @@ func (p *Provider) Name() string {
 }
 
 func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
-	// Get Token (Refresh if needed)
-	// If token provided via constructor (e.g. from config), use it.
-	// Otherwise, load from auth file.
-	var accessToken string
-	var accountID string
+	body, err := p.openStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
 
-	if p.token != "" {
-		// Token from config/env
-		accessToken = p.token
-		// AccountID not available in static token config unless parsed, but auth package handles it.
-		// For static token, we assume it's valid.
-	} else {
-		tok, err := loadToken(p.tokenPath)
+	// Process SSE Stream
+	return consumeCodexSSE(body, p.streamLimits())
+}
+
+// GenerateStream forwards output text deltas as they arrive on the codex SSE
+// stream. The final chunk carries the assembled tool calls.
+func (p *Provider) GenerateStream(ctx context.Context, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	body, err := p.openStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan contract.StreamChunk, 16)
+	go func() {
+		defer close(ch)
+		defer body.Close()
+
+		send := func(chunk contract.StreamChunk) bool {
+			select {
+			case ch <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		aborted := false
+		out, err := streamCodexSSE(body, p.streamLimits(), func(delta string) {
+			if !aborted && !send(contract.StreamChunk{Delta: delta}) {
+				aborted = true
+			}
+		})
+		if aborted {
+			return
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to load codex token: %w", err)
+			send(contract.StreamChunk{Err: err})
+			return
 		}
-		accessToken = tok.AccessToken
-		accountID = tok.AccountID
+		send(contract.StreamChunk{ToolCalls: out.ToolCalls, Done: true})
+	}()
+	return ch, nil
+}
+
+func (p *Provider) openStream(ctx context.Context, req contract.CompletionRequest) (io.ReadCloser, error) {
+	tok, err := p.currentToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load codex token: %w", err)
 	}
 
 	// Prepare Request
@@ -95,6 +164,11 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		systemPrompt = defaultCodexInstructions
 	}
 
+	textFormat, err := toCodexTextFormat(req.ResponseFormat)
+	if err != nil {
+		return nil, err
+	}
+
 	model := resolveCodexModel(req.Model)
 	reqBody := codexRequest{
 		Model:        model,
@@ -104,6 +178,7 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		Input:        inputItems,
 		Text: codexTextConfig{
 			Verbosity: "medium",
+			Format:    textFormat,
 		},
 		Include:           []string{"reasoning.encrypted_content"},
 		PromptCacheKey:    codexPromptCacheKey(req.Messages),
@@ -120,18 +195,41 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		return nil, err
 	}
 
+	resp, err := p.post(ctx, b, tok)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && p.token == "" {
+		resp.Body.Close()
+		refreshed, err := p.refreshToken(ctx, tok)
+		if err != nil {
+			return nil, fmt.Errorf("codex http 401: %w", err)
+		}
+		resp, err = p.post(ctx, b, refreshed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+		return nil, fmt.Errorf("codex http %d: %s", resp.StatusCode, string(raw))
+	}
+
+	return resp.Body, nil
+}
+
+func (p *Provider) post(ctx context.Context, body []byte, tok *auth.CodexToken) (*http.Response, error) {
 	endpoint := codexResponsesEndpoint(p.baseURL)
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+accessToken)
-	// We might need AccountID if present in token logic, but auth package handles it opaquely for now.
-	// Assuming token is sufficient or we add logic to extract accountID.
-	// For now, let's assume token is enough or we fetch user info separately if needed.
-	if accountID != "" {
-		httpReq.Header.Set("chatgpt-account-id", accountID)
+	httpReq.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	if tok.AccountID != "" {
+		httpReq.Header.Set("chatgpt-account-id", tok.AccountID)
 	}
 
 	httpReq.Header.Set("OpenAI-Beta", "responses=experimental")
@@ -140,50 +238,106 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	client := newCodexStreamingHTTPClient(p.runtimeConf.RequestTimeout)
-	resp, err := client.Do(httpReq)
+	return p.client.Do(httpReq)
+}
+
+// currentToken returns the static token from config, or the OAuth token
+// saved by 'heike provider login'.
+func (p *Provider) currentToken() (*auth.CodexToken, error) {
+	if p.token != "" {
+		return &auth.CodexToken{AccessToken: p.token}, nil
+	}
+	return auth.LoadToken(p.tokenPath)
+}
+
+// refreshToken replaces the rejected token using its refresh token and saves
+// the result. If another request refreshed the file first, that token is used.
+func (p *Provider) refreshToken(ctx context.Context, rejected *auth.CodexToken) (*auth.CodexToken, error) {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	if stored, err := auth.LoadToken(p.tokenPath); err == nil && stored.AccessToken != rejected.AccessToken {
+		return stored, nil
+	}
+
+	refreshed, err := p.refresh(ctx, rejected)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("token expired and refresh failed, run 'heike provider login openai-codex': %w", err)
 	}
-	defer resp.Body.Close()
+	if err := auth.SaveToken(refreshed, p.tokenPath); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed codex token: %w", err)
+	}
+	slog.Info("Refreshed codex OAuth token")
+	return refreshed, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
-		return nil, fmt.Errorf("codex http %d: %s", resp.StatusCode, string(raw))
+// Health checks the token, and with HealthProbe also sends a minimal
+// completion, since the ChatGPT backend has no free endpoint to probe. A
+// saved OAuth token is unhealthy only when it has expired and cannot be
+// refreshed; requests refresh it otherwise.
+func (p *Provider) Health(ctx context.Context) error {
+	tok, err := p.currentToken()
+	if err != nil {
+		return fmt.Errorf("codex health check: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return fmt.Errorf("codex health check: no access token, run 'heike provider login openai-codex'")
 	}
+	if p.token == "" && tok.RefreshToken == "" && tokenExpired(tok.AccessToken, time.Now()) {
+		return fmt.Errorf("codex health check: token expired and has no refresh token, run 'heike provider login openai-codex'")
+	}
+	if !p.runtimeConf.HealthProbe {
+		return nil
+	}
+	if _, err := p.Generate(ctx, contract.CompletionRequest{
+		Messages: []contract.Message{{Role: "user", Content: "ping"}},
+	}); err != nil {
+		return fmt.Errorf("codex health check: %w", err)
+	}
+	return nil
+}
 
-	// Process SSE Stream
-	return consumeCodexSSE(resp.Body)
+// tokenExpired reports whether a JWT access token's exp claim is before now.
+// Tokens that are not JWTs or carry no exp are treated as valid.
+func tokenExpired(accessToken string, now time.Time) bool {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		ExpiresAt *float64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.ExpiresAt == nil {
+		return false
+	}
+	return now.After(time.Unix(int64(*claims.ExpiresAt), 0))
 }
 
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
-	// Get Token
-	var accessToken string
-	if p.token != "" {
-		accessToken = p.token
-	} else {
-		tok, err := loadToken(p.tokenPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load codex token for embedding: %w", err)
-		}
-		accessToken = tok.AccessToken
+	tok, err := p.currentToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load codex token for embedding: %w", err)
 	}
 
-	// Create OpenAI Client with OAuth Token
-	// We use the standard OpenAI API for embeddings, assuming the OAuth token is valid.
-	config := openai.DefaultConfig(accessToken)
-	client := openai.NewClientWithConfig(config)
-
 	// Truncate input before API call to avoid embedding request rejection.
 	truncatedText := text
 	if len(truncatedText) > p.runtimeConf.EmbeddingInputMaxChars {
 		truncatedText = truncatedText[:p.runtimeConf.EmbeddingInputMaxChars]
 	}
 
-	resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
-		Input: []string{truncatedText},
-		Model: openai.SmallEmbedding3,
-	})
+	resp, err := embedWithToken(ctx, tok.AccessToken, truncatedText)
+	var apiErr *openai.APIError
+	if err != nil && p.token == "" && errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusUnauthorized {
+		refreshed, refreshErr := p.refreshToken(ctx, tok)
+		if refreshErr != nil {
+			return nil, fmt.Errorf("codex embedding failed: %w", refreshErr)
+		}
+		resp, err = embedWithToken(ctx, refreshed.AccessToken, truncatedText)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("codex embedding failed: %w", err)
 	}
@@ -195,24 +349,13 @@ func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	return resp.Data[0].Embedding, nil
 }
 
-func loadToken(tokenPath string) (*auth.CodexToken, error) {
-	path, err := auth.ResolveTokenPath(tokenPath)
-	if err != nil {
-		return nil, err
-	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("auth file not found, run 'heike provider login openai-codex'")
-	}
-	defer f.Close()
-
-	var tok auth.CodexToken
-	if err := json.NewDecoder(f).Decode(&tok); err != nil {
-		return nil, err
-	}
-
-	return &tok, nil
+// embedWithToken calls the standard OpenAI embeddings API with the OAuth token.
+func embedWithToken(ctx context.Context, accessToken, text string) (openai.EmbeddingResponse, error) {
+	client := openai.NewClientWithConfig(openai.DefaultConfig(accessToken))
+	return client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{text},
+		Model: openai.SmallEmbedding3,
+	})
 }
 
 type codexRequest struct {
@@ -230,7 +373,14 @@ type codexRequest struct {
 }
 
 type codexTextConfig struct {
-	Verbosity string `json:"verbosity,omitempty"`
+	Verbosity string           `json:"verbosity,omitempty"`
+	Format    *codexTextFormat `json:"format,omitempty"`
+}
+
+type codexTextFormat struct {
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name,omitempty"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
 }
 
 type codexTool struct {
@@ -253,8 +403,9 @@ type codexInputItem struct {
 }
 
 type codexInputContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
 }
 
 func toCodexTools(tools []contract.ToolDef) []codexTool {
@@ -279,11 +430,15 @@ func toCodexInput(messages []contract.Message) (string, []codexInputItem) {
 		case "system":
 			systemPrompt = m.Content
 		case "user":
+			content := []codexInputContent{
+				{Type: "input_text", Text: m.Content},
+			}
+			for _, img := range m.Images {
+				content = append(content, codexInputContent{Type: "input_image", ImageURL: img.DataURL()})
+			}
 			input = append(input, codexInputItem{
-				Role: "user",
-				Content: []codexInputContent{
-					{Type: "input_text", Text: m.Content},
-				},
+				Role:    "user",
+				Content: content,
 			})
 		case "assistant":
 			if m.Content != "" {
@@ -370,6 +525,26 @@ func codexPromptCacheKey(messages []contract.Message) string {
 
 // --- SSE Processing ---
 
+// toCodexTextFormat maps a response format onto the Responses API
+// text.format field, which flattens the json_schema name and schema.
+func toCodexTextFormat(format *contract.ResponseFormat) (*codexTextFormat, error) {
+	if format == nil {
+		return nil, nil
+	}
+	switch format.Type {
+	case contract.ResponseFormatJSONObject:
+		return &codexTextFormat{Type: contract.ResponseFormatJSONObject}, nil
+	case contract.ResponseFormatJSONSchema:
+		name := format.Name
+		if name == "" {
+			name = "response"
+		}
+		return &codexTextFormat{Type: contract.ResponseFormatJSONSchema, Name: name, Schema: format.Schema}, nil
+	default:
+		return nil, fmt.Errorf("unsupported response format %q", format.Type)
+	}
+}
+
 type codexSSEEvent struct {
 	Type      string                `json:"type"`
 	Delta     string                `json:"delta"`
@@ -409,19 +584,53 @@ type codexSSEErrorPayload struct {
 	Code    string `json:"code"`
 }
 
-func consumeCodexSSE(r io.Reader) (*contract.CompletionResponse, error) {
+// codexStreamLimits caps the size of one streamed response.
+type codexStreamLimits struct {
+	MaxEventBytes    int
+	MaxResponseBytes int
+	MaxToolArgBytes  int
+}
+
+func (p *Provider) streamLimits() codexStreamLimits {
+	return codexStreamLimits{
+		MaxEventBytes:    p.runtimeConf.MaxEventBytes,
+		MaxResponseBytes: p.runtimeConf.MaxResponseBytes,
+		MaxToolArgBytes:  p.runtimeConf.MaxToolArgBytes,
+	}
+}
+
+func consumeCodexSSE(r io.Reader, limits codexStreamLimits) (*contract.CompletionResponse, error) {
+	return streamCodexSSE(r, limits, nil)
+}
+
+// streamCodexSSE parses the codex SSE stream, calling onDelta with each newly
+// appended piece of output text. Data past the limits is dropped rather than
+// failing the stream, and the response is marked Truncated.
+func streamCodexSSE(r io.Reader, limits codexStreamLimits, onDelta func(string)) (*contract.CompletionResponse, error) {
 	out := &contract.CompletionResponse{}
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(make([]byte, 64*1024), 8<<20)
+	reader := bufio.NewReaderSize(r, 64*1024)
 
 	toolByItemID := make(map[string]*contract.ToolCall)
 	toolByCallID := make(map[string]*contract.ToolCall)
 	toolOrder := make([]*contract.ToolCall, 0, 4)
+	dropped := make(map[*contract.ToolCall]bool)
+	var skippedEvents int
 
 	var eventName string
 	dataLines := make([]string, 0, 1)
+	dataBytes := 0
+	oversized := false
 
 	flushEvent := func() (bool, error) {
+		if oversized {
+			oversized = false
+			dataLines = dataLines[:0]
+			dataBytes = 0
+			eventName = ""
+			skippedEvents++
+			out.Truncated = true
+			return false, nil
+		}
 		if len(dataLines) == 0 {
 			eventName = ""
 			return false, nil
@@ -429,14 +638,28 @@ func consumeCodexSSE(r io.Reader) (*contract.CompletionResponse, error) {
 
 		data := strings.Join(dataLines, "\n")
 		dataLines = dataLines[:0]
+		dataBytes = 0
+		before := out.Content
 		done, err := applyCodexSSEPayload(out, toolByItemID, toolByCallID, &toolOrder, eventName, data)
 		eventName = ""
+		enforceCodexStreamLimits(out, toolOrder, dropped, limits)
+		if onDelta != nil && len(out.Content) > len(before) && strings.HasPrefix(out.Content, before) {
+			onDelta(out.Content[len(before):])
+		}
 		return done, err
 	}
 
-	for scanner.Scan() {
-		line := strings.TrimRight(scanner.Text(), "\r")
-		if line == "" {
+	for {
+		line, truncated, readErr := readCodexSSELine(reader, limits.MaxEventBytes)
+		if readErr != nil && readErr != io.EOF {
+			return nil, fmt.Errorf("codex stream read failed: %w", readErr)
+		}
+		if readErr == io.EOF && line == "" && !truncated {
+			break
+		}
+		line = strings.TrimRight(line, "\r")
+
+		if line == "" && !truncated {
 			done, err := flushEvent()
 			if err != nil {
 				return nil, err
@@ -444,42 +667,110 @@ func consumeCodexSSE(r io.Reader) (*contract.CompletionResponse, error) {
 			if done {
 				break
 			}
-			continue
-		}
-
-		if strings.HasPrefix(line, ":") {
-			continue
-		}
-		if strings.HasPrefix(line, "event:") {
+		} else if strings.HasPrefix(line, "event:") && !truncated {
 			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-			continue
-		}
-		if strings.HasPrefix(line, "data:") {
+		} else if strings.HasPrefix(line, "data:") {
 			payload := strings.TrimPrefix(line, "data:")
 			if strings.HasPrefix(payload, " ") {
 				payload = payload[1:]
 			}
-			dataLines = append(dataLines, payload)
+			dataBytes += len(payload)
+			if truncated || (limits.MaxEventBytes > 0 && dataBytes > limits.MaxEventBytes) {
+				oversized = true
+				dataLines = dataLines[:0]
+			} else if !oversized {
+				dataLines = append(dataLines, payload)
+			}
+		}
+		if readErr == io.EOF {
+			break
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("codex stream read failed: %w", err)
-	}
-
-	if len(dataLines) > 0 {
+	if len(dataLines) > 0 || oversized {
 		if _, err := flushEvent(); err != nil {
 			return nil, err
 		}
 	}
 
 	for _, tc := range toolOrder {
-		appendCodexToolCall(out, tc)
+		if !dropped[tc] {
+			appendCodexToolCall(out, tc)
+		}
 	}
 
+	if out.Truncated {
+		slog.Warn("Codex response truncated at stream limits",
+			"content_bytes", len(out.Content),
+			"dropped_tool_calls", len(dropped),
+			"skipped_events", skippedEvents,
+			"max_event_bytes", limits.MaxEventBytes,
+			"max_response_bytes", limits.MaxResponseBytes,
+			"max_tool_arg_bytes", limits.MaxToolArgBytes)
+	}
 	return out, nil
 }
 
+// readCodexSSELine reads one line without its newline. A line longer than
+// max bytes is consumed to its end but only max bytes are kept, and
+// truncated is set. max <= 0 keeps the whole line.
+func readCodexSSELine(r *bufio.Reader, max int) (line string, truncated bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if max <= 0 || len(buf)+len(chunk) <= max {
+			buf = append(buf, chunk...)
+		} else {
+			if len(buf) < max {
+				buf = append(buf, chunk[:max-len(buf)]...)
+			}
+			truncated = true
+		}
+		switch err {
+		case nil:
+			return strings.TrimSuffix(string(buf), "\n"), truncated, nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return string(buf), truncated, err
+		}
+	}
+}
+
+// enforceCodexStreamLimits cuts content at MaxResponseBytes and drops tool
+// calls whose arguments grew past MaxToolArgBytes, so a pathological stream
+// holds at most the caps plus one event.
+func enforceCodexStreamLimits(out *contract.CompletionResponse, toolOrder []*contract.ToolCall, dropped map[*contract.ToolCall]bool, limits codexStreamLimits) {
+	if limits.MaxResponseBytes > 0 && len(out.Content) > limits.MaxResponseBytes {
+		cut := limits.MaxResponseBytes
+		for cut > 0 && !utf8.RuneStart(out.Content[cut]) {
+			cut--
+		}
+		out.Content = out.Content[:cut]
+		out.Truncated = true
+	}
+	if limits.MaxToolArgBytes <= 0 {
+		return
+	}
+	for _, tc := range toolOrder {
+		if len(tc.Input) > limits.MaxToolArgBytes {
+			tc.Input = ""
+			dropped[tc] = true
+			out.Truncated = true
+		}
+	}
+	kept := out.ToolCalls[:0]
+	for _, tc := range out.ToolCalls {
+		if dropped[tc] || len(tc.Input) > limits.MaxToolArgBytes {
+			dropped[tc] = true
+			out.Truncated = true
+			continue
+		}
+		kept = append(kept, tc)
+	}
+	out.ToolCalls = kept
+}
+
 func applyCodexSSEPayload(
 	out *contract.CompletionResponse,
 	toolByItemID map[string]*contract.ToolCall,
@@ -714,21 +1005,12 @@ func normalizeCodexToolInput(raw string) string {
 	return s
 }
 
-func newCodexStreamingHTTPClient(requestTimeout time.Duration) *http.Client {
-	dialer := &net.Dialer{
-		Timeout:   15 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}
-	transport := &http.Transport{
-		Proxy:                 http.ProxyFromEnvironment,
-		DialContext:           dialer.DialContext,
-		ForceAttemptHTTP2:     true,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   15 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-		ResponseHeaderTimeout: codexResponseHeaderTimeout(requestTimeout),
-	}
+// newCodexStreamingHTTPClient uses a dedicated pool because the response
+// header timeout is a transport setting.
+func newCodexStreamingHTTPClient(requestTimeout time.Duration, clients *httpclient.Factory) *http.Client {
+	transport := clients.Dedicated("openai-codex", func(t *http.Transport) {
+		t.ResponseHeaderTimeout = codexResponseHeaderTimeout(requestTimeout)
+	})
 
 	// Do not use http.Client.Timeout for SSE because it caps total stream duration.
 	return &http.Client{Transport: transport}
@@ -1,17 +1,30 @@
 package codex
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/harunnryd/heike/internal/auth"
+	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/model/contract"
 
 	"github.com/stretchr/testify/assert"
 )
 
+var testCodexStreamLimits = codexStreamLimits{
+	MaxEventBytes:    config.DefaultCodexMaxEventBytes,
+	MaxResponseBytes: config.DefaultCodexMaxResponseBytes,
+	MaxToolArgBytes:  config.DefaultCodexMaxToolArgBytes,
+}
+
 func TestToCodexTools_NormalizesFunctionNames(t *testing.T) {
 	tools := []contract.ToolDef{
 		{
@@ -96,7 +109,7 @@ func TestConsumeCodexSSE_ParsesFunctionCallByItemID(t *testing.T) {
 		``,
 	}, "\n")
 
-	got, err := consumeCodexSSE(strings.NewReader(stream))
+	got, err := consumeCodexSSE(strings.NewReader(stream), testCodexStreamLimits)
 	assert.NoError(t, err)
 	if assert.NotNil(t, got) {
 		assert.Equal(t, "Hi", got.Content)
@@ -117,7 +130,7 @@ func TestConsumeCodexSSE_FallbackFromResponseCompleted(t *testing.T) {
 		``,
 	}, "\n")
 
-	got, err := consumeCodexSSE(strings.NewReader(stream))
+	got, err := consumeCodexSSE(strings.NewReader(stream), testCodexStreamLimits)
 	assert.NoError(t, err)
 	if assert.NotNil(t, got) {
 		assert.Equal(t, "done", got.Content)
@@ -138,13 +151,49 @@ func TestConsumeCodexSSE_AllowsLargePayloadLine(t *testing.T) {
 	assert.NoError(t, err)
 
 	stream := "data: " + string(payload) + "\n\n" + "data: [DONE]\n\n"
-	got, err := consumeCodexSSE(strings.NewReader(stream))
+	got, err := consumeCodexSSE(strings.NewReader(stream), testCodexStreamLimits)
 	assert.NoError(t, err)
 	if assert.NotNil(t, got) {
 		assert.Len(t, got.Content, len(longText))
 	}
 }
 
+func TestConsumeCodexSSE_TruncatesAtStreamLimits(t *testing.T) {
+	delta := func(text string) string {
+		payload, _ := json.Marshal(map[string]string{"type": "response.output_text.delta", "delta": text})
+		return "data: " + string(payload) + "\n\n"
+	}
+	args := func(callID, name, arguments string) string {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type": "response.output_item.done",
+			"item": map[string]string{"id": "fc_" + callID, "type": "function_call", "call_id": callID, "name": name, "arguments": arguments},
+		})
+		return "data: " + string(payload) + "\n\n"
+	}
+	stream := delta("héllo ") +
+		delta(strings.Repeat("x", 5000)) + // larger than one event may be
+		delta("wörld and more") +
+		args("call_1", "open", `{"url":"a"}`) +
+		args("call_2", "write", `{"text":"`+strings.Repeat("y", 300)+`"}`) +
+		"data: [DONE]\n\n"
+
+	limits := codexStreamLimits{MaxEventBytes: 1024, MaxResponseBytes: 9, MaxToolArgBytes: 100}
+	got, err := consumeCodexSSE(strings.NewReader(stream), limits)
+	assert.NoError(t, err)
+	if assert.NotNil(t, got) {
+		assert.True(t, got.Truncated)
+		// Cut at the cap without splitting the two-byte "ö".
+		assert.Equal(t, "héllo w", got.Content)
+		if assert.Len(t, got.ToolCalls, 1) {
+			assert.Equal(t, "call_1", got.ToolCalls[0].ID)
+		}
+	}
+
+	got, err = consumeCodexSSE(strings.NewReader(delta("small")+"data: [DONE]\n\n"), limits)
+	assert.NoError(t, err)
+	assert.False(t, got.Truncated)
+}
+
 func TestConsumeCodexSSE_PropagatesErrorEvent(t *testing.T) {
 	stream := strings.Join([]string{
 		`event: error`,
@@ -152,11 +201,74 @@ func TestConsumeCodexSSE_PropagatesErrorEvent(t *testing.T) {
 		``,
 	}, "\n")
 
-	_, err := consumeCodexSSE(strings.NewReader(stream))
+	_, err := consumeCodexSSE(strings.NewReader(stream), testCodexStreamLimits)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "boom")
 }
 
+func TestStreamCodexSSE_ForwardsDeltasIncludingFallbackText(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"type":"response.output_text.delta","delta":"Hel"}`,
+		``,
+		`data: {"type":"response.output_text.delta","delta":"lo"}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	var deltas []string
+	got, err := streamCodexSSE(strings.NewReader(stream), testCodexStreamLimits, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hel", "lo"}, deltas)
+	assert.Equal(t, "Hello", got.Content)
+
+	deltas = nil
+	fallback := `data: {"type":"response.output_text.done","text":"whole"}` + "\n\n"
+	_, err = streamCodexSSE(strings.NewReader(fallback), testCodexStreamLimits, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"whole"}, deltas)
+}
+
+func TestProvider_GenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(strings.Join([]string{
+			`data: {"type":"response.output_text.delta","delta":"a"}`,
+			``,
+			`data: {"type":"response.output_text.delta","delta":"b"}`,
+			``,
+			`data: {"type":"response.output_item.done","item":{"id":"fc_1","type":"function_call","call_id":"call_1","name":"open","arguments":"{}"}}`,
+			``,
+			`data: [DONE]`,
+			``,
+		}, "\n")))
+	}))
+	defer server.Close()
+
+	provider := New("token", server.URL, "", RuntimeConfig{RequestTimeout: 5 * time.Second})
+	ch, err := provider.GenerateStream(context.Background(), contract.CompletionRequest{
+		Messages: []contract.Message{{Role: "user", Content: "hi"}},
+	})
+	assert.NoError(t, err)
+
+	var chunks []contract.StreamChunk
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+	if assert.Len(t, chunks, 3) {
+		assert.Equal(t, "a", chunks[0].Delta)
+		assert.Equal(t, "b", chunks[1].Delta)
+		assert.True(t, chunks[2].Done)
+		if assert.Len(t, chunks[2].ToolCalls, 1) {
+			assert.Equal(t, "open", chunks[2].ToolCalls[0].Name)
+		}
+	}
+}
+
 func TestCodexResponseHeaderTimeout(t *testing.T) {
 	assert.Equal(t, 30*time.Second, codexResponseHeaderTimeout(0))
 	assert.Equal(t, 10*time.Second, codexResponseHeaderTimeout(10*time.Second))
@@ -165,12 +277,153 @@ func TestCodexResponseHeaderTimeout(t *testing.T) {
 }
 
 func TestNewCodexStreamingHTTPClient_HasNoGlobalTimeout(t *testing.T) {
-	client := newCodexStreamingHTTPClient(2 * time.Minute)
+	client := newCodexStreamingHTTPClient(2*time.Minute, nil)
 	assert.NotNil(t, client)
 	assert.Equal(t, time.Duration(0), client.Timeout)
 
-	transport, ok := client.Transport.(*http.Transport)
+	wrapped, ok := client.Transport.(interface{ Unwrap() http.RoundTripper })
+	if !assert.True(t, ok) {
+		return
+	}
+	transport, ok := wrapped.Unwrap().(*http.Transport)
 	if assert.True(t, ok) {
 		assert.Equal(t, 45*time.Second, transport.ResponseHeaderTimeout)
 	}
 }
+
+func TestToCodexTextFormat(t *testing.T) {
+	got, err := toCodexTextFormat(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	schema := map[string]interface{}{"type": "object"}
+	got, err = toCodexTextFormat(&contract.ResponseFormat{Type: contract.ResponseFormatJSONSchema, Schema: schema})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "json_schema", got.Type)
+		assert.Equal(t, "response", got.Name)
+		assert.Equal(t, schema, got.Schema)
+	}
+
+	_, err = toCodexTextFormat(&contract.ResponseFormat{Type: "xml"})
+	assert.Error(t, err)
+}
+
+func TestToCodexInput_UserImages(t *testing.T) {
+	_, items := toCodexInput([]contract.Message{{
+		Role:    "user",
+		Content: "what is this?",
+		Images: []contract.ImagePart{
+			{URL: "https://example.com/a.png"},
+			{Data: "aGk=", MIMEType: "image/png"},
+		},
+	}})
+	if assert.Len(t, items, 1) && assert.Len(t, items[0].Content, 3) {
+		assert.Equal(t, "input_image", items[0].Content[1].Type)
+		assert.Equal(t, "https://example.com/a.png", items[0].Content[1].ImageURL)
+		assert.Equal(t, "data:image/png;base64,aGk=", items[0].Content[2].ImageURL)
+	}
+}
+
+func TestProvider_RefreshesTokenOn401(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		seen = append(seen, header)
+		if header != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "codex.json")
+	assert.NoError(t, auth.SaveToken(&auth.CodexToken{AccessToken: "stale", RefreshToken: "refresh-1", AccountID: "acct"}, tokenPath))
+
+	provider := New("", server.URL, tokenPath, RuntimeConfig{RequestTimeout: 5 * time.Second})
+	refreshCalls := 0
+	provider.refresh = func(ctx context.Context, current *auth.CodexToken) (*auth.CodexToken, error) {
+		refreshCalls++
+		assert.Equal(t, "refresh-1", current.RefreshToken)
+		return &auth.CodexToken{AccessToken: "fresh", RefreshToken: "refresh-2", AccountID: current.AccountID}, nil
+	}
+
+	resp, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Messages: []contract.Message{{Role: "user", Content: "hi"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp.Content)
+	assert.Equal(t, 1, refreshCalls)
+	assert.Equal(t, []string{"Bearer stale", "Bearer fresh"}, seen)
+
+	stored, err := auth.LoadToken(tokenPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", stored.AccessToken)
+	assert.Equal(t, "refresh-2", stored.RefreshToken)
+}
+
+func TestProvider_RefreshFailureReportsLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "codex.json")
+	assert.NoError(t, auth.SaveToken(&auth.CodexToken{AccessToken: "stale"}, tokenPath))
+
+	provider := New("", server.URL, tokenPath, RuntimeConfig{RequestTimeout: 5 * time.Second})
+	_, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Messages: []contract.Message{{Role: "user", Content: "hi"}},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "heike provider login openai-codex")
+	}
+}
+
+func TestProvider_HealthDoesNotSpendTokensByDefault(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	jwt := func(exp time.Time) string {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+		return "e30." + payload + ".sig"
+	}
+	dir := t.TempDir()
+	save := func(name string, tok *auth.CodexToken) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, auth.SaveToken(tok, path))
+		return path
+	}
+	expired := jwt(time.Now().Add(-time.Hour))
+
+	cases := map[string]struct {
+		token, tokenPath string
+		wantErr          string
+	}{
+		"static token":          {token: "sk-static"},
+		"valid oauth token":     {tokenPath: save("valid.json", &auth.CodexToken{AccessToken: jwt(time.Now().Add(time.Hour))})},
+		"refreshable token":     {tokenPath: save("refresh.json", &auth.CodexToken{AccessToken: expired, RefreshToken: "refresh-1"})},
+		"expired without renew": {tokenPath: save("expired.json", &auth.CodexToken{AccessToken: expired}), wantErr: "token expired"},
+		"missing token file":    {tokenPath: filepath.Join(dir, "missing.json"), wantErr: "codex health check"},
+	}
+	for name, tc := range cases {
+		provider := New(tc.token, server.URL, tc.tokenPath, RuntimeConfig{RequestTimeout: 5 * time.Second})
+		err := provider.Health(context.Background())
+		if tc.wantErr == "" {
+			assert.NoError(t, err, name)
+		} else if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), tc.wantErr, name)
+		}
+	}
+	assert.Zero(t, requests)
+
+	provider := New("sk-static", server.URL, "", RuntimeConfig{RequestTimeout: 5 * time.Second, HealthProbe: true})
+	assert.NoError(t, provider.Health(context.Background()))
+	assert.Equal(t, 1, requests)
+}
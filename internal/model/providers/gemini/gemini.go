@@ -2,8 +2,10 @@ package gemini
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/harunnryd/heike/internal/model/contract"
@@ -17,11 +19,13 @@ type Provider struct {
 
 const defaultEmbeddingModel = "text-embedding-004"
 
-func New(apiKey string) (*Provider, error) {
+// New creates a Gemini provider. httpClient carries the requests; nil uses the
+// SDK default.
+func New(apiKey string, httpClient *http.Client) (*Provider, error) {
 	if apiKey == "" {
 		apiKey = os.Getenv("GEMINI_API_KEY")
 	}
-	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{APIKey: apiKey, Backend: genai.BackendGeminiAPI})
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{APIKey: apiKey, Backend: genai.BackendGeminiAPI, HTTPClient: httpClient})
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +47,15 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		case "assistant":
 			contents = append(contents, &genai.Content{Role: "model", Parts: []*genai.Part{{Text: m.Content}}})
 		default:
-			contents = append(contents, &genai.Content{Role: "user", Parts: []*genai.Part{{Text: m.Content}}})
+			parts := []*genai.Part{{Text: m.Content}}
+			for _, img := range m.Images {
+				part, err := imagePart(img)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, part)
+			}
+			contents = append(contents, &genai.Content{Role: "user", Parts: parts})
 		}
 	}
 
@@ -59,7 +71,20 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		tools = append(tools, &genai.Tool{FunctionDeclarations: decls})
 	}
 
-	resp, err := p.client.Models.GenerateContent(ctx, req.Model, contents, &genai.GenerateContentConfig{Tools: tools})
+	genCfg := &genai.GenerateContentConfig{Tools: tools}
+	if req.Temperature != nil {
+		temperature := float32(*req.Temperature)
+		genCfg.Temperature = &temperature
+	}
+	if req.Seed != nil {
+		seed := int32(*req.Seed)
+		genCfg.Seed = &seed
+	}
+	if err := applyResponseFormat(genCfg, req.ResponseFormat); err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Models.GenerateContent(ctx, req.Model, contents, genCfg)
 	if err != nil {
 		return nil, fmt.Errorf("gemini request failed: %w", err)
 	}
@@ -69,6 +94,13 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		return out, nil
 	}
 
+	if resp.UsageMetadata != nil {
+		out.Usage = &contract.Usage{
+			PromptTokens:     int(resp.UsageMetadata.PromptTokenCount),
+			CompletionTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		}
+	}
+
 	for _, fc := range resp.FunctionCalls() {
 		argsJSON, _ := json.Marshal(fc.Args)
 		id := fc.ID
@@ -89,6 +121,41 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	return out, nil
 }
 
+func imagePart(img contract.ImagePart) (*genai.Part, error) {
+	if img.URL != "" {
+		return &genai.Part{FileData: &genai.FileData{FileURI: img.URL, MIMEType: img.MIMEType}}, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(img.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image data: %w", err)
+	}
+	return &genai.Part{InlineData: &genai.Blob{Data: data, MIMEType: img.MIMEType}}, nil
+}
+
+func applyResponseFormat(cfg *genai.GenerateContentConfig, format *contract.ResponseFormat) error {
+	if format == nil {
+		return nil
+	}
+	switch format.Type {
+	case contract.ResponseFormatJSONObject:
+		cfg.ResponseMIMEType = "application/json"
+	case contract.ResponseFormatJSONSchema:
+		cfg.ResponseMIMEType = "application/json"
+		cfg.ResponseJsonSchema = format.Schema
+	default:
+		return fmt.Errorf("unsupported response format %q", format.Type)
+	}
+	return nil
+}
+
+// Health lists a single model to verify the key without spending tokens.
+func (p *Provider) Health(ctx context.Context) error {
+	if _, err := p.client.Models.List(ctx, &genai.ListModelsConfig{PageSize: 1}); err != nil {
+		return fmt.Errorf("gemini health check: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	resp, err := p.client.Models.EmbedContent(ctx, defaultEmbeddingModel, genai.Text(text), nil)
 	if err != nil {
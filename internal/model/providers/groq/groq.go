@@ -0,0 +1,102 @@
+package groq
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+	openaiProvider "github.com/harunnryd/heike/internal/model/providers/openai"
+	"github.com/harunnryd/heike/internal/model/providers/ratelimit"
+)
+
+const (
+	DefaultBaseURL = "https://api.groq.com/openai/v1"
+
+	// modelPrefix lets registry names like "groq/llama-3.3-70b-versatile"
+	// stay distinct from the same model served elsewhere.
+	modelPrefix = "groq/"
+)
+
+type Provider struct {
+	inner *openaiProvider.Provider
+	model string
+}
+
+// New creates a Groq provider. Requests go through base, wrapped in the
+// rate-limit transport; nil uses http.DefaultTransport.
+func New(apiKey, baseURL, model string, timeout time.Duration, base http.RoundTripper) (*Provider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("api key is required")
+	}
+	model = ModelID(model)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	transport := ratelimit.NewTransport(base, nil, ParseRateLimit, ratelimit.DefaultMaxWait)
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = strings.TrimSuffix(baseURL, "/")
+	cfg.HTTPClient = &http.Client{Transport: transport, Timeout: timeout}
+
+	return &Provider{
+		inner: openaiProvider.NewWithConfig(cfg, model),
+		model: model,
+	}, nil
+}
+
+// ModelID strips the optional "groq/" prefix from a registry name.
+func ModelID(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), modelPrefix)
+}
+
+func (p *Provider) Name() string {
+	return "groq"
+}
+
+func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	req.Model = p.model
+	resp, err := p.inner.Generate(ctx, req)
+	if err != nil {
+		return nil, ratelimit.ClassifyError(err, "groq")
+	}
+	return resp, nil
+}
+
+func (p *Provider) Health(ctx context.Context) error {
+	if err := p.inner.Health(ctx); err != nil {
+		return ratelimit.ClassifyError(err, "groq")
+	}
+	return nil
+}
+
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embedding not supported by groq provider")
+}
+
+// ParseRateLimit reads Groq's per-request and per-token limit headers. Reset
+// values are durations such as "2m59.56s"; the later of the exhausted windows
+// wins.
+func ParseRateLimit(h http.Header, now time.Time) (time.Time, bool) {
+	var reset time.Time
+	for _, kind := range []string{"requests", "tokens"} {
+		if h.Get("x-ratelimit-remaining-"+kind) != "0" {
+			continue
+		}
+		d, err := time.ParseDuration(h.Get("x-ratelimit-reset-" + kind))
+		if err != nil {
+			continue
+		}
+		if at := now.Add(d); at.After(reset) {
+			reset = at
+		}
+	}
+	return reset, !reset.IsZero()
+}
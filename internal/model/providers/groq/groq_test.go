@@ -0,0 +1,36 @@
+package groq
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestModelID_StripsPrefix(t *testing.T) {
+	if got := ModelID("groq/llama-3.3-70b-versatile"); got != "llama-3.3-70b-versatile" {
+		t.Fatalf("ModelID = %q", got)
+	}
+	if got := ModelID("mixtral-8x7b-32768"); got != "mixtral-8x7b-32768" {
+		t.Fatalf("ModelID = %q", got)
+	}
+}
+
+func TestParseRateLimit_UsesLatestExhaustedWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("x-ratelimit-remaining-requests", "0")
+	h.Set("x-ratelimit-reset-requests", "2m59.56s")
+	h.Set("x-ratelimit-remaining-tokens", "0")
+	h.Set("x-ratelimit-reset-tokens", "7.66s")
+
+	reset, ok := ParseRateLimit(h, now)
+	if !ok || reset.Sub(now) != 2*time.Minute+59560*time.Millisecond {
+		t.Fatalf("reset = %v %v", reset.Sub(now), ok)
+	}
+
+	h.Set("x-ratelimit-remaining-requests", "10")
+	h.Set("x-ratelimit-remaining-tokens", "5000")
+	if _, ok := ParseRateLimit(h, now); ok {
+		t.Fatal("expected no block with remaining capacity")
+	}
+}
@@ -0,0 +1,182 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+	"github.com/harunnryd/heike/internal/pathutil"
+)
+
+// DefaultEmbeddingDims is the embedding size when the fixture sets none.
+const DefaultEmbeddingDims = 64
+
+// Fixture scripts the mock provider's replies.
+type Fixture struct {
+	// Responses are tried in order. The first entry that has not been used
+	// (or repeats) and whose Match appears in the last message answers.
+	Responses []Response `yaml:"responses"`
+	// Fallback answers when no response matches; without it the request
+	// fails.
+	Fallback      string `yaml:"fallback"`
+	EmbeddingDims int    `yaml:"embedding_dims"`
+}
+
+// Response is one canned completion.
+type Response struct {
+	Match     string     `yaml:"match"`
+	Content   string     `yaml:"content"`
+	ToolCalls []ToolCall `yaml:"tool_calls"`
+	// Error fails the request with this message instead of answering.
+	Error  string `yaml:"error"`
+	Repeat bool   `yaml:"repeat"`
+}
+
+// ToolCall is a scripted tool call. Input may be a YAML mapping or a JSON
+// string.
+type ToolCall struct {
+	ID    string      `yaml:"id"`
+	Name  string      `yaml:"name"`
+	Input interface{} `yaml:"input"`
+}
+
+// Provider answers from a fixture without network access, for deterministic
+// tests. Embeddings are hashed bags of words, so texts sharing words are
+// similar.
+type Provider struct {
+	mu       sync.Mutex
+	fixture  Fixture
+	used     []bool
+	requests []contract.CompletionRequest
+}
+
+func New(fixture Fixture) *Provider {
+	if fixture.EmbeddingDims <= 0 {
+		fixture.EmbeddingDims = DefaultEmbeddingDims
+	}
+	return &Provider{fixture: fixture, used: make([]bool, len(fixture.Responses))}
+}
+
+// Load reads a YAML fixture from path. With an empty path every completion
+// fails, but embeddings still work.
+func Load(path string) (*Provider, error) {
+	if strings.TrimSpace(path) == "" {
+		return New(Fixture{}), nil
+	}
+	expanded, err := pathutil.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("read mock fixture: %w", err)
+	}
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse mock fixture %s: %w", expanded, err)
+	}
+	return New(fixture), nil
+}
+
+func (p *Provider) Name() string {
+	return "mock"
+}
+
+func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+
+	last := ""
+	if len(req.Messages) > 0 {
+		last = req.Messages[len(req.Messages)-1].Content
+	}
+	for i, resp := range p.fixture.Responses {
+		if p.used[i] && !resp.Repeat {
+			continue
+		}
+		if !strings.Contains(last, resp.Match) {
+			continue
+		}
+		p.used[i] = true
+		if resp.Error != "" {
+			return nil, fmt.Errorf("mock: %s", resp.Error)
+		}
+		return toCompletion(resp)
+	}
+	if p.fixture.Fallback != "" {
+		return &contract.CompletionResponse{Content: p.fixture.Fallback, Usage: &contract.Usage{}}, nil
+	}
+	return nil, fmt.Errorf("mock: no fixture response matches request %d", len(p.requests))
+}
+
+func toCompletion(resp Response) (*contract.CompletionResponse, error) {
+	result := &contract.CompletionResponse{Content: resp.Content, Usage: &contract.Usage{}}
+	for i, tc := range resp.ToolCalls {
+		input := "{}"
+		switch v := tc.Input.(type) {
+		case nil:
+		case string:
+			input = v
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("mock: tool call %s input: %w", tc.Name, err)
+			}
+			input = string(data)
+		}
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d", i+1)
+		}
+		result.ToolCalls = append(result.ToolCalls, &contract.ToolCall{ID: id, Name: tc.Name, Input: input})
+	}
+	return result, nil
+}
+
+// Requests returns the completion requests received so far.
+func (p *Provider) Requests() []contract.CompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]contract.CompletionRequest(nil), p.requests...)
+}
+
+func (p *Provider) Health(ctx context.Context) error {
+	return nil
+}
+
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	vec := make([]float32, p.fixture.EmbeddingDims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		vec[h.Sum32()%uint32(len(vec))]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		// Keep cosine similarity defined for text without words.
+		vec[0] = 1
+		return vec, nil
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vec {
+		vec[i] *= scale
+	}
+	return vec, nil
+}
@@ -0,0 +1,99 @@
+package mock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+func generate(t *testing.T, p *Provider, content string) (*contract.CompletionResponse, error) {
+	t.Helper()
+	return p.Generate(context.Background(), contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: content}}})
+}
+
+func TestLoad_ScriptedResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+	fixture := `
+responses:
+  - match: "PLAN"
+    content: '[{"id":1,"description":"search"}]'
+    repeat: true
+  - tool_calls:
+      - name: search
+        input: {q: heike, limit: 2}
+  - tool_calls:
+      - id: raw
+        name: search
+        input: '{"q":"raw"}'
+  - error: "provider unavailable"
+fallback: "done"
+`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := generate(t, p, "PLAN this goal")
+		if err != nil || !strings.HasPrefix(resp.Content, "[") {
+			t.Fatalf("plan response %d = %+v, %v", i, resp, err)
+		}
+	}
+	resp, err := generate(t, p, "goal")
+	if err != nil || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Input != `{"limit":2,"q":"heike"}` || resp.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("first tool call = %+v, %v", resp, err)
+	}
+	resp, err = generate(t, p, "goal")
+	if err != nil || resp.ToolCalls[0].ID != "raw" || resp.ToolCalls[0].Input != `{"q":"raw"}` {
+		t.Fatalf("second tool call = %+v, %v", resp, err)
+	}
+	if _, err := generate(t, p, "goal"); err == nil || !strings.Contains(err.Error(), "provider unavailable") {
+		t.Fatalf("expected scripted error, got %v", err)
+	}
+	if resp, err := generate(t, p, "goal"); err != nil || resp.Content != "done" {
+		t.Fatalf("fallback = %+v, %v", resp, err)
+	}
+	if got := len(p.Requests()); got != 6 {
+		t.Fatalf("recorded %d requests, want 6", got)
+	}
+}
+
+func TestGenerate_FailsWithoutMatch(t *testing.T) {
+	p := New(Fixture{Responses: []Response{{Match: "only this", Content: "x"}}})
+	if _, err := generate(t, p, "something else"); err == nil {
+		t.Fatal("expected error when no response matches")
+	}
+}
+
+func TestEmbed_IsDeterministicAndWordBased(t *testing.T) {
+	p := New(Fixture{EmbeddingDims: 32})
+	ctx := context.Background()
+
+	a, _ := p.Embed(ctx, "the checkout page fails")
+	b, _ := p.Embed(ctx, "The checkout page fails!")
+	c, _ := p.Embed(ctx, "weather in Jakarta")
+	if len(a) != 32 {
+		t.Fatalf("dims = %d", len(a))
+	}
+	if dot(a, b) < 0.999 {
+		t.Fatalf("same words should embed identically, dot = %f", dot(a, b))
+	}
+	if dot(a, c) >= dot(a, b) {
+		t.Fatalf("unrelated text should be less similar: %f >= %f", dot(a, c), dot(a, b))
+	}
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
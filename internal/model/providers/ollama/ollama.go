@@ -0,0 +1,374 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// DefaultBaseURL is Ollama's native API root, without the /v1 OpenAI shim.
+const DefaultBaseURL = "http://localhost:11434"
+
+type RuntimeConfig struct {
+	RequestTimeout time.Duration
+	// KeepAlive is how long Ollama keeps the model loaded after a request
+	// (e.g. "10m", "-1" for forever); empty uses the server default.
+	KeepAlive string
+	// NumCtx overrides the context window; <= 0 uses the model default.
+	NumCtx int
+	// PullMissing pulls the model once when Ollama reports it is missing.
+	PullMissing bool
+	// Transport carries the requests; nil uses http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// Provider talks to Ollama's native /api/chat and /api/embeddings endpoints.
+type Provider struct {
+	baseURL string
+	model   string
+	cfg     RuntimeConfig
+	client  *http.Client
+
+	pullMu sync.Mutex
+	pulled bool
+}
+
+func New(baseURL, model string, cfg RuntimeConfig) (*Provider, error) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	// Accept the OpenAI shim URL used by the ollama provider.
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+
+	return &Provider{
+		baseURL: baseURL,
+		model:   model,
+		cfg:     cfg,
+		client:  &http.Client{Transport: cfg.Transport, Timeout: cfg.RequestTimeout},
+	}, nil
+}
+
+func (p *Provider) Name() string {
+	return "ollama-native"
+}
+
+type chatMessage struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Images    []string   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+type toolCall struct {
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type tool struct {
+	Type     string         `json:"type"`
+	Function toolDefinition `json:"function"`
+}
+
+type toolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type chatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []chatMessage          `json:"messages"`
+	Tools     []tool                 `json:"tools,omitempty"`
+	Format    interface{}            `json:"format,omitempty"`
+	Stream    bool                   `json:"stream"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+type chatResponse struct {
+	Message         chatMessage `json:"message"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+}
+
+func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	chatReq := chatRequest{
+		Model:     p.model,
+		Messages:  toChatMessages(req.Messages),
+		Format:    toFormat(req.ResponseFormat),
+		KeepAlive: p.cfg.KeepAlive,
+		Options:   p.chatOptions(req),
+	}
+	for _, t := range req.Tools {
+		params := t.Parameters
+		if params == nil {
+			params = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			}
+		}
+		chatReq.Tools = append(chatReq.Tools, tool{
+			Type:     "function",
+			Function: toolDefinition{Name: t.Name, Description: t.Description, Parameters: params},
+		})
+	}
+
+	var resp chatResponse
+	if err := p.post(ctx, "/api/chat", chatReq, &resp); err != nil {
+		return nil, fmt.Errorf("ollama request failed: %w", err)
+	}
+
+	result := &contract.CompletionResponse{
+		Content: resp.Message.Content,
+		Usage: &contract.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+		},
+	}
+	for i, tc := range resp.Message.ToolCalls {
+		input := string(tc.Function.Arguments)
+		if input == "" || input == "null" {
+			input = "{}"
+		}
+		// Ollama does not assign tool call IDs.
+		result.ToolCalls = append(result.ToolCalls, &contract.ToolCall{
+			ID:    fmt.Sprintf("call_%d", i+1),
+			Name:  tc.Function.Name,
+			Input: input,
+		})
+	}
+	return result, nil
+}
+
+// toChatMessages converts messages to Ollama's format, where tool call
+// arguments are JSON objects and images are bare base64. Images given by URL
+// are dropped because Ollama cannot fetch them.
+func toChatMessages(messages []contract.Message) []chatMessage {
+	out := make([]chatMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := chatMessage{Role: m.Role, Content: m.Content}
+		for _, img := range m.Images {
+			if img.Data != "" {
+				msg.Images = append(msg.Images, img.Data)
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			args := json.RawMessage(tc.Input)
+			if !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, toolCall{Function: toolFunction{Name: tc.Name, Arguments: args}})
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// toFormat maps a response format to Ollama's format field: "json" or a
+// JSON schema.
+func toFormat(format *contract.ResponseFormat) interface{} {
+	if format == nil {
+		return nil
+	}
+	switch format.Type {
+	case contract.ResponseFormatJSONObject:
+		return "json"
+	case contract.ResponseFormatJSONSchema:
+		if format.Schema != nil {
+			return format.Schema
+		}
+		return "json"
+	default:
+		return nil
+	}
+}
+
+func (p *Provider) options() map[string]interface{} {
+	if p.cfg.NumCtx <= 0 {
+		return nil
+	}
+	return map[string]interface{}{"num_ctx": p.cfg.NumCtx}
+}
+
+// chatOptions adds the request's sampling overrides to options.
+func (p *Provider) chatOptions(req contract.CompletionRequest) map[string]interface{} {
+	options := p.options()
+	if req.Temperature == nil && req.Seed == nil {
+		return options
+	}
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	if req.Temperature != nil {
+		options["temperature"] = *req.Temperature
+	}
+	if req.Seed != nil {
+		options["seed"] = *req.Seed
+	}
+	return options
+}
+
+// Health checks that the server answers and the model is available locally,
+// or can be pulled on first use.
+func (p *Provider) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama health check: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama health check: http %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("ollama health check: %w", err)
+	}
+	for _, m := range tags.Models {
+		if sameModel(m.Name, p.model) {
+			return nil
+		}
+	}
+	if p.cfg.PullMissing {
+		return nil
+	}
+	return fmt.Errorf("ollama health check: model %s is not pulled (run 'ollama pull %s' or set pull_missing)", p.model, p.model)
+}
+
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	req := struct {
+		Model     string                 `json:"model"`
+		Prompt    string                 `json:"prompt"`
+		KeepAlive string                 `json:"keep_alive,omitempty"`
+		Options   map[string]interface{} `json:"options,omitempty"`
+	}{Model: p.model, Prompt: text, KeepAlive: p.cfg.KeepAlive, Options: p.options()}
+
+	var resp struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := p.post(ctx, "/api/embeddings", req, &resp); err != nil {
+		return nil, fmt.Errorf("ollama embedding failed: %w", err)
+	}
+	if len(resp.Embedding) == 0 {
+		return nil, fmt.Errorf("no embedding data returned")
+	}
+	return resp.Embedding, nil
+}
+
+// post sends body to path and decodes the reply into out. When the model is
+// missing and PullMissing is set, it pulls the model and retries once.
+func (p *Provider) post(ctx context.Context, path string, body, out interface{}) error {
+	err := p.do(ctx, p.client, path, body, out)
+	if !p.cfg.PullMissing || !isModelNotFound(err) {
+		return err
+	}
+	if err := p.pull(ctx); err != nil {
+		return err
+	}
+	return p.do(ctx, p.client, path, body, out)
+}
+
+// pull downloads the model once per provider. Concurrent callers wait for the
+// first pull instead of starting their own.
+func (p *Provider) pull(ctx context.Context) error {
+	p.pullMu.Lock()
+	defer p.pullMu.Unlock()
+	if p.pulled {
+		return nil
+	}
+
+	// Pulls can take minutes, so only ctx bounds them, not the request timeout.
+	req := map[string]interface{}{"model": p.model, "stream": false}
+	var resp struct {
+		Status string `json:"status"`
+	}
+	if err := p.do(ctx, &http.Client{Transport: p.cfg.Transport}, "/api/pull", req, &resp); err != nil {
+		return fmt.Errorf("pull model %s: %w", p.model, err)
+	}
+	p.pulled = true
+	return nil
+}
+
+// statusError is a non-2xx reply from Ollama.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.code, e.message)
+}
+
+func isModelNotFound(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound && strings.Contains(se.message, "not found")
+}
+
+func (p *Provider) do(ctx context.Context, client *http.Client, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			message = apiErr.Error
+		}
+		return &statusError{code: resp.StatusCode, message: message}
+	}
+	return json.Unmarshal(data, out)
+}
+
+// sameModel matches model names, treating a missing tag as ":latest".
+func sameModel(a, b string) bool {
+	if !strings.Contains(a, ":") {
+		a += ":latest"
+	}
+	if !strings.Contains(b, ":") {
+		b += ":latest"
+	}
+	return a == b
+}
@@ -0,0 +1,160 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// fakeOllama serves /api/chat, /api/embeddings, /api/tags and /api/pull. The
+// model is reported missing until it has been pulled when pulled is false.
+type fakeOllama struct {
+	mu       sync.Mutex
+	pulled   bool
+	pulls    int
+	requests []map[string]interface{}
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path == "/api/tags" {
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest"}]}`))
+		return
+	}
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.requests = append(f.requests, body)
+	switch r.URL.Path {
+	case "/api/pull":
+		f.pulls++
+		f.pulled = true
+		w.Write([]byte(`{"status":"success"}`))
+		return
+	}
+	if !f.pulled {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"qwen3\" not found, try pulling it first"}`))
+		return
+	}
+	switch r.URL.Path {
+	case "/api/chat":
+		w.Write([]byte(`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"search","arguments":{"q":"heike"}}}]},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+	case "/api/embeddings":
+		w.Write([]byte(`{"embedding":[0.1,0.2,0.3]}`))
+	}
+}
+
+func TestProvider_GenerateSendsNativeRequest(t *testing.T) {
+	fake := &fakeOllama{pulled: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := New(server.URL+"/v1", "qwen3", RuntimeConfig{RequestTimeout: time.Second, KeepAlive: "10m", NumCtx: 8192})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	resp, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Messages: []contract.Message{
+			{Role: "user", Content: "find heike", Images: []contract.ImagePart{{Data: "aGk=", MIMEType: "image/png"}}},
+			{Role: "assistant", ToolCalls: []*contract.ToolCall{{ID: "call_1", Name: "search", Input: `{"q":"x"}`}}},
+			{Role: "tool", Content: "result", ToolCallID: "call_1"},
+		},
+		Tools:          []contract.ToolDef{{Name: "search", Description: "Search"}},
+		ResponseFormat: &contract.ResponseFormat{Type: contract.ResponseFormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Input != `{"q":"heike"}` || resp.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 3 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+
+	req := fake.requests[0]
+	if req["model"] != "qwen3" || req["keep_alive"] != "10m" || req["stream"] != false || req["format"] != "json" {
+		t.Fatalf("unexpected request: %v", req)
+	}
+	if opts, _ := req["options"].(map[string]interface{}); opts["num_ctx"] != float64(8192) {
+		t.Fatalf("options = %v", req["options"])
+	}
+	messages := req["messages"].([]interface{})
+	if images := messages[0].(map[string]interface{})["images"].([]interface{}); images[0] != "aGk=" {
+		t.Fatalf("images = %v", images)
+	}
+	args := messages[1].(map[string]interface{})["tool_calls"].([]interface{})[0].(map[string]interface{})["function"].(map[string]interface{})["arguments"]
+	if args.(map[string]interface{})["q"] != "x" {
+		t.Fatalf("tool call arguments should be an object, got %v", args)
+	}
+}
+
+func TestProvider_GenerateSendsSamplingOptions(t *testing.T) {
+	fake := &fakeOllama{pulled: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, err := New(server.URL+"/v1", "qwen3", RuntimeConfig{RequestTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	temperature, seed := 0.8, int64(2)
+	if _, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Messages:    []contract.Message{{Role: "user", Content: "hi"}},
+		Temperature: &temperature,
+		Seed:        &seed,
+	}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	opts, _ := fake.requests[0]["options"].(map[string]interface{})
+	if opts["temperature"] != 0.8 || opts["seed"] != float64(2) || opts["num_ctx"] != nil {
+		t.Fatalf("options = %v", fake.requests[0]["options"])
+	}
+}
+
+func TestProvider_PullsMissingModelOnce(t *testing.T) {
+	fake := &fakeOllama{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	provider, _ := New(server.URL, "qwen3", RuntimeConfig{RequestTimeout: time.Second, PullMissing: true})
+	embedding, err := provider.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(embedding) != 3 {
+		t.Fatalf("embedding = %v", embedding)
+	}
+	if _, err := provider.Generate(context.Background(), contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if fake.pulls != 1 {
+		t.Fatalf("pulls = %d, want 1", fake.pulls)
+	}
+}
+
+func TestProvider_MissingModelWithoutPull(t *testing.T) {
+	server := httptest.NewServer(&fakeOllama{})
+	defer server.Close()
+
+	provider, _ := New(server.URL, "qwen3", RuntimeConfig{RequestTimeout: time.Second})
+	if _, err := provider.Embed(context.Background(), "hello"); err == nil {
+		t.Fatal("expected model not found error")
+	}
+	if err := provider.Health(context.Background()); err == nil {
+		t.Fatal("expected health error for a model that is not pulled")
+	}
+
+	provider, _ = New(server.URL, "llama3.2", RuntimeConfig{RequestTimeout: time.Second})
+	if err := provider.Health(context.Background()); err != nil {
+		t.Fatalf("health: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -16,7 +18,9 @@ type Provider struct {
 	model  string
 }
 
-func New(apiKey, baseURL, model string) *Provider {
+// New creates an OpenAI provider. client carries the requests; nil uses the
+// SDK default.
+func New(apiKey, baseURL, model string, client *http.Client) *Provider {
 	if apiKey == "" {
 		apiKey = os.Getenv("OPENAI_API_KEY")
 	}
@@ -34,8 +38,17 @@ func New(apiKey, baseURL, model string) *Provider {
 	// But for proper OAuth rotation, we might need a custom HTTP client or transport.
 	// For now, we assume static token provided via config or env.
 
-	client := openai.NewClientWithConfig(cfg)
-	return &Provider{client: client, model: model}
+	if client != nil {
+		cfg.HTTPClient = client
+	}
+
+	return &Provider{client: openai.NewClientWithConfig(cfg), model: model}
+}
+
+// NewWithConfig builds a provider from a prepared client config, for
+// OpenAI-compatible services that need their own headers or transport.
+func NewWithConfig(cfg openai.ClientConfig, model string) *Provider {
+	return &Provider{client: openai.NewClientWithConfig(cfg), model: model}
 }
 
 func (p *Provider) Name() string {
@@ -50,6 +63,10 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 			Content:    m.Content,
 			ToolCallID: m.ToolCallID,
 		}
+		if len(m.Images) > 0 {
+			msg.Content = ""
+			msg.MultiContent = toMultiContent(m)
+		}
 
 		if len(m.ToolCalls) > 0 {
 			var tcs []openai.ToolCall
@@ -88,10 +105,23 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		})
 	}
 
+	responseFormat, err := toResponseFormat(req.ResponseFormat)
+	if err != nil {
+		return nil, err
+	}
+
 	chatReq := openai.ChatCompletionRequest{
-		Model:    req.Model,
-		Messages: messages,
-		Tools:    tools,
+		Model:          req.Model,
+		Messages:       messages,
+		Tools:          tools,
+		ResponseFormat: responseFormat,
+	}
+	if req.Temperature != nil {
+		chatReq.Temperature = float32(*req.Temperature)
+	}
+	if req.Seed != nil {
+		seed := int(*req.Seed)
+		chatReq.Seed = &seed
 	}
 
 	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
@@ -103,7 +133,16 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	}
 
 	choice := resp.Choices[0]
-	result := &contract.CompletionResponse{Content: choice.Message.Content}
+	result := &contract.CompletionResponse{
+		Content: choice.Message.Content,
+		Usage: &contract.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}
+	if details := resp.Usage.PromptTokensDetails; details != nil {
+		result.Usage.CachedPromptTokens = details.CachedTokens
+	}
 
 	if len(choice.Message.ToolCalls) > 0 {
 		for _, tc := range choice.Message.ToolCalls {
@@ -122,6 +161,59 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	return result, nil
 }
 
+// toMultiContent converts a message with images into text and image_url
+// parts; the API rejects Content and MultiContent together.
+func toMultiContent(m contract.Message) []openai.ChatMessagePart {
+	parts := make([]openai.ChatMessagePart, 0, len(m.Images)+1)
+	if m.Content != "" {
+		parts = append(parts, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: m.Content})
+	}
+	for _, img := range m.Images {
+		parts = append(parts, openai.ChatMessagePart{
+			Type:     openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{URL: img.DataURL(), Detail: openai.ImageURLDetailAuto},
+		})
+	}
+	return parts
+}
+
+func toResponseFormat(format *contract.ResponseFormat) (*openai.ChatCompletionResponseFormat, error) {
+	if format == nil {
+		return nil, nil
+	}
+	switch format.Type {
+	case contract.ResponseFormatJSONObject:
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}, nil
+	case contract.ResponseFormatJSONSchema:
+		schema, err := json.Marshal(format.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid response schema: %w", err)
+		}
+		name := format.Name
+		if name == "" {
+			name = "response"
+		}
+		return &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   name,
+				Schema: json.RawMessage(schema),
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported response format %q", format.Type)
+	}
+}
+
+// Health lists models, which fails fast on an invalid key without spending
+// tokens.
+func (p *Provider) Health(ctx context.Context) error {
+	if _, err := p.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("openai health check: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	model := p.model
 	if model == "" {
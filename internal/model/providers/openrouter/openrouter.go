@@ -0,0 +1,124 @@
+package openrouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+	openaiProvider "github.com/harunnryd/heike/internal/model/providers/openai"
+	"github.com/harunnryd/heike/internal/model/providers/ratelimit"
+)
+
+const (
+	DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+	// Attribution headers OpenRouter uses to identify the calling app.
+	appReferer = "https://github.com/harunnryd/heike"
+	appTitle   = "Heike"
+)
+
+type Provider struct {
+	inner   *openaiProvider.Provider
+	model   string
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// New creates an OpenRouter provider. Requests go through base, wrapped in
+// the rate-limit transport; nil uses http.DefaultTransport.
+func New(apiKey, baseURL, model string, timeout time.Duration, base http.RoundTripper) (*Provider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("api key is required")
+	}
+	model = strings.TrimSpace(model)
+	if !ValidModelID(model) {
+		return nil, fmt.Errorf("openrouter model must be <vendor>/<model>, got %q", model)
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	transport := ratelimit.NewTransport(base, map[string]string{
+		"HTTP-Referer": appReferer,
+		"X-Title":      appTitle,
+	}, ParseRateLimit, ratelimit.DefaultMaxWait)
+	client := &http.Client{Transport: transport, Timeout: timeout}
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = strings.TrimSuffix(baseURL, "/")
+	cfg.HTTPClient = client
+
+	return &Provider{
+		inner:   openaiProvider.NewWithConfig(cfg, model),
+		model:   model,
+		apiKey:  apiKey,
+		baseURL: cfg.BaseURL,
+		client:  client,
+	}, nil
+}
+
+// ValidModelID reports whether name follows OpenRouter's "<vendor>/<model>"
+// naming, optionally with a ":variant" suffix such as ":free".
+func ValidModelID(name string) bool {
+	vendor, model, ok := strings.Cut(name, "/")
+	return ok && vendor != "" && model != "" && !strings.ContainsAny(name, " \t")
+}
+
+func (p *Provider) Name() string {
+	return "openrouter"
+}
+
+func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	req.Model = p.model
+	resp, err := p.inner.Generate(ctx, req)
+	if err != nil {
+		return nil, ratelimit.ClassifyError(err, "openrouter")
+	}
+	return resp, nil
+}
+
+// Health queries the key endpoint. OpenRouter's model list is public, so it
+// would not catch an invalid key.
+func (p *Provider) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/key", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("openrouter health check: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("openrouter health check: http %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embedding not supported by openrouter provider")
+}
+
+// ParseRateLimit reads OpenRouter's X-RateLimit-* headers, where the reset is
+// a Unix timestamp in milliseconds.
+func ParseRateLimit(h http.Header, now time.Time) (time.Time, bool) {
+	if h.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	reset := time.UnixMilli(ms)
+	if !reset.After(now) {
+		return time.Time{}, false
+	}
+	return reset, true
+}
@@ -0,0 +1,116 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+func TestNew_RequiresVendorModelName(t *testing.T) {
+	if _, err := New("key", "", "llama-3.3-70b", time.Second, nil); err == nil {
+		t.Fatal("expected error for model without vendor")
+	}
+	if _, err := New("key", "", "meta-llama/llama-3.3-70b-instruct:free", time.Second, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProvider_GenerateSendsAttributionAndModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("HTTP-Referer") == "" || r.Header.Get("X-Title") != appTitle {
+			t.Errorf("missing attribution headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"model":"anthropic/claude-3.5-sonnet"`) {
+			t.Errorf("unexpected request body: %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := New("key", server.URL, "anthropic/claude-3.5-sonnet", time.Second, nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	resp, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Model:    "alias",
+		Messages: []contract.Message{{Role: "user", Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if resp.Content != "hi" {
+		t.Fatalf("content = %q", resp.Content)
+	}
+}
+
+func TestProvider_RateLimitIsTransient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"rate limited","code":429}}`))
+	}))
+	defer server.Close()
+
+	provider, _ := New("key", server.URL, "openrouter/auto", time.Second, nil)
+	_, err := provider.Generate(context.Background(), contract.CompletionRequest{
+		Messages: []contract.Message{{Role: "user", Content: "hello"}},
+	})
+	if !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(5*time.Second).UnixMilli(), 10))
+	if reset, ok := ParseRateLimit(h, now); !ok || !reset.Equal(now.Add(5*time.Second)) {
+		t.Fatalf("reset = %v %v", reset, ok)
+	}
+	h.Set("X-RateLimit-Remaining", "3")
+	if _, ok := ParseRateLimit(h, now); ok {
+		t.Fatal("expected no block with remaining capacity")
+	}
+}
+
+func TestProvider_HealthChecksKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/key" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	good, err := New("good", server.URL, "anthropic/claude-3.5-sonnet", time.Second, nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if err := good.Health(context.Background()); err != nil {
+		t.Fatalf("expected healthy provider, got %v", err)
+	}
+
+	bad, err := New("bad", server.URL, "anthropic/claude-3.5-sonnet", time.Second, nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if err := bad.Health(context.Background()); err == nil {
+		t.Fatal("expected invalid key to be unhealthy")
+	}
+}
@@ -0,0 +1,156 @@
+// Package ratelimit tracks provider rate-limit headers for OpenAI-compatible
+// HTTP APIs and holds back requests until the advertised window resets.
+package ratelimit
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// DefaultMaxWait is the longest a request is held back for a rate-limit
+// window. Longer windows fail fast so the router can fall back.
+const DefaultMaxWait = 10 * time.Second
+
+// HeaderParser reports when the provider's exhausted rate-limit window
+// resets. ok is false when the response shows remaining capacity.
+type HeaderParser func(h http.Header, now time.Time) (reset time.Time, ok bool)
+
+// Transport injects provider headers and delays requests while the last
+// response reported an exhausted rate limit.
+type Transport struct {
+	base    http.RoundTripper
+	headers map[string]string
+	parse   HeaderParser
+	maxWait time.Duration
+	now     func() time.Time
+
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func NewTransport(base http.RoundTripper, headers map[string]string, parse HeaderParser, maxWait time.Duration) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if maxWait <= 0 {
+		maxWait = DefaultMaxWait
+	}
+	return &Transport{
+		base:    base,
+		headers: headers,
+		parse:   parse,
+		maxWait: maxWait,
+		now:     time.Now,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.wait(req); err != nil {
+		return nil, err
+	}
+
+	if len(t.headers) > 0 {
+		req = req.Clone(req.Context())
+		for key, value := range t.headers {
+			if value != "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.observe(resp)
+	return resp, nil
+}
+
+// BlockedUntil returns the time the current rate-limit window resets, or the
+// zero time when requests are not being held back.
+func (t *Transport) BlockedUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.blockedUntil.After(t.now()) {
+		return t.blockedUntil
+	}
+	return time.Time{}
+}
+
+func (t *Transport) wait(req *http.Request) error {
+	until := t.BlockedUntil()
+	if until.IsZero() {
+		return nil
+	}
+	delay := until.Sub(t.now())
+	if delay > t.maxWait {
+		return heikeErrors.RateLimited(fmt.Sprintf("rate limited by %s for %s", req.URL.Host, delay.Round(time.Second)))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (t *Transport) observe(resp *http.Response) {
+	now := t.now()
+	var reset time.Time
+	if resp.StatusCode == http.StatusTooManyRequests {
+		reset, _ = RetryAfter(resp.Header, now)
+	}
+	if reset.IsZero() && t.parse != nil {
+		reset, _ = t.parse(resp.Header, now)
+	}
+	if reset.IsZero() {
+		return
+	}
+
+	t.mu.Lock()
+	if reset.After(t.blockedUntil) {
+		t.blockedUntil = reset
+	}
+	t.mu.Unlock()
+}
+
+// RetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func RetryAfter(h http.Header, now time.Time) (time.Time, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds * float64(time.Second))), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at, true
+	}
+	return time.Time{}, false
+}
+
+// ClassifyError marks HTTP 429 responses from an OpenAI-compatible API as
+// transient so callers retry or fall back instead of failing hard.
+func ClassifyError(err error, provider string) error {
+	if err == nil {
+		return nil
+	}
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	if (stdErrors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusTooManyRequests) ||
+		(stdErrors.As(err, &reqErr) && reqErr.HTTPStatusCode == http.StatusTooManyRequests) {
+		return heikeErrors.WrapWithCategory(err, provider+" rate limit exceeded", heikeErrors.ErrRateLimited)
+	}
+	return err
+}
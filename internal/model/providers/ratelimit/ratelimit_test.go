@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+func TestTransport_InjectsHeadersAndBlocksAfterRetryAfter(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Title") != "Heike" {
+			t.Errorf("expected X-Title header, got %q", r.Header.Get("X-Title"))
+		}
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil, map[string]string{"X-Title": "Heike"}, nil, time.Second)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	resp.Body.Close()
+	if transport.BlockedUntil().IsZero() {
+		t.Fatal("expected transport to be blocked after 429")
+	}
+
+	_, err = client.Get(server.URL)
+	if err == nil || !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected transient rate-limit error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected blocked request to skip the server, got %d calls", calls)
+	}
+}
+
+func TestTransport_WaitsForShortWindow(t *testing.T) {
+	parse := func(h http.Header, now time.Time) (time.Time, bool) {
+		if h.Get("X-Remaining") == "0" {
+			return now.Add(50 * time.Millisecond), true
+		}
+		return time.Time{}, false
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Remaining", "0")
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil, nil, parse, time.Second)
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled wait, got %v", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := http.Header{}
+	h.Set("Retry-After", "1.5")
+	if at, ok := RetryAfter(h, now); !ok || at.Sub(now) != 1500*time.Millisecond {
+		t.Fatalf("seconds retry-after = %v %v", at, ok)
+	}
+	h.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	if at, ok := RetryAfter(h, now); !ok || !at.Equal(now.Add(time.Minute)) {
+		t.Fatalf("date retry-after = %v %v", at, ok)
+	}
+}
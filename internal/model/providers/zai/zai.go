@@ -3,6 +3,7 @@ package zai
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/harunnryd/heike/internal/model/contract"
 
@@ -17,9 +18,18 @@ const (
 type Provider struct {
 	client *openai.Client
 	model  string
+	cfg    RuntimeConfig
 }
 
-func New(apiKey string, model string) (*Provider, error) {
+// RuntimeConfig tunes a Provider.
+type RuntimeConfig struct {
+	// HealthProbe makes Health send a one-token completion, which is billed.
+	HealthProbe bool
+}
+
+// New creates a Z.ai provider. client carries the requests; nil uses the SDK
+// default.
+func New(apiKey string, model string, client *http.Client, cfg RuntimeConfig) (*Provider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("api key is required")
 	}
@@ -29,10 +39,14 @@ func New(apiKey string, model string) (*Provider, error) {
 
 	config := openai.DefaultConfig(apiKey)
 	config.BaseURL = CodingBaseURL
+	if client != nil {
+		config.HTTPClient = client
+	}
 
 	return &Provider{
 		client: openai.NewClientWithConfig(config),
 		model:  model,
+		cfg:    cfg,
 	}, nil
 }
 
@@ -92,6 +106,13 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 		Messages: messages,
 		Tools:    tools,
 	}
+	if req.Temperature != nil {
+		chatReq.Temperature = float32(*req.Temperature)
+	}
+	if req.Seed != nil {
+		seed := int(*req.Seed)
+		chatReq.Seed = &seed
+	}
 
 	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
 	if err != nil {
@@ -109,6 +130,10 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	result := &contract.CompletionResponse{
 		Content:   choice.Message.Content,
 		ToolCalls: nil,
+		Usage: &contract.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
 	}
 
 	if len(choice.Message.ToolCalls) > 0 {
@@ -129,6 +154,24 @@ func (p *Provider) Generate(ctx context.Context, req contract.CompletionRequest)
 	return result, nil
 }
 
+// Health sends a one-token completion when HealthProbe is set, since the
+// coding endpoint does not serve a model list. Otherwise it relies on New
+// having required an API key.
+func (p *Provider) Health(ctx context.Context) error {
+	if !p.cfg.HealthProbe {
+		return nil
+	}
+	_, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model:     p.model,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "ping"}},
+		MaxTokens: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("zai health check: %w", err)
+	}
+	return nil
+}
+
 func (p *Provider) Embed(ctx context.Context, text string) ([]float32, error) {
 	return nil, fmt.Errorf("embedding not supported by zai provider")
 }
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// ToolFixture holds the tool results of one recorded turn, keyed by tool
+// call ID. It is written by ExtractToolFixture, edited by hand, and read
+// back to replay the turn with different tool output.
+type ToolFixture struct {
+	SessionID string              `json:"session_id"`
+	TraceID   string              `json:"trace_id"`
+	Results   []ToolFixtureResult `json:"results"`
+}
+
+type ToolFixtureResult struct {
+	CallID string `json:"call_id"`
+	Name   string `json:"name"`
+	Input  string `json:"input,omitempty"`
+	Output string `json:"output"`
+}
+
+// ReplayResult compares the recorded response of the first completion that
+// saw a substituted tool result with the response to the edited request.
+type ReplayResult struct {
+	// Step is the index of the replayed completion within the turn.
+	Step        int
+	Model       string
+	Substituted []string
+	Request     contract.CompletionRequest
+	Recorded    *contract.CompletionResponse
+	Replayed    *contract.CompletionResponse
+}
+
+// WireTurn returns the entries of the turn traced as traceID, or of the last
+// traced turn when traceID is empty.
+func WireTurn(entries []WireEntry, traceID string) ([]WireEntry, error) {
+	if traceID == "" {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].TraceID != "" {
+				traceID = entries[i].TraceID
+				break
+			}
+		}
+		if traceID == "" {
+			return nil, fmt.Errorf("wire log has no traced turns")
+		}
+	}
+	var turn []WireEntry
+	for _, entry := range entries {
+		if entry.TraceID == traceID {
+			turn = append(turn, entry)
+		}
+	}
+	if len(turn) == 0 {
+		return nil, fmt.Errorf("no wire log entries for trace %s", traceID)
+	}
+	return turn, nil
+}
+
+// ExtractToolFixture collects the tool results the model saw during turn,
+// in the order they first appeared.
+func ExtractToolFixture(turn []WireEntry) ToolFixture {
+	fixture := ToolFixture{}
+	if len(turn) > 0 {
+		fixture.SessionID = turn[0].SessionID
+		fixture.TraceID = turn[0].TraceID
+	}
+	calls := make(map[string]*contract.ToolCall)
+	seen := make(map[string]bool)
+	for _, entry := range turn {
+		for _, msg := range entry.Request.Messages {
+			for _, tc := range msg.ToolCalls {
+				if tc != nil {
+					calls[tc.ID] = tc
+				}
+			}
+			if msg.Role != "tool" || msg.ToolCallID == "" || seen[msg.ToolCallID] {
+				continue
+			}
+			seen[msg.ToolCallID] = true
+			result := ToolFixtureResult{CallID: msg.ToolCallID, Output: msg.Content}
+			if tc, ok := calls[msg.ToolCallID]; ok {
+				result.Name = tc.Name
+				result.Input = tc.Input
+			}
+			fixture.Results = append(fixture.Results, result)
+		}
+	}
+	return fixture
+}
+
+// ReadToolFixture loads a fixture written by WriteToolFixture.
+func ReadToolFixture(path string) (ToolFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ToolFixture{}, err
+	}
+	var fixture ToolFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return ToolFixture{}, fmt.Errorf("parse tool fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// WriteToolFixture saves fixture as indented JSON for editing.
+func WriteToolFixture(path string, fixture ToolFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// ReplayTurn re-sends the first completion of turn whose prompt contains a
+// tool result that fixture changes, with the fixture outputs substituted.
+// Later completions depend on the new response, so only that step is run.
+func ReplayTurn(ctx context.Context, router ModelRouter, turn []WireEntry, fixture ToolFixture) (*ReplayResult, error) {
+	outputs := make(map[string]string, len(fixture.Results))
+	for _, result := range fixture.Results {
+		outputs[result.CallID] = result.Output
+	}
+
+	for step, entry := range turn {
+		req := entry.Request
+		messages := make([]contract.Message, len(req.Messages))
+		var substituted []string
+		for i, msg := range req.Messages {
+			if output, ok := outputs[msg.ToolCallID]; ok && msg.Role == "tool" && output != msg.Content {
+				msg.Content = output
+				substituted = append(substituted, msg.ToolCallID)
+			}
+			messages[i] = msg
+		}
+		if len(substituted) == 0 {
+			continue
+		}
+		req.Messages = messages
+
+		resp, err := router.Route(ctx, entry.Model, req)
+		if err != nil {
+			return nil, fmt.Errorf("replay step %d: %w", step, err)
+		}
+		return &ReplayResult{
+			Step:        step,
+			Model:       entry.Model,
+			Substituted: substituted,
+			Request:     req,
+			Recorded:    entry.Response,
+			Replayed:    resp,
+		}, nil
+	}
+	return nil, fmt.Errorf("fixture does not change any tool result the model saw")
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// captureProvider records the last request and answers with a fixed reply.
+type captureProvider struct {
+	countingProvider
+	last contract.CompletionRequest
+}
+
+func (p *captureProvider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.last = req
+	return &contract.CompletionResponse{Content: "it rains, take an umbrella"}, nil
+}
+
+func TestReplayTurn_SubstitutesToolResults(t *testing.T) {
+	call := &contract.ToolCall{ID: "call_1", Name: "weather", Input: `{"city":"Oslo"}`}
+	entries := []WireEntry{
+		{TraceID: "old", SessionID: "s1", Model: "primary", Request: contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "hi"}}}},
+		{TraceID: "t1", SessionID: "s1", Model: "primary",
+			Request:  contract.CompletionRequest{Messages: []contract.Message{{Role: "user", Content: "weather?"}}},
+			Response: &contract.CompletionResponse{ToolCalls: []*contract.ToolCall{call}}},
+		{TraceID: "t1", SessionID: "s1", Model: "primary",
+			Request: contract.CompletionRequest{Messages: []contract.Message{
+				{Role: "user", Content: "weather?"},
+				{Role: "assistant", ToolCalls: []*contract.ToolCall{call}},
+				{Role: "tool", ToolCallID: "call_1", Content: "sunny"},
+			}},
+			Response: &contract.CompletionResponse{Content: "it is sunny"}},
+	}
+
+	turn, err := WireTurn(entries, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turn) != 2 {
+		t.Fatalf("turn has %d entries, want 2", len(turn))
+	}
+
+	fixture := ExtractToolFixture(turn)
+	if fixture.TraceID != "t1" || len(fixture.Results) != 1 {
+		t.Fatalf("fixture = %+v", fixture)
+	}
+	if got := fixture.Results[0]; got.Name != "weather" || got.Input != `{"city":"Oslo"}` || got.Output != "sunny" {
+		t.Fatalf("fixture result = %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture.Results[0].Output = "heavy rain"
+	if err := WriteToolFixture(path, fixture); err != nil {
+		t.Fatal(err)
+	}
+	edited, err := ReadToolFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewModelRouter(config.ModelsConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &captureProvider{}
+	router.providers["primary"] = provider
+
+	result, err := ReplayTurn(context.Background(), router, turn, edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Step != 1 || len(result.Substituted) != 1 || result.Substituted[0] != "call_1" {
+		t.Fatalf("result = %+v", result)
+	}
+	if result.Recorded.Content != "it is sunny" || result.Replayed.Content != "it rains, take an umbrella" {
+		t.Fatalf("responses = %q, %q", result.Recorded.Content, result.Replayed.Content)
+	}
+	if got := provider.last.Messages[2].Content; got != "heavy rain" {
+		t.Fatalf("replayed tool result = %q", got)
+	}
+	if entries[2].Request.Messages[2].Content != "sunny" {
+		t.Fatal("replay modified the recorded request")
+	}
+
+	if _, err := ReplayTurn(context.Background(), router, turn, ExtractToolFixture(turn)); err == nil {
+		t.Fatal("expected error for an unedited fixture")
+	}
+}
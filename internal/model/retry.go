@@ -0,0 +1,161 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/metrics"
+	"github.com/harunnryd/heike/internal/model/contract"
+
+	anthropic "github.com/anthropics/anthropic-sdk-go"
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// Retry classes accepted by models.retry.retry_on.
+const (
+	RetryRateLimit   = "rate_limit"
+	RetryServerError = "server_error"
+	RetryTimeout     = "timeout"
+)
+
+// maxRetryBackoff caps the exponential backoff between attempts.
+const maxRetryBackoff = 30 * time.Second
+
+var httpStatusPattern = regexp.MustCompile(`(?i)(?:http|status(?: code)?:?)\s*(\d{3})\b`)
+
+// retryPolicy retries one model on transient provider errors.
+type retryPolicy struct {
+	maxAttempts int
+	backoffBase time.Duration
+	classes     map[string]bool
+}
+
+func newRetryPolicy(cfg config.ModelRetryConfig) (*retryPolicy, error) {
+	base, err := config.DurationOrDefault(cfg.BackoffBase, config.DefaultModelRetryBackoffBase)
+	if err != nil {
+		return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid models.retry.backoff_base: %v", err))
+	}
+	policy := &retryPolicy{
+		maxAttempts: cfg.MaxAttempts,
+		backoffBase: base,
+		classes:     make(map[string]bool, len(cfg.RetryOn)),
+	}
+	if policy.maxAttempts < 1 {
+		policy.maxAttempts = 1
+	}
+	for _, class := range cfg.RetryOn {
+		class = strings.ToLower(strings.TrimSpace(class))
+		switch class {
+		case RetryRateLimit, RetryServerError, RetryTimeout:
+			policy.classes[class] = true
+		default:
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid models.retry.retry_on class %q (allowed: %s, %s, %s)", class, RetryRateLimit, RetryServerError, RetryTimeout))
+		}
+	}
+	return policy, nil
+}
+
+// backoff returns the wait before the given retry (1-based).
+func (p *retryPolicy) backoff(retry int) time.Duration {
+	wait := p.backoffBase << (retry - 1)
+	if wait <= 0 || wait > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return wait
+}
+
+// RetryClass returns the retry class of a provider error, or "" when the
+// error is not transient (bad request, auth, cancellation, ...).
+func RetryClass(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+	if IsQuotaError(err) {
+		return RetryRateLimit
+	}
+	if status := httpStatus(err); status != 0 {
+		switch {
+		case status == http.StatusTooManyRequests:
+			return RetryRateLimit
+		case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+			return RetryTimeout
+		case status >= 500:
+			return RetryServerError
+		}
+		return ""
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return RetryTimeout
+	}
+	if strings.Contains(strings.ToLower(err.Error()), "timeout") {
+		return RetryTimeout
+	}
+	return ""
+}
+
+// httpStatus extracts the HTTP status code from SDK errors, falling back to
+// "http 503"-style text used by the hand-written providers.
+func httpStatus(err error) int {
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) {
+		return openaiAPIErr.HTTPStatusCode
+	}
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) {
+		return openaiReqErr.HTTPStatusCode
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode
+	}
+	var genaiErr genai.APIError
+	if errors.As(err, &genaiErr) {
+		return genaiErr.Code
+	}
+	if match := httpStatusPattern.FindStringSubmatch(err.Error()); match != nil {
+		if status, convErr := strconv.Atoi(match[1]); convErr == nil {
+			return status
+		}
+	}
+	return 0
+}
+
+// generateWithRetry calls the provider, retrying errors in the configured
+// classes with exponential backoff. The last error is returned once the
+// attempts are spent so the caller can fall back to another model.
+func (r *DefaultModelRouter) generateWithRetry(ctx context.Context, model string, provider Provider, req contract.CompletionRequest, traceID string) (*contract.CompletionResponse, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := provider.Generate(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		class := RetryClass(err)
+		if attempt >= r.retry.maxAttempts || !r.retry.classes[class] || ctx.Err() != nil {
+			return nil, err
+		}
+
+		wait := r.retry.backoff(attempt)
+		slog.Warn("Provider request failed, retrying", "model", model, "class", class, "attempt", attempt, "backoff", wait, "error", err, "trace_id", traceID)
+		metrics.Inc("provider_retries_total", "model", model, "class", class)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/model/contract"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type flakyProvider struct {
+	countingProvider
+	errs []error
+}
+
+func (p *flakyProvider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+	return &contract.CompletionResponse{Content: "recovered"}, nil
+}
+
+func TestRetryClass(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{&openai.APIError{HTTPStatusCode: 429}, RetryRateLimit},
+		{&openai.APIError{HTTPStatusCode: 503}, RetryServerError},
+		{&openai.APIError{HTTPStatusCode: 400}, ""},
+		{fmt.Errorf("codex http 502: bad gateway"), RetryServerError},
+		{fmt.Errorf("call: %w", context.DeadlineExceeded), RetryTimeout},
+		{context.Canceled, ""},
+		{errors.New("invalid api key"), ""},
+	}
+	for _, tc := range cases {
+		if got := RetryClass(tc.err); got != tc.want {
+			t.Errorf("RetryClass(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy, err := newRetryPolicy(config.ModelRetryConfig{MaxAttempts: 3, BackoffBase: "100ms"})
+	if err != nil {
+		t.Fatalf("newRetryPolicy failed: %v", err)
+	}
+	if got := policy.backoff(1); got != 100*time.Millisecond {
+		t.Fatalf("expected 100ms first backoff, got %s", got)
+	}
+	if got := policy.backoff(3); got != 400*time.Millisecond {
+		t.Fatalf("expected 400ms third backoff, got %s", got)
+	}
+	if got := policy.backoff(20); got != maxRetryBackoff {
+		t.Fatalf("expected capped backoff, got %s", got)
+	}
+
+	if _, err := newRetryPolicy(config.ModelRetryConfig{RetryOn: []string{"auth"}}); err == nil {
+		t.Fatal("expected unknown retry class to be rejected")
+	}
+}
+
+func TestRouter_RetriesBeforeFallback(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{
+		Fallback: "backup",
+		Retry:    config.ModelRetryConfig{MaxAttempts: 3, BackoffBase: "1ms", RetryOn: []string{RetryServerError}},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	primary := &flakyProvider{errs: []error{
+		&openai.APIError{HTTPStatusCode: 500},
+		&openai.APIError{HTTPStatusCode: 502},
+	}}
+	backup := &countingProvider{}
+	router.providers["primary"] = primary
+	router.providers["backup"] = backup
+
+	resp, err := router.Route(context.Background(), "primary", contract.CompletionRequest{})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if resp.Content != "recovered" || primary.calls != 3 || backup.calls != 0 {
+		t.Fatalf("expected primary to recover on third attempt, got %q primary=%d backup=%d", resp.Content, primary.calls, backup.calls)
+	}
+}
+
+func TestRouter_NonRetryableErrorFallsBackImmediately(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{
+		Fallback: "backup",
+		Retry:    config.ModelRetryConfig{MaxAttempts: 3, BackoffBase: "1ms", RetryOn: []string{RetryServerError, RetryRateLimit}},
+	})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	primary := &flakyProvider{errs: []error{&openai.APIError{HTTPStatusCode: 401}}}
+	backup := &countingProvider{}
+	router.providers["primary"] = primary
+	router.providers["backup"] = backup
+
+	if _, err := router.Route(context.Background(), "primary", contract.CompletionRequest{}); err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if primary.calls != 1 || backup.calls != 1 {
+		t.Fatalf("expected one primary call then fallback, got primary=%d backup=%d", primary.calls, backup.calls)
+	}
+}
@@ -2,20 +2,30 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/harunnryd/heike/internal/auth"
 	"github.com/harunnryd/heike/internal/config"
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/logger"
+	"github.com/harunnryd/heike/internal/metrics"
 	"github.com/harunnryd/heike/internal/model/contract"
 	anthropicProvider "github.com/harunnryd/heike/internal/model/providers/anthropic"
 	codexProvider "github.com/harunnryd/heike/internal/model/providers/codex"
 	geminiProvider "github.com/harunnryd/heike/internal/model/providers/gemini"
+	groqProvider "github.com/harunnryd/heike/internal/model/providers/groq"
+	mockProvider "github.com/harunnryd/heike/internal/model/providers/mock"
+	ollamaProvider "github.com/harunnryd/heike/internal/model/providers/ollama"
 	openaiProvider "github.com/harunnryd/heike/internal/model/providers/openai"
+	openrouterProvider "github.com/harunnryd/heike/internal/model/providers/openrouter"
 	zaiProvider "github.com/harunnryd/heike/internal/model/providers/zai"
 )
 
@@ -23,14 +33,66 @@ import (
 type DefaultModelRouter struct {
 	cfg       config.ModelsConfig
 	providers map[string]Provider
+	// clients supplies the pooled HTTP transport providers send through.
+	clients *httpclient.Factory
+	costs   *CostTracker
+	wire    *WireLog
+	cache   *CompletionCache
+	breaker *circuitBreaker
+	retry   *retryPolicy
+	// healthTimeout bounds each provider probe in Health.
+	healthTimeout time.Duration
+	// randFloat draws weighted picks within a tag group; tests replace it.
+	randFloat func() float64
 	mu        sync.RWMutex
 }
 
-// NewModelRouter creates a new model router
+// NewModelRouter creates a new model router whose providers use the default
+// HTTP client pool.
 func NewModelRouter(cfg config.ModelsConfig) (*DefaultModelRouter, error) {
+	return NewModelRouterWithHTTPClients(cfg, nil)
+}
+
+// NewModelRouterWithHTTPClients creates a model router whose providers send
+// requests through clients; nil uses httpclient.Default.
+func NewModelRouterWithHTTPClients(cfg config.ModelsConfig, clients *httpclient.Factory) (*DefaultModelRouter, error) {
 	router := &DefaultModelRouter{
 		cfg:       cfg,
 		providers: make(map[string]Provider),
+		clients:   clients,
+		randFloat: rand.Float64,
+	}
+	if cfg.Cache.Enabled {
+		ttl, err := config.DurationOrDefault(cfg.Cache.TTL, config.DefaultModelCacheTTL)
+		if err != nil {
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid models.cache.ttl: %v", err))
+		}
+		maxEntries := cfg.Cache.MaxEntries
+		if maxEntries <= 0 {
+			maxEntries = config.DefaultModelCacheMaxEntries
+		}
+		router.cache = NewCompletionCache(ttl, maxEntries)
+	}
+	healthTimeout, err := config.DurationOrDefault(cfg.HealthTimeout, config.DefaultModelHealthTimeout)
+	if err != nil {
+		return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid models.health_timeout: %v", err))
+	}
+	router.healthTimeout = healthTimeout
+	retry, err := newRetryPolicy(cfg.Retry)
+	if err != nil {
+		return nil, err
+	}
+	router.retry = retry
+	if cfg.CircuitBreaker.Enabled {
+		cooldown, err := config.DurationOrDefault(cfg.CircuitBreaker.Cooldown, config.DefaultModelCircuitBreakerCooldown)
+		if err != nil {
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid models.circuit_breaker.cooldown: %v", err))
+		}
+		threshold := cfg.CircuitBreaker.FailureThreshold
+		if threshold <= 0 {
+			threshold = config.DefaultModelCircuitBreakerThreshold
+		}
+		router.breaker = newCircuitBreaker(threshold, cooldown)
 	}
 
 	if err := router.initProviders(); err != nil {
@@ -40,23 +102,209 @@ func NewModelRouter(cfg config.ModelsConfig) (*DefaultModelRouter, error) {
 	return router, nil
 }
 
+// SetCostTracker enables spend accounting and daily budget enforcement.
+func (r *DefaultModelRouter) SetCostTracker(tracker *CostTracker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.costs = tracker
+}
+
+// CostTracker returns the configured cost tracker, or nil.
+func (r *DefaultModelRouter) CostTracker() *CostTracker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.costs
+}
+
+// SetWireLog records every completion request and response to w; nil
+// disables recording.
+func (r *DefaultModelRouter) SetWireLog(w *WireLog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wire = w
+}
+
+func (r *DefaultModelRouter) wireLog() *WireLog {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.wire
+}
+
 // Route routes a completion request to the appropriate provider
 func (r *DefaultModelRouter) Route(ctx context.Context, model string, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	start := time.Now()
+	resp, err := r.route(ctx, model, req)
+	r.wireLog().record(ctx, model, false, req, resp, err, start)
+	return resp, err
+}
+
+func (r *DefaultModelRouter) route(ctx context.Context, model string, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
 	traceID := logger.GetTraceID(ctx)
 
 	slog.Info("Routing completion request", "model", model, "trace_id", traceID)
 
-	provider, err := r.resolveProvider(ctx, model)
+	var cacheKey string
+	useCache := r.cache != nil && cacheable(req)
+	if useCache {
+		cacheKey = CacheKey(model, req)
+		if resp, ok := r.cache.Get(cacheKey); ok {
+			slog.Debug("Completion cache hit", "model", model, "trace_id", traceID)
+			metrics.Inc("completion_cache_hits_total", "model", model)
+			return resp, nil
+		}
+	}
+
+	if costs := r.CostTracker(); costs != nil {
+		if err := costs.CheckBudget(); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *contract.CompletionResponse
+	if tag, ok := TagRef(model); ok {
+		tagResp, err := r.routeTag(ctx, tag, req, traceID)
+		if err != nil {
+			return nil, err
+		}
+		resp = tagResp
+	} else {
+		provider, err := r.resolveProvider(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err = r.executeWithFallback(ctx, model, provider, req, traceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if useCache {
+		r.cache.Put(cacheKey, resp)
+	}
+
+	return resp, nil
+}
+
+// RouteStream routes a streaming completion request. Fallback applies only
+// while opening the stream; errors after the first chunk end the stream.
+func (r *DefaultModelRouter) RouteStream(ctx context.Context, model string, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	start := time.Now()
+	wire := r.wireLog()
+	stream, err := r.routeStream(ctx, model, req)
 	if err != nil {
+		wire.record(ctx, model, true, req, nil, err, start)
 		return nil, err
 	}
+	return wire.wireStream(ctx, model, req, start, stream), nil
+}
+
+func (r *DefaultModelRouter) routeStream(ctx context.Context, model string, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	traceID := logger.GetTraceID(ctx)
+
+	slog.Info("Routing streaming completion request", "model", model, "trace_id", traceID)
+
+	costs := r.CostTracker()
+	if costs != nil {
+		if err := costs.CheckBudget(); err != nil {
+			return nil, err
+		}
+	}
+
+	if tag, ok := TagRef(model); ok {
+		return r.routeTagStream(ctx, tag, req, traceID)
+	}
 
-	resp, err := r.executeWithFallback(ctx, model, provider, req, traceID)
+	provider, err := r.resolveProvider(ctx, model)
 	if err != nil {
 		return nil, err
 	}
 
-	return resp, nil
+	primaryErr := circuitOpenError(model)
+	if r.circuitAllows(model) {
+		stream, err := provider.GenerateStream(ctx, req)
+		if err == nil {
+			r.circuitSuccess(model)
+			return r.trackStream(ctx, model, req, stream), nil
+		}
+		r.circuitFailure(ctx, model, err)
+		slog.Error("Provider stream failed", "model", model, "error", err)
+		if IsQuotaError(err) {
+			metrics.Inc("provider_quota_events_total", "model", model)
+		}
+		primaryErr = providerFailure(err, "provider stream failed")
+	} else {
+		slog.Warn("Circuit open, skipping model", "model", model, "trace_id", traceID)
+	}
+
+	if r.cfg.Fallback == "" || model == r.cfg.Fallback {
+		return nil, primaryErr
+	}
+
+	r.mu.RLock()
+	fallbackProvider, exists := r.providers[r.cfg.Fallback]
+	r.mu.RUnlock()
+	if !exists || fallbackProvider == provider || !r.circuitAllows(r.cfg.Fallback) {
+		return nil, primaryErr
+	}
+
+	slog.Info("Attempting stream fallback", "from", model, "to", r.cfg.Fallback)
+	stream, err := fallbackProvider.GenerateStream(ctx, req)
+	if err != nil {
+		r.circuitFailure(ctx, r.cfg.Fallback, err)
+		return nil, providerFailure(err, "fallback stream failed")
+	}
+	r.circuitSuccess(r.cfg.Fallback)
+	return r.trackStream(ctx, r.cfg.Fallback, req, stream), nil
+}
+
+// trackStream forwards chunks unchanged and records the estimated cost once
+// the stream completes. Streams carry no usage, so tokens are estimated.
+func (r *DefaultModelRouter) trackStream(ctx context.Context, model string, req contract.CompletionRequest, in <-chan contract.StreamChunk) <-chan contract.StreamChunk {
+	costs := r.CostTracker()
+	if costs == nil && ctx.Value(usageObserverKey{}) == nil {
+		return in
+	}
+
+	out := make(chan contract.StreamChunk, cap(in))
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		for chunk := range in {
+			content.WriteString(chunk.Delta)
+			if chunk.Done {
+				usage := estimateUsage(req, content.String())
+				cost := 0.0
+				if costs != nil {
+					cost = costs.Record(logger.GetSessionID(ctx), model, usage)
+				}
+				observeUsage(ctx, model, usage, cost)
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (r *DefaultModelRouter) recordUsage(ctx context.Context, model string, req contract.CompletionRequest, resp *contract.CompletionResponse) {
+	if resp == nil {
+		return
+	}
+	usage := estimateUsage(req, resp.Content)
+	if resp.Usage != nil {
+		usage = *resp.Usage
+	}
+	cost := 0.0
+	if costs := r.CostTracker(); costs != nil {
+		if cost = costs.Record(logger.GetSessionID(ctx), model, usage); cost > 0 {
+			slog.Debug("Recorded completion cost", "model", model, "cost_usd", cost)
+		}
+	}
+	observeUsage(ctx, model, usage, cost)
 }
 
 // RouteEmbedding routes an embedding request to the appropriate provider
@@ -161,18 +409,41 @@ func (r *DefaultModelRouter) ListModels() []string {
 	return models
 }
 
-// Health checks the health of the router and its providers
+// Health probes every provider concurrently, each bounded by the health
+// timeout, and reports all unhealthy models in one error.
 func (r *DefaultModelRouter) Health(ctx context.Context) error {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
+	providers := make(map[string]Provider, len(r.providers))
 	for name, provider := range r.providers {
-		if err := provider.Health(ctx); err != nil {
-			slog.Warn("Provider unhealthy", "provider", name, "error", err)
-			return heikeErrors.Transient(fmt.Sprintf("provider %s unhealthy", name))
-		}
+		providers[name] = provider
+	}
+	r.mu.RUnlock()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		unhealthy []string
+	)
+	for name, provider := range providers {
+		wg.Add(1)
+		go func(name string, provider Provider) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, r.healthTimeout)
+			defer cancel()
+			if err := provider.Health(probeCtx); err != nil {
+				slog.Warn("Provider unhealthy", "provider", name, "error", err)
+				mu.Lock()
+				unhealthy = append(unhealthy, name)
+				mu.Unlock()
+			}
+		}(name, provider)
 	}
+	wg.Wait()
 
+	if len(unhealthy) > 0 {
+		sort.Strings(unhealthy)
+		return heikeErrors.Transient(fmt.Sprintf("providers unhealthy: %s", strings.Join(unhealthy, ", ")))
+	}
 	return nil
 }
 
@@ -248,20 +519,29 @@ func (r *DefaultModelRouter) executeWithFallback(ctx context.Context, model stri
 		default:
 		}
 
-		resp, err := currentProvider.Generate(ctx, req)
-		if err == nil {
-			slog.Info("Request completed", "model", currentModel, "attempt", attempt+1, "trace_id", traceID)
-			return resp, nil
-		}
-
-		slog.Error("Provider request failed", "model", currentModel, "attempt", attempt+1, "error", err)
+		if !r.circuitAllows(currentModel) {
+			slog.Warn("Circuit open, skipping model", "model", currentModel, "attempt", attempt+1, "trace_id", traceID)
+			if r.cfg.Fallback == "" || currentModel == r.cfg.Fallback {
+				return nil, circuitOpenError(currentModel)
+			}
+		} else {
+			resp, err := r.generateWithRetry(ctx, currentModel, currentProvider, req, traceID)
+			if err == nil {
+				r.circuitSuccess(currentModel)
+				slog.Info("Request completed", "model", currentModel, "attempt", attempt+1, "trace_id", traceID)
+				r.recordUsage(ctx, currentModel, req, resp)
+				return resp, nil
+			}
+			r.circuitFailure(ctx, currentModel, err)
 
-		if attempt == 0 && currentModel == r.cfg.Fallback {
-			return nil, heikeErrors.WrapWithCategory(err, "provider request failed", heikeErrors.ErrInternal)
-		}
+			slog.Error("Provider request failed", "model", currentModel, "attempt", attempt+1, "error", err)
+			if IsQuotaError(err) {
+				metrics.Inc("provider_quota_events_total", "model", currentModel)
+			}
 
-		if r.cfg.Fallback == "" || currentModel == r.cfg.Fallback {
-			return nil, heikeErrors.WrapWithCategory(err, "provider request failed", heikeErrors.ErrInternal)
+			if r.cfg.Fallback == "" || currentModel == r.cfg.Fallback {
+				return nil, providerFailure(err, "provider request failed")
+			}
 		}
 
 		slog.Info("Attempting fallback", "from", currentModel, "to", r.cfg.Fallback)
@@ -278,6 +558,47 @@ func (r *DefaultModelRouter) executeWithFallback(ctx context.Context, model stri
 	return nil, heikeErrors.Internal("fallback exhausted")
 }
 
+// CircuitStates returns the circuit breaker state of every registered model,
+// or nil when the breaker is disabled.
+func (r *DefaultModelRouter) CircuitStates() map[string]CircuitState {
+	if r.breaker == nil {
+		return nil
+	}
+	models := r.ListModels()
+	sort.Strings(models)
+	return r.breaker.states(models)
+}
+
+func (r *DefaultModelRouter) circuitAllows(model string) bool {
+	return r.breaker == nil || r.breaker.allow(model)
+}
+
+func (r *DefaultModelRouter) circuitSuccess(model string) {
+	if r.breaker != nil {
+		r.breaker.recordSuccess(model)
+	}
+}
+
+// circuitFailure counts a provider failure. Cancellations say nothing about
+// the provider, so they only end a pending probe.
+func (r *DefaultModelRouter) circuitFailure(ctx context.Context, model string, err error) {
+	if r.breaker == nil {
+		return
+	}
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		r.breaker.release(model)
+		return
+	}
+	if r.breaker.recordFailure(model) {
+		slog.Warn("Circuit opened for model", "model", model, "cooldown", r.breaker.cooldown)
+		metrics.Inc("provider_circuit_opened_total", "model", model)
+	}
+}
+
+func circuitOpenError(model string) error {
+	return heikeErrors.Transient(fmt.Sprintf("circuit open for model %s", model))
+}
+
 // createProvider creates a provider instance based on registry entry
 func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provider, error) {
 	switch entry.Provider {
@@ -292,7 +613,7 @@ func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provide
 		}
 
 		return &ProviderAdapter{
-			provider:     openaiProvider.New(entry.APIKey, baseURL, entry.Name),
+			provider:     openaiProvider.New(entry.APIKey, baseURL, entry.Name, r.clients.Client("openai", 0)),
 			name:         entry.Name,
 			providerType: "openai",
 		}, nil
@@ -309,28 +630,59 @@ func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provide
 		}
 
 		return &ProviderAdapter{
-			provider:     openaiProvider.New(apiKey, baseURL, entry.Name),
+			provider:     openaiProvider.New(apiKey, baseURL, entry.Name, r.clients.Client("ollama", 0)),
 			name:         entry.Name,
 			providerType: "ollama",
 		}, nil
 
+	case "ollama-native":
+		requestTimeout, err := config.DurationOrDefault(entry.RequestTimeout, config.DefaultOllamaRequestTimeout)
+		if err != nil {
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid request_timeout for ollama-native model %s: %v", entry.Name, err))
+		}
+
+		provider, err := ollamaProvider.New(entry.BaseURL, entry.Name, ollamaProvider.RuntimeConfig{
+			RequestTimeout: requestTimeout,
+			KeepAlive:      entry.KeepAlive,
+			NumCtx:         entry.NumCtx,
+			PullMissing:    entry.PullMissing,
+			Transport:      r.clients.Transport("ollama-native"),
+		})
+		if err != nil {
+			return nil, heikeErrors.WrapWithCategory(err, "failed to create Ollama provider", heikeErrors.ErrInvalidInput)
+		}
+
+		return &ProviderAdapter{
+			provider:     provider,
+			name:         entry.Name,
+			providerType: "ollama-native",
+		}, nil
+
 	case "anthropic":
-		if entry.APIKey == "" {
-			return nil, heikeErrors.InvalidInput("API key required for Anthropic provider")
+		apiKey, err := apiKeyOrAuthFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		if apiKey == "" {
+			return nil, heikeErrors.InvalidInput("API key required for Anthropic provider (set api_key or run 'heike provider login anthropic')")
 		}
 
 		return &ProviderAdapter{
-			provider:     anthropicProvider.New(entry.APIKey),
+			provider:     anthropicProvider.New(apiKey, entry.PromptCache, r.clients.Client("anthropic", 0)),
 			name:         entry.Name,
 			providerType: "anthropic",
 		}, nil
 
 	case "gemini":
-		if entry.APIKey == "" {
-			return nil, heikeErrors.InvalidInput("API key required for Gemini provider")
+		apiKey, err := apiKeyOrAuthFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		if apiKey == "" {
+			return nil, heikeErrors.InvalidInput("API key required for Gemini provider (set api_key or run 'heike provider login gemini')")
 		}
 
-		provider, err := geminiProvider.New(entry.APIKey)
+		provider, err := geminiProvider.New(apiKey, r.clients.Client("gemini", 0))
 		if err != nil {
 			return nil, heikeErrors.WrapWithCategory(err, "failed to create Gemini provider", heikeErrors.ErrInternal)
 		}
@@ -346,7 +698,9 @@ func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provide
 			return nil, heikeErrors.InvalidInput("API key required for Zai provider")
 		}
 
-		provider, err := zaiProvider.New(entry.APIKey, entry.Name)
+		provider, err := zaiProvider.New(entry.APIKey, entry.Name, r.clients.Client("zai", 0), zaiProvider.RuntimeConfig{
+			HealthProbe: r.cfg.HealthProbeCompletions,
+		})
 		if err != nil {
 			return nil, heikeErrors.WrapWithCategory(err, "failed to create Zai provider", heikeErrors.ErrInternal)
 		}
@@ -357,6 +711,48 @@ func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provide
 			providerType: "zai",
 		}, nil
 
+	case "groq":
+		if entry.APIKey == "" {
+			return nil, heikeErrors.InvalidInput("API key required for Groq provider")
+		}
+
+		requestTimeout, err := config.DurationOrDefault(entry.RequestTimeout, config.DefaultProviderRequestTimeout)
+		if err != nil {
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid request_timeout for groq model %s: %v", entry.Name, err))
+		}
+
+		provider, err := groqProvider.New(entry.APIKey, entry.BaseURL, entry.Name, requestTimeout, r.clients.Transport("groq"))
+		if err != nil {
+			return nil, heikeErrors.WrapWithCategory(err, "failed to create Groq provider", heikeErrors.ErrInvalidInput)
+		}
+
+		return &ProviderAdapter{
+			provider:     provider,
+			name:         entry.Name,
+			providerType: "groq",
+		}, nil
+
+	case "openrouter":
+		if entry.APIKey == "" {
+			return nil, heikeErrors.InvalidInput("API key required for OpenRouter provider")
+		}
+
+		requestTimeout, err := config.DurationOrDefault(entry.RequestTimeout, config.DefaultProviderRequestTimeout)
+		if err != nil {
+			return nil, heikeErrors.InvalidInput(fmt.Sprintf("invalid request_timeout for openrouter model %s: %v", entry.Name, err))
+		}
+
+		provider, err := openrouterProvider.New(entry.APIKey, entry.BaseURL, entry.Name, requestTimeout, r.clients.Transport("openrouter"))
+		if err != nil {
+			return nil, heikeErrors.WrapWithCategory(err, "failed to create OpenRouter provider", heikeErrors.ErrInvalidInput)
+		}
+
+		return &ProviderAdapter{
+			provider:     provider,
+			name:         entry.Name,
+			providerType: "openrouter",
+		}, nil
+
 	case "openai-codex":
 		requestTimeout, err := config.DurationOrDefault(entry.RequestTimeout, config.DefaultCodexRequestTimeout)
 		if err != nil {
@@ -373,12 +769,69 @@ func (r *DefaultModelRouter) createProvider(entry config.ModelRegistry) (Provide
 			provider: codexProvider.New(entry.APIKey, entry.BaseURL, entry.AuthFile, codexProvider.RuntimeConfig{
 				RequestTimeout:         requestTimeout,
 				EmbeddingInputMaxChars: embeddingInputMaxChars,
+				MaxEventBytes:          entry.MaxEventBytes,
+				MaxResponseBytes:       entry.MaxResponseBytes,
+				MaxToolArgBytes:        entry.MaxToolArgBytes,
+				HTTPClients:            r.clients,
+				HealthProbe:            r.cfg.HealthProbeCompletions,
 			}),
 			name:         entry.Name,
 			providerType: "openai-codex",
 		}, nil
 
+	case "mock":
+		provider, err := mockProvider.Load(entry.Fixture)
+		if err != nil {
+			return nil, heikeErrors.WrapWithCategory(err, "failed to create mock provider", heikeErrors.ErrInvalidInput)
+		}
+
+		return &ProviderAdapter{
+			provider:     provider,
+			name:         entry.Name,
+			providerType: "mock",
+		}, nil
+
 	default:
 		return nil, heikeErrors.InvalidInput(fmt.Sprintf("unknown provider type: %s", entry.Provider))
 	}
 }
+
+// apiKeyOrAuthFile returns the entry's API key, falling back to the key saved
+// by 'heike provider login' in auth_file (default ~/.heike/auth/<provider>.json).
+func apiKeyOrAuthFile(entry config.ModelRegistry) (string, error) {
+	if entry.APIKey != "" {
+		return entry.APIKey, nil
+	}
+	apiKey, err := auth.LoadProviderAPIKey(entry.Provider, entry.AuthFile)
+	if err != nil {
+		return "", heikeErrors.InvalidInput(fmt.Sprintf("model %s: %v", entry.Name, err))
+	}
+	return apiKey, nil
+}
+
+// IsQuotaError reports whether err is a provider rate limit or exhausted quota.
+// Providers without typed errors are matched on their HTTP status text.
+func IsQuotaError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, heikeErrors.ErrRateLimited) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{"insufficient_quota", "rate limit", "rate_limit", "resource_exhausted", "too many requests"} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// providerFailure categorises a provider error. Quota errors keep their own
+// category so callers can back off instead of failing the goal.
+func providerFailure(err error, message string) error {
+	if IsQuotaError(err) {
+		return heikeErrors.WrapWithCategory(err, message, heikeErrors.ErrRateLimited)
+	}
+	return heikeErrors.WrapWithCategory(err, message, heikeErrors.ErrInternal)
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/auth"
+	"github.com/harunnryd/heike/internal/config"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+func TestIsQuotaError(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want bool
+	}{
+		"typed":           {heikeErrors.RateLimited("groq"), true},
+		"openai status":   {errors.New("error, status code: 429, status: 429 Too Many Requests"), true},
+		"insufficient":    {errors.New(`{"code":"insufficient_quota"}`), true},
+		"gemini":          {errors.New("Error 429, Status: RESOURCE_EXHAUSTED"), true},
+		"plain transient": {heikeErrors.Transient("timeout"), false},
+		"nil":             {nil, false},
+	}
+	for name, tc := range cases {
+		if got := IsQuotaError(tc.err); got != tc.want {
+			t.Errorf("%s: IsQuotaError = %v, want %v", name, got, tc.want)
+		}
+	}
+}
+
+func TestProviderFailure_KeepsRateLimitCategory(t *testing.T) {
+	err := providerFailure(fmt.Errorf("anthropic request failed: 429 Too Many Requests"), "provider request failed")
+	if !errors.Is(err, heikeErrors.ErrRateLimited) || !heikeErrors.IsRetryable(err) {
+		t.Fatalf("expected retryable rate-limit error, got %v", err)
+	}
+	err = providerFailure(errors.New("boom"), "provider request failed")
+	if !errors.Is(err, heikeErrors.ErrInternal) {
+		t.Fatalf("expected internal error, got %v", err)
+	}
+}
+
+type healthProvider struct {
+	countingProvider
+	err   error
+	block bool
+}
+
+func (p *healthProvider) Health(ctx context.Context) error {
+	if p.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return p.err
+}
+
+func TestRouter_HealthProbesAllProvidersWithTimeout(t *testing.T) {
+	router, err := NewModelRouter(config.ModelsConfig{HealthTimeout: "50ms"})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	router.providers["ok"] = &healthProvider{}
+	router.providers["bad-key"] = &healthProvider{err: errors.New("401 unauthorized")}
+	router.providers["hung"] = &healthProvider{block: true}
+
+	start := time.Now()
+	err = router.Health(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("health probes should run concurrently with a timeout, took %s", elapsed)
+	}
+	if !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "bad-key, hung") {
+		t.Fatalf("expected both unhealthy models listed, got %v", err)
+	}
+
+	delete(router.providers, "bad-key")
+	delete(router.providers, "hung")
+	if err := router.Health(context.Background()); err != nil {
+		t.Fatalf("expected healthy router, got %v", err)
+	}
+}
+
+func TestCreateProvider_LoadsAPIKeyFromAuthFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	r := &DefaultModelRouter{}
+
+	if _, err := r.createProvider(config.ModelRegistry{Name: "claude", Provider: "anthropic"}); !errors.Is(err, heikeErrors.ErrInvalidInput) {
+		t.Fatalf("expected invalid input without a key, got %v", err)
+	}
+
+	authFile := filepath.Join(t.TempDir(), "anthropic.json")
+	if _, err := auth.SaveProviderAPIKey("anthropic", "sk-ant-test", authFile); err != nil {
+		t.Fatalf("SaveProviderAPIKey: %v", err)
+	}
+	if _, err := r.createProvider(config.ModelRegistry{Name: "claude", Provider: "anthropic", AuthFile: authFile}); err != nil {
+		t.Fatalf("createProvider with auth file: %v", err)
+	}
+
+	// The default path under ~/.heike/auth is used when auth_file is unset.
+	if _, err := auth.SaveProviderAPIKey("gemini", "gm-test", ""); err != nil {
+		t.Fatalf("SaveProviderAPIKey: %v", err)
+	}
+	if _, err := r.createProvider(config.ModelRegistry{Name: "gemini-2.0-flash", Provider: "gemini"}); err != nil {
+		t.Fatalf("createProvider with default auth file: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// StreamHandler receives content deltas as a streamed completion arrives.
+type StreamHandler func(delta string)
+
+type streamHandlerKey struct{}
+
+// WithStreamHandler attaches a delta handler to ctx. Callers that support
+// streaming (such as the orchestrator LLM adapter) switch to RouteStream when
+// a handler is present.
+func WithStreamHandler(ctx context.Context, handler StreamHandler) context.Context {
+	if handler == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, streamHandlerKey{}, handler)
+}
+
+// StreamHandlerFromContext returns the delta handler attached to ctx, if any.
+func StreamHandlerFromContext(ctx context.Context) (StreamHandler, bool) {
+	handler, ok := ctx.Value(streamHandlerKey{}).(StreamHandler)
+	return handler, ok && handler != nil
+}
+
+// CollectStream drains a stream into a completion response, invoking onDelta
+// for each content delta.
+func CollectStream(ch <-chan contract.StreamChunk, onDelta StreamHandler) (*contract.CompletionResponse, error) {
+	out := &contract.CompletionResponse{}
+	for chunk := range ch {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		if chunk.Delta != "" {
+			out.Content += chunk.Delta
+			if onDelta != nil {
+				onDelta(chunk.Delta)
+			}
+		}
+		if len(chunk.ToolCalls) > 0 {
+			out.ToolCalls = append(out.ToolCalls, chunk.ToolCalls...)
+		}
+	}
+	return out, nil
+}
+
+// bufferedStream adapts a non-streaming response to the streaming contract by
+// emitting the whole content as one delta followed by a done chunk.
+func bufferedStream(resp *contract.CompletionResponse) <-chan contract.StreamChunk {
+	ch := make(chan contract.StreamChunk, 2)
+	if resp.Content != "" {
+		ch <- contract.StreamChunk{Delta: resp.Content}
+	}
+	ch <- contract.StreamChunk{ToolCalls: resp.ToolCalls, Done: true}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/metrics"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+// TagPrefix marks a model reference as a capability tag, e.g. "tag:fast".
+const TagPrefix = "tag:"
+
+// TagRef returns the tag named by a "tag:<name>" model reference.
+func TagRef(model string) (string, bool) {
+	if !strings.HasPrefix(model, TagPrefix) {
+		return "", false
+	}
+	tag := strings.TrimSpace(strings.TrimPrefix(model, TagPrefix))
+	return tag, tag != ""
+}
+
+// tagGroup orders the initialized models carrying tag for one request. The
+// first model is drawn with probability proportional to its weight, then the
+// next from the rest, and so on, so weights split traffic while every member
+// remains a fallback.
+func (r *DefaultModelRouter) tagGroup(tag string) []string {
+	r.mu.RLock()
+	type member struct {
+		name   string
+		weight int
+	}
+	var members []member
+	total := 0
+	for _, entry := range r.cfg.Registry {
+		if _, ok := r.providers[entry.Name]; !ok || !hasTag(entry.Tags, tag) {
+			continue
+		}
+		weight := entry.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		members = append(members, member{name: entry.Name, weight: weight})
+		total += weight
+	}
+	r.mu.RUnlock()
+
+	order := make([]string, 0, len(members))
+	for len(members) > 0 {
+		pick := r.randFloat() * float64(total)
+		i := 0
+		for ; i < len(members)-1; i++ {
+			pick -= float64(members[i].weight)
+			if pick < 0 {
+				break
+			}
+		}
+		order = append(order, members[i].name)
+		total -= members[i].weight
+		members = append(members[:i], members[i+1:]...)
+	}
+	return order
+}
+
+// tagCandidates is the tag group followed by the configured fallback model.
+func (r *DefaultModelRouter) tagCandidates(tag string) ([]string, error) {
+	group := r.tagGroup(tag)
+	if len(group) == 0 {
+		return nil, heikeErrors.NotFound(fmt.Sprintf("no models tagged %s", tag))
+	}
+	if fallback := r.cfg.Fallback; fallback != "" && !containsString(group, fallback) {
+		r.mu.RLock()
+		_, exists := r.providers[fallback]
+		r.mu.RUnlock()
+		if exists {
+			group = append(group, fallback)
+		}
+	}
+	return group, nil
+}
+
+// routeTag tries the tag group in order, skipping models whose circuit is
+// open, and returns the first successful completion.
+func (r *DefaultModelRouter) routeTag(ctx context.Context, tag string, req contract.CompletionRequest, traceID string) (*contract.CompletionResponse, error) {
+	candidates, err := r.tagCandidates(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := error(nil)
+	for _, name := range candidates {
+		if ctx.Err() != nil {
+			return nil, heikeErrors.Wrap(ctx.Err(), "request execution cancelled")
+		}
+		if !r.circuitAllows(name) {
+			slog.Warn("Circuit open, skipping model", "model", name, "tag", tag, "trace_id", traceID)
+			continue
+		}
+		r.mu.RLock()
+		provider := r.providers[name]
+		r.mu.RUnlock()
+
+		attempt := req
+		attempt.Model = name
+		resp, err := r.generateWithRetry(ctx, name, provider, attempt, traceID)
+		if err == nil {
+			r.circuitSuccess(name)
+			slog.Info("Request completed", "model", name, "tag", tag, "trace_id", traceID)
+			r.recordUsage(ctx, name, attempt, resp)
+			return resp, nil
+		}
+		r.circuitFailure(ctx, name, err)
+		slog.Error("Provider request failed, trying next tagged model", "model", name, "tag", tag, "error", err)
+		if IsQuotaError(err) {
+			metrics.Inc("provider_quota_events_total", "model", name)
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, heikeErrors.Transient(fmt.Sprintf("circuit open for every model tagged %s", tag))
+	}
+	return nil, providerFailure(lastErr, "provider request failed")
+}
+
+// routeTagStream is routeTag for streams; fallback applies only while
+// opening the stream.
+func (r *DefaultModelRouter) routeTagStream(ctx context.Context, tag string, req contract.CompletionRequest, traceID string) (<-chan contract.StreamChunk, error) {
+	candidates, err := r.tagCandidates(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := error(nil)
+	for _, name := range candidates {
+		if ctx.Err() != nil {
+			return nil, heikeErrors.Wrap(ctx.Err(), "request execution cancelled")
+		}
+		if !r.circuitAllows(name) {
+			slog.Warn("Circuit open, skipping model", "model", name, "tag", tag, "trace_id", traceID)
+			continue
+		}
+		r.mu.RLock()
+		provider := r.providers[name]
+		r.mu.RUnlock()
+
+		attempt := req
+		attempt.Model = name
+		stream, err := provider.GenerateStream(ctx, attempt)
+		if err == nil {
+			r.circuitSuccess(name)
+			return r.trackStream(ctx, name, attempt, stream), nil
+		}
+		r.circuitFailure(ctx, name, err)
+		slog.Error("Provider stream failed, trying next tagged model", "model", name, "tag", tag, "error", err)
+		if IsQuotaError(err) {
+			metrics.Inc("provider_quota_events_total", "model", name)
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return nil, heikeErrors.Transient(fmt.Sprintf("circuit open for every model tagged %s", tag))
+	}
+	return nil, providerFailure(lastErr, "provider stream failed")
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+type namedProvider struct {
+	countingProvider
+	fail   bool
+	models []string
+}
+
+func (p *namedProvider) Generate(ctx context.Context, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	p.calls++
+	p.models = append(p.models, req.Model)
+	if p.fail {
+		return nil, errors.New("upstream unavailable")
+	}
+	return &contract.CompletionResponse{Content: req.Model}, nil
+}
+
+func newTagRouter(t *testing.T, registry []config.ModelRegistry, providers map[string]Provider) *DefaultModelRouter {
+	t.Helper()
+	router, err := NewModelRouter(config.ModelsConfig{})
+	if err != nil {
+		t.Fatalf("NewModelRouter failed: %v", err)
+	}
+	router.cfg.Registry = registry
+	for name, provider := range providers {
+		router.providers[name] = provider
+	}
+	return router
+}
+
+func TestTagRef(t *testing.T) {
+	if tag, ok := TagRef("tag:fast"); !ok || tag != "fast" {
+		t.Fatalf("expected fast tag, got %q %v", tag, ok)
+	}
+	for _, model := range []string{"gpt-4o", "tag:", "tag: "} {
+		if _, ok := TagRef(model); ok {
+			t.Fatalf("expected %q not to be a tag reference", model)
+		}
+	}
+}
+
+func TestRouter_TagGroupOrderedByWeight(t *testing.T) {
+	router := newTagRouter(t, []config.ModelRegistry{
+		{Name: "small", Tags: []string{"fast"}, Weight: 1},
+		{Name: "big", Tags: []string{"reasoning"}},
+		{Name: "mid", Tags: []string{"Fast", "cheap"}, Weight: 3},
+	}, map[string]Provider{
+		"small": &namedProvider{},
+		"big":   &namedProvider{},
+		"mid":   &namedProvider{},
+	})
+
+	router.randFloat = func() float64 { return 0.5 }
+	if got := router.tagGroup("fast"); len(got) != 2 || got[0] != "mid" || got[1] != "small" {
+		t.Fatalf("expected heavier model first, got %v", got)
+	}
+	router.randFloat = func() float64 { return 0.1 }
+	if got := router.tagGroup("fast"); len(got) != 2 || got[0] != "small" || got[1] != "mid" {
+		t.Fatalf("expected lighter model first for low draw, got %v", got)
+	}
+}
+
+func TestRouter_RouteTagFallsBackAcrossGroup(t *testing.T) {
+	first := &namedProvider{fail: true}
+	second := &namedProvider{}
+	router := newTagRouter(t, []config.ModelRegistry{
+		{Name: "first", Tags: []string{"cheap"}, Weight: 10},
+		{Name: "second", Tags: []string{"cheap"}, Weight: 1},
+	}, map[string]Provider{"first": first, "second": second})
+	router.randFloat = func() float64 { return 0 }
+
+	resp, err := router.Route(context.Background(), "tag:cheap", contract.CompletionRequest{Model: "tag:cheap"})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if resp.Content != "second" {
+		t.Fatalf("expected second model to answer, got %q", resp.Content)
+	}
+	if len(first.models) != 1 || first.models[0] != "first" {
+		t.Fatalf("expected request model rewritten per candidate, got %v", first.models)
+	}
+}
+
+func TestRouter_RouteTagUnknown(t *testing.T) {
+	router := newTagRouter(t, []config.ModelRegistry{{Name: "m", Tags: []string{"fast"}}}, map[string]Provider{"m": &namedProvider{}})
+
+	_, err := router.Route(context.Background(), "tag:reasoning", contract.CompletionRequest{})
+	if !errors.Is(err, heikeErrors.ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/logger"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+const wireRedacted = "[REDACTED]"
+
+// wireSecretPatterns match API keys and bearer tokens that may appear in
+// prompts, tool output or provider errors.
+var wireSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`\bgsk_[A-Za-z0-9]{16,}`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{30,}`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/\-]{16,}=*`),
+	regexp.MustCompile(`(?i)\b(api[_-]?key|x-api-key|access[_-]?token)(["']?\s*[:=]\s*["']?)[^\s"'&,]{8,}`),
+}
+
+// WireEntry is one completion exchange in a session's wire log.
+type WireEntry struct {
+	Time       time.Time                    `json:"time"`
+	TraceID    string                       `json:"trace_id,omitempty"`
+	SessionID  string                       `json:"session_id"`
+	Model      string                       `json:"model"`
+	Stream     bool                         `json:"stream,omitempty"`
+	Request    contract.CompletionRequest   `json:"request"`
+	Response   *contract.CompletionResponse `json:"response,omitempty"`
+	Error      string                       `json:"error,omitempty"`
+	DurationMS int64                        `json:"duration_ms"`
+}
+
+// WireLog appends every completion request and response to a JSONL file per
+// session, so a turn can be replayed with `heike session trace`. Configured
+// API keys and key-like strings are redacted before writing. Completions
+// outside a session are not recorded.
+type WireLog struct {
+	dir     string
+	secrets []string
+	mu      sync.Mutex
+}
+
+// NewWireLog writes session logs under dir. secrets are literal values, such
+// as configured API keys, that are always redacted.
+func NewWireLog(dir string, secrets []string) (*WireLog, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create wire log dir: %w", err)
+	}
+	w := &WireLog{dir: dir}
+	for _, s := range secrets {
+		if s = strings.TrimSpace(s); s != "" {
+			w.secrets = append(w.secrets, s)
+		}
+	}
+	return w, nil
+}
+
+// WireLogPath returns the wire log file of a session under dir.
+func WireLogPath(dir, sessionID string) (string, error) {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) || sessionID == "." || sessionID == ".." {
+		return "", fmt.Errorf("invalid session id %q", sessionID)
+	}
+	return filepath.Join(dir, sessionID+".jsonl"), nil
+}
+
+// ReadWireLog returns a session's wire log entries in the order they were
+// recorded.
+func ReadWireLog(dir, sessionID string) ([]WireEntry, error) {
+	path, err := WireLogPath(dir, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []WireEntry
+	scanner := bufio.NewScanner(f)
+	// Prompts carry whole transcripts, so lines can be large.
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry WireEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			slog.Warn("Failed to parse wire log entry", "session_id", sessionID, "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// record appends one exchange. Failures are logged, never returned, so the
+// wire log cannot break a completion.
+func (w *WireLog) record(ctx context.Context, model string, stream bool, req contract.CompletionRequest, resp *contract.CompletionResponse, err error, start time.Time) {
+	if w == nil {
+		return
+	}
+	sessionID := logger.GetSessionID(ctx)
+	path, pathErr := WireLogPath(w.dir, sessionID)
+	if pathErr != nil {
+		return
+	}
+
+	entry := WireEntry{
+		Time:       start.UTC(),
+		TraceID:    logger.GetTraceID(ctx),
+		SessionID:  sessionID,
+		Model:      model,
+		Stream:     stream,
+		Request:    w.redactRequest(req),
+		Response:   w.redactResponse(resp),
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = w.redact(err.Error())
+	}
+	data, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		slog.Warn("Failed to marshal wire log entry", "session_id", sessionID, "error", marshalErr)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, openErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if openErr != nil {
+		slog.Warn("Failed to open wire log", "path", path, "error", openErr)
+		return
+	}
+	defer f.Close()
+	if _, writeErr := f.Write(append(data, '\n')); writeErr != nil {
+		slog.Warn("Failed to write wire log entry", "path", path, "error", writeErr)
+	}
+}
+
+func (w *WireLog) redact(s string) string {
+	for _, secret := range w.secrets {
+		s = strings.ReplaceAll(s, secret, wireRedacted)
+	}
+	for _, re := range wireSecretPatterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			// Keep the key name of "api_key=..." style matches readable.
+			if sub := re.FindStringSubmatch(match); len(sub) == 3 {
+				return sub[1] + sub[2] + wireRedacted
+			}
+			return wireRedacted
+		})
+	}
+	return s
+}
+
+func (w *WireLog) redactToolCalls(calls []*contract.ToolCall) []*contract.ToolCall {
+	if calls == nil {
+		return nil
+	}
+	out := make([]*contract.ToolCall, len(calls))
+	for i, tc := range calls {
+		if tc == nil {
+			continue
+		}
+		out[i] = &contract.ToolCall{ID: tc.ID, Name: tc.Name, Input: w.redact(tc.Input)}
+	}
+	return out
+}
+
+func (w *WireLog) redactRequest(req contract.CompletionRequest) contract.CompletionRequest {
+	messages := make([]contract.Message, len(req.Messages))
+	for i, m := range req.Messages {
+		m.Content = w.redact(m.Content)
+		m.ToolCalls = w.redactToolCalls(m.ToolCalls)
+		if len(m.Images) > 0 {
+			// Base64 image data would bloat the log; keep only references.
+			images := make([]contract.ImagePart, len(m.Images))
+			for j, img := range m.Images {
+				images[j] = contract.ImagePart{URL: img.URL, MIMEType: img.MIMEType}
+			}
+			m.Images = images
+		}
+		messages[i] = m
+	}
+	req.Messages = messages
+	return req
+}
+
+func (w *WireLog) redactResponse(resp *contract.CompletionResponse) *contract.CompletionResponse {
+	if resp == nil {
+		return nil
+	}
+	return &contract.CompletionResponse{
+		Content:   w.redact(resp.Content),
+		ToolCalls: w.redactToolCalls(resp.ToolCalls),
+		Usage:     resp.Usage,
+	}
+}
+
+// wireStream forwards chunks unchanged and records the assembled response
+// once the stream ends.
+func (w *WireLog) wireStream(ctx context.Context, model string, req contract.CompletionRequest, start time.Time, in <-chan contract.StreamChunk) <-chan contract.StreamChunk {
+	if w == nil {
+		return in
+	}
+	out := make(chan contract.StreamChunk, cap(in))
+	go func() {
+		defer close(out)
+		var content strings.Builder
+		var toolCalls []*contract.ToolCall
+		var streamErr error
+		recorded := false
+		finish := func() {
+			if recorded {
+				return
+			}
+			recorded = true
+			w.record(ctx, model, true, req, &contract.CompletionResponse{Content: content.String(), ToolCalls: toolCalls}, streamErr, start)
+		}
+		defer finish()
+		for chunk := range in {
+			content.WriteString(chunk.Delta)
+			toolCalls = append(toolCalls, chunk.ToolCalls...)
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			if chunk.Done || chunk.Err != nil {
+				finish()
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
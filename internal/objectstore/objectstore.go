@@ -0,0 +1,136 @@
+// Package objectstore is a minimal client for S3 and GCS buckets, used to
+// keep backups and archives off the local disk.
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	SchemeS3  = "s3"
+	SchemeGCS = "gs"
+
+	defaultS3Region   = "us-east-1"
+	defaultGCSRegion  = "auto"
+	defaultGCSBaseURL = "https://storage.googleapis.com"
+)
+
+// Object describes a stored object.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Bucket stores, lists, and deletes objects by key.
+type Bucket interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Location is a parsed s3:// or gs:// URI.
+type Location struct {
+	Scheme string
+	Bucket string
+	Prefix string
+}
+
+// ParseURI parses s3://bucket/prefix or gs://bucket/prefix.
+func ParseURI(uri string) (Location, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return Location{}, errors.InvalidInput(fmt.Sprintf("invalid object storage uri %q: %v", uri, err))
+	}
+	if u.Scheme != SchemeS3 && u.Scheme != SchemeGCS {
+		return Location{}, errors.InvalidInput(fmt.Sprintf("unsupported object storage scheme %q (want s3:// or gs://)", u.Scheme))
+	}
+	if u.Host == "" {
+		return Location{}, errors.InvalidInput(fmt.Sprintf("object storage uri %q is missing a bucket", uri))
+	}
+	return Location{
+		Scheme: u.Scheme,
+		Bucket: u.Host,
+		Prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// Key joins the location prefix with the given path segments.
+func (l Location) Key(parts ...string) string {
+	segments := make([]string, 0, len(parts)+1)
+	if l.Prefix != "" {
+		segments = append(segments, l.Prefix)
+	}
+	for _, part := range parts {
+		if part = strings.Trim(part, "/"); part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (l Location) String() string {
+	if l.Prefix == "" {
+		return l.Scheme + "://" + l.Bucket
+	}
+	return l.Scheme + "://" + l.Bucket + "/" + l.Prefix
+}
+
+// OpenFromEnv opens the bucket for loc with credentials from the environment.
+// timeout bounds each request; zero means no limit.
+//
+//   - s3: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, optional AWS_SESSION_TOKEN,
+//     AWS_REGION (default us-east-1), and AWS_ENDPOINT_URL_S3 for S3-compatible
+//     services such as MinIO.
+//   - gs: GCS_ACCESS_KEY_ID and GCS_SECRET_ACCESS_KEY (HMAC interoperability
+//     keys), optionally GCS_ENDPOINT_URL.
+func OpenFromEnv(loc Location, timeout time.Duration) (Bucket, error) {
+	switch loc.Scheme {
+	case SchemeS3:
+		region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+		if region == "" {
+			region = defaultS3Region
+		}
+		return NewS3Bucket(S3Config{
+			Bucket:       loc.Bucket,
+			Region:       region,
+			Endpoint:     firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			Timeout:      timeout,
+		})
+	case SchemeGCS:
+		endpoint := os.Getenv("GCS_ENDPOINT_URL")
+		if endpoint == "" {
+			endpoint = defaultGCSBaseURL
+		}
+		return NewS3Bucket(S3Config{
+			Bucket:    loc.Bucket,
+			Region:    defaultGCSRegion,
+			Endpoint:  endpoint,
+			AccessKey: os.Getenv("GCS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("GCS_SECRET_ACCESS_KEY"),
+			Timeout:   timeout,
+		})
+	default:
+		return nil, errors.InvalidInput("unsupported object storage scheme: " + loc.Scheme)
+	}
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
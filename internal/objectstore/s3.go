@@ -0,0 +1,287 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+)
+
+const (
+	emptyPayloadHash  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	maxErrorBodyBytes = 512
+)
+
+// S3Config configures an S3-compatible bucket. GCS buckets are reached through
+// the same XML API with HMAC keys.
+type S3Config struct {
+	Bucket       string
+	Region       string
+	Endpoint     string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Timeout      time.Duration
+}
+
+// S3Bucket talks to an S3-compatible API using AWS Signature Version 4.
+type S3Bucket struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Bucket(cfg S3Config) (*S3Bucket, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.InvalidInput("bucket is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.InvalidInput(fmt.Sprintf("object storage credentials for bucket %s are not set", cfg.Bucket))
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3Bucket{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		now:    time.Now,
+	}, nil
+}
+
+func (b *S3Bucket) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	hash := sha256.New()
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash object body")
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind object body")
+	}
+
+	req, err := b.newRequest(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	_, err = b.do(req, hex.EncodeToString(hash.Sum(nil)))
+	return err
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (b *S3Bucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := b.newRequest(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := b.do(req, emptyPayloadHash)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, errors.Wrap(err, "failed to decode object listing")
+		}
+		for _, obj := range result.Contents {
+			objects = append(objects, Object{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (b *S3Bucket) Delete(ctx context.Context, key string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	_, err = b.do(req, emptyPayloadHash)
+	return err
+}
+
+// newRequest addresses the bucket virtual-host style on AWS and path style on
+// custom endpoints (GCS, MinIO).
+func (b *S3Bucket) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	var u *url.URL
+	if b.cfg.Endpoint == "" {
+		u = &url.URL{Scheme: "https", Host: b.cfg.Bucket + ".s3." + b.cfg.Region + ".amazonaws.com", Path: "/" + key}
+	} else {
+		base, err := url.Parse(b.cfg.Endpoint)
+		if err != nil {
+			return nil, errors.InvalidInput(fmt.Sprintf("invalid object storage endpoint %q", b.cfg.Endpoint))
+		}
+		u = &url.URL{Scheme: base.Scheme, Host: base.Host, Path: strings.TrimRight(base.Path, "/") + "/" + b.cfg.Bucket}
+		if key != "" {
+			u.Path += "/" + key
+		}
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build object storage request")
+	}
+	return req, nil
+}
+
+func (b *S3Bucket) do(req *http.Request, payloadHash string) ([]byte, error) {
+	signV4(req, payloadHash, b.cfg.AccessKey, b.cfg.SecretKey, b.cfg.SessionToken, b.cfg.Region, b.now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, errors.WrapWithCategory(err, "object storage request failed", errors.ErrTransient)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WrapWithCategory(err, "failed to read object storage response", errors.ErrTransient)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxErrorBodyBytes {
+			msg = msg[:maxErrorBodyBytes]
+		}
+		detail := fmt.Sprintf("%s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, msg)
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return nil, errors.PermissionDenied(detail)
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, errors.NotFound(detail)
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return nil, errors.Transient(detail)
+		default:
+			return nil, errors.InvalidInput(detail)
+		}
+	}
+	return body, nil
+}
+
+// signV4 adds AWS Signature Version 4 headers for the S3 service. Host, Range,
+// Content-Type, Content-MD5, and all x-amz-* headers are signed.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, sessionToken, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("x-amz-security-token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" || lower == "content-type" || lower == "content-md5" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by key, as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except RFC 3986 unreserved characters
+// and, unless encodeSlash is set, '/'.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			sb.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCancelled is returned for a turn cancelled through CancelSession.
+var ErrCancelled = errors.New("task cancelled")
+
+// runRegistry tracks the cancel functions of the turns running per session.
+// The zero value is ready to use.
+type runRegistry struct {
+	mu   sync.Mutex
+	next uint64
+	runs map[string]map[uint64]context.CancelCauseFunc
+}
+
+// start derives a cancellable context for a turn in sessionID. The returned
+// func must be called when the turn ends.
+func (r *runRegistry) start(ctx context.Context, sessionID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	r.mu.Lock()
+	if r.runs == nil {
+		r.runs = make(map[string]map[uint64]context.CancelCauseFunc)
+	}
+	r.next++
+	id := r.next
+	if r.runs[sessionID] == nil {
+		r.runs[sessionID] = make(map[uint64]context.CancelCauseFunc)
+	}
+	r.runs[sessionID][id] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.runs[sessionID], id)
+		if len(r.runs[sessionID]) == 0 {
+			delete(r.runs, sessionID)
+		}
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancel cancels every turn running in sessionID and returns how many there
+// were.
+func (r *runRegistry) cancel(sessionID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	runs := r.runs[sessionID]
+	for _, cancel := range runs {
+		cancel(ErrCancelled)
+	}
+	return len(runs)
+}
+
+// CancelSession cancels the turns running in sessionID. The cancellation
+// reaches the cognitive loop and the sub-task coordinator through their
+// contexts; in-flight sub-tasks are recorded as cancelled. It returns the
+// number of turns cancelled.
+func (k *DefaultKernel) CancelSession(sessionID string) int {
+	return k.runs.cancel(sessionID)
+}
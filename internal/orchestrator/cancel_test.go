@@ -0,0 +1,29 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunRegistry_CancelSession(t *testing.T) {
+	var runs runRegistry
+	ctxA, finishA := runs.start(context.Background(), "s1")
+	ctxB, finishB := runs.start(context.Background(), "s2")
+	defer finishB()
+
+	if n := runs.cancel("s1"); n != 1 {
+		t.Fatalf("cancel s1 = %d, want 1", n)
+	}
+	if !errors.Is(context.Cause(ctxA), ErrCancelled) {
+		t.Fatalf("cause = %v, want ErrCancelled", context.Cause(ctxA))
+	}
+	if ctxB.Err() != nil {
+		t.Fatal("cancelling s1 cancelled s2")
+	}
+
+	finishA()
+	if n := runs.cancel("s1"); n != 0 {
+		t.Fatalf("cancel after finish = %d, want 0", n)
+	}
+}
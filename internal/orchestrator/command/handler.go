@@ -2,16 +2,20 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/orchestrator/task"
 	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/store"
 
 	"github.com/google/shlex"
+	"github.com/oklog/ulid/v2"
 )
 
 type Handler interface {
@@ -20,10 +24,16 @@ type Handler interface {
 }
 
 type DefaultCommandHandler struct {
-	policy  *policy.Engine
-	session session.Manager
-	store   *store.Worker
-	output  commandOutput
+	policy     *policy.Engine
+	session    session.Manager
+	store      *store.Worker
+	output     commandOutput
+	selections ToolSelectionSource
+}
+
+// ToolSelectionSource reports the tools offered in a session's last turn.
+type ToolSelectionSource interface {
+	LastToolSelection(sessionID string) (task.ToolSelection, bool)
 }
 
 type commandOutput interface {
@@ -33,6 +43,12 @@ type commandOutput interface {
 const commandOutputPrefix = "[CMD] "
 const defaultCommandSessionSource = "cli"
 
+// sessionHistoryMetadataKeys describe what happened to a session's earlier
+// conversation rather than how the session is configured, so a reset drops
+// them. Every other key (source, model, debug, egress targets, tool
+// allowlist, skill exclusions, notify URL) carries over.
+var sessionHistoryMetadataKeys = []string{session.MergedFromMetadataKey, session.MergedIntoMetadataKey}
+
 func NewHandler(p *policy.Engine, s session.Manager, st *store.Worker, output commandOutput) *DefaultCommandHandler {
 	return &DefaultCommandHandler{
 		policy:  p,
@@ -42,15 +58,32 @@ func NewHandler(p *policy.Engine, s session.Manager, st *store.Worker, output co
 	}
 }
 
+// SetToolSelections enables /why-tools.
+func (h *DefaultCommandHandler) SetToolSelections(source ToolSelectionSource) {
+	h.selections = source
+}
+
 func (h *DefaultCommandHandler) CanHandle(input string) bool {
 	return strings.HasPrefix(input, "/")
 }
 
-func (h *DefaultCommandHandler) Execute(ctx context.Context, sessionID string, input string) error {
-	parts, parseErr := shlex.Split(input)
-	if parseErr != nil {
+// ResolvesApproval reports whether input is an /approve or /deny command,
+// so callers can require the right to resolve approvals before submitting it.
+func ResolvesApproval(input string) bool {
+	parts := splitCommand(input)
+	return len(parts) > 0 && (parts[0] == "/approve" || parts[0] == "/deny")
+}
+
+func splitCommand(input string) []string {
+	parts, err := shlex.Split(input)
+	if err != nil {
 		parts = strings.Fields(input)
 	}
+	return parts
+}
+
+func (h *DefaultCommandHandler) Execute(ctx context.Context, sessionID string, input string) error {
+	parts := splitCommand(input)
 	if len(parts) == 0 {
 		return nil
 	}
@@ -67,10 +100,20 @@ func (h *DefaultCommandHandler) Execute(ctx context.Context, sessionID string, i
 		msg, err = h.handleApprove(args)
 	case "/deny":
 		msg, err = h.handleDeny(args)
-	case "/clear":
+	case "/clear", "/reset":
 		msg, err = h.handleClear(sessionID)
+	case "/new":
+		msg, err = h.handleNew(sessionID)
+	case "/history":
+		msg, err = h.handleHistory(sessionID, args)
+	case "/session":
+		msg, err = h.handleSession(sessionID)
 	case "/model":
 		msg, err = h.handleModel(sessionID, args)
+	case "/debug":
+		msg, err = h.handleDebug(sessionID, args)
+	case "/why-tools":
+		msg, err = h.handleWhyTools(sessionID)
 	case "/help":
 		msg = h.helpText()
 	default:
@@ -133,27 +176,170 @@ func (h *DefaultCommandHandler) handleClear(sessionID string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if err := h.resetSession(sessionID, existing); err != nil {
+		return "", err
+	}
+	return "Session cleared.", nil
+}
+
+// handleNew starts a fresh conversation. Adapters address a chat by its
+// session ID, so the ID is kept: the transcript so far is copied to an
+// archived session and the current one is reset.
+func (h *DefaultCommandHandler) handleNew(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("session id is required")
+	}
+	if h.store == nil {
+		return "", fmt.Errorf("store not initialized")
+	}
+	existing, err := h.store.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	lines, err := h.store.ReadTranscript(sessionID, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "Already in a new session.", nil
+	}
+
+	archiveID := sessionID + ":" + ulid.Make().String()
+	for _, line := range lines {
+		if err := h.store.WriteTranscript(archiveID, []byte(line)); err != nil {
+			return "", fmt.Errorf("archive transcript: %w", err)
+		}
+	}
+	archived := &store.SessionMeta{
+		ID:        archiveID,
+		Title:     "Session " + sessionID,
+		Status:    "archived",
+		CreatedAt: time.Now(),
+		Metadata:  map[string]string{"source": sessionSourceOrDefault(existing)},
+	}
+	if existing != nil {
+		archived.Title = existing.Title
+		archived.CreatedAt = existing.CreatedAt
+	}
+	archived.UpdatedAt = time.Now()
+	if err := h.store.SaveSession(archived); err != nil {
+		return "", err
+	}
+
+	if err := h.resetSession(sessionID, existing); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Started a new session. The previous conversation was archived as %s.", archiveID), nil
+}
+
+// resetSession clears the transcript of sessionID and recreates it as an
+// active session, keeping its title and configuration metadata.
+func (h *DefaultCommandHandler) resetSession(sessionID string, existing *store.SessionMeta) error {
 	source := sessionSourceOrDefault(existing)
 	title := "Session " + sessionID
 	if existing != nil && strings.TrimSpace(existing.Title) != "" {
 		title = existing.Title
 	}
+	// Configuration carries over; in particular a tool restriction only
+	// narrows, so resetting must not lift it.
+	metadata := map[string]string{}
+	if existing != nil {
+		for k, v := range existing.Metadata {
+			metadata[k] = v
+		}
+	}
+	for _, k := range sessionHistoryMetadataKeys {
+		delete(metadata, k)
+	}
+	metadata["source"] = source
 
 	if err := h.store.ResetSession(sessionID); err != nil {
-		return "", err
+		return err
 	}
-	if err := h.store.SaveSession(&store.SessionMeta{
+	return h.store.SaveSession(&store.SessionMeta{
 		ID:        sessionID,
 		Title:     title,
 		Status:    "active",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Metadata:  map[string]string{"source": source},
-	}); err != nil {
+		Metadata:  metadata,
+	})
+}
+
+// defaultHistoryMessages and maxHistoryMessages bound /history [n].
+const (
+	defaultHistoryMessages = 10
+	maxHistoryMessages     = 50
+	// maxHistoryMessageChars caps each message echoed by /history.
+	maxHistoryMessageChars = 200
+)
+
+func (h *DefaultCommandHandler) handleHistory(sessionID string, args []string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("session id is required")
+	}
+	if h.store == nil {
+		return "", fmt.Errorf("store not initialized")
+	}
+	n := defaultHistoryMessages
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v <= 0 {
+			return "Usage: /history [n]", nil
+		}
+		n = min(v, maxHistoryMessages)
+	}
+
+	lines, err := h.store.ReadTranscript(sessionID, 0)
+	if err != nil {
 		return "", err
 	}
+	// Command output is persisted as system messages, so only the
+	// conversation itself is shown.
+	var messages []session.Event
+	for _, line := range lines {
+		var evt session.Event
+		if json.Unmarshal([]byte(line), &evt) != nil {
+			continue
+		}
+		if evt.Type == session.EventTypeUser || evt.Type == session.EventTypeAssistant {
+			messages = append(messages, evt)
+		}
+	}
+	if len(messages) == 0 {
+		return "No messages in this session yet.", nil
+	}
+	if len(messages) > n {
+		messages = messages[len(messages)-n:]
+	}
 
-	return "Session cleared.", nil
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Last %d messages:", len(messages))
+	for _, evt := range messages {
+		content := []rune(strings.TrimSpace(evt.Content))
+		if len(content) > maxHistoryMessageChars {
+			content = append(content[:maxHistoryMessageChars], []rune("...")...)
+		}
+		fmt.Fprintf(&sb, "\n[%s] %s: %s", evt.Timestamp.Format("2006-01-02 15:04"), evt.Role, string(content))
+	}
+	return sb.String(), nil
+}
+
+func (h *DefaultCommandHandler) handleSession(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("session id is required")
+	}
+	if h.store == nil {
+		return "", fmt.Errorf("store not initialized")
+	}
+	sess, err := h.store.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if sess == nil {
+		return fmt.Sprintf("Session ID: %s", sessionID), nil
+	}
+	return fmt.Sprintf("Session ID: %s (%s, source %s)", sessionID, sess.Title, sessionSourceOrDefault(sess)), nil
 }
 
 func (h *DefaultCommandHandler) handleModel(sessionID string, args []string) (string, error) {
@@ -196,8 +382,90 @@ func (h *DefaultCommandHandler) handleModel(sessionID string, args []string) (st
 	return fmt.Sprintf("Model set to %s", modelName), nil
 }
 
+func (h *DefaultCommandHandler) handleDebug(sessionID string, args []string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("session id is required")
+	}
+	if h.store == nil {
+		return "", fmt.Errorf("store not initialized")
+	}
+
+	sess, err := h.store.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if sess == nil {
+		sess = &store.SessionMeta{
+			ID:        sessionID,
+			Title:     "Session " + sessionID,
+			Status:    "active",
+			CreatedAt: time.Now(),
+			Metadata:  map[string]string{"source": defaultCommandSessionSource},
+		}
+	}
+	if sess.Metadata == nil {
+		sess.Metadata = make(map[string]string)
+	}
+
+	enabled := sess.Metadata[session.DebugMetadataKey] != "true"
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on":
+			enabled = true
+		case "off":
+			enabled = false
+		default:
+			return "Usage: /debug [on|off]", nil
+		}
+	}
+
+	if enabled {
+		sess.Metadata[session.DebugMetadataKey] = "true"
+	} else {
+		delete(sess.Metadata, session.DebugMetadataKey)
+	}
+	sess.UpdatedAt = time.Now()
+	if err := h.store.SaveSession(sess); err != nil {
+		return "", err
+	}
+	if enabled {
+		return "Debug mode on. Planner, tool selection, and reflector steps will be streamed.", nil
+	}
+	return "Debug mode off.", nil
+}
+
+// maxWhyToolsGoalChars caps the goal echoed by /why-tools.
+const maxWhyToolsGoalChars = 80
+
+func (h *DefaultCommandHandler) handleWhyTools(sessionID string) (string, error) {
+	if sessionID == "" {
+		return "", fmt.Errorf("session id is required")
+	}
+	if h.selections == nil {
+		return "", fmt.Errorf("tool selection not available")
+	}
+	sel, ok := h.selections.LastToolSelection(sessionID)
+	if !ok {
+		return "No tool selection recorded for this session yet.", nil
+	}
+
+	goal := []rune(sel.Goal)
+	if len(goal) > maxWhyToolsGoalChars {
+		goal = append(goal[:maxWhyToolsGoalChars], []rune("...")...)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Last turn offered %d of %d tools (%s broker) for %q:", len(sel.Details), sel.Available, sel.Broker, string(goal))
+	for _, d := range sel.Details {
+		fmt.Fprintf(&sb, "\n- %s: score %d", d.Name, d.Score)
+		if len(d.Reasons) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(d.Reasons, ", "))
+		}
+	}
+	return sb.String(), nil
+}
+
 func (h *DefaultCommandHandler) helpText() string {
-	return "Available commands: /help, /model <name>, /clear, /approve <id>, /deny <id>"
+	return "Available commands: /help, /new, /reset, /history [n], /session, /model <name>, /clear, /debug [on|off], /why-tools, /approve <id>, /deny <id>"
 }
 
 func formatCommandOutput(msg string) string {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/orchestrator/task"
 	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/store"
 )
@@ -44,6 +46,14 @@ func (s *stubSessionManager) PersistTool(ctx context.Context, sessionID, toolCal
 	return nil
 }
 
+func (s *stubSessionManager) AppendDebug(ctx context.Context, sessionID, stage, content string) error {
+	return nil
+}
+
+func (s *stubSessionManager) AppendEvent(ctx context.Context, sessionID string, evt session.Event) error {
+	return nil
+}
+
 func setupWorker(t *testing.T) *store.Worker {
 	t.Helper()
 	tmpDir := t.TempDir()
@@ -146,6 +156,177 @@ func TestHandler_ClearCommand(t *testing.T) {
 	}
 }
 
+func TestHandler_ResetKeepsSessionConfiguration(t *testing.T) {
+	for _, cmd := range []string{"/clear", "/reset", "/new"} {
+		t.Run(cmd, func(t *testing.T) {
+			worker := setupWorker(t)
+			defer worker.Stop()
+
+			handler := NewHandler(nil, &stubSessionManager{}, worker, &stubCommandOutput{})
+
+			sessionID := "session-restricted"
+			if err := worker.SaveSession(&store.SessionMeta{ID: sessionID, Title: "restricted", Status: "active", Metadata: map[string]string{
+				"source":                      "api",
+				"model":                       "gpt-4o-mini",
+				policy.ToolsAllowMetadataKey:  "search_query,open",
+				"skills_exclude":              "legacy",
+				"notify_url":                  "https://hooks.example.com/heike",
+				"egress_targets":              `[{"source":"slack","id":"C1"}]`,
+				session.MergedFromMetadataKey: "older",
+			}}); err != nil {
+				t.Fatalf("seed session: %v", err)
+			}
+			if err := worker.WriteTranscript(sessionID, []byte(`{"id":"1","type":"user","role":"user","content":"hi"}`)); err != nil {
+				t.Fatalf("seed transcript: %v", err)
+			}
+
+			if err := handler.Execute(context.Background(), sessionID, cmd); err != nil {
+				t.Fatalf("execute %s: %v", cmd, err)
+			}
+
+			meta, err := worker.GetSession(sessionID)
+			if err != nil || meta == nil {
+				t.Fatalf("get session = %+v, %v", meta, err)
+			}
+			for key, want := range map[string]string{
+				"source":                     "api",
+				"model":                      "gpt-4o-mini",
+				policy.ToolsAllowMetadataKey: "search_query,open",
+				"skills_exclude":             "legacy",
+				"notify_url":                 "https://hooks.example.com/heike",
+				"egress_targets":             `[{"source":"slack","id":"C1"}]`,
+			} {
+				if got := meta.Metadata[key]; got != want {
+					t.Errorf("%s after %s = %q, want %q", key, cmd, got, want)
+				}
+			}
+			if _, ok := meta.Metadata[session.MergedFromMetadataKey]; ok {
+				t.Errorf("%s should drop %s with the old transcript", cmd, session.MergedFromMetadataKey)
+			}
+		})
+	}
+}
+
+func TestHandler_NewCommandArchivesTranscript(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	session := &stubSessionManager{}
+	handler := NewHandler(nil, session, worker, &stubCommandOutput{})
+
+	sessionID := "12345"
+	if err := worker.SaveSession(&store.SessionMeta{ID: sessionID, Title: "chat", Status: "active", Metadata: map[string]string{"source": "telegram"}}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	if err := worker.WriteTranscript(sessionID, []byte(`{"id":"1","type":"user","role":"user","content":"hi"}`)); err != nil {
+		t.Fatalf("seed transcript: %v", err)
+	}
+
+	if err := handler.Execute(context.Background(), sessionID, "/new"); err != nil {
+		t.Fatalf("execute new: %v", err)
+	}
+	if !strings.Contains(session.lastContent, "archived as "+sessionID+":") {
+		t.Fatalf("unexpected output: %q", session.lastContent)
+	}
+	archiveID := strings.TrimSuffix(session.lastContent[strings.Index(session.lastContent, sessionID+":"):], ".")
+
+	archived, err := worker.GetSession(archiveID)
+	if err != nil || archived == nil || archived.Status != "archived" || archived.Metadata["source"] != "telegram" {
+		t.Fatalf("archived session = %+v, %v", archived, err)
+	}
+	lines, err := worker.ReadTranscript(archiveID, 0)
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("archived transcript = %v, %v", lines, err)
+	}
+	if lines, _ := worker.ReadTranscript(sessionID, 0); len(lines) != 0 {
+		t.Fatalf("current transcript should be empty, got %v", lines)
+	}
+	current, err := worker.GetSession(sessionID)
+	if err != nil || current == nil || current.Status != "active" || current.Metadata["source"] != "telegram" {
+		t.Fatalf("current session = %+v, %v", current, err)
+	}
+}
+
+func TestHandler_HistoryAndSessionCommands(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	session := &stubSessionManager{}
+	handler := NewHandler(nil, session, worker, &stubCommandOutput{})
+
+	sessionID := "session-history"
+	if err := worker.SaveSession(&store.SessionMeta{ID: sessionID, Title: "history", Status: "active"}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	for _, line := range []string{
+		`{"id":"1","type":"user","role":"user","content":"first"}`,
+		`{"id":"2","type":"assistant","role":"assistant","content":"second"}`,
+		`{"id":"3","type":"system","role":"system","content":"[CMD] noise"}`,
+		`{"id":"4","type":"user","role":"user","content":"third"}`,
+	} {
+		if err := worker.WriteTranscript(sessionID, []byte(line)); err != nil {
+			t.Fatalf("seed transcript: %v", err)
+		}
+	}
+
+	if err := handler.Execute(context.Background(), sessionID, "/history 2"); err != nil {
+		t.Fatalf("execute history: %v", err)
+	}
+	if !strings.HasPrefix(session.lastContent, "Last 2 messages:") || strings.Contains(session.lastContent, "first") ||
+		strings.Contains(session.lastContent, "noise") || !strings.Contains(session.lastContent, "assistant: second") {
+		t.Fatalf("unexpected history: %q", session.lastContent)
+	}
+
+	if err := handler.Execute(context.Background(), sessionID, "/history zero"); err != nil {
+		t.Fatalf("execute history: %v", err)
+	}
+	if session.lastContent != "Usage: /history [n]" {
+		t.Fatalf("unexpected usage: %q", session.lastContent)
+	}
+
+	if err := handler.Execute(context.Background(), sessionID, "/session"); err != nil {
+		t.Fatalf("execute session: %v", err)
+	}
+	if !strings.HasPrefix(session.lastContent, "Session ID: "+sessionID) {
+		t.Fatalf("unexpected session output: %q", session.lastContent)
+	}
+}
+
+func TestHandler_DebugCommandTogglesSessionFlag(t *testing.T) {
+	worker := setupWorker(t)
+	defer worker.Stop()
+
+	handler := NewHandler(nil, &stubSessionManager{}, worker, &stubCommandOutput{})
+	sessionID := "session-debug"
+	if err := worker.SaveSession(&store.SessionMeta{ID: sessionID, Title: "test", Status: "active", Metadata: map[string]string{"source": "slack"}}); err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{input: "/debug", want: "true"},
+		{input: "/debug", want: ""},
+		{input: "/debug on", want: "true"},
+		{input: "/debug off", want: ""},
+	} {
+		if err := handler.Execute(context.Background(), sessionID, tc.input); err != nil {
+			t.Fatalf("execute %s: %v", tc.input, err)
+		}
+		meta, err := worker.GetSession(sessionID)
+		if err != nil {
+			t.Fatalf("get session: %v", err)
+		}
+		if meta.Metadata["debug"] != tc.want {
+			t.Fatalf("after %s debug flag = %q, want %q", tc.input, meta.Metadata["debug"], tc.want)
+		}
+		if meta.Metadata["source"] != "slack" {
+			t.Fatalf("source metadata changed to %q", meta.Metadata["source"])
+		}
+	}
+}
+
 func TestHandler_ModelCommand_CreatesSessionWithCLISource(t *testing.T) {
 	worker := setupWorker(t)
 	defer worker.Stop()
@@ -230,6 +411,44 @@ func TestHandler_DenyCommand(t *testing.T) {
 	}
 }
 
+type stubToolSelections map[string]task.ToolSelection
+
+func (s stubToolSelections) LastToolSelection(sessionID string) (task.ToolSelection, bool) {
+	sel, ok := s[sessionID]
+	return sel, ok
+}
+
+func TestHandler_WhyToolsCommand(t *testing.T) {
+	session := &stubSessionManager{}
+	handler := NewHandler(nil, session, nil, &stubCommandOutput{})
+	handler.SetToolSelections(stubToolSelections{"s1": {
+		Goal:      "run the tests",
+		Broker:    task.ToolSelectionBrokerHeuristic,
+		Available: 12,
+		Details: []task.ToolSelectionDetail{
+			{Name: "exec_command", Score: 7, Reasons: []string{"name_match", "keyword:run"}},
+			{Name: "read_file", Score: 0},
+		},
+	}})
+
+	if err := handler.Execute(context.Background(), "s1", "/why-tools"); err != nil {
+		t.Fatalf("execute why-tools: %v", err)
+	}
+	want := "Last turn offered 2 of 12 tools (heuristic broker) for \"run the tests\":\n" +
+		"- exec_command: score 7 (name_match, keyword:run)\n" +
+		"- read_file: score 0"
+	if session.lastContent != want {
+		t.Fatalf("why-tools output = %q, want %q", session.lastContent, want)
+	}
+
+	if err := handler.Execute(context.Background(), "s2", "/why-tools"); err != nil {
+		t.Fatalf("execute why-tools: %v", err)
+	}
+	if !strings.HasPrefix(session.lastContent, "No tool selection recorded") {
+		t.Fatalf("unexpected output for new session: %q", session.lastContent)
+	}
+}
+
 func TestFormatCommandOutput_Idempotent(t *testing.T) {
 	raw := "Available commands: /help"
 	formatted := formatCommandOutput(raw)
@@ -2,10 +2,16 @@ package orchestrator
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/harunnryd/heike/internal/adapter"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/egress"
+	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
 	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/store"
@@ -41,6 +47,22 @@ func (m *mockE2EEgress) ListAdapters() []adapter.OutputAdapter {
 	return []adapter.OutputAdapter{}
 }
 
+func (m *mockE2EEgress) Targets(sessionID string) ([]egress.Target, error) {
+	return nil, nil
+}
+
+func (m *mockE2EEgress) SetTargets(sessionID string, targets []egress.Target) error {
+	return nil
+}
+
+func (m *mockE2EEgress) AddTarget(sessionID string, target egress.Target) error {
+	return nil
+}
+
+func (m *mockE2EEgress) RemoveTarget(sessionID string, adapterName string) error {
+	return nil
+}
+
 func createE2ETestPolicy() *policy.Engine {
 	pol, _ := policy.NewEngine(config.GovernanceConfig{}, "test-workspace-e2e", "")
 	return pol
@@ -559,3 +581,207 @@ func TestE2ECognitiveLoop_FullLifecycle(t *testing.T) {
 		t.Error("Orchestrator should not be running after Stop")
 	}
 }
+
+func TestE2ECognitiveLoop_MockProviderAnswersGoal(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.yaml")
+	if err := os.WriteFile(fixture, []byte(`
+responses:
+  - match: "strategic planning agent"
+    content: '[{"id":1,"description":"Answer the question"}]'
+    repeat: true
+  - content: "The answer is 42."
+`), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	cfg := config.Config{
+		Models: config.ModelsConfig{
+			Default:   "mock-model",
+			Embedding: "mock-model",
+			Registry:  []config.ModelRegistry{{Name: "mock-model", Provider: "mock", Fixture: fixture}},
+		},
+		Orchestrator: config.OrchestratorConfig{
+			MaxSubTasks: 5,
+		},
+	}
+
+	st, err := store.NewWorker("test-e2e-mock-"+t.Name(), "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	st.Start()
+	defer st.Stop()
+
+	registry := tool.NewRegistry()
+	toolRunner := tool.NewRunner(registry, createE2ETestPolicy())
+	mockEgress := &mockE2EEgress{}
+
+	orch, err := NewKernel(cfg, st, toolRunner, createE2ETestPolicy(), skill.NewRegistry(), mockEgress)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := orch.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize orchestrator: %v", err)
+	}
+	if err := orch.Start(ctx); err != nil {
+		t.Fatalf("Failed to start orchestrator: %v", err)
+	}
+	defer orch.Stop(ctx)
+
+	evt := &ingress.Event{ID: "evt-mock", SessionID: "sess-mock", Type: ingress.TypeUserMessage, Content: "What is the answer?"}
+	if err := orch.Execute(ctx, evt); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.Contains(mockEgress.sentContent, "The answer is 42.") {
+		t.Fatalf("egress content = %q", mockEgress.sentContent)
+	}
+
+	lines, err := st.ReadTranscript("sess-mock", 0)
+	if err != nil || len(lines) < 4 {
+		t.Fatalf("transcript = %v, %v", lines, err)
+	}
+	// The turn ends: user, status, streamed delta, assistant, done.
+	if n := len(lines); !strings.Contains(lines[n-4], `"type":"status"`) || !strings.Contains(lines[n-3], `"type":"delta"`) || !strings.Contains(lines[n-1], `"type":"done"`) {
+		t.Fatalf("expected status and delta before the answer and done last, got %v", lines[n-4:])
+	}
+}
+
+func TestExecuteGoal_ReturnsTurnResult(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.yaml")
+	if err := os.WriteFile(fixture, []byte(`
+responses:
+  - match: "strategic planning agent"
+    content: '[{"id":1,"description":"Look up the answer"}]'
+    repeat: true
+  - content: ""
+    tool_calls:
+      - id: call-1
+        name: lookup
+        input: {query: answer}
+  - content: "The answer is 42."
+fallback: "The answer is 42."
+`), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	cfg := config.Config{
+		Models: config.ModelsConfig{
+			Default:   "mock-model",
+			Embedding: "mock-model",
+			Registry:  []config.ModelRegistry{{Name: "mock-model", Provider: "mock", Fixture: fixture}},
+		},
+		Orchestrator: config.OrchestratorConfig{MaxSubTasks: 5},
+	}
+
+	st, err := store.NewWorker("test-e2e-goal-"+t.Name(), "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	st.Start()
+	defer st.Stop()
+
+	toolRunner := tool.NewRunner(tool.NewRegistry(), createE2ETestPolicy())
+	orch, err := NewKernel(cfg, st, toolRunner, createE2ETestPolicy(), skill.NewRegistry(), &mockE2EEgress{})
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	ctx := context.Background()
+	if err := orch.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize orchestrator: %v", err)
+	}
+
+	if _, err := orch.ExecuteGoal(ctx, "sess-goal", "/help"); err == nil {
+		t.Fatal("expected slash command to be rejected")
+	}
+
+	result, err := orch.ExecuteGoal(ctx, "sess-goal", "What is the answer?")
+	if err != nil {
+		t.Fatalf("ExecuteGoal failed: %v", err)
+	}
+	if result.Content != "The answer is 42." {
+		t.Fatalf("content = %q, messages = %+v, errors = %v", result.Content, result.Messages, result.Errors)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Name != "lookup" || result.ToolCalls[0].Error == "" {
+		t.Fatalf("tool calls = %+v", result.ToolCalls)
+	}
+	if result.Usage.Completions == 0 {
+		t.Fatalf("expected completions to be counted, got %+v", result.Usage)
+	}
+	if result.SessionID != "sess-goal" || result.ID == "" {
+		t.Fatalf("result identity = %q/%q", result.ID, result.SessionID)
+	}
+}
+
+func TestExecute_AppendsExecutionReport(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.yaml")
+	if err := os.WriteFile(fixture, []byte(`
+responses:
+  - match: "strategic planning agent"
+    content: '[{"id":1,"description":"Look up the answer"}]'
+    repeat: true
+  - content: ""
+    tool_calls:
+      - id: call-1
+        name: lookup
+        input: {query: answer}
+  - content: "The answer is 42."
+fallback: "The answer is 42."
+`), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	cfg := config.Config{
+		Models: config.ModelsConfig{
+			Default:   "mock-model",
+			Embedding: "mock-model",
+			Registry:  []config.ModelRegistry{{Name: "mock-model", Provider: "mock", Fixture: fixture}},
+		},
+		Orchestrator: config.OrchestratorConfig{MaxSubTasks: 5, ExecutionReport: true},
+	}
+
+	st, err := store.NewWorker("test-e2e-report-"+t.Name(), "", store.RuntimeConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	st.Start()
+	defer st.Stop()
+
+	toolRunner := tool.NewRunner(tool.NewRegistry(), createE2ETestPolicy())
+	orch, err := NewKernel(cfg, st, toolRunner, createE2ETestPolicy(), skill.NewRegistry(), &mockE2EEgress{})
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	ctx := context.Background()
+	if err := orch.Init(ctx); err != nil {
+		t.Fatalf("Failed to initialize orchestrator: %v", err)
+	}
+
+	evt := &ingress.Event{ID: "evt-report", SessionID: "sess-report", Type: ingress.TypeUserMessage, Content: "What is the answer?"}
+	if err := orch.Execute(ctx, evt); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	lines, err := st.ReadTranscript("sess-report", 0)
+	if err != nil || len(lines) < 2 {
+		t.Fatalf("transcript = %v, %v", lines, err)
+	}
+	if n := len(lines); !strings.Contains(lines[n-2], `"type":"execution_report"`) || !strings.Contains(lines[n-1], `"type":"done"`) {
+		t.Fatalf("expected the execution report right before done, got %v", lines[n-2:])
+	}
+	reports := session.ExecutionReports(lines)
+	if len(reports) == 0 {
+		t.Fatal("expected an execution report")
+	}
+	r := reports[len(reports)-1]
+	if r.EventID != "evt-report" || r.Goal != "What is the answer?" || r.Status != "completed" {
+		t.Fatalf("report identity = %+v", r)
+	}
+	if r.Turns == 0 || len(r.Tools) != 1 || r.Tools[0].Name != "lookup" || r.Tools[0].Error == "" {
+		t.Fatalf("report turns/tools = %d/%+v", r.Turns, r.Tools)
+	}
+	if len(r.Models) != 1 || r.Models[0].Model != "mock-model" || r.Models[0].Completions == 0 {
+		t.Fatalf("report models = %+v", r.Models)
+	}
+}
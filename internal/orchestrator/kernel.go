@@ -3,13 +3,18 @@ package orchestrator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/egress"
+	"github.com/harunnryd/heike/internal/featureflag"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/ingress"
 	"github.com/harunnryd/heike/internal/logger"
 	"github.com/harunnryd/heike/internal/model"
@@ -27,6 +32,8 @@ import (
 // Kernel orchestrates the high-level request flow
 type Kernel interface {
 	Execute(ctx context.Context, evt *ingress.Event) error
+	// ExecuteGoal runs a turn synchronously and returns its outcome.
+	ExecuteGoal(ctx context.Context, sessionID, goal string) (*TurnResult, error)
 	Init(ctx context.Context) error
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
@@ -51,6 +58,16 @@ type DefaultKernel struct {
 	task    task.Manager
 	command command.Handler
 	memory  cognitive.MemoryManager
+	router  *model.DefaultModelRouter
+	llm     *LLMExecutorAdapter
+
+	// Quota retry
+	delayed         DelayedSubmitter
+	quotaBackoff    time.Duration
+	quotaMaxRetries int
+
+	// runs tracks running turns so they can be cancelled per session.
+	runs runRegistry
 }
 
 func NewKernel(
@@ -62,15 +79,31 @@ func NewKernel(
 	egress egress.Egress,
 ) (*DefaultKernel, error) {
 	// Initialize Core Services
-	router, err := model.NewModelRouter(cfg.Models)
+	httpClients, err := httpclient.ForConfig(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	router, err := model.NewModelRouterWithHTTPClients(cfg.Models, httpClients)
 	if err != nil {
 		return nil, fmt.Errorf("model router init: %w", err)
 	}
+	costs := model.NewCostTracker(cfg.Models.Pricing, cfg.Governance.DailyCostLimitUSD)
+	if policy != nil {
+		costs.SetCounterStore(policy.CounterStore())
+	}
+	router.SetCostTracker(costs)
 
 	llmExecutor := NewLLMAdapter(router, cfg.Models.Default) // Adapter for Cognitive Engine
 
 	// Initialize Memory
 	memMgr := memory.NewManager(store, router, cfg.Models.Embedding)
+	if cfg.Knowledge.Enabled {
+		collection := cfg.Knowledge.Collection
+		if collection == "" {
+			collection = config.DefaultKnowledgeCollection
+		}
+		memMgr.AddKnowledgeCollection(collection)
+	}
 
 	// Initialize Cognitive Engine
 	planner := cognitive.NewPlanner(llmExecutor, cognitive.PlannerPromptConfig{
@@ -82,8 +115,11 @@ func NewKernel(
 		Instruction: cfg.Prompts.Thinker.Instruction,
 	})
 
+	sessMgr := session.NewManager(store, memMgr, cfg.Orchestrator.SessionHistoryLimit)
+
 	// Adapter for Actor (ToolRunner + Egress)
 	actorAdapter := NewActorAdapter(runner)
+	actorAdapter.events = sessMgr
 	actor := cognitive.NewActor(actorAdapter)
 
 	reflector := cognitive.NewReflector(llmExecutor, cognitive.ReflectorPromptConfig{
@@ -100,6 +136,22 @@ func NewKernel(
 		cfg.Orchestrator.MaxTurns,
 		cfg.Orchestrator.TokenBudget,
 	)
+	engine.SetToolImages(cfg.Orchestrator.ToolImages)
+	if cfg.Orchestrator.BestOfN.Enabled {
+		samples := cfg.Orchestrator.BestOfN.Samples
+		if samples <= 0 {
+			samples = config.DefaultOrchestratorBestOfNSamples
+		}
+		maxSampleTokens := cfg.Orchestrator.BestOfN.MaxSampleTokens
+		if maxSampleTokens <= 0 {
+			maxSampleTokens = config.DefaultOrchestratorBestOfNMaxTokens
+		}
+		bestOfN := cognitive.BestOfNConfig{Samples: samples, MaxSampleTokens: maxSampleTokens}
+		if judgeModel := strings.TrimSpace(cfg.Orchestrator.BestOfN.JudgeModel); judgeModel != "" {
+			bestOfN.Judge = NewLLMAdapter(router, judgeModel)
+		}
+		engine.SetBestOfN(bestOfN)
+	}
 
 	subTaskRetryBackoff, err := config.DurationOrDefault(
 		cfg.Orchestrator.SubTaskRetryBackoff,
@@ -110,7 +162,6 @@ func NewKernel(
 	}
 
 	// Initialize Managers
-	sessMgr := session.NewManager(store, memMgr, cfg.Orchestrator.SessionHistoryLimit)
 	cmdHandler := command.NewHandler(policy, sessMgr, store, egress)
 
 	decomposer := task.NewDecomposer(llmExecutor, cfg.Orchestrator.DecomposeWordThreshold, task.DecomposerPromptConfig{
@@ -131,14 +182,64 @@ func NewKernel(
 		cfg.Orchestrator.MaxParallelSubTasks,
 		egress,
 	)
+	taskMgr.SetVerbose(cfg.Orchestrator.Verbose)
+	taskMgr.SetTokenBudget(cfg.Orchestrator.TokenBudget)
+	taskMgr.SetSkillLimits(cfg.Orchestrator.Skills.MaxSelected, cfg.Orchestrator.Skills.MaxChars)
+	taskMgr.SetLLMToolBroker(task.NewLLMToolBroker(llmExecutor, cfg.Orchestrator.MaxToolsPerTurn))
+	if cfg.Orchestrator.Synthesis.Enabled {
+		var synthesizer cognitive.LLMClient = llmExecutor
+		if synthesisModel := strings.TrimSpace(cfg.Orchestrator.Synthesis.Model); synthesisModel != "" {
+			synthesizer = NewLLMAdapter(router, synthesisModel)
+		}
+		taskMgr.SetSynthesizer(synthesizer, cfg.Prompts.Synthesizer.System)
+	}
+	var postMortemMemory cognitive.MemoryManager
+	if cfg.Orchestrator.PostMortem.Remember {
+		postMortemMemory = memMgr
+	}
+	taskMgr.SetPostMortem(cfg.Orchestrator.PostMortem.Enabled, postMortemMemory)
+	cmdHandler.SetToolSelections(taskMgr)
 
-	return &DefaultKernel{
+	kernel := &DefaultKernel{
 		cfg:     cfg,
 		session: sessMgr,
 		task:    taskMgr,
 		command: cmdHandler,
 		memory:  memMgr,
-	}, nil
+		router:  router,
+		llm:     llmExecutor,
+	}
+	if cfg.Orchestrator.QuotaRetry.Enabled {
+		backoff, err := config.DurationOrDefault(cfg.Orchestrator.QuotaRetry.Backoff, config.DefaultOrchestratorQuotaRetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("parse orchestrator quota retry backoff: %w", err)
+		}
+		kernel.quotaBackoff = backoff
+		kernel.quotaMaxRetries = cfg.Orchestrator.QuotaRetry.MaxRetries
+		if kernel.quotaMaxRetries <= 0 {
+			kernel.quotaMaxRetries = config.DefaultOrchestratorQuotaRetryMax
+		}
+		taskMgr.SetQuotaRetry(kernel.scheduleQuotaRetry)
+	}
+	if policy != nil {
+		policy.OnApprovalRequested(kernel.recordApproval)
+	}
+	return kernel, nil
+}
+
+// recordApproval adds an approval_required event to the requesting session.
+func (k *DefaultKernel) recordApproval(app policy.Approval) {
+	if app.SessionID == "" {
+		return
+	}
+	k.appendEvent(context.Background(), app.SessionID, session.Event{
+		Type:    session.EventTypeApprovalRequired,
+		Content: app.Input,
+		Metadata: map[string]interface{}{
+			"approval_id": app.ID,
+			"tool":        app.Tool,
+		},
+	})
 }
 
 func (k *DefaultKernel) Init(ctx context.Context) error {
@@ -184,9 +285,86 @@ func (k *DefaultKernel) Health(ctx context.Context) (*ComponentHealth, error) {
 	return status, nil
 }
 
+// ModelCircuits returns the router's per-model circuit breaker states, or nil
+// when circuit breaking is disabled.
+func (k *DefaultKernel) ModelCircuits() map[string]model.CircuitState {
+	if k.router == nil {
+		return nil
+	}
+	return k.router.CircuitStates()
+}
+
+// SetWireLog records the router's completion traffic to w.
+func (k *DefaultKernel) SetWireLog(w *model.WireLog) {
+	if k.router != nil {
+		k.router.SetWireLog(w)
+	}
+}
+
+// SetFeatureFlags makes the task manager and model adapter consult flags on
+// each request. Call it before the kernel starts.
+func (k *DefaultKernel) SetFeatureFlags(flags *featureflag.Flags) {
+	if k.llm != nil {
+		k.llm.features = flags
+	}
+	if taskMgr, ok := k.task.(*task.DefaultTaskManager); ok {
+		taskMgr.SetFeatureFlags(flags)
+	}
+}
+
+// LastToolSelection returns the tools offered in the last turn of sessionID
+// with their broker scores and reasons.
+func (k *DefaultKernel) LastToolSelection(sessionID string) (task.ToolSelection, bool) {
+	taskMgr, ok := k.task.(*task.DefaultTaskManager)
+	if !ok {
+		return task.ToolSelection{}, false
+	}
+	return taskMgr.LastToolSelection(sessionID)
+}
+
+// InjectSessionContext stores context documents that memory recall surfaces
+// on later turns of sessionID.
+func (k *DefaultKernel) InjectSessionContext(ctx context.Context, sessionID string, docs []memory.ContextDocument) (int, error) {
+	mem, ok := k.memory.(*memory.VectorMemory)
+	if !ok {
+		return 0, fmt.Errorf("memory does not support session context")
+	}
+	return mem.InjectSessionContext(ctx, sessionID, docs)
+}
+
+// ReembedCollection re-embeds a vector collection with the current
+// embedding model.
+func (k *DefaultKernel) ReembedCollection(ctx context.Context, collection string, progress func(done, total int)) (int, error) {
+	mem, ok := k.memory.(*memory.VectorMemory)
+	if !ok {
+		return 0, fmt.Errorf("memory does not support re-embedding")
+	}
+	return mem.Reembed(ctx, collection, progress)
+}
+
+// BackfillMemory embeds past session transcripts into the memory collection.
+func (k *DefaultKernel) BackfillMemory(ctx context.Context, opts memory.BackfillOptions, progress func(done, total int)) (memory.BackfillResult, error) {
+	mem, ok := k.memory.(*memory.VectorMemory)
+	if !ok {
+		return memory.BackfillResult{}, fmt.Errorf("memory does not support backfill")
+	}
+	return mem.BackfillTranscripts(ctx, opts, progress)
+}
+
+// MoveSessionVectors re-points the vectors of a merged session at the
+// session it was merged into.
+func (k *DefaultKernel) MoveSessionVectors(sourceID, targetID string) (int, error) {
+	mem, ok := k.memory.(*memory.VectorMemory)
+	if !ok {
+		return 0, nil
+	}
+	return mem.MoveSessionVectors(sourceID, targetID)
+}
+
 func (k *DefaultKernel) Execute(ctx context.Context, evt *ingress.Event) error {
 	ctx = logger.WithTraceID(ctx, evt.ID)
 	ctx = logger.WithSessionID(ctx, evt.SessionID)
+	ctx = withEvent(ctx, evt)
 	slog.Info("Kernel executing event", "id", evt.ID, "type", evt.Type)
 
 	// Slash Commands
@@ -194,22 +372,181 @@ func (k *DefaultKernel) Execute(ctx context.Context, evt *ingress.Event) error {
 		return k.command.Execute(ctx, evt.SessionID, evt.Content)
 	}
 
-	// Task Execution
-	if evt.Type == ingress.TypeUserMessage {
-		// Persist user message first
-		if err := k.session.AppendInteraction(ctx, evt.SessionID, "user", evt.Content); err != nil {
+	// Task Execution (background goals run their content the same way)
+	if evt.Type == ingress.TypeUserMessage || (isBackgroundGoal(evt.Type) && strings.TrimSpace(evt.Content) != "") {
+		// Quota retries replay a message already in the transcript
+		return k.runGoal(ctx, evt.ID, evt.SessionID, evt.Content, quotaRetryAttempt(evt) == 0)
+	}
+
+	return nil
+}
+
+// isBackgroundGoal reports whether events of type t carry a goal to run:
+// scheduled jobs, batch goals, mail and webhook payloads.
+func isBackgroundGoal(t ingress.EventType) bool {
+	switch t {
+	case ingress.TypeCron, ingress.TypeBatch, ingress.TypeEmail, ingress.TypeWebhook:
+		return true
+	}
+	return false
+}
+
+// runGoal runs one turn for goal, framed by status and done events.
+func (k *DefaultKernel) runGoal(ctx context.Context, eventID, sessionID, goal string, persistUser bool) error {
+	ctx, finish := k.runs.start(ctx, sessionID)
+	defer finish()
+
+	started := time.Now()
+	rec := turnRecorderFrom(ctx)
+	if k.cfg.Orchestrator.ExecutionReport && rec == nil {
+		rec = &turnRecorder{}
+		ctx = withTurnRecorder(ctx, rec)
+	}
+
+	// Persist user message first
+	if persistUser {
+		if err := k.session.AppendInteraction(ctx, sessionID, "user", goal); err != nil {
 			slog.Warn("Failed to persist user message", "error", err)
 		}
+	}
 
-		return k.task.HandleRequest(ctx, evt.SessionID, evt.Content)
+	k.appendEvent(ctx, sessionID, session.Event{
+		Type:     session.EventTypeStatus,
+		Metadata: map[string]interface{}{"state": "processing", "event_id": eventID},
+	})
+	deltas := &deltaRecorder{kernel: k, ctx: context.WithoutCancel(ctx), sessionID: sessionID, eventID: eventID}
+	err := k.task.HandleRequest(withDeltaRecorder(ctx, deltas), sessionID, goal)
+	deltas.flush()
+	done := session.Event{
+		Type:     session.EventTypeDone,
+		Metadata: map[string]interface{}{"state": "completed", "event_id": eventID},
+	}
+	if errors.Is(context.Cause(ctx), ErrCancelled) {
+		if err == nil || !errors.Is(err, context.Canceled) {
+			err = ErrCancelled
+		} else {
+			err = fmt.Errorf("%w: %v", ErrCancelled, err)
+		}
+		done.Metadata["state"] = "cancelled"
+	} else if err != nil {
+		done.Metadata["state"] = "failed"
+		done.Metadata["error"] = err.Error()
+	}
+	if k.cfg.Orchestrator.ExecutionReport {
+		report := buildExecutionReport(eventID, goal, done.Metadata["state"].(string), started, rec.snapshot())
+		k.appendEvent(context.WithoutCancel(ctx), sessionID, session.Event{
+			Type:     session.EventTypeExecutionReport,
+			Content:  goal,
+			Metadata: map[string]interface{}{"report": report},
+		})
 	}
+	k.appendEvent(context.WithoutCancel(ctx), sessionID, done)
+	return err
+}
 
-	return nil
+// appendEvent persists a progress event for stream clients. Failures are
+// only logged.
+func (k *DefaultKernel) appendEvent(ctx context.Context, sessionID string, evt session.Event) {
+	if k.session == nil {
+		return
+	}
+	if err := k.session.AppendEvent(ctx, sessionID, evt); err != nil {
+		slog.Warn("Failed to persist session event", "session", sessionID, "type", evt.Type, "error", err)
+	}
+}
+
+// Token deltas are coalesced into delta events of at least deltaFlushChars
+// bytes, or whatever arrived within deltaFlushInterval, so a long reply does
+// not cost one transcript line per token.
+const (
+	deltaFlushChars    = 256
+	deltaFlushInterval = 250 * time.Millisecond
+)
+
+// deltaRecorder records the streamed model output of one turn as delta
+// events, numbered from 1 in metadata.seq. The buffer is flushed when a
+// streamed completion ends, so deltas precede the tool calls and assistant
+// message they belong to.
+type deltaRecorder struct {
+	kernel    *DefaultKernel
+	ctx       context.Context
+	sessionID string
+	eventID   string
+
+	mu    sync.Mutex
+	buf   strings.Builder
+	since time.Time
+	seq   int
+}
+
+type deltaRecorderKey struct{}
+
+// withDeltaRecorder attaches rec to ctx as the model stream handler.
+func withDeltaRecorder(ctx context.Context, rec *deltaRecorder) context.Context {
+	if rec.kernel.session == nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, deltaRecorderKey{}, rec)
+	return model.WithStreamHandler(ctx, rec.emit)
+}
+
+func deltaRecorderFrom(ctx context.Context) *deltaRecorder {
+	rec, _ := ctx.Value(deltaRecorderKey{}).(*deltaRecorder)
+	return rec
+}
+
+func (d *deltaRecorder) emit(delta string) {
+	if delta == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.buf.Len() == 0 {
+		d.since = time.Now()
+	}
+	d.buf.WriteString(delta)
+	if d.buf.Len() >= deltaFlushChars || time.Since(d.since) >= deltaFlushInterval {
+		d.flushLocked()
+	}
+}
+
+func (d *deltaRecorder) flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flushLocked()
+}
+
+func (d *deltaRecorder) flushLocked() {
+	if d.buf.Len() == 0 {
+		return
+	}
+	d.seq++
+	d.kernel.appendEvent(d.ctx, d.sessionID, session.Event{
+		Type:     session.EventTypeDelta,
+		Content:  d.buf.String(),
+		Metadata: map[string]interface{}{"seq": d.seq, "event_id": d.eventID},
+	})
+	d.buf.Reset()
+}
+
+// maxToolResultEventChars caps tool output copied into tool_result and
+// tool_progress events.
+const maxToolResultEventChars = 4096
+
+// maxToolProgressEvents caps the tool_progress events recorded for one
+// call; later chunks are counted in the tool_result's progress_dropped.
+const maxToolProgressEvents = 200
+
+// sessionEventAppender persists progress events to a session transcript.
+type sessionEventAppender interface {
+	AppendEvent(ctx context.Context, sessionID string, evt session.Event) error
 }
 
 // ActorAdapter adapts ToolRunner and Egress to Cognitive Actor interfaces
 type ActorAdapter struct {
 	runner *tool.Runner
+	// events, when set, records tool_call and tool_result events.
+	events sessionEventAppender
 }
 
 func NewActorAdapter(r *tool.Runner) *ActorAdapter {
@@ -217,13 +554,105 @@ func NewActorAdapter(r *tool.Runner) *ActorAdapter {
 }
 
 func (a *ActorAdapter) Execute(ctx context.Context, name string, args json.RawMessage, input string) (json.RawMessage, error) {
-	return a.runner.Execute(ctx, name, args, input)
+	sessionID := logger.GetSessionID(ctx)
+	callID := cognitive.ToolCallIDFromContext(ctx)
+	a.appendEvent(ctx, sessionID, session.Event{
+		Type:      session.EventTypeToolCall,
+		ToolCalls: []*contract.ToolCall{{ID: callID, Name: name, Input: string(args)}},
+	})
+
+	progress := &toolProgressRecorder{adapter: a, ctx: ctx, sessionID: sessionID, callID: callID, name: name}
+	if a.events != nil && sessionID != "" {
+		ctx = tool.WithProgress(ctx, progress.emit)
+	}
+
+	started := time.Now()
+	res, err := a.runner.Execute(ctx, name, args, input)
+	if rec := turnRecorderFrom(ctx); rec != nil {
+		rec.addToolCall(callID, name, args, res, err, time.Since(started))
+	}
+
+	content := string(res)
+	metadata := map[string]interface{}{"name": name}
+	if len(content) > maxToolResultEventChars {
+		content = content[:maxToolResultEventChars]
+		metadata["truncated"] = true
+	}
+	if err != nil {
+		metadata["error"] = err.Error()
+	}
+	if dropped := progress.droppedCount(); dropped > 0 {
+		metadata["progress_dropped"] = dropped
+	}
+	a.appendEvent(ctx, sessionID, session.Event{
+		Type:       session.EventTypeToolResult,
+		Role:       "tool",
+		Content:    content,
+		ToolCallID: callID,
+		Metadata:   metadata,
+	})
+	return res, err
+}
+
+// toolProgressRecorder records the progress chunks of one tool call as
+// tool_progress events, numbered from 1 in metadata.seq.
+type toolProgressRecorder struct {
+	adapter   *ActorAdapter
+	ctx       context.Context
+	sessionID string
+	callID    string
+	name      string
+
+	mu      sync.Mutex
+	seq     int
+	dropped int
+}
+
+func (p *toolProgressRecorder) emit(chunk string) {
+	if chunk == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.seq >= maxToolProgressEvents {
+		p.dropped++
+		return
+	}
+	p.seq++
+	metadata := map[string]interface{}{"name": p.name, "seq": p.seq}
+	if len(chunk) > maxToolResultEventChars {
+		chunk = chunk[:maxToolResultEventChars]
+		metadata["truncated"] = true
+	}
+	p.adapter.appendEvent(p.ctx, p.sessionID, session.Event{
+		Type:       session.EventTypeToolProgress,
+		Role:       "tool",
+		Content:    chunk,
+		ToolCallID: p.callID,
+		Metadata:   metadata,
+	})
+}
+
+func (p *toolProgressRecorder) droppedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dropped
+}
+
+func (a *ActorAdapter) appendEvent(ctx context.Context, sessionID string, evt session.Event) {
+	if a.events == nil || sessionID == "" {
+		return
+	}
+	if err := a.events.AppendEvent(ctx, sessionID, evt); err != nil {
+		slog.Warn("Failed to persist tool event", "session", sessionID, "type", evt.Type, "error", err)
+	}
 }
 
 // LLMExecutorAdapter adapts Orchestrator LLMExecutor to Cognitive LLMClient
 type LLMExecutorAdapter struct {
 	router    model.ModelRouter
 	modelName string
+	features  *featureflag.Flags
 }
 
 func NewLLMAdapter(router model.ModelRouter, modelName string) *LLMExecutorAdapter {
@@ -233,6 +662,17 @@ func NewLLMAdapter(router model.ModelRouter, modelName string) *LLMExecutorAdapt
 	}
 }
 
+// applySampling copies best-of-N sampling overrides from ctx onto req.
+func applySampling(ctx context.Context, req *contract.CompletionRequest) {
+	s, ok := cognitive.SamplingFromContext(ctx)
+	if !ok {
+		return
+	}
+	temperature, seed := s.Temperature, s.Seed
+	req.Temperature = &temperature
+	req.Seed = &seed
+}
+
 func (l *LLMExecutorAdapter) Complete(ctx context.Context, prompt string) (string, error) {
 	req := contract.CompletionRequest{
 		Model: l.modelName,
@@ -240,6 +680,7 @@ func (l *LLMExecutorAdapter) Complete(ctx context.Context, prompt string) (strin
 			{Role: "user", Content: prompt},
 		},
 	}
+	applySampling(ctx, &req)
 
 	resp, err := l.router.Route(ctx, l.modelName, req)
 	if err != nil {
@@ -249,12 +690,47 @@ func (l *LLMExecutorAdapter) Complete(ctx context.Context, prompt string) (strin
 	return resp.Content, nil
 }
 
+func (l *LLMExecutorAdapter) CompleteStructured(ctx context.Context, prompt string, format contract.ResponseFormat) (string, error) {
+	req := contract.CompletionRequest{
+		Model: l.modelName,
+		Messages: []contract.Message{
+			{Role: "user", Content: prompt},
+		},
+		ResponseFormat: &format,
+	}
+	applySampling(ctx, &req)
+
+	resp, err := l.router.Route(ctx, l.modelName, req)
+	if err != nil {
+		return "", fmt.Errorf("LLM structured execution failed: %w", err)
+	}
+
+	return resp.Content, nil
+}
+
 func (l *LLMExecutorAdapter) ChatComplete(ctx context.Context, messages []contract.Message, tools []contract.ToolDef) (string, []*contract.ToolCall, error) {
 	req := contract.CompletionRequest{
 		Model:    l.modelName,
 		Messages: messages,
 		Tools:    tools,
 	}
+	applySampling(ctx, &req)
+
+	// Forward token deltas when the caller attached a stream handler
+	if onDelta, ok := model.StreamHandlerFromContext(ctx); ok && l.features.Enabled(featureflag.Streaming) {
+		stream, err := l.router.RouteStream(ctx, l.modelName, req)
+		if err != nil {
+			return "", nil, fmt.Errorf("LLM streaming with tools failed: %w", err)
+		}
+		resp, err := model.CollectStream(stream, onDelta)
+		if deltas := deltaRecorderFrom(ctx); deltas != nil {
+			deltas.flush()
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("LLM streaming with tools failed: %w", err)
+		}
+		return resp.Content, resp.ToolCalls, nil
+	}
 
 	resp, err := l.router.Route(ctx, l.modelName, req)
 	if err != nil {
@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/model/contract"
+)
+
+type recordingRouter struct {
+	model.ModelRouter
+	requests []contract.CompletionRequest
+}
+
+func (r *recordingRouter) Route(ctx context.Context, modelName string, req contract.CompletionRequest) (*contract.CompletionResponse, error) {
+	r.requests = append(r.requests, req)
+	return &contract.CompletionResponse{Content: "ok"}, nil
+}
+
+func TestLLMAdapter_AppliesSamplingFromContext(t *testing.T) {
+	router := &recordingRouter{}
+	adapter := NewLLMAdapter(router, "gpt-4o")
+
+	if _, err := adapter.Complete(context.Background(), "plain"); err != nil {
+		t.Fatalf("Complete() failed: %v", err)
+	}
+	sampled := cognitive.WithSampling(context.Background(), cognitive.Sampling{Temperature: 0.8, Seed: 2})
+	if _, _, err := adapter.ChatComplete(sampled, []contract.Message{{Role: "user", Content: "sampled"}}, nil); err != nil {
+		t.Fatalf("ChatComplete() failed: %v", err)
+	}
+
+	if len(router.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(router.requests))
+	}
+	if plain := router.requests[0]; plain.Temperature != nil || plain.Seed != nil {
+		t.Fatalf("plain request should keep provider defaults, got temperature %v seed %v", plain.Temperature, plain.Seed)
+	}
+	sample := router.requests[1]
+	if sample.Temperature == nil || *sample.Temperature != 0.8 || sample.Seed == nil || *sample.Seed != 2 {
+		t.Fatalf("sampled request = temperature %v seed %v, want 0.8 and 2", sample.Temperature, sample.Seed)
+	}
+}
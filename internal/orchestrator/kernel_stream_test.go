@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/model/contract"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+)
+
+type recordingSession struct {
+	session.Manager
+	events recordedEvents
+}
+
+func (s *recordingSession) AppendEvent(ctx context.Context, sessionID string, evt session.Event) error {
+	return s.events.AppendEvent(ctx, sessionID, evt)
+}
+
+type streamingRouter struct {
+	model.ModelRouter
+	deltas []string
+}
+
+func (r *streamingRouter) RouteStream(ctx context.Context, modelName string, req contract.CompletionRequest) (<-chan contract.StreamChunk, error) {
+	ch := make(chan contract.StreamChunk, len(r.deltas)+1)
+	for _, delta := range r.deltas {
+		ch <- contract.StreamChunk{Delta: delta}
+	}
+	ch <- contract.StreamChunk{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestLLMAdapter_RecordsCoalescedDeltas(t *testing.T) {
+	router := &streamingRouter{deltas: []string{"The ", "answer ", strings.Repeat("x", deltaFlushChars), " is 42."}}
+	sess := &recordingSession{}
+	k := &DefaultKernel{session: sess}
+	deltas := &deltaRecorder{kernel: k, ctx: context.Background(), sessionID: "sess-1", eventID: "evt-1"}
+	ctx := withDeltaRecorder(context.Background(), deltas)
+
+	content, _, err := NewLLMAdapter(router, "gpt-4o").ChatComplete(ctx, []contract.Message{{Role: "user", Content: "answer?"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatComplete() failed: %v", err)
+	}
+
+	// The long delta fills the buffer; the tail is flushed when the stream ends.
+	if len(sess.events.events) != 2 {
+		t.Fatalf("events = %+v, want 2 coalesced deltas", sess.events.events)
+	}
+	var streamed strings.Builder
+	for i, evt := range sess.events.events {
+		if evt.Type != session.EventTypeDelta || evt.Metadata["seq"] != i+1 || evt.Metadata["event_id"] != "evt-1" {
+			t.Fatalf("event %d = %+v", i, evt)
+		}
+		streamed.WriteString(evt.Content)
+	}
+	if streamed.String() != content {
+		t.Fatalf("streamed %q, want %q", streamed.String(), content)
+	}
+	if session.EventTypeDelta.Replayed() {
+		t.Fatal("delta events must not be replayed to the model")
+	}
+}
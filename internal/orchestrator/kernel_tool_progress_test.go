@@ -0,0 +1,120 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/logger"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/tool"
+)
+
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []session.Event
+}
+
+func (r *recordedEvents) AppendEvent(ctx context.Context, sessionID string, evt session.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, evt)
+	return nil
+}
+
+type chattyTool struct {
+	chunks int
+}
+
+func (t *chattyTool) Name() string        { return "chatty" }
+func (t *chattyTool) Description() string { return "emits progress" }
+func (t *chattyTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (t *chattyTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	return t.ExecuteStream(ctx, input, nil)
+}
+func (t *chattyTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit tool.ProgressFunc) (json.RawMessage, error) {
+	for i := 1; i <= t.chunks && emit != nil; i++ {
+		emit(fmt.Sprintf("chunk %d", i))
+	}
+	return json.RawMessage(`{"done":true}`), nil
+}
+
+func newProgressAdapter(t *testing.T, chatty *chattyTool) (*ActorAdapter, *recordedEvents) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	pol, err := policy.NewEngine(config.GovernanceConfig{AutoAllow: []string{"chatty"}}, "progress-"+t.Name(), "")
+	if err != nil {
+		t.Fatalf("policy.NewEngine() failed: %v", err)
+	}
+	registry := tool.NewRegistry()
+	registry.Register(chatty)
+	events := &recordedEvents{}
+	adapter := NewActorAdapter(tool.NewRunner(registry, pol))
+	adapter.events = events
+	return adapter, events
+}
+
+func TestActorAdapter_RecordsToolProgress(t *testing.T) {
+	adapter, events := newProgressAdapter(t, &chattyTool{chunks: 2})
+
+	ctx := logger.WithSessionID(context.Background(), "sess-1")
+	if _, err := adapter.Execute(ctx, "chatty", json.RawMessage(`{}`), ""); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	var types []session.EventType
+	for _, evt := range events.events {
+		types = append(types, evt.Type)
+	}
+	want := []session.EventType{session.EventTypeToolCall, session.EventTypeToolProgress, session.EventTypeToolProgress, session.EventTypeToolResult}
+	if fmt.Sprint(types) != fmt.Sprint(want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	second := events.events[2]
+	if second.Content != "chunk 2" || second.Metadata["seq"] != 2 || second.Metadata["name"] != "chatty" {
+		t.Fatalf("second progress event = %+v", second)
+	}
+	if session.EventTypeToolProgress.Replayed() {
+		t.Fatal("tool_progress events must not be replayed to the model")
+	}
+}
+
+func TestActorAdapter_CapsToolProgress(t *testing.T) {
+	adapter, events := newProgressAdapter(t, &chattyTool{chunks: maxToolProgressEvents + 5})
+
+	ctx := logger.WithSessionID(context.Background(), "sess-1")
+	if _, err := adapter.Execute(ctx, "chatty", json.RawMessage(`{}`), ""); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	progress := 0
+	for _, evt := range events.events {
+		if evt.Type == session.EventTypeToolProgress {
+			progress++
+		}
+	}
+	if progress != maxToolProgressEvents {
+		t.Fatalf("progress events = %d, want %d", progress, maxToolProgressEvents)
+	}
+	result := events.events[len(events.events)-1]
+	if result.Type != session.EventTypeToolResult || result.Metadata["progress_dropped"] != 5 {
+		t.Fatalf("tool_result = %+v, want progress_dropped 5", result)
+	}
+}
+
+func TestActorAdapter_NoProgressWithoutSession(t *testing.T) {
+	adapter, events := newProgressAdapter(t, &chattyTool{chunks: 3})
+
+	if _, err := adapter.Execute(context.Background(), "chatty", json.RawMessage(`{}`), ""); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if len(events.events) != 0 {
+		t.Fatalf("events = %+v, want none outside a session", events.events)
+	}
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/knowledge"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+)
+
+// BackfillOptions selects the transcripts BackfillTranscripts embeds. Empty
+// fields select everything in the workspace.
+type BackfillOptions struct {
+	Sessions []string
+	// Source matches the session's "source" metadata, such as slack.
+	Source string
+	// Since skips messages written before it.
+	Since time.Time
+}
+
+// BackfillResult counts what BackfillTranscripts embedded.
+type BackfillResult struct {
+	Sessions int
+	Chunks   int
+}
+
+// BackfillTranscripts chunks and embeds the user and assistant messages of
+// past session transcripts into the memory collection, so history from
+// before memory was in use can be recalled. Chunk IDs are derived from the
+// session, so running it again replaces chunks instead of adding copies.
+// progress, if set, is called after each session.
+func (m *VectorMemory) BackfillTranscripts(ctx context.Context, opts BackfillOptions, progress func(done, total int)) (BackfillResult, error) {
+	sessions, err := m.backfillSessions(opts)
+	if err != nil {
+		return BackfillResult{}, err
+	}
+	if progress != nil {
+		progress(0, len(sessions))
+	}
+
+	var result BackfillResult
+	for i, sessionID := range sessions {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		chunks, err := m.backfillSession(ctx, sessionID, opts.Since)
+		if err != nil {
+			return result, fmt.Errorf("backfill session %s: %w", sessionID, err)
+		}
+		if chunks > 0 {
+			result.Sessions++
+			result.Chunks += chunks
+		}
+		if progress != nil {
+			progress(i+1, len(sessions))
+		}
+	}
+
+	slog.Info("Transcripts backfilled into memory", "sessions", result.Sessions, "chunks", result.Chunks, "model", m.embeddingModel)
+	return result, nil
+}
+
+func (m *VectorMemory) backfillSessions(opts BackfillOptions) ([]string, error) {
+	sessions := opts.Sessions
+	if len(sessions) == 0 {
+		all, err := m.store.ListSessions()
+		if err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		sessions = all
+	}
+	sort.Strings(sessions)
+
+	source := strings.TrimSpace(opts.Source)
+	if source == "" {
+		return sessions, nil
+	}
+	var matched []string
+	for _, sessionID := range sessions {
+		meta, err := m.store.GetSession(sessionID)
+		if err != nil || meta == nil {
+			continue
+		}
+		if meta.Metadata["source"] == source {
+			matched = append(matched, sessionID)
+		}
+	}
+	return matched, nil
+}
+
+func (m *VectorMemory) backfillSession(ctx context.Context, sessionID string, since time.Time) (int, error) {
+	lines, err := m.store.ReadTranscript(sessionID, 0)
+	if err != nil {
+		return 0, fmt.Errorf("read transcript: %w", err)
+	}
+	conversation := transcriptConversation(lines, since)
+	if conversation == "" {
+		return 0, nil
+	}
+
+	title := "Conversation in session " + sessionID
+	chunks := knowledge.Chunk(conversation, config.DefaultKnowledgeChunkSize, config.DefaultKnowledgeChunkOverlap)
+	for i, chunk := range chunks {
+		embedding, err := m.router.RouteEmbedding(ctx, m.embeddingModel, title+"\n\n"+chunk)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed transcript chunk: %w", err)
+		}
+		metadata := map[string]string{
+			"source":     "transcript",
+			"session_id": sessionID,
+			"title":      title,
+			"chunk":      strconv.Itoa(i),
+		}
+		if err := m.store.UpsertVector(CollectionMemory, "transcript:"+sessionID+"#"+strconv.Itoa(i), embedding, metadata, chunk); err != nil {
+			return 0, fmt.Errorf("failed to upsert transcript chunk: %w", err)
+		}
+	}
+	return len(chunks), nil
+}
+
+// transcriptConversation renders the user and assistant messages of a
+// transcript, one "role: content" paragraph each. Tool output, progress
+// and debug events are left out.
+func transcriptConversation(lines []string, since time.Time) string {
+	var b strings.Builder
+	for _, line := range lines {
+		var evt session.Event
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			continue
+		}
+		if evt.Type != session.EventTypeUser && evt.Type != session.EventTypeAssistant {
+			continue
+		}
+		content := strings.TrimSpace(evt.Content)
+		if content == "" || (!since.IsZero() && evt.Timestamp.Before(since)) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(evt.Role)
+		b.WriteString(": ")
+		b.WriteString(content)
+	}
+	return b.String()
+}
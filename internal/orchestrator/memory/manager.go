@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 
 	"github.com/oklog/ulid/v2"
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/knowledge"
 	"github.com/harunnryd/heike/internal/model"
 	"github.com/harunnryd/heike/internal/store"
 )
@@ -18,10 +20,28 @@ const (
 	CollectionMemory = "memories"
 )
 
+// sessionContextLimit is how many injected context chunks are recalled per turn.
+const sessionContextLimit = 5
+
+// SessionContextCollection is the vector collection holding the context
+// documents injected for sessionID.
+func SessionContextCollection(sessionID string) string {
+	return "session_context:" + sessionID
+}
+
+// ContextDocument is structured context pushed by an upstream system, such
+// as ticket details or CRM records. Metadata "title" and "url" are shown
+// with recalled chunks.
+type ContextDocument struct {
+	Text     string
+	Metadata map[string]string
+}
+
 type VectorMemory struct {
 	store          *store.Worker
 	router         model.ModelRouter
 	embeddingModel string
+	knowledge      []string
 }
 
 func NewManager(s *store.Worker, r model.ModelRouter, embeddingModel string) *VectorMemory {
@@ -37,25 +57,62 @@ func NewManager(s *store.Worker, r model.ModelRouter, embeddingModel string) *Ve
 	}
 }
 
-// Ensure VectorMemory implements cognitive.MemoryManager
-var _ cognitive.MemoryManager = (*VectorMemory)(nil)
+// AddKnowledgeCollection makes Retrieve also search a synced document
+// collection, so answers can draw on team docs alongside remembered facts.
+func (m *VectorMemory) AddKnowledgeCollection(collection string) {
+	collection = strings.TrimSpace(collection)
+	if collection == "" || collection == CollectionMemory {
+		return
+	}
+	m.knowledge = append(m.knowledge, collection)
+}
+
+// Ensure VectorMemory implements cognitive.SessionMemoryManager
+var _ cognitive.SessionMemoryManager = (*VectorMemory)(nil)
 
 func (m *VectorMemory) Retrieve(ctx context.Context, query string) ([]string, error) {
+	return m.RetrieveForSession(ctx, "", query)
+}
+
+// RetrieveForSession recalls the context injected for sessionID ahead of
+// remembered facts and knowledge documents.
+func (m *VectorMemory) RetrieveForSession(ctx context.Context, sessionID, query string) ([]string, error) {
 	embedding, err := m.router.RouteEmbedding(ctx, m.embeddingModel, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
+	var facts []string
+	if sessionID != "" {
+		docs, err := m.store.SearchVectors(SessionContextCollection(sessionID), embedding, sessionContextLimit)
+		if err != nil {
+			slog.Warn("Session context search failed", "session", sessionID, "error", err)
+		}
+		for _, r := range docs {
+			facts = append(facts, knowledgeFact(r))
+		}
+	}
+
 	results, err := m.store.SearchVectors(CollectionMemory, embedding, 5) // Top 5
 	if err != nil {
 		return nil, fmt.Errorf("failed to search vectors: %w", err)
 	}
 
-	var facts []string
 	for _, r := range results {
 		facts = append(facts, r.Content)
 	}
 
+	for _, collection := range m.knowledge {
+		docs, err := m.store.SearchVectors(collection, embedding, 5)
+		if err != nil {
+			slog.Warn("Knowledge search failed", "collection", collection, "error", err)
+			continue
+		}
+		for _, r := range docs {
+			facts = append(facts, knowledgeFact(r))
+		}
+	}
+
 	slog.Info("Memory retrieved", "query", query, "count", len(facts))
 	return facts, nil
 }
@@ -77,3 +134,140 @@ func (m *VectorMemory) Remember(ctx context.Context, fact string) error {
 	slog.Info("Memory stored", "fact_preview", fact[:min(len(fact), 50)], "id", id)
 	return nil
 }
+
+// InjectSessionContext chunks and embeds docs into the session's context
+// collection, so later turns of sessionID recall them. It returns the number
+// of chunks stored.
+func (m *VectorMemory) InjectSessionContext(ctx context.Context, sessionID string, docs []ContextDocument) (int, error) {
+	collection := SessionContextCollection(sessionID)
+	stored := 0
+	for _, doc := range docs {
+		docID := ulid.Make().String()
+		title := doc.Metadata["title"]
+		for i, chunk := range knowledge.Chunk(doc.Text, config.DefaultKnowledgeChunkSize, config.DefaultKnowledgeChunkOverlap) {
+			embedText := chunk
+			if title != "" {
+				embedText = title + "\n\n" + chunk
+			}
+			embedding, err := m.router.RouteEmbedding(ctx, m.embeddingModel, embedText)
+			if err != nil {
+				return stored, fmt.Errorf("failed to embed context chunk: %w", err)
+			}
+			metadata := make(map[string]string, len(doc.Metadata)+2)
+			for key, value := range doc.Metadata {
+				metadata[key] = value
+			}
+			metadata["doc_id"] = docID
+			metadata["chunk"] = strconv.Itoa(i)
+			if err := m.store.UpsertVector(collection, docID+"#"+strconv.Itoa(i), embedding, metadata, chunk); err != nil {
+				return stored, fmt.Errorf("failed to upsert context chunk: %w", err)
+			}
+			stored++
+		}
+	}
+
+	slog.Info("Session context injected", "session", sessionID, "documents", len(docs), "chunks", stored)
+	return stored, nil
+}
+
+// Reembed embeds every document of collection again with the configured
+// embedding model and replaces the collection in one store operation, e.g.
+// after models.embedding changed dimension. progress, if set, is called
+// after each document. It returns the number of documents re-embedded.
+func (m *VectorMemory) Reembed(ctx context.Context, collection string, progress func(done, total int)) (int, error) {
+	docs, err := m.store.ListVectors(collection)
+	if err != nil {
+		return 0, err
+	}
+	if progress != nil {
+		progress(0, len(docs))
+	}
+	for i, doc := range docs {
+		// Knowledge and session context chunks were embedded with their
+		// title, which is kept in metadata.
+		text := doc.Content
+		if title := doc.Metadata["title"]; title != "" {
+			text = title + "\n\n" + doc.Content
+		}
+		embedding, err := m.router.RouteEmbedding(ctx, m.embeddingModel, text)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+		}
+		docs[i].Vector = embedding
+		if progress != nil {
+			progress(i+1, len(docs))
+		}
+	}
+	if err := m.store.ReplaceVectors(collection, docs); err != nil {
+		return 0, fmt.Errorf("failed to replace vectors: %w", err)
+	}
+
+	slog.Info("Vector collection re-embedded", "collection", collection, "documents", len(docs), "model", m.embeddingModel)
+	return len(docs), nil
+}
+
+// knowledgeFact prefixes a document chunk with its title and link so the
+// model can cite where the answer came from.
+func knowledgeFact(r store.VectorResult) string {
+	source := r.Metadata["title"]
+	if url := r.Metadata["url"]; url != "" {
+		if source == "" {
+			source = url
+		} else {
+			source += " (" + url + ")"
+		}
+	}
+	if source == "" {
+		return r.Content
+	}
+	return "From " + source + ":\n" + r.Content
+}
+
+// MoveSessionVectors re-points the vectors of sourceID at targetID after a
+// session merge: its context collection is copied into the target's and
+// emptied, and memory chunks tagged with its session_id are retagged. It
+// returns the number of vectors moved.
+func (m *VectorMemory) MoveSessionVectors(sourceID, targetID string) (int, error) {
+	moved := 0
+	source := SessionContextCollection(sourceID)
+	if m.store.HasVectorCollection(source) {
+		docs, err := m.store.ListVectors(source)
+		if err != nil {
+			return 0, err
+		}
+		target := SessionContextCollection(targetID)
+		for _, doc := range docs {
+			if err := m.store.UpsertVector(target, doc.ID, doc.Vector, doc.Metadata, doc.Content); err != nil {
+				return moved, fmt.Errorf("failed to move context chunk: %w", err)
+			}
+			moved++
+		}
+		if err := m.store.ReplaceVectors(source, nil); err != nil {
+			return moved, fmt.Errorf("failed to clear %s: %w", source, err)
+		}
+	}
+
+	if m.store.HasVectorCollection(CollectionMemory) {
+		docs, err := m.store.ListVectors(CollectionMemory)
+		if err != nil {
+			return moved, err
+		}
+		for _, doc := range docs {
+			if doc.Metadata["session_id"] != sourceID {
+				continue
+			}
+			metadata := make(map[string]string, len(doc.Metadata))
+			for key, value := range doc.Metadata {
+				metadata[key] = value
+			}
+			metadata["session_id"] = targetID
+			if err := m.store.UpsertVector(CollectionMemory, doc.ID, doc.Vector, metadata, doc.Content); err != nil {
+				return moved, fmt.Errorf("failed to retag memory chunk: %w", err)
+			}
+			moved++
+		}
+	}
+
+	slog.Info("Session vectors moved", "from", sourceID, "to", targetID, "vectors", moved)
+	return moved, nil
+}
@@ -0,0 +1,90 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/harunnryd/heike/internal/ingress"
+)
+
+// QuotaRetryMetadataKey marks a re-submitted turn with its retry attempt.
+const QuotaRetryMetadataKey = "quota_retry"
+
+// maxQuotaRetryDelay caps the exponential backoff between quota retries.
+const maxQuotaRetryDelay = 10 * time.Minute
+
+// DelayedSubmitter re-submits events after a delay.
+type DelayedSubmitter interface {
+	SubmitAfter(evt *ingress.Event, delay time.Duration) error
+}
+
+type eventContextKey struct{}
+
+// SetDelayedSubmitter lets the kernel retry rate-limited turns through the
+// delayed-event queue. Until it is set, rate-limited turns fail as before.
+func (k *DefaultKernel) SetDelayedSubmitter(submitter DelayedSubmitter) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.delayed = submitter
+}
+
+func withEvent(ctx context.Context, evt *ingress.Event) context.Context {
+	return context.WithValue(ctx, eventContextKey{}, evt)
+}
+
+func eventFromContext(ctx context.Context) *ingress.Event {
+	evt, _ := ctx.Value(eventContextKey{}).(*ingress.Event)
+	return evt
+}
+
+func quotaRetryAttempt(evt *ingress.Event) int {
+	if evt == nil || evt.Metadata == nil {
+		return 0
+	}
+	attempt, err := strconv.Atoi(evt.Metadata[QuotaRetryMetadataKey])
+	if err != nil {
+		return 0
+	}
+	return attempt
+}
+
+// scheduleQuotaRetry re-submits the event being executed with exponential
+// backoff, up to the configured number of retries.
+func (k *DefaultKernel) scheduleQuotaRetry(ctx context.Context, sessionID string, goal string) (time.Duration, bool) {
+	k.mu.RLock()
+	submitter := k.delayed
+	k.mu.RUnlock()
+
+	evt := eventFromContext(ctx)
+	if submitter == nil || evt == nil {
+		return 0, false
+	}
+	attempt := quotaRetryAttempt(evt)
+	if attempt >= k.quotaMaxRetries {
+		slog.Warn("Quota retries exhausted", "session", sessionID, "attempts", attempt)
+		return 0, false
+	}
+
+	delay := k.quotaBackoff << attempt
+	if delay <= 0 || delay > maxQuotaRetryDelay {
+		delay = maxQuotaRetryDelay
+	}
+
+	metadata := make(map[string]string, len(evt.Metadata)+1)
+	for key, value := range evt.Metadata {
+		metadata[key] = value
+	}
+	metadata[QuotaRetryMetadataKey] = strconv.Itoa(attempt + 1)
+	retry := ingress.NewEvent(evt.Source, evt.Type, evt.SessionID, goal, metadata)
+	retry.CallbackURL = evt.CallbackURL
+
+	if err := submitter.SubmitAfter(&retry, delay); err != nil {
+		slog.Warn("Failed to schedule quota retry", "session", sessionID, "error", err)
+		return 0, false
+	}
+	// The retry reports the outcome; the deferred turn has none yet.
+	evt.CallbackURL = ""
+	return delay, true
+}
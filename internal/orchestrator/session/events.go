@@ -13,8 +13,44 @@ const (
 	EventTypeAssistant EventType = "assistant"
 	EventTypeTool      EventType = "tool"
 	EventTypeSystem    EventType = "system"
+	// EventTypeDebug carries verbose orchestrator steps; it is never replayed to the model
+	EventTypeDebug EventType = "debug"
+
+	// Progress events let stream clients follow a turn. Like debug events they
+	// are never replayed to the model.
+	EventTypeToolCall         EventType = "tool_call"
+	EventTypeToolProgress     EventType = "tool_progress"
+	EventTypeToolResult       EventType = "tool_result"
+	EventTypeApprovalRequired EventType = "approval_required"
+	EventTypeStatus           EventType = "status"
+	EventTypeDone             EventType = "done"
+	// EventTypeDelta carries streamed model output; the finished reply
+	// follows as an assistant message.
+	EventTypeDelta EventType = "delta"
+
+	// EventTypeTaskResult holds the sub-task report of a decomposed goal in
+	// metadata.report. The synthesized reply follows it as an assistant
+	// message, so it is not replayed.
+	EventTypeTaskResult EventType = "task_result"
+
+	// EventTypeExecutionReport holds the ExecutionReport of a goal in
+	// metadata.report. It is never replayed to the model.
+	EventTypeExecutionReport EventType = "execution_report"
 )
 
+// Replayed reports whether events of this type belong in model history.
+func (t EventType) Replayed() bool {
+	switch t {
+	case EventTypeDebug, EventTypeToolCall, EventTypeToolProgress, EventTypeToolResult, EventTypeApprovalRequired, EventTypeStatus, EventTypeDone, EventTypeDelta, EventTypeTaskResult, EventTypeExecutionReport:
+		return false
+	default:
+		return true
+	}
+}
+
+// DebugMetadataKey is the session metadata flag toggled by /debug
+const DebugMetadataKey = "debug"
+
 // Event represents a persisted interaction in the session history
 type Event struct {
 	ID        string    `json:"id"`
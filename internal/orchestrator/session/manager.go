@@ -12,6 +12,8 @@ import (
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/model/contract"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/store"
 )
 
@@ -19,8 +21,13 @@ type Manager interface {
 	GetContext(ctx context.Context, sessionID string) (*cognitive.CognitiveContext, error)
 	AppendInteraction(ctx context.Context, sessionID string, role, content string) error
 	PersistTool(ctx context.Context, sessionID, toolCallID, content string) error
+	AppendDebug(ctx context.Context, sessionID, stage, content string) error
+	AppendEvent(ctx context.Context, sessionID string, evt Event) error
 }
 
+// contextFlagKeys are session metadata flags carried into the cognitive context.
+var contextFlagKeys = []string{DebugMetadataKey, cognitive.HighStakesMetadataKey}
+
 type DefaultSessionManager struct {
 	store        *store.Worker
 	memory       cognitive.MemoryManager
@@ -40,20 +47,30 @@ func NewManager(s *store.Worker, m cognitive.MemoryManager, historyLimit int) *D
 }
 
 func (sm *DefaultSessionManager) GetContext(ctx context.Context, sessionID string) (*cognitive.CognitiveContext, error) {
-	// Load History
-	historyLines, err := sm.store.ReadTranscript(sessionID, sm.historyLimit)
+	// Load History. Progress events are interleaved with messages, so the
+	// limit applies after they are filtered out.
+	historyLines, err := sm.store.ReadTranscript(sessionID, 0)
 	if err != nil {
 		slog.Warn("Failed to read transcript", "error", err)
 	}
 
 	history := sm.parseHistoryLines(historyLines)
+	if len(history) > sm.historyLimit {
+		history = history[len(history)-sm.historyLimit:]
+	}
 
 	// Load Memories (using last message as query if available)
 	var memories []string
 	if len(history) > 0 {
 		lastMsg := history[len(history)-1].Content
 		if sm.memory != nil && lastMsg != "" {
-			mems, err := sm.memory.Retrieve(ctx, lastMsg)
+			var mems []string
+			var err error
+			if sessionMemory, ok := sm.memory.(cognitive.SessionMemoryManager); ok {
+				mems, err = sessionMemory.RetrieveForSession(ctx, sessionID, lastMsg)
+			} else {
+				mems, err = sm.memory.Retrieve(ctx, lastMsg)
+			}
 			if err != nil {
 				slog.Warn("Failed to retrieve memories", "error", err)
 			} else {
@@ -62,11 +79,26 @@ func (sm *DefaultSessionManager) GetContext(ctx context.Context, sessionID strin
 		}
 	}
 
+	metadata := make(map[string]string)
+	if meta, err := sm.store.GetSession(sessionID); err == nil && meta != nil {
+		for _, key := range contextFlagKeys {
+			if meta.Metadata[key] == "true" {
+				metadata[key] = "true"
+			}
+		}
+		if allow, ok := meta.Metadata[policy.ToolsAllowMetadataKey]; ok {
+			metadata[policy.ToolsAllowMetadataKey] = allow
+		}
+		if exclude := meta.Metadata[skill.ExcludeMetadataKey]; exclude != "" {
+			metadata[skill.ExcludeMetadataKey] = exclude
+		}
+	}
+
 	return &cognitive.CognitiveContext{
 		SessionID: sessionID,
 		History:   history,
 		Memories:  memories,
-		Metadata:  make(map[string]string),
+		Metadata:  metadata,
 	}, nil
 }
 
@@ -112,6 +144,47 @@ func (sm *DefaultSessionManager) PersistTool(ctx context.Context, sessionID, too
 	return sm.store.WriteTranscript(sessionID, line)
 }
 
+// AppendDebug persists a collapsible debug event for verbose sessions.
+func (sm *DefaultSessionManager) AppendDebug(ctx context.Context, sessionID, stage, content string) error {
+	evt := Event{
+		ID:        ulid.Make().String(),
+		Timestamp: time.Now(),
+		Type:      EventTypeDebug,
+		Role:      "system",
+		Content:   content,
+		Metadata: map[string]interface{}{
+			"stage":       stage,
+			"collapsible": true,
+		},
+	}
+
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal debug failed: %w", err)
+	}
+	return sm.store.WriteTranscript(sessionID, line)
+}
+
+// AppendEvent persists a progress event such as a tool call or status change.
+// ID and Timestamp are filled in when empty.
+func (sm *DefaultSessionManager) AppendEvent(ctx context.Context, sessionID string, evt Event) error {
+	if evt.ID == "" {
+		evt.ID = ulid.Make().String()
+	}
+	if evt.Timestamp.IsZero() {
+		evt.Timestamp = time.Now()
+	}
+	if evt.Role == "" {
+		evt.Role = "system"
+	}
+
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal %s event failed: %w", evt.Type, err)
+	}
+	return sm.store.WriteTranscript(sessionID, line)
+}
+
 func (sm *DefaultSessionManager) parseHistoryLines(historyLines []string) []contract.Message {
 	var messages []contract.Message
 	for _, line := range historyLines {
@@ -119,6 +192,9 @@ func (sm *DefaultSessionManager) parseHistoryLines(historyLines []string) []cont
 		if err := json.Unmarshal([]byte(line), &evt); err != nil {
 			continue
 		}
+		if !evt.Type.Replayed() {
+			continue
+		}
 
 		messages = append(messages, evt.ToContractMessage())
 	}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/store"
+)
+
+// Session metadata recording a merge: the target lists the sessions merged
+// into it, and the archived source names its target.
+const (
+	MergedFromMetadataKey = "merged_from"
+	MergedIntoMetadataKey = "merged_into"
+)
+
+// MergeResult describes a completed Merge.
+type MergeResult struct {
+	Target *store.SessionMeta
+	// Events counts the transcript lines copied from the source.
+	Events int
+}
+
+// Merge appends the transcript of sourceID to targetID and archives the
+// source. Copied events keep their IDs and timestamps and gain a
+// merged_from metadata field; a status event marks where they start.
+// Metadata missing from the target is taken from the source, and a
+// tools_allow restriction on either session applies to the result.
+func Merge(s *store.Worker, targetID, sourceID string) (MergeResult, error) {
+	if targetID == sourceID {
+		return MergeResult{}, errors.InvalidInput("cannot merge a session into itself")
+	}
+	target, err := s.GetSession(targetID)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	if target == nil {
+		return MergeResult{}, errors.NotFound(fmt.Sprintf("session %s", targetID))
+	}
+	source, err := s.GetSession(sourceID)
+	if err != nil {
+		return MergeResult{}, err
+	}
+	if source == nil {
+		return MergeResult{}, errors.NotFound(fmt.Sprintf("session %s", sourceID))
+	}
+	if into := source.Metadata[MergedIntoMetadataKey]; into != "" {
+		return MergeResult{}, fmt.Errorf("session %s was already merged into %s: %w", sourceID, into, errors.ErrConflict)
+	}
+
+	lines, err := s.ReadTranscript(sourceID, 0)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("read transcript: %w", err)
+	}
+	now := time.Now()
+	marker := Event{
+		ID:        ulid.Make().String(),
+		Timestamp: now,
+		Type:      EventTypeStatus,
+		Role:      "system",
+		Content:   fmt.Sprintf("Merged session %s (%s)", sourceID, source.Title),
+		Metadata:  map[string]interface{}{"state": "merged", MergedFromMetadataKey: sourceID},
+	}
+	if err := writeEvent(s, targetID, marker); err != nil {
+		return MergeResult{}, err
+	}
+	events := 0
+	for _, line := range lines {
+		var evt Event
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			continue
+		}
+		if evt.Metadata == nil {
+			evt.Metadata = make(map[string]interface{})
+		}
+		evt.Metadata[MergedFromMetadataKey] = sourceID
+		if err := writeEvent(s, targetID, evt); err != nil {
+			return MergeResult{Events: events}, err
+		}
+		events++
+	}
+
+	merged := *target
+	merged.Metadata = mergeMetadata(target.Metadata, source.Metadata, sourceID)
+	merged.UpdatedAt = now
+	if err := s.SaveSession(&merged); err != nil {
+		return MergeResult{Events: events}, err
+	}
+
+	archived := *source
+	archived.Metadata = make(map[string]string, len(source.Metadata)+1)
+	for k, v := range source.Metadata {
+		archived.Metadata[k] = v
+	}
+	archived.Metadata[MergedIntoMetadataKey] = targetID
+	archived.Status = "archived"
+	archived.UpdatedAt = now
+	if err := s.SaveSession(&archived); err != nil {
+		return MergeResult{Target: &merged, Events: events}, err
+	}
+	return MergeResult{Target: &merged, Events: events}, nil
+}
+
+// mergeMetadata returns target's metadata with keys it lacks filled in
+// from source. tools_allow is narrowed rather than overwritten, and
+// merged_from lists every session merged so far.
+func mergeMetadata(target, source map[string]string, sourceID string) map[string]string {
+	merged := make(map[string]string, len(target)+len(source)+1)
+	for k, v := range source {
+		merged[k] = v
+	}
+	for k, v := range target {
+		merged[k] = v
+	}
+	if allow, ok := source[policy.ToolsAllowMetadataKey]; ok {
+		merged[policy.ToolsAllowMetadataKey] = policy.NarrowToolAllowlist(target, allow)
+	}
+	delete(merged, MergedIntoMetadataKey)
+
+	from := make([]string, 0, 4)
+	for _, list := range []string{target[MergedFromMetadataKey], source[MergedFromMetadataKey]} {
+		for _, id := range strings.Split(list, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				from = append(from, id)
+			}
+		}
+	}
+	merged[MergedFromMetadataKey] = strings.Join(append(from, sourceID), ",")
+	return merged
+}
+
+func writeEvent(s *store.Worker, sessionID string, evt Event) error {
+	line, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("marshal %s event failed: %w", evt.Type, err)
+	}
+	return s.WriteTranscript(sessionID, line)
+}
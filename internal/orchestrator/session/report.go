@@ -0,0 +1,65 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExecutionReport summarizes where a goal's time and money went. With
+// orchestrator.execution_report it is appended after each goal as an
+// execution_report event holding it in metadata.report.
+type ExecutionReport struct {
+	EventID string `json:"event_id,omitempty"`
+	Goal    string `json:"goal"`
+	// Status is completed, failed or cancelled, as in the done event.
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Turns counts cognitive loop turns across the goal's sub-tasks.
+	Turns int `json:"turns"`
+	// Retries counts turns repeated at the reflector's request and sub-task
+	// re-runs.
+	Retries          int                   `json:"retries"`
+	Tools            []ExecutionToolCall   `json:"tools,omitempty"`
+	Models           []ExecutionModelUsage `json:"models,omitempty"`
+	PromptTokens     int                   `json:"prompt_tokens"`
+	CompletionTokens int                   `json:"completion_tokens"`
+	// CostUSD is zero for models without models.pricing.
+	CostUSD float64 `json:"cost_usd"`
+}
+
+type ExecutionToolCall struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ExecutionModelUsage totals the completions made with one model. Tokens
+// are estimated for providers that do not report usage.
+type ExecutionModelUsage struct {
+	Model              string  `json:"model"`
+	Completions        int     `json:"completions"`
+	PromptTokens       int     `json:"prompt_tokens"`
+	CompletionTokens   int     `json:"completion_tokens"`
+	CachedPromptTokens int     `json:"cached_prompt_tokens,omitempty"`
+	CostUSD            float64 `json:"cost_usd"`
+}
+
+// ExecutionReports returns the execution reports in transcript lines,
+// oldest first.
+func ExecutionReports(lines []string) []ExecutionReport {
+	var reports []ExecutionReport
+	for _, line := range lines {
+		var evt struct {
+			Type     EventType `json:"type"`
+			Metadata struct {
+				Report *ExecutionReport `json:"report"`
+			} `json:"metadata"`
+		}
+		if json.Unmarshal([]byte(line), &evt) != nil || evt.Type != EventTypeExecutionReport || evt.Metadata.Report == nil {
+			continue
+		}
+		reports = append(reports, *evt.Metadata.Report)
+	}
+	return reports
+}
@@ -48,13 +48,24 @@ func NewCoordinator(engine cognitive.Engine, retryMax int, retryBackoff time.Dur
 }
 
 type SubTaskResult struct {
-	ID      string
-	Success bool
-	Output  string
-	Error   error
+	ID          string
+	Description string
+	Success     bool
+	Output      string
+	Error       error
+	// Attempts counts engine runs; it is zero when the sub-task never ran,
+	// e.g. because a dependency failed.
+	Attempts int
+	// Cancelled is set when the run was cancelled before the sub-task
+	// finished or started.
+	Cancelled bool
+	StartedAt time.Time
+	Duration  time.Duration
 }
 
-// ExecuteDAG executes subtasks in deterministic topological batches.
+// ExecuteDAG executes subtasks in deterministic topological batches. When ctx
+// is cancelled, it returns ctx.Err() with a result for every sub-task: those
+// in flight or not yet started are marked Cancelled.
 func (c *Coordinator) ExecuteDAG(ctx context.Context, parentCtx *cognitive.CognitiveContext, subTasks []*SubTask) ([]SubTaskResult, error) {
 	if len(subTasks) == 0 {
 		return nil, nil
@@ -69,23 +80,20 @@ func (c *Coordinator) ExecuteDAG(ctx context.Context, parentCtx *cognitive.Cogni
 	ordered := make([]SubTaskResult, 0, len(subTasks))
 
 	for _, batch := range batches {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+		if ctx.Err() != nil {
+			for _, t := range batch {
+				ordered = append(ordered, SubTaskResult{ID: t.ID, Description: t.Description, Error: ctx.Err(), Cancelled: true})
+			}
+			continue
 		}
 
-		batchResults, err := c.executeBatch(ctx, parentCtx, batch, resultsByID)
-		if err != nil {
-			return nil, err
-		}
-		for _, res := range batchResults {
+		for _, res := range c.executeBatch(ctx, parentCtx, batch, resultsByID) {
 			resultsByID[res.ID] = res
 			ordered = append(ordered, res)
 		}
 	}
 
-	return ordered, nil
+	return ordered, ctx.Err()
 }
 
 func (c *Coordinator) executeBatch(
@@ -93,7 +101,7 @@ func (c *Coordinator) executeBatch(
 	parentCtx *cognitive.CognitiveContext,
 	batch []*SubTask,
 	resultsByID map[string]SubTaskResult,
-) ([]SubTaskResult, error) {
+) []SubTaskResult {
 	sem := make(chan struct{}, c.maxParallel)
 	batchResultByID := make(map[string]SubTaskResult, len(batch))
 
@@ -109,7 +117,7 @@ func (c *Coordinator) executeBatch(
 			select {
 			case <-ctx.Done():
 				mu.Lock()
-				batchResultByID[t.ID] = SubTaskResult{ID: t.ID, Success: false, Error: ctx.Err()}
+				batchResultByID[t.ID] = SubTaskResult{ID: t.ID, Description: t.Description, Success: false, Error: ctx.Err(), Cancelled: true}
 				mu.Unlock()
 				return
 			case sem <- struct{}{}:
@@ -126,17 +134,11 @@ func (c *Coordinator) executeBatch(
 
 	wg.Wait()
 
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
 	ordered := make([]SubTaskResult, 0, len(batch))
 	for _, task := range batch {
 		ordered = append(ordered, batchResultByID[task.ID])
 	}
-	return ordered, nil
+	return ordered
 }
 
 func (c *Coordinator) executeTask(
@@ -144,6 +146,26 @@ func (c *Coordinator) executeTask(
 	parentCtx *cognitive.CognitiveContext,
 	t *SubTask,
 	resultsByID map[string]SubTaskResult,
+) SubTaskResult {
+	started := time.Now()
+	res := c.runTask(ctx, parentCtx, t, resultsByID)
+	res.ID = t.ID
+	res.Description = t.Description
+	res.StartedAt = started
+	res.Duration = time.Since(started)
+	// A failure once ctx is done is the cancellation surfacing through the
+	// engine, e.g. as an aborted model request.
+	if !res.Success && ctx.Err() != nil {
+		res.Cancelled = true
+	}
+	return res
+}
+
+func (c *Coordinator) runTask(
+	ctx context.Context,
+	parentCtx *cognitive.CognitiveContext,
+	t *SubTask,
+	resultsByID map[string]SubTaskResult,
 ) SubTaskResult {
 	for _, depID := range t.Dependencies {
 		depRes, ok := resultsByID[depID]
@@ -182,14 +204,14 @@ func (c *Coordinator) executeTask(
 	for attempt := 0; attempt < c.retryMax; attempt++ {
 		select {
 		case <-ctx.Done():
-			return SubTaskResult{ID: t.ID, Success: false, Error: ctx.Err()}
+			return SubTaskResult{ID: t.ID, Success: false, Error: ctx.Err(), Attempts: attempt}
 		default:
 		}
 
 		res, err := c.engine.Run(ctx, t.Description, subCtxOpts)
 		if err == nil {
 			slog.Info("Sub-task completed", "id", t.ID)
-			return SubTaskResult{ID: t.ID, Success: true, Output: res.Content}
+			return SubTaskResult{ID: t.ID, Success: true, Output: res.Content, Attempts: attempt + 1}
 		}
 
 		lastErr = err
@@ -199,14 +221,15 @@ func (c *Coordinator) executeTask(
 			backoff := c.retryBackoff * time.Duration(attempt+1)
 			select {
 			case <-ctx.Done():
-				return SubTaskResult{ID: t.ID, Success: false, Error: ctx.Err()}
+				return SubTaskResult{ID: t.ID, Success: false, Error: ctx.Err(), Attempts: attempt + 1}
 			case <-time.After(backoff):
 			}
+			cognitive.NotifyRetry(ctx)
 		}
 	}
 
 	slog.Error("Sub-task failed", "id", t.ID, "error", lastErr)
-	return SubTaskResult{ID: t.ID, Success: false, Error: lastErr}
+	return SubTaskResult{ID: t.ID, Success: false, Error: lastErr, Attempts: c.retryMax}
 }
 
 func resolveExecutionBatches(subTasks []*SubTask) ([][]*SubTask, error) {
@@ -136,3 +136,46 @@ func TestCoordinator_ExecuteDAG_DependencyFailurePropagation(t *testing.T) {
 		t.Fatalf("unexpected dependency error: %v", results[1].Error)
 	}
 }
+
+func TestCoordinator_ExecuteDAG_CancelMarksRemainingSubTasks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine := &coordinatorTestEngine{
+		runFn: func(ctx context.Context, goal string) (*cognitive.Result, error) {
+			if goal == "task-a" {
+				return &cognitive.Result{Content: "a done"}, nil
+			}
+			cancel()
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	coord := NewCoordinator(engine, 3, time.Millisecond, 1)
+
+	subTasks := []*SubTask{
+		{ID: "a", Description: "task-a"},
+		{ID: "b", Description: "task-b", Dependencies: []string{"a"}},
+		{ID: "c", Description: "task-c", Dependencies: []string{"b"}},
+	}
+	results, err := coord.ExecuteDAG(ctx, &cognitive.CognitiveContext{SessionID: "session-3"}, subTasks)
+	if err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("result count = %d, want 3", len(results))
+	}
+	if !results[0].Success || results[0].Cancelled {
+		t.Fatalf("task a = %+v, want succeeded", results[0])
+	}
+	if !results[1].Cancelled || results[1].Attempts != 1 {
+		t.Fatalf("task b = %+v, want cancelled after one attempt", results[1])
+	}
+	if !results[2].Cancelled || results[2].Attempts != 0 {
+		t.Fatalf("task c = %+v, want cancelled before starting", results[2])
+	}
+
+	report := BuildTaskReport("ship", results, time.Now())
+	if report.Status != ReportStatusCancelled || report.SubTasks[1].Status != SubTaskStatusCancelled {
+		t.Fatalf("report = %+v", report)
+	}
+}
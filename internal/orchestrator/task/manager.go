@@ -6,14 +6,17 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/featureflag"
 	"github.com/harunnryd/heike/internal/model/contract"
 	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/policy"
 	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/tool"
 )
@@ -38,11 +41,33 @@ type DefaultTaskManager struct {
 	session     session.Manager
 	tools       []tool.ToolDescriptor
 	toolBroker  ToolBroker
+	llmBroker   *LLMToolBroker
+	features    *featureflag.Flags
 	skills      SkillProvider
 	response    ResponseSink
 	maxSubTasks int
+	verbose     bool
+	postMortem  bool
+	memory      cognitive.MemoryManager
+	quotaRetry  QuotaRetryFunc
+
+	synthesizer     cognitive.LLMClient
+	synthesisPrompt string
+
+	skillMaxSelected int
+	skillMaxChars    int
+	tokenBudget      int
+
+	selections toolSelectionLog
 }
 
+// DebugStageToolSelection reports tool broker picks with their scores
+const DebugStageToolSelection = "tool_selection"
+
+// DebugStageSynthesis carries the raw sub-task results a reply was
+// synthesized from
+const DebugStageSynthesis = "synthesis"
+
 func NewManager(
 	e cognitive.Engine,
 	d TaskDecomposer,
@@ -70,15 +95,62 @@ func NewManager(
 		skills:      skills,
 		response:    responseSink,
 		maxSubTasks: maxSubTasks,
+
+		skillMaxSelected: config.DefaultOrchestratorSkillsMaxSelected,
+		skillMaxChars:    config.DefaultOrchestratorSkillsMaxChars,
+		tokenBudget:      config.DefaultOrchestratorTokenBudget,
+	}
+}
+
+// SetVerbose streams internal steps to every session, not just those with /debug on.
+func (tm *DefaultTaskManager) SetVerbose(verbose bool) {
+	tm.verbose = verbose
+}
+
+// SetSkillLimits sets how many skills are injected per turn and how many
+// characters of guidance each keeps. Non-positive values keep the defaults.
+func (tm *DefaultTaskManager) SetSkillLimits(maxSelected, maxChars int) {
+	if maxSelected > 0 {
+		tm.skillMaxSelected = maxSelected
+	}
+	if maxChars > 0 {
+		tm.skillMaxChars = maxChars
 	}
 }
 
+// SetTokenBudget sets the context budget the skill context is fitted to. It
+// should match the engine's.
+func (tm *DefaultTaskManager) SetTokenBudget(n int) {
+	if n > 0 {
+		tm.tokenBudget = n
+	}
+}
+
+// SetLLMToolBroker sets the broker used instead of the tool broker while the
+// llm_tool_selection feature flag is on.
+func (tm *DefaultTaskManager) SetLLMToolBroker(broker *LLMToolBroker) {
+	tm.llmBroker = broker
+}
+
+// SetFeatureFlags sets the flags consulted for each request.
+func (tm *DefaultTaskManager) SetFeatureFlags(flags *featureflag.Flags) {
+	tm.features = flags
+}
+
+// SetPostMortem enables post-mortems for failed goals. When memory is non-nil,
+// each post-mortem is also remembered so the pattern is recalled next time.
+func (tm *DefaultTaskManager) SetPostMortem(enabled bool, memory cognitive.MemoryManager) {
+	tm.postMortem = enabled
+	tm.memory = memory
+}
+
 func (tm *DefaultTaskManager) HandleRequest(ctx context.Context, sessionID string, goal string) error {
 	// Build Context
 	cCtx, err := tm.session.GetContext(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to load context: %w", err)
 	}
+	tm.attachDebug(ctx, cCtx)
 	tm.applySkillContext(cCtx, goal)
 
 	// Decide: Simple or Complex?
@@ -94,12 +166,29 @@ func (tm *DefaultTaskManager) executeSimpleTask(ctx context.Context, cCtx *cogni
 
 	// Reuse Cognitive Engine directly
 	result, err := tm.engine.Run(ctx, goal, func(c *cognitive.CognitiveContext) {
+		budget := c.TokenBudget
 		*c = *cCtx // Inject session context
-		tm.applyToolDefinitions(c, goal)
+		if c.TokenBudget <= 0 {
+			c.TokenBudget = budget
+		}
+		tm.applyToolDefinitions(ctx, c, goal)
 	})
 
 	if err != nil {
-		return tm.persistAndSend(ctx, cCtx.SessionID, "system", fmt.Sprintf("Error: %v", err))
+		if ctx.Err() != nil {
+			return tm.recordCancelled(ctx, cCtx.SessionID, err)
+		}
+		if tm.deferOnQuota(ctx, cCtx.SessionID, goal, err) {
+			return nil
+		}
+		if o := observerFrom(ctx); o != nil {
+			o.ObserveError(err)
+		}
+		if sendErr := tm.persistAndSend(ctx, cCtx.SessionID, "system", fmt.Sprintf("Error: %v", err)); sendErr != nil {
+			return sendErr
+		}
+		tm.recordPostMortem(ctx, cCtx.SessionID, BuildPostMortem(goal, err))
+		return nil
 	}
 
 	return tm.persistAndSend(ctx, cCtx.SessionID, "assistant", result.Content)
@@ -107,10 +196,13 @@ func (tm *DefaultTaskManager) executeSimpleTask(ctx context.Context, cCtx *cogni
 
 func (tm *DefaultTaskManager) executeComplexTask(ctx context.Context, cCtx *cognitive.CognitiveContext, goal string) error {
 	slog.Info("Executing complex task", "goal", goal)
-	tm.applyToolDefinitions(cCtx, goal)
+	tm.applyToolDefinitions(ctx, cCtx, goal)
 
 	subTasks, err := tm.decomposer.Decompose(ctx, goal)
 	if err != nil {
+		if tm.deferOnQuota(ctx, cCtx.SessionID, goal, err) {
+			return nil
+		}
 		return err
 	}
 	if len(subTasks) > tm.maxSubTasks {
@@ -122,26 +214,62 @@ func (tm *DefaultTaskManager) executeComplexTask(ctx context.Context, cCtx *cogn
 
 	tm.session.AppendInteraction(ctx, cCtx.SessionID, "system", fmt.Sprintf("Task decomposed into %d sub-tasks.", len(subTasks)))
 
+	started := time.Now()
 	results, err := tm.coordinator.ExecuteDAG(ctx, cCtx, subTasks)
+	if err != nil && ctx.Err() != nil && len(results) > 0 {
+		tm.persistReport(context.WithoutCancel(ctx), cCtx.SessionID, BuildTaskReport(goal, results, started))
+		return tm.recordCancelled(ctx, cCtx.SessionID, err)
+	}
 	if err != nil {
 		return fmt.Errorf("DAG execution failed: %w", err)
 	}
+	if quotaErr := onlyQuotaFailures(results); quotaErr != nil && tm.deferOnQuota(ctx, cCtx.SessionID, goal, quotaErr) {
+		return nil
+	}
 
-	// Aggregate results
-	var sb strings.Builder
-	sb.WriteString("Sub-task results:\n")
-	for _, res := range results {
-		status := "Success"
-		if !res.Success {
-			status = fmt.Sprintf("Failed (%v)", res.Error)
-		}
-		sb.WriteString(fmt.Sprintf("- Task %s: %s\n", res.ID, status))
-		if res.Output != "" {
-			sb.WriteString(fmt.Sprintf("  Output: %s\n", res.Output))
+	report := BuildTaskReport(goal, results, started)
+	failed := report.Status != ReportStatusCompleted
+	if o := observerFrom(ctx); o != nil {
+		for _, res := range results {
+			if !res.Success {
+				o.ObserveError(fmt.Errorf("sub-task %s: %w", res.ID, res.Error))
+			}
 		}
 	}
+	tm.persistReport(ctx, cCtx.SessionID, report)
+
+	if err := tm.persistAndSend(ctx, cCtx.SessionID, "assistant", tm.synthesize(ctx, cCtx, report)); err != nil {
+		return err
+	}
+	if failed {
+		tm.recordPostMortem(ctx, cCtx.SessionID, buildSubTaskPostMortem(goal, results))
+	}
+	return nil
+}
+
+// recordCancelled tells the session its task was cancelled and returns err.
+// ctx is already done, so the note is written without its cancellation.
+func (tm *DefaultTaskManager) recordCancelled(ctx context.Context, sessionID string, err error) error {
+	if sendErr := tm.persistAndSend(context.WithoutCancel(ctx), sessionID, "system", "Task cancelled."); sendErr != nil {
+		slog.Warn("Failed to persist cancellation", "session", sessionID, "error", sendErr)
+	}
+	return err
+}
 
-	return tm.persistAndSend(ctx, cCtx.SessionID, "assistant", sb.String())
+// recordPostMortem appends the post-mortem to the transcript and, when a
+// memory manager is configured, remembers it. Failures are only logged.
+func (tm *DefaultTaskManager) recordPostMortem(ctx context.Context, sessionID string, pm *PostMortem) {
+	if !tm.postMortem || pm == nil {
+		return
+	}
+	if err := tm.session.AppendInteraction(ctx, sessionID, "system", pm.Markdown()); err != nil {
+		slog.Warn("Failed to persist post-mortem", "session", sessionID, "error", err)
+	}
+	if tm.memory != nil {
+		if err := tm.memory.Remember(ctx, pm.MemoryFact()); err != nil {
+			slog.Warn("Failed to remember post-mortem", "session", sessionID, "error", err)
+		}
+	}
 }
 
 func (tm *DefaultTaskManager) persistAndSend(ctx context.Context, sessionID, role, content string) error {
@@ -152,6 +280,9 @@ func (tm *DefaultTaskManager) persistAndSend(ctx context.Context, sessionID, rol
 	if role != "assistant" && role != "system" {
 		return nil
 	}
+	if o := observerFrom(ctx); o != nil {
+		o.ObserveResponse(role, content)
+	}
 	if tm.response == nil {
 		return nil
 	}
@@ -162,20 +293,30 @@ func (tm *DefaultTaskManager) persistAndSend(ctx context.Context, sessionID, rol
 	return nil
 }
 
-func (tm *DefaultTaskManager) applyToolDefinitions(cCtx *cognitive.CognitiveContext, goal string) {
+func (tm *DefaultTaskManager) applyToolDefinitions(ctx context.Context, cCtx *cognitive.CognitiveContext, goal string) {
 	if cCtx == nil {
 		return
 	}
 
-	selected := append([]tool.ToolDescriptor(nil), tm.tools...)
+	selected := sessionTools(tm.tools, cCtx.Metadata)
+	available := len(selected)
 	selectionDetails := []ToolSelectionDetail(nil)
-	if tm.toolBroker != nil {
+	broker := ToolSelectionBrokerNone
+	if tm.llmBroker != nil && tm.features.Enabled(featureflag.LLMToolSelection) {
+		result := tm.llmBroker.SelectWithContext(ctx, goal, selected)
+		if len(result.Tools) > 0 {
+			selected = result.Tools
+		}
+		selectionDetails = result.Details
+		broker = ToolSelectionBrokerLLM
+	} else if tm.toolBroker != nil {
 		if explainable, ok := tm.toolBroker.(ExplainableToolBroker); ok {
 			result := explainable.SelectWithMetadata(goal, selected)
 			if len(result.Tools) > 0 {
 				selected = result.Tools
 			}
 			selectionDetails = result.Details
+			broker = ToolSelectionBrokerHeuristic
 		} else {
 			brokerSelected := tm.toolBroker.Select(goal, selected)
 			if len(brokerSelected) > 0 {
@@ -186,6 +327,26 @@ func (tm *DefaultTaskManager) applyToolDefinitions(cCtx *cognitive.CognitiveCont
 
 	defs := toolDefinitionsFromDescriptors(selected)
 	cCtx.AvailableTools = defs
+	recorded := selectionDetails
+	if len(recorded) == 0 {
+		for _, def := range defs {
+			recorded = append(recorded, ToolSelectionDetail{Name: def.Name})
+		}
+	}
+	tm.selections.record(cCtx.SessionID, ToolSelection{
+		Goal:       goal,
+		Broker:     broker,
+		Available:  available,
+		Details:    recorded,
+		SelectedAt: time.Now(),
+	})
+	if cCtx.Debug != nil {
+		summary := formatSelectionDetails(selectionDetails, len(selectionDetails))
+		if len(summary) == 0 {
+			summary = toolNames(defs)
+		}
+		cCtx.Debug(DebugStageToolSelection, strings.Join(summary, "\n"))
+	}
 
 	slog.Debug("Tool selection applied",
 		"goal_preview", previewString(goal, 80),
@@ -195,6 +356,39 @@ func (tm *DefaultTaskManager) applyToolDefinitions(cCtx *cognitive.CognitiveCont
 		"selection_details", formatSelectionDetails(selectionDetails, 5))
 }
 
+// sessionTools returns the registry tools the session may use: all of them,
+// or those named in its tools_allow metadata.
+func sessionTools(tools []tool.ToolDescriptor, metadata map[string]string) []tool.ToolDescriptor {
+	allow, restricted := policy.SessionToolAllowlist(metadata)
+	if !restricted {
+		return append([]tool.ToolDescriptor(nil), tools...)
+	}
+	allowed := make([]tool.ToolDescriptor, 0, len(allow))
+	for _, descriptor := range tools {
+		if policy.ToolAllowed(allow, descriptor.Definition.Name) {
+			allowed = append(allowed, descriptor)
+		}
+	}
+	return allowed
+}
+
+// attachDebug wires the cognitive debug hook to the session transcript when
+// verbose mode is enabled globally or the session has /debug on.
+func (tm *DefaultTaskManager) attachDebug(ctx context.Context, cCtx *cognitive.CognitiveContext) {
+	if cCtx == nil {
+		return
+	}
+	if !tm.verbose && cCtx.Metadata[session.DebugMetadataKey] != "true" {
+		return
+	}
+	sessionID := cCtx.SessionID
+	cCtx.Debug = func(stage, content string) {
+		if err := tm.session.AppendDebug(ctx, sessionID, stage, content); err != nil {
+			slog.Warn("Failed to persist debug event", "session", sessionID, "stage", stage, "error", err)
+		}
+	}
+}
+
 func previewString(s string, n int) string {
 	s = strings.TrimSpace(s)
 	if n <= 0 || len(s) <= n {
@@ -237,19 +431,25 @@ func formatSelectionDetails(details []ToolSelectionDetail, limit int) []string {
 	return out
 }
 
-var skillMentionPattern = regexp.MustCompile(`\$([A-Za-z0-9_-]+)`)
+// skillMentionPattern matches $name, which asks for a skill, and !$name, which
+// keeps it out of the turn.
+var skillMentionPattern = regexp.MustCompile(`(!?)\$([A-Za-z0-9_-]+)`)
 
-const (
-	defaultSkillSelectionLimit = 4
-	maxSkillContentChars       = 600
-)
+// skillBudgetDivisor reserves 1/skillBudgetDivisor of the token budget for
+// the skill context.
+const skillBudgetDivisor = 4
+
+// minSkillGuidanceChars is the shortest guidance worth keeping; below it the
+// guidance is omitted.
+const minSkillGuidanceChars = 40
 
 func (tm *DefaultTaskManager) applySkillContext(cCtx *cognitive.CognitiveContext, goal string) {
 	if cCtx == nil || tm.skills == nil {
 		return
 	}
 
-	selected, err := tm.selectSkills(goal, defaultSkillSelectionLimit)
+	excluded := skill.ParseSkillList(cCtx.Metadata[skill.ExcludeMetadataKey])
+	selected, err := tm.selectSkills(goal, tm.skillMaxSelected, excluded)
 	if err != nil || len(selected) == 0 {
 		if err != nil {
 			slog.Warn("Skill selection failed", "error", err)
@@ -257,45 +457,125 @@ func (tm *DefaultTaskManager) applySkillContext(cCtx *cognitive.CognitiveContext
 		return
 	}
 
-	names := make([]string, 0, len(selected))
-	details := make([]string, 0, len(selected))
+	candidates := make([]*skill.Skill, 0, len(selected))
 	for _, item := range selected {
-		if item == nil {
-			continue
+		if item != nil && strings.TrimSpace(item.Name) != "" {
+			candidates = append(candidates, item)
 		}
-
-		name := strings.TrimSpace(item.Name)
-		if name == "" {
-			continue
-		}
-
-		names = append(names, name)
-		details = append(details, formatSkillContextLine(item))
 	}
-
-	if len(names) == 0 {
+	maxChars := tm.tokenBudget / skillBudgetDivisor * cognitive.CharsPerToken
+	kept, lines := fitSkillContext(candidates, tm.skillMaxChars, maxChars)
+	if len(kept) == 0 {
 		return
 	}
+	names := make([]string, 0, len(kept))
+	for _, item := range kept {
+		names = append(names, strings.TrimSpace(item.Name))
+	}
 
 	if cCtx.Metadata == nil {
 		cCtx.Metadata = make(map[string]string)
 	}
 	cCtx.AvailableSkills = names
-	cCtx.Metadata["skills_context"] = strings.Join(details, "\n")
+	cCtx.Metadata["skills_context"] = strings.Join(lines, "\n")
 
 	slog.Debug("Skill context applied",
 		"goal_preview", previewString(goal, 80),
 		"skills", names,
-		"count", len(names))
+		"count", len(names),
+		"dropped", len(candidates)-len(kept))
+}
+
+// fitSkillContext renders skills, best first, in at most maxChars (no limit
+// when non-positive). Guidance is shortened evenly before skills are dropped
+// from the end.
+func fitSkillContext(skills []*skill.Skill, guidanceChars, maxChars int) ([]*skill.Skill, []string) {
+	for n := len(skills); n > 0; n-- {
+		kept := skills[:n]
+		headers := make([]string, n)
+		guidance := make([]string, n)
+		total := n - 1 // newlines
+		for i, item := range kept {
+			headers[i] = formatSkillContextLine(item)
+			guidance[i] = compactSkillContent(item.Content, guidanceChars)
+			total += len(headers[i]) + guidanceLen(guidance[i])
+		}
+		if maxChars <= 0 || total <= maxChars {
+			return kept, joinSkillLines(headers, guidance)
+		}
+
+		spare := maxChars - (n - 1)
+		for _, h := range headers {
+			spare -= len(h)
+		}
+		if spare < 0 {
+			continue
+		}
+		// Share the spare characters evenly, shortest guidance first so what
+		// it leaves unused goes to the longer ones.
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return len(guidance[order[a]]) < len(guidance[order[b]])
+		})
+		for k, i := range order {
+			share := spare / (n - k)
+			if guidanceLen(guidance[i]) > share {
+				guidance[i] = truncateSkillGuidance(guidance[i], share-len(skillGuidancePrefix)-len("..."))
+			}
+			spare -= guidanceLen(guidance[i])
+		}
+		return kept, joinSkillLines(headers, guidance)
+	}
+	return nil, nil
+}
+
+const skillGuidancePrefix = " | guidance="
+
+func guidanceLen(guidance string) int {
+	if guidance == "" {
+		return 0
+	}
+	return len(skillGuidancePrefix) + len(guidance)
 }
 
-func (tm *DefaultTaskManager) selectSkills(goal string, limit int) ([]*skill.Skill, error) {
+func truncateSkillGuidance(guidance string, limit int) string {
+	if limit < minSkillGuidanceChars {
+		return ""
+	}
+	return guidance[:limit] + "..."
+}
+
+func joinSkillLines(headers, guidance []string) []string {
+	lines := make([]string, len(headers))
+	for i := range headers {
+		lines[i] = headers[i]
+		if guidance[i] != "" {
+			lines[i] += skillGuidancePrefix + guidance[i]
+		}
+	}
+	return lines
+}
+
+// selectSkills picks up to limit skills for goal: mentioned skills first, then
+// the most relevant. Skills in excluded or excluded with !$name are skipped,
+// as is any skill that conflicts with one already picked.
+func (tm *DefaultTaskManager) selectSkills(goal string, limit int, excluded []string) ([]*skill.Skill, error) {
 	if tm.skills == nil || limit <= 0 {
 		return nil, nil
 	}
 
+	mentions, exclusions := extractSkillMentions(goal)
+	seen := make(map[string]struct{}, limit+len(excluded)+len(exclusions))
+	for _, names := range [][]string{excluded, exclusions} {
+		for _, name := range names {
+			seen[strings.ToLower(name)] = struct{}{}
+		}
+	}
+
 	selected := make([]*skill.Skill, 0, limit)
-	seen := make(map[string]struct{}, limit)
 	add := func(candidate *skill.Skill) {
 		if candidate == nil {
 			return
@@ -308,10 +588,16 @@ func (tm *DefaultTaskManager) selectSkills(goal string, limit int) ([]*skill.Ski
 			return
 		}
 		seen[name] = struct{}{}
+		for _, picked := range selected {
+			if candidate.ConflictsWith(picked) {
+				slog.Debug("Skill skipped for conflict", "skill", candidate.Name, "conflicts_with", picked.Name)
+				return
+			}
+		}
 		selected = append(selected, candidate)
 	}
 
-	for _, mention := range extractSkillMentions(goal) {
+	for _, mention := range mentions {
 		if len(selected) >= limit {
 			break
 		}
@@ -360,33 +646,53 @@ func (tm *DefaultTaskManager) resolveSkillMention(name string) (*skill.Skill, er
 	return alternatives[0], nil
 }
 
-func extractSkillMentions(goal string) []string {
+// extractSkillMentions returns the skills goal asks for with $name and those
+// it excludes with !$name. An exclusion wins over a mention of the same skill.
+func extractSkillMentions(goal string) ([]string, []string) {
 	matches := skillMentionPattern.FindAllStringSubmatch(goal, -1)
 	if len(matches) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	var mentions, exclusions []string
+	excluded := make(map[string]struct{}, len(matches))
+	for _, match := range matches {
+		if len(match) < 3 || match[1] == "" {
+			continue
+		}
+		name := strings.TrimSpace(match[2])
+		key := strings.ToLower(name)
+		if _, exists := excluded[key]; exists {
+			continue
+		}
+		excluded[key] = struct{}{}
+		exclusions = append(exclusions, name)
 	}
 
-	out := make([]string, 0, len(matches))
 	seen := make(map[string]struct{}, len(matches))
 	for _, match := range matches {
-		if len(match) < 2 {
+		if len(match) < 3 || match[1] != "" {
 			continue
 		}
-		name := strings.TrimSpace(match[1])
+		name := strings.TrimSpace(match[2])
 		key := strings.ToLower(name)
 		if name == "" {
 			continue
 		}
+		if _, exists := excluded[key]; exists {
+			continue
+		}
 		if _, exists := seen[key]; exists {
 			continue
 		}
 		seen[key] = struct{}{}
-		out = append(out, name)
+		mentions = append(mentions, name)
 	}
 
-	return out
+	return mentions, exclusions
 }
 
+// formatSkillContextLine renders a skill without its guidance.
 func formatSkillContextLine(item *skill.Skill) string {
 	if item == nil {
 		return ""
@@ -407,20 +713,15 @@ func formatSkillContextLine(item *skill.Skill) string {
 		parts = append(parts, fmt.Sprintf("tags=%s", strings.Join(item.Tags, ", ")))
 	}
 
-	content := compactSkillContent(item.Content)
-	if content != "" {
-		parts = append(parts, "guidance="+content)
-	}
-
 	return strings.Join(parts, " | ")
 }
 
-func compactSkillContent(content string) string {
+func compactSkillContent(content string, maxChars int) string {
 	clean := strings.Join(strings.Fields(strings.TrimSpace(content)), " ")
-	if len(clean) <= maxSkillContentChars {
+	if maxChars <= 0 || len(clean) <= maxChars {
 		return clean
 	}
-	return clean[:maxSkillContentChars] + "..."
+	return clean[:maxChars] + "..."
 }
 
 // Re-using decomposition logic but decoupled
@@ -477,7 +778,7 @@ GOAL: %s
 %s
 `, d.promptCfg.System, task, d.promptCfg.Requirements)
 
-	response, err := d.llm.Complete(ctx, prompt)
+	response, err := cognitive.CompleteJSON(ctx, d.llm, prompt, decompositionResponseFormat)
 	if err != nil {
 		return nil, fmt.Errorf("decomposition failed: %w", err)
 	}
@@ -501,6 +802,35 @@ const (
 	decompositionParseModeDefault    decompositionParseMode = "goal_default"
 )
 
+// decompositionResponseFormat asks for {"sub_tasks": [...]}; schema mode
+// requires an object at the root, which subTaskPayload already accepts.
+var decompositionResponseFormat = contract.ResponseFormat{
+	Type: contract.ResponseFormatJSONSchema,
+	Name: "decomposition",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"sub_tasks": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id":          map[string]interface{}{"type": "string"},
+						"description": map[string]interface{}{"type": "string"},
+						"priority":    map[string]interface{}{"type": "integer"},
+						"dependencies": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "string"},
+						},
+					},
+					"required": []string{"id", "description"},
+				},
+			},
+		},
+		"required": []string{"sub_tasks"},
+	},
+}
+
 type subTaskPayload struct {
 	SubTasksSnake []SubTask `json:"sub_tasks"`
 	SubTasks      []SubTask `json:"subtasks"`
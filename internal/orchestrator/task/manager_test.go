@@ -2,15 +2,18 @@ package task
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/harunnryd/heike/internal/cognitive"
 	"github.com/harunnryd/heike/internal/model/contract"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
 	"github.com/harunnryd/heike/internal/skill"
 	"github.com/harunnryd/heike/internal/tool"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type stubEngine struct {
@@ -37,7 +40,8 @@ func (d *stubDecomposer) Decompose(ctx context.Context, task string) ([]*SubTask
 }
 
 type stubSessionManager struct {
-	context *cognitive.CognitiveContext
+	context     *cognitive.CognitiveContext
+	debugStages []string
 }
 
 func (s *stubSessionManager) GetContext(ctx context.Context, sessionID string) (*cognitive.CognitiveContext, error) {
@@ -52,6 +56,15 @@ func (s *stubSessionManager) PersistTool(ctx context.Context, sessionID, toolCal
 	return nil
 }
 
+func (s *stubSessionManager) AppendDebug(ctx context.Context, sessionID, stage, content string) error {
+	s.debugStages = append(s.debugStages, stage)
+	return nil
+}
+
+func (s *stubSessionManager) AppendEvent(ctx context.Context, sessionID string, evt session.Event) error {
+	return nil
+}
+
 type stubResponseSink struct {
 	lastSessionID string
 	lastContent   string
@@ -122,11 +135,60 @@ func TestTaskManager_AppliesToolBrokerBudget(t *testing.T) {
 		4,
 		&stubResponseSink{},
 	)
+	_, ok := manager.LastToolSelection("session-2")
+	assert.False(t, ok)
+
 	err := manager.HandleRequest(context.Background(), "session-2", "Research AI updates on the web")
 	assert.NoError(t, err)
 	if assert.NotNil(t, engine.capturedContext) {
 		assert.Len(t, engine.capturedContext.AvailableTools, 1)
 	}
+
+	sel, ok := manager.LastToolSelection("session-2")
+	if assert.True(t, ok) {
+		assert.Equal(t, ToolSelectionBrokerHeuristic, sel.Broker)
+		assert.Equal(t, 3, sel.Available)
+		assert.Equal(t, "Research AI updates on the web", sel.Goal)
+		if assert.Len(t, sel.Details, 1) {
+			assert.Equal(t, engine.capturedContext.AvailableTools[0].Name, sel.Details[0].Name)
+			assert.NotEmpty(t, sel.Details[0].Reasons)
+		}
+	}
+}
+
+func TestTaskManager_LimitsToolsToSessionAllowlist(t *testing.T) {
+	engine := &stubEngine{}
+	sessionManager := &stubSessionManager{
+		context: &cognitive.CognitiveContext{
+			SessionID: "session-untrusted",
+			Metadata:  map[string]string{"tools_allow": "open,not_registered"},
+		},
+	}
+
+	tools := []tool.ToolDescriptor{
+		{Definition: contract.ToolDef{Name: "search_query", Description: "Search the web"}},
+		{Definition: contract.ToolDef{Name: "open", Description: "Open web pages"}},
+		{Definition: contract.ToolDef{Name: "exec_command", Description: "Execute shell commands"}},
+	}
+
+	manager := NewManager(
+		engine,
+		&stubDecomposer{},
+		sessionManager,
+		tools,
+		NewDefaultToolBroker(10),
+		nil,
+		3,
+		time.Second,
+		10,
+		4,
+		&stubResponseSink{},
+	)
+	err := manager.HandleRequest(context.Background(), "session-untrusted", "Run a shell command")
+	assert.NoError(t, err)
+	if assert.NotNil(t, engine.capturedContext) {
+		assert.Equal(t, []string{"open"}, toolNames(engine.capturedContext.AvailableTools))
+	}
 }
 
 func TestTaskManager_InjectsRelevantSkillsIntoContext(t *testing.T) {
@@ -193,3 +255,95 @@ func TestTaskManager_SendsFinalResponse(t *testing.T) {
 	assert.Equal(t, "session-send", sink.lastSessionID)
 	assert.Equal(t, "ok", sink.lastContent)
 }
+
+func TestTaskManager_StreamsDebugStepsWhenSessionDebugEnabled(t *testing.T) {
+	tools := []tool.ToolDescriptor{
+		{Definition: contract.ToolDef{Name: "search_query", Description: "Search the web"}},
+	}
+	newManager := func(sessionManager *stubSessionManager) *DefaultTaskManager {
+		return NewManager(
+			&stubEngine{},
+			&stubDecomposer{},
+			sessionManager,
+			tools,
+			NewDefaultToolBroker(10),
+			nil,
+			3,
+			time.Second,
+			10,
+			4,
+			&stubResponseSink{},
+		)
+	}
+
+	quiet := &stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "quiet", Metadata: map[string]string{}},
+	}
+	assert.NoError(t, newManager(quiet).HandleRequest(context.Background(), "quiet", "Search the web"))
+	assert.Empty(t, quiet.debugStages)
+
+	debug := &stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "debug", Metadata: map[string]string{"debug": "true"}},
+	}
+	assert.NoError(t, newManager(debug).HandleRequest(context.Background(), "debug", "Search the web"))
+	assert.Contains(t, debug.debugStages, DebugStageToolSelection)
+
+	verbose := &stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "verbose", Metadata: map[string]string{}},
+	}
+	manager := newManager(verbose)
+	manager.SetVerbose(true)
+	assert.NoError(t, manager.HandleRequest(context.Background(), "verbose", "Search the web"))
+	assert.Contains(t, verbose.debugStages, DebugStageToolSelection)
+}
+
+func TestFitSkillContext_TruncatesGuidanceBeforeDroppingSkills(t *testing.T) {
+	skills := []*skill.Skill{
+		{Name: "deploy", Description: "Ship builds", Content: strings.Repeat("a", 300)},
+		{Name: "notes", Description: "Write notes", Content: "Keep it short."},
+		{Name: "review", Description: "Review diffs", Content: strings.Repeat("b", 300)},
+	}
+
+	kept, lines := fitSkillContext(skills, 600, 0)
+	require.Len(t, kept, 3)
+	assert.Equal(t, "- deploy: Ship builds | guidance="+strings.Repeat("a", 300), lines[0])
+
+	kept, lines = fitSkillContext(skills, 600, 400)
+	require.Len(t, kept, 3, "guidance is shortened before skills are dropped")
+	assert.LessOrEqual(t, len(strings.Join(lines, "\n")), 400)
+	assert.Equal(t, "- notes: Write notes | guidance=Keep it short.", lines[1])
+	assert.True(t, strings.HasSuffix(lines[0], "..."))
+	assert.True(t, strings.HasSuffix(lines[2], "..."))
+
+	kept, lines = fitSkillContext(skills, 600, 60)
+	require.Len(t, kept, 2, "skills are dropped from the end once headers no longer fit")
+	assert.Equal(t, []string{"- deploy: Ship builds", "- notes: Write notes"}, lines)
+}
+
+func TestSelectSkills_RespectsExclusionsAndConflicts(t *testing.T) {
+	registry := skill.NewRegistry()
+	registry.Register(&skill.Skill{Name: "terse", Description: "Answer tersely", Tags: []string{"style"}, Conflicts: []string{"verbose"}})
+	registry.Register(&skill.Skill{Name: "verbose", Description: "Answer with full detail", Tags: []string{"style"}})
+	registry.Register(&skill.Skill{Name: "deploy", Description: "Ship builds", Tags: []string{"deploy"}})
+	tm := &DefaultTaskManager{skills: registry}
+
+	names := func(skills []*skill.Skill) []string {
+		out := make([]string, 0, len(skills))
+		for _, s := range skills {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+
+	selected, err := tm.selectSkills("Use $verbose and $terse", 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"verbose"}, names(selected), "a skill conflicting with an earlier pick is skipped")
+
+	selected, err = tm.selectSkills("Use $verbose and $terse but !$verbose", 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terse"}, names(selected))
+
+	selected, err = tm.selectSkills("Use $deploy and $terse", 4, []string{"deploy"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terse"}, names(selected), "session exclusions apply to mentions")
+}
@@ -0,0 +1,27 @@
+package task
+
+import "context"
+
+// Observer receives what a request produced, for callers that need the
+// outcome of a turn and not only its transcript. Sub-tasks run in parallel,
+// so implementations must be safe for concurrent use.
+type Observer interface {
+	// ObserveResponse is called for each assistant or system message sent
+	// to the session.
+	ObserveResponse(role, content string)
+	// ObserveError is called for failures that are reported to the session
+	// instead of being returned.
+	ObserveError(err error)
+}
+
+type observerKey struct{}
+
+// WithObserver returns a context whose requests report to o.
+func WithObserver(ctx context.Context, o Observer) context.Context {
+	return context.WithValue(ctx, observerKey{}, o)
+}
+
+func observerFrom(ctx context.Context) Observer {
+	o, _ := ctx.Value(observerKey{}).(Observer)
+	return o
+}
@@ -0,0 +1,164 @@
+package task
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// repeatedToolFailureThreshold is the failure count at which a tool is called
+// out as a repeated failure pattern.
+const repeatedToolFailureThreshold = 2
+
+// PostMortem summarizes why a goal failed and what to change next time.
+type PostMortem struct {
+	Goal        string
+	Failure     string
+	Attempts    []cognitive.Attempt
+	FailedTools map[string]int
+	Suggestions []string
+}
+
+// BuildPostMortem derives a post-mortem from a failed run. Attempts are taken
+// from the cognitive error when available.
+func BuildPostMortem(goal string, err error) *PostMortem {
+	return buildPostMortem(goal, err, attemptsFromError(err))
+}
+
+// buildSubTaskPostMortem merges the failures of a decomposed goal.
+func buildSubTaskPostMortem(goal string, results []SubTaskResult) *PostMortem {
+	var errs []error
+	var attempts []cognitive.Attempt
+	for _, res := range results {
+		if res.Success {
+			continue
+		}
+		err := res.Error
+		if err == nil {
+			err = fmt.Errorf("sub-task %s failed", res.ID)
+		}
+		errs = append(errs, fmt.Errorf("sub-task %s: %w", res.ID, err))
+		attempts = append(attempts, attemptsFromError(err)...)
+	}
+	return buildPostMortem(goal, stdErrors.Join(errs...), attempts)
+}
+
+func buildPostMortem(goal string, err error, attempts []cognitive.Attempt) *PostMortem {
+	pm := &PostMortem{
+		Goal:        goal,
+		Attempts:    attempts,
+		FailedTools: make(map[string]int),
+	}
+	if err != nil {
+		pm.Failure = err.Error()
+	}
+	for _, attempt := range pm.Attempts {
+		if attempt.Error != "" {
+			pm.FailedTools[attempt.Tool]++
+		}
+	}
+
+	var cogErr *cognitive.CognitiveError
+	stdErrors.As(err, &cogErr)
+	pm.Suggestions = suggestFixes(err, cogErr, pm.FailedTools)
+	return pm
+}
+
+func attemptsFromError(err error) []cognitive.Attempt {
+	var cogErr *cognitive.CognitiveError
+	if stdErrors.As(err, &cogErr) {
+		return cogErr.Attempts
+	}
+	return nil
+}
+
+func suggestFixes(err error, cogErr *cognitive.CognitiveError, failedTools map[string]int) []string {
+	var suggestions []string
+
+	switch {
+	case stdErrors.Is(err, heikeErrors.ErrApprovalRequired):
+		suggestions = append(suggestions, "Approve the pending tool call with /approve, or add the tool to governance.auto_allow.")
+	case stdErrors.Is(err, heikeErrors.ErrPermissionDenied):
+		suggestions = append(suggestions, "A tool was denied by policy; review governance.require_approval and governance.auto_allow.")
+	case stdErrors.Is(err, context.DeadlineExceeded):
+		suggestions = append(suggestions, "The run timed out; split the goal into smaller requests or raise the relevant timeout.")
+	case stdErrors.Is(err, heikeErrors.ErrInvalidModelOutput):
+		suggestions = append(suggestions, "The model returned unparseable structured output; raise orchestrator.structured_retry_max or switch models.default.")
+	case heikeErrors.IsRetryable(err):
+		suggestions = append(suggestions, "The failure looks transient; retry, or configure models.fallback for provider outages.")
+	}
+
+	if cogErr != nil && cogErr.Type == cognitive.ErrMaxTurns {
+		suggestions = append(suggestions, "The loop ran out of turns; raise orchestrator.max_turns or lower orchestrator.decompose_word_threshold so the goal is decomposed.")
+	}
+
+	for _, tool := range sortedToolNames(failedTools) {
+		if failedTools[tool] >= repeatedToolFailureThreshold {
+			suggestions = append(suggestions, fmt.Sprintf("Tool %s failed %d times; check its configuration or add a skill describing how to use it.", tool, failedTools[tool]))
+		}
+	}
+
+	if len(suggestions) == 0 {
+		suggestions = append(suggestions, "Rephrase the goal with more specific constraints or expected output.")
+	}
+	return suggestions
+}
+
+// Markdown renders the post-mortem for the session transcript.
+func (pm *PostMortem) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("## Post-mortem\n\n")
+	fmt.Fprintf(&sb, "**Goal:** %s\n\n", pm.Goal)
+	fmt.Fprintf(&sb, "**Failure:** %s\n\n", pm.Failure)
+
+	sb.WriteString("**What was tried:**\n")
+	if len(pm.Attempts) == 0 {
+		sb.WriteString("- No tool calls were made.\n")
+	}
+	for _, attempt := range pm.Attempts {
+		status := "ok"
+		if attempt.Error != "" {
+			status = "failed: " + attempt.Error
+		}
+		fmt.Fprintf(&sb, "- turn %d: %s (%s)\n", attempt.Turn, attempt.Tool, status)
+	}
+
+	if len(pm.FailedTools) > 0 {
+		sb.WriteString("\n**Failed tools:**\n")
+		for _, tool := range sortedToolNames(pm.FailedTools) {
+			fmt.Fprintf(&sb, "- %s (%d)\n", tool, pm.FailedTools[tool])
+		}
+	}
+
+	sb.WriteString("\n**Suggested changes:**\n")
+	for _, suggestion := range pm.Suggestions {
+		fmt.Fprintf(&sb, "- %s\n", suggestion)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// MemoryFact condenses the post-mortem into a single fact for long-term memory.
+func (pm *PostMortem) MemoryFact() string {
+	fact := fmt.Sprintf("Past failure for goal %q: %s.", previewString(pm.Goal, 120), pm.Failure)
+	if tools := sortedToolNames(pm.FailedTools); len(tools) > 0 {
+		fact += " Failing tools: " + strings.Join(tools, ", ") + "."
+	}
+	if len(pm.Suggestions) > 0 {
+		fact += " Next time: " + pm.Suggestions[0]
+	}
+	return fact
+}
+
+func sortedToolNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,101 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingEngine struct {
+	err error
+}
+
+func (f *failingEngine) Run(ctx context.Context, goal string, opts ...cognitive.ExecutionOption) (*cognitive.Result, error) {
+	return nil, f.err
+}
+
+type recordingSessionManager struct {
+	stubSessionManager
+	interactions []string
+}
+
+func (r *recordingSessionManager) AppendInteraction(ctx context.Context, sessionID string, role, content string) error {
+	r.interactions = append(r.interactions, role+": "+content)
+	return nil
+}
+
+type recordingMemory struct {
+	facts []string
+}
+
+func (m *recordingMemory) Retrieve(ctx context.Context, query string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *recordingMemory) Remember(ctx context.Context, fact string) error {
+	m.facts = append(m.facts, fact)
+	return nil
+}
+
+func maxTurnsError() error {
+	return &cognitive.CognitiveError{
+		Type:    cognitive.ErrMaxTurns,
+		Message: "Max cognitive turns reached",
+		Attempts: []cognitive.Attempt{
+			{Turn: 1, Tool: "exec_command", Error: "exit status 1"},
+			{Turn: 2, Tool: "exec_command", Error: "exit status 1"},
+			{Turn: 3, Tool: "read_file"},
+		},
+	}
+}
+
+func TestBuildPostMortem_SummarizesAttemptsAndSuggestions(t *testing.T) {
+	pm := BuildPostMortem("fix the build", maxTurnsError())
+
+	assert.Len(t, pm.Attempts, 3)
+	assert.Equal(t, map[string]int{"exec_command": 2}, pm.FailedTools)
+
+	markdown := pm.Markdown()
+	assert.Contains(t, markdown, "## Post-mortem")
+	assert.Contains(t, markdown, "turn 1: exec_command (failed: exit status 1)")
+	assert.Contains(t, markdown, "orchestrator.max_turns")
+	assert.Contains(t, markdown, "Tool exec_command failed 2 times")
+	assert.Contains(t, pm.MemoryFact(), "Failing tools: exec_command.")
+}
+
+func TestTaskManager_RecordsPostMortemOnFailure(t *testing.T) {
+	sessionManager := &recordingSessionManager{
+		stubSessionManager: stubSessionManager{context: &cognitive.CognitiveContext{SessionID: "s1"}},
+	}
+	memory := &recordingMemory{}
+	manager := NewManager(
+		&failingEngine{err: maxTurnsError()},
+		&stubDecomposer{},
+		sessionManager,
+		nil,
+		NewDefaultToolBroker(10),
+		nil,
+		3,
+		time.Second,
+		10,
+		4,
+		&stubResponseSink{},
+	)
+	manager.SetPostMortem(true, memory)
+
+	assert.NoError(t, manager.HandleRequest(context.Background(), "s1", "fix the build"))
+	if assert.Len(t, sessionManager.interactions, 2) {
+		assert.Contains(t, sessionManager.interactions[0], "system: Error:")
+		assert.Contains(t, sessionManager.interactions[1], "## Post-mortem")
+	}
+	assert.Len(t, memory.facts, 1)
+
+	sessionManager.interactions = nil
+	manager.SetPostMortem(false, nil)
+	assert.NoError(t, manager.HandleRequest(context.Background(), "s1", "fix the build"))
+	assert.Len(t, sessionManager.interactions, 1)
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+// QuotaRetryFunc schedules a later retry of the current turn after a provider
+// rate limit. It returns the delay and false when no retry was scheduled,
+// e.g. because the retry budget for the turn is spent.
+type QuotaRetryFunc func(ctx context.Context, sessionID string, goal string) (time.Duration, bool)
+
+// SetQuotaRetry enables deferring rate-limited turns instead of failing them.
+func (tm *DefaultTaskManager) SetQuotaRetry(retry QuotaRetryFunc) {
+	tm.quotaRetry = retry
+}
+
+// deferOnQuota schedules a retry for rate-limited failures and tells the
+// session when it will happen. It reports whether the failure was handled.
+func (tm *DefaultTaskManager) deferOnQuota(ctx context.Context, sessionID string, goal string, err error) bool {
+	if tm.quotaRetry == nil || !errors.Is(err, heikeErrors.ErrRateLimited) {
+		return false
+	}
+	delay, ok := tm.quotaRetry(ctx, sessionID, goal)
+	if !ok {
+		metrics.Inc("quota_retries_exhausted_total")
+		return false
+	}
+	metrics.Inc("quota_retries_scheduled_total")
+
+	notice := fmt.Sprintf("Provider rate-limited, retrying in %s.", delay.Round(time.Second))
+	if sendErr := tm.persistAndSend(ctx, sessionID, "system", notice); sendErr != nil {
+		slog.Warn("Failed to send quota retry notice", "session", sessionID, "error", sendErr)
+	}
+	return true
+}
+
+// onlyQuotaFailures reports whether every failed sub-task hit a rate limit.
+func onlyQuotaFailures(results []SubTaskResult) error {
+	var quotaErr error
+	for _, res := range results {
+		if res.Success {
+			continue
+		}
+		if !errors.Is(res.Error, heikeErrors.ErrRateLimited) {
+			return nil
+		}
+		quotaErr = res.Error
+	}
+	return quotaErr
+}
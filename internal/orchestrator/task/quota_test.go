@@ -0,0 +1,69 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskManager_DefersRateLimitedTurn(t *testing.T) {
+	sessionManager := &recordingSessionManager{
+		stubSessionManager: stubSessionManager{context: &cognitive.CognitiveContext{SessionID: "s1"}},
+	}
+	sink := &stubResponseSink{}
+	manager := NewManager(
+		&failingEngine{err: fmt.Errorf("thinking failed: %w", heikeErrors.RateLimited("openai"))},
+		&stubDecomposer{},
+		sessionManager,
+		nil,
+		NewDefaultToolBroker(10),
+		nil,
+		3,
+		time.Second,
+		10,
+		4,
+		sink,
+	)
+	manager.SetPostMortem(true, nil)
+
+	var scheduled []string
+	allow := true
+	manager.SetQuotaRetry(func(ctx context.Context, sessionID string, goal string) (time.Duration, bool) {
+		if !allow {
+			return 0, false
+		}
+		scheduled = append(scheduled, goal)
+		return 30 * time.Second, true
+	})
+
+	assert.NoError(t, manager.HandleRequest(context.Background(), "s1", "summarise the report"))
+	assert.Equal(t, []string{"summarise the report"}, scheduled)
+	assert.Equal(t, []string{"system: Provider rate-limited, retrying in 30s."}, sessionManager.interactions)
+
+	// Once retries are spent the turn fails normally, with a post-mortem.
+	allow = false
+	sessionManager.interactions = nil
+	assert.NoError(t, manager.HandleRequest(context.Background(), "s1", "summarise the report"))
+	if assert.Len(t, sessionManager.interactions, 2) {
+		assert.Contains(t, sessionManager.interactions[0], "system: Error:")
+	}
+}
+
+func TestOnlyQuotaFailures(t *testing.T) {
+	quota := heikeErrors.RateLimited("groq")
+	assert.Equal(t, quota, onlyQuotaFailures([]SubTaskResult{
+		{ID: "1", Success: true},
+		{ID: "2", Error: quota},
+	}))
+	assert.Nil(t, onlyQuotaFailures([]SubTaskResult{
+		{ID: "1", Error: quota},
+		{ID: "2", Error: fmt.Errorf("boom")},
+	}))
+	assert.Nil(t, onlyQuotaFailures([]SubTaskResult{{ID: "1", Success: true}}))
+}
@@ -0,0 +1,163 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+)
+
+// Report statuses.
+const (
+	ReportStatusCompleted = "completed"
+	ReportStatusPartial   = "partial"
+	ReportStatusFailed    = "failed"
+	ReportStatusCancelled = "cancelled"
+
+	SubTaskStatusSucceeded = "succeeded"
+	SubTaskStatusFailed    = "failed"
+	SubTaskStatusCancelled = "cancelled"
+)
+
+// TaskReport is the structured outcome of a decomposed goal. It is persisted
+// as a task_result transcript event and summarized for the user.
+type TaskReport struct {
+	Goal string `json:"goal"`
+	// Status is cancelled when the run was cancelled, completed when every
+	// sub-task succeeded, failed when none did, and partial otherwise.
+	Status     string          `json:"status"`
+	SubTasks   []SubTaskReport `json:"sub_tasks"`
+	StartedAt  time.Time       `json:"started_at"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+type SubTaskReport struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	Output      string `json:"output,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// Retries counts runs after the first; a sub-task skipped because a
+	// dependency failed has none.
+	Retries    int       `json:"retries"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// BuildTaskReport collects the coordinator results for goal.
+func BuildTaskReport(goal string, results []SubTaskResult, startedAt time.Time) *TaskReport {
+	report := &TaskReport{
+		Goal:       goal,
+		SubTasks:   make([]SubTaskReport, 0, len(results)),
+		StartedAt:  startedAt,
+		DurationMS: time.Since(startedAt).Milliseconds(),
+	}
+	succeeded, cancelled := 0, false
+	for _, res := range results {
+		sub := SubTaskReport{
+			ID:          res.ID,
+			Description: res.Description,
+			Status:      SubTaskStatusSucceeded,
+			Output:      res.Output,
+			Retries:     max(res.Attempts-1, 0),
+			StartedAt:   res.StartedAt,
+			DurationMS:  res.Duration.Milliseconds(),
+		}
+		switch {
+		case res.Success:
+			succeeded++
+		case res.Cancelled:
+			sub.Status = SubTaskStatusCancelled
+			cancelled = true
+		default:
+			sub.Status = SubTaskStatusFailed
+			if res.Error != nil {
+				sub.Error = res.Error.Error()
+			}
+		}
+		report.SubTasks = append(report.SubTasks, sub)
+	}
+	switch {
+	case cancelled:
+		report.Status = ReportStatusCancelled
+	case succeeded == len(results):
+		report.Status = ReportStatusCompleted
+	case succeeded == 0:
+		report.Status = ReportStatusFailed
+	default:
+		report.Status = ReportStatusPartial
+	}
+	return report
+}
+
+// Text renders the report as plain text. It is the reply when no synthesizer
+// is configured or synthesis fails.
+func (r *TaskReport) Text() string {
+	var sb strings.Builder
+	sb.WriteString("Sub-task results:\n")
+	for _, sub := range r.SubTasks {
+		status := "Success"
+		switch sub.Status {
+		case SubTaskStatusCancelled:
+			status = "Cancelled"
+		case SubTaskStatusFailed:
+			status = fmt.Sprintf("Failed (%s)", sub.Error)
+		}
+		sb.WriteString(fmt.Sprintf("- Task %s: %s\n", sub.ID, status))
+		if sub.Output != "" {
+			sb.WriteString(fmt.Sprintf("  Output: %s\n", sub.Output))
+		}
+	}
+	return sb.String()
+}
+
+// SetSynthesizer enables a final model call that writes the reply to a
+// decomposed goal from its report. An empty system prompt uses the default.
+func (tm *DefaultTaskManager) SetSynthesizer(llm cognitive.LLMClient, systemPrompt string) {
+	if strings.TrimSpace(systemPrompt) == "" {
+		systemPrompt = config.DefaultSynthesizerSystemPrompt
+	}
+	tm.synthesizer = llm
+	tm.synthesisPrompt = systemPrompt
+}
+
+// synthesize returns the user-facing reply for report, falling back to its
+// plain text rendering, which debug sessions also get as a debug event.
+func (tm *DefaultTaskManager) synthesize(ctx context.Context, cCtx *cognitive.CognitiveContext, report *TaskReport) string {
+	if tm.synthesizer == nil {
+		return report.Text()
+	}
+	if cCtx.Debug != nil {
+		cCtx.Debug(DebugStageSynthesis, report.Text())
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return report.Text()
+	}
+	prompt := fmt.Sprintf("%s\n\nGOAL: %s\n\nREPORT:\n%s\n", tm.synthesisPrompt, report.Goal, data)
+	summary, err := tm.synthesizer.Complete(ctx, prompt)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		slog.Warn("Result synthesis failed, sending sub-task results", "error", err)
+		return report.Text()
+	}
+	return strings.TrimSpace(summary)
+}
+
+// persistReport appends the report as a task_result event. Failures are only
+// logged; the reply still reaches the user.
+func (tm *DefaultTaskManager) persistReport(ctx context.Context, sessionID string, report *TaskReport) {
+	err := tm.session.AppendEvent(ctx, sessionID, session.Event{
+		Type:     session.EventTypeTaskResult,
+		Content:  report.Goal,
+		Metadata: map[string]interface{}{"report": report},
+	})
+	if err != nil {
+		slog.Warn("Failed to persist task report", "session", sessionID, "error", err)
+	}
+}
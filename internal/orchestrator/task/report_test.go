@@ -0,0 +1,141 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/tool"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type splitDecomposer struct {
+	subTasks []*SubTask
+}
+
+func (d *splitDecomposer) ShouldDecompose(task string) bool {
+	return true
+}
+
+func (d *splitDecomposer) Decompose(ctx context.Context, task string) ([]*SubTask, error) {
+	return d.subTasks, nil
+}
+
+// goalEngine fails goals listed in failures and echoes the rest.
+type goalEngine struct {
+	failures map[string]error
+}
+
+func (e *goalEngine) Run(ctx context.Context, goal string, opts ...cognitive.ExecutionOption) (*cognitive.Result, error) {
+	if err := e.failures[goal]; err != nil {
+		return nil, err
+	}
+	return &cognitive.Result{Content: "done: " + goal}, nil
+}
+
+type eventRecordingSessionManager struct {
+	stubSessionManager
+	events []session.Event
+}
+
+func (s *eventRecordingSessionManager) AppendEvent(ctx context.Context, sessionID string, evt session.Event) error {
+	s.events = append(s.events, evt)
+	return nil
+}
+
+type promptRecordingLLM struct {
+	decomposerLLMStub
+	prompt string
+}
+
+func (l *promptRecordingLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	l.prompt = prompt
+	return l.decomposerLLMStub.Complete(ctx, prompt)
+}
+
+func newReportManager(sessions *eventRecordingSessionManager, sink *stubResponseSink) *DefaultTaskManager {
+	return NewManager(
+		&goalEngine{failures: map[string]error{"deploy": errors.New("cluster unreachable")}},
+		&splitDecomposer{subTasks: []*SubTask{
+			{ID: "build", Description: "build"},
+			{ID: "deploy", Description: "deploy", Dependencies: []string{"build"}},
+			{ID: "notify", Description: "notify", Dependencies: []string{"deploy"}},
+		}},
+		sessions,
+		[]tool.ToolDescriptor{},
+		NewDefaultToolBroker(10),
+		nil,
+		2,
+		time.Millisecond,
+		10,
+		4,
+		sink,
+	)
+}
+
+func TestTaskManager_PersistsReportAndSynthesizesReply(t *testing.T) {
+	sessions := &eventRecordingSessionManager{stubSessionManager: stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "s1"},
+	}}
+	sink := &stubResponseSink{}
+	llm := &promptRecordingLLM{decomposerLLMStub: decomposerLLMStub{response: "Built, but the deploy failed."}}
+	manager := newReportManager(sessions, sink)
+	manager.SetSynthesizer(llm, "")
+
+	require.NoError(t, manager.HandleRequest(context.Background(), "s1", "ship it"))
+
+	require.Len(t, sessions.events, 1)
+	assert.Equal(t, session.EventTypeTaskResult, sessions.events[0].Type)
+	report, ok := sessions.events[0].Metadata["report"].(*TaskReport)
+	require.True(t, ok)
+	assert.Equal(t, ReportStatusPartial, report.Status)
+	require.Len(t, report.SubTasks, 3)
+
+	build, deploy, notify := report.SubTasks[0], report.SubTasks[1], report.SubTasks[2]
+	assert.Equal(t, SubTaskStatusSucceeded, build.Status)
+	assert.Equal(t, "done: build", build.Output)
+	assert.Equal(t, 0, build.Retries)
+	assert.Equal(t, SubTaskStatusFailed, deploy.Status)
+	assert.Equal(t, "cluster unreachable", deploy.Error)
+	assert.Equal(t, 1, deploy.Retries)
+	assert.Equal(t, SubTaskStatusFailed, notify.Status)
+	assert.Equal(t, 0, notify.Retries)
+
+	assert.Equal(t, "Built, but the deploy failed.", sink.lastContent)
+	assert.True(t, strings.HasPrefix(llm.prompt, "You are summarizing"))
+	assert.Contains(t, llm.prompt, `"error": "cluster unreachable"`)
+}
+
+func TestTaskManager_FallsBackToReportTextWhenSynthesisFails(t *testing.T) {
+	sessions := &eventRecordingSessionManager{stubSessionManager: stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "s1"},
+	}}
+	sink := &stubResponseSink{}
+	manager := newReportManager(sessions, sink)
+	manager.SetSynthesizer(&decomposerLLMStub{err: errors.New("model down")}, "")
+
+	require.NoError(t, manager.HandleRequest(context.Background(), "s1", "ship it"))
+	assert.Contains(t, sink.lastContent, "Sub-task results:\n- Task build: Success\n  Output: done: build\n")
+	assert.Contains(t, sink.lastContent, "- Task deploy: Failed (cluster unreachable)\n")
+}
+
+func TestTaskManager_KeepsRawResultsAsDebugEvent(t *testing.T) {
+	sessions := &eventRecordingSessionManager{stubSessionManager: stubSessionManager{
+		context: &cognitive.CognitiveContext{SessionID: "s1", Metadata: map[string]string{session.DebugMetadataKey: "true"}},
+	}}
+	manager := newReportManager(sessions, &stubResponseSink{})
+
+	require.NoError(t, manager.HandleRequest(context.Background(), "s1", "ship it"))
+	assert.NotContains(t, sessions.debugStages, DebugStageSynthesis, "raw results are the reply without a synthesizer")
+
+	sessions.debugStages = nil
+	manager.SetSynthesizer(&decomposerLLMStub{response: "Shipped."}, "")
+	require.NoError(t, manager.HandleRequest(context.Background(), "s1", "ship it"))
+	assert.Contains(t, sessions.debugStages, DebugStageSynthesis)
+}
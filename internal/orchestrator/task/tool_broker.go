@@ -1,10 +1,15 @@
 package task
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"sort"
 	"strings"
 
+	"github.com/harunnryd/heike/internal/cognitive"
+	"github.com/harunnryd/heike/internal/model/contract"
 	"github.com/harunnryd/heike/internal/tool"
 )
 
@@ -234,3 +239,82 @@ func countSetMatches(tokens map[string]struct{}, goalTokens map[string]struct{})
 	}
 	return matches
 }
+
+// LLMToolBroker asks the model which tools a goal needs when there are more
+// tools than the per-turn budget. It falls back to keyword scoring when the
+// model fails or names no known tool.
+type LLMToolBroker struct {
+	llm      cognitive.LLMClient
+	fallback *DefaultToolBroker
+	maxTools int
+}
+
+func NewLLMToolBroker(llm cognitive.LLMClient, maxTools int) *LLMToolBroker {
+	return &LLMToolBroker{llm: llm, fallback: NewDefaultToolBroker(maxTools), maxTools: maxTools}
+}
+
+var toolSelectionResponseFormat = contract.ResponseFormat{
+	Type: contract.ResponseFormatJSONSchema,
+	Name: "tool_selection",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tools": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+		"required": []string{"tools"},
+	},
+}
+
+// SelectWithContext returns the tools the model picked for goal, in its order.
+func (b *LLMToolBroker) SelectWithContext(ctx context.Context, goal string, tools []tool.ToolDescriptor) ToolSelectionResult {
+	if b.llm == nil || b.maxTools <= 0 || len(tools) <= b.maxTools {
+		return b.fallback.SelectWithMetadata(goal, tools)
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Choose the tools needed to accomplish the goal. Reply with JSON {\"tools\": [names]} listing at most %d tool names, most useful first.\n\nGOAL: %s\n\nTOOLS:\n", b.maxTools, goal)
+	byName := make(map[string]tool.ToolDescriptor, len(tools))
+	for _, descriptor := range tools {
+		name := descriptor.Definition.Name
+		byName[strings.ToLower(name)] = descriptor
+		fmt.Fprintf(&prompt, "- %s: %s\n", name, descriptor.Definition.Description)
+	}
+
+	response, err := cognitive.CompleteJSON(ctx, b.llm, prompt.String(), toolSelectionResponseFormat)
+	if err != nil {
+		slog.Warn("LLM tool selection failed, using keyword scoring", "error", err)
+		return b.fallback.SelectWithMetadata(goal, tools)
+	}
+	var payload struct {
+		Tools []string `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(cleanModelJSONBlock(response)), &payload); err != nil {
+		slog.Warn("LLM tool selection returned invalid JSON, using keyword scoring", "error", err)
+		return b.fallback.SelectWithMetadata(goal, tools)
+	}
+
+	result := ToolSelectionResult{}
+	for _, name := range payload.Tools {
+		key := strings.ToLower(strings.TrimSpace(name))
+		descriptor, ok := byName[key]
+		if !ok {
+			continue
+		}
+		delete(byName, key)
+		result.Tools = append(result.Tools, descriptor)
+		result.Details = append(result.Details, ToolSelectionDetail{
+			Name:    descriptor.Definition.Name,
+			Reasons: []string{"llm_selected"},
+		})
+		if len(result.Tools) == b.maxTools {
+			break
+		}
+	}
+	if len(result.Tools) == 0 {
+		return b.fallback.SelectWithMetadata(goal, tools)
+	}
+	return result
+}
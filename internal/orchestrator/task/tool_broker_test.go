@@ -1,6 +1,7 @@
 package task
 
 import (
+	"context"
 	"testing"
 
 	"github.com/harunnryd/heike/internal/model/contract"
@@ -129,3 +130,38 @@ func TestDefaultToolBroker_RespectsUnlimitedBudget(t *testing.T) {
 
 	assert.Len(t, selected, len(tools))
 }
+
+type toolPickerLLM struct {
+	reply string
+	err   error
+}
+
+func (l *toolPickerLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	return l.reply, l.err
+}
+
+func (l *toolPickerLLM) ChatComplete(ctx context.Context, messages []contract.Message, tools []contract.ToolDef) (string, []*contract.ToolCall, error) {
+	return l.reply, nil, l.err
+}
+
+func TestLLMToolBroker_UsesModelPicksAndFallsBack(t *testing.T) {
+	descriptors := []tool.ToolDescriptor{
+		{Definition: contract.ToolDef{Name: "search_query", Description: "Search the web"}},
+		{Definition: contract.ToolDef{Name: "weather", Description: "Get the forecast"}},
+		{Definition: contract.ToolDef{Name: "exec_command", Description: "Run a command"}},
+	}
+
+	broker := NewLLMToolBroker(&toolPickerLLM{reply: `{"tools": ["Weather", "unknown"]}`}, 2)
+	result := broker.SelectWithContext(context.Background(), "will it rain", descriptors)
+	if assert.Len(t, result.Tools, 1) {
+		assert.Equal(t, "weather", result.Tools[0].Definition.Name)
+		assert.Equal(t, []string{"llm_selected"}, result.Details[0].Reasons)
+	}
+
+	broker = NewLLMToolBroker(&toolPickerLLM{reply: "not json"}, 2)
+	result = broker.SelectWithContext(context.Background(), "search the web", descriptors)
+	assert.NotEmpty(t, result.Tools)
+	for _, detail := range result.Details {
+		assert.NotContains(t, detail.Reasons, "llm_selected")
+	}
+}
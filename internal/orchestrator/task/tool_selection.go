@@ -0,0 +1,55 @@
+package task
+
+import (
+	"sync"
+	"time"
+)
+
+// Tool selection brokers.
+const (
+	ToolSelectionBrokerLLM       = "llm"
+	ToolSelectionBrokerHeuristic = "heuristic"
+	ToolSelectionBrokerNone      = "none"
+)
+
+// ToolSelection records which tools a session's last turn offered the model
+// and why, for /why-tools and the sessions API.
+type ToolSelection struct {
+	Goal   string
+	Broker string
+	// Available is the number of tools the session may use before selection.
+	Available int
+	// Details has one entry per selected tool, best first. Scores are zero
+	// when every tool fit within orchestrator.max_tools_per_turn.
+	Details    []ToolSelectionDetail
+	SelectedAt time.Time
+}
+
+// toolSelectionLog keeps the latest selection per session in memory; it is
+// not persisted across restarts.
+type toolSelectionLog struct {
+	mu   sync.Mutex
+	last map[string]ToolSelection
+}
+
+func (l *toolSelectionLog) record(sessionID string, sel ToolSelection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last == nil {
+		l.last = make(map[string]ToolSelection)
+	}
+	l.last[sessionID] = sel
+}
+
+func (l *toolSelectionLog) get(sessionID string) (ToolSelection, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sel, ok := l.last[sessionID]
+	return sel, ok
+}
+
+// LastToolSelection returns the tool selection of the last turn run in
+// sessionID since the daemon started.
+func (tm *DefaultTaskManager) LastToolSelection(sessionID string) (ToolSelection, bool) {
+	return tm.selections.get(sessionID)
+}
@@ -0,0 +1,233 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/cognitive"
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/logger"
+	"github.com/harunnryd/heike/internal/model"
+	"github.com/harunnryd/heike/internal/model/contract"
+	"github.com/harunnryd/heike/internal/orchestrator/session"
+	"github.com/harunnryd/heike/internal/orchestrator/task"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// TurnResult is the outcome of a turn run with ExecuteGoal.
+type TurnResult struct {
+	ID        string
+	SessionID string
+	// Content is the last assistant message; empty when the turn failed
+	// before answering.
+	Content string
+	// Messages are the assistant and system messages sent to the session,
+	// in order, including progress notices.
+	Messages  []TurnMessage
+	ToolCalls []TurnToolCall
+	Usage     TurnUsage
+	// ModelUsage splits Usage by model.
+	ModelUsage map[string]TurnUsage
+	// Turns counts cognitive loop turns; Retries counts turns repeated at
+	// the reflector's request and sub-task re-runs.
+	Turns   int
+	Retries int
+	// Errors are failures reported to the session instead of returned,
+	// such as a failed engine run or sub-task.
+	Errors   []error
+	Duration time.Duration
+}
+
+type TurnMessage struct {
+	Role    string
+	Content string
+}
+
+// TurnToolCall is a tool invocation made during the turn.
+type TurnToolCall struct {
+	ID       string
+	Name     string
+	Input    string
+	Output   string
+	Error    string
+	Duration time.Duration
+}
+
+// TurnUsage totals the completions made during the turn. Tokens are
+// estimated for providers that do not report usage.
+type TurnUsage struct {
+	Completions        int
+	PromptTokens       int
+	CompletionTokens   int
+	CachedPromptTokens int
+	// CostUSD is zero for models without models.pricing.
+	CostUSD float64
+}
+
+// turnRecorder collects a TurnResult from the hooks along the turn.
+type turnRecorder struct {
+	mu     sync.Mutex
+	result TurnResult
+}
+
+type turnRecorderKey struct{}
+
+func withTurnRecorder(ctx context.Context, rec *turnRecorder) context.Context {
+	ctx = context.WithValue(ctx, turnRecorderKey{}, rec)
+	ctx = task.WithObserver(ctx, rec)
+	ctx = cognitive.WithLoopObserver(ctx, rec)
+	return model.WithUsageObserver(ctx, rec.addUsage)
+}
+
+func turnRecorderFrom(ctx context.Context) *turnRecorder {
+	rec, _ := ctx.Value(turnRecorderKey{}).(*turnRecorder)
+	return rec
+}
+
+func (r *turnRecorder) ObserveResponse(role, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Messages = append(r.result.Messages, TurnMessage{Role: role, Content: content})
+	if role == "assistant" {
+		r.result.Content = content
+	}
+}
+
+func (r *turnRecorder) ObserveError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Errors = append(r.result.Errors, err)
+}
+
+func (r *turnRecorder) ObserveTurn() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Turns++
+}
+
+func (r *turnRecorder) ObserveRetry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Retries++
+}
+
+func (r *turnRecorder) addToolCall(id, name string, args json.RawMessage, output json.RawMessage, err error, duration time.Duration) {
+	call := TurnToolCall{ID: id, Name: name, Input: string(args), Output: string(output), Duration: duration}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.ToolCalls = append(r.result.ToolCalls, call)
+}
+
+func (r *turnRecorder) addUsage(model string, usage contract.Usage, costUSD float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Usage.add(usage, costUSD)
+	if r.result.ModelUsage == nil {
+		r.result.ModelUsage = make(map[string]TurnUsage)
+	}
+	perModel := r.result.ModelUsage[model]
+	perModel.add(usage, costUSD)
+	r.result.ModelUsage[model] = perModel
+}
+
+func (u *TurnUsage) add(usage contract.Usage, costUSD float64) {
+	u.Completions++
+	u.PromptTokens += usage.PromptTokens
+	u.CompletionTokens += usage.CompletionTokens
+	u.CachedPromptTokens += usage.CachedPromptTokens
+	u.CostUSD += costUSD
+}
+
+// snapshot returns the result so far with its maps copied.
+func (r *turnRecorder) snapshot() TurnResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.result
+	result.Messages = append([]TurnMessage(nil), r.result.Messages...)
+	result.ToolCalls = append([]TurnToolCall(nil), r.result.ToolCalls...)
+	result.Errors = append([]error(nil), r.result.Errors...)
+	if r.result.ModelUsage != nil {
+		result.ModelUsage = make(map[string]TurnUsage, len(r.result.ModelUsage))
+		for model, usage := range r.result.ModelUsage {
+			result.ModelUsage[model] = usage
+		}
+	}
+	return result
+}
+
+// ExecuteGoal runs goal as a user message in sessionID and waits for the
+// turn to finish, bypassing the ingress queues. The transcript and egress
+// see the turn as usual. Rate-limited turns fail instead of being deferred,
+// and slash commands are rejected since they produce no turn.
+//
+// The returned error reports a turn that could not run; failures the turn
+// reports to the session are in TurnResult.Errors.
+func (k *DefaultKernel) ExecuteGoal(ctx context.Context, sessionID, goal string) (*TurnResult, error) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return nil, heikeErrors.InvalidInput("session id is required")
+	}
+	if strings.TrimSpace(goal) == "" {
+		return nil, heikeErrors.InvalidInput("goal is required")
+	}
+	if k.command.CanHandle(goal) {
+		return nil, heikeErrors.InvalidInput("slash commands are not goals; submit them as events")
+	}
+
+	id := ulid.Make().String()
+	rec := &turnRecorder{result: TurnResult{ID: id, SessionID: sessionID}}
+	ctx = logger.WithTraceID(ctx, id)
+	ctx = logger.WithSessionID(ctx, sessionID)
+	ctx = withTurnRecorder(ctx, rec)
+
+	started := time.Now()
+	err := k.runGoal(ctx, id, sessionID, goal, true)
+
+	result := rec.snapshot()
+	result.Duration = time.Since(started)
+	return &result, err
+}
+
+// buildExecutionReport condenses the turn recorded for goal into the
+// report appended with orchestrator.execution_report.
+func buildExecutionReport(eventID, goal, status string, started time.Time, result TurnResult) session.ExecutionReport {
+	report := session.ExecutionReport{
+		EventID:          eventID,
+		Goal:             goal,
+		Status:           status,
+		StartedAt:        started,
+		DurationMS:       time.Since(started).Milliseconds(),
+		Turns:            result.Turns,
+		Retries:          result.Retries,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+		CostUSD:          result.Usage.CostUSD,
+	}
+	for _, call := range result.ToolCalls {
+		report.Tools = append(report.Tools, session.ExecutionToolCall{
+			Name:       call.Name,
+			DurationMS: call.Duration.Milliseconds(),
+			Error:      call.Error,
+		})
+	}
+	for model, usage := range result.ModelUsage {
+		report.Models = append(report.Models, session.ExecutionModelUsage{
+			Model:              model,
+			Completions:        usage.Completions,
+			PromptTokens:       usage.PromptTokens,
+			CompletionTokens:   usage.CompletionTokens,
+			CachedPromptTokens: usage.CachedPromptTokens,
+			CostUSD:            usage.CostUSD,
+		})
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].Model < report.Models[j].Model })
+	return report
+}
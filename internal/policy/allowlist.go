@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// ToolsAllowMetadataKey restricts a session to a comma-separated list of
+// tools. The restriction applies whenever the key is present, so an empty
+// value leaves the session without tools.
+const ToolsAllowMetadataKey = "tools_allow"
+
+// ParseToolAllowlist splits a tools_allow value into unique tool names.
+func ParseToolAllowlist(value string) []string {
+	seen := make(map[string]bool)
+	allow := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = normalizeToolName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		allow = append(allow, name)
+	}
+	return allow
+}
+
+// SessionToolAllowlist returns the tools a session may use and whether it is
+// restricted at all.
+func SessionToolAllowlist(metadata map[string]string) ([]string, bool) {
+	value, ok := metadata[ToolsAllowMetadataKey]
+	if !ok {
+		return nil, false
+	}
+	return ParseToolAllowlist(value), true
+}
+
+// NarrowToolAllowlist applies a requested tools_allow value to a session's
+// current metadata and returns the value to store. A request can only remove
+// tools: it is intersected with any existing restriction.
+func NarrowToolAllowlist(current map[string]string, requested string) string {
+	allow := ParseToolAllowlist(requested)
+	if existing, ok := SessionToolAllowlist(current); ok {
+		kept := allow[:0]
+		for _, name := range allow {
+			if ToolAllowed(existing, name) {
+				kept = append(kept, name)
+			}
+		}
+		allow = kept
+	}
+	return strings.Join(allow, ",")
+}
+
+// ToolAllowed reports whether toolName is in allow.
+func ToolAllowed(allow []string, toolName string) bool {
+	toolName = normalizeToolName(toolName)
+	for _, name := range allow {
+		if name == toolName {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckToolAllowlist denies toolName when it is not in a session's allowlist.
+// It runs before the global checks in Check, which still apply to allowed tools.
+func (e *Engine) CheckToolAllowlist(toolName string, allow []string) error {
+	if ToolAllowed(allow, toolName) {
+		return nil
+	}
+	slog.Warn("Tool outside session allowlist", "tool", normalizeToolName(toolName), "allowed", allow)
+	return heikeErrors.PermissionDenied(fmt.Sprintf("tool %s is not allowed in this session", normalizeToolName(toolName)))
+}
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/counter"
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
 	"github.com/harunnryd/heike/internal/store"
 
@@ -36,6 +38,7 @@ const (
 
 type Approval struct {
 	ID        string         `json:"id"`
+	SessionID string         `json:"session_id,omitempty"`
 	Tool      string         `json:"tool"`
 	Input     string         `json:"input"`
 	Status    ApprovalStatus `json:"status"`
@@ -55,8 +58,13 @@ type Engine struct {
 	mu             sync.RWMutex
 	store          *store.Worker
 	// Quota limits
-	dailyLimit int
-	usage      map[string]int // tool -> count
+	dailyLimit     int
+	rateLimit      int // per tool per minute, 0 disables
+	usage          counter.Store
+	counterTimeout time.Duration
+	now            func() time.Time
+	// Approval listeners
+	approvalListeners []func(Approval)
 }
 
 func NewEngine(cfg config.GovernanceConfig, workspaceID string, workspaceRootPath string) (*Engine, error) {
@@ -72,13 +80,21 @@ func NewEngine(cfg config.GovernanceConfig, workspaceID string, workspaceRootPat
 		return nil, fmt.Errorf("failed to create governance dir: %w", err)
 	}
 
+	usage, err := counter.NewFileStore(filepath.Join(base, "usage.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage counters: %w", err)
+	}
+
 	e := &Engine{
-		config:     cfg,
-		storePath:  storePath,
-		domainPath: domainPath,
-		approvals:  make(map[string]Approval),
-		usage:      make(map[string]int),
-		dailyLimit: cfg.DailyToolLimit,
+		config:         cfg,
+		storePath:      storePath,
+		domainPath:     domainPath,
+		approvals:      make(map[string]Approval),
+		usage:          usage,
+		dailyLimit:     cfg.DailyToolLimit,
+		rateLimit:      cfg.RateLimitPerMinute,
+		counterTimeout: 5 * time.Second,
+		now:            time.Now,
 	}
 	if e.dailyLimit <= 0 {
 		e.dailyLimit = config.DefaultGovernanceDailyToolLimit
@@ -128,6 +144,12 @@ func (e *Engine) saveDomains() error {
 
 // Check evaluates whether a tool call is allowed.
 func (e *Engine) Check(toolName string, input json.RawMessage) (bool, string, error) {
+	return e.CheckSession("", toolName, input)
+}
+
+// CheckSession is Check for a tool call made in sessionID, which is recorded
+// on any approval it creates.
+func (e *Engine) CheckSession(sessionID, toolName string, input json.RawMessage) (bool, string, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -139,29 +161,31 @@ func (e *Engine) Check(toolName string, input json.RawMessage) (bool, string, er
 		case "", sandboxPermissionUseDefault:
 			// continue
 		case sandboxPermissionRequireEscalated:
-			return e.createApproval(toolName, input)
+			return e.createApproval(sessionID, toolName, input)
 		default:
 			return false, "", fmt.Errorf("sandbox_permissions %q is denied: %w", sandboxPerm, heikeErrors.ErrPermissionDenied)
 		}
 	}
 
 	// Quota Check
-	if count := e.usage[toolName]; count >= e.dailyLimit {
-		return false, "", fmt.Errorf("quota exceeded for tool %s", toolName)
+	if err := e.checkQuotaLocked(toolName); err != nil {
+		return false, "", err
 	}
 
 	// Domain allowlist applies to any tool input that carries a URL.
 	if host, ok := extractHostFromInput(input); ok {
 		if !containsDomain(e.allowedDomains, host) {
-			return e.createApproval(toolName, input)
+			return e.createApproval(sessionID, toolName, input)
 		}
 		e.consumeQuotaLocked(toolName)
 		return true, "", nil
 	}
 
+	action := extractActionFromInput(input)
+
 	// Check Auto-Allow List
 	for _, allowed := range e.config.AutoAllow {
-		if normalizeToolName(allowed) == toolName {
+		if matchesToolRule(allowed, toolName, action) {
 			e.consumeQuotaLocked(toolName)
 			return true, "", nil
 		}
@@ -170,7 +194,7 @@ func (e *Engine) Check(toolName string, input json.RawMessage) (bool, string, er
 	// Check Require-Approval List
 	requiresApproval := false
 	for _, restricted := range e.config.RequireApproval {
-		if normalizeToolName(restricted) == toolName {
+		if matchesToolRule(restricted, toolName, action) {
 			requiresApproval = true
 			break
 		}
@@ -182,14 +206,15 @@ func (e *Engine) Check(toolName string, input json.RawMessage) (bool, string, er
 		return true, "", nil
 	}
 
-	return e.createApproval(toolName, input)
+	return e.createApproval(sessionID, toolName, input)
 }
 
-func (e *Engine) createApproval(toolName string, input json.RawMessage) (bool, string, error) {
+func (e *Engine) createApproval(sessionID, toolName string, input json.RawMessage) (bool, string, error) {
 	toolName = normalizeToolName(toolName)
 	id := ulid.Make().String()
 	app := Approval{
 		ID:        id,
+		SessionID: sessionID,
 		Tool:      toolName,
 		Input:     string(input),
 		Status:    StatusPending,
@@ -201,9 +226,22 @@ func (e *Engine) createApproval(toolName string, input json.RawMessage) (bool, s
 	}
 
 	slog.Info("Approval required", "id", id, "tool", toolName)
+	for _, listener := range e.approvalListeners {
+		go listener(app)
+	}
 	return false, id, heikeErrors.ErrApprovalRequired
 }
 
+// OnApprovalRequested registers a listener invoked asynchronously for every new pending approval.
+func (e *Engine) OnApprovalRequested(listener func(Approval)) {
+	if listener == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.approvalListeners = append(e.approvalListeners, listener)
+}
+
 // Resolve updates the status of an approval.
 func (e *Engine) Resolve(id string, approve bool) error {
 	e.mu.Lock()
@@ -242,6 +280,28 @@ func normalizeToolName(name string) string {
 	return strings.TrimSpace(name)
 }
 
+// matchesToolRule reports whether a governance list entry covers a call.
+// An entry is a tool name, or tool:action to cover only calls whose input
+// names that action, such as github:create_issue.
+func matchesToolRule(rule, toolName, action string) bool {
+	rule = normalizeToolName(rule)
+	name, ruleAction, scoped := strings.Cut(rule, ":")
+	if !scoped {
+		return rule == toolName
+	}
+	return name == toolName && action != "" && strings.EqualFold(ruleAction, action)
+}
+
+func extractActionFromInput(input json.RawMessage) string {
+	var args struct {
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(args.Action)
+}
+
 func extractHostFromInput(input json.RawMessage) (string, bool) {
 	var args struct {
 		URL string `json:"url"`
@@ -291,16 +351,81 @@ func (e *Engine) IsGranted(id string) bool {
 	return ok && app.Status == StatusGranted
 }
 
+// SetCounterStore replaces the per-workspace usage file, e.g. with a Redis
+// store so quotas and rate limits are shared across replicas.
+func (e *Engine) SetCounterStore(store counter.Store, timeout time.Duration) {
+	if store == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.usage = store
+	if timeout > 0 {
+		e.counterTimeout = timeout
+	}
+}
+
+// CounterStore returns the store quotas are counted in and its timeout, so
+// other per-day budgets share the same persistence.
+func (e *Engine) CounterStore() (counter.Store, time.Duration) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.usage, e.counterTimeout
+}
+
+func (e *Engine) dailyKey(toolName string) string {
+	return "quota:" + e.now().UTC().Format("2006-01-02") + ":" + toolName
+}
+
+func (e *Engine) rateKey(toolName string) string {
+	return fmt.Sprintf("rate:%d:%s", e.now().Unix()/60, toolName)
+}
+
+func (e *Engine) checkQuotaLocked(toolName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.counterTimeout)
+	defer cancel()
+
+	count, err := e.usage.Get(ctx, e.dailyKey(toolName))
+	if err != nil {
+		return heikeErrors.WrapWithCategory(err, "read tool quota", heikeErrors.ErrTransient)
+	}
+	if count >= int64(e.dailyLimit) {
+		return fmt.Errorf("quota exceeded for tool %s", toolName)
+	}
+
+	if e.rateLimit > 0 {
+		count, err := e.usage.Get(ctx, e.rateKey(toolName))
+		if err != nil {
+			return heikeErrors.WrapWithCategory(err, "read tool rate limit", heikeErrors.ErrTransient)
+		}
+		if count >= int64(e.rateLimit) {
+			return fmt.Errorf("rate limit exceeded for tool %s: %w", toolName, heikeErrors.ErrTransient)
+		}
+	}
+	return nil
+}
+
 func (e *Engine) consumeQuotaLocked(toolName string) {
-	e.usage[normalizeToolName(toolName)]++
+	toolName = normalizeToolName(toolName)
+	ctx, cancel := context.WithTimeout(context.Background(), e.counterTimeout)
+	defer cancel()
+
+	if _, err := e.usage.Incr(ctx, e.dailyKey(toolName), 25*time.Hour); err != nil {
+		slog.Warn("Failed to record tool usage", "tool", toolName, "error", err)
+	}
+	if e.rateLimit > 0 {
+		if _, err := e.usage.Incr(ctx, e.rateKey(toolName), 2*time.Minute); err != nil {
+			slog.Warn("Failed to record tool rate", "tool", toolName, "error", err)
+		}
+	}
 }
 
 func (e *Engine) ConsumeQuota(toolName string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	normalized := normalizeToolName(toolName)
-	if e.usage[normalized] >= e.dailyLimit {
-		return fmt.Errorf("quota exceeded for tool %s", normalized)
+	if err := e.checkQuotaLocked(normalized); err != nil {
+		return err
 	}
 	e.consumeQuotaLocked(normalized)
 	return nil
@@ -6,9 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/counter"
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/redis"
+	"github.com/harunnryd/heike/internal/redis/redistest"
 )
 
 func TestPolicyEngine(t *testing.T) {
@@ -154,3 +158,98 @@ func TestPolicyEngine_QuotaNotConsumedByPendingApproval(t *testing.T) {
 		t.Fatalf("expected approval required error on second check, got %v", err)
 	}
 }
+
+func TestPolicyEngine_CheckSessionRecordsSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	engine, err := NewEngine(config.GovernanceConfig{RequireApproval: []string{"rm"}}, "approval-session-"+t.Name(), "")
+	if err != nil {
+		t.Fatalf("init policy engine: %v", err)
+	}
+	notified := make(chan Approval, 1)
+	engine.OnApprovalRequested(func(app Approval) { notified <- app })
+
+	_, id, err := engine.CheckSession("api:embed", "rm", nil)
+	if !errors.Is(err, heikeErrors.ErrApprovalRequired) {
+		t.Fatalf("expected approval required error, got %v", err)
+	}
+	if app := <-notified; app.ID != id || app.SessionID != "api:embed" {
+		t.Fatalf("unexpected approval notification: %+v", app)
+	}
+	if pending := engine.ListApprovals(StatusPending); len(pending) != 1 || pending[0].SessionID != "api:embed" {
+		t.Fatalf("pending approvals = %+v", pending)
+	}
+}
+
+func TestPolicyEngine_ActionScopedRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	engine, err := NewEngine(config.GovernanceConfig{
+		AutoAllow:       []string{"github:search_repos"},
+		RequireApproval: []string{"github:create_issue", "github:comment"},
+	}, "action-rules-"+t.Name(), "")
+	if err != nil {
+		t.Fatalf("init policy engine: %v", err)
+	}
+
+	for input, wantAllowed := range map[string]bool{
+		`{"action":"search_repos","query":"heike"}`: true,
+		`{"action":"get_file","repo":"a/b"}`:        true,
+		`{"action":"create_issue","repo":"a/b"}`:    false,
+		`{"action":"Comment","repo":"a/b"}`:         false,
+		`{"repo":"a/b"}`:                            true,
+	} {
+		allowed, _, err := engine.Check("github", json.RawMessage(input))
+		if allowed != wantAllowed {
+			t.Errorf("Check(github, %s) allowed = %v (err %v), want %v", input, allowed, err, wantAllowed)
+		}
+		if !wantAllowed && !errors.Is(err, heikeErrors.ErrApprovalRequired) {
+			t.Errorf("Check(github, %s) err = %v, want approval required", input, err)
+		}
+	}
+
+	// Scoped entries do not cover other tools with the same action.
+	if allowed, _, err := engine.Check("gitlab", json.RawMessage(`{"action":"create_issue"}`)); !allowed || err != nil {
+		t.Errorf("Check(gitlab) = %v, %v", allowed, err)
+	}
+}
+
+func TestPolicyEngine_SharedCounterStoreAndRateLimit(t *testing.T) {
+	srv := redistest.NewServer(t)
+	client, err := redis.NewClient(redis.Config{Addr: srv.Addr})
+	if err != nil {
+		t.Fatalf("new redis client: %v", err)
+	}
+	defer client.Close()
+
+	cfg := config.GovernanceConfig{
+		AutoAllow:          []string{"ls"},
+		DailyToolLimit:     10,
+		RateLimitPerMinute: 2,
+	}
+	newReplica := func(root string) *Engine {
+		engine, err := NewEngine(cfg, "shared-ws", root)
+		if err != nil {
+			t.Fatalf("init policy engine: %v", err)
+		}
+		engine.SetCounterStore(counter.NewRedisStore(client, "heike:shared-ws:"), time.Second)
+		return engine
+	}
+	first := newReplica(t.TempDir())
+	second := newReplica(t.TempDir())
+
+	if allowed, _, err := first.Check("ls", nil); !allowed || err != nil {
+		t.Fatalf("first call: allowed=%v err=%v", allowed, err)
+	}
+	if allowed, _, err := second.Check("ls", nil); !allowed || err != nil {
+		t.Fatalf("second call: allowed=%v err=%v", allowed, err)
+	}
+
+	allowed, _, err := first.Check("ls", nil)
+	if allowed {
+		t.Fatal("expected shared rate limit to deny third call")
+	}
+	if !errors.Is(err, heikeErrors.ErrTransient) {
+		t.Fatalf("expected transient rate limit error, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+)
+
+// Nil is returned by Do when the server replies with a null bulk string or array.
+var Nil = errors.New("redis: nil")
+
+// Config describes how to reach a Redis server.
+type Config struct {
+	Addr        string
+	Password    string
+	DB          int
+	DialTimeout time.Duration
+	PoolSize    int
+}
+
+// Client is a minimal RESP2 client with a small connection pool. It supports
+// the handful of commands governance needs and nothing more.
+type Client struct {
+	cfg  Config
+	pool chan *conn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type conn struct {
+	nc net.Conn
+	rd *bufio.Reader
+}
+
+// NewClient creates a client. Connections are dialed lazily on first use.
+func NewClient(cfg Config) (*Client, error) {
+	if strings.TrimSpace(cfg.Addr) == "" {
+		return nil, heikeErrors.InvalidInput("redis addr is required")
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 4
+	}
+	return &Client{cfg: cfg, pool: make(chan *conn, cfg.PoolSize)}, nil
+}
+
+// Do sends a command and returns its reply. Replies are decoded as string,
+// int64, []interface{} or nil; server errors are returned as errors.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, heikeErrors.InvalidInput("redis command is empty")
+	}
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = cn.nc.SetDeadline(deadline)
+	} else {
+		_ = cn.nc.SetDeadline(time.Time{})
+	}
+
+	reply, err := cn.roundTrip(args)
+	var serverErr *ServerError
+	if err != nil && !errors.As(err, &serverErr) && !errors.Is(err, Nil) {
+		cn.nc.Close()
+		return nil, heikeErrors.WrapWithCategory(err, fmt.Sprintf("redis %s", args[0]), heikeErrors.ErrTransient)
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Close releases pooled connections.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.pool)
+	for cn := range c.pool {
+		cn.nc.Close()
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn, ok := <-c.pool:
+		if ok && cn != nil {
+			return cn, nil
+		}
+	default:
+	}
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		cn.nc.Close()
+		return
+	}
+	select {
+	case c.pool <- cn:
+	default:
+		cn.nc.Close()
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: c.cfg.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, heikeErrors.WrapWithCategory(err, "redis dial", heikeErrors.ErrTransient)
+	}
+	cn := &conn{nc: nc, rd: bufio.NewReader(nc)}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = nc.SetDeadline(deadline)
+	}
+
+	if c.cfg.Password != "" {
+		if _, err := cn.roundTrip([]string{"AUTH", c.cfg.Password}); err != nil {
+			nc.Close()
+			return nil, heikeErrors.WrapWithCategory(err, "redis auth", heikeErrors.ErrPermissionDenied)
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := cn.roundTrip([]string{"SELECT", strconv.Itoa(c.cfg.DB)}); err != nil {
+			nc.Close()
+			return nil, heikeErrors.Wrap(err, "redis select")
+		}
+	}
+	return cn, nil
+}
+
+func (cn *conn) roundTrip(args []string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(cn.nc, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(cn.rd)
+}
+
+// ServerError is an error reply sent by the Redis server.
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return "redis: " + e.Message
+}
+
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, &ServerError{Message: line[1:]}
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, Nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad array length %q", line)
+		}
+		if n < 0 {
+			return nil, Nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(rd)
+			if err != nil && !errors.Is(err, Nil) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// Int converts an integer or numeric string reply.
+func Int(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("redis: unexpected reply type %T", reply)
+	}
+}
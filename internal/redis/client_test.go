@@ -0,0 +1,71 @@
+package redis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/redis"
+	"github.com/harunnryd/heike/internal/redis/redistest"
+)
+
+func TestClient_Commands(t *testing.T) {
+	srv := redistest.NewServer(t)
+	srv.RequirePass("secret")
+	client, err := redis.NewClient(redis.Config{Addr: srv.Addr, Password: "secret", DB: 2})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, "GET", "missing"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected Nil for missing key, got %v", err)
+	}
+	for want := int64(1); want <= 3; want++ {
+		got, err := redis.Int(client.Do(ctx, "INCR", "n"))
+		if err != nil || got != want {
+			t.Fatalf("INCR = %d, %v; want %d", got, err, want)
+		}
+	}
+	got, err := redis.Int(client.Do(ctx, "GET", "n"))
+	if err != nil || got != 3 {
+		t.Fatalf("GET = %d, %v; want 3", got, err)
+	}
+
+	var serverErr *redis.ServerError
+	if _, err := client.Do(ctx, "BOGUS"); !errors.As(err, &serverErr) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	// The connection must still be usable after an error reply.
+	if _, err := client.Do(ctx, "SET", "k", "v"); err != nil {
+		t.Fatalf("SET after error reply: %v", err)
+	}
+}
+
+func TestClient_WrongPassword(t *testing.T) {
+	srv := redistest.NewServer(t)
+	srv.RequirePass("secret")
+	client, err := redis.NewClient(redis.Config{Addr: srv.Addr, Password: "nope"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	defer client.Close()
+
+	_, err = client.Do(context.Background(), "GET", "k")
+	if !heikeErrors.IsCategory(err, heikeErrors.ErrPermissionDenied) {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	client, err := redis.NewClient(redis.Config{Addr: "127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	_, err = client.Do(context.Background(), "GET", "k")
+	if !heikeErrors.IsRetryable(err) {
+		t.Fatalf("expected retryable error, got %v", err)
+	}
+}
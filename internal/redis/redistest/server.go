@@ -0,0 +1,214 @@
+// Package redistest provides an in-memory Redis server for tests.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server speaks enough RESP to serve AUTH, SELECT, GET, SET (NX/PX), INCR and PEXPIRE.
+type Server struct {
+	Addr string
+
+	ln       net.Listener
+	mu       sync.Mutex
+	password string
+	values   map[string]string
+	expires  map[string]time.Time
+}
+
+// NewServer starts a server that is closed when the test finishes.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &Server{
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+	}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+// RequirePass makes the server reject commands until AUTH password succeeds.
+func (s *Server) RequirePass(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// TTL returns the remaining lifetime of key, or zero when it has none.
+func (s *Server) TTL(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	exp, ok := s.expires[key]
+	if !ok {
+		return 0
+	}
+	return time.Until(exp)
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer c.Close()
+	rd := bufio.NewReader(c)
+	s.mu.Lock()
+	password := s.password
+	s.mu.Unlock()
+	authed := password == ""
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if cmd == "AUTH" {
+			if len(args) == 2 && args[1] == password {
+				authed = true
+				io.WriteString(c, "+OK\r\n")
+			} else {
+				io.WriteString(c, "-WRONGPASS invalid password\r\n")
+			}
+			continue
+		}
+		if !authed {
+			io.WriteString(c, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		io.WriteString(c, s.exec(cmd, args[1:]))
+	}
+}
+
+func (s *Server) exec(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, exp := range s.expires {
+		if time.Now().After(exp) {
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+	}
+
+	switch cmd {
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		key, val := args[0], args[1]
+		nx := false
+		var ttl time.Duration
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				i++
+				ms, _ := strconv.ParseInt(args[i], 10, 64)
+				ttl = time.Duration(ms) * time.Millisecond
+			}
+		}
+		if _, exists := s.values[key]; exists && nx {
+			return "$-1\r\n"
+		}
+		s.values[key] = val
+		delete(s.expires, key)
+		if ttl > 0 {
+			s.expires[key] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "INCR":
+		n, err := strconv.ParseInt(s.values[args[0]], 10, 64)
+		if err != nil && s.values[args[0]] != "" {
+			return "-ERR value is not an integer\r\n"
+		}
+		n++
+		s.values[args[0]] = strconv.FormatInt(n, 10)
+		return fmt.Sprintf(":%d\r\n", n)
+	case "INCRBY":
+		n, err := strconv.ParseInt(s.values[args[0]], 10, 64)
+		if err != nil && s.values[args[0]] != "" {
+			return "-ERR value is not an integer\r\n"
+		}
+		delta, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer\r\n"
+		}
+		n += delta
+		s.values[args[0]] = strconv.FormatInt(n, 10)
+		return fmt.Sprintf(":%d\r\n", n)
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				delete(s.expires, key)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "PEXPIRE":
+		if _, ok := s.values[args[0]]; !ok {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.ParseInt(args[1], 10, 64)
+		s.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", cmd)
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("bad command header %q", line)
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		header, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
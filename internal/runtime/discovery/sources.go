@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/harunnryd/heike/internal/store"
+	"github.com/harunnryd/heike/skills"
 )
 
 type SourceKind string
@@ -29,6 +30,9 @@ type ResolveOptions struct {
 	WorkspaceRootPath string
 	WorkspacePath     string
 	ProjectPath       string
+	// BundledVersion pins the bundled skill library used when the project has
+	// no skills/ directory; empty means the version embedded in the binary.
+	BundledVersion string
 }
 
 func ResolveSkillSources(opts ResolveOptions) ([]SourceDescriptor, error) {
@@ -54,7 +58,7 @@ func resolveSources(opts ResolveOptions) ([]SourceDescriptor, error) {
 			return nil, fmt.Errorf("runtime discovery order[%d] is empty", idx)
 		}
 
-		path, err := sourcePath(kind, opts.WorkspaceID, opts.WorkspaceRootPath, projectRoot)
+		path, err := sourcePath(kind, opts.WorkspaceID, opts.WorkspaceRootPath, projectRoot, opts.BundledVersion)
 		if err != nil {
 			return nil, err
 		}
@@ -89,13 +93,17 @@ func resolveProjectRoot(projectPath, workspacePath string) string {
 	return filepath.Clean(projectRoot)
 }
 
-func sourcePath(kind SourceKind, workspaceID, workspaceRootPath, projectRoot string) (string, error) {
+func sourcePath(kind SourceKind, workspaceID, workspaceRootPath, projectRoot, bundledVersion string) (string, error) {
 	switch kind {
 	case SourceBundled:
-		if projectRoot == "" {
-			return "", nil
+		// A Heike checkout carries the library as skills/; use it as is.
+		if projectRoot != "" {
+			checkout := filepath.Join(projectRoot, "skills")
+			if info, err := os.Stat(checkout); err == nil && info.IsDir() {
+				return checkout, nil
+			}
 		}
-		return filepath.Join(projectRoot, "skills"), nil
+		return BundledLibraryPath(bundledVersion)
 	case SourceGlobal:
 		return store.GetSkillsDir()
 	case SourceWorkspace:
@@ -112,3 +120,20 @@ func sourcePath(kind SourceKind, workspaceID, workspaceRootPath, projectRoot str
 		return "", fmt.Errorf("unknown runtime source kind %q (allowed: bundled, global, workspace, project)", kind)
 	}
 }
+
+// BundledLibraryPath returns where the pinned version of the bundled skill
+// library is extracted. An empty or uninstalled version resolves to the one
+// embedded in the binary.
+func BundledLibraryPath(version string) (string, error) {
+	root, err := store.GetBundledSkillsRoot()
+	if err != nil {
+		return "", err
+	}
+	if version = strings.TrimSpace(version); version != "" {
+		pinned := filepath.Join(root, version)
+		if _, err := os.Stat(pinned); err == nil {
+			return pinned, nil
+		}
+	}
+	return filepath.Join(root, skills.Version), nil
+}
@@ -1,14 +1,20 @@
 package discovery
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/harunnryd/heike/skills"
 )
 
 func TestResolveSkillSources_DefaultOrder(t *testing.T) {
 	home := t.TempDir()
 	project := t.TempDir()
 	t.Setenv("HOME", home)
+	if err := os.Mkdir(filepath.Join(project, "skills"), 0755); err != nil {
+		t.Fatal(err)
+	}
 
 	got, err := ResolveSkillSources(ResolveOptions{
 		Order:             []string{"bundled", "global", "workspace", "project"},
@@ -36,6 +42,34 @@ func TestResolveSkillSources_DefaultOrder(t *testing.T) {
 	}
 }
 
+func TestResolveSkillSources_BundledFallsBackToLibrary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	pinned := filepath.Join(home, ".heike", "bundled-skills", "1.0.0")
+	if err := os.MkdirAll(pinned, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for version, want := range map[string]string{
+		"":      filepath.Join(home, ".heike", "bundled-skills", skills.Version),
+		"1.0.0": pinned,
+		"0.9.0": filepath.Join(home, ".heike", "bundled-skills", skills.Version),
+	} {
+		got, err := ResolveSkillSources(ResolveOptions{
+			Order:          []string{"bundled"},
+			WorkspacePath:  t.TempDir(),
+			BundledVersion: version,
+		})
+		if err != nil {
+			t.Fatalf("resolve skill sources: %v", err)
+		}
+		if len(got) != 1 || got[0].Path != want {
+			t.Fatalf("version %q: sources = %+v, want %s", version, got, want)
+		}
+	}
+}
+
 func TestResolveToolSources_UnknownKindFails(t *testing.T) {
 	_, err := ResolveToolSources(ResolveOptions{
 		Order:         []string{"bundled", "invalid_kind"},
@@ -166,6 +166,21 @@ func (s *Scheduler) Health(ctx context.Context) error {
 	return nil
 }
 
+// Status is a snapshot of the scheduler for diagnosing stuck runs.
+type Status struct {
+	Running  bool
+	InFlight int
+	Leases   []TaskLease
+}
+
+func (s *Scheduler) Status() Status {
+	s.mu.RLock()
+	status := Status{Running: s.running, InFlight: int(s.inFlightTasks)}
+	s.mu.RUnlock()
+	status.Leases = s.store.Leases()
+	return status
+}
+
 func (s *Scheduler) IsRunning() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
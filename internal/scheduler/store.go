@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -223,6 +224,27 @@ func (s *Store) MarkTaskDone(taskID, runID string) error {
 	return s.save()
 }
 
+// TaskLease is the lease held on a task.
+type TaskLease struct {
+	TaskID string
+	Lease
+}
+
+// Leases returns the tasks that hold a lease, ordered by task ID.
+func (s *Store) Leases() []TaskLease {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	leases := make([]TaskLease, 0)
+	for id, t := range s.data.Tasks {
+		if t.Lease != nil {
+			leases = append(leases, TaskLease{TaskID: id, Lease: *t.Lease})
+		}
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].TaskID < leases[j].TaskID })
+	return leases
+}
+
 func (s *Store) GetLease(taskID string) (*Lease, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
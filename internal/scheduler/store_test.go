@@ -79,3 +79,28 @@ func TestLeaseLogic(t *testing.T) {
 		t.Error("Lease should be cleared after completion")
 	}
 }
+
+func TestStoreLeases(t *testing.T) {
+	st, err := NewStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"report", "backup", "idle"} {
+		if err := st.UpdateTask(&Task{ID: id, Schedule: "@every 1h"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"report", "backup"} {
+		if err := st.LeaseTask(id, "run-"+id, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	leases := st.Leases()
+	if len(leases) != 2 || leases[0].TaskID != "backup" || leases[1].TaskID != "report" {
+		t.Fatalf("leases = %+v", leases)
+	}
+	if leases[0].RunID != "run-backup" || leases[0].Status != StatusLeased {
+		t.Fatalf("backup lease = %+v", leases[0])
+	}
+}
@@ -0,0 +1,46 @@
+package skill
+
+import "strings"
+
+// ExcludeMetadataKey keeps a comma-separated list of skills out of a
+// session's turns, even when mentioned or relevant.
+const ExcludeMetadataKey = "skills_exclude"
+
+// ParseSkillList splits a comma-separated list into unique lower-case skill
+// names.
+func ParseSkillList(value string) []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		name = normalizeSkillName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// ConflictsWith reports whether either skill declares a conflict with the
+// other.
+func (s *Skill) ConflictsWith(other *Skill) bool {
+	if s == nil || other == nil {
+		return false
+	}
+	return declaresConflict(s, other.Name) || declaresConflict(other, s.Name)
+}
+
+func declaresConflict(s *Skill, name string) bool {
+	name = normalizeSkillName(name)
+	for _, conflict := range s.Conflicts {
+		if normalizeSkillName(conflict) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeSkillName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
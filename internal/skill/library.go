@@ -0,0 +1,249 @@
+package skill
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/store"
+	"github.com/harunnryd/heike/skills"
+)
+
+// libraryStateFile records the bundled library version in use and the one
+// before it, so the changes of the last upgrade can be reported.
+const libraryStateFile = "current"
+
+// Library change kinds.
+const (
+	LibraryAdded   = "added"
+	LibraryRemoved = "removed"
+	LibraryChanged = "changed"
+)
+
+// LibraryChange is one bundled skill that differs between two library
+// versions.
+type LibraryChange struct {
+	Name   string `json:"name"`
+	Change string `json:"change"`
+}
+
+// LibraryStatus describes the bundled skill library in use.
+type LibraryStatus struct {
+	// Embedded is the version compiled into this binary.
+	Embedded string `json:"embedded"`
+	// Version is the version in use: the pin when it is installed, otherwise
+	// Embedded.
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	// Previous is the version used before Version; Changes lists what changed
+	// since then.
+	Previous string          `json:"previous,omitempty"`
+	Changes  []LibraryChange `json:"changes,omitempty"`
+	// Switched is true when this call changed the version in use from
+	// Previous.
+	Switched bool `json:"switched,omitempty"`
+	// Pending lists what unpinning would change, when Version is pinned to
+	// something other than Embedded.
+	Pending []LibraryChange `json:"pending,omitempty"`
+}
+
+// InstallLibrary extracts the embedded bundled skill library under
+// ~/.heike/bundled-skills/<version> if it is not there yet and resolves the
+// version to use. A pin that was never installed falls back to the embedded
+// version and is reported as an error alongside the status.
+func InstallLibrary(pin string) (*LibraryStatus, error) {
+	embeddedPath, err := libraryDir(skills.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := extractLibrary(embeddedPath); err != nil {
+		return nil, fmt.Errorf("extract bundled skills %s: %w", skills.Version, err)
+	}
+
+	status := &LibraryStatus{Embedded: skills.Version, Version: skills.Version, Path: embeddedPath}
+	var pinErr error
+	if pin = strings.TrimSpace(pin); pin != "" && pin != skills.Version {
+		pinnedPath, err := libraryDir(pin)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(pinnedPath); err == nil {
+			status.Version = pin
+			status.Path = pinnedPath
+			status.Pending, err = DiffLibrary(pinnedPath, embeddedPath)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			pinErr = fmt.Errorf("pinned bundled skills %s are not installed; using %s", pin, skills.Version)
+		}
+	}
+
+	statePath := filepath.Join(filepath.Dir(embeddedPath), libraryStateFile)
+	var current, previous string
+	if data, err := os.ReadFile(statePath); err == nil {
+		lines := strings.Fields(string(data))
+		if len(lines) > 0 {
+			current = lines[0]
+		}
+		if len(lines) > 1 {
+			previous = lines[1]
+		}
+	}
+	if current != status.Version {
+		previous = current
+		status.Switched = current != ""
+		if err := os.WriteFile(statePath, []byte(status.Version+"\n"+previous+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("record bundled skills version: %w", err)
+		}
+	}
+	if previous != "" && previous != status.Version {
+		previousPath, err := libraryDir(previous)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(previousPath); err == nil {
+			status.Previous = previous
+			status.Changes, err = DiffLibrary(previousPath, status.Path)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return status, pinErr
+}
+
+func libraryDir(version string) (string, error) {
+	root, err := store.GetBundledSkillsRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, version), nil
+}
+
+// extractLibrary writes the embedded library to dir unless it exists. Files
+// are staged in a sibling directory and renamed into place, so a partial
+// extraction is never loaded.
+func extractLibrary(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dir), ".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	err = fs.WalkDir(skills.FS, ".", func(name string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || name == "." {
+			return walkErr
+		}
+		target := filepath.Join(staging, filepath.FromSlash(name))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := skills.FS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		perm := os.FileMode(0644)
+		if path.Base(path.Dir(name)) == "tools" && path.Ext(name) != ".yaml" {
+			perm = 0755
+		}
+		return os.WriteFile(target, data, perm)
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		// Another process may have extracted the same version first.
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// DiffLibrary compares two library directories skill by skill, by the
+// contents of every file in each skill directory.
+func DiffLibrary(oldDir, newDir string) ([]LibraryChange, error) {
+	oldSums, err := librarySums(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newSums, err := librarySums(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]LibraryChange, 0)
+	for name, sum := range newSums {
+		oldSum, ok := oldSums[name]
+		switch {
+		case !ok:
+			changes = append(changes, LibraryChange{Name: name, Change: LibraryAdded})
+		case oldSum != sum:
+			changes = append(changes, LibraryChange{Name: name, Change: LibraryChanged})
+		}
+	}
+	for name := range oldSums {
+		if _, ok := newSums[name]; !ok {
+			changes = append(changes, LibraryChange{Name: name, Change: LibraryRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// librarySums hashes each skill directory under dir.
+func librarySums(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		root := filepath.Join(dir, entry.Name())
+		h := sha256.New()
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil || d.IsDir() {
+				return walkErr
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			// WalkDir visits files in lexical order, so the sum is stable.
+			h.Write([]byte(filepath.ToSlash(rel)))
+			h.Write([]byte{0})
+			h.Write(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")))
+			h.Write([]byte{0})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sums[entry.Name()] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
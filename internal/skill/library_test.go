@@ -0,0 +1,106 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harunnryd/heike/skills"
+)
+
+func TestInstallLibrary_PinAndUpgradeReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := filepath.Join(home, ".heike", "bundled-skills")
+
+	status, err := InstallLibrary("")
+	if err != nil {
+		t.Fatalf("install library: %v", err)
+	}
+	if status.Version != skills.Version || status.Switched || len(status.Changes) != 0 {
+		t.Fatalf("first install status = %+v", status)
+	}
+	if _, err := os.Stat(filepath.Join(status.Path, "summarizer", "SKILL.md")); err != nil {
+		t.Fatalf("embedded library not extracted: %v", err)
+	}
+
+	// An older release: coder differs, legacy was dropped since, and it
+	// predates summarizer.
+	old := filepath.Join(root, "1.0.0")
+	if err := copyTree(status.Path, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(old, "summarizer")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old, "coder", "SKILL.md"), []byte("---\nname: coder\n---\nold"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(old, "legacy"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old, "legacy", "SKILL.md"), []byte("legacy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := []LibraryChange{
+		{Name: "coder", Change: LibraryChanged},
+		{Name: "legacy", Change: LibraryRemoved},
+		{Name: "summarizer", Change: LibraryAdded},
+	}
+
+	status, err = InstallLibrary("1.0.0")
+	if err != nil {
+		t.Fatalf("pin library: %v", err)
+	}
+	if status.Version != "1.0.0" || status.Path != old || !status.Switched {
+		t.Fatalf("pinned status = %+v", status)
+	}
+	assertLibraryChanges(t, "pending", status.Pending, want)
+
+	status, err = InstallLibrary("")
+	if err != nil {
+		t.Fatalf("unpin library: %v", err)
+	}
+	if status.Version != skills.Version || !status.Switched || status.Previous != "1.0.0" {
+		t.Fatalf("upgraded status = %+v", status)
+	}
+	assertLibraryChanges(t, "changes", status.Changes, want)
+
+	status, err = InstallLibrary("0.1.0")
+	if err == nil || status == nil || status.Version != skills.Version {
+		t.Fatalf("uninstalled pin: status = %+v, err = %v", status, err)
+	}
+}
+
+func assertLibraryChanges(t *testing.T, label string, got, want []LibraryChange) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", label, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s = %+v, want %+v", label, got, want)
+		}
+	}
+}
+
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
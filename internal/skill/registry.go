@@ -11,12 +11,15 @@ import (
 )
 
 type Skill struct {
-	Name        string                 `yaml:"name"`
-	Description string                 `yaml:"description"`
-	Tags        []string               `yaml:"tags"`
-	Tools       []string               `yaml:"tools"`
-	Metadata    map[string]interface{} `yaml:"metadata"`
-	Content     string                 `yaml:"-"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Tags        []string `yaml:"tags"`
+	Tools       []string `yaml:"tools"`
+	// Conflicts names skills whose guidance contradicts this one; they are
+	// never injected into the same turn.
+	Conflicts []string               `yaml:"conflicts"`
+	Metadata  map[string]interface{} `yaml:"metadata"`
+	Content   string                 `yaml:"-"`
 }
 
 type SkillLoadError struct {
@@ -10,6 +10,7 @@ type RuntimeLoadOptions struct {
 	WorkspacePath     string
 	ProjectPath       string
 	SourceOrder       []string
+	BundledVersion    string
 }
 
 // LoadRuntimeRegistry loads skills from configured runtime sources.
@@ -29,6 +30,7 @@ func LoadRuntimeRegistry(registry *Registry, opts RuntimeLoadOptions) []error {
 		WorkspaceRootPath: opts.WorkspaceRootPath,
 		WorkspacePath:     opts.WorkspacePath,
 		ProjectPath:       opts.ProjectPath,
+		BundledVersion:    opts.BundledVersion,
 	})
 	if err != nil {
 		return []error{err}
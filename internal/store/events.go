@@ -0,0 +1,101 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// EventState is the lifecycle stage of a submitted event.
+type EventState string
+
+const (
+	EventQueued       EventState = "queued"
+	EventProcessing   EventState = "processing"
+	EventCompleted    EventState = "completed"
+	EventFailed       EventState = "failed"
+	EventDeadLettered EventState = "dead_lettered"
+	// EventCancelled marks a turn stopped through the cancel endpoint.
+	EventCancelled EventState = "cancelled"
+)
+
+// EventStatus is the tracked state of one event. Timestamps are nil until
+// the event reaches the matching stage.
+type EventStatus struct {
+	ID          string     `json:"id"`
+	Status      EventState `json:"status"`
+	SessionID   string     `json:"session_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	QueuedAt    *time.Time `json:"queued_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// eventTracker keeps the status of the most recent events in memory. It is
+// not persisted: statuses are lost on restart.
+type eventTracker struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+func newEventTracker(maxEntries int) *eventTracker {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	return &eventTracker{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (t *eventTracker) set(id, sessionID string, state EventState, errMsg string) {
+	if id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var status *EventStatus
+	if elem, ok := t.entries[id]; ok {
+		status = elem.Value.(*EventStatus)
+	} else {
+		status = &EventStatus{ID: id}
+		t.entries[id] = t.order.PushFront(status)
+		for t.order.Len() > t.maxEntries {
+			oldest := t.order.Back()
+			t.order.Remove(oldest)
+			delete(t.entries, oldest.Value.(*EventStatus).ID)
+		}
+	}
+
+	now := t.now()
+	status.Status = state
+	status.Error = errMsg
+	if sessionID != "" {
+		status.SessionID = sessionID
+	}
+	switch state {
+	case EventQueued:
+		status.QueuedAt = &now
+	case EventProcessing:
+		status.StartedAt = &now
+	default:
+		status.CompletedAt = &now
+	}
+}
+
+func (t *eventTracker) get(id string) (EventStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.entries[id]
+	if !ok {
+		return EventStatus{}, false
+	}
+	return *elem.Value.(*EventStatus), true
+}
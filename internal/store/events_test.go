@@ -0,0 +1,41 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventTracker_Lifecycle(t *testing.T) {
+	tracker := newEventTracker(2)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.set("evt1", "sess1", EventQueued, "")
+	now = now.Add(time.Second)
+	tracker.set("evt1", "", EventProcessing, "")
+	now = now.Add(time.Second)
+	tracker.set("evt1", "", EventFailed, "boom")
+
+	status, ok := tracker.get("evt1")
+	if !ok {
+		t.Fatal("expected evt1 to be tracked")
+	}
+	if status.Status != EventFailed || status.Error != "boom" || status.SessionID != "sess1" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if status.QueuedAt == nil || status.StartedAt == nil || status.CompletedAt == nil {
+		t.Fatalf("expected all timestamps set: %+v", status)
+	}
+	if got := status.CompletedAt.Sub(*status.QueuedAt); got != 2*time.Second {
+		t.Fatalf("expected 2s between queued and completed, got %s", got)
+	}
+
+	tracker.set("evt2", "sess1", EventQueued, "")
+	tracker.set("evt3", "sess1", EventQueued, "")
+	if _, ok := tracker.get("evt1"); ok {
+		t.Fatal("expected oldest event to be evicted")
+	}
+	if _, ok := tracker.get("evt3"); !ok {
+		t.Fatal("expected newest event to be tracked")
+	}
+}
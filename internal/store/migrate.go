@@ -0,0 +1,312 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natefinch/atomic"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	schemaVersionFile   = "schema_version.json"
+	migrationBackupsDir = "migration-backups"
+)
+
+// Migration upgrades workspace data from Version-1 to Version. Apply must be
+// safe to re-run, because a crash can leave the version stamp behind the data.
+type Migration struct {
+	Version int
+	Name    string
+	Apply   func(basePath string) error
+}
+
+// migrations are applied in order; SchemaVersion is the last version.
+var migrations = []Migration{
+	{Version: 1, Name: "backfill session index", Apply: migrateSessionIndexBackfill},
+	{Version: 2, Name: "transcript event types", Apply: migrateTranscriptEventTypes},
+}
+
+// SchemaVersion is the workspace data version this build reads and writes.
+var SchemaVersion = migrations[len(migrations)-1].Version
+
+// MigrationOptions controls how pending migrations are applied.
+type MigrationOptions struct {
+	// DryRun reports pending migrations without touching any data.
+	DryRun bool
+	// Backup copies the workspace data aside before the first migration.
+	Backup bool
+}
+
+// MigrationReport describes a migration run.
+type MigrationReport struct {
+	FromVersion int      `json:"from_version"`
+	ToVersion   int      `json:"to_version"`
+	Pending     []string `json:"pending,omitempty"`
+	Applied     []string `json:"applied,omitempty"`
+	BackupPath  string   `json:"backup_path,omitempty"`
+	DryRun      bool     `json:"dry_run"`
+}
+
+type schemaStamp struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReadSchemaVersion returns the stamped schema version of a workspace.
+// Workspaces written before versioning report 0.
+func ReadSchemaVersion(basePath string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, schemaVersionFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var stamp schemaStamp
+	if err := json.Unmarshal(data, &stamp); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", schemaVersionFile, err)
+	}
+	return stamp.Version, nil
+}
+
+func writeSchemaVersion(basePath string, version int) error {
+	data, err := json.MarshalIndent(schemaStamp{Version: version, UpdatedAt: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(filepath.Join(basePath, schemaVersionFile), bytes.NewReader(data))
+}
+
+// MigrateWorkspace applies pending migrations to the workspace at basePath,
+// stamping the version after each one. The caller must hold the workspace
+// lock unless opts.DryRun is set.
+func MigrateWorkspace(basePath string, opts MigrationOptions) (MigrationReport, error) {
+	report := MigrationReport{ToVersion: SchemaVersion, DryRun: opts.DryRun}
+
+	from, err := ReadSchemaVersion(basePath)
+	if err != nil {
+		return report, err
+	}
+	report.FromVersion = from
+	if from > SchemaVersion {
+		return report, fmt.Errorf("workspace schema version %d is newer than supported version %d", from, SchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.Version > from {
+			report.Pending = append(report.Pending, migrationLabel(m))
+		}
+	}
+	if opts.DryRun || from == SchemaVersion {
+		return report, nil
+	}
+
+	// A workspace with no data yet has nothing to migrate.
+	if from == 0 && !hasWorkspaceData(basePath) {
+		report.Pending = nil
+		return report, writeSchemaVersion(basePath, SchemaVersion)
+	}
+
+	if opts.Backup {
+		backupPath, err := backupWorkspace(basePath, from)
+		if err != nil {
+			return report, fmt.Errorf("backup before migrate: %w", err)
+		}
+		report.BackupPath = backupPath
+		slog.Info("Backed up workspace before migration", "path", backupPath)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= from {
+			continue
+		}
+		slog.Info("Applying workspace migration", "version", m.Version, "name", m.Name)
+		if err := m.Apply(basePath); err != nil {
+			return report, fmt.Errorf("migration %s: %w", migrationLabel(m), err)
+		}
+		if err := writeSchemaVersion(basePath, m.Version); err != nil {
+			return report, fmt.Errorf("stamp schema version %d: %w", m.Version, err)
+		}
+		report.Applied = append(report.Applied, migrationLabel(m))
+	}
+	return report, nil
+}
+
+func migrationLabel(m Migration) string {
+	return fmt.Sprintf("%d: %s", m.Version, m.Name)
+}
+
+func hasWorkspaceData(basePath string) bool {
+	entries, err := os.ReadDir(filepath.Join(basePath, "sessions"))
+	return err == nil && len(entries) > 0
+}
+
+// backupWorkspace copies workspace data into migration-backups/v<from>-<ts>.
+// Sandboxes, artifacts, earlier backups and the lock file are skipped.
+func backupWorkspace(basePath string, from int) (string, error) {
+	dest := filepath.Join(basePath, migrationBackupsDir, fmt.Sprintf("v%d-%s", from, time.Now().UTC().Format("20060102T150405Z")))
+	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(basePath, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			switch rel {
+			case migrationBackupsDir, "sandbox", "artifacts":
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dest, rel), 0755)
+		}
+		if !d.Type().IsRegular() || rel == "workspace.lock" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dest, rel), data, info.Mode().Perm())
+	})
+	if err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// migrateSessionIndexBackfill adds index.json entries for transcripts that
+// predate the index and marks entries without a status as active.
+func migrateSessionIndexBackfill(basePath string) error {
+	sessionsDir := filepath.Join(basePath, "sessions")
+	indexPath := filepath.Join(sessionsDir, "index.json")
+
+	index := SessionIndex{Sessions: make(map[string]SessionMeta)}
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("parse session index: %w", err)
+		}
+		if index.Sessions == nil {
+			index.Sessions = make(map[string]SessionMeta)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	entries, err := os.ReadDir(sessionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".jsonl")
+		if _, ok := index.Sessions[id]; ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		index.Sessions[id] = SessionMeta{
+			ID:        id,
+			Title:     id,
+			Status:    "active",
+			CreatedAt: info.ModTime(),
+			UpdatedAt: info.ModTime(),
+		}
+	}
+	for id, meta := range index.Sessions {
+		if meta.Status == "" {
+			meta.Status = "active"
+			index.Sessions[id] = meta
+		}
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(indexPath, bytes.NewReader(data))
+}
+
+// migrateTranscriptEventTypes rewrites transcript lines written before events
+// carried an id and type, deriving the type from the role.
+func migrateTranscriptEventTypes(basePath string) error {
+	paths, err := filepath.Glob(filepath.Join(basePath, "sessions", "*.jsonl"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := migrateTranscriptFile(path); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+func migrateTranscriptFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	changed := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event map[string]any
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &event) != nil {
+			// Keep unparseable lines as-is; readers already skip them.
+			out.Write(line)
+			out.WriteByte('\n')
+			continue
+		}
+		lineChanged := false
+		if id, _ := event["id"].(string); id == "" {
+			event["id"] = ulid.Make().String()
+			lineChanged = true
+		}
+		if eventType, _ := event["type"].(string); eventType == "" {
+			if role, _ := event["role"].(string); role != "" {
+				event["type"] = role
+				lineChanged = true
+			}
+		}
+		if !lineChanged {
+			out.Write(line)
+			out.WriteByte('\n')
+			continue
+		}
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		out.WriteByte('\n')
+		changed = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	return atomic.WriteFile(path, &out)
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLegacyWorkspace(t *testing.T) string {
+	t.Helper()
+	base := t.TempDir()
+	sessions := filepath.Join(base, "sessions")
+	if err := os.MkdirAll(sessions, 0755); err != nil {
+		t.Fatalf("mkdir sessions: %v", err)
+	}
+	index := `{"sessions":{"known":{"id":"known","title":"Known"}}}`
+	if err := os.WriteFile(filepath.Join(sessions, "index.json"), []byte(index), 0644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	transcript := `{"role":"user","content":"hi"}` + "\n" + `{"id":"01J","type":"assistant","role":"assistant","content":"hello"}` + "\n"
+	for _, id := range []string{"known", "orphan"} {
+		if err := os.WriteFile(filepath.Join(sessions, id+".jsonl"), []byte(transcript), 0644); err != nil {
+			t.Fatalf("write transcript: %v", err)
+		}
+	}
+	return base
+}
+
+func TestMigrateWorkspace_AppliesPendingMigrations(t *testing.T) {
+	base := writeLegacyWorkspace(t)
+
+	report, err := MigrateWorkspace(base, MigrationOptions{Backup: true})
+	if err != nil {
+		t.Fatalf("MigrateWorkspace failed: %v", err)
+	}
+	if report.FromVersion != 0 || report.ToVersion != SchemaVersion || len(report.Applied) != len(migrations) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if version, err := ReadSchemaVersion(base); err != nil || version != SchemaVersion {
+		t.Fatalf("expected stamped version %d, got %d (%v)", SchemaVersion, version, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(base, "sessions", "index.json"))
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	var index SessionIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("parse index: %v", err)
+	}
+	if index.Sessions["known"].Status != "active" || index.Sessions["known"].Title != "Known" {
+		t.Fatalf("expected known session kept and activated, got %+v", index.Sessions["known"])
+	}
+	if _, ok := index.Sessions["orphan"]; !ok {
+		t.Fatal("expected orphan transcript backfilled into index")
+	}
+
+	lines, err := os.ReadFile(filepath.Join(base, "sessions", "orphan.jsonl"))
+	if err != nil {
+		t.Fatalf("read transcript: %v", err)
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(strings.SplitN(string(lines), "\n", 2)[0]), &first); err != nil {
+		t.Fatalf("parse transcript line: %v", err)
+	}
+	if first["type"] != "user" || first["id"] == "" || first["id"] == nil {
+		t.Fatalf("expected id and type backfilled, got %v", first)
+	}
+
+	backup, err := os.ReadFile(filepath.Join(report.BackupPath, "sessions", "orphan.jsonl"))
+	if err != nil {
+		t.Fatalf("expected backup of legacy transcript: %v", err)
+	}
+	if strings.Contains(strings.SplitN(string(backup), "\n", 2)[0], `"type"`) {
+		t.Fatal("expected backup to hold the unmigrated transcript")
+	}
+
+	again, err := MigrateWorkspace(base, MigrationOptions{Backup: true})
+	if err != nil || len(again.Applied) != 0 || again.BackupPath != "" {
+		t.Fatalf("expected no-op on current workspace, got %+v (%v)", again, err)
+	}
+}
+
+func TestMigrateWorkspace_DryRunLeavesDataUntouched(t *testing.T) {
+	base := writeLegacyWorkspace(t)
+	before, _ := os.ReadFile(filepath.Join(base, "sessions", "orphan.jsonl"))
+
+	report, err := MigrateWorkspace(base, MigrationOptions{DryRun: true, Backup: true})
+	if err != nil {
+		t.Fatalf("MigrateWorkspace failed: %v", err)
+	}
+	if len(report.Pending) != len(migrations) || len(report.Applied) != 0 || report.BackupPath != "" {
+		t.Fatalf("unexpected dry-run report: %+v", report)
+	}
+	after, _ := os.ReadFile(filepath.Join(base, "sessions", "orphan.jsonl"))
+	if string(before) != string(after) {
+		t.Fatal("expected dry run not to rewrite transcripts")
+	}
+	if version, _ := ReadSchemaVersion(base); version != 0 {
+		t.Fatalf("expected dry run not to stamp version, got %d", version)
+	}
+}
+
+func TestMigrateWorkspace_RejectsNewerSchema(t *testing.T) {
+	base := t.TempDir()
+	if err := writeSchemaVersion(base, SchemaVersion+1); err != nil {
+		t.Fatalf("writeSchemaVersion failed: %v", err)
+	}
+	if _, err := MigrateWorkspace(base, MigrationOptions{}); err == nil {
+		t.Fatal("expected newer schema to be rejected")
+	}
+}
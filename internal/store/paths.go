@@ -40,6 +40,35 @@ func GetSessionsDir(workspaceID string, workspaceRootPath string) (string, error
 	return filepath.Join(base, "sessions"), nil
 }
 
+// GetWireLogDir returns the directory of per-session completion wire logs.
+func GetWireLogDir(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "wire"), nil
+}
+
+// GetFeatureFlagsPath returns the file holding a workspace's runtime
+// feature flag overrides.
+func GetFeatureFlagsPath(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "feature_flags.json"), nil
+}
+
+// GetWatchlistsPath returns the file holding a workspace's saved finance
+// watchlists.
+func GetWatchlistsPath(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "watchlists.json"), nil
+}
+
 // GetLockPath returns the lock file path for a workspace.
 func GetLockPath(workspaceID string, workspaceRootPath string) (string, error) {
 	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
@@ -49,6 +78,16 @@ func GetLockPath(workspaceID string, workspaceRootPath string) (string, error) {
 	return filepath.Join(base, "workspace.lock"), nil
 }
 
+// GetAttachmentsDir returns the directory holding files received by chat
+// adapters, such as voice notes.
+func GetAttachmentsDir(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "attachments"), nil
+}
+
 // GetSchedulerDir returns the scheduler directory for a workspace.
 func GetSchedulerDir(workspaceID string, workspaceRootPath string) (string, error) {
 	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
@@ -58,6 +97,15 @@ func GetSchedulerDir(workspaceID string, workspaceRootPath string) (string, erro
 	return filepath.Join(base, "scheduler"), nil
 }
 
+// GetKnowledgeDir returns the knowledge sync state directory for a workspace.
+func GetKnowledgeDir(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "knowledge"), nil
+}
+
 // GetSkillsDir returns the global skills directory.
 func GetSkillsDir() (string, error) {
 	home, err := os.UserHomeDir()
@@ -67,6 +115,16 @@ func GetSkillsDir() (string, error) {
 	return filepath.Join(home, ".heike", "skills"), nil
 }
 
+// GetBundledSkillsRoot returns the directory holding one extracted copy of
+// the bundled skill library per version.
+func GetBundledSkillsRoot() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".heike", "bundled-skills"), nil
+}
+
 // GetWorkspaceSkillsDir returns the workspace-specific skills directory.
 func GetWorkspaceSkillsDir(workspaceID string, workspaceRootPath string) (string, error) {
 	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
@@ -75,3 +133,12 @@ func GetWorkspaceSkillsDir(workspaceID string, workspaceRootPath string) (string
 	}
 	return filepath.Join(base, "skills"), nil
 }
+
+// GetSandboxDir returns the directory holding per-session sandboxes for a workspace.
+func GetSandboxDir(workspaceID string, workspaceRootPath string) (string, error) {
+	base, err := GetWorkspacePath(workspaceID, workspaceRootPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "sandbox"), nil
+}
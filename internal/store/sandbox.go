@@ -0,0 +1,64 @@
+package store
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sandboxPruneInterval is how often the worker removes expired session sandboxes.
+const sandboxPruneInterval = time.Hour
+
+// SessionSandboxPath returns the session's private directory under the
+// workspace sandbox, creating it on first use. Each call refreshes the
+// directory's modification time, which the retention policy measures from.
+func (w *Worker) SessionSandboxPath(sessionID string) (string, error) {
+	if sessionID == "" || sessionID != filepath.Base(sessionID) || sessionID == "." || sessionID == ".." {
+		return "", fmt.Errorf("invalid session id for sandbox: %q", sessionID)
+	}
+
+	path := filepath.Join(w.basePath, "sandbox", sessionID)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", fmt.Errorf("create session sandbox: %w", err)
+	}
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return "", fmt.Errorf("touch session sandbox: %w", err)
+	}
+	return path, nil
+}
+
+// pruneSandboxes removes session sandboxes unused for longer than the
+// retention period.
+func (w *Worker) pruneSandboxes() {
+	root := filepath.Join(w.basePath, "sandbox")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("Failed to list session sandboxes", "error", err)
+		}
+		return
+	}
+
+	cutoff := time.Now().Add(-w.sandboxRetention)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			slog.Error("Failed to remove session sandbox", "session", entry.Name(), "error", err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		slog.Info("Pruned expired session sandboxes", "count", removed)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionSandboxPath_CreatesPerSessionDirectory(t *testing.T) {
+	w := &Worker{basePath: t.TempDir(), sandboxRetention: time.Hour}
+
+	a, err := w.SessionSandboxPath("sess-a")
+	if err != nil {
+		t.Fatalf("SessionSandboxPath failed: %v", err)
+	}
+	b, err := w.SessionSandboxPath("sess-b")
+	if err != nil {
+		t.Fatalf("SessionSandboxPath failed: %v", err)
+	}
+	if a == b {
+		t.Fatalf("expected distinct sandboxes, got %q for both", a)
+	}
+	if info, err := os.Stat(a); err != nil || !info.IsDir() {
+		t.Fatalf("expected sandbox directory at %q: %v", a, err)
+	}
+
+	for _, bad := range []string{"", "..", "../escape", "a/b"} {
+		if _, err := w.SessionSandboxPath(bad); err == nil {
+			t.Fatalf("expected session id %q to be rejected", bad)
+		}
+	}
+}
+
+func TestPruneSandboxes_RemovesExpiredSessions(t *testing.T) {
+	w := &Worker{basePath: t.TempDir(), sandboxRetention: time.Hour}
+
+	stale, err := w.SessionSandboxPath("stale")
+	if err != nil {
+		t.Fatalf("SessionSandboxPath failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(stale, "out.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write sandbox file: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("age sandbox: %v", err)
+	}
+	fresh, err := w.SessionSandboxPath("fresh")
+	if err != nil {
+		t.Fatalf("SessionSandboxPath failed: %v", err)
+	}
+
+	w.pruneSandboxes()
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale sandbox removed, stat err=%v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("expected fresh sandbox kept: %v", err)
+	}
+}
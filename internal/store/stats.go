@@ -0,0 +1,193 @@
+package store
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskUsage is a workspace's on-disk size in bytes, by area. Rotated
+// transcript backups are counted separately from live sessions.
+type DiskUsage struct {
+	Sessions       int64 `json:"sessions_bytes"`
+	Vectors        int64 `json:"vectors_bytes"`
+	Sandbox        int64 `json:"sandbox_bytes"`
+	RotatedBackups int64 `json:"rotated_backups_bytes"`
+	Other          int64 `json:"other_bytes"`
+	Total          int64 `json:"total_bytes"`
+}
+
+// OpLatency summarizes how long the worker spent handling one operation.
+type OpLatency struct {
+	Count int64   `json:"count"`
+	AvgMS float64 `json:"avg_ms"`
+	MaxMS float64 `json:"max_ms"`
+}
+
+// Stats is a point-in-time view of a workspace store.
+type Stats struct {
+	WorkspaceID   string               `json:"workspace_id"`
+	Disk          DiskUsage            `json:"disk"`
+	InboxDepth    int                  `json:"inbox_depth"`
+	InboxCapacity int                  `json:"inbox_capacity"`
+	Ops           map[string]OpLatency `json:"ops"`
+	// TranscriptWatches is the number of open session stream subscriptions.
+	TranscriptWatches int `json:"transcript_watches"`
+	// PendingVectorUpserts counts failed vector writes waiting for a retry.
+	PendingVectorUpserts int `json:"pending_vector_upserts"`
+	// Warnings describes conditions that need attention.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (op Operation) String() string {
+	switch op {
+	case OpWriteTranscript:
+		return "write_transcript"
+	case OpSaveIdempotency:
+		return "save_idempotency"
+	case OpResetSession:
+		return "reset_session"
+	case OpGetSession:
+		return "get_session"
+	case OpSaveSession:
+		return "save_session"
+	case OpUpsertVector:
+		return "upsert_vector"
+	case OpSearchVectors:
+		return "search_vectors"
+	case OpReadTranscript:
+		return "read_transcript"
+	case OpWatchTranscript:
+		return "watch_transcript"
+	case OpListVectors:
+		return "list_vectors"
+	case OpReplaceVectors:
+		return "replace_vectors"
+	case OpListSessions:
+		return "list_sessions"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats reports disk usage, inbox depth and per-operation latencies. Disk
+// usage is measured by walking the workspace, so it costs a directory scan.
+// Inbox returns the queued requests and the inbox capacity without waiting
+// on the worker loop.
+func (w *Worker) Inbox() (depth, capacity int) {
+	return len(w.inbox), cap(w.inbox)
+}
+
+func (w *Worker) Stats() (Stats, error) {
+	disk, err := diskUsage(w.basePath)
+	if err != nil {
+		return Stats{}, err
+	}
+	depth, capacity := w.Inbox()
+	pending := int(w.vectorRetry.pending.Load())
+	var warnings []string
+	if pending > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d vector upserts failed and are waiting to be retried; check disk space and the vectors directory", pending))
+	}
+	return Stats{
+		WorkspaceID:   w.workspaceID,
+		Disk:          disk,
+		InboxDepth:    depth,
+		InboxCapacity: capacity,
+		Ops:           w.opStats.snapshot(),
+
+		TranscriptWatches:    w.watchers.count(),
+		PendingVectorUpserts: pending,
+		Warnings:             warnings,
+	}, nil
+}
+
+func diskUsage(basePath string) (DiskUsage, error) {
+	var usage DiskUsage
+	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == basePath {
+				return err
+			}
+			// Files can vanish mid-walk (rotation, sandbox pruning).
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		size := info.Size()
+		rel, err := filepath.Rel(basePath, path)
+		if err != nil {
+			return nil
+		}
+		area, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		switch {
+		case area == "sessions" && strings.HasSuffix(d.Name(), ".bak"):
+			usage.RotatedBackups += size
+		case area == "sessions":
+			usage.Sessions += size
+		case area == "vectors":
+			usage.Vectors += size
+		case area == "sandbox":
+			usage.Sandbox += size
+		default:
+			usage.Other += size
+		}
+		usage.Total += size
+		return nil
+	})
+	return usage, err
+}
+
+type opLatencyStats struct {
+	mu  sync.Mutex
+	ops map[Operation]*opLatencyEntry
+}
+
+type opLatencyEntry struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+func newOpLatencyStats() *opLatencyStats {
+	return &opLatencyStats{ops: make(map[Operation]*opLatencyEntry)}
+}
+
+func (s *opLatencyStats) record(op Operation, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.ops[op]
+	if !ok {
+		entry = &opLatencyEntry{}
+		s.ops[op] = entry
+	}
+	entry.count++
+	entry.total += d
+	if d > entry.max {
+		entry.max = d
+	}
+}
+
+func (s *opLatencyStats) snapshot() map[string]OpLatency {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]OpLatency, len(s.ops))
+	for op, entry := range s.ops {
+		out[op.String()] = OpLatency{
+			Count: entry.count,
+			AvgMS: float64(entry.total) / float64(entry.count) / float64(time.Millisecond),
+			MaxMS: float64(entry.max) / float64(time.Millisecond),
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskUsage_ByArea(t *testing.T) {
+	base := t.TempDir()
+	files := map[string]int{
+		"sessions/s1.jsonl":                    10,
+		"sessions/s1.jsonl.20260301120000.bak": 20,
+		"vectors/docs/0001.gob":                30,
+		"sandbox/s1/out.txt":                   40,
+		"governance/processed_keys.json":       5,
+	}
+	for rel, size := range files {
+		path := filepath.Join(base, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := diskUsage(base)
+	if err != nil {
+		t.Fatalf("diskUsage failed: %v", err)
+	}
+	want := DiskUsage{Sessions: 10, RotatedBackups: 20, Vectors: 30, Sandbox: 40, Other: 5, Total: 105}
+	if usage != want {
+		t.Fatalf("usage = %+v, want %+v", usage, want)
+	}
+}
+
+func TestOpLatencyStats_Snapshot(t *testing.T) {
+	stats := newOpLatencyStats()
+	stats.record(OpWriteTranscript, 2*time.Millisecond)
+	stats.record(OpWriteTranscript, 4*time.Millisecond)
+
+	snap := stats.snapshot()
+	got, ok := snap["write_transcript"]
+	if !ok {
+		t.Fatalf("missing write_transcript in %v", snap)
+	}
+	if got.Count != 2 || got.AvgMS != 3 || got.MaxMS != 4 {
+		t.Fatalf("unexpected latency: %+v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	stdatomic "sync/atomic"
+	"time"
+
+	"github.com/harunnryd/heike/internal/metrics"
+
+	"github.com/natefinch/atomic"
+)
+
+// vectorRetryFile holds upserts that failed and are waiting to be retried.
+// Like dimensions.json it sits next to the collections.
+const vectorRetryFile = "pending_upserts.json"
+
+// vectorRetryMaxBackoff caps the delay between retries.
+const vectorRetryMaxBackoff = 5 * time.Minute
+
+// vectorRetryQueue buffers failed vector upserts so memories survive a
+// transient failure such as a full disk. Only the worker loop touches it;
+// pending mirrors the length for Stats.
+type vectorRetryQueue struct {
+	path        string
+	maxEntries  int
+	baseBackoff time.Duration
+	backoff     time.Duration
+	due         <-chan time.Time
+	entries     []UpsertVectorPayload
+	pending     stdatomic.Int64
+}
+
+func loadVectorRetryQueue(vectorPath string, maxEntries int, backoff time.Duration) *vectorRetryQueue {
+	q := &vectorRetryQueue{
+		path:        filepath.Join(vectorPath, vectorRetryFile),
+		maxEntries:  maxEntries,
+		baseBackoff: backoff,
+		backoff:     backoff,
+	}
+	data, err := os.ReadFile(q.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read pending vector upserts", "error", err)
+		}
+		return q
+	}
+	if err := json.Unmarshal(data, &q.entries); err != nil {
+		slog.Warn("Failed to parse pending vector upserts, discarding them", "error", err)
+		q.entries = nil
+	}
+	q.pending.Store(int64(len(q.entries)))
+	return q
+}
+
+func (q *vectorRetryQueue) save() {
+	q.pending.Store(int64(len(q.entries)))
+	if len(q.entries) == 0 {
+		if err := os.Remove(q.path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove pending vector upserts", "error", err)
+		}
+		return
+	}
+	data, err := json.Marshal(q.entries)
+	if err == nil {
+		err = atomic.WriteFile(q.path, bytes.NewReader(data))
+	}
+	if err != nil {
+		// Entries stay queued in memory and are written with the next change.
+		slog.Error("Failed to persist pending vector upserts", "pending", len(q.entries), "error", err)
+	}
+}
+
+func (q *vectorRetryQueue) schedule() {
+	if q.due == nil && len(q.entries) > 0 {
+		q.due = time.After(q.backoff)
+	}
+}
+
+// discard drops queued upserts superseded by a successful write of the same
+// document.
+func (q *vectorRetryQueue) discard(collection, id string) {
+	kept := q.entries[:0]
+	for _, e := range q.entries {
+		if e.Collection != collection || e.ID != id {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) != len(q.entries) {
+		q.entries = kept
+		q.save()
+	}
+}
+
+// deferVectorUpsert queues p after upsertVector failed with err. Dimension
+// mismatches are returned as is, since retrying cannot fix them.
+func (w *Worker) deferVectorUpsert(p UpsertVectorPayload, err error) error {
+	if errors.Is(err, ErrVectorDimensionMismatch) {
+		return err
+	}
+	q := w.vectorRetry
+	replaced := false
+	for i, e := range q.entries {
+		if e.Collection == p.Collection && e.ID == p.ID {
+			q.entries[i] = p
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		if len(q.entries) >= q.maxEntries {
+			return fmt.Errorf("vector retry queue full (%d entries): %w", q.maxEntries, err)
+		}
+		q.entries = append(q.entries, p)
+	}
+	q.save()
+	q.schedule()
+	metrics.Inc("vector_upsert_retries_queued_total")
+	slog.Warn("Vector upsert failed, queued for retry", "collection", p.Collection, "id", p.ID, "pending", len(q.entries), "error", err)
+	return nil
+}
+
+// retryVectorUpserts replays queued upserts in order, stopping at the first
+// failure and backing off exponentially until the queue drains.
+func (w *Worker) retryVectorUpserts() {
+	q := w.vectorRetry
+	q.due = nil
+	done := 0
+	for i, p := range q.entries {
+		err := w.upsertVector(p)
+		if err == nil {
+			done++
+			continue
+		}
+		if errors.Is(err, ErrVectorDimensionMismatch) {
+			metrics.Inc("vector_upserts_dropped_total")
+			slog.Error("Dropping queued vector upsert", "collection", p.Collection, "id", p.ID, "error", err)
+			continue
+		}
+		q.entries = q.entries[i:]
+		q.backoff = min(q.backoff*2, vectorRetryMaxBackoff)
+		q.save()
+		q.schedule()
+		slog.Warn("Vector upsert retry failed", "pending", len(q.entries), "next_retry", q.backoff, "error", err)
+		return
+	}
+	q.entries = nil
+	q.backoff = q.baseBackoff
+	q.save()
+	slog.Info("Retried pending vector upserts", "written", done)
+}
@@ -1,8 +1,14 @@
 package store
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,4 +59,118 @@ func TestVectorOps(t *testing.T) {
 	require.Len(t, results, 1)
 	assert.Equal(t, id, results[0].ID)
 	assert.Less(t, results[0].Score, float32(0.9)) // Should be lower score
+
+	// Search with a limit above the collection size
+	results, err = w.SearchVectors(collection, vector, 5)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestVectorDimensionMismatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	w, err := NewWorker("test-vector-ws", "", RuntimeConfig{})
+	require.NoError(t, err)
+	w.Start()
+
+	require.NoError(t, w.UpsertVector("memories", "a", []float32{1, 0, 0}, nil, "three"))
+	err = w.UpsertVector("memories", "b", []float32{1, 0}, nil, "two")
+	assert.ErrorIs(t, err, ErrVectorDimensionMismatch)
+	assert.Contains(t, err.Error(), "heike vectors reembed --collection memories")
+	_, err = w.SearchVectors("memories", []float32{1, 0}, 1)
+	assert.ErrorIs(t, err, ErrVectorDimensionMismatch)
+
+	// Collections written before dimensions were recorded are probed.
+	w.Stop()
+	require.NoError(t, os.Remove(filepath.Join(w.basePath, "vectors", vectorDimensionsFile)))
+	w, err = NewWorker("test-vector-ws", "", RuntimeConfig{})
+	require.NoError(t, err)
+	w.Start()
+	defer w.Stop()
+	assert.ErrorIs(t, w.UpsertVector("memories", "b", []float32{1, 0}, nil, "two"), ErrVectorDimensionMismatch)
+	require.NoError(t, w.UpsertVector("memories", "c", []float32{0, 1, 0}, nil, "three again"))
+}
+
+func TestReplaceVectors_ChangesDimension(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	w, err := NewWorker("test-vector-ws", "", RuntimeConfig{})
+	require.NoError(t, err)
+	w.Start()
+	defer w.Stop()
+
+	require.NoError(t, w.UpsertVector("docs", "a", []float32{1, 0, 0}, map[string]string{"title": "A"}, "alpha"))
+	require.NoError(t, w.UpsertVector("docs", "b", []float32{0, 1, 0}, nil, "beta"))
+
+	docs, err := w.ListVectors("docs")
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	byID := map[string]VectorDocument{}
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	assert.Equal(t, "alpha", byID["a"].Content)
+	assert.Equal(t, "A", byID["a"].Metadata["title"])
+	assert.Len(t, byID["b"].Vector, 3)
+
+	_, err = w.ListVectors("missing")
+	assert.ErrorIs(t, err, heikeErrors.ErrNotFound)
+
+	require.NoError(t, w.ReplaceVectors("docs", []VectorDocument{
+		{ID: "a", Vector: []float32{1, 0}, Metadata: byID["a"].Metadata, Content: "alpha"},
+		{ID: "b", Vector: []float32{0, 1}, Content: "beta"},
+	}))
+	results, err := w.SearchVectors("docs", []float32{0, 1}, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "b", results[0].ID)
+	assert.ErrorIs(t, w.UpsertVector("docs", "c", []float32{1, 0, 0}, nil, "gamma"), ErrVectorDimensionMismatch)
+}
+
+func TestUpsertVector_RetriesFailedWrites(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	w, err := NewWorker("test-vector-retry-ws", "", RuntimeConfig{VectorRetryBackoff: 20 * time.Millisecond})
+	require.NoError(t, err)
+	w.Start()
+	defer w.Stop()
+
+	require.NoError(t, w.UpsertVector("notes", "seed", []float32{1, 0}, nil, "seed"))
+	// A directory where the document file belongs makes the write fail.
+	docPath := filepath.Join(w.basePath, "vectors", hashName("notes"), hashName("doc")+".gob")
+	require.NoError(t, os.MkdirAll(docPath, 0o755))
+
+	require.NoError(t, w.UpsertVector("notes", "doc", []float32{0, 1}, nil, "queued"))
+	stats, err := w.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.PendingVectorUpserts)
+	assert.Len(t, stats.Warnings, 1)
+	assert.FileExists(t, filepath.Join(w.basePath, "vectors", vectorRetryFile))
+
+	require.NoError(t, os.Remove(docPath))
+	require.Eventually(t, func() bool {
+		stats, err := w.Stats()
+		return err == nil && stats.PendingVectorUpserts == 0
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.FileExists(t, docPath)
+	assert.NoFileExists(t, filepath.Join(w.basePath, "vectors", vectorRetryFile))
+
+	assert.ErrorIs(t, w.UpsertVector("notes", "other", []float32{1, 0, 0}, nil, "bad"), ErrVectorDimensionMismatch)
+}
+
+func TestLoadVectorRetryQueue_RestoresPendingUpserts(t *testing.T) {
+	dir := t.TempDir()
+	data := `[{"Collection":"notes","ID":"doc","Vector":[0.5,0.5],"Metadata":null,"Content":"kept"}]`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, vectorRetryFile), []byte(data), 0o644))
+
+	q := loadVectorRetryQueue(dir, 10, time.Second)
+	require.Len(t, q.entries, 1)
+	assert.Equal(t, "kept", q.entries[0].Content)
+	assert.Equal(t, int64(1), q.pending.Load())
+}
+
+// hashName mirrors chromem-go's file naming for collections and documents.
+func hashName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:4])
 }
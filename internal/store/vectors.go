@@ -0,0 +1,224 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+
+	"github.com/natefinch/atomic"
+	"github.com/philippgille/chromem-go"
+)
+
+// ErrVectorDimensionMismatch is returned when a vector's dimension differs
+// from the vectors already in its collection, usually because
+// models.embedding changed. `heike vectors reembed` rebuilds the collection.
+var ErrVectorDimensionMismatch = errors.New("vector dimension mismatch")
+
+// vectorDimensionsFile records the dimension of each collection. chromem-go
+// skips plain files in its directory, so it lives next to the collections.
+const vectorDimensionsFile = "dimensions.json"
+
+// VectorDocument is a stored vector with its content.
+type VectorDocument struct {
+	ID       string
+	Vector   []float32
+	Metadata map[string]string
+	Content  string
+}
+
+type ListVectorsPayload struct {
+	Collection string
+}
+
+type ReplaceVectorsPayload struct {
+	Collection string
+	Documents  []VectorDocument
+}
+
+func loadVectorDimensions(vectorPath string) map[string]int {
+	dims := make(map[string]int)
+	data, err := os.ReadFile(filepath.Join(vectorPath, vectorDimensionsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read vector dimensions, probing collections again", "error", err)
+		}
+		return dims
+	}
+	if err := json.Unmarshal(data, &dims); err != nil {
+		slog.Warn("Failed to parse vector dimensions, probing collections again", "error", err)
+		return make(map[string]int)
+	}
+	return dims
+}
+
+func (w *Worker) saveVectorDimensions() error {
+	data, err := json.MarshalIndent(w.vectorDims, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomic.WriteFile(filepath.Join(w.basePath, "vectors", vectorDimensionsFile), bytes.NewReader(data))
+}
+
+func (w *Worker) setVectorDimension(collection string, dim int) {
+	if w.vectorDims[collection] == dim {
+		return
+	}
+	w.vectorDims[collection] = dim
+	if err := w.saveVectorDimensions(); err != nil {
+		slog.Warn("Failed to save vector dimensions", "collection", collection, "error", err)
+	}
+}
+
+// checkVectorDimension rejects vector when its collection holds vectors of
+// another dimension. Collections written before dimensions were recorded
+// are probed with a one-result query, which fails on a mismatch.
+func (w *Worker) checkVectorDimension(col *chromem.Collection, collection string, vector []float32) error {
+	if dim, ok := w.vectorDims[collection]; ok {
+		if dim != len(vector) {
+			return dimensionMismatch(collection, dim, len(vector))
+		}
+		return nil
+	}
+	if col != nil && col.Count() > 0 {
+		if _, err := col.QueryEmbedding(context.Background(), vector, 1, nil, nil); err != nil {
+			if isLengthMismatch(err) {
+				return dimensionMismatch(collection, 0, len(vector))
+			}
+			return err
+		}
+	}
+	w.setVectorDimension(collection, len(vector))
+	return nil
+}
+
+func dimensionMismatch(collection string, have, got int) error {
+	held := "vectors of another dimension"
+	if have > 0 {
+		held = fmt.Sprintf("%d-dimensional vectors", have)
+	}
+	slog.Warn("Vector dimension mismatch", "collection", collection, "have", have, "got", got)
+	return fmt.Errorf("collection %s holds %s but got %d; run `heike vectors reembed --collection %s`: %w",
+		collection, held, got, collection, ErrVectorDimensionMismatch)
+}
+
+// isLengthMismatch reports whether err is chromem-go's error for comparing
+// vectors of different lengths.
+func isLengthMismatch(err error) bool {
+	return strings.Contains(err.Error(), "vectors must have the same length")
+}
+
+// listVectors reads every document of a collection. chromem-go has no
+// listing API, so the collection is exported and decoded.
+func (w *Worker) listVectors(collection string) ([]VectorDocument, error) {
+	if w.vectorDB.GetCollection(collection, nil) == nil {
+		return nil, heikeErrors.NotFound(fmt.Sprintf("vector collection %s", collection))
+	}
+	var buf bytes.Buffer
+	if err := w.vectorDB.ExportToWriter(&buf, false, "", collection); err != nil {
+		return nil, err
+	}
+	// Mirrors the structure DB.ExportToWriter encodes.
+	var exported struct {
+		Collections map[string]*struct {
+			Documents map[string]*chromem.Document
+		}
+	}
+	if err := gob.NewDecoder(&buf).Decode(&exported); err != nil {
+		return nil, fmt.Errorf("decode vector collection %s: %w", collection, err)
+	}
+	col := exported.Collections[collection]
+	if col == nil {
+		return []VectorDocument{}, nil
+	}
+	docs := make([]VectorDocument, 0, len(col.Documents))
+	for _, doc := range col.Documents {
+		docs = append(docs, VectorDocument{
+			ID:       doc.ID,
+			Vector:   doc.Embedding,
+			Metadata: doc.Metadata,
+			Content:  doc.Content,
+		})
+	}
+	return docs, nil
+}
+
+// replaceVectors swaps a collection's documents for docs, which may have a
+// new dimension.
+func (w *Worker) replaceVectors(p ReplaceVectorsPayload) error {
+	dim := 0
+	documents := make([]chromem.Document, 0, len(p.Documents))
+	for _, doc := range p.Documents {
+		if dim == 0 {
+			dim = len(doc.Vector)
+		}
+		if len(doc.Vector) == 0 || len(doc.Vector) != dim {
+			return fmt.Errorf("document %s: vectors must all have %d dimensions", doc.ID, dim)
+		}
+		documents = append(documents, chromem.Document{
+			ID:        doc.ID,
+			Metadata:  doc.Metadata,
+			Embedding: doc.Vector,
+			Content:   doc.Content,
+		})
+	}
+
+	if err := w.vectorDB.DeleteCollection(p.Collection); err != nil {
+		return err
+	}
+	delete(w.vectorDims, p.Collection)
+	col, err := w.vectorDB.GetOrCreateCollection(p.Collection, nil, nil)
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 {
+		return w.saveVectorDimensions()
+	}
+	if err := col.AddDocuments(context.Background(), documents, 1); err != nil {
+		return err
+	}
+	w.setVectorDimension(p.Collection, dim)
+	return nil
+}
+
+// HasVectorCollection reports whether collection exists. chromem-go guards
+// its collections, so this bypasses the inbox.
+func (w *Worker) HasVectorCollection(collection string) bool {
+	return w.vectorDB.GetCollection(collection, nil) != nil
+}
+
+// ListVectors returns every document of a collection with its vector.
+func (w *Worker) ListVectors(collection string) ([]VectorDocument, error) {
+	res := make(chan error, 1)
+	resp := make(chan interface{}, 1)
+	w.inbox <- Request{
+		Op:       OpListVectors,
+		Payload:  ListVectorsPayload{Collection: collection},
+		Result:   res,
+		Response: resp,
+	}
+	if err := <-res; err != nil {
+		return nil, err
+	}
+	return (<-resp).([]VectorDocument), nil
+}
+
+// ReplaceVectors replaces all documents of a collection in one store
+// operation, resetting its recorded dimension.
+func (w *Worker) ReplaceVectors(collection string, docs []VectorDocument) error {
+	res := make(chan error, 1)
+	w.inbox <- Request{
+		Op:      OpReplaceVectors,
+		Payload: ReplaceVectorsPayload{Collection: collection, Documents: docs},
+		Result:  res,
+	}
+	return <-res
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/harunnryd/heike/internal/metrics"
+)
+
+// transcriptWatchBuffer is how many appended lines a watch may fall behind
+// before it is dropped.
+const transcriptWatchBuffer = 256
+
+// TranscriptLine is a line appended to a transcript. Seq is its 1-based
+// position in the current transcript file; it restarts at 1 after the
+// transcript is rotated or reset.
+type TranscriptLine struct {
+	Seq  int
+	Data string
+}
+
+// TranscriptWatch receives the lines appended to one session's transcript.
+type TranscriptWatch struct {
+	watchers  *transcriptWatchers
+	sessionID string
+	lines     chan TranscriptLine
+	seq       int
+}
+
+// Lines returns the appended lines. The channel is closed when the watch is
+// closed, falls too far behind, or the worker stops; callers that still
+// want lines watch again and resume from the last Seq they received.
+func (t *TranscriptWatch) Lines() <-chan TranscriptLine {
+	return t.lines
+}
+
+// Close stops the watch. It is safe to call more than once.
+func (t *TranscriptWatch) Close() {
+	t.watchers.remove(t)
+}
+
+// transcriptWatchers tracks open watches per session. Lines are published
+// from the worker loop, so they reach watches in write order.
+type transcriptWatchers struct {
+	mu      sync.Mutex
+	watches map[string]map[*TranscriptWatch]struct{}
+}
+
+func newTranscriptWatchers() *transcriptWatchers {
+	return &transcriptWatchers{watches: make(map[string]map[*TranscriptWatch]struct{})}
+}
+
+func (ws *transcriptWatchers) add(sessionID string, seq int) *TranscriptWatch {
+	watch := &TranscriptWatch{
+		watchers:  ws,
+		sessionID: sessionID,
+		lines:     make(chan TranscriptLine, transcriptWatchBuffer),
+		seq:       seq,
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.watches[sessionID] == nil {
+		ws.watches[sessionID] = make(map[*TranscriptWatch]struct{})
+	}
+	ws.watches[sessionID][watch] = struct{}{}
+	return watch
+}
+
+func (ws *transcriptWatchers) remove(watch *TranscriptWatch) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.removeLocked(watch)
+}
+
+func (ws *transcriptWatchers) removeLocked(watch *TranscriptWatch) {
+	session := ws.watches[watch.sessionID]
+	if _, ok := session[watch]; !ok {
+		return
+	}
+	delete(session, watch)
+	if len(session) == 0 {
+		delete(ws.watches, watch.sessionID)
+	}
+	close(watch.lines)
+}
+
+// publish hands an appended line to the session's watches. A watch whose
+// buffer is full is dropped rather than blocking the worker.
+func (ws *transcriptWatchers) publish(sessionID string, data []byte) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for watch := range ws.watches[sessionID] {
+		watch.seq++
+		select {
+		case watch.lines <- TranscriptLine{Seq: watch.seq, Data: string(data)}:
+		default:
+			slog.Debug("Transcript watch fell behind, dropping it", "session", sessionID)
+			metrics.Inc("transcript_watches_dropped_total")
+			ws.removeLocked(watch)
+		}
+	}
+}
+
+// restart resets line numbering after the session's transcript file was
+// replaced.
+func (ws *transcriptWatchers) restart(sessionID string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for watch := range ws.watches[sessionID] {
+		watch.seq = 0
+	}
+}
+
+func (ws *transcriptWatchers) count() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	n := 0
+	for _, session := range ws.watches {
+		n += len(session)
+	}
+	return n
+}
+
+func (ws *transcriptWatchers) closeAll() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	for _, session := range ws.watches {
+		for watch := range session {
+			ws.removeLocked(watch)
+		}
+	}
+}
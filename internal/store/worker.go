@@ -31,6 +31,10 @@ const (
 	OpUpsertVector
 	OpSearchVectors
 	OpReadTranscript
+	OpWatchTranscript
+	OpListVectors
+	OpReplaceVectors
+	OpListSessions
 )
 
 type Request struct {
@@ -76,6 +80,15 @@ type ReadTranscriptPayload struct {
 	Limit     int // 0 = all
 }
 
+type WatchTranscriptPayload struct {
+	SessionID string
+}
+
+type watchTranscriptResult struct {
+	lines []string
+	watch *TranscriptWatch
+}
+
 type VectorResult struct {
 	ID       string
 	Score    float32
@@ -87,14 +100,20 @@ type Worker struct {
 	workspaceID              string
 	basePath                 string
 	inbox                    chan Request
-	idemStore                *idempotency.Store
+	idemStore                idempotency.Checker
 	fileLock                 *FileLock
 	quit                     chan struct{}
 	wg                       sync.WaitGroup
 	sessionIndex             *SessionIndex
 	vectorDB                 *chromem.DB
+	vectorDims               map[string]int
 	running                  stdatomic.Bool
 	transcriptRotateMaxBytes int64
+	events                   *eventTracker
+	sandboxRetention         time.Duration
+	opStats                  *opLatencyStats
+	watchers                 *transcriptWatchers
+	vectorRetry              *vectorRetryQueue
 }
 
 type RuntimeConfig struct {
@@ -103,6 +122,20 @@ type RuntimeConfig struct {
 	LockMaxRetry             int
 	InboxSize                int
 	TranscriptRotateMaxBytes int64
+	EventStatusMaxEntries    int
+	// SandboxRetention is how long an idle session sandbox is kept.
+	SandboxRetention time.Duration
+	// VectorRetryBackoff is the first delay before a failed vector upsert
+	// is retried; it doubles on each failure.
+	VectorRetryBackoff time.Duration
+	// VectorRetryMaxEntries caps the failed upserts kept for retry.
+	VectorRetryMaxEntries int
+	// Migration controls the schema migrations applied once the workspace
+	// lock is held.
+	Migration MigrationOptions
+	// Idempotency overrides the per-workspace processed_keys.json store,
+	// e.g. with a Redis-backed checker shared by replicas.
+	Idempotency idempotency.Checker
 }
 
 func NewWorker(workspaceID string, workspaceRootPath string, runtimeCfg RuntimeConfig) (*Worker, error) {
@@ -117,6 +150,7 @@ func NewWorker(workspaceID string, workspaceRootPath string, runtimeCfg RuntimeC
 		filepath.Join(basePath, "governance"),
 		filepath.Join(basePath, "sandbox"),
 		filepath.Join(basePath, "scheduler"),
+		filepath.Join(basePath, "artifacts"),
 	}
 	for _, d := range dirs {
 		if err := os.MkdirAll(d, 0755); err != nil {
@@ -147,6 +181,26 @@ func NewWorker(workspaceID string, workspaceRootPath string, runtimeCfg RuntimeC
 	if runtimeCfg.TranscriptRotateMaxBytes <= 0 {
 		runtimeCfg.TranscriptRotateMaxBytes = config.DefaultStoreTranscriptRotateMaxBytes
 	}
+	if runtimeCfg.EventStatusMaxEntries <= 0 {
+		runtimeCfg.EventStatusMaxEntries = config.DefaultStoreEventStatusMaxEntries
+	}
+	if runtimeCfg.SandboxRetention <= 0 {
+		sandboxRetention, err := config.DurationOrDefault("", config.DefaultStoreSandboxRetention)
+		if err != nil {
+			return nil, fmt.Errorf("parse default store sandbox retention: %w", err)
+		}
+		runtimeCfg.SandboxRetention = sandboxRetention
+	}
+	if runtimeCfg.VectorRetryBackoff <= 0 {
+		vectorRetryBackoff, err := config.DurationOrDefault("", config.DefaultStoreVectorRetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("parse default store vector retry backoff: %w", err)
+		}
+		runtimeCfg.VectorRetryBackoff = vectorRetryBackoff
+	}
+	if runtimeCfg.VectorRetryMaxEntries <= 0 {
+		runtimeCfg.VectorRetryMaxEntries = config.DefaultStoreVectorRetryMaxEntries
+	}
 
 	// File Lock (Single Instance per Workspace)
 	fileLock, err := NewFileLock(workspaceID, basePath, &FileLockConfig{
@@ -158,12 +212,27 @@ func NewWorker(workspaceID string, workspaceRootPath string, runtimeCfg RuntimeC
 		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 
-	// Load Idempotency Store
-	idemPath := filepath.Join(basePath, "governance", "processed_keys.json")
-	idemStore, err := idempotency.NewStore(idemPath)
+	report, err := MigrateWorkspace(basePath, runtimeCfg.Migration)
 	if err != nil {
 		fileLock.Unlock()
-		return nil, fmt.Errorf("failed to load idempotency store: %w", err)
+		return nil, fmt.Errorf("failed to migrate workspace: %w", err)
+	}
+	if report.DryRun && len(report.Pending) > 0 {
+		slog.Warn("Workspace migrations pending (dry run)", "workspace", workspaceID, "from", report.FromVersion, "to", report.ToVersion, "pending", report.Pending)
+	} else if len(report.Applied) > 0 {
+		slog.Info("Workspace migrated", "workspace", workspaceID, "from", report.FromVersion, "to", report.ToVersion, "applied", report.Applied, "backup", report.BackupPath)
+	}
+
+	// Load Idempotency Store
+	idemStore := runtimeCfg.Idempotency
+	if idemStore == nil {
+		idemPath := filepath.Join(basePath, "governance", "processed_keys.json")
+		fileStore, err := idempotency.NewStore(idemPath)
+		if err != nil {
+			fileLock.Unlock()
+			return nil, fmt.Errorf("failed to load idempotency store: %w", err)
+		}
+		idemStore = fileStore
 	}
 
 	// Load Session Index
@@ -197,7 +266,13 @@ func NewWorker(workspaceID string, workspaceRootPath string, runtimeCfg RuntimeC
 		quit:                     make(chan struct{}),
 		sessionIndex:             sessionIndex,
 		vectorDB:                 vectorDB,
+		vectorDims:               loadVectorDimensions(vectorPath),
 		transcriptRotateMaxBytes: runtimeCfg.TranscriptRotateMaxBytes,
+		events:                   newEventTracker(runtimeCfg.EventStatusMaxEntries),
+		sandboxRetention:         runtimeCfg.SandboxRetention,
+		opStats:                  newOpLatencyStats(),
+		watchers:                 newTranscriptWatchers(),
+		vectorRetry:              loadVectorRetryQueue(vectorPath, runtimeCfg.VectorRetryMaxEntries, runtimeCfg.VectorRetryBackoff),
 	}, nil
 }
 
@@ -223,13 +298,24 @@ func (w *Worker) loop() {
 		}
 	}
 
+	w.pruneSandboxes()
+	sandboxTicker := time.NewTicker(sandboxPruneInterval)
+	defer sandboxTicker.Stop()
+	w.vectorRetry.schedule()
+
 	for {
 		select {
 		case req := <-w.inbox:
+			started := time.Now()
 			err := w.handle(req)
+			w.opStats.record(req.Op, time.Since(started))
 			if req.Result != nil {
 				req.Result <- err
 			}
+		case <-sandboxTicker.C:
+			w.pruneSandboxes()
+		case <-w.vectorRetry.due:
+			w.retryVectorUpserts()
 		case <-w.quit:
 			slog.Info("StoreWorker stopping")
 			return
@@ -268,6 +354,15 @@ func (w *Worker) handle(req Request) error {
 			}
 		}
 		return nil
+	case OpListSessions:
+		ids := make([]string, 0, len(w.sessionIndex.Sessions))
+		for id := range w.sessionIndex.Sessions {
+			ids = append(ids, id)
+		}
+		if req.Response != nil {
+			req.Response <- ids
+		}
+		return nil
 	case OpSaveSession:
 		p, ok := req.Payload.(SaveSessionPayload)
 		if !ok {
@@ -280,7 +375,27 @@ func (w *Worker) handle(req Request) error {
 		if !ok {
 			return fmt.Errorf("invalid payload for UpsertVector")
 		}
-		return w.upsertVector(p)
+		if err := w.upsertVector(p); err != nil {
+			return w.deferVectorUpsert(p, err)
+		}
+		w.vectorRetry.discard(p.Collection, p.ID)
+		return nil
+	case OpListVectors:
+		p, ok := req.Payload.(ListVectorsPayload)
+		if !ok {
+			return fmt.Errorf("invalid payload for ListVectors")
+		}
+		docs, err := w.listVectors(p.Collection)
+		if req.Response != nil {
+			req.Response <- docs
+		}
+		return err
+	case OpReplaceVectors:
+		p, ok := req.Payload.(ReplaceVectorsPayload)
+		if !ok {
+			return fmt.Errorf("invalid payload for ReplaceVectors")
+		}
+		return w.replaceVectors(p)
 	case OpSearchVectors:
 		p, ok := req.Payload.(SearchVectorsPayload)
 		if !ok {
@@ -301,6 +416,22 @@ func (w *Worker) handle(req Request) error {
 			req.Response <- lines
 		}
 		return err
+	case OpWatchTranscript:
+		p, ok := req.Payload.(WatchTranscriptPayload)
+		if !ok {
+			return fmt.Errorf("invalid payload for WatchTranscript")
+		}
+		lines, err := w.readTranscript(p.SessionID, 0)
+		if err != nil {
+			return err
+		}
+		// Registered inside the loop so no append falls between the read
+		// and the watch.
+		watch := w.watchers.add(p.SessionID, len(lines))
+		if req.Response != nil {
+			req.Response <- watchTranscriptResult{lines: lines, watch: watch}
+		}
+		return nil
 	default:
 		return fmt.Errorf("unknown operation: %d", req.Op)
 	}
@@ -308,6 +439,9 @@ func (w *Worker) handle(req Request) error {
 
 func (w *Worker) readTranscript(sessionID string, limit int) ([]string, error) {
 	path := filepath.Join(w.basePath, "sessions", sessionID+".jsonl")
+	if limit > 0 {
+		return readTranscriptTail(path, limit)
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -321,9 +455,48 @@ func (w *Worker) readTranscript(sessionID string, limit int) ([]string, error) {
 		return []string{}, nil
 	}
 
-	if limit > 0 && len(lines) > limit {
-		// Return last N lines
-		return lines[len(lines)-limit:], nil
+	return lines, nil
+}
+
+// transcriptTailChunk is how much of a transcript readTranscriptTail reads
+// per step, walking back from the end of the file.
+const transcriptTailChunk = 64 << 10
+
+// readTranscriptTail returns the last limit lines of the transcript at
+// path without loading the whole file.
+func readTranscriptTail(path string, limit int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	offset := info.Size()
+	// limit newlines before the trailing ones mean limit complete lines.
+	for offset > 0 && bytes.Count(bytes.TrimRight(data, " \t\r\n"), []byte("\n")) < limit {
+		n := min(int64(transcriptTailChunk), offset)
+		offset -= n
+		chunk := make([]byte, n)
+		if _, err := f.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return []string{}, nil
+	}
+	if len(lines) > limit {
+		lines = lines[len(lines)-limit:]
 	}
 	return lines, nil
 }
@@ -334,6 +507,9 @@ func (w *Worker) upsertVector(p UpsertVectorPayload) error {
 	if err != nil {
 		return err
 	}
+	if err := w.checkVectorDimension(col, p.Collection, p.Vector); err != nil {
+		return err
+	}
 	// AddDocuments is upsert in chromem
 	return col.AddDocuments(context.Background(), []chromem.Document{
 		{
@@ -352,11 +528,24 @@ func (w *Worker) searchVectors(p SearchVectorsPayload) ([]VectorResult, error) {
 		return []VectorResult{}, nil
 	}
 
+	// QueryEmbedding rejects nResults above the document count.
+	limit := min(p.Limit, col.Count())
+	if limit <= 0 {
+		return []VectorResult{}, nil
+	}
+
+	if dim, ok := w.vectorDims[p.Collection]; ok && dim != len(p.Vector) {
+		return nil, dimensionMismatch(p.Collection, dim, len(p.Vector))
+	}
 	// QueryEmbedding(ctx, embedding, nResults, where, whereDocument)
-	docs, err := col.QueryEmbedding(context.Background(), p.Vector, p.Limit, nil, nil)
+	docs, err := col.QueryEmbedding(context.Background(), p.Vector, limit, nil, nil)
 	if err != nil {
+		if isLengthMismatch(err) {
+			return nil, dimensionMismatch(p.Collection, w.vectorDims[p.Collection], len(p.Vector))
+		}
 		return nil, err
 	}
+	w.setVectorDimension(p.Collection, len(p.Vector))
 
 	var results []VectorResult
 	for _, doc := range docs {
@@ -398,7 +587,11 @@ func (w *Worker) appendTranscript(sessionID string, data []byte) error {
 	if _, err := f.WriteString("\n"); err != nil {
 		return err
 	}
-	return f.Sync()
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	w.watchers.publish(sessionID, data)
+	return nil
 }
 
 func (w *Worker) resetSession(sessionID string) error {
@@ -406,6 +599,7 @@ func (w *Worker) resetSession(sessionID string) error {
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	w.watchers.restart(sessionID)
 	// Remove from index
 	delete(w.sessionIndex.Sessions, sessionID)
 	return w.saveSessionIndex()
@@ -440,6 +634,7 @@ func (w *Worker) checkAndRotate(sessionID, path string) error {
 		return fmt.Errorf("failed to create new transcript: %w", err)
 	}
 	f.Close()
+	w.watchers.restart(sessionID)
 
 	return nil
 }
@@ -466,38 +661,42 @@ func (w *Worker) ResetSession(sessionID string) error {
 	return <-res
 }
 
-// ListSessions lists all session IDs in the workspace.
-// This is a direct read operation, safe if concurrent with writes as file system handles dir listing.
+// ListSessions lists the IDs of sessions that have a transcript or an index
+// entry, so sessions created without a message yet are included. The index
+// is read through the worker loop; transcripts are found by a directory scan,
+// which also picks up files the index does not know about.
 func (w *Worker) ListSessions() ([]string, error) {
-	// Better to use the index now
-	// But let's stick to filesystem scan for robustness or use index?
-	// The docs say index.json is the registry.
-	// But let's keep it consistent with what we have in memory.
-	// Since GetSession uses memory, ListSessions should too.
-	// However, ListSessions is synchronous and runs in the caller's goroutine.
-	// Accessing w.sessionIndex (map) concurrently is unsafe!
-	// So ListSessions MUST go through the worker loop or use a mutex.
-	// The previous implementation used os.ReadDir which is safe.
-	// Let's stick to os.ReadDir for now to avoid breaking changes, or implement OpListSessions.
-	// Given we have the index in memory, let's use it but safely.
-	// Actually, let's keep the os.ReadDir implementation as it is robust against manual file deletions.
-	// But wait, if I use index.json, I should rely on it.
-	// For now, let's keep os.ReadDir as it was "safe" before.
-	// But strict adherence to architecture says "Use Index".
-	// Let's implement OpListSessions for correctness.
-
-	// For now, let's keep the old implementation to minimize friction, but note that it might be out of sync with index.
+	res := make(chan error, 1)
+	resp := make(chan interface{}, 1)
+	w.inbox <- Request{
+		Op:       OpListSessions,
+		Result:   res,
+		Response: resp,
+	}
+	if err := <-res; err != nil {
+		return nil, err
+	}
+	indexed := (<-resp).([]string)
+
+	seen := make(map[string]bool, len(indexed))
+	sessions := make([]string, 0, len(indexed))
+	for _, id := range indexed {
+		seen[id] = true
+		sessions = append(sessions, id)
+	}
+
 	sessionsDir := filepath.Join(w.basePath, "sessions")
 	entries, err := os.ReadDir(sessionsDir)
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
-
-	var sessions []string
 	for _, entry := range entries {
 		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".jsonl") {
 			id := strings.TrimSuffix(entry.Name(), ".jsonl")
-			sessions = append(sessions, id)
+			if !seen[id] {
+				seen[id] = true
+				sessions = append(sessions, id)
+			}
 		}
 	}
 	return sessions, nil
@@ -532,6 +731,9 @@ func (w *Worker) SaveSession(session *SessionMeta) error {
 	return <-res
 }
 
+// UpsertVector adds or replaces a document. A write that fails for any
+// reason but a dimension mismatch is queued and retried in the background,
+// so it only returns an error when the retry queue is full.
 func (w *Worker) UpsertVector(collection, id string, vector []float32, metadata map[string]string, content string) error {
 	res := make(chan error, 1)
 	w.inbox <- Request{
@@ -587,6 +789,24 @@ func (w *Worker) ReadTranscript(sessionID string, limit int) ([]string, error) {
 	return val.([]string), nil
 }
 
+// WatchTranscript returns the session's transcript so far and a watch that
+// receives every line appended after it.
+func (w *Worker) WatchTranscript(sessionID string) ([]string, *TranscriptWatch, error) {
+	res := make(chan error, 1)
+	resp := make(chan interface{}, 1)
+	w.inbox <- Request{
+		Op:       OpWatchTranscript,
+		Payload:  WatchTranscriptPayload{SessionID: sessionID},
+		Result:   res,
+		Response: resp,
+	}
+	if err := <-res; err != nil {
+		return nil, nil, err
+	}
+	val := (<-resp).(watchTranscriptResult)
+	return val.lines, val.watch, nil
+}
+
 func (w *Worker) SaveIdempotency() {
 	// Fire and forget usually, but we might want to block if critical
 	w.inbox <- Request{
@@ -622,11 +842,31 @@ func (w *Worker) CheckAndMarkKey(key string, ttl time.Duration) bool {
 	return exists
 }
 
+// ForgetKey unmarks an idempotency key, e.g. when the event it guarded was
+// rejected before being queued.
+func (w *Worker) ForgetKey(key string) {
+	w.idemStore.Forget(key)
+	w.SaveIdempotency()
+}
+
+// TrackEvent records that event id reached state. Like the idempotency
+// checker it is safe for concurrent use and bypasses the inbox.
+func (w *Worker) TrackEvent(id, sessionID string, state EventState, errMsg string) {
+	w.events.set(id, sessionID, state, errMsg)
+}
+
+// EventStatus returns the tracked status of event id. Only the most recent
+// store.event_status_max_entries events are kept.
+func (w *Worker) EventStatus(id string) (EventStatus, bool) {
+	return w.events.get(id)
+}
+
 func (w *Worker) Stop() {
 	slog.Info("StoreWorker Stop called", "workspace", w.workspaceID, "lock_held", w.fileLock.IsLocked())
 
 	close(w.quit)
 	w.wg.Wait()
+	w.watchers.closeAll()
 
 	if w.fileLock.IsLocked() {
 		w.fileLock.Unlock()
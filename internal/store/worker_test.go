@@ -1,9 +1,12 @@
 package store
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTranscriptRotation(t *testing.T) {
@@ -68,3 +71,128 @@ func TestTranscriptRotation(t *testing.T) {
 		t.Error("Backup file not found")
 	}
 }
+
+func TestReadTranscript_LimitReadsTail(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	w, err := NewWorker("test-ws", "", RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	// Enough lines to span several tail chunks.
+	const total = 3000
+	for i := 0; i < total; i++ {
+		line := fmt.Sprintf(`{"id":"%d","content":"%s"}`, i, strings.Repeat("x", 40))
+		if err := w.WriteTranscript("tail-sess", []byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := w.ReadTranscript("tail-sess", 0)
+	if err != nil || len(all) != total {
+		t.Fatalf("full read = %d lines, %v", len(all), err)
+	}
+	for _, limit := range []int{1, 3, 1500, total, total + 10} {
+		lines, err := w.ReadTranscript("tail-sess", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := all[max(0, total-limit):]
+		if len(lines) != len(want) || lines[0] != want[0] || lines[len(lines)-1] != want[len(want)-1] {
+			t.Fatalf("limit %d: got %d lines from %q, want %d from %q", limit, len(lines), lines[0], len(want), want[0])
+		}
+	}
+	if lines, err := w.ReadTranscript("missing-sess", 5); err != nil || len(lines) != 0 {
+		t.Fatalf("missing transcript = %v, %v", lines, err)
+	}
+}
+
+func TestWatchTranscript_ReceivesAppendedLines(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	w, err := NewWorker("test-ws", "", RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	sessionID := "watch-sess"
+	if err := w.WriteTranscript(sessionID, []byte(`{"n":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	lines, watch, err := w.WatchTranscript(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0] != `{"n":1}` {
+		t.Fatalf("backlog = %v", lines)
+	}
+
+	if err := w.WriteTranscript(sessionID, []byte(`{"n":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteTranscript("other-sess", []byte(`{"other":true}`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-watch.Lines():
+		if line.Seq != 2 || line.Data != `{"n":2}` {
+			t.Fatalf("line = %+v", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("appended line was not delivered")
+	}
+
+	if err := w.ResetSession(sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteTranscript(sessionID, []byte(`{"n":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	if line := <-watch.Lines(); line.Seq != 1 {
+		t.Fatalf("seq after reset = %d, want 1", line.Seq)
+	}
+
+	if stats, err := w.Stats(); err != nil || stats.TranscriptWatches != 1 {
+		t.Fatalf("stats watches = %d, err = %v", stats.TranscriptWatches, err)
+	}
+	watch.Close()
+	watch.Close()
+	if stats, _ := w.Stats(); stats.TranscriptWatches != 0 {
+		t.Fatalf("watches after close = %d, want 0", stats.TranscriptWatches)
+	}
+	if _, ok := <-watch.Lines(); ok {
+		t.Fatal("closed watch should not receive lines")
+	}
+}
+
+func TestWatchTranscript_DropsLaggingWatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	w, err := NewWorker("test-ws", "", RuntimeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Start()
+	defer w.Stop()
+
+	_, watch, err := w.WatchTranscript("lag-sess")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= transcriptWatchBuffer; i++ {
+		if err := w.WriteTranscript("lag-sess", []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	received := 0
+	for range watch.Lines() {
+		received++
+	}
+	if received != transcriptWatchBuffer {
+		t.Fatalf("received %d lines before drop, want %d", received, transcriptWatchBuffer)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/harunnryd/heike/internal/httpclient"
 )
 
 // BuiltinOptions carries runtime dependencies needed by built-in tool factories.
@@ -12,17 +14,143 @@ type BuiltinOptions struct {
 	WebTimeout          time.Duration
 	WebBaseURL          string
 	WebMaxContentLength int
+	// WebEngine is the search_query backend, one of WebEngines; empty
+	// uses Bing.
+	WebEngine           string
+	WebAPIKey           string
 	WeatherBaseURL      string
 	WeatherTimeout      time.Duration
 	FinanceBaseURL      string
 	FinanceTimeout      time.Duration
-	SportsBaseURL       string
-	SportsTimeout       time.Duration
-	ImageQueryBaseURL   string
-	ImageQueryTimeout   time.Duration
-	ScreenshotTimeout   time.Duration
-	ScreenshotRenderer  string
-	ApplyPatchCommand   string
+	FinanceChartBaseURL string
+	// FinanceCryptoBaseURL is the CoinGecko API root; empty quotes crypto
+	// through Yahoo like other assets.
+	FinanceCryptoBaseURL string
+	FinanceCryptoAPIKey  string
+	// FinanceWatchlistsPath is the workspace file holding saved
+	// watchlists; empty disables them.
+	FinanceWatchlistsPath string
+	SportsBaseURL         string
+	SportsTimeout         time.Duration
+	ImageQueryBaseURL     string
+	ImageQueryTimeout     time.Duration
+	ScreenshotTimeout     time.Duration
+	ScreenshotRenderer    string
+	// ScreenshotChromePath is the browser used when ScreenshotRenderer is
+	// "chrome"; empty searches PATH.
+	ScreenshotChromePath string
+	ApplyPatchCommand    string
+	// File tool limits; zero sizes use the defaults.
+	FilesMaxReadBytes      int64
+	FilesMaxWriteBytes     int64
+	FilesAllowedExtensions []string
+	FilesDeniedExtensions  []string
+	// http_request limits; zero sizes use the defaults.
+	HTTPRequestTimeout          time.Duration
+	HTTPRequestAllowedDomains   []string
+	HTTPRequestDeniedDomains    []string
+	HTTPRequestMaxRequestBytes  int64
+	HTTPRequestMaxResponseBytes int64
+	HTTPRequestAllowPrivate     bool
+	// sql_query settings; zero limits use the defaults.
+	SQLTimeout         time.Duration
+	SQLMaxRows         int
+	SQLMaxOutputBytes  int64
+	SQLReadOnly        bool
+	SQLSQLiteCommand   string
+	SQLPostgresCommand string
+	SQLDatabases       []SQLDatabase
+	GitHubBaseURL      string
+	GitHubTimeout      time.Duration
+	GitHubToken        string
+	Calendar           CalendarOptions
+	// news settings; zero limits use the defaults.
+	NewsTimeout     time.Duration
+	NewsCacheTTL    time.Duration
+	NewsMaxItems    int
+	NewsFeeds       []NewsFeed
+	ConvertBaseURL  string
+	ConvertTimeout  time.Duration
+	ConvertCacheTTL time.Duration
+	Email           EmailOptions
+	// python_exec settings; zero limits use the defaults.
+	PythonCommand        string
+	PythonVenv           string
+	PythonTimeout        time.Duration
+	PythonMaxMemoryBytes int64
+	PythonMaxOutputBytes int64
+	// HTTPClients supplies the pooled clients for tools that call out over
+	// HTTP; nil uses httpclient.Default.
+	HTTPClients *httpclient.Factory
+}
+
+// Search engines search_query supports.
+const (
+	WebEngineBing       = "bing"
+	WebEngineBrave      = "brave"
+	WebEngineSearxNG    = "searxng"
+	WebEngineDuckDuckGo = "duckduckgo"
+)
+
+// WebEngines lists the supported search engines.
+var WebEngines = []string{WebEngineBing, WebEngineBrave, WebEngineSearxNG, WebEngineDuckDuckGo}
+
+// SQL drivers sql_query supports.
+const (
+	SQLDriverSQLite   = "sqlite"
+	SQLDriverPostgres = "postgres"
+)
+
+// SQLDatabase is a database sql_query can reach by name.
+type SQLDatabase struct {
+	Name   string
+	Driver string
+	DSN    string
+}
+
+// NewsFeed is an RSS or Atom feed the news tool can read by name.
+type NewsFeed struct {
+	Name string
+	URL  string
+}
+
+// EmailOptions configures send_email; an empty SMTPHost leaves it
+// unconfigured.
+type EmailOptions struct {
+	From               string
+	Timeout            time.Duration
+	AllowedRecipients  []string
+	MaxAttachmentBytes int64
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+}
+
+// Calendar providers the calendar tool supports.
+const (
+	CalendarProviderCalDAV = "caldav"
+	CalendarProviderGoogle = "google"
+)
+
+// CalendarOptions configures the calendar tool; an empty Provider leaves
+// it unconfigured.
+type CalendarOptions struct {
+	Provider string
+	Timeout  time.Duration
+	Location *time.Location
+
+	CalDAVURL      string
+	CalDAVUsername string
+	CalDAVPassword string
+
+	GoogleBaseURL      string
+	GoogleTokenURL     string
+	GoogleCalendarID   string
+	GoogleAccessToken  string
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRefreshToken string
 }
 
 const (
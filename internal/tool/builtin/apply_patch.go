@@ -67,7 +67,7 @@ func (t *ApplyPatchTool) Parameters() map[string]interface{} {
 			},
 			"workdir": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional working directory",
+				"description": "Optional working directory (defaults to the session sandbox)",
 			},
 			"dry_run": map[string]interface{}{
 				"type":        "boolean",
@@ -100,7 +100,16 @@ func (t *ApplyPatchTool) Execute(ctx context.Context, input json.RawMessage) (js
 		runner = runApplyPatchCommand
 	}
 
-	output, err := runner(ctx, command, strings.TrimSpace(args.Workdir), args.Patch)
+	workdir := strings.TrimSpace(args.Workdir)
+	if workdir == "" {
+		sandboxPath, err := toolcore.SandboxPath(ctx)
+		if err != nil {
+			return nil, err
+		}
+		workdir = sandboxPath
+	}
+
+	output, err := runner(ctx, command, workdir, args.Patch)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +117,7 @@ func (t *ApplyPatchTool) Execute(ctx context.Context, input json.RawMessage) (js
 	return json.Marshal(map[string]interface{}{
 		"applied": true,
 		"command": command,
-		"workdir": strings.TrimSpace(args.Workdir),
+		"workdir": workdir,
 		"output":  output,
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	toolcore "github.com/harunnryd/heike/internal/tool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +52,22 @@ func TestApplyPatchToolExecute_DryRunUnsupported(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "dry_run")
 }
+
+func TestApplyPatchToolExecute_DefaultsToSessionSandbox(t *testing.T) {
+	sandbox := t.TempDir()
+	tool := &ApplyPatchTool{
+		Command: "apply_patch",
+		run: func(ctx context.Context, command, workdir, patch string) (string, error) {
+			assert.Equal(t, sandbox, workdir)
+			return "ok", nil
+		},
+	}
+
+	ctx := toolcore.WithSandboxPath(context.Background(), func() (string, error) { return sandbox, nil })
+	raw, err := tool.Execute(ctx, json.RawMessage(`{"patch":"*** Begin Patch\n*** End Patch\n"}`))
+	require.NoError(t, err)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, sandbox, resp["workdir"])
+}
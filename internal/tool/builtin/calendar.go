@@ -0,0 +1,373 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultCalendarTimeout       = 15 * time.Second
+	defaultCalendarListWindow    = 7 * 24 * time.Hour
+	defaultCalendarListLimit     = 25
+	maxCalendarListLimit         = 100
+	defaultCalendarEventDuration = 30 * time.Minute
+)
+
+// Calendar actions. list_events needs no approval; create_event is on
+// governance.require_approval by default.
+const (
+	calendarActionListEvents  = "list_events"
+	calendarActionCreateEvent = "create_event"
+)
+
+// calendarEvent is an event as the tool reports it. All-day events start
+// and end at midnight in the calendar location, with End exclusive.
+type calendarEvent struct {
+	ID          string
+	Title       string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Location    string
+	Description string
+	Attendees   []string
+	URL         string
+}
+
+// calendarBackend lists and creates events in one calendar.
+type calendarBackend interface {
+	listEvents(ctx context.Context, start, end time.Time, query string) ([]calendarEvent, error)
+	createEvent(ctx context.Context, event calendarEvent) (calendarEvent, error)
+}
+
+func init() {
+	toolcore.RegisterBuiltin("calendar", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewCalendarTool(options), nil
+	})
+}
+
+// CalendarTool lists and creates events in a CalDAV or Google calendar.
+type CalendarTool struct {
+	backend  calendarBackend
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewCalendarTool(options toolcore.BuiltinOptions) *CalendarTool {
+	cal := options.Calendar
+	timeout := cal.Timeout
+	if timeout <= 0 {
+		timeout = defaultCalendarTimeout
+	}
+	location := cal.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	t := &CalendarTool{location: location, now: time.Now}
+	client := options.HTTPClients.Client("calendar", timeout)
+	switch cal.Provider {
+	case toolcore.CalendarProviderCalDAV:
+		t.backend = newCalDAVBackend(client, cal, location)
+	case toolcore.CalendarProviderGoogle:
+		t.backend = newGoogleCalendarBackend(client, cal, location)
+	}
+	return t
+}
+
+func (t *CalendarTool) Name() string { return "calendar" }
+
+func (t *CalendarTool) Description() string {
+	return "List events in a time range and create events in the configured calendar. Times without an offset use " + t.location.String() + ". Creating events requires approval."
+}
+
+func (t *CalendarTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"calendar.read",
+			"calendar.write",
+			"scheduling",
+		},
+		Risk: toolcore.RiskMedium,
+	}
+}
+
+func (t *CalendarTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{calendarActionListEvents, calendarActionCreateEvent},
+				"description": "Operation to run",
+			},
+			"start": map[string]interface{}{
+				"type":        "string",
+				"description": "Start as RFC 3339 or YYYY-MM-DDTHH:MM; a date alone is an all-day event for create_event (list_events defaults to now)",
+			},
+			"end": map[string]interface{}{
+				"type":        "string",
+				"description": "End, same formats as start (list_events defaults to 7 days after start)",
+			},
+			"duration_minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Event length for create_event when end is omitted (default 30)",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list events whose title, description or location contains this text",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum events to list (default 25, max 100)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Event title for create_event",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "Event description for create_event",
+			},
+			"location": map[string]interface{}{
+				"type":        "string",
+				"description": "Event location for create_event",
+			},
+			"attendees": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Attendee email addresses for create_event",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *CalendarTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Action          string   `json:"action"`
+		Start           string   `json:"start"`
+		End             string   `json:"end"`
+		DurationMinutes int      `json:"duration_minutes"`
+		Query           string   `json:"query"`
+		Limit           int      `json:"limit"`
+		Title           string   `json:"title"`
+		Description     string   `json:"description"`
+		Location        string   `json:"location"`
+		Attendees       []string `json:"attendees"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	action := strings.TrimSpace(args.Action)
+	if action != calendarActionListEvents && action != calendarActionCreateEvent {
+		if action == "" {
+			return nil, fmt.Errorf("action is required")
+		}
+		return nil, fmt.Errorf("unsupported action %q", args.Action)
+	}
+	if t.backend == nil {
+		return nil, fmt.Errorf("calendar is not configured; set tools.calendar.caldav or tools.calendar.google")
+	}
+
+	if action == calendarActionListEvents {
+		start := t.now().In(t.location)
+		if strings.TrimSpace(args.Start) != "" {
+			parsed, _, err := parseCalendarTime(args.Start, t.location)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start: %w", err)
+			}
+			start = parsed
+		}
+		end := start.Add(defaultCalendarListWindow)
+		if strings.TrimSpace(args.End) != "" {
+			parsed, dateOnly, err := parseCalendarTime(args.End, t.location)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end: %w", err)
+			}
+			if dateOnly {
+				// A date as the end of a range includes that day.
+				parsed = parsed.AddDate(0, 0, 1)
+			}
+			end = parsed
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("end must be after start")
+		}
+		limit := args.Limit
+		if limit <= 0 {
+			limit = defaultCalendarListLimit
+		}
+		if limit > maxCalendarListLimit {
+			limit = maxCalendarListLimit
+		}
+
+		events, err := t.backend.listEvents(ctx, start, end, strings.TrimSpace(args.Query))
+		if err != nil {
+			return nil, err
+		}
+		events = filterCalendarEvents(events, strings.TrimSpace(args.Query))
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+		truncated := len(events) > limit
+		if truncated {
+			events = events[:limit]
+		}
+		out := make([]map[string]interface{}, 0, len(events))
+		for _, event := range events {
+			out = append(out, t.eventJSON(event))
+		}
+		return json.Marshal(map[string]interface{}{
+			"start":     start.Format(time.RFC3339),
+			"end":       end.Format(time.RFC3339),
+			"events":    out,
+			"truncated": truncated,
+		})
+	}
+
+	title := strings.TrimSpace(args.Title)
+	if title == "" {
+		return nil, fmt.Errorf("title is required for create_event")
+	}
+	if strings.TrimSpace(args.Start) == "" {
+		return nil, fmt.Errorf("start is required for create_event")
+	}
+	start, allDay, err := parseCalendarTime(args.Start, t.location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+	var end time.Time
+	switch {
+	case strings.TrimSpace(args.End) != "":
+		parsed, dateOnly, err := parseCalendarTime(args.End, t.location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+		if dateOnly != allDay {
+			return nil, fmt.Errorf("start and end must both be dates or both be times")
+		}
+		if allDay {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		end = parsed
+	case allDay:
+		end = start.AddDate(0, 0, 1)
+	case args.DurationMinutes > 0:
+		end = start.Add(time.Duration(args.DurationMinutes) * time.Minute)
+	default:
+		end = start.Add(defaultCalendarEventDuration)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+
+	attendees := make([]string, 0, len(args.Attendees))
+	for _, attendee := range args.Attendees {
+		attendee = strings.TrimSpace(strings.TrimPrefix(attendee, "mailto:"))
+		if attendee == "" {
+			continue
+		}
+		if !strings.Contains(attendee, "@") {
+			return nil, fmt.Errorf("attendee %q is not an email address", attendee)
+		}
+		attendees = append(attendees, attendee)
+	}
+
+	created, err := t.backend.createEvent(ctx, calendarEvent{
+		Title:       title,
+		Start:       start,
+		End:         end,
+		AllDay:      allDay,
+		Location:    strings.TrimSpace(args.Location),
+		Description: args.Description,
+		Attendees:   attendees,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"created": true,
+		"event":   t.eventJSON(created),
+	})
+}
+
+func (t *CalendarTool) eventJSON(event calendarEvent) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":      event.ID,
+		"title":   event.Title,
+		"all_day": event.AllDay,
+	}
+	if event.AllDay {
+		out["start"] = event.Start.Format("2006-01-02")
+		// Report the last day rather than the exclusive end.
+		out["end"] = event.End.AddDate(0, 0, -1).Format("2006-01-02")
+	} else {
+		out["start"] = event.Start.In(t.location).Format(time.RFC3339)
+		out["end"] = event.End.In(t.location).Format(time.RFC3339)
+	}
+	if event.Location != "" {
+		out["location"] = event.Location
+	}
+	if event.Description != "" {
+		out["description"] = event.Description
+	}
+	if len(event.Attendees) > 0 {
+		out["attendees"] = event.Attendees
+	}
+	if event.URL != "" {
+		out["url"] = event.URL
+	}
+	return out
+}
+
+func filterCalendarEvents(events []calendarEvent, query string) []calendarEvent {
+	if query == "" {
+		return events
+	}
+	query = strings.ToLower(query)
+	filtered := events[:0]
+	for _, event := range events {
+		text := strings.ToLower(event.Title + "\n" + event.Description + "\n" + event.Location)
+		if strings.Contains(text, query) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// parseCalendarTime parses RFC 3339, a local date-time or a date alone,
+// which it reports as dateOnly. Values without an offset are in loc.
+func parseCalendarTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not RFC 3339, YYYY-MM-DDTHH:MM or YYYY-MM-DD", value)
+}
+
+// calendarHTTPError describes a failed calendar API response.
+func calendarHTTPError(provider string, resp *http.Response, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 300 {
+		msg = msg[:300] + "..."
+	}
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return fmt.Errorf("%s calendar error (status %d): %s", provider, resp.StatusCode, msg)
+}
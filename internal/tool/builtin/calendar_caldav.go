@@ -0,0 +1,342 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	icsDateTimeUTC = "20060102T150405Z"
+	icsDateTime    = "20060102T150405"
+	icsDate        = "20060102"
+	// calDAVMaxResponseBytes bounds a REPORT response.
+	calDAVMaxResponseBytes = 8 << 20
+)
+
+// calDAVBackend talks to one CalDAV calendar collection (RFC 4791).
+type calDAVBackend struct {
+	client   *http.Client
+	url      string
+	username string
+	password string
+	location *time.Location
+}
+
+func newCalDAVBackend(client *http.Client, options toolcore.CalendarOptions, location *time.Location) *calDAVBackend {
+	collection := options.CalDAVURL
+	if !strings.HasSuffix(collection, "/") {
+		collection += "/"
+	}
+	return &calDAVBackend{
+		client:   client,
+		url:      collection,
+		username: options.CalDAVUsername,
+		password: options.CalDAVPassword,
+		location: location,
+	}
+}
+
+type calDAVMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Prop struct {
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// listEvents runs a calendar-query REPORT. The server expands recurring
+// events into the instances inside the range.
+func (b *calDAVBackend) listEvents(ctx context.Context, start, end time.Time, _ string) ([]calendarEvent, error) {
+	rangeStart := start.UTC().Format(icsDateTimeUTC)
+	rangeEnd := end.UTC().Format(icsDateTimeUTC)
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data>
+      <C:expand start="` + rangeStart + `" end="` + rangeEnd + `"/>
+    </C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="` + rangeStart + `" end="` + rangeEnd + `"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+	req, err := http.NewRequestWithContext(ctx, "REPORT", b.url, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+	data, err := b.do(req, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	var status calDAVMultistatus
+	if err := xml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("decode caldav response: %w", err)
+	}
+	var events []calendarEvent
+	for _, resp := range status.Responses {
+		for _, propstat := range resp.Propstats {
+			if strings.TrimSpace(propstat.Prop.CalendarData) == "" {
+				continue
+			}
+			parsed := parseICSEvents(propstat.Prop.CalendarData, b.location)
+			for i := range parsed {
+				parsed[i].URL = b.resolveHref(resp.Href)
+			}
+			events = append(events, parsed...)
+		}
+	}
+	return events, nil
+}
+
+// createEvent stores the event as a new calendar object resource.
+func (b *calDAVBackend) createEvent(ctx context.Context, event calendarEvent) (calendarEvent, error) {
+	event.ID = newCalendarUID()
+	target := b.url + url.PathEscape(event.ID) + ".ics"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, strings.NewReader(formatICSEvent(event, time.Now())))
+	if err != nil {
+		return calendarEvent{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	req.Header.Set("If-None-Match", "*")
+	if _, err := b.do(req, http.StatusCreated, http.StatusNoContent, http.StatusOK); err != nil {
+		return calendarEvent{}, err
+	}
+	event.URL = target
+	return event, nil
+}
+
+func (b *calDAVBackend) do(req *http.Request, okStatus ...int) ([]byte, error) {
+	if b.username != "" || b.password != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	req.Header.Set("User-Agent", "Heike/1.0")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caldav request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, calDAVMaxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read caldav response: %w", err)
+	}
+	for _, status := range okStatus {
+		if resp.StatusCode == status {
+			return data, nil
+		}
+	}
+	return nil, calendarHTTPError("caldav", resp, data)
+}
+
+func (b *calDAVBackend) resolveHref(href string) string {
+	base, err := url.Parse(b.url)
+	if err != nil || href == "" {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func newCalendarUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:]) + "@heike"
+}
+
+// parseICSEvents reads the VEVENTs of an iCalendar object (RFC 5545).
+func parseICSEvents(data string, loc *time.Location) []calendarEvent {
+	// Unfold continuation lines first.
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	var (
+		events  []calendarEvent
+		current *calendarEvent
+		hasEnd  bool
+		depth   int
+	)
+	for _, line := range strings.Split(data, "\n") {
+		name, params, value, ok := parseICSLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &calendarEvent{}
+			hasEnd = false
+			depth = 0
+			continue
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			// Skip nested components such as VALARM.
+			depth++
+			continue
+		case name == "END" && value == "VEVENT":
+			if !hasEnd {
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		case name == "END":
+			depth--
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.ID = value
+		case "SUMMARY":
+			current.Title = unescapeICSText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeICSText(value)
+		case "LOCATION":
+			current.Location = unescapeICSText(value)
+		case "DTSTART":
+			if t, allDay, err := parseICSTime(value, params, loc); err == nil {
+				current.Start, current.AllDay = t, allDay
+			}
+		case "DTEND":
+			if t, _, err := parseICSTime(value, params, loc); err == nil {
+				current.End, hasEnd = t, true
+			}
+		case "ATTENDEE":
+			if email := strings.TrimPrefix(strings.TrimPrefix(value, "mailto:"), "MAILTO:"); email != "" {
+				current.Attendees = append(current.Attendees, email)
+			}
+		}
+	}
+	return events
+}
+
+// parseICSLine splits NAME;PARAM=V;...:VALUE, honouring quoted parameter
+// values that contain colons.
+func parseICSLine(line string) (name string, params map[string]string, value string, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(line[:colon], ";")
+	params = make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		if key, val, found := strings.Cut(part, "="); found {
+			params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+func parseICSTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icsDate) {
+		t, err := time.ParseInLocation(icsDate, value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsDateTimeUTC, value)
+		return t, false, err
+	}
+	zone := loc
+	if tzid := params["TZID"]; tzid != "" {
+		if named, err := time.LoadLocation(tzid); err == nil {
+			zone = named
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTime, value, zone)
+	return t, false, err
+}
+
+func unescapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(value)
+}
+
+func escapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// formatICSEvent renders event as an iCalendar object with one VEVENT.
+func formatICSEvent(event calendarEvent, stamp time.Time) string {
+	var b bytes.Buffer
+	line := func(s string) {
+		// Fold lines longer than 75 octets without splitting characters.
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Heike//Calendar//EN")
+	line("BEGIN:VEVENT")
+	line("UID:" + event.ID)
+	line("DTSTAMP:" + stamp.UTC().Format(icsDateTimeUTC))
+	if event.AllDay {
+		line("DTSTART;VALUE=DATE:" + event.Start.Format(icsDate))
+		line("DTEND;VALUE=DATE:" + event.End.Format(icsDate))
+	} else {
+		line("DTSTART:" + event.Start.UTC().Format(icsDateTimeUTC))
+		line("DTEND:" + event.End.UTC().Format(icsDateTimeUTC))
+	}
+	line("SUMMARY:" + escapeICSText(event.Title))
+	if event.Description != "" {
+		line("DESCRIPTION:" + escapeICSText(event.Description))
+	}
+	if event.Location != "" {
+		line("LOCATION:" + escapeICSText(event.Location))
+	}
+	for _, attendee := range event.Attendees {
+		line("ATTENDEE;RSVP=TRUE:mailto:" + attendee)
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	googleCalendarMaxResponseBytes = 4 << 20
+	// googleTokenRefreshMargin refreshes access tokens shortly before they
+	// expire.
+	googleTokenRefreshMargin = time.Minute
+)
+
+// googleCalendarBackend talks to the Google Calendar API v3.
+type googleCalendarBackend struct {
+	client     *http.Client
+	baseURL    string
+	tokenURL   string
+	calendarID string
+	location   *time.Location
+
+	clientID     string
+	clientSecret string
+	refreshToken string
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func newGoogleCalendarBackend(client *http.Client, options toolcore.CalendarOptions, location *time.Location) *googleCalendarBackend {
+	return &googleCalendarBackend{
+		client:       client,
+		baseURL:      strings.TrimRight(options.GoogleBaseURL, "/"),
+		tokenURL:     options.GoogleTokenURL,
+		calendarID:   options.GoogleCalendarID,
+		location:     location,
+		clientID:     options.GoogleClientID,
+		clientSecret: options.GoogleClientSecret,
+		refreshToken: options.GoogleRefreshToken,
+		accessToken:  options.GoogleAccessToken,
+	}
+}
+
+type googleEventTime struct {
+	Date     string `json:"date,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type googleEvent struct {
+	ID          string          `json:"id,omitempty"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description,omitempty"`
+	Location    string          `json:"location,omitempty"`
+	HTMLLink    string          `json:"htmlLink,omitempty"`
+	Start       googleEventTime `json:"start"`
+	End         googleEventTime `json:"end"`
+	Attendees   []struct {
+		Email string `json:"email"`
+	} `json:"attendees,omitempty"`
+}
+
+func (b *googleCalendarBackend) eventsURL() string {
+	return b.baseURL + "/calendars/" + url.PathEscape(b.calendarID) + "/events"
+}
+
+// listEvents expands recurring events into single instances.
+func (b *googleCalendarBackend) listEvents(ctx context.Context, start, end time.Time, query string) ([]calendarEvent, error) {
+	params := url.Values{}
+	params.Set("timeMin", start.Format(time.RFC3339))
+	params.Set("timeMax", end.Format(time.RFC3339))
+	params.Set("singleEvents", "true")
+	params.Set("orderBy", "startTime")
+	params.Set("maxResults", strconv.Itoa(maxCalendarListLimit+1))
+	if query != "" {
+		params.Set("q", query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.eventsURL()+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	var resp struct {
+		Items []googleEvent `json:"items"`
+	}
+	if err := b.do(req, &resp); err != nil {
+		return nil, err
+	}
+	events := make([]calendarEvent, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		event, err := b.fromGoogle(item)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (b *googleCalendarBackend) createEvent(ctx context.Context, event calendarEvent) (calendarEvent, error) {
+	payload := googleEvent{
+		Summary:     event.Title,
+		Description: event.Description,
+		Location:    event.Location,
+	}
+	if event.AllDay {
+		payload.Start.Date = event.Start.Format("2006-01-02")
+		payload.End.Date = event.End.Format("2006-01-02")
+	} else {
+		payload.Start.DateTime = event.Start.Format(time.RFC3339)
+		payload.End.DateTime = event.End.Format(time.RFC3339)
+	}
+	for _, attendee := range event.Attendees {
+		payload.Attendees = append(payload.Attendees, struct {
+			Email string `json:"email"`
+		}{Email: attendee})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return calendarEvent{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.eventsURL(), bytes.NewReader(body))
+	if err != nil {
+		return calendarEvent{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var created googleEvent
+	if err := b.do(req, &created); err != nil {
+		return calendarEvent{}, err
+	}
+	return b.fromGoogle(created)
+}
+
+func (b *googleCalendarBackend) fromGoogle(item googleEvent) (calendarEvent, error) {
+	event := calendarEvent{
+		ID:          item.ID,
+		Title:       item.Summary,
+		Description: item.Description,
+		Location:    item.Location,
+		URL:         item.HTMLLink,
+	}
+	for _, attendee := range item.Attendees {
+		event.Attendees = append(event.Attendees, attendee.Email)
+	}
+	var err error
+	if item.Start.Date != "" {
+		event.AllDay = true
+		if event.Start, err = time.ParseInLocation("2006-01-02", item.Start.Date, b.location); err != nil {
+			return calendarEvent{}, fmt.Errorf("decode event %s start: %w", item.ID, err)
+		}
+		if event.End, err = time.ParseInLocation("2006-01-02", item.End.Date, b.location); err != nil {
+			return calendarEvent{}, fmt.Errorf("decode event %s end: %w", item.ID, err)
+		}
+		return event, nil
+	}
+	if event.Start, err = time.Parse(time.RFC3339, item.Start.DateTime); err != nil {
+		return calendarEvent{}, fmt.Errorf("decode event %s start: %w", item.ID, err)
+	}
+	if event.End, err = time.Parse(time.RFC3339, item.End.DateTime); err != nil {
+		return calendarEvent{}, fmt.Errorf("decode event %s end: %w", item.ID, err)
+	}
+	return event, nil
+}
+
+func (b *googleCalendarBackend) do(req *http.Request, out interface{}) error {
+	token, err := b.token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Heike/1.0")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google calendar request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, googleCalendarMaxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read google calendar response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return calendarHTTPError("google", resp, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode google calendar response: %w", err)
+	}
+	return nil
+}
+
+// token returns the static access token, or one obtained with the refresh
+// token when client credentials are configured.
+func (b *googleCalendarBackend) token(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refreshToken == "" {
+		return b.accessToken, nil
+	}
+	if b.accessToken != "" && time.Now().Add(googleTokenRefreshMargin).Before(b.expiry) {
+		return b.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", b.refreshToken)
+	form.Set("client_id", b.clientID)
+	form.Set("client_secret", b.clientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("google token refresh failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, googleCalendarMaxResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read google token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", calendarHTTPError("google", resp, data)
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return "", fmt.Errorf("decode google token response: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("google token response has no access_token")
+	}
+	b.accessToken = tok.AccessToken
+	b.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return b.accessToken, nil
+}
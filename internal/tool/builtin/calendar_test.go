@@ -0,0 +1,225 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCalendarTool(t *testing.T, options toolcore.CalendarOptions) *CalendarTool {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	options.Location = loc
+	tool := NewCalendarTool(toolcore.BuiltinOptions{Calendar: options})
+	tool.now = func() time.Time { return time.Date(2026, 10, 12, 9, 0, 0, 0, loc) }
+	return tool
+}
+
+func TestCalendarTool_Unconfigured(t *testing.T) {
+	tool := newTestCalendarTool(t, toolcore.CalendarOptions{})
+
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"list_events"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not configured")
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"delete_event"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported action")
+}
+
+func TestCalendarTool_CalDAV(t *testing.T) {
+	var put string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "ana", user)
+		assert.Equal(t, "secret", pass)
+		switch r.Method {
+		case "REPORT":
+			assert.Equal(t, "/cal/work/", r.URL.Path)
+			assert.Equal(t, "1", r.Header.Get("Depth"))
+			body, _ := io.ReadAll(r.Body)
+			assert.Contains(t, string(body), `start="20261012T070000Z" end="20261019T070000Z"`)
+			w.WriteHeader(http.StatusMultiStatus)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/cal/work/standup.ics</d:href>
+    <d:propstat><d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:standup-1
+SUMMARY:Team standup\, daily
+DTSTART;TZID=Europe/Berlin:20261013T093000
+DTEND;TZID=Europe/Berlin:20261013T094500
+LOCATION:Room 4
+BEGIN:VALARM
+SUMMARY:ignored
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop></d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/cal/work/offsite.ics</d:href>
+    <d:propstat><d:prop><cal:calendar-data>BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:offsite-1
+SUMMARY:Offsite
+DESCRIPTION:Planning for
+  Q4
+DTSTART;VALUE=DATE:20261014
+DTEND;VALUE=DATE:20261016
+END:VEVENT
+END:VCALENDAR
+</cal:calendar-data></d:prop></d:propstat>
+  </d:response>
+</d:multistatus>`))
+		case http.MethodPut:
+			assert.True(t, strings.HasPrefix(r.URL.Path, "/cal/work/"))
+			assert.True(t, strings.HasSuffix(r.URL.Path, ".ics"))
+			assert.Equal(t, "*", r.Header.Get("If-None-Match"))
+			body, _ := io.ReadAll(r.Body)
+			put = string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.Error(w, "unexpected", http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+	tool := newTestCalendarTool(t, toolcore.CalendarOptions{
+		Provider:       toolcore.CalendarProviderCalDAV,
+		CalDAVURL:      server.URL + "/cal/work",
+		CalDAVUsername: "ana",
+		CalDAVPassword: "secret",
+	})
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"list_events"}`))
+	require.NoError(t, err)
+	var listed struct {
+		Events    []map[string]interface{} `json:"events"`
+		Truncated bool                     `json:"truncated"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &listed))
+	require.Len(t, listed.Events, 2)
+	assert.Equal(t, "Team standup, daily", listed.Events[0]["title"])
+	assert.Equal(t, "2026-10-13T09:30:00+02:00", listed.Events[0]["start"])
+	assert.Equal(t, server.URL+"/cal/work/standup.ics", listed.Events[0]["url"])
+	assert.Equal(t, true, listed.Events[1]["all_day"])
+	assert.Equal(t, "2026-10-14", listed.Events[1]["start"])
+	assert.Equal(t, "2026-10-15", listed.Events[1]["end"])
+	assert.Equal(t, "Planning for Q4", listed.Events[1]["description"])
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"list_events","query":"OFFSITE"}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &listed))
+	require.Len(t, listed.Events, 1)
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"create_event","title":"Review; draft","start":"2026-10-16T15:00","attendees":["mailto:bo@example.com"]}`))
+	require.NoError(t, err)
+	var created struct {
+		Event map[string]interface{} `json:"event"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &created))
+	assert.Equal(t, "2026-10-16T15:00:00+02:00", created.Event["start"])
+	assert.Equal(t, "2026-10-16T15:30:00+02:00", created.Event["end"])
+	assert.Contains(t, put, "DTSTART:20261016T130000Z\r\n")
+	assert.Contains(t, put, "DTEND:20261016T133000Z\r\n")
+	assert.Contains(t, put, `SUMMARY:Review\; draft`)
+	assert.Contains(t, put, "ATTENDEE;RSVP=TRUE:mailto:bo@example.com")
+	assert.Contains(t, put, "UID:"+created.Event["id"].(string))
+}
+
+func TestCalendarTool_Google(t *testing.T) {
+	var refreshes int
+	var posted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			refreshes++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "rt", r.PostForm.Get("refresh_token"))
+			assert.Equal(t, "cid", r.PostForm.Get("client_id"))
+			_, _ = w.Write([]byte(`{"access_token":"fresh","expires_in":3600}`))
+			return
+		}
+		assert.Equal(t, "Bearer fresh", r.Header.Get("Authorization"))
+		assert.Equal(t, "/calendars/team@example.com/events", r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			assert.Equal(t, "true", query.Get("singleEvents"))
+			assert.Equal(t, "2026-10-12T00:00:00+02:00", query.Get("timeMin"))
+			assert.Equal(t, "2026-10-13T00:00:00+02:00", query.Get("timeMax"))
+			assert.Equal(t, "review", query.Get("q"))
+			_, _ = w.Write([]byte(`{"items":[
+				{"id":"e1","summary":"Design review","htmlLink":"https://calendar.example/e1","start":{"dateTime":"2026-10-12T14:00:00Z"},"end":{"dateTime":"2026-10-12T15:00:00Z"}},
+				{"id":"e2","summary":"Review holiday","start":{"date":"2026-10-12"},"end":{"date":"2026-10-13"}}]}`))
+		case http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &posted))
+			posted["id"] = "e3"
+			out, _ := json.Marshal(posted)
+			_, _ = w.Write(out)
+		}
+	}))
+	defer server.Close()
+	tool := newTestCalendarTool(t, toolcore.CalendarOptions{
+		Provider:           toolcore.CalendarProviderGoogle,
+		GoogleBaseURL:      server.URL,
+		GoogleTokenURL:     server.URL + "/token",
+		GoogleCalendarID:   "team@example.com",
+		GoogleClientID:     "cid",
+		GoogleClientSecret: "cs",
+		GoogleRefreshToken: "rt",
+	})
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"list_events","start":"2026-10-12","end":"2026-10-12","query":"review"}`))
+	require.NoError(t, err)
+	var listed struct {
+		Events []map[string]interface{} `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &listed))
+	require.Len(t, listed.Events, 2)
+	assert.Equal(t, "Review holiday", listed.Events[0]["title"])
+	assert.Equal(t, "2026-10-12T16:00:00+02:00", listed.Events[1]["start"])
+	assert.Equal(t, "https://calendar.example/e1", listed.Events[1]["url"])
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"create_event","title":"Retro","start":"2026-10-16","end":"2026-10-16","location":"HQ"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"date": "2026-10-16"}, posted["start"])
+	assert.Equal(t, map[string]interface{}{"date": "2026-10-17"}, posted["end"])
+	assert.Equal(t, "HQ", posted["location"])
+	assert.Equal(t, 1, refreshes)
+}
+
+func TestCalendarTool_CreateValidation(t *testing.T) {
+	tool := newTestCalendarTool(t, toolcore.CalendarOptions{
+		Provider:         toolcore.CalendarProviderGoogle,
+		GoogleBaseURL:    "http://127.0.0.1:1",
+		GoogleCalendarID: "primary",
+	})
+
+	cases := map[string]string{
+		`{"action":"create_event","start":"2026-10-16T15:00"}`:                                      "title is required",
+		`{"action":"create_event","title":"x"}`:                                                     "start is required",
+		`{"action":"create_event","title":"x","start":"Friday 3pm"}`:                                "invalid start",
+		`{"action":"create_event","title":"x","start":"2026-10-16T15:00","end":"2026-10-16"}`:       "both be dates",
+		`{"action":"create_event","title":"x","start":"2026-10-16T15:00","end":"2026-10-16T14:00"}`: "end must be after start",
+		`{"action":"create_event","title":"x","start":"2026-10-16T15:00","attendees":["bo"]}`:       "not an email address",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+}
@@ -0,0 +1,332 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultConvertBaseURL  = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	defaultConvertTimeout  = 10 * time.Second
+	defaultConvertCacheTTL = time.Hour
+	// convertMaxResponseBytes bounds an exchange-rate response.
+	convertMaxResponseBytes = 1 << 20
+	// convertSignificantDigits is the precision results are rounded to.
+	convertSignificantDigits = 10
+)
+
+func init() {
+	toolcore.RegisterBuiltin("convert", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewConvertTool(options), nil
+	})
+}
+
+// ConvertTool converts currencies with cached reference rates and common
+// units with a fixed table.
+type ConvertTool struct {
+	Client   *http.Client
+	BaseURL  string
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	rates     *currencyRates
+	fetchedAt time.Time
+}
+
+// currencyRates are units of each currency per one unit of Base.
+type currencyRates struct {
+	Base  string
+	Date  string
+	Rates map[string]float64
+}
+
+func NewConvertTool(options toolcore.BuiltinOptions) *ConvertTool {
+	timeout := options.ConvertTimeout
+	if timeout <= 0 {
+		timeout = defaultConvertTimeout
+	}
+	t := &ConvertTool{
+		Client:   options.HTTPClients.Client("convert", timeout),
+		BaseURL:  strings.TrimSpace(options.ConvertBaseURL),
+		cacheTTL: options.ConvertCacheTTL,
+		now:      time.Now,
+	}
+	if t.BaseURL == "" {
+		t.BaseURL = defaultConvertBaseURL
+	}
+	if t.cacheTTL <= 0 {
+		t.cacheTTL = defaultConvertCacheTTL
+	}
+	return t
+}
+
+func (t *ConvertTool) Name() string { return "convert" }
+
+func (t *ConvertTool) Description() string {
+	return "Convert an amount between currencies (ISO codes such as USD, EUR) using daily reference rates, or between units of length, mass, volume, area, speed, time, data, temperature, energy and pressure."
+}
+
+func (t *ConvertTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"convert.currency",
+			"convert.unit",
+		},
+		Risk: toolcore.RiskLow,
+	}
+}
+
+func (t *ConvertTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"value": map[string]interface{}{
+				"type":        "number",
+				"description": "Amount to convert (default 1)",
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "Source currency code or unit, e.g. USD, km, lb, F, GiB",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "Target currency code or unit",
+			},
+		},
+		"required": []string{"from", "to"},
+	}
+}
+
+func (t *ConvertTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Value *float64 `json:"value"`
+		From  string   `json:"from"`
+		To    string   `json:"to"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	from := strings.TrimSpace(args.From)
+	to := strings.TrimSpace(args.To)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from and to are required")
+	}
+	value := 1.0
+	if args.Value != nil {
+		value = *args.Value
+	}
+
+	fromUnit, fromIsUnit := lookupConvertUnit(from)
+	toUnit, toIsUnit := lookupConvertUnit(to)
+	switch {
+	case fromIsUnit && toIsUnit:
+		if fromUnit.kind != toUnit.kind {
+			return nil, fmt.Errorf("cannot convert %s (%s) to %s (%s)", fromUnit.name, fromUnit.kind, toUnit.name, toUnit.kind)
+		}
+		return json.Marshal(map[string]interface{}{
+			"value":  value,
+			"from":   fromUnit.name,
+			"to":     toUnit.name,
+			"result": roundSignificant(fromUnit.convert(value, toUnit)),
+			"kind":   fromUnit.kind,
+		})
+	case fromIsUnit || toIsUnit:
+		unknown := from
+		if fromIsUnit {
+			unknown = to
+		}
+		return nil, fmt.Errorf("unknown unit %q", unknown)
+	}
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if !isCurrencyCode(from) || !isCurrencyCode(to) {
+		return nil, fmt.Errorf("unknown unit or currency in %q to %q; currencies are three-letter ISO codes", args.From, args.To)
+	}
+	return t.convertCurrency(ctx, value, from, to)
+}
+
+func (t *ConvertTool) convertCurrency(ctx context.Context, value float64, from, to string) (json.RawMessage, error) {
+	rates, stale, err := t.currencyRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fromRate, ok := rates.Rates[from]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s; available: %s", from, strings.Join(rates.codes(), ", "))
+	}
+	toRate, ok := rates.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("no exchange rate for %s; available: %s", to, strings.Join(rates.codes(), ", "))
+	}
+	rate := toRate / fromRate
+
+	result := map[string]interface{}{
+		"value":  value,
+		"from":   from,
+		"to":     to,
+		"result": roundSignificant(value * rate),
+		"kind":   "currency",
+		"rate":   roundSignificant(rate),
+		"base":   rates.Base,
+	}
+	if rates.Date != "" {
+		result["date"] = rates.Date
+	}
+	if stale {
+		result["stale"] = true
+	}
+	return json.Marshal(result)
+}
+
+// currencyRates returns cached rates, fetching them once the cache TTL
+// has passed. When a refresh fails, the previous rates are returned as
+// stale.
+func (t *ConvertTool) currencyRates(ctx context.Context) (*currencyRates, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.rates != nil && t.now().Sub(t.fetchedAt) < t.cacheTTL {
+		return t.rates, false, nil
+	}
+	rates, err := t.fetchRates(ctx)
+	if err != nil {
+		if t.rates != nil {
+			return t.rates, true, nil
+		}
+		return nil, false, err
+	}
+	t.rates = rates
+	t.fetchedAt = t.now()
+	return rates, false, nil
+}
+
+func (t *ConvertTool) fetchRates(ctx context.Context) (*currencyRates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.BaseURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Heike/1.0")
+	req.Header.Set("Accept", "application/xml, application/json")
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("exchange rate request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, convertMaxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate request returned %d", resp.StatusCode)
+	}
+	rates, err := parseCurrencyRates(data)
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type currencyRatesJSON struct {
+	Result     string             `json:"result"`
+	ErrorType  string             `json:"error-type"`
+	Base       string             `json:"base"`
+	BaseCode   string             `json:"base_code"`
+	Date       string             `json:"date"`
+	LastUpdate string             `json:"time_last_update_utc"`
+	Rates      map[string]float64 `json:"rates"`
+}
+
+// parseCurrencyRates reads the ECB reference-rate XML (the latest day
+// when several are listed) or JSON with a base currency and rates.
+func parseCurrencyRates(data []byte) (*currencyRates, error) {
+	data = bytes.TrimSpace(data)
+	rates := &currencyRates{Rates: map[string]float64{}}
+	if bytes.HasPrefix(data, []byte("<")) {
+		var envelope ecbEnvelope
+		if err := xml.Unmarshal(data, &envelope); err != nil {
+			return nil, fmt.Errorf("decode exchange rates: %w", err)
+		}
+		if len(envelope.Cube.Days) == 0 {
+			return nil, fmt.Errorf("decode exchange rates: no rates in response")
+		}
+		day := envelope.Cube.Days[0]
+		rates.Base = "EUR"
+		rates.Date = day.Time
+		for _, entry := range day.Rates {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(entry.Rate), 64)
+			if err != nil || rate <= 0 {
+				continue
+			}
+			rates.Rates[strings.ToUpper(entry.Currency)] = rate
+		}
+	} else {
+		var payload currencyRatesJSON
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, fmt.Errorf("decode exchange rates: %w", err)
+		}
+		if payload.Result == "error" {
+			return nil, fmt.Errorf("exchange rate API error: %s", payload.ErrorType)
+		}
+		rates.Base = strings.ToUpper(payload.Base)
+		if rates.Base == "" {
+			rates.Base = strings.ToUpper(payload.BaseCode)
+		}
+		rates.Date = payload.Date
+		if rates.Date == "" {
+			rates.Date = payload.LastUpdate
+		}
+		for code, rate := range payload.Rates {
+			if rate > 0 {
+				rates.Rates[strings.ToUpper(code)] = rate
+			}
+		}
+	}
+	if rates.Base == "" || len(rates.Rates) == 0 {
+		return nil, fmt.Errorf("decode exchange rates: no base currency or rates in response")
+	}
+	rates.Rates[rates.Base] = 1
+	return rates, nil
+}
+
+func (r *currencyRates) codes() []string {
+	codes := make([]string, 0, len(r.Rates))
+	for code := range r.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// roundSignificant drops floating-point noise such as 0.30000000000000004.
+func roundSignificant(v float64) float64 {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', convertSignificantDigits, 64), 64)
+	if err != nil {
+		return v
+	}
+	return rounded
+}
@@ -0,0 +1,125 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testECBRates = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+  <gesmes:subject>Reference rates</gesmes:subject>
+  <Cube>
+    <Cube time="2026-10-15">
+      <Cube currency="USD" rate="1.0800"/>
+      <Cube currency="JPY" rate="162.00"/>
+      <Cube currency="GBP" rate="0.8600"/>
+    </Cube>
+  </Cube>
+</gesmes:Envelope>`
+
+func convertResult(t *testing.T, tool *ConvertTool, input string) map[string]interface{} {
+	t.Helper()
+	raw, err := tool.Execute(context.Background(), json.RawMessage(input))
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &out))
+	return out
+}
+
+func TestConvertTool_Units(t *testing.T) {
+	tool := NewConvertTool(toolcore.BuiltinOptions{ConvertBaseURL: "http://127.0.0.1:0/unused"})
+
+	cases := []struct {
+		input  string
+		result float64
+		kind   string
+	}{
+		{`{"value":10,"from":"km","to":"miles"}`, 6.213711922, "length"},
+		{`{"value":100,"from":"°C","to":"fahrenheit"}`, 212, "temperature"},
+		{`{"value":-40,"from":"F","to":"C"}`, -40, "temperature"},
+		{`{"value":2,"from":"GiB","to":"MB"}`, 2147.483648, "data"},
+		{`{"value":1,"from":"cup","to":"ml"}`, 236.5882365, "volume"},
+		{`{"value":3,"from":"fl oz","to":"tbsp"}`, 6, "volume"},
+		{`{"value":0.1,"from":"KM","to":"m"}`, 100, "length"},
+		{`{"from":"h","to":"min"}`, 60, "time"},
+	}
+	for _, tc := range cases {
+		out := convertResult(t, tool, tc.input)
+		assert.Equal(t, tc.result, out["result"], tc.input)
+		assert.Equal(t, tc.kind, out["kind"], tc.input)
+	}
+
+	errorsByInput := map[string]string{
+		`{"value":1,"from":"kg","to":"m"}`:         "cannot convert kg (mass) to m (length)",
+		`{"value":1,"from":"kg","to":"furlongs"}`:  `unknown unit "furlongs"`,
+		`{"value":1,"from":"parsec","to":"light"}`: "unknown unit or currency",
+		`{"value":1,"from":"kg"}`:                  "from and to are required",
+	}
+	for input, want := range errorsByInput {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+}
+
+func TestConvertTool_CurrencyWithCache(t *testing.T) {
+	requests := 0
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testECBRates))
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tool := NewConvertTool(toolcore.BuiltinOptions{ConvertBaseURL: server.URL, ConvertCacheTTL: time.Hour})
+	tool.now = func() time.Time { return now }
+
+	out := convertResult(t, tool, `{"value":100,"from":"usd","to":"JPY"}`)
+	assert.Equal(t, "currency", out["kind"])
+	assert.Equal(t, 15000.0, out["result"])
+	assert.Equal(t, 150.0, out["rate"])
+	assert.Equal(t, "EUR", out["base"])
+	assert.Equal(t, "2026-10-15", out["date"])
+
+	// "CUP" is a currency code, not the volume unit.
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"value":1,"from":"EUR","to":"CUP"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no exchange rate for CUP; available: EUR, GBP, JPY, USD")
+	assert.Equal(t, 1, requests)
+
+	now = now.Add(2 * time.Hour)
+	failing = true
+	out = convertResult(t, tool, `{"value":10,"from":"EUR","to":"GBP"}`)
+	assert.Equal(t, 8.6, out["result"])
+	assert.Equal(t, true, out["stale"])
+	assert.Equal(t, 2, requests)
+}
+
+func TestParseCurrencyRates_JSON(t *testing.T) {
+	rates, err := parseCurrencyRates([]byte(`{"result":"success","base_code":"USD","time_last_update_utc":"Thu, 15 Oct 2026 00:02:31 +0000","rates":{"USD":1,"EUR":0.925,"IDR":15800.5}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+	assert.Equal(t, 15800.5, rates.Rates["IDR"])
+	assert.Equal(t, []string{"EUR", "IDR", "USD"}, rates.codes())
+
+	_, err = parseCurrencyRates([]byte(`{"result":"error","error-type":"unsupported-code"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported-code")
+
+	_, err = NewConvertTool(toolcore.BuiltinOptions{ConvertBaseURL: "http://127.0.0.1:1/rates"}).Execute(context.Background(), json.RawMessage(`{"from":"USD","to":"EUR"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exchange rate request failed")
+}
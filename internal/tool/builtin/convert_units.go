@@ -0,0 +1,165 @@
+package builtin
+
+import (
+	"strings"
+	"unicode"
+)
+
+// convertUnit converts to its kind's base unit as value*factor + offset.
+type convertUnit struct {
+	name   string
+	kind   string
+	factor float64
+	offset float64
+}
+
+// convertUnitTable lists the supported units. The first name is canonical;
+// the rest are aliases. Bases: metre, kilogram, litre, square metre,
+// metre per second, second, byte, kelvin, joule and pascal.
+var convertUnitTable = []struct {
+	names  []string
+	kind   string
+	factor float64
+	offset float64
+}{
+	{[]string{"m", "meter", "meters", "metre", "metres"}, "length", 1, 0},
+	{[]string{"km", "kilometer", "kilometers", "kilometre", "kilometres"}, "length", 1000, 0},
+	{[]string{"cm", "centimeter", "centimeters", "centimetre", "centimetres"}, "length", 0.01, 0},
+	{[]string{"mm", "millimeter", "millimeters", "millimetre", "millimetres"}, "length", 0.001, 0},
+	{[]string{"um", "µm", "micrometer", "micrometers", "micron", "microns"}, "length", 1e-6, 0},
+	{[]string{"nm", "nanometer", "nanometers"}, "length", 1e-9, 0},
+	{[]string{"mi", "mile", "miles"}, "length", 1609.344, 0},
+	{[]string{"yd", "yard", "yards"}, "length", 0.9144, 0},
+	{[]string{"ft", "foot", "feet"}, "length", 0.3048, 0},
+	{[]string{"in", "inch", "inches"}, "length", 0.0254, 0},
+	{[]string{"nmi", "nautical_mile", "nautical_miles"}, "length", 1852, 0},
+
+	{[]string{"kg", "kilogram", "kilograms", "kilo", "kilos"}, "mass", 1, 0},
+	{[]string{"g", "gram", "grams"}, "mass", 0.001, 0},
+	{[]string{"mg", "milligram", "milligrams"}, "mass", 1e-6, 0},
+	{[]string{"t", "tonne", "tonnes", "metric_ton", "metric_tons"}, "mass", 1000, 0},
+	{[]string{"lb", "lbs", "pound", "pounds"}, "mass", 0.45359237, 0},
+	{[]string{"oz", "ounce", "ounces"}, "mass", 0.028349523125, 0},
+	{[]string{"st", "stone", "stones"}, "mass", 6.35029318, 0},
+
+	{[]string{"l", "L", "liter", "liters", "litre", "litres"}, "volume", 1, 0},
+	{[]string{"ml", "mL", "milliliter", "milliliters", "millilitre", "millilitres"}, "volume", 0.001, 0},
+	{[]string{"m3", "m^3", "cubic_meter", "cubic_meters", "cubic_metre", "cubic_metres"}, "volume", 1000, 0},
+	{[]string{"gal", "gallon", "gallons", "us_gal"}, "volume", 3.785411784, 0},
+	{[]string{"imp_gal", "imperial_gallon", "imperial_gallons"}, "volume", 4.54609, 0},
+	{[]string{"qt", "quart", "quarts"}, "volume", 0.946352946, 0},
+	{[]string{"pt", "pint", "pints"}, "volume", 0.473176473, 0},
+	{[]string{"cup", "cups"}, "volume", 0.2365882365, 0},
+	{[]string{"fl_oz", "floz", "fluid_ounce", "fluid_ounces"}, "volume", 0.0295735295625, 0},
+	{[]string{"tbsp", "tablespoon", "tablespoons"}, "volume", 0.01478676478125, 0},
+	{[]string{"tsp", "teaspoon", "teaspoons"}, "volume", 0.00492892159375, 0},
+
+	{[]string{"m2", "m^2", "sq_m", "square_meter", "square_meters", "square_metre", "square_metres"}, "area", 1, 0},
+	{[]string{"km2", "km^2", "sq_km", "square_kilometer", "square_kilometers"}, "area", 1e6, 0},
+	{[]string{"cm2", "cm^2", "sq_cm"}, "area", 1e-4, 0},
+	{[]string{"ha", "hectare", "hectares"}, "area", 1e4, 0},
+	{[]string{"acre", "acres", "ac"}, "area", 4046.8564224, 0},
+	{[]string{"ft2", "ft^2", "sq_ft", "square_foot", "square_feet"}, "area", 0.09290304, 0},
+	{[]string{"in2", "in^2", "sq_in", "square_inch", "square_inches"}, "area", 0.00064516, 0},
+	{[]string{"mi2", "mi^2", "sq_mi", "square_mile", "square_miles"}, "area", 2589988.110336, 0},
+
+	{[]string{"m/s", "mps", "meters_per_second"}, "speed", 1, 0},
+	{[]string{"km/h", "kmh", "kph", "kilometers_per_hour"}, "speed", 1 / 3.6, 0},
+	{[]string{"mph", "mi/h", "miles_per_hour"}, "speed", 0.44704, 0},
+	{[]string{"kn", "kt", "knot", "knots"}, "speed", 1852.0 / 3600, 0},
+	{[]string{"ft/s", "fps", "feet_per_second"}, "speed", 0.3048, 0},
+
+	{[]string{"s", "sec", "secs", "second", "seconds"}, "time", 1, 0},
+	{[]string{"ms", "millisecond", "milliseconds"}, "time", 0.001, 0},
+	{[]string{"min", "mins", "minute", "minutes"}, "time", 60, 0},
+	{[]string{"h", "hr", "hrs", "hour", "hours"}, "time", 3600, 0},
+	{[]string{"d", "day", "days"}, "time", 86400, 0},
+	{[]string{"wk", "week", "weeks"}, "time", 604800, 0},
+
+	{[]string{"B", "byte", "bytes"}, "data", 1, 0},
+	{[]string{"bit", "bits"}, "data", 0.125, 0},
+	{[]string{"kB", "KB", "kilobyte", "kilobytes"}, "data", 1e3, 0},
+	{[]string{"MB", "megabyte", "megabytes"}, "data", 1e6, 0},
+	{[]string{"GB", "gigabyte", "gigabytes"}, "data", 1e9, 0},
+	{[]string{"TB", "terabyte", "terabytes"}, "data", 1e12, 0},
+	{[]string{"KiB", "kibibyte", "kibibytes"}, "data", 1 << 10, 0},
+	{[]string{"MiB", "mebibyte", "mebibytes"}, "data", 1 << 20, 0},
+	{[]string{"GiB", "gibibyte", "gibibytes"}, "data", 1 << 30, 0},
+	{[]string{"TiB", "tebibyte", "tebibytes"}, "data", 1 << 40, 0},
+
+	{[]string{"C", "°C", "degC", "celsius"}, "temperature", 1, 273.15},
+	{[]string{"F", "°F", "degF", "fahrenheit"}, "temperature", 5.0 / 9, 273.15 - 32*5.0/9},
+	{[]string{"K", "kelvin"}, "temperature", 1, 0},
+
+	{[]string{"J", "joule", "joules"}, "energy", 1, 0},
+	{[]string{"kJ", "kilojoule", "kilojoules"}, "energy", 1e3, 0},
+	{[]string{"cal", "calorie", "calories"}, "energy", 4.184, 0},
+	{[]string{"kcal", "kilocalorie", "kilocalories"}, "energy", 4184, 0},
+	{[]string{"Wh", "watt_hour", "watt_hours"}, "energy", 3600, 0},
+	{[]string{"kWh", "kilowatt_hour", "kilowatt_hours"}, "energy", 3.6e6, 0},
+	{[]string{"BTU", "btu"}, "energy", 1055.05585262, 0},
+
+	{[]string{"Pa", "pascal", "pascals"}, "pressure", 1, 0},
+	{[]string{"kPa", "kilopascal", "kilopascals"}, "pressure", 1e3, 0},
+	{[]string{"bar", "bars"}, "pressure", 1e5, 0},
+	{[]string{"atm", "atmosphere", "atmospheres"}, "pressure", 101325, 0},
+	{[]string{"psi"}, "pressure", 6894.757293168, 0},
+	{[]string{"mmHg", "mmhg"}, "pressure", 133.322387415, 0},
+}
+
+var (
+	// convertUnitsExact maps every unit name as written.
+	convertUnitsExact = map[string]convertUnit{}
+	// convertUnitsFolded maps lower-cased names that are not ambiguous.
+	convertUnitsFolded = map[string]convertUnit{}
+)
+
+func init() {
+	ambiguous := map[string]bool{}
+	for _, entry := range convertUnitTable {
+		unit := convertUnit{name: entry.names[0], kind: entry.kind, factor: entry.factor, offset: entry.offset}
+		for _, name := range entry.names {
+			convertUnitsExact[name] = unit
+			folded := strings.ToLower(name)
+			if existing, ok := convertUnitsFolded[folded]; ok && existing.name != unit.name {
+				ambiguous[folded] = true
+			}
+			convertUnitsFolded[folded] = unit
+		}
+	}
+	for folded := range ambiguous {
+		delete(convertUnitsFolded, folded)
+	}
+}
+
+// lookupConvertUnit resolves a unit name, exactly first and then ignoring
+// case. Three upper-case letters are left to currencies ("CUP" is the
+// Cuban peso, "cup" the volume).
+func lookupConvertUnit(name string) (convertUnit, bool) {
+	name = strings.Join(strings.Fields(name), "_")
+	if unit, ok := convertUnitsExact[name]; ok {
+		return unit, true
+	}
+	if isCurrencyCode(name) {
+		return convertUnit{}, false
+	}
+	unit, ok := convertUnitsFolded[strings.ToLower(name)]
+	return unit, ok
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsUpper(r) || r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func (u convertUnit) convert(value float64, to convertUnit) float64 {
+	base := value*u.factor + u.offset
+	return (base - to.offset) / to.factor
+}
@@ -1,6 +1,7 @@
 package builtin
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -77,7 +78,7 @@ func (t *ExecCommandTool) Parameters() map[string]interface{} {
 			},
 			"workdir": map[string]interface{}{
 				"type":        "string",
-				"description": "Working directory for command execution",
+				"description": "Working directory for command execution (defaults to the session sandbox)",
 			},
 			"shell": map[string]interface{}{
 				"type":        "string",
@@ -105,6 +106,12 @@ func (t *ExecCommandTool) Parameters() map[string]interface{} {
 }
 
 func (t *ExecCommandTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	return t.ExecuteStream(ctx, input, nil)
+}
+
+// ExecuteStream runs the command like Execute and, for one-shot commands,
+// passes output to emit as the command writes it.
+func (t *ExecCommandTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit toolcore.ProgressFunc) (json.RawMessage, error) {
 	var args toolcore.ExecCommandInput
 	if err := json.Unmarshal(input, &args); err != nil {
 		return nil, fmt.Errorf("invalid input: %w", err)
@@ -116,8 +123,13 @@ func (t *ExecCommandTool) Execute(ctx context.Context, input json.RawMessage) (j
 		return nil, fmt.Errorf("cmd or command is required")
 	}
 
+	sandboxPath, err := toolcore.SandboxPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if args.TTY {
-		cmd, err := buildExecCommand(nil, args, cmdText, command, true)
+		cmd, err := buildExecCommand(nil, args, cmdText, command, sandboxPath, true)
 		if err != nil {
 			return nil, err
 		}
@@ -132,25 +144,32 @@ func (t *ExecCommandTool) Execute(ctx context.Context, input json.RawMessage) (j
 			return nil, fmt.Errorf("session not found after start")
 		}
 
-		return json.Marshal(map[string]interface{}{
+		result := map[string]interface{}{
 			"session_id": sessionID,
 			"output":     session.readNewOutput(args.MaxOutputTokens),
 			"running":    session.running(),
 			"exit_code":  session.getExitCode(),
-		})
+		}
+		if sandboxPath != "" {
+			result["sandbox_path"] = sandboxPath
+		}
+		return json.Marshal(result)
 	}
 
-	cmd, err := buildExecCommand(ctx, args, cmdText, command, false)
+	cmd, err := buildExecCommand(ctx, args, cmdText, command, sandboxPath, false)
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := cmd.CombinedOutput()
+	output, err := runExecCommand(cmd, emit)
 	exitCode := 0
 	result := map[string]interface{}{
 		"output":    truncateOutputByTokens(string(output), args.MaxOutputTokens),
 		"exit_code": exitCode,
 	}
+	if sandboxPath != "" {
+		result["sandbox_path"] = sandboxPath
+	}
 	if err != nil {
 		result["error"] = err.Error()
 		if cmd.ProcessState != nil {
@@ -163,14 +182,45 @@ func (t *ExecCommandTool) Execute(ctx context.Context, input json.RawMessage) (j
 	return json.Marshal(result)
 }
 
+// runExecCommand runs cmd and returns its combined output, copying each
+// write to emit as well when it is set.
+func runExecCommand(cmd *exec.Cmd, emit toolcore.ProgressFunc) ([]byte, error) {
+	if emit == nil {
+		return cmd.CombinedOutput()
+	}
+	out := &progressWriter{emit: emit}
+	// One writer for both streams, so exec serializes their writes.
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := cmd.Run()
+	return out.buf.Bytes(), err
+}
+
+// progressWriter collects output and forwards each write as a progress
+// chunk.
+type progressWriter struct {
+	buf  bytes.Buffer
+	emit toolcore.ProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	w.emit(string(p))
+	return len(p), nil
+}
+
 func buildExecCommand(
 	ctx context.Context,
 	args toolcore.ExecCommandInput,
 	cmdText string,
 	command string,
+	sandboxPath string,
 	interactive bool,
 ) (*exec.Cmd, error) {
 	workdir := strings.TrimSpace(args.Workdir)
+	if workdir == "" {
+		workdir = sandboxPath
+	}
 
 	if cmdText != "" {
 		shellPath := resolveExecShell(args.Shell)
@@ -186,6 +236,7 @@ func buildExecCommand(
 		if workdir != "" {
 			cmd.Dir = workdir
 		}
+		setSandboxEnv(cmd, sandboxPath)
 		return cmd, nil
 	}
 
@@ -202,9 +253,19 @@ func buildExecCommand(
 	if workdir != "" {
 		cmd.Dir = workdir
 	}
+	setSandboxEnv(cmd, sandboxPath)
 	return cmd, nil
 }
 
+// setSandboxEnv exposes the session sandbox to the command as
+// HEIKE_SANDBOX_PATH.
+func setSandboxEnv(cmd *exec.Cmd, sandboxPath string) {
+	if sandboxPath == "" {
+		return
+	}
+	cmd.Env = append(os.Environ(), "HEIKE_SANDBOX_PATH="+sandboxPath)
+}
+
 func execUsesLogin(login *bool) bool {
 	if login == nil {
 		return true
@@ -39,6 +39,21 @@ func TestExecCommandTool_Execute_Workdir(t *testing.T) {
 	assert.Equal(t, float64(0), resp["exit_code"])
 }
 
+func TestExecCommandTool_ExecuteStream_EmitsOutput(t *testing.T) {
+	tool := &ExecCommandTool{}
+
+	var chunks []string
+	raw, err := tool.ExecuteStream(context.Background(), json.RawMessage(`{"cmd":"printf 'one\\n'; printf 'two\\n' >&2","login":false}`), func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "one\ntwo\n", resp["output"])
+	assert.Equal(t, "one\ntwo\n", strings.Join(chunks, ""))
+}
+
 func TestExecCommandAndWriteStdin_InteractiveSession(t *testing.T) {
 	execTool := &ExecCommandTool{}
 	writeTool := &WriteStdinTool{}
@@ -0,0 +1,481 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultFilesMaxReadBytes  = 1 << 20
+	defaultFilesMaxWriteBytes = 1 << 20
+	// listDirMaxEntries caps list_dir output.
+	listDirMaxEntries = 500
+)
+
+func init() {
+	toolcore.RegisterBuiltin("read_file", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return &ReadFileTool{policy: newSandboxFilePolicy(options)}, nil
+	})
+	toolcore.RegisterBuiltin("write_file", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return &WriteFileTool{policy: newSandboxFilePolicy(options)}, nil
+	})
+	toolcore.RegisterBuiltin("list_dir", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return &ListDirTool{policy: newSandboxFilePolicy(options)}, nil
+	})
+}
+
+// sandboxFilePolicy confines the file tools to the session sandbox and
+// applies the size and extension limits of tools.files.
+type sandboxFilePolicy struct {
+	maxReadBytes  int64
+	maxWriteBytes int64
+	allowed       map[string]struct{}
+	denied        map[string]struct{}
+}
+
+func newSandboxFilePolicy(options toolcore.BuiltinOptions) sandboxFilePolicy {
+	p := sandboxFilePolicy{
+		maxReadBytes:  options.FilesMaxReadBytes,
+		maxWriteBytes: options.FilesMaxWriteBytes,
+		allowed:       extensionSet(options.FilesAllowedExtensions),
+		denied:        extensionSet(options.FilesDeniedExtensions),
+	}
+	if p.maxReadBytes <= 0 {
+		p.maxReadBytes = defaultFilesMaxReadBytes
+	}
+	if p.maxWriteBytes <= 0 {
+		p.maxWriteBytes = defaultFilesMaxWriteBytes
+	}
+	return p
+}
+
+func extensionSet(extensions []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = struct{}{}
+	}
+	return set
+}
+
+// checkExtension applies the extension lists to a file path.
+func (p sandboxFilePolicy) checkExtension(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if _, denied := p.denied[ext]; denied && ext != "" {
+		return fmt.Errorf("files with extension %s are not allowed", ext)
+	}
+	if len(p.allowed) == 0 {
+		return nil
+	}
+	if _, ok := p.allowed[ext]; !ok {
+		if ext == "" {
+			return fmt.Errorf("files without an extension are not allowed")
+		}
+		return fmt.Errorf("files with extension %s are not allowed", ext)
+	}
+	return nil
+}
+
+// resolve maps a sandbox-relative path to the sandbox root and an absolute
+// path under it. Paths that leave the sandbox, directly or through a
+// symlink, are refused.
+func (p sandboxFilePolicy) resolve(ctx context.Context, rel string) (root, path string, err error) {
+	root, err = toolcore.SandboxPath(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if root == "" {
+		return "", "", fmt.Errorf("no session sandbox is available for this call")
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", "", fmt.Errorf("resolve sandbox: %w", err)
+	}
+
+	rel = strings.TrimSpace(rel)
+	if rel == "" {
+		rel = "."
+	}
+	path = filepath.Clean(rel)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if !withinDir(root, path) {
+		return "", "", fmt.Errorf("path %q is outside the session sandbox", rel)
+	}
+
+	// Resolve symlinks on the longest existing prefix, since the file
+	// itself may not exist yet.
+	existing, rest := path, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			if !withinDir(root, filepath.Join(resolved, rest)) {
+				return "", "", fmt.Errorf("path %q is outside the session sandbox", rel)
+			}
+			return root, path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", err
+		}
+		if _, lerr := os.Lstat(existing); lerr == nil {
+			// A dangling symlink could point anywhere once created.
+			return "", "", fmt.Errorf("path %q crosses a broken symlink", rel)
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+}
+
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func sandboxRelative(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ReadFileTool reads a text file from the session sandbox.
+type ReadFileTool struct {
+	policy sandboxFilePolicy
+}
+
+func (t *ReadFileTool) Name() string { return "read_file" }
+
+func (t *ReadFileTool) Description() string {
+	return "Read a text file from the session sandbox. Long files are returned in pages; pass next_offset as offset to continue."
+}
+
+func (t *ReadFileTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"filesystem.read",
+			"sandbox.read",
+		},
+		Risk: toolcore.RiskLow,
+	}
+}
+
+func (t *ReadFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path relative to the session sandbox",
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Byte offset to start reading from (optional)",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *ReadFileTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Path   string `json:"path"`
+		Offset int64  `json:"offset"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if args.Offset < 0 {
+		return nil, fmt.Errorf("offset cannot be negative")
+	}
+	root, path, err := t.policy.resolve(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.policy.checkExtension(path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory; use list_dir", args.Path)
+	}
+	if _, err := f.Seek(args.Offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, t.policy.maxReadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	truncated := args.Offset+int64(len(data)) < info.Size()
+	if truncated {
+		data = trimPartialRune(data)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is not a UTF-8 text file", args.Path)
+	}
+
+	resp := map[string]interface{}{
+		"path":      sandboxRelative(root, path),
+		"content":   string(data),
+		"size":      info.Size(),
+		"truncated": truncated,
+	}
+	if truncated {
+		resp["next_offset"] = args.Offset + int64(len(data))
+	}
+	return json.Marshal(resp)
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// WriteFileTool writes a text file in the session sandbox.
+type WriteFileTool struct {
+	policy sandboxFilePolicy
+}
+
+func (t *WriteFileTool) Name() string { return "write_file" }
+
+func (t *WriteFileTool) Description() string {
+	return "Create, overwrite or append to a text file in the session sandbox. Parent directories are created."
+}
+
+func (t *WriteFileTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"filesystem.write",
+			"sandbox.write",
+		},
+		Risk: toolcore.RiskMedium,
+	}
+}
+
+func (t *WriteFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path relative to the session sandbox",
+			},
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to write",
+			},
+			"append": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Append instead of overwriting (optional)",
+			},
+		},
+		"required": []string{"path", "content"},
+	}
+}
+
+func (t *WriteFileTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+		Append  bool   `json:"append"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(args.Path) == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	root, path, err := t.policy.resolve(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if path == root {
+		return nil, fmt.Errorf("path must name a file")
+	}
+	if err := t.policy.checkExtension(path); err != nil {
+		return nil, err
+	}
+
+	size := int64(len(args.Content))
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", args.Path)
+		}
+		if args.Append {
+			size += info.Size()
+		}
+	}
+	if size > t.policy.maxWriteBytes {
+		return nil, fmt.Errorf("file would be %d bytes, over the %d byte limit", size, t.policy.maxWriteBytes)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if args.Append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+	_, err = f.WriteString(args.Content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"path":          sandboxRelative(root, path),
+		"bytes_written": len(args.Content),
+		"size":          size,
+	})
+}
+
+// ListDirTool lists a directory of the session sandbox.
+type ListDirTool struct {
+	policy sandboxFilePolicy
+}
+
+func (t *ListDirTool) Name() string { return "list_dir" }
+
+func (t *ListDirTool) Description() string {
+	return "List files and directories in the session sandbox."
+}
+
+func (t *ListDirTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"filesystem.list",
+			"sandbox.read",
+		},
+		Risk: toolcore.RiskLow,
+	}
+}
+
+func (t *ListDirTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory relative to the session sandbox (default: the sandbox root)",
+			},
+			"recursive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include subdirectories (optional)",
+			},
+		},
+	}
+}
+
+type listDirEntry struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Size int64  `json:"size,omitempty"`
+}
+
+func (t *ListDirTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Path      string `json:"path"`
+		Recursive bool   `json:"recursive"`
+	}
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &args); err != nil {
+			return nil, fmt.Errorf("invalid input: %w", err)
+		}
+	}
+	root, dir, err := t.policy.resolve(ctx, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("list directory: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", args.Path)
+	}
+
+	entries := make([]listDirEntry, 0)
+	truncated := false
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if len(entries) == listDirMaxEntries {
+			truncated = true
+			return filepath.SkipAll
+		}
+		entry := listDirEntry{Path: sandboxRelative(root, path), Type: "file"}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			entry.Type = "symlink"
+		case d.IsDir():
+			entry.Type = "dir"
+		default:
+			if info, err := d.Info(); err == nil {
+				entry.Size = info.Size()
+			}
+		}
+		entries = append(entries, entry)
+		if d.IsDir() && !args.Recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list directory: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	return json.Marshal(map[string]interface{}{
+		"path":      sandboxRelative(root, dir),
+		"entries":   entries,
+		"truncated": truncated,
+	})
+}
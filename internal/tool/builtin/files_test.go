@@ -0,0 +1,130 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sandboxContext(t *testing.T) (context.Context, string) {
+	t.Helper()
+	sandbox := t.TempDir()
+	ctx := toolcore.WithSandboxPath(context.Background(), func() (string, error) { return sandbox, nil })
+	return ctx, sandbox
+}
+
+func TestFileTools_WriteReadList(t *testing.T) {
+	ctx, sandbox := sandboxContext(t)
+	options := toolcore.BuiltinOptions{}
+	write := &WriteFileTool{policy: newSandboxFilePolicy(options)}
+	read := &ReadFileTool{policy: newSandboxFilePolicy(options)}
+	list := &ListDirTool{policy: newSandboxFilePolicy(options)}
+
+	raw, err := write.Execute(ctx, json.RawMessage(`{"path":"notes/plan.md","content":"step one\n"}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path":"notes/plan.md","bytes_written":9,"size":9}`, string(raw))
+
+	_, err = write.Execute(ctx, json.RawMessage(`{"path":"notes/plan.md","content":"step two\n","append":true}`))
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(sandbox, "notes", "plan.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "step one\nstep two\n", string(data))
+
+	raw, err = read.Execute(ctx, json.RawMessage(`{"path":"notes/plan.md"}`))
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, "step one\nstep two\n", got["content"])
+	assert.Equal(t, false, got["truncated"])
+
+	raw, err = list.Execute(ctx, json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path":".","entries":[{"path":"notes","type":"dir"}],"truncated":false}`, string(raw))
+	raw, err = list.Execute(ctx, json.RawMessage(`{"recursive":true}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"path":".","entries":[{"path":"notes","type":"dir"},{"path":"notes/plan.md","type":"file","size":18}],"truncated":false}`, string(raw))
+}
+
+func TestReadFileTool_PagesLongFiles(t *testing.T) {
+	ctx, sandbox := sandboxContext(t)
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox, "long.txt"), []byte("ab€cd"), 0o644))
+	read := &ReadFileTool{policy: newSandboxFilePolicy(toolcore.BuiltinOptions{FilesMaxReadBytes: 4})}
+
+	raw, err := read.Execute(ctx, json.RawMessage(`{"path":"long.txt"}`))
+	require.NoError(t, err)
+	var page struct {
+		Content    string `json:"content"`
+		Truncated  bool   `json:"truncated"`
+		NextOffset int64  `json:"next_offset"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &page))
+	// The euro sign is three bytes; a page never splits it.
+	assert.Equal(t, "ab", page.Content)
+	assert.True(t, page.Truncated)
+
+	raw, err = read.Execute(ctx, json.RawMessage(`{"path":"long.txt","offset":`+jsonInt(page.NextOffset)+`}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &page))
+	assert.Equal(t, "€c", page.Content)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox, "blob.txt"), []byte{0xff, 0xfe, 0x00}, 0o644))
+	_, err = read.Execute(ctx, json.RawMessage(`{"path":"blob.txt"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "UTF-8")
+}
+
+func jsonInt(n int64) string {
+	raw, _ := json.Marshal(n)
+	return string(raw)
+}
+
+func TestFileTools_Policies(t *testing.T) {
+	ctx, sandbox := sandboxContext(t)
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(sandbox, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(sandbox, "dangling.txt")))
+
+	options := toolcore.BuiltinOptions{
+		FilesMaxWriteBytes:     10,
+		FilesAllowedExtensions: []string{"txt", ".MD"},
+		FilesDeniedExtensions:  []string{".md"},
+	}
+	write := &WriteFileTool{policy: newSandboxFilePolicy(options)}
+	read := &ReadFileTool{policy: newSandboxFilePolicy(options)}
+	list := &ListDirTool{policy: newSandboxFilePolicy(options)}
+
+	for input, want := range map[string]string{
+		`{"path":"../x.txt","content":"x"}`:                  "outside",
+		`{"path":"` + outside + `/x.txt","content":"x"}`:     "outside",
+		`{"path":"escape/x.txt","content":"x"}`:              "outside",
+		`{"path":"dangling.txt","content":"x"}`:              "symlink",
+		`{"path":"run.sh","content":"x"}`:                    ".sh",
+		`{"path":"notes.md","content":"x"}`:                  ".md",
+		`{"path":"Makefile","content":"x"}`:                  "without an extension",
+		`{"path":"big.txt","content":"more than ten bytes"}`: "limit",
+		`{"path":".","content":"x"}`:                         "file",
+	} {
+		_, err := write.Execute(ctx, json.RawMessage(input))
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), want, input)
+		}
+	}
+
+	_, err := read.Execute(ctx, json.RawMessage(`{"path":"escape/secret.txt"}`))
+	require.Error(t, err)
+	_, err = list.Execute(ctx, json.RawMessage(`{"path":"escape"}`))
+	require.Error(t, err)
+	_, err = list.Execute(ctx, json.RawMessage(`{"path":".."}`))
+	require.Error(t, err)
+
+	_, err = read.Execute(context.Background(), json.RawMessage(`{"path":"a.txt"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sandbox")
+}
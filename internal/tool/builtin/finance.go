@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	toolcore "github.com/harunnryd/heike/internal/tool"
@@ -30,6 +31,12 @@ type financeRequest struct {
 	Type    string         `json:"type"`
 	Market  string         `json:"market"`
 	Finance []financeInput `json:"finance"`
+	// Fn is quote (the default), history, or one of the watchlist
+	// functions; Range and Interval apply to history.
+	Fn        string `json:"fn"`
+	Range     string `json:"range"`
+	Interval  string `json:"interval"`
+	Watchlist string `json:"watchlist"`
 }
 
 type yahooQuoteResponse struct {
@@ -65,22 +72,39 @@ func init() {
 		}
 
 		return &FinanceTool{
-			Client:  &http.Client{Timeout: timeout},
-			BaseURL: baseURL,
+			Client:         options.HTTPClients.Client("finance", timeout),
+			BaseURL:        baseURL,
+			ChartBaseURL:   strings.TrimSpace(options.FinanceChartBaseURL),
+			CryptoBaseURL:  strings.TrimSpace(options.FinanceCryptoBaseURL),
+			CryptoAPIKey:   strings.TrimSpace(options.FinanceCryptoAPIKey),
+			WatchlistsPath: options.FinanceWatchlistsPath,
 		}, nil
 	})
 }
 
-// FinanceTool retrieves market quote data by ticker symbol.
+// FinanceTool retrieves market quotes and price history by ticker symbol.
+// Crypto goes to CoinGecko when CryptoBaseURL is set, everything else to
+// Yahoo Finance.
 type FinanceTool struct {
-	Client  *http.Client
-	BaseURL string
+	Client        *http.Client
+	BaseURL       string
+	ChartBaseURL  string
+	CryptoBaseURL string
+	CryptoAPIKey  string
+	// WatchlistsPath is the JSON file saved watchlists are kept in.
+	WatchlistsPath string
+
+	mu sync.Mutex
+	// coinIDs caches CoinGecko ids found by symbol search.
+	coinIDs map[string]string
+	// watchlistMu serializes reading and rewriting WatchlistsPath.
+	watchlistMu sync.Mutex
 }
 
 func (t *FinanceTool) Name() string { return "finance" }
 
 func (t *FinanceTool) Description() string {
-	return "Look up market quote data for stocks, funds, crypto, and indexes."
+	return "Look up market quote data for stocks, funds, crypto, and indexes, one ticker, a batch, or a saved watchlist at a time, or price history over a range with fn=history. Save named watchlists such as a portfolio with fn=save_watchlist."
 }
 
 func (t *FinanceTool) ToolMetadata() toolcore.ToolMetadata {
@@ -109,11 +133,27 @@ func (t *FinanceTool) Parameters() map[string]interface{} {
 			},
 			"market": map[string]interface{}{
 				"type":        "string",
-				"description": "Optional market code (for crypto use empty string)",
+				"description": "Optional market code; for crypto, the quote currency (default USD)",
+			},
+			"fn": map[string]interface{}{
+				"type":        "string",
+				"description": "quote (default), history, watchlists, save_watchlist, or delete_watchlist",
+			},
+			"watchlist": map[string]interface{}{
+				"type":        "string",
+				"description": "Saved watchlist name: quote its tickers, or the list to save or delete",
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"description": "History range: 1d, 5d, 1mo, 3mo, 6mo, 1y, 2y, 5y, 10y, ytd or max (default 1mo)",
+			},
+			"interval": map[string]interface{}{
+				"type":        "string",
+				"description": "History interval: 1m, 5m, 15m, 30m, 1h, 1d, 1wk or 1mo (default 1d)",
 			},
 			"finance": map[string]interface{}{
 				"type":        "array",
-				"description": "Batch lookup mode",
+				"description": "Batch lookup mode, or the tickers to save with fn=save_watchlist",
 				"items": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -134,6 +174,32 @@ func (t *FinanceTool) Execute(ctx context.Context, input json.RawMessage) (json.
 		return nil, fmt.Errorf("invalid input: %w", err)
 	}
 
+	switch strings.ToLower(strings.TrimSpace(args.Fn)) {
+	case "", "quote":
+	case "history":
+		return t.executeHistory(ctx, args)
+	case "watchlists":
+		return marshalFinanceResult(t.listWatchlists())
+	case "save_watchlist":
+		return marshalFinanceResult(t.saveWatchlist(args.Watchlist, args.Finance))
+	case "delete_watchlist":
+		return marshalFinanceResult(t.deleteWatchlist(args.Watchlist))
+	default:
+		return nil, fmt.Errorf("unsupported finance fn: %s", strconv.Quote(args.Fn))
+	}
+
+	if strings.TrimSpace(args.Watchlist) != "" {
+		name, entries, err := t.watchlist(args.Watchlist)
+		if err != nil {
+			return nil, err
+		}
+		results, err := t.executeBatch(ctx, entries)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]interface{}{"watchlist": name, "results": results})
+	}
+
 	if len(args.Finance) > 0 {
 		if len(args.Finance) > maxFinanceBatchSize {
 			return nil, fmt.Errorf("finance supports at most %d tickers per call", maxFinanceBatchSize)
@@ -166,6 +232,13 @@ func (t *FinanceTool) Execute(ctx context.Context, input json.RawMessage) (json.
 	return json.Marshal(results[0])
 }
 
+func marshalFinanceResult(result map[string]interface{}, err error) (json.RawMessage, error) {
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
 func (t *FinanceTool) executeBatch(ctx context.Context, requests []financeInput) ([]map[string]interface{}, error) {
 	type normalized struct {
 		Original financeInput
@@ -174,6 +247,7 @@ func (t *FinanceTool) executeBatch(ctx context.Context, requests []financeInput)
 
 	normalizedReqs := make([]normalized, 0, len(requests))
 	symbols := make([]string, 0, len(requests))
+	var cryptoSymbols []string
 	seen := make(map[string]struct{}, len(requests))
 
 	for _, req := range requests {
@@ -187,6 +261,10 @@ func (t *FinanceTool) executeBatch(ctx context.Context, requests []financeInput)
 			continue
 		}
 		seen[symbol] = struct{}{}
+		if t.usesCoinGecko(req.Type) {
+			cryptoSymbols = append(cryptoSymbols, symbol)
+			continue
+		}
 		symbols = append(symbols, symbol)
 	}
 
@@ -194,6 +272,10 @@ func (t *FinanceTool) executeBatch(ctx context.Context, requests []financeInput)
 	if err != nil {
 		return nil, err
 	}
+	cryptoQuotes, err := t.fetchCoinGeckoQuotes(ctx, cryptoSymbols)
+	if err != nil {
+		return nil, err
+	}
 
 	results := make([]map[string]interface{}, 0, len(normalizedReqs))
 	for _, req := range normalizedReqs {
@@ -205,6 +287,14 @@ func (t *FinanceTool) executeBatch(ctx context.Context, requests []financeInput)
 			"found":           false,
 		}
 
+		if fields, ok := cryptoQuotes[req.Symbol]; ok {
+			for key, value := range fields {
+				entry[key] = value
+			}
+			entry["found"] = true
+			results = append(results, entry)
+			continue
+		}
 		quote, ok := quotes[req.Symbol]
 		if !ok {
 			results = append(results, entry)
@@ -309,6 +399,9 @@ func resolveFinanceSymbol(input financeInput) (string, error) {
 		if strings.Contains(ticker, "-") {
 			return ticker, nil
 		}
+		if market := strings.ToUpper(strings.TrimSpace(input.Market)); cryptoQuoteCurrencies[market] {
+			return ticker + "-" + market, nil
+		}
 		return ticker + "-USD", nil
 	default:
 		return "", fmt.Errorf("unsupported finance type: %s", strconv.Quote(assetType))
@@ -0,0 +1,320 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+// coinGeckoIDs maps common symbols to CoinGecko coin ids. Other symbols
+// are looked up with the search endpoint.
+var coinGeckoIDs = map[string]string{
+	"ADA":   "cardano",
+	"ATOM":  "cosmos",
+	"AVAX":  "avalanche-2",
+	"BCH":   "bitcoin-cash",
+	"BNB":   "binancecoin",
+	"BTC":   "bitcoin",
+	"DAI":   "dai",
+	"DOGE":  "dogecoin",
+	"DOT":   "polkadot",
+	"ETH":   "ethereum",
+	"LINK":  "chainlink",
+	"LTC":   "litecoin",
+	"MATIC": "matic-network",
+	"SHIB":  "shiba-inu",
+	"SOL":   "solana",
+	"TON":   "the-open-network",
+	"TRX":   "tron",
+	"UNI":   "uniswap",
+	"USDC":  "usd-coin",
+	"USDT":  "tether",
+	"XLM":   "stellar",
+	"XMR":   "monero",
+	"XRP":   "ripple",
+}
+
+// cryptoQuoteCurrencies are the currencies a crypto price can be quoted
+// in, given as the market or as the suffix of a pair such as BTC-EUR.
+var cryptoQuoteCurrencies = map[string]bool{
+	"AED": true, "ARS": true, "AUD": true, "BRL": true, "BTC": true, "CAD": true,
+	"CHF": true, "CLP": true, "CNY": true, "CZK": true, "DKK": true, "ETH": true,
+	"EUR": true, "GBP": true, "HKD": true, "HUF": true, "IDR": true, "ILS": true,
+	"INR": true, "JPY": true, "KRW": true, "MXN": true, "MYR": true, "NGN": true,
+	"NOK": true, "NZD": true, "PHP": true, "PLN": true, "SAR": true, "SEK": true,
+	"SGD": true, "THB": true, "TRY": true, "TWD": true, "UAH": true, "USD": true,
+	"VND": true, "ZAR": true,
+}
+
+// usesCoinGecko reports whether assets of this type are priced through
+// CoinGecko rather than Yahoo Finance.
+func (t *FinanceTool) usesCoinGecko(assetType string) bool {
+	return strings.TrimSpace(t.CryptoBaseURL) != "" && strings.EqualFold(strings.TrimSpace(assetType), "crypto")
+}
+
+// splitCryptoSymbol splits a resolved pair such as BTC-EUR into the coin
+// symbol and the lower-case quote currency CoinGecko expects.
+func splitCryptoSymbol(symbol string) (string, string) {
+	coin, vs, ok := strings.Cut(symbol, "-")
+	if !ok || vs == "" {
+		vs = "USD"
+	}
+	return coin, strings.ToLower(vs)
+}
+
+type coinGeckoSimplePrice map[string]map[string]float64
+
+// fetchCoinGeckoQuotes returns quote fields keyed by resolved symbol.
+// Symbols CoinGecko does not know are left out.
+func (t *FinanceTool) fetchCoinGeckoQuotes(ctx context.Context, symbols []string) (map[string]map[string]interface{}, error) {
+	results := make(map[string]map[string]interface{}, len(symbols))
+	if len(symbols) == 0 {
+		return results, nil
+	}
+
+	type coinRef struct {
+		symbol string
+		id     string
+	}
+	byCurrency := make(map[string][]coinRef)
+	var currencies []string
+	for _, symbol := range symbols {
+		coin, vs := splitCryptoSymbol(symbol)
+		id, err := t.coinGeckoID(ctx, coin)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			continue
+		}
+		if _, ok := byCurrency[vs]; !ok {
+			currencies = append(currencies, vs)
+		}
+		byCurrency[vs] = append(byCurrency[vs], coinRef{symbol: symbol, id: id})
+	}
+
+	for _, vs := range currencies {
+		refs := byCurrency[vs]
+		ids := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			ids = append(ids, ref.id)
+		}
+		query := url.Values{}
+		query.Set("ids", strings.Join(ids, ","))
+		query.Set("vs_currencies", vs)
+		query.Set("include_24hr_change", "true")
+		query.Set("include_last_updated_at", "true")
+
+		var payload coinGeckoSimplePrice
+		if err := t.coinGeckoGet(ctx, "/simple/price", query, &payload); err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			fields, ok := payload[ref.id]
+			if !ok {
+				continue
+			}
+			price, ok := fields[vs]
+			if !ok {
+				continue
+			}
+			entry := map[string]interface{}{
+				"symbol":   ref.symbol,
+				"coin_id":  ref.id,
+				"price":    price,
+				"currency": strings.ToUpper(vs),
+				"source":   "coingecko",
+			}
+			if pct, ok := fields[vs+"_24h_change"]; ok {
+				entry["change_percent"] = roundSignificant(pct)
+				if pct > -100 {
+					entry["change"] = roundSignificant(price - price/(1+pct/100))
+				}
+			}
+			if updated := int64(fields["last_updated_at"]); updated > 0 {
+				entry["timestamp"] = time.Unix(updated, 0).UTC().Format(time.RFC3339)
+			}
+			results[ref.symbol] = entry
+		}
+	}
+	return results, nil
+}
+
+type coinGeckoSearchResponse struct {
+	Coins []struct {
+		ID     string `json:"id"`
+		Symbol string `json:"symbol"`
+	} `json:"coins"`
+}
+
+// coinGeckoID resolves a coin symbol to its CoinGecko id, returning "" when
+// the search finds no coin with exactly that symbol. Search results are
+// ranked by market cap, so the first exact match is the one meant.
+func (t *FinanceTool) coinGeckoID(ctx context.Context, symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if id, ok := coinGeckoIDs[symbol]; ok {
+		return id, nil
+	}
+	t.mu.Lock()
+	id, ok := t.coinIDs[symbol]
+	t.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var payload coinGeckoSearchResponse
+	if err := t.coinGeckoGet(ctx, "/search", url.Values{"query": {symbol}}, &payload); err != nil {
+		return "", err
+	}
+	for _, coin := range payload.Coins {
+		if strings.EqualFold(coin.Symbol, symbol) && coin.ID != "" {
+			id = coin.ID
+			break
+		}
+	}
+	if id != "" {
+		t.mu.Lock()
+		if t.coinIDs == nil {
+			t.coinIDs = make(map[string]string)
+		}
+		t.coinIDs[symbol] = id
+		t.mu.Unlock()
+	}
+	return id, nil
+}
+
+type coinGeckoMarketChart struct {
+	Prices [][2]float64 `json:"prices"`
+}
+
+// fetchCoinGeckoHistory loads prices for a range and buckets them into
+// points at the requested interval. CoinGecko picks the raw granularity
+// from the range (minutes for a day, hourly up to 90 days, daily beyond),
+// so intraday intervals over long ranges come back daily.
+func (t *FinanceTool) fetchCoinGeckoHistory(ctx context.Context, symbol, historyRange, interval string) (*financeHistory, error) {
+	coin, vs := splitCryptoSymbol(symbol)
+	id, err := t.coinGeckoID(ctx, coin)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("coingecko has no coin with symbol %s", coin)
+	}
+
+	query := url.Values{}
+	query.Set("vs_currency", vs)
+	query.Set("days", coinGeckoDays(historyRange, time.Now().UTC()))
+	if financeIntervals[interval] == 0 {
+		query.Set("interval", "daily")
+	}
+	var payload coinGeckoMarketChart
+	if err := t.coinGeckoGet(ctx, "/coins/"+url.PathEscape(id)+"/market_chart", query, &payload); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(payload.Prices, func(i, j int) bool { return payload.Prices[i][0] < payload.Prices[j][0] })
+	var points []financePoint
+	var bucket time.Time
+	for _, sample := range payload.Prices {
+		at := time.UnixMilli(int64(sample[0])).UTC()
+		price := sample[1]
+		start := financeBucketStart(at, interval)
+		if len(points) == 0 || !start.Equal(bucket) {
+			bucket = start
+			points = append(points, financePoint{Time: start.Format(time.RFC3339), Open: price, High: price, Low: price, Close: price})
+			continue
+		}
+		last := &points[len(points)-1]
+		last.Close = price
+		if price > last.High {
+			last.High = price
+		}
+		if price < last.Low {
+			last.Low = price
+		}
+	}
+	return &financeHistory{
+		CoinID:   id,
+		Source:   "coingecko",
+		Currency: strings.ToUpper(vs),
+		Points:   points,
+	}, nil
+}
+
+// coinGeckoDays converts a history range to the market_chart days value.
+func coinGeckoDays(historyRange string, now time.Time) string {
+	switch historyRange {
+	case "max":
+		return "max"
+	case "ytd":
+		start := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return fmt.Sprint(int(now.Sub(start).Hours()/24) + 1)
+	}
+	days := map[string]int{
+		"1d": 1, "5d": 5, "1mo": 30, "3mo": 90, "6mo": 180,
+		"1y": 365, "2y": 730, "5y": 1825, "10y": 3650,
+	}[historyRange]
+	return fmt.Sprint(days)
+}
+
+// coinGeckoGet fetches path under CryptoBaseURL and decodes the JSON body
+// into out.
+func (t *FinanceTool) coinGeckoGet(ctx context.Context, path string, query url.Values, out interface{}) error {
+	parsed, err := url.Parse(strings.TrimRight(strings.TrimSpace(t.CryptoBaseURL), "/") + path)
+	if err != nil {
+		return fmt.Errorf("invalid crypto endpoint: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid crypto endpoint")
+	}
+	parsed.RawQuery = query.Encode()
+
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: toolcore.DefaultBuiltinWebTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Heike/1.0 (+https://example.invalid)")
+	req.Header.Set("Accept", "application/json")
+	if key := strings.TrimSpace(t.CryptoAPIKey); key != "" {
+		// Pro plans are served from pro-api.coingecko.com and take a
+		// different header from the free demo keys.
+		if strings.HasPrefix(parsed.Host, "pro-api.") {
+			req.Header.Set("x-cg-pro-api-key", key)
+		} else {
+			req.Header.Set("x-cg-demo-api-key", key)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("crypto request rate limited: %s; set tools.finance.crypto_api_key or retry later", resp.Status)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("crypto request failed: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode crypto response: %w", err)
+	}
+	return nil
+}
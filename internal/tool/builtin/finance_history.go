@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultFinanceChartBaseURL = "https://query1.finance.yahoo.com/v8/finance/chart"
+	defaultFinanceRange        = "1mo"
+	defaultFinanceInterval     = "1d"
+	// maxFinanceHistoryPoints caps the points returned; the latest are kept.
+	maxFinanceHistoryPoints = 500
+)
+
+var financeRanges = map[string]bool{
+	"1d": true, "5d": true, "1mo": true, "3mo": true, "6mo": true,
+	"1y": true, "2y": true, "5y": true, "10y": true, "ytd": true, "max": true,
+}
+
+// financeIntervals maps the supported intervals to their length; daily
+// and coarser intervals are zero and bucketed by calendar.
+var financeIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"1d":  0,
+	"1wk": 0,
+	"1mo": 0,
+}
+
+type financePoint struct {
+	Time   string  `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume int64   `json:"volume,omitempty"`
+}
+
+type financeHistory struct {
+	CoinID   string
+	Source   string
+	Currency string
+	Points   []financePoint
+}
+
+type yahooChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Currency string `json:"currency"`
+				Symbol   string `json:"symbol"`
+			} `json:"meta"`
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Open   []*float64 `json:"open"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
+					Close  []*float64 `json:"close"`
+					Volume []*float64 `json:"volume"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+func (t *FinanceTool) executeHistory(ctx context.Context, args financeRequest) (json.RawMessage, error) {
+	if len(args.Finance) > 0 || strings.TrimSpace(args.Watchlist) != "" {
+		return nil, fmt.Errorf("fn history takes a single ticker, not a finance batch or watchlist")
+	}
+	if strings.TrimSpace(args.Ticker) == "" {
+		return nil, fmt.Errorf("ticker is required")
+	}
+	if strings.TrimSpace(args.Type) == "" {
+		return nil, fmt.Errorf("type is required")
+	}
+	historyRange := strings.ToLower(strings.TrimSpace(args.Range))
+	if historyRange == "" {
+		historyRange = defaultFinanceRange
+	}
+	if !financeRanges[historyRange] {
+		return nil, fmt.Errorf("unsupported finance range: %s", strconv.Quote(args.Range))
+	}
+	interval := strings.ToLower(strings.TrimSpace(args.Interval))
+	if interval == "" {
+		interval = defaultFinanceInterval
+	}
+	if _, ok := financeIntervals[interval]; !ok {
+		return nil, fmt.Errorf("unsupported finance interval: %s", strconv.Quote(args.Interval))
+	}
+
+	input := financeInput{Ticker: args.Ticker, Type: args.Type, Market: args.Market}
+	symbol, err := resolveFinanceSymbol(input)
+	if err != nil {
+		return nil, err
+	}
+	var history *financeHistory
+	if t.usesCoinGecko(input.Type) {
+		history, err = t.fetchCoinGeckoHistory(ctx, symbol, historyRange, interval)
+	} else {
+		history, err = t.fetchChart(ctx, symbol, historyRange, interval)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"ticker":          strings.ToUpper(strings.TrimSpace(args.Ticker)),
+		"type":            strings.ToLower(strings.TrimSpace(args.Type)),
+		"resolved_symbol": symbol,
+		"source":          history.Source,
+		"currency":        history.Currency,
+		"range":           historyRange,
+		"interval":        interval,
+	}
+	if history.CoinID != "" {
+		result["coin_id"] = history.CoinID
+	}
+	points := history.Points
+	if len(points) > 0 {
+		result["summary"] = summarizeFinanceHistory(points)
+	}
+	if len(points) > maxFinanceHistoryPoints {
+		points = points[len(points)-maxFinanceHistoryPoints:]
+		result["truncated"] = true
+	}
+	if points == nil {
+		points = []financePoint{}
+	}
+	result["points"] = points
+	return json.Marshal(result)
+}
+
+// summarizeFinanceHistory describes the move across all points, before
+// any truncation.
+func summarizeFinanceHistory(points []financePoint) map[string]interface{} {
+	first := points[0].Close
+	last := points[len(points)-1].Close
+	high, low := points[0].High, points[0].Low
+	for _, point := range points[1:] {
+		if point.High > high {
+			high = point.High
+		}
+		if point.Low < low {
+			low = point.Low
+		}
+	}
+	summary := map[string]interface{}{
+		"first":  first,
+		"last":   last,
+		"change": roundSignificant(last - first),
+		"high":   high,
+		"low":    low,
+	}
+	if first != 0 {
+		summary["change_percent"] = roundSignificant((last - first) / first * 100)
+	}
+	return summary
+}
+
+// financeBucketStart returns the start of the interval containing at:
+// the UTC day, the week starting Monday, the month, or a multiple of an
+// intraday interval.
+func financeBucketStart(at time.Time, interval string) time.Time {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	switch interval {
+	case "1d":
+		return day
+	case "1wk":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "1mo":
+		return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return at.Truncate(financeIntervals[interval])
+}
+
+// fetchChart loads price history from the Yahoo Finance chart endpoint.
+func (t *FinanceTool) fetchChart(ctx context.Context, symbol, historyRange, interval string) (*financeHistory, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(t.ChartBaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultFinanceChartBaseURL
+	}
+	parsed, err := url.Parse(baseURL + "/" + url.PathEscape(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("invalid finance chart endpoint: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid finance chart endpoint")
+	}
+	q := parsed.Query()
+	q.Set("range", historyRange)
+	q.Set("interval", interval)
+	parsed.RawQuery = q.Encode()
+
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: toolcore.DefaultBuiltinWebTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Heike/1.0 (+https://example.invalid)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	var payload yahooChartResponse
+	decodeErr := json.Unmarshal(body, &payload)
+	// Yahoo reports unknown symbols and bad range/interval pairs as a
+	// chart error with a 4xx status; its description is the useful part.
+	if decodeErr == nil && payload.Chart.Error != nil && payload.Chart.Error.Description != "" {
+		return nil, fmt.Errorf("finance chart request failed: %s", payload.Chart.Error.Description)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("finance chart request failed: %s", resp.Status)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decode finance chart response: %w", decodeErr)
+	}
+	if len(payload.Chart.Result) == 0 {
+		return nil, fmt.Errorf("no price history for %s", symbol)
+	}
+
+	chart := payload.Chart.Result[0]
+	history := &financeHistory{
+		Source:   "yahoo",
+		Currency: strings.TrimSpace(chart.Meta.Currency),
+	}
+	if len(chart.Indicators.Quote) == 0 {
+		return history, nil
+	}
+	quote := chart.Indicators.Quote[0]
+	for i, ts := range chart.Timestamp {
+		closePrice := financeValueAt(quote.Close, i)
+		if closePrice == nil {
+			// Yahoo leaves nulls for periods without trades.
+			continue
+		}
+		point := financePoint{
+			Time:  time.Unix(ts, 0).UTC().Format(time.RFC3339),
+			Close: *closePrice,
+			Open:  *closePrice,
+			High:  *closePrice,
+			Low:   *closePrice,
+		}
+		if v := financeValueAt(quote.Open, i); v != nil {
+			point.Open = *v
+		}
+		if v := financeValueAt(quote.High, i); v != nil {
+			point.High = *v
+		}
+		if v := financeValueAt(quote.Low, i); v != nil {
+			point.Low = *v
+		}
+		if v := financeValueAt(quote.Volume, i); v != nil {
+			point.Volume = int64(*v)
+		}
+		history.Points = append(history.Points, point)
+	}
+	return history, nil
+}
+
+func financeValueAt(values []*float64, i int) *float64 {
+	if i >= len(values) {
+		return nil
+	}
+	return values[i]
+}
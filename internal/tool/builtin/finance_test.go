@@ -6,6 +6,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -70,3 +74,238 @@ func TestFinanceToolExecute_UnsupportedType(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported finance type")
 }
+
+func TestFinanceToolExecute_CryptoViaCoinGecko(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "demo-key", r.Header.Get("x-cg-demo-api-key"))
+		switch r.URL.Path {
+		case "/search":
+			assert.Equal(t, "PEPE", r.URL.Query().Get("query"))
+			_, _ = io.WriteString(w, `{"coins":[{"id":"pepe-wrapped","symbol":"WPEPE"},{"id":"pepe","symbol":"PEPE"}]}`)
+		case "/simple/price":
+			assert.Equal(t, "eur", r.URL.Query().Get("vs_currencies"))
+			assert.Equal(t, "bitcoin,pepe", r.URL.Query().Get("ids"))
+			_, _ = io.WriteString(w, `{"bitcoin":{"eur":50000,"eur_24h_change":25,"last_updated_at":1767139200},"pepe":{"eur":0.00001}}`)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	quotes := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "SPY", r.URL.Query().Get("symbols"))
+		_, _ = io.WriteString(w, `{"quoteResponse":{"result":[{"symbol":"SPY","regularMarketPrice":540.2,"currency":"USD"}]}}`)
+	}))
+	defer quotes.Close()
+
+	tool := &FinanceTool{
+		Client:        server.Client(),
+		BaseURL:       quotes.URL,
+		CryptoBaseURL: server.URL,
+		CryptoAPIKey:  "demo-key",
+	}
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"finance":[{"ticker":"BTC","type":"crypto","market":"EUR"},{"ticker":"pepe/eur","type":"crypto"},{"ticker":"SPY","type":"fund"}]}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	require.Len(t, resp.Results, 3)
+
+	btc := resp.Results[0]
+	assert.Equal(t, "BTC-EUR", btc["resolved_symbol"])
+	assert.Equal(t, true, btc["found"])
+	assert.Equal(t, "coingecko", btc["source"])
+	assert.Equal(t, "bitcoin", btc["coin_id"])
+	assert.Equal(t, 50000.0, btc["price"])
+	assert.Equal(t, "EUR", btc["currency"])
+	assert.Equal(t, 10000.0, btc["change"])
+	assert.Equal(t, "2025-12-31T00:00:00Z", btc["timestamp"])
+
+	assert.Equal(t, "pepe", resp.Results[1]["coin_id"])
+	assert.Equal(t, true, resp.Results[2]["found"])
+	assert.Equal(t, 540.2, resp.Results[2]["price"])
+}
+
+func TestFinanceToolExecute_CoinGeckoRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	tool := &FinanceTool{Client: server.Client(), CryptoBaseURL: server.URL}
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"ticker":"ETH","type":"crypto"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "crypto_api_key")
+}
+
+func TestFinanceToolExecute_HistoryFromChart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/MISSING" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`)
+			return
+		}
+		assert.Equal(t, "/AMD", r.URL.Path)
+		assert.Equal(t, "3mo", r.URL.Query().Get("range"))
+		assert.Equal(t, "1wk", r.URL.Query().Get("interval"))
+		_, _ = io.WriteString(w, `{"chart":{"result":[{"meta":{"currency":"USD","symbol":"AMD"},"timestamp":[1767139200,1767744000,1768348800],"indicators":{"quote":[{"open":[100,104,null],"high":[106,111,null],"low":[98,103,null],"close":[105,110,null],"volume":[1000,2000,null]}]}}],"error":null}}`)
+	}))
+	defer server.Close()
+
+	tool := &FinanceTool{Client: server.Client(), ChartBaseURL: server.URL}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"fn":"history","ticker":"amd","type":"equity","range":"3mo","interval":"1wk"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Source   string                 `json:"source"`
+		Currency string                 `json:"currency"`
+		Points   []financePoint         `json:"points"`
+		Summary  map[string]interface{} `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "yahoo", resp.Source)
+	assert.Equal(t, "USD", resp.Currency)
+	require.Len(t, resp.Points, 2)
+	assert.Equal(t, financePoint{Time: "2025-12-31T00:00:00Z", Open: 100, High: 106, Low: 98, Close: 105, Volume: 1000}, resp.Points[0])
+	assert.Equal(t, 5.0, resp.Summary["change"])
+	assert.Equal(t, 98.0, resp.Summary["low"])
+	assert.InDelta(t, 4.761904762, resp.Summary["change_percent"], 1e-9)
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"fn":"history","ticker":"MISSING","type":"equity"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "symbol may be delisted")
+}
+
+func TestFinanceToolExecute_HistoryFromCoinGecko(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coins/ethereum/market_chart", r.URL.Path)
+		assert.Equal(t, "usd", r.URL.Query().Get("vs_currency"))
+		assert.Equal(t, "30", r.URL.Query().Get("days"))
+		assert.Equal(t, "daily", r.URL.Query().Get("interval"))
+		// 2026-01-05 is a Monday: the first two days close one week, the
+		// rest fall in the next.
+		var prices []string
+		for i, price := range []string{"3000", "3100", "2900", "3300", "3200"} {
+			ms := (1767398400 + int64(i)*86400) * 1000
+			prices = append(prices, "["+strconv.FormatInt(ms, 10)+","+price+"]")
+		}
+		_, _ = io.WriteString(w, `{"prices":[`+strings.Join(prices, ",")+`]}`)
+	}))
+	defer server.Close()
+
+	tool := &FinanceTool{Client: server.Client(), CryptoBaseURL: server.URL}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"fn":"history","ticker":"ETH","type":"crypto","interval":"1wk"}`))
+	require.NoError(t, err)
+
+	var resp struct {
+		Source string         `json:"source"`
+		CoinID string         `json:"coin_id"`
+		Range  string         `json:"range"`
+		Points []financePoint `json:"points"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "coingecko", resp.Source)
+	assert.Equal(t, "ethereum", resp.CoinID)
+	assert.Equal(t, "1mo", resp.Range)
+	require.Len(t, resp.Points, 2)
+	assert.Equal(t, financePoint{Time: "2025-12-29T00:00:00Z", Open: 3000, High: 3100, Low: 3000, Close: 3100}, resp.Points[0])
+	assert.Equal(t, financePoint{Time: "2026-01-05T00:00:00Z", Open: 2900, High: 3300, Low: 2900, Close: 3200}, resp.Points[1])
+}
+
+func TestFinanceToolExecute_HistoryValidation(t *testing.T) {
+	tool := &FinanceTool{}
+	cases := map[string]string{
+		`{"fn":"history","ticker":"AMD","type":"equity","range":"2w"}`:    "unsupported finance range",
+		`{"fn":"history","ticker":"AMD","type":"equity","interval":"2d"}`: "unsupported finance interval",
+		`{"fn":"history","finance":[{"ticker":"AMD","type":"equity"}]}`:   "single ticker",
+		`{"fn":"chart","ticker":"AMD","type":"equity"}`:                   "unsupported finance fn",
+		`{"fn":"history","type":"equity"}`:                                "ticker is required",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+}
+
+func TestFinanceToolExecute_Watchlists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AMD,BTC-USD", r.URL.Query().Get("symbols"))
+		_, _ = io.WriteString(w, `{"quoteResponse":{"result":[{"symbol":"AMD","regularMarketPrice":190.5},{"symbol":"BTC-USD","regularMarketPrice":60123.4}]}}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "ws", "watchlists.json")
+	tool := &FinanceTool{Client: server.Client(), BaseURL: server.URL, WatchlistsPath: path}
+	execute := func(input string) map[string]interface{} {
+		t.Helper()
+		raw, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.NoError(t, err, input)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &resp))
+		return resp
+	}
+
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"watchlist":"portfolio"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "none saved yet")
+
+	saved := execute(`{"fn":"save_watchlist","watchlist":"Portfolio","finance":[{"ticker":" amd","type":"Equity","market":"USA"},{"ticker":"btc","type":"crypto"}]}`)
+	assert.Equal(t, "portfolio", saved["watchlist"])
+	assert.FileExists(t, path)
+
+	quoted := execute(`{"watchlist":"portfolio"}`)
+	assert.Equal(t, "portfolio", quoted["watchlist"])
+	results, ok := quoted["results"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, results, 2)
+	assert.Equal(t, 190.5, results[0].(map[string]interface{})["price"])
+	assert.Equal(t, "BTC-USD", results[1].(map[string]interface{})["resolved_symbol"])
+
+	// A second tool reading the same workspace file sees the watchlist.
+	listed := listFinanceWatchlists(t, &FinanceTool{WatchlistsPath: path})
+	require.Len(t, listed, 1)
+	assert.Equal(t, "portfolio", listed[0]["name"])
+
+	execute(`{"fn":"delete_watchlist","watchlist":"portfolio"}`)
+	assert.Empty(t, listFinanceWatchlists(t, tool))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+}
+
+func listFinanceWatchlists(t *testing.T, tool *FinanceTool) []map[string]interface{} {
+	t.Helper()
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"fn":"watchlists"}`))
+	require.NoError(t, err)
+	var resp struct {
+		Watchlists []map[string]interface{} `json:"watchlists"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	return resp.Watchlists
+}
+
+func TestFinanceToolExecute_WatchlistValidation(t *testing.T) {
+	tool := &FinanceTool{WatchlistsPath: filepath.Join(t.TempDir(), "watchlists.json")}
+	cases := map[string]string{
+		`{"fn":"save_watchlist","finance":[{"ticker":"AMD","type":"equity"}]}`:                      "watchlist is required",
+		`{"fn":"save_watchlist","watchlist":"../etc","finance":[{"ticker":"AMD","type":"equity"}]}`: "invalid watchlist name",
+		`{"fn":"save_watchlist","watchlist":"bonds","finance":[{"ticker":"T","type":"bond"}]}`:      "unsupported finance type",
+		`{"fn":"save_watchlist","watchlist":"empty"}`:                                               "finance entries are required",
+		`{"fn":"delete_watchlist","watchlist":"missing"}`:                                           "not found",
+		`{"fn":"history","watchlist":"portfolio"}`:                                                  "single ticker",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+
+	_, err := (&FinanceTool{}).Execute(context.Background(), json.RawMessage(`{"fn":"watchlists"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "without a workspace")
+}
@@ -0,0 +1,177 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxFinanceWatchlists bounds the watchlists a workspace can save.
+const maxFinanceWatchlists = 50
+
+var financeWatchlistName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// normalizeWatchlistName lower-cases a watchlist name and checks it is a
+// short slug.
+func normalizeWatchlistName(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if normalized == "" {
+		return "", fmt.Errorf("watchlist is required")
+	}
+	if !financeWatchlistName.MatchString(normalized) {
+		return "", fmt.Errorf("invalid watchlist name %q: use up to 64 letters, digits, '-' or '_'", name)
+	}
+	return normalized, nil
+}
+
+// loadWatchlists reads the saved watchlists. A missing file means none.
+// Callers hold watchlistMu.
+func (t *FinanceTool) loadWatchlists() (map[string][]financeInput, error) {
+	if strings.TrimSpace(t.WatchlistsPath) == "" {
+		return nil, fmt.Errorf("finance watchlists are not available without a workspace")
+	}
+	watchlists := map[string][]financeInput{}
+	data, err := os.ReadFile(t.WatchlistsPath)
+	if os.IsNotExist(err) {
+		return watchlists, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read watchlists: %w", err)
+	}
+	if err := json.Unmarshal(data, &watchlists); err != nil {
+		return nil, fmt.Errorf("parse watchlists %s: %w", t.WatchlistsPath, err)
+	}
+	return watchlists, nil
+}
+
+// saveWatchlists writes the watchlists through a temporary file so a
+// failed write leaves the previous ones in place. Callers hold watchlistMu.
+func (t *FinanceTool) saveWatchlists(watchlists map[string][]financeInput) error {
+	data, err := json.MarshalIndent(watchlists, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode watchlists: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.WatchlistsPath), 0700); err != nil {
+		return fmt.Errorf("create watchlists dir: %w", err)
+	}
+	tmp := t.WatchlistsPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write watchlists: %w", err)
+	}
+	if err := os.Rename(tmp, t.WatchlistsPath); err != nil {
+		return fmt.Errorf("write watchlists: %w", err)
+	}
+	return nil
+}
+
+// watchlist returns the entries saved under name.
+func (t *FinanceTool) watchlist(name string) (string, []financeInput, error) {
+	name, err := normalizeWatchlistName(name)
+	if err != nil {
+		return "", nil, err
+	}
+	t.watchlistMu.Lock()
+	defer t.watchlistMu.Unlock()
+	watchlists, err := t.loadWatchlists()
+	if err != nil {
+		return "", nil, err
+	}
+	entries, ok := watchlists[name]
+	if !ok {
+		if len(watchlists) == 0 {
+			return "", nil, fmt.Errorf("watchlist %q not found; none saved yet", name)
+		}
+		return "", nil, fmt.Errorf("watchlist %q not found; saved: %s", name, strings.Join(sortedWatchlistNames(watchlists), ", "))
+	}
+	return name, entries, nil
+}
+
+// saveWatchlist replaces the watchlist called name with entries, checking
+// each resolves like a quote request would.
+func (t *FinanceTool) saveWatchlist(name string, entries []financeInput) (map[string]interface{}, error) {
+	name, err := normalizeWatchlistName(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("finance entries are required to save a watchlist")
+	}
+	if len(entries) > maxFinanceBatchSize {
+		return nil, fmt.Errorf("a watchlist holds at most %d tickers", maxFinanceBatchSize)
+	}
+	cleaned := make([]financeInput, 0, len(entries))
+	for _, entry := range entries {
+		if _, err := resolveFinanceSymbol(entry); err != nil {
+			return nil, err
+		}
+		cleaned = append(cleaned, financeInput{
+			Ticker: strings.ToUpper(strings.TrimSpace(entry.Ticker)),
+			Type:   strings.ToLower(strings.TrimSpace(entry.Type)),
+			Market: strings.TrimSpace(entry.Market),
+		})
+	}
+
+	t.watchlistMu.Lock()
+	defer t.watchlistMu.Unlock()
+	watchlists, err := t.loadWatchlists()
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := watchlists[name]; !exists && len(watchlists) >= maxFinanceWatchlists {
+		return nil, fmt.Errorf("at most %d watchlists can be saved; delete one first", maxFinanceWatchlists)
+	}
+	watchlists[name] = cleaned
+	if err := t.saveWatchlists(watchlists); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"watchlist": name, "saved": true, "finance": cleaned}, nil
+}
+
+func (t *FinanceTool) deleteWatchlist(name string) (map[string]interface{}, error) {
+	name, err := normalizeWatchlistName(name)
+	if err != nil {
+		return nil, err
+	}
+	t.watchlistMu.Lock()
+	defer t.watchlistMu.Unlock()
+	watchlists, err := t.loadWatchlists()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := watchlists[name]; !ok {
+		return nil, fmt.Errorf("watchlist %q not found", name)
+	}
+	delete(watchlists, name)
+	if err := t.saveWatchlists(watchlists); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"watchlist": name, "deleted": true}, nil
+}
+
+func (t *FinanceTool) listWatchlists() (map[string]interface{}, error) {
+	t.watchlistMu.Lock()
+	defer t.watchlistMu.Unlock()
+	watchlists, err := t.loadWatchlists()
+	if err != nil {
+		return nil, err
+	}
+	names := sortedWatchlistNames(watchlists)
+	listed := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		listed = append(listed, map[string]interface{}{"name": name, "finance": watchlists[name]})
+	}
+	return map[string]interface{}{"watchlists": listed}, nil
+}
+
+func sortedWatchlistNames(watchlists map[string][]financeInput) []string {
+	names := make([]string, 0, len(watchlists))
+	for name := range watchlists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,485 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultGitHubBaseURL = "https://api.github.com"
+	defaultGitHubTimeout = 15 * time.Second
+	// githubMaxFileBytes caps the file content get_file returns.
+	githubMaxFileBytes = 100 * 1024
+	// githubMaxResponseBytes covers a 1 MB file, the contents API limit,
+	// after base64 encoding.
+	githubMaxResponseBytes = 2 << 20
+	githubMaxSearchSize    = 50
+)
+
+// GitHub actions. Reads need no approval; create_issue and comment are on
+// governance.require_approval by default.
+const (
+	githubActionSearchRepos  = "search_repos"
+	githubActionSearchIssues = "search_issues"
+	githubActionGetFile      = "get_file"
+	githubActionCreateIssue  = "create_issue"
+	githubActionComment      = "comment"
+)
+
+type githubInput struct {
+	Action string   `json:"action"`
+	Query  string   `json:"query"`
+	Limit  int      `json:"limit"`
+	Repo   string   `json:"repo"`
+	Path   string   `json:"path"`
+	Ref    string   `json:"ref"`
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels"`
+	Number int      `json:"number"`
+}
+
+func init() {
+	toolcore.RegisterBuiltin("github", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		timeout := options.GitHubTimeout
+		if timeout <= 0 {
+			timeout = defaultGitHubTimeout
+		}
+		baseURL := strings.TrimSpace(options.GitHubBaseURL)
+		if baseURL == "" {
+			baseURL = defaultGitHubBaseURL
+		}
+		return &GitHubTool{
+			Client:  options.HTTPClients.Client("github", timeout),
+			BaseURL: strings.TrimRight(baseURL, "/"),
+			Token:   options.GitHubToken,
+		}, nil
+	})
+}
+
+// GitHubTool searches GitHub, reads repository files, and creates issues
+// and comments with a personal access token.
+type GitHubTool struct {
+	Client  *http.Client
+	BaseURL string
+	Token   string
+}
+
+func (t *GitHubTool) Name() string { return "github" }
+
+func (t *GitHubTool) Description() string {
+	return "Search GitHub repositories and issues, read repository files, and create issues or issue/PR comments. Creating issues and comments requires approval."
+}
+
+func (t *GitHubTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"github.search",
+			"github.read",
+			"github.write",
+			"http.get",
+			"http.post",
+		},
+		Risk: toolcore.RiskMedium,
+	}
+}
+
+func (t *GitHubTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type": "string",
+				"enum": []string{
+					githubActionSearchRepos,
+					githubActionSearchIssues,
+					githubActionGetFile,
+					githubActionCreateIssue,
+					githubActionComment,
+				},
+				"description": "Operation to run",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "GitHub search query for search_repos and search_issues (for example: repo:owner/name is:open label:bug)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum search results (default 10, max 50)",
+			},
+			"repo": map[string]interface{}{
+				"type":        "string",
+				"description": "Repository as owner/name for get_file, create_issue and comment",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File or directory path for get_file",
+			},
+			"ref": map[string]interface{}{
+				"type":        "string",
+				"description": "Branch, tag or commit for get_file (default branch when empty)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Issue title for create_issue",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Issue body for create_issue, comment text for comment",
+			},
+			"labels": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Labels for create_issue",
+			},
+			"number": map[string]interface{}{
+				"type":        "integer",
+				"description": "Issue or pull request number for comment",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *GitHubTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args githubInput
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	switch strings.TrimSpace(args.Action) {
+	case githubActionSearchRepos:
+		return t.searchRepos(ctx, args)
+	case githubActionSearchIssues:
+		return t.searchIssues(ctx, args)
+	case githubActionGetFile:
+		return t.getFile(ctx, args)
+	case githubActionCreateIssue:
+		return t.createIssue(ctx, args)
+	case githubActionComment:
+		return t.comment(ctx, args)
+	case "":
+		return nil, fmt.Errorf("action is required")
+	default:
+		return nil, fmt.Errorf("unsupported action %q", args.Action)
+	}
+}
+
+func (t *GitHubTool) searchRepos(ctx context.Context, args githubInput) (json.RawMessage, error) {
+	var resp struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			FullName    string `json:"full_name"`
+			Description string `json:"description"`
+			HTMLURL     string `json:"html_url"`
+			Stars       int    `json:"stargazers_count"`
+			Language    string `json:"language"`
+			UpdatedAt   string `json:"updated_at"`
+		} `json:"items"`
+	}
+	if err := t.search(ctx, "/search/repositories", args, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		results = append(results, map[string]interface{}{
+			"repo":        item.FullName,
+			"description": item.Description,
+			"url":         item.HTMLURL,
+			"stars":       item.Stars,
+			"language":    item.Language,
+			"updated_at":  item.UpdatedAt,
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"total_count": resp.TotalCount,
+		"results":     results,
+	})
+}
+
+func (t *GitHubTool) searchIssues(ctx context.Context, args githubInput) (json.RawMessage, error) {
+	var resp struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			Number        int    `json:"number"`
+			Title         string `json:"title"`
+			State         string `json:"state"`
+			HTMLURL       string `json:"html_url"`
+			RepositoryURL string `json:"repository_url"`
+			UpdatedAt     string `json:"updated_at"`
+			Comments      int    `json:"comments"`
+			User          struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			PullRequest *json.RawMessage `json:"pull_request"`
+		} `json:"items"`
+	}
+	if err := t.search(ctx, "/search/issues", args, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, 0, len(resp.Items))
+	for _, item := range resp.Items {
+		results = append(results, map[string]interface{}{
+			"repo":            repoFromAPIURL(item.RepositoryURL),
+			"number":          item.Number,
+			"title":           item.Title,
+			"state":           item.State,
+			"author":          item.User.Login,
+			"comments":        item.Comments,
+			"is_pull_request": item.PullRequest != nil,
+			"url":             item.HTMLURL,
+			"updated_at":      item.UpdatedAt,
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"total_count": resp.TotalCount,
+		"results":     results,
+	})
+}
+
+func (t *GitHubTool) search(ctx context.Context, path string, args githubInput, out interface{}) error {
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return fmt.Errorf("query is required for %s", args.Action)
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > githubMaxSearchSize {
+		limit = githubMaxSearchSize
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", strconv.Itoa(limit))
+	return t.do(ctx, http.MethodGet, path+"?"+params.Encode(), nil, out)
+}
+
+func (t *GitHubTool) getFile(ctx context.Context, args githubInput) (json.RawMessage, error) {
+	repoPath, err := githubRepoPath(args.Repo)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]string, 0)
+	for _, segment := range strings.Split(strings.Trim(args.Path, "/"), "/") {
+		if segment != "" {
+			segments = append(segments, url.PathEscape(segment))
+		}
+	}
+	endpoint := repoPath + "/contents/" + strings.Join(segments, "/")
+	if ref := strings.TrimSpace(args.Ref); ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(ref)
+	}
+
+	var raw json.RawMessage
+	if err := t.do(ctx, http.MethodGet, endpoint, nil, &raw); err != nil {
+		return nil, err
+	}
+
+	// A directory comes back as a list of entries.
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+			Type string `json:"type"`
+			Size int64  `json:"size"`
+		}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("decode directory listing: %w", err)
+		}
+		return json.Marshal(map[string]interface{}{
+			"repo":    strings.TrimSpace(args.Repo),
+			"path":    strings.Trim(args.Path, "/"),
+			"type":    "dir",
+			"entries": entries,
+		})
+	}
+
+	var file struct {
+		Type     string `json:"type"`
+		Path     string `json:"path"`
+		Size     int64  `json:"size"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+		HTMLURL  string `json:"html_url"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("decode file: %w", err)
+	}
+	if file.Type != "file" {
+		return nil, fmt.Errorf("%s is a %s, not a file", args.Path, file.Type)
+	}
+	if file.Encoding != "base64" {
+		return nil, fmt.Errorf("%s is too large to read through the contents API (%d bytes)", file.Path, file.Size)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("decode file content: %w", err)
+	}
+	truncated := len(data) > githubMaxFileBytes
+	if truncated {
+		data = trimPartialRune(data[:githubMaxFileBytes])
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%s is not a UTF-8 text file", file.Path)
+	}
+	return json.Marshal(map[string]interface{}{
+		"repo":      strings.TrimSpace(args.Repo),
+		"path":      file.Path,
+		"type":      "file",
+		"size":      file.Size,
+		"content":   string(data),
+		"truncated": truncated,
+		"url":       file.HTMLURL,
+	})
+}
+
+func (t *GitHubTool) createIssue(ctx context.Context, args githubInput) (json.RawMessage, error) {
+	repoPath, err := githubRepoPath(args.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Title) == "" {
+		return nil, fmt.Errorf("title is required for create_issue")
+	}
+	payload := map[string]interface{}{
+		"title": strings.TrimSpace(args.Title),
+		"body":  args.Body,
+	}
+	if len(args.Labels) > 0 {
+		payload["labels"] = args.Labels
+	}
+
+	var resp struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := t.do(ctx, http.MethodPost, repoPath+"/issues", payload, &resp); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"repo":   strings.TrimSpace(args.Repo),
+		"number": resp.Number,
+		"url":    resp.HTMLURL,
+	})
+}
+
+func (t *GitHubTool) comment(ctx context.Context, args githubInput) (json.RawMessage, error) {
+	repoPath, err := githubRepoPath(args.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if args.Number <= 0 {
+		return nil, fmt.Errorf("number is required for comment")
+	}
+	if strings.TrimSpace(args.Body) == "" {
+		return nil, fmt.Errorf("body is required for comment")
+	}
+
+	// Pull requests share the issue comment endpoint.
+	var resp struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}
+	endpoint := fmt.Sprintf("%s/issues/%d/comments", repoPath, args.Number)
+	if err := t.do(ctx, http.MethodPost, endpoint, map[string]string{"body": args.Body}, &resp); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"repo":   strings.TrimSpace(args.Repo),
+		"number": args.Number,
+		"id":     resp.ID,
+		"url":    resp.HTMLURL,
+	})
+}
+
+// do sends one API request and decodes the JSON response into out.
+func (t *GitHubTool) do(ctx context.Context, method, endpoint string, payload, out interface{}) error {
+	if method != http.MethodGet && strings.TrimSpace(t.Token) == "" {
+		return fmt.Errorf("github token is not configured; set tools.github.token or GITHUB_TOKEN")
+	}
+
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.BaseURL+endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", "Heike/1.0")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := strings.TrimSpace(t.Token); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, githubMaxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read github response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		msg := strings.TrimSpace(apiErr.Message)
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		if (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound) && strings.TrimSpace(t.Token) == "" {
+			msg += " (no github token is configured; private repositories need tools.github.token)"
+		}
+		return fmt.Errorf("github API error (status %d): %s", resp.StatusCode, msg)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode github response: %w", err)
+	}
+	return nil
+}
+
+// githubRepoPath validates an owner/name repository and returns its API path.
+func githubRepoPath(repo string) (string, error) {
+	repo = strings.TrimSpace(repo)
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") || owner == ".." || name == ".." {
+		return "", fmt.Errorf("repo must be owner/name, got %q", repo)
+	}
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name), nil
+}
+
+// repoFromAPIURL turns https://api.github.com/repos/owner/name into owner/name.
+func repoFromAPIURL(apiURL string) string {
+	if _, rest, ok := strings.Cut(apiURL, "/repos/"); ok {
+		return rest
+	}
+	return apiURL
+}
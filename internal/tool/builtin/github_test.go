@@ -0,0 +1,133 @@
+package builtin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubTool_Reads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ghp_test", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		switch r.URL.Path {
+		case "/search/repositories":
+			assert.Equal(t, "agent language:go", r.URL.Query().Get("q"))
+			assert.Equal(t, "50", r.URL.Query().Get("per_page"))
+			_, _ = w.Write([]byte(`{"total_count":1,"items":[{"full_name":"acme/heike","description":"agent","html_url":"https://github.com/acme/heike","stargazers_count":42,"language":"Go"}]}`))
+		case "/search/issues":
+			_, _ = w.Write([]byte(`{"total_count":2,"items":[
+				{"number":7,"title":"Crash","state":"open","repository_url":"https://api.github.com/repos/acme/heike","user":{"login":"ana"}},
+				{"number":8,"title":"Fix crash","state":"closed","repository_url":"https://api.github.com/repos/acme/heike","user":{"login":"bo"},"pull_request":{}}]}`))
+		case "/repos/acme/heike/contents/docs/read me.md":
+			assert.Equal(t, "v1.0", r.URL.Query().Get("ref"))
+			content := base64.StdEncoding.EncodeToString([]byte("# Heike\n"))
+			_, _ = w.Write([]byte(`{"type":"file","path":"docs/read me.md","size":8,"encoding":"base64","content":"` + content + `"}`))
+		case "/repos/acme/heike/contents/docs":
+			_, _ = w.Write([]byte(`[{"name":"read me.md","path":"docs/read me.md","type":"file","size":8}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tool := &GitHubTool{Client: server.Client(), BaseURL: server.URL, Token: "ghp_test"}
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"search_repos","query":"agent language:go","limit":100}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"total_count":1,"results":[{"repo":"acme/heike","description":"agent","url":"https://github.com/acme/heike","stars":42,"language":"Go","updated_at":""}]}`, string(raw))
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"search_issues","query":"repo:acme/heike crash"}`))
+	require.NoError(t, err)
+	var issues struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &issues))
+	require.Len(t, issues.Results, 2)
+	assert.Equal(t, "acme/heike", issues.Results[0]["repo"])
+	assert.Equal(t, false, issues.Results[0]["is_pull_request"])
+	assert.Equal(t, true, issues.Results[1]["is_pull_request"])
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"get_file","repo":"acme/heike","path":"/docs/read me.md","ref":"v1.0"}`))
+	require.NoError(t, err)
+	var file map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &file))
+	assert.Equal(t, "# Heike\n", file["content"])
+	assert.Equal(t, false, file["truncated"])
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"get_file","repo":"acme/heike","path":"docs"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"type":"dir"`)
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"get_file","repo":"acme/heike","path":"missing"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
+
+func TestGitHubTool_Writes(t *testing.T) {
+	var got []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payload["path"] = r.URL.Path
+		got = append(got, payload)
+		switch r.URL.Path {
+		case "/repos/acme/heike/issues":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number":9,"html_url":"https://github.com/acme/heike/issues/9"}`))
+		case "/repos/acme/heike/issues/9/comments":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":123,"html_url":"https://github.com/acme/heike/issues/9#issuecomment-123"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Resource not accessible by personal access token"}`))
+		}
+	}))
+	defer server.Close()
+	tool := &GitHubTool{Client: server.Client(), BaseURL: server.URL, Token: "ghp_test"}
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"action":"create_issue","repo":"acme/heike","title":"Flaky test","body":"Seen twice.","labels":["ci"]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"repo":"acme/heike","number":9,"url":"https://github.com/acme/heike/issues/9"}`, string(raw))
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"comment","repo":"acme/heike","number":9,"body":"Fixed in #10."}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"id":123`)
+
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]interface{}{"path": "/repos/acme/heike/issues", "title": "Flaky test", "body": "Seen twice.", "labels": []interface{}{"ci"}}, got[0])
+	assert.Equal(t, map[string]interface{}{"path": "/repos/acme/heike/issues/9/comments", "body": "Fixed in #10."}, got[1])
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"action":"comment","repo":"other/repo","number":1,"body":"hi"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Resource not accessible")
+
+	for input, want := range map[string]string{
+		`{"action":"create_issue","repo":"acme","title":"x"}`:  "owner/name",
+		`{"action":"create_issue","repo":"a/b/c","title":"x"}`: "owner/name",
+		`{"action":"create_issue","repo":"acme/heike"}`:        "title",
+		`{"action":"comment","repo":"acme/heike","body":"x"}`:  "number",
+		`{"action":"search_repos"}`:                            "query",
+		`{"action":"delete_repo","repo":"acme/heike"}`:         "unsupported",
+	} {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), want, input)
+		}
+	}
+
+	anonymous := &GitHubTool{Client: server.Client(), BaseURL: server.URL}
+	_, err = anonymous.Execute(context.Background(), json.RawMessage(`{"action":"create_issue","repo":"acme/heike","title":"x"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token")
+	// Refused before any request is sent.
+	assert.Len(t, got, 3)
+}
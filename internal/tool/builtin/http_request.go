@@ -0,0 +1,295 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/harunnryd/heike/internal/httpclient"
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultHTTPRequestTimeout          = 30 * time.Second
+	defaultHTTPRequestMaxRequestBytes  = 1 << 20
+	defaultHTTPRequestMaxResponseBytes = 256 << 10
+	// httpRequestMaxRedirects bounds redirect chains; each hop is checked
+	// against the domain lists again.
+	httpRequestMaxRedirects = 5
+)
+
+var httpRequestMethods = map[string]struct{}{
+	http.MethodGet:    {},
+	http.MethodHead:   {},
+	http.MethodPost:   {},
+	http.MethodPut:    {},
+	http.MethodPatch:  {},
+	http.MethodDelete: {},
+}
+
+func init() {
+	toolcore.RegisterBuiltin("http_request", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewHTTPRequestTool(options), nil
+	})
+}
+
+// HTTPRequestTool calls an arbitrary HTTP API, within the domain lists and
+// size limits of tools.http.
+type HTTPRequestTool struct {
+	Client           *http.Client
+	allowedDomains   []string
+	deniedDomains    []string
+	maxRequestBytes  int64
+	maxResponseBytes int64
+	allowPrivate     bool
+	// lookupIP resolves hosts for the early private network check; the
+	// client checks the address it actually connects to again.
+	lookupIP func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+func NewHTTPRequestTool(options toolcore.BuiltinOptions) *HTTPRequestTool {
+	timeout := options.HTTPRequestTimeout
+	if timeout <= 0 {
+		timeout = defaultHTTPRequestTimeout
+	}
+	// Unless private networks are allowed, the client refuses non-public
+	// addresses when it dials, so DNS rebinding cannot slip past checkURL.
+	client := options.HTTPClients.PublicOnly("http_request", timeout)
+	if options.HTTPRequestAllowPrivate {
+		client = options.HTTPClients.Client("http_request", timeout)
+	}
+	t := &HTTPRequestTool{
+		Client:           client,
+		allowedDomains:   normalizeDomains(options.HTTPRequestAllowedDomains),
+		deniedDomains:    normalizeDomains(options.HTTPRequestDeniedDomains),
+		maxRequestBytes:  options.HTTPRequestMaxRequestBytes,
+		maxResponseBytes: options.HTTPRequestMaxResponseBytes,
+		allowPrivate:     options.HTTPRequestAllowPrivate,
+		lookupIP: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}
+	if t.maxRequestBytes <= 0 {
+		t.maxRequestBytes = defaultHTTPRequestMaxRequestBytes
+	}
+	if t.maxResponseBytes <= 0 {
+		t.maxResponseBytes = defaultHTTPRequestMaxResponseBytes
+	}
+	t.Client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= httpRequestMaxRedirects {
+			return fmt.Errorf("stopped after %d redirects", httpRequestMaxRedirects)
+		}
+		return t.checkURL(req.Context(), req.URL)
+	}
+	return t
+}
+
+func normalizeDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		domain = strings.TrimPrefix(domain, "*.")
+		domain = strings.Trim(domain, ".")
+		if domain != "" {
+			out = append(out, domain)
+		}
+	}
+	return out
+}
+
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL applies the scheme, domain and private network rules to u.
+func (t *HTTPRequestTool) checkURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q: only http and https are allowed", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("URL %q has no host", u.String())
+	}
+	if matchesDomain(host, t.deniedDomains) {
+		return fmt.Errorf("host %s is denied by tools.http.denied_domains", host)
+	}
+	if len(t.allowedDomains) > 0 && !matchesDomain(host, t.allowedDomains) {
+		return fmt.Errorf("host %s is not in tools.http.allowed_domains", host)
+	}
+	if t.allowPrivate {
+		return nil
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var err error
+		if ips, err = t.lookupIP(ctx, host); err != nil {
+			return fmt.Errorf("resolve %s: %w", host, err)
+		}
+	}
+	for _, ip := range ips {
+		if !httpclient.IsPublicAddress(ip) {
+			return fmt.Errorf("host %s resolves to private address %s; set tools.http.allow_private_networks to reach it", host, ip)
+		}
+	}
+	return nil
+}
+
+func (t *HTTPRequestTool) Name() string {
+	return "http_request"
+}
+
+func (t *HTTPRequestTool) Description() string {
+	return "Send an HTTP request (GET, POST, PUT, PATCH, DELETE or HEAD) with optional headers and body to an API. Requires Domain Approval."
+}
+
+func (t *HTTPRequestTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"http.request",
+			"http.get",
+			"http.post",
+		},
+		Risk: toolcore.RiskMedium,
+	}
+}
+
+func (t *HTTPRequestTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "Absolute http or https URL",
+			},
+			"method": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+				"description": "HTTP method (default GET)",
+			},
+			"headers": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+				"description":          "Request headers (optional)",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Raw request body (optional)",
+			},
+			"json": map[string]interface{}{
+				"description": "JSON request body; sets Content-Type to application/json (optional, replaces body)",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *HTTPRequestTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		URL     string            `json:"url"`
+		Method  string            `json:"method"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body"`
+		JSON    json.RawMessage   `json:"json"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(args.URL) == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	method := strings.ToUpper(strings.TrimSpace(args.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	if _, ok := httpRequestMethods[method]; !ok {
+		return nil, fmt.Errorf("unsupported method %q", args.Method)
+	}
+
+	target, err := url.Parse(strings.TrimSpace(args.URL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.checkURL(ctx, target); err != nil {
+		return nil, err
+	}
+
+	body := []byte(args.Body)
+	hasJSON := len(args.JSON) > 0 && string(args.JSON) != "null"
+	if hasJSON {
+		body = args.JSON
+	}
+	if int64(len(body)) > t.maxRequestBytes {
+		return nil, fmt.Errorf("request body is %d bytes, over the %d byte limit", len(body), t.maxRequestBytes)
+	}
+
+	var reader io.Reader
+	if len(body) > 0 {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range args.Headers {
+		req.Header.Set(name, value)
+	}
+	if hasJSON && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "Heike/1.0")
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		if errors.Is(err, httpclient.ErrPrivateAddress) {
+			return nil, fmt.Errorf("request failed: %w; set tools.http.allow_private_networks to reach it", err)
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := int64(len(data)) > t.maxResponseBytes
+	if truncated {
+		data = trimPartialRune(data[:t.maxResponseBytes])
+	}
+
+	headers := make(map[string]string, len(resp.Header))
+	for name, values := range resp.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	result := map[string]interface{}{
+		"url":       resp.Request.URL.String(),
+		"status":    resp.StatusCode,
+		"headers":   headers,
+		"truncated": truncated,
+	}
+	if utf8.Valid(data) {
+		result["body"] = string(data)
+	} else {
+		result["body_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+	return json.Marshal(result)
+}
@@ -0,0 +1,177 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRequestTool_Execute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("echo:" + string(body)))
+	}))
+	defer server.Close()
+
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{HTTPRequestAllowPrivate: true})
+	assert.Equal(t, "http_request", tool.Name())
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`/items","method":"post","headers":{"Authorization":"Bearer t"},"json":{"name":"a"}}`))
+	require.NoError(t, err)
+	var got struct {
+		Status    int               `json:"status"`
+		Headers   map[string]string `json:"headers"`
+		Body      string            `json:"body"`
+		Truncated bool              `json:"truncated"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, http.StatusOK, got.Status)
+	assert.Equal(t, "POST", got.Headers["X-Method"])
+	assert.Equal(t, "application/json", got.Headers["X-Content-Type"])
+	assert.Equal(t, "Bearer t", got.Headers["X-Token"])
+	assert.Equal(t, `echo:{"name":"a"}`, got.Body)
+	assert.False(t, got.Truncated)
+}
+
+func TestHTTPRequestTool_Limits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{
+		HTTPRequestAllowPrivate:     true,
+		HTTPRequestMaxRequestBytes:  4,
+		HTTPRequestMaxResponseBytes: 4,
+	})
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`"}`))
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, "0123", got["body"])
+	assert.Equal(t, true, got["truncated"])
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`","method":"POST","body":"too long"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limit")
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`","method":"TRACE"}`))
+	require.Error(t, err)
+}
+
+func TestHTTPRequestTool_DomainPolicy(t *testing.T) {
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{
+		HTTPRequestAllowedDomains: []string{"example.com", "*.api.test"},
+		HTTPRequestDeniedDomains:  []string{"admin.example.com"},
+	})
+	tool.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		if host == "internal.api.test" {
+			return []net.IP{net.ParseIP("10.0.0.5")}, nil
+		}
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+
+	for rawURL, want := range map[string]string{
+		"https://admin.example.com/x":   "denied",
+		"https://a.admin.example.com/x": "denied",
+		"https://other.org/x":           "not in tools.http.allowed_domains",
+		"https://internal.api.test/x":   "private address",
+		"http://127.0.0.1/x":            "not in tools.http.allowed_domains",
+		"ftp://example.com/x":           "scheme",
+	} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		err = tool.checkURL(context.Background(), u)
+		if assert.Error(t, err, rawURL) {
+			assert.Contains(t, err.Error(), want, rawURL)
+		}
+	}
+	for _, rawURL := range []string{"https://example.com/x", "https://www.example.com/x", "https://v1.api.test/x"} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		assert.NoError(t, tool.checkURL(context.Background(), u), rawURL)
+	}
+}
+
+func TestHTTPRequestTool_BlocksPrivateNetworksAndRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer target.Close()
+
+	_, err := NewHTTPRequestTool(toolcore.BuiltinOptions{}).Execute(context.Background(), json.RawMessage(`{"url":"`+target.URL+`"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "private address")
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://blocked.test/", http.StatusFound)
+	}))
+	defer redirect.Close()
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{
+		HTTPRequestAllowPrivate:  true,
+		HTTPRequestDeniedDomains: []string{"blocked.test"},
+	})
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"url":"`+redirect.URL+`"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "denied")
+}
+
+func TestHTTPRequestTool_ChecksConnectedAddress(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer target.Close()
+	_, port, err := net.SplitHostPort(target.Listener.Addr().String())
+	require.NoError(t, err)
+
+	// The name check sees a public address, as with a rebinding DNS server,
+	// but the connection goes to 127.0.0.1.
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{})
+	tool.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("93.184.216.34")}, nil
+	}
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"url":"http://localhost:`+port+`/"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "private network address")
+	assert.Contains(t, err.Error(), "tools.http.allow_private_networks")
+}
+
+func TestHTTPRequestTool_BlocksPrivateRedirectTargets(t *testing.T) {
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	_, port, err := net.SplitHostPort(redirect.Listener.Addr().String())
+	require.NoError(t, err)
+
+	tool := NewHTTPRequestTool(toolcore.BuiltinOptions{})
+	tool.lookupIP = func(ctx context.Context, host string) ([]net.IP, error) {
+		if host == "localhost" {
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		}
+		return []net.IP{net.ParseIP("169.254.169.254")}, nil
+	}
+	// Reach the local redirector as if it were public, keeping the tool's
+	// redirect policy.
+	tool.Client.Transport = http.DefaultTransport
+
+	for _, to := range []string{"http://metadata.test/latest", "http://100.64.0.1/", "http://[::1]/"} {
+		_, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"http://localhost:`+port+`/?to=`+url.QueryEscape(to)+`"}`))
+		if assert.Error(t, err, to) {
+			assert.Contains(t, err.Error(), "private address", to)
+		}
+	}
+}
@@ -71,7 +71,7 @@ func init() {
 		}
 
 		return &ImageQueryTool{
-			Client:  &http.Client{Timeout: timeout},
+			Client:  options.HTTPClients.Client("image_query", timeout),
 			BaseURL: baseURL,
 		}, nil
 	})
@@ -0,0 +1,599 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultNewsTimeout  = 15 * time.Second
+	defaultNewsCacheTTL = 15 * time.Minute
+	defaultNewsMaxItems = 10
+	maxNewsItemsHardCap = 50
+	maxNewsFeedsPerCall = 10
+	maxNewsFeedBytes    = 4 << 20
+	// newsSummaryRunes bounds each item summary.
+	newsSummaryRunes = 300
+)
+
+var newsDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+type newsItem struct {
+	Title     string
+	Link      string
+	Published time.Time
+	Summary   string
+}
+
+type newsFeed struct {
+	Title string
+	Items []newsItem
+}
+
+type newsCacheEntry struct {
+	feed         newsFeed
+	fetchedAt    time.Time
+	etag         string
+	lastModified string
+}
+
+func init() {
+	toolcore.RegisterBuiltin("news", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewNewsTool(options), nil
+	})
+}
+
+// NewsTool reads RSS and Atom feeds, caching each feed for the configured
+// TTL so recurring digests do not refetch unchanged feeds.
+type NewsTool struct {
+	Client   *http.Client
+	feeds    []toolcore.NewsFeed
+	cacheTTL time.Duration
+	maxItems int
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]*newsCacheEntry
+}
+
+func NewNewsTool(options toolcore.BuiltinOptions) *NewsTool {
+	timeout := options.NewsTimeout
+	if timeout <= 0 {
+		timeout = defaultNewsTimeout
+	}
+	t := &NewsTool{
+		Client:   options.HTTPClients.Client("news", timeout),
+		feeds:    options.NewsFeeds,
+		cacheTTL: options.NewsCacheTTL,
+		maxItems: options.NewsMaxItems,
+		now:      time.Now,
+		cache:    make(map[string]*newsCacheEntry),
+	}
+	if t.cacheTTL <= 0 {
+		t.cacheTTL = defaultNewsCacheTTL
+	}
+	if t.maxItems <= 0 {
+		t.maxItems = defaultNewsMaxItems
+	}
+	return t
+}
+
+func (t *NewsTool) Name() string { return "news" }
+
+func (t *NewsTool) Description() string {
+	description := "Fetch the latest items from RSS and Atom feeds with titles, links, dates and short summaries."
+	if len(t.feeds) > 0 {
+		names := make([]string, 0, len(t.feeds))
+		for _, feed := range t.feeds {
+			names = append(names, feed.Name)
+		}
+		description += " Configured feeds: " + strings.Join(names, ", ") + "."
+	}
+	return description
+}
+
+func (t *NewsTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"news.read",
+			"research.web",
+			"http.get",
+		},
+		Risk: toolcore.RiskMedium,
+	}
+}
+
+func (t *NewsTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"feeds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Names of configured feeds to read (default all configured feeds)",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "RSS or Atom feed URL to read instead of configured feeds",
+			},
+			"max_items": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Items per feed, newest first (default %d, max %d)", t.maxItems, maxNewsItemsHardCap),
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "Only items published after this RFC 3339 time, date, or duration ago such as 24h",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Only items whose title or summary contains this text",
+			},
+		},
+	}
+}
+
+func (t *NewsTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Feeds    []string `json:"feeds"`
+		URL      string   `json:"url"`
+		MaxItems int      `json:"max_items"`
+		Since    string   `json:"since"`
+		Query    string   `json:"query"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+
+	targets, err := t.selectFeeds(args.Feeds, strings.TrimSpace(args.URL))
+	if err != nil {
+		return nil, err
+	}
+	maxItems := args.MaxItems
+	if maxItems <= 0 {
+		maxItems = t.maxItems
+	}
+	if maxItems > maxNewsItemsHardCap {
+		maxItems = maxNewsItemsHardCap
+	}
+	var since time.Time
+	if strings.TrimSpace(args.Since) != "" {
+		if since, err = parseNewsSince(args.Since, t.now()); err != nil {
+			return nil, err
+		}
+	}
+	query := strings.ToLower(strings.TrimSpace(args.Query))
+
+	type fetched struct {
+		entry  *newsCacheEntry
+		cached bool
+		err    error
+	}
+	results := make([]fetched, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target toolcore.NewsFeed) {
+			defer wg.Done()
+			entry, cached, err := t.fetch(ctx, target.URL)
+			results[i] = fetched{entry: entry, cached: cached, err: err}
+		}(i, target)
+	}
+	wg.Wait()
+
+	out := make([]map[string]interface{}, 0, len(targets))
+	failures := 0
+	for i, target := range targets {
+		feedOut := map[string]interface{}{
+			"name": target.Name,
+			"url":  target.URL,
+		}
+		result := results[i]
+		if result.err != nil {
+			failures++
+			feedOut["error"] = result.err.Error()
+			out = append(out, feedOut)
+			continue
+		}
+
+		items := make([]map[string]interface{}, 0, maxItems)
+		total := 0
+		for _, item := range result.entry.feed.Items {
+			if !since.IsZero() && (item.Published.IsZero() || !item.Published.After(since)) {
+				continue
+			}
+			if query != "" && !strings.Contains(strings.ToLower(item.Title+"\n"+item.Summary), query) {
+				continue
+			}
+			total++
+			if len(items) == maxItems {
+				continue
+			}
+			itemOut := map[string]interface{}{
+				"title": item.Title,
+				"link":  item.Link,
+			}
+			if !item.Published.IsZero() {
+				itemOut["published"] = item.Published.Format(time.RFC3339)
+			}
+			if item.Summary != "" {
+				itemOut["summary"] = item.Summary
+			}
+			items = append(items, itemOut)
+		}
+		feedOut["title"] = result.entry.feed.Title
+		feedOut["fetched_at"] = result.entry.fetchedAt.Format(time.RFC3339)
+		feedOut["cached"] = result.cached
+		feedOut["items"] = items
+		feedOut["truncated"] = total > len(items)
+		out = append(out, feedOut)
+	}
+	if failures == len(targets) {
+		if failures == 1 {
+			return nil, results[0].err
+		}
+		return nil, fmt.Errorf("all %d feeds failed: %w", failures, results[0].err)
+	}
+	return json.Marshal(map[string]interface{}{
+		"feeds": out,
+	})
+}
+
+// selectFeeds resolves the feeds a call reads: an ad-hoc url, the named
+// feeds, or every configured feed.
+func (t *NewsTool) selectFeeds(names []string, feedURL string) ([]toolcore.NewsFeed, error) {
+	if feedURL != "" {
+		if len(names) > 0 {
+			return nil, fmt.Errorf("use either url or feeds, not both")
+		}
+		parsed, err := url.Parse(feedURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("url must be an absolute http or https URL")
+		}
+		return []toolcore.NewsFeed{{Name: parsed.Host, URL: feedURL}}, nil
+	}
+	if len(names) == 0 {
+		if len(t.feeds) == 0 {
+			return nil, fmt.Errorf("no feeds configured; pass url or add feeds under tools.news.feeds")
+		}
+		if len(t.feeds) > maxNewsFeedsPerCall {
+			return nil, fmt.Errorf("%d feeds are configured; name at most %d per call", len(t.feeds), maxNewsFeedsPerCall)
+		}
+		return t.feeds, nil
+	}
+	if len(names) > maxNewsFeedsPerCall {
+		return nil, fmt.Errorf("news reads at most %d feeds per call", maxNewsFeedsPerCall)
+	}
+	selected := make([]toolcore.NewsFeed, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, feed := range t.feeds {
+			if feed.Name == name {
+				selected = append(selected, feed)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown feed %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// fetch returns the feed at feedURL from the cache while it is fresh, and
+// otherwise revalidates or refetches it.
+func (t *NewsTool) fetch(ctx context.Context, feedURL string) (*newsCacheEntry, bool, error) {
+	t.mu.Lock()
+	cached := t.cache[feedURL]
+	t.mu.Unlock()
+	now := t.now()
+	if cached != nil && now.Sub(cached.fetchedAt) < t.cacheTTL {
+		return cached, true, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Heike/1.0")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultNewsTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("feed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		entry := *cached
+		entry.fetchedAt = now
+		t.store(feedURL, &entry)
+		return &entry, true, nil
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, false, fmt.Errorf("feed request failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxNewsFeedBytes+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if len(data) > maxNewsFeedBytes {
+		return nil, false, fmt.Errorf("feed is larger than %d bytes", maxNewsFeedBytes)
+	}
+	feed, err := parseNewsFeed(data, resp.Request.URL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry := &newsCacheEntry{
+		feed:         feed,
+		fetchedAt:    now,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	t.store(feedURL, entry)
+	return entry, false, nil
+}
+
+func (t *NewsTool) store(feedURL string, entry *newsCacheEntry) {
+	t.mu.Lock()
+	t.cache[feedURL] = entry
+	t.mu.Unlock()
+}
+
+// parseNewsSince accepts an RFC 3339 time, a date, or a duration before now.
+func parseNewsSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("since must be an RFC 3339 time, a YYYY-MM-DD date or a duration such as 24h")
+}
+
+// feedText is element text including that of child elements, which feeds
+// produce when they embed HTML without escaping it.
+type feedText string
+
+func (f *feedText) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			b.Write(tok)
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				*f = feedText(b.String())
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+type rssItem struct {
+	Title       feedText `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description feedText `xml:"description"`
+	Content     feedText `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title     feedText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	ID        string     `xml:"id"`
+	Summary   feedText   `xml:"summary"`
+	Content   feedText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// feedDocument covers RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF>
+// with items beside the channel) and Atom (<feed><entry>).
+type feedDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title feedText  `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Title   feedText    `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// parseNewsFeed decodes an RSS or Atom document into items, newest first.
+// Relative links resolve against base.
+func parseNewsFeed(data []byte, base *url.URL) (newsFeed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = newsCharsetReader
+
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return newsFeed{}, fmt.Errorf("decode feed: %w", err)
+	}
+
+	resolve := func(link string) string {
+		link = strings.TrimSpace(link)
+		if link == "" || base == nil {
+			return link
+		}
+		ref, err := url.Parse(link)
+		if err != nil {
+			return link
+		}
+		return base.ResolveReference(ref).String()
+	}
+
+	var feed newsFeed
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "rss", "rdf":
+		feed.Title = cleanNewsText(string(doc.Channel.Title), 0)
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			link := item.Link
+			if strings.TrimSpace(link) == "" && strings.HasPrefix(strings.TrimSpace(item.GUID), "http") {
+				link = item.GUID
+			}
+			summary := item.Description
+			if strings.TrimSpace(string(summary)) == "" {
+				summary = item.Content
+			}
+			published := parseNewsDate(item.PubDate)
+			if published.IsZero() {
+				published = parseNewsDate(item.Date)
+			}
+			feed.Items = append(feed.Items, newsItem{
+				Title:     cleanNewsText(string(item.Title), 0),
+				Link:      resolve(link),
+				Published: published,
+				Summary:   cleanNewsText(string(summary), newsSummaryRunes),
+			})
+		}
+	case "feed":
+		feed.Title = cleanNewsText(string(doc.Title), 0)
+		for _, entry := range doc.Entries {
+			link := ""
+			for _, candidate := range entry.Links {
+				if candidate.Rel == "" || candidate.Rel == "alternate" {
+					link = candidate.Href
+					break
+				}
+			}
+			if link == "" && len(entry.Links) > 0 {
+				link = entry.Links[0].Href
+			}
+			summary := entry.Summary
+			if strings.TrimSpace(string(summary)) == "" {
+				summary = entry.Content
+			}
+			published := parseNewsDate(entry.Published)
+			if published.IsZero() {
+				published = parseNewsDate(entry.Updated)
+			}
+			feed.Items = append(feed.Items, newsItem{
+				Title:     cleanNewsText(string(entry.Title), 0),
+				Link:      resolve(link),
+				Published: published,
+				Summary:   cleanNewsText(string(summary), newsSummaryRunes),
+			})
+		}
+	default:
+		return newsFeed{}, fmt.Errorf("not an RSS or Atom feed (root element <%s>)", doc.XMLName.Local)
+	}
+
+	// Newest first; undated items keep their feed order after dated ones.
+	sort.SliceStable(feed.Items, func(i, j int) bool {
+		a, b := feed.Items[i].Published, feed.Items[j].Published
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.After(b)
+	})
+	return feed, nil
+}
+
+func parseNewsDate(value string) time.Time {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range newsDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// cleanNewsText strips markup from feed text and collapses whitespace;
+// limit > 0 truncates the result to that many runes.
+func cleanNewsText(value string, limit int) string {
+	value = htmlTagRe.ReplaceAllString(value, " ")
+	value = strings.Join(strings.Fields(html.UnescapeString(value)), " ")
+	if limit > 0 && utf8.RuneCountInString(value) > limit {
+		runes := []rune(value)
+		value = strings.TrimSpace(string(runes[:limit])) + "..."
+	}
+	return value
+}
+
+// newsCharsetReader decodes the single-byte Latin-1 family; other
+// charsets besides UTF-8 are refused.
+func newsCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported feed charset %q", charset)
+}
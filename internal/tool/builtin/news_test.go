@@ -0,0 +1,176 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+  <title>Example News</title>
+  <item>
+    <title>Older release</title>
+    <link>/posts/1</link>
+    <pubDate>Mon, 12 Oct 2026 08:00:00 +0000</pubDate>
+    <description>&lt;p&gt;First &amp;amp; <b>bold</b> post&lt;/p&gt;</description>
+  </item>
+  <item>
+    <title>Newest release</title>
+    <guid>https://news.example.com/posts/2</guid>
+    <pubDate>Wed, 14 Oct 2026 08:00:00 GMT</pubDate>
+    <content:encoded><![CDATA[<p>Release notes for v2</p>]]></content:encoded>
+  </item>
+  <item>
+    <title>Undated note</title>
+    <link>https://news.example.com/posts/3</link>
+  </item>
+</channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="text">Go Blog</title>
+  <entry>
+    <title>Go 1.30 is released</title>
+    <link rel="alternate" href="https://go.dev/blog/go1.30"/>
+    <link rel="enclosure" href="https://go.dev/blog/go1.30.mp3"/>
+    <id>tag:go.dev,2026:go1.30</id>
+    <updated>2026-10-13T17:00:00Z</updated>
+    <summary type="html">&lt;p&gt;Go 1.30 ships today.&lt;/p&gt;</summary>
+  </entry>
+</feed>`
+
+func TestParseNewsFeed(t *testing.T) {
+	base, err := url.Parse("https://news.example.com/feed.xml")
+	require.NoError(t, err)
+
+	feed, err := parseNewsFeed([]byte(testRSSFeed), base)
+	require.NoError(t, err)
+	assert.Equal(t, "Example News", feed.Title)
+	require.Len(t, feed.Items, 3)
+	assert.Equal(t, "Newest release", feed.Items[0].Title)
+	assert.Equal(t, "https://news.example.com/posts/2", feed.Items[0].Link)
+	assert.Equal(t, "Release notes for v2", feed.Items[0].Summary)
+	assert.Equal(t, "https://news.example.com/posts/1", feed.Items[1].Link)
+	assert.Equal(t, "First & bold post", feed.Items[1].Summary)
+	assert.True(t, feed.Items[2].Published.IsZero())
+
+	feed, err = parseNewsFeed([]byte(testAtomFeed), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Go Blog", feed.Title)
+	require.Len(t, feed.Items, 1)
+	assert.Equal(t, "https://go.dev/blog/go1.30", feed.Items[0].Link)
+	assert.Equal(t, "Go 1.30 ships today.", feed.Items[0].Summary)
+	assert.Equal(t, time.Date(2026, 10, 13, 17, 0, 0, 0, time.UTC), feed.Items[0].Published.UTC())
+
+	_, err = parseNewsFeed([]byte(`<html><body>not a feed</body></html>`), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an RSS or Atom feed")
+}
+
+func TestNewsTool_ExecuteWithCache(t *testing.T) {
+	requests := 0
+	revalidations := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			requests++
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidations++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(testRSSFeed))
+		case "/atom":
+			_, _ = w.Write([]byte(testAtomFeed))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	tool := NewNewsTool(toolcore.BuiltinOptions{
+		NewsCacheTTL: time.Minute,
+		NewsMaxItems: 2,
+		NewsFeeds: []toolcore.NewsFeed{
+			{Name: "example", URL: server.URL + "/rss"},
+			{Name: "go", URL: server.URL + "/atom"},
+			{Name: "broken", URL: server.URL + "/missing"},
+		},
+	})
+	tool.now = func() time.Time { return now }
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+	var out struct {
+		Feeds []struct {
+			Name      string                   `json:"name"`
+			Title     string                   `json:"title"`
+			Cached    bool                     `json:"cached"`
+			Truncated bool                     `json:"truncated"`
+			Error     string                   `json:"error"`
+			Items     []map[string]interface{} `json:"items"`
+		} `json:"feeds"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &out))
+	require.Len(t, out.Feeds, 3)
+	assert.Equal(t, "Example News", out.Feeds[0].Title)
+	assert.Len(t, out.Feeds[0].Items, 2)
+	assert.True(t, out.Feeds[0].Truncated)
+	assert.False(t, out.Feeds[0].Cached)
+	assert.Equal(t, "2026-10-14T08:00:00Z", out.Feeds[0].Items[0]["published"])
+	assert.Equal(t, "Go Blog", out.Feeds[1].Title)
+	assert.Contains(t, out.Feeds[2].Error, "404")
+
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"feeds":["example"],"since":"48h","max_items":10}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &out))
+	require.Len(t, out.Feeds, 1)
+	assert.True(t, out.Feeds[0].Cached)
+	require.Len(t, out.Feeds[0].Items, 1)
+	assert.Equal(t, "Newest release", out.Feeds[0].Items[0]["title"])
+	assert.Equal(t, 1, requests)
+
+	now = now.Add(2 * time.Minute)
+	raw, err = tool.Execute(context.Background(), json.RawMessage(`{"feeds":["example"],"query":"OLDER"}`))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.True(t, out.Feeds[0].Cached)
+	require.Len(t, out.Feeds[0].Items, 1)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, revalidations)
+}
+
+func TestNewsTool_ExecuteErrors(t *testing.T) {
+	tool := NewNewsTool(toolcore.BuiltinOptions{
+		NewsFeeds: []toolcore.NewsFeed{{Name: "example", URL: "https://news.example.com/rss"}},
+	})
+
+	cases := map[string]string{
+		`{"feeds":["missing"]}`:                               "unknown feed",
+		`{"url":"ftp://news.example.com/rss"}`:                "absolute http or https URL",
+		`{"url":"https://a.example/rss","feeds":["example"]}`: "either url or feeds",
+		`{"feeds":["example"],"since":"last tuesday"}`:        "since must be",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(context.Background(), json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+
+	_, err := NewNewsTool(toolcore.BuiltinOptions{}).Execute(context.Background(), json.RawMessage(`{}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no feeds configured")
+}
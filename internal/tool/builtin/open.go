@@ -10,13 +10,15 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/harunnryd/heike/internal/httpclient"
 	toolcore "github.com/harunnryd/heike/internal/tool"
 )
 
 func init() {
 	toolcore.RegisterBuiltin("open", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
-		return NewOpenTool(options.WebTimeout, options.WebMaxContentLength), nil
+		return NewOpenTool(options.HTTPClients, options.WebTimeout, options.WebMaxContentLength), nil
 	})
 }
 
@@ -26,7 +28,7 @@ type OpenTool struct {
 	maxContentLength int
 }
 
-func NewOpenTool(timeout time.Duration, maxContentLength int) *OpenTool {
+func NewOpenTool(clients *httpclient.Factory, timeout time.Duration, maxContentLength int) *OpenTool {
 	if timeout <= 0 {
 		timeout = toolcore.DefaultBuiltinWebTimeout
 	}
@@ -36,9 +38,7 @@ func NewOpenTool(timeout time.Duration, maxContentLength int) *OpenTool {
 	}
 
 	return &OpenTool{
-		Client: &http.Client{
-			Timeout: timeout,
-		},
+		Client:           clients.Client("open", timeout),
 		maxContentLength: maxContentLength,
 	}
 }
@@ -109,6 +109,12 @@ type openRequest struct {
 }
 
 func (t *OpenTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	return t.ExecuteStream(ctx, input, nil)
+}
+
+// ExecuteStream opens pages like Execute and, in batch mode, reports each
+// page to emit as soon as it is fetched.
+func (t *OpenTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit toolcore.ProgressFunc) (json.RawMessage, error) {
 	var args openRequest
 	if err := json.Unmarshal(input, &args); err != nil {
 		return nil, fmt.Errorf("invalid input: %w", err)
@@ -116,12 +122,15 @@ func (t *OpenTool) Execute(ctx context.Context, input json.RawMessage) (json.Raw
 
 	if len(args.Open) > 0 {
 		results := make([]map[string]interface{}, 0, len(args.Open))
-		for _, req := range args.Open {
+		for i, req := range args.Open {
 			result, err := t.executeOne(ctx, req)
 			if err != nil {
 				return nil, err
 			}
 			results = append(results, result)
+			if emit != nil {
+				emit(fmt.Sprintf("opened %d/%d: %v (%v, ref_id %v)", i+1, len(args.Open), result["url"], result["status"], result["ref_id"]))
+			}
 		}
 		return json.Marshal(map[string]interface{}{"results": results})
 	}
@@ -148,6 +157,7 @@ func (t *OpenTool) executeOne(ctx context.Context, args openInput) (map[string]i
 				"content": ref.Content,
 				"links":   ref.Links,
 			}
+			addArticleFields(resp, ref.Title, ref.Byline)
 			if args.Lineno > 0 {
 				resp["excerpt"] = lineExcerpt(ref.Content, args.Lineno, 2)
 			}
@@ -194,27 +204,65 @@ func (t *OpenTool) executeOne(ctx context.Context, args openInput) (map[string]i
 		return nil, err
 	}
 
-	content := string(body)
-	if len(content) > t.maxContentLength {
-		content = content[:t.maxContentLength] + "...(truncated)"
+	// HTML pages are cut down to their article before truncation, so
+	// max_content_length counts readable text rather than markup.
+	page := webPageRef{
+		URL:     parsedURL.String(),
+		Status:  resp.Status,
+		Content: string(body),
+		Links:   parseOpenLinks(parsedURL.String(), string(body)),
 	}
-
-	links := parseOpenLinks(parsedURL.String(), string(body))
-	refID := storeWebPage(parsedURL.String(), resp.Status, content, links)
+	if isHTMLResponse(resp.Header.Get("Content-Type"), body) {
+		article := extractArticle(string(body))
+		page.Title = article.Title
+		page.Byline = article.Byline
+		page.Content = article.Text
+	}
+	page.Content = truncateRunes(page.Content, t.maxContentLength)
+	refID := storeWebPageRef(page)
 
 	result := map[string]interface{}{
 		"ref_id":  refID,
-		"url":     parsedURL.String(),
-		"status":  resp.Status,
-		"content": content,
-		"links":   links,
+		"url":     page.URL,
+		"status":  page.Status,
+		"content": page.Content,
+		"links":   page.Links,
 	}
+	addArticleFields(result, page.Title, page.Byline)
 	if args.Lineno > 0 {
-		result["excerpt"] = lineExcerpt(content, args.Lineno, 2)
+		result["excerpt"] = lineExcerpt(page.Content, args.Lineno, 2)
 	}
 	return result, nil
 }
 
+// isHTMLResponse reports whether a response is an HTML page, by its
+// Content-Type or, when that is missing, by sniffing the body.
+func isHTMLResponse(contentType string, body []byte) bool {
+	if strings.TrimSpace(contentType) == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// truncateRunes cuts content to at most limit characters; a limit of
+// zero or less keeps all of it.
+func truncateRunes(content string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(content) <= limit {
+		return content
+	}
+	return string([]rune(content)[:limit]) + "...(truncated)"
+}
+
+func addArticleFields(result map[string]interface{}, title, byline string) {
+	if title != "" {
+		result["title"] = title
+	}
+	if byline != "" {
+		result["byline"] = byline
+	}
+}
+
 func isLikelyURL(raw string) bool {
 	parsed, err := url.Parse(strings.TrimSpace(raw))
 	if err != nil {
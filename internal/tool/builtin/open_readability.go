@@ -0,0 +1,371 @@
+package builtin
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minArticleTextLength is the least text a candidate needs to count as
+// the article rather than a stray paragraph.
+const minArticleTextLength = 140
+
+var (
+	unlikelyArticleRe = regexp.MustCompile(`(?i)(^|[-_\s])(nav|navbar|menu|breadcrumbs?|sidebar|footer|masthead|header|ad|ads|advert|advertisement|promo|sponsor(ed)?|comments?|share|sharing|social|cookie|consent|banner|related|recommended|newsletter|subscribe|popup|modal|skip)([-_\s]|$)`)
+	likelyArticleRe   = regexp.MustCompile(`(?i)(^|[-_\s])(article|content|main|body|post|entry|story|text|prose)([-_\s]|$)`)
+	bylineClassRe     = regexp.MustCompile(`(?i)byline|author`)
+	bylinePrefixRe    = regexp.MustCompile(`(?i)^(by|written by|posted by)\s+`)
+	whitespaceRunRe   = regexp.MustCompile(`\s+`)
+)
+
+// webArticle is the readable part of an HTML page.
+type webArticle struct {
+	Title  string
+	Byline string
+	Text   string
+}
+
+// extractArticle parses an HTML page and returns its title, byline and
+// main text with navigation, ads and other page furniture removed. When no
+// element stands out as the article, Text holds the visible text of the
+// whole body instead.
+func extractArticle(doc string) webArticle {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return webArticle{}
+	}
+
+	article := webArticle{
+		Title:  articleTitle(root),
+		Byline: articleByline(root),
+	}
+	pruneArticleTree(root)
+
+	body := findElement(root, atom.Body)
+	if body == nil {
+		body = root
+	}
+	content := articleCandidate(body)
+	if content == nil {
+		content = body
+	}
+	article.Text = renderArticleText(content)
+	if content != body && utf8.RuneCountInString(article.Text) < minArticleTextLength {
+		article.Text = renderArticleText(body)
+	}
+	return article
+}
+
+func articleTitle(root *html.Node) string {
+	if title := metaContent(root, "og:title"); title != "" {
+		return title
+	}
+	if node := findElement(root, atom.Title); node != nil {
+		if title := collapseText(nodeText(node)); title != "" {
+			return title
+		}
+	}
+	if node := findElement(root, atom.H1); node != nil {
+		return collapseText(nodeText(node))
+	}
+	return ""
+}
+
+func articleByline(root *html.Node) string {
+	if author := metaContent(root, "author"); author != "" {
+		return author
+	}
+	var byline string
+	walkElements(root, func(n *html.Node) bool {
+		if byline != "" {
+			return false
+		}
+		if attr(n, "rel") == "author" || bylineClassRe.MatchString(attr(n, "class")+" "+attr(n, "itemprop")) {
+			text := bylinePrefixRe.ReplaceAllString(collapseText(nodeText(n)), "")
+			// Whole author boxes with a bio are not a byline.
+			if text != "" && utf8.RuneCountInString(text) <= 100 {
+				byline = text
+				return false
+			}
+		}
+		return true
+	})
+	return byline
+}
+
+// metaContent returns the content of the meta tag whose name or property
+// is key.
+func metaContent(root *html.Node, key string) string {
+	var content string
+	walkElements(root, func(n *html.Node) bool {
+		if content != "" {
+			return false
+		}
+		if n.DataAtom == atom.Meta && (strings.EqualFold(attr(n, "name"), key) || strings.EqualFold(attr(n, "property"), key)) {
+			content = collapseText(attr(n, "content"))
+		}
+		return true
+	})
+	return content
+}
+
+// pruneArticleTree removes elements that are never part of an article:
+// scripts, forms, navigation, and blocks whose class or id reads like page
+// furniture.
+func pruneArticleTree(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && isArticleFurniture(child)) {
+			n.RemoveChild(child)
+		} else {
+			pruneArticleTree(child)
+		}
+		child = next
+	}
+}
+
+func isArticleFurniture(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Nav, atom.Aside, atom.Footer,
+		atom.Form, atom.Iframe, atom.Svg, atom.Button, atom.Select, atom.Input, atom.Textarea, atom.Dialog:
+		return true
+	case atom.Header:
+		// Article headers hold the headline; only page headers go.
+		return n.Parent == nil || n.Parent.DataAtom == atom.Body
+	case atom.Html, atom.Body, atom.Article, atom.Main:
+		return false
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "complementary" || role == "contentinfo" {
+		return true
+	}
+	if strings.EqualFold(attr(n, "aria-hidden"), "true") || hasAttr(n, "hidden") {
+		return true
+	}
+	marker := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyArticleRe.MatchString(marker) && !likelyArticleRe.MatchString(marker)
+}
+
+// articleCandidate picks the element holding the article: a single
+// <article> or <main> when the page marks one, otherwise the block whose
+// paragraphs score highest by text length and comma count, discounted by
+// how much of its text is links.
+func articleCandidate(body *html.Node) *html.Node {
+	for _, a := range []atom.Atom{atom.Article, atom.Main} {
+		if nodes := findElements(body, a); len(nodes) == 1 && utf8.RuneCountInString(collapseText(nodeText(nodes[0]))) >= minArticleTextLength {
+			return nodes[0]
+		}
+	}
+	if role := findElementFunc(body, func(n *html.Node) bool { return attr(n, "role") == "main" }); role != nil {
+		return role
+	}
+
+	scores := map[*html.Node]float64{}
+	walkElements(body, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Blockquote, atom.Td:
+		default:
+			return true
+		}
+		text := collapseText(nodeText(n))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + minFloat(float64(length)/100, 3)
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score / 2
+			}
+		}
+		return false
+	})
+
+	var best *html.Node
+	var bestScore float64
+	for node, score := range scores {
+		marker := attr(node, "class") + " " + attr(node, "id")
+		if likelyArticleRe.MatchString(marker) {
+			score += 25
+		}
+		score *= 1 - linkDensity(node)
+		if best == nil || score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(collapseText(nodeText(n)))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	for _, a := range findElements(n, atom.A) {
+		linked += utf8.RuneCountInString(collapseText(nodeText(a)))
+	}
+	return float64(linked) / float64(total)
+}
+
+// renderArticleText flattens n to plain text: one paragraph per block
+// element, list items as "- " lines, and preformatted text kept verbatim.
+func renderArticleText(n *html.Node) string {
+	var b strings.Builder
+	var line strings.Builder
+	flush := func(prefix string) {
+		text := strings.TrimSpace(whitespaceRunRe.ReplaceAllString(line.String(), " "))
+		line.Reset()
+		if text == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(prefix)
+		b.WriteString(text)
+	}
+
+	var walk func(*html.Node, string)
+	walk = func(node *html.Node, prefix string) {
+		switch node.Type {
+		case html.TextNode:
+			line.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			switch node.DataAtom {
+			case atom.Br:
+				line.WriteString(" ")
+				return
+			case atom.Pre:
+				flush(prefix)
+				if text := strings.Trim(nodeText(node), "\n"); strings.TrimSpace(text) != "" {
+					if b.Len() > 0 {
+						b.WriteString("\n\n")
+					}
+					b.WriteString(text)
+				}
+				return
+			case atom.Li:
+				flush(prefix)
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					walk(child, "- ")
+				}
+				flush("- ")
+				return
+			}
+			if isBlockElement(node.DataAtom) {
+				flush(prefix)
+				for child := node.FirstChild; child != nil; child = child.NextSibling {
+					walk(child, prefix)
+				}
+				flush(prefix)
+				return
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, prefix)
+		}
+	}
+	walk(n, "")
+	flush("")
+	return b.String()
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.H1, atom.H2, atom.H3,
+		atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol, atom.Dl, atom.Dt, atom.Dd, atom.Table, atom.Tr,
+		atom.Blockquote, atom.Figure, atom.Figcaption, atom.Hr, atom.Address, atom.Details, atom.Summary:
+		return true
+	}
+	return false
+}
+
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func collapseText(s string) string {
+	return strings.TrimSpace(whitespaceRunRe.ReplaceAllString(s, " "))
+}
+
+// walkElements calls fn on each element under n in document order,
+// descending into an element only when fn returns true.
+func walkElements(n *html.Node, fn func(*html.Node) bool) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && !fn(child) {
+			continue
+		}
+		walkElements(child, fn)
+	}
+}
+
+func findElementFunc(n *html.Node, match func(*html.Node) bool) *html.Node {
+	var found *html.Node
+	walkElements(n, func(node *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if match(node) {
+			found = node
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	return findElementFunc(n, func(node *html.Node) bool { return node.DataAtom == a })
+}
+
+func findElements(n *html.Node, a atom.Atom) []*html.Node {
+	var nodes []*html.Node
+	walkElements(n, func(node *html.Node) bool {
+		if node.DataAtom == a {
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	return nodes
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+package builtin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const readabilityFixture = `<!doctype html>
+<html>
+<head>
+  <title>Tuning the Go GC | Example Blog</title>
+  <meta property="og:title" content="Tuning the Go GC">
+  <script>var tracking = "nope";</script>
+</head>
+<body>
+  <header class="site-header"><a href="/">Home</a> <a href="/about">About</a></header>
+  <nav><ul><li><a href="/a">Section A</a></li><li><a href="/b">Section B</a></li></ul></nav>
+  <div class="ad-slot">Buy one, get one free, today only, limited offer</div>
+  <div id="main-column">
+    <div class="post-body">
+      <h1>Tuning the Go GC</h1>
+      <p class="byline">By Jane Gopher</p>
+      <p>The garbage collector trades memory for CPU, and GOGC sets the ratio between them.</p>
+      <p>Raising GOGC makes collections rarer, which lowers CPU cost, but the heap grows larger in turn.</p>
+      <ul><li>Measure first</li><li>Then set a memory limit</li></ul>
+      <pre>GOGC=200 ./server
+GOMEMLIMIT=1GiB ./server</pre>
+    </div>
+    <div class="share-buttons"><a href="/share">Share this, please, on every network</a></div>
+  </div>
+  <aside class="sidebar"><p>Related posts you will surely love, with many, many words in them.</p></aside>
+  <footer>Copyright, terms, privacy, cookies and more boilerplate text.</footer>
+</body>
+</html>`
+
+func TestExtractArticle(t *testing.T) {
+	article := extractArticle(readabilityFixture)
+
+	assert.Equal(t, "Tuning the Go GC", article.Title)
+	assert.Equal(t, "Jane Gopher", article.Byline)
+	assert.Contains(t, article.Text, "The garbage collector trades memory for CPU, and GOGC sets the ratio between them.")
+	assert.Contains(t, article.Text, "- Measure first\n\n- Then set a memory limit")
+	assert.Contains(t, article.Text, "GOGC=200 ./server\nGOMEMLIMIT=1GiB ./server")
+	for _, furniture := range []string{"tracking", "Section A", "Buy one", "Share this", "Related posts", "Copyright", "About"} {
+		assert.NotContains(t, article.Text, furniture)
+	}
+}
+
+func TestExtractArticle_PrefersArticleElement(t *testing.T) {
+	article := extractArticle(`<html><head><meta name="author" content="Rob"></head><body>
+<div class="menu"><a href="/x">Menu entry with quite a long label, commas, and more</a></div>
+<article><h1>Release notes</h1><p>` + strings.Repeat("Faster builds, smaller binaries. ", 6) + `</p></article>
+</body></html>`)
+
+	assert.Equal(t, "Release notes", article.Title)
+	assert.Equal(t, "Rob", article.Byline)
+	assert.True(t, strings.HasPrefix(article.Text, "Release notes\n\nFaster builds"), article.Text)
+	assert.NotContains(t, article.Text, "Menu entry")
+}
+
+func TestExtractArticle_FallsBackToBodyText(t *testing.T) {
+	article := extractArticle(`<html><body><div>Short status page</div><span>All systems normal</span></body></html>`)
+
+	assert.Empty(t, article.Title)
+	assert.Equal(t, "Short status page\n\nAll systems normal", article.Text)
+}
@@ -33,6 +33,27 @@ func TestOpenTool_Execute_Batch(t *testing.T) {
 	require.Len(t, results, 2)
 }
 
+func TestOpenTool_ExecuteStream_EmitsPerPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "<html><body>batch-open</body></html>")
+	}))
+	defer server.Close()
+
+	tool := &OpenTool{
+		Client:           server.Client(),
+		maxContentLength: 5000,
+	}
+
+	var chunks []string
+	_, err := tool.ExecuteStream(context.Background(), json.RawMessage(`{"open":[{"url":"`+server.URL+`/a"},{"url":"`+server.URL+`/b"}]}`), func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Contains(t, chunks[0], "opened 1/2: "+server.URL+"/a")
+	assert.Contains(t, chunks[1], "opened 2/2: "+server.URL+"/b")
+}
+
 func TestOpenTool_CanResolveSearchRefID(t *testing.T) {
 	pageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, _ = io.WriteString(w, "<html><body>hello from page</body></html>")
@@ -80,3 +101,48 @@ func TestOpenTool_CanResolveSearchRefID(t *testing.T) {
 	content, _ := openResp["content"].(string)
 	assert.Contains(t, content, "hello from page")
 }
+
+func TestOpenTool_Execute_ExtractsArticle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, readabilityFixture)
+	}))
+	defer server.Close()
+
+	tool := &OpenTool{Client: server.Client(), maxContentLength: 60}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`/post"}`))
+	require.NoError(t, err)
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "Tuning the Go GC", resp["title"])
+	assert.Equal(t, "Jane Gopher", resp["byline"])
+	assert.Equal(t, "Tuning the Go GC\n\nBy Jane Gopher\n\nThe garbage collector trad...(truncated)", resp["content"])
+	links, _ := resp["links"].([]interface{})
+	assert.NotEmpty(t, links, "links still come from the whole page")
+
+	refID, _ := resp["ref_id"].(string)
+	reopened, err := tool.Execute(context.Background(), json.RawMessage(`{"ref_id":"`+refID+`"}`))
+	require.NoError(t, err)
+	reopenedResp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(reopened, &reopenedResp))
+	assert.Equal(t, resp["content"], reopenedResp["content"])
+	assert.Equal(t, "Jane Gopher", reopenedResp["byline"])
+}
+
+func TestOpenTool_Execute_KeepsNonHTMLContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"name":"héiké"}`)
+	}))
+	defer server.Close()
+
+	tool := &OpenTool{Client: server.Client(), maxContentLength: 12}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"url":"`+server.URL+`"}`))
+	require.NoError(t, err)
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, `{"name":"héi...(truncated)`, resp["content"])
+	assert.NotContains(t, resp, "title")
+}
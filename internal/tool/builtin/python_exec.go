@@ -0,0 +1,344 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultPythonCommand        = "python3"
+	defaultPythonTimeout        = 60 * time.Second
+	defaultPythonMaxMemoryBytes = 1 << 30
+	defaultPythonMaxOutputBytes = 64 << 10
+	// pythonVenvCreateTimeout bounds creating tools.python.venv.
+	pythonVenvCreateTimeout = 2 * time.Minute
+	// pythonMaxReportedFiles caps the files listed in a result.
+	pythonMaxReportedFiles = 100
+	// pythonMaxScannedFiles bounds the sandbox walk that finds written files.
+	pythonMaxScannedFiles = 20000
+)
+
+// pythonBootstrap caps the address space at argv[2] bytes and runs the
+// snippet at argv[1] as __main__. Isolated mode (-I) leaves the working
+// directory off sys.path, so it and the sandbox root are put first there
+// for snippets to import modules saved in the sandbox.
+const pythonBootstrap = `import os, sys
+try:
+    import resource
+    limit = int(sys.argv[2])
+    if limit > 0:
+        soft, hard = resource.getrlimit(resource.RLIMIT_AS)
+        if hard != resource.RLIM_INFINITY:
+            limit = min(limit, hard)
+        resource.setrlimit(resource.RLIMIT_AS, (limit, limit))
+except (ImportError, ValueError, OSError):
+    pass
+path = sys.argv[1]
+sys.argv = [path]
+sys.path[:0] = [p for p in dict.fromkeys([os.getcwd(), os.environ.get("HEIKE_SANDBOX_PATH", "")]) if p]
+with open(path, encoding="utf-8") as f:
+    code = compile(f.read(), path, "exec")
+exec(code, {"__name__": "__main__", "__file__": path, "__builtins__": __builtins__})
+`
+
+func init() {
+	toolcore.RegisterBuiltin("python_exec", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewPythonExecTool(options), nil
+	})
+}
+
+// PythonExecTool runs Python snippets in a fresh interpreter process with
+// the session sandbox as working directory.
+type PythonExecTool struct {
+	command        string
+	venv           string
+	timeout        time.Duration
+	maxMemoryBytes int64
+	maxOutputBytes int64
+	files          sandboxFilePolicy
+
+	// venvMu serializes creating the virtual environment.
+	venvMu sync.Mutex
+}
+
+func NewPythonExecTool(options toolcore.BuiltinOptions) *PythonExecTool {
+	t := &PythonExecTool{
+		command:        strings.TrimSpace(options.PythonCommand),
+		venv:           strings.TrimSpace(options.PythonVenv),
+		timeout:        options.PythonTimeout,
+		maxMemoryBytes: options.PythonMaxMemoryBytes,
+		maxOutputBytes: options.PythonMaxOutputBytes,
+		files:          newSandboxFilePolicy(options),
+	}
+	if t.command == "" {
+		t.command = defaultPythonCommand
+	}
+	if t.timeout <= 0 {
+		t.timeout = defaultPythonTimeout
+	}
+	if t.maxMemoryBytes <= 0 {
+		t.maxMemoryBytes = defaultPythonMaxMemoryBytes
+	}
+	if t.maxOutputBytes <= 0 {
+		t.maxOutputBytes = defaultPythonMaxOutputBytes
+	}
+	return t
+}
+
+func (t *PythonExecTool) Name() string { return "python_exec" }
+
+func (t *PythonExecTool) Description() string {
+	return fmt.Sprintf("Run a Python snippet in a fresh interpreter with the session sandbox as working directory, within %s and %d MB of memory. Returns stdout, stderr, the exit code and the sandbox files it created or changed. Print results you need; state does not persist between calls.", t.timeout, t.maxMemoryBytes>>20)
+}
+
+func (t *PythonExecTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"python.exec",
+			"code.run",
+			"data.analyze",
+		},
+		Risk: toolcore.RiskHigh,
+	}
+}
+
+func (t *PythonExecTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code": map[string]interface{}{
+				"type":        "string",
+				"description": "Python source to run as __main__",
+			},
+			"workdir": map[string]interface{}{
+				"type":        "string",
+				"description": "Sandbox-relative working directory (optional, defaults to the sandbox root)",
+			},
+			"timeout_seconds": map[string]interface{}{
+				"type":        "integer",
+				"description": "Wall-clock limit, up to the configured timeout (optional)",
+			},
+		},
+		"required": []string{"code"},
+	}
+}
+
+func (t *PythonExecTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Code           string `json:"code"`
+		Workdir        string `json:"workdir"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if strings.TrimSpace(args.Code) == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	root, workdir, err := t.files.resolve(ctx, args.Workdir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(workdir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("workdir %q is not a directory in the session sandbox", args.Workdir)
+	}
+	timeout := t.timeout
+	if requested := time.Duration(args.TimeoutSeconds) * time.Second; requested > 0 && requested < timeout {
+		timeout = requested
+	}
+
+	interpreter, err := t.interpreter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snippet and its temporary files live outside the sandbox and are
+	// removed afterwards.
+	scratch, err := os.MkdirTemp("", "heike-python-*")
+	if err != nil {
+		return nil, fmt.Errorf("create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+	script := filepath.Join(scratch, "main.py")
+	if err := os.WriteFile(script, []byte(args.Code), 0o600); err != nil {
+		return nil, fmt.Errorf("write snippet: %w", err)
+	}
+
+	before := snapshotSandboxFiles(root)
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, interpreter, "-I", "-B", "-u", "-c", pythonBootstrap, script, strconv.FormatInt(t.maxMemoryBytes, 10))
+	cmd.Dir = workdir
+	// Only an allowlisted environment is passed so the snippet cannot read
+	// the daemon's provider keys or other secrets.
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + scratch,
+		"TMPDIR=" + scratch,
+		"MPLBACKEND=Agg",
+		"HEIKE_SANDBOX_PATH=" + root,
+	}
+	isolateProcessGroup(cmd)
+	// Do not wait on pipes held open by processes the snippet left behind.
+	cmd.WaitDelay = time.Second
+	stdout := &limitedBuffer{limit: t.maxOutputBytes}
+	stderr := &limitedBuffer{limit: t.maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	exitCode := 0
+	if runErr != nil {
+		if cmd.ProcessState == nil {
+			return nil, fmt.Errorf("run python: %w", runErr)
+		}
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+
+	files, truncatedFiles := changedSandboxFiles(root, before)
+	result := map[string]interface{}{
+		"exit_code":    exitCode,
+		"stdout":       stdout.buf.String(),
+		"stderr":       stderr.buf.String(),
+		"duration_ms":  duration.Milliseconds(),
+		"files":        files,
+		"sandbox_path": root,
+	}
+	if stdout.overflow {
+		result["stdout_truncated"] = true
+	}
+	if stderr.overflow {
+		result["stderr_truncated"] = true
+	}
+	if truncatedFiles {
+		result["files_truncated"] = true
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		result["timed_out"] = true
+		result["error"] = fmt.Sprintf("timed out after %s", timeout)
+	}
+	return json.Marshal(result)
+}
+
+// interpreter returns the Python binary to run, creating tools.python.venv
+// on first use.
+func (t *PythonExecTool) interpreter(ctx context.Context) (string, error) {
+	base, lookErr := exec.LookPath(t.command)
+	if t.venv == "" {
+		if lookErr != nil {
+			return "", fmt.Errorf("python_exec command %q not found in PATH", t.command)
+		}
+		return base, nil
+	}
+
+	python := venvPython(t.venv)
+	t.venvMu.Lock()
+	defer t.venvMu.Unlock()
+	if _, err := os.Stat(python); err == nil {
+		return python, nil
+	}
+	if lookErr != nil {
+		return "", fmt.Errorf("python_exec command %q not found in PATH to create %s", t.command, t.venv)
+	}
+	ctx, cancel := context.WithTimeout(ctx, pythonVenvCreateTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, base, "-m", "venv", t.venv).CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("create venv %s: %s", t.venv, msg)
+	}
+	return python, nil
+}
+
+func venvPython(dir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(dir, "Scripts", "python.exe")
+	}
+	return filepath.Join(dir, "bin", "python")
+}
+
+type sandboxFileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshotSandboxFiles records the size and modification time of the
+// regular files under root, keyed by slash-separated relative path.
+func snapshotSandboxFiles(root string) map[string]sandboxFileState {
+	files := make(map[string]sandboxFileState)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == "__pycache__" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(files) >= pythonMaxScannedFiles {
+			return fs.SkipAll
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		files[filepath.ToSlash(rel)] = sandboxFileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// changedSandboxFiles lists the files under root that are new or changed
+// since before, sorted by path, and reports whether the list was capped.
+func changedSandboxFiles(root string, before map[string]sandboxFileState) ([]map[string]interface{}, bool) {
+	after := snapshotSandboxFiles(root)
+	paths := make([]string, 0)
+	for path, state := range after {
+		if previous, ok := before[path]; ok && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	truncated := len(paths) > pythonMaxReportedFiles
+	if truncated {
+		paths = paths[:pythonMaxReportedFiles]
+	}
+	files := make([]map[string]interface{}, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, map[string]interface{}{
+			"path": path,
+			"size": after[path].size,
+		})
+	}
+	return files, truncated
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package builtin
+
+import "os/exec"
+
+// isolateProcessGroup is a no-op where process groups are not available;
+// cancel kills only the interpreter.
+func isolateProcessGroup(cmd *exec.Cmd) {}
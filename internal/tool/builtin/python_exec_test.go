@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pythonExecResult struct {
+	ExitCode        int    `json:"exit_code"`
+	Stdout          string `json:"stdout"`
+	Stderr          string `json:"stderr"`
+	StdoutTruncated bool   `json:"stdout_truncated"`
+	TimedOut        bool   `json:"timed_out"`
+	Files           []struct {
+		Path string `json:"path"`
+		Size int64  `json:"size"`
+	} `json:"files"`
+}
+
+func newTestPythonExecTool(t *testing.T, options toolcore.BuiltinOptions) (*PythonExecTool, string, context.Context) {
+	t.Helper()
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not installed")
+	}
+	sandbox := t.TempDir()
+	ctx := toolcore.WithSandboxPath(context.Background(), func() (string, error) { return sandbox, nil })
+	return NewPythonExecTool(options), sandbox, ctx
+}
+
+func runPythonExec(t *testing.T, tool *PythonExecTool, ctx context.Context, input string) pythonExecResult {
+	t.Helper()
+	raw, err := tool.Execute(ctx, json.RawMessage(input))
+	require.NoError(t, err)
+	var out pythonExecResult
+	require.NoError(t, json.Unmarshal(raw, &out))
+	return out
+}
+
+func TestPythonExecTool_RunsInSandbox(t *testing.T) {
+	tool, sandbox, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{})
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox, "helpers.py"), []byte("def total(xs):\n    return sum(xs)\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(sandbox, "data"), 0o755))
+
+	out := runPythonExec(t, tool, ctx, `{"code":"import csv, os, helpers\nprint(helpers.total([1, 2, 3]))\nwith open('report.csv', 'w', newline='') as f:\n    csv.writer(f).writerow(['total', 6])\nprint(os.environ['HEIKE_SANDBOX_PATH'] == os.path.dirname(os.getcwd()))","workdir":"data"}`)
+	assert.Equal(t, 0, out.ExitCode, out.Stderr)
+	assert.Equal(t, "6\nTrue\n", out.Stdout)
+	require.Len(t, out.Files, 1)
+	assert.Equal(t, "data/report.csv", out.Files[0].Path)
+	assert.Equal(t, int64(len("total,6\r\n")), out.Files[0].Size)
+
+	// Unchanged files are not reported again.
+	out = runPythonExec(t, tool, ctx, `{"code":"print(open('data/report.csv').read().strip())"}`)
+	assert.Equal(t, "total,6\n", out.Stdout)
+	assert.Empty(t, out.Files)
+}
+
+func TestPythonExecTool_Failures(t *testing.T) {
+	tool, _, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{
+		PythonTimeout:        time.Second,
+		PythonMaxMemoryBytes: 256 << 20,
+		PythonMaxOutputBytes: 16,
+	})
+
+	out := runPythonExec(t, tool, ctx, `{"code":"print('partial')\n1/0"}`)
+	assert.Equal(t, 1, out.ExitCode)
+	assert.Equal(t, "partial\n", out.Stdout)
+	assert.True(t, len(out.Stderr) <= 16)
+
+	out = runPythonExec(t, tool, ctx, `{"code":"import sys\nsys.exit(3)"}`)
+	assert.Equal(t, 3, out.ExitCode)
+
+	out = runPythonExec(t, tool, ctx, `{"code":"print('x' * 100)"}`)
+	assert.True(t, out.StdoutTruncated)
+	assert.Len(t, out.Stdout, 16)
+
+	out = runPythonExec(t, tool, ctx, `{"code":"import time\ntime.sleep(10)"}`)
+	assert.True(t, out.TimedOut)
+
+	if runtime.GOOS == "linux" {
+		out = runPythonExec(t, tool, ctx, `{"code":"try:\n    b = bytearray(512 * 1024 * 1024)\nexcept MemoryError:\n    print('oom')"}`)
+		assert.Equal(t, "oom\n", out.Stdout)
+	}
+}
+
+func TestPythonExecTool_PassesOnlyAllowlistedEnvironment(t *testing.T) {
+	t.Setenv("HEIKE_TEST_PROVIDER_KEY", "sk-secret")
+	tool, _, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{})
+
+	out := runPythonExec(t, tool, ctx, `{"code":"import os\nprint(os.environ.get('HEIKE_TEST_PROVIDER_KEY'))\nprint(os.environ['HOME'] == os.environ['TMPDIR'])"}`)
+	assert.Equal(t, 0, out.ExitCode, out.Stderr)
+	assert.Equal(t, "None\nTrue\n", out.Stdout)
+}
+
+func TestPythonExecTool_TimeoutKillsChildProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reads process state from /proc")
+	}
+	tool, sandbox, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{PythonTimeout: time.Second})
+
+	out := runPythonExec(t, tool, ctx, `{"code":"import subprocess, sys, time\nchild = subprocess.Popen([sys.executable, '-c', 'import time; time.sleep(30)'])\nopen('child.pid', 'w').write(str(child.pid))\ntime.sleep(10)"}`)
+	require.True(t, out.TimedOut)
+	pid, err := os.ReadFile(filepath.Join(sandbox, "child.pid"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile(filepath.Join("/proc", string(pid), "stat"))
+		// A killed child may linger as a zombie until it is reaped.
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, 5*time.Second, 50*time.Millisecond, "child process %s survived the timeout", pid)
+}
+
+func TestPythonExecTool_Errors(t *testing.T) {
+	tool, _, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{})
+
+	cases := map[string]string{
+		`{"code":"  "}`:                        "code is required",
+		`{"code":"print(1)","workdir":"../"}`:  "outside the session sandbox",
+		`{"code":"print(1)","workdir":"nope"}`: "not a directory",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(ctx, json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"code":"print(1)"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no session sandbox")
+
+	_, err = NewPythonExecTool(toolcore.BuiltinOptions{PythonCommand: "heike-missing-python"}).Execute(ctx, json.RawMessage(`{"code":"print(1)"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found in PATH")
+}
+
+func TestPythonExecTool_CreatesVenv(t *testing.T) {
+	if testing.Short() {
+		t.Skip("creating a virtual environment is slow")
+	}
+	venv := filepath.Join(t.TempDir(), "venv")
+	tool, _, ctx := newTestPythonExecTool(t, toolcore.BuiltinOptions{PythonVenv: venv})
+
+	out := runPythonExec(t, tool, ctx, `{"code":"import sys\nprint(sys.prefix != sys.base_prefix)"}`)
+	assert.Equal(t, "True\n", out.Stdout, out.Stderr)
+	assert.FileExists(t, venvPython(venv))
+}
@@ -19,17 +19,22 @@ import (
 
 const (
 	defaultScreenshotRenderer = "pdftoppm"
+	defaultScreenshotTimeout  = 20 * time.Second
 	maxScreenshotBatchSize    = 4
 )
 
 type screenshotInput struct {
 	RefID  string `json:"ref_id"`
 	PageNo int    `json:"pageno"`
+	// Web page options, used with the chrome renderer.
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FullPage bool   `json:"full_page"`
+	WaitFor  string `json:"wait_for"`
 }
 
 type screenshotRequest struct {
-	RefID      string            `json:"ref_id"`
-	PageNo     int               `json:"pageno"`
+	screenshotInput
 	Screenshot []screenshotInput `json:"screenshot"`
 }
 
@@ -42,27 +47,38 @@ func init() {
 			timeout = options.WebTimeout
 		}
 		if timeout <= 0 {
-			timeout = 20 * time.Second
+			timeout = defaultScreenshotTimeout
 		}
 
 		return &ScreenshotTool{
-			Client:   &http.Client{Timeout: timeout},
-			Renderer: strings.TrimSpace(options.ScreenshotRenderer),
-			render:   renderPDFPageToPNG,
+			Client:     options.HTTPClients.Client("screenshot", timeout),
+			Renderer:   strings.TrimSpace(options.ScreenshotRenderer),
+			ChromePath: strings.TrimSpace(options.ScreenshotChromePath),
+			Timeout:    timeout,
+			render:     renderPDFPageToPNG,
+			capture:    capturePageWithChrome,
 		}, nil
 	})
 }
 
-// ScreenshotTool renders a PDF page to PNG.
+// ScreenshotTool renders a PDF page to PNG. With the chrome renderer it
+// also captures web pages in headless Chrome.
 type ScreenshotTool struct {
-	Client   *http.Client
-	Renderer string
-	render   screenshotRendererFn
+	Client     *http.Client
+	Renderer   string
+	ChromePath string
+	// Timeout bounds a browser capture.
+	Timeout time.Duration
+	render  screenshotRendererFn
+	capture pageCaptureFn
 }
 
 func (t *ScreenshotTool) Name() string { return "screenshot" }
 
 func (t *ScreenshotTool) Description() string {
+	if t.usesChrome() {
+		return "Capture a web page (optionally full-page, at a viewport size, after a CSS selector appears) or render a PDF page from ref_id/url into a PNG screenshot."
+	}
 	return "Render a PDF page from ref_id/url into a PNG screenshot."
 }
 
@@ -90,14 +106,34 @@ func (t *ScreenshotTool) Parameters() map[string]interface{} {
 				"type":        "integer",
 				"description": "0-based PDF page number",
 			},
+			"width": map[string]interface{}{
+				"type":        "integer",
+				"description": "Web page viewport width in pixels (default 1280)",
+			},
+			"height": map[string]interface{}{
+				"type":        "integer",
+				"description": "Web page viewport height in pixels (default 800)",
+			},
+			"full_page": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Capture the whole scrollable web page instead of the viewport",
+			},
+			"wait_for": map[string]interface{}{
+				"type":        "string",
+				"description": "CSS selector to wait for before capturing a web page",
+			},
 			"screenshot": map[string]interface{}{
 				"type":        "array",
 				"description": "Batch mode",
 				"items": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
-						"ref_id": map[string]interface{}{"type": "string"},
-						"pageno": map[string]interface{}{"type": "integer"},
+						"ref_id":    map[string]interface{}{"type": "string"},
+						"pageno":    map[string]interface{}{"type": "integer"},
+						"width":     map[string]interface{}{"type": "integer"},
+						"height":    map[string]interface{}{"type": "integer"},
+						"full_page": map[string]interface{}{"type": "boolean"},
+						"wait_for":  map[string]interface{}{"type": "string"},
 					},
 				},
 			},
@@ -127,10 +163,7 @@ func (t *ScreenshotTool) Execute(ctx context.Context, input json.RawMessage) (js
 		return json.Marshal(map[string]interface{}{"results": results})
 	}
 
-	result, err := t.executeOne(ctx, screenshotInput{
-		RefID:  args.RefID,
-		PageNo: args.PageNo,
-	})
+	result, err := t.executeOne(ctx, args.screenshotInput)
 	if err != nil {
 		return nil, err
 	}
@@ -171,14 +204,22 @@ func (t *ScreenshotTool) executeOne(ctx context.Context, input screenshotInput)
 		return nil, err
 	}
 	if !isLikelyPDF(resp.Header.Get("Content-Type"), pdfBytes, urlValue) {
-		return nil, fmt.Errorf("screenshot currently supports PDF sources only")
+		if !t.usesChrome() {
+			return nil, fmt.Errorf("screenshot currently supports PDF sources only; set tools.screenshot.renderer to chrome for web pages")
+		}
+		return t.captureWebPage(ctx, input, urlValue)
 	}
 
 	renderer := t.render
 	if renderer == nil {
 		renderer = renderPDFPageToPNG
 	}
-	filePath, err := renderer(ctx, t.Renderer, pdfBytes, input.PageNo)
+	// PDFs still go through pdftoppm when web pages use Chrome.
+	pdfRenderer := t.Renderer
+	if t.usesChrome() {
+		pdfRenderer = ""
+	}
+	filePath, err := renderer(ctx, pdfRenderer, pdfBytes, input.PageNo)
 	if err != nil {
 		return nil, err
 	}
@@ -192,6 +233,53 @@ func (t *ScreenshotTool) executeOne(ctx context.Context, input screenshotInput)
 	}, nil
 }
 
+func (t *ScreenshotTool) usesChrome() bool {
+	return strings.EqualFold(strings.TrimSpace(t.Renderer), screenshotRendererChrome)
+}
+
+func (t *ScreenshotTool) captureWebPage(ctx context.Context, input screenshotInput, urlValue string) (map[string]interface{}, error) {
+	opts := pageCaptureOptions{
+		Width:    input.Width,
+		Height:   input.Height,
+		FullPage: input.FullPage,
+		WaitFor:  strings.TrimSpace(input.WaitFor),
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultScreenshotViewportW
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultScreenshotViewportH
+	}
+	if opts.Width > maxScreenshotViewportSize || opts.Height > maxScreenshotViewportSize {
+		return nil, fmt.Errorf("width and height must be at most %d", maxScreenshotViewportSize)
+	}
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultScreenshotTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	capture := t.capture
+	if capture == nil {
+		capture = capturePageWithChrome
+	}
+	filePath, err := capture(ctx, t.ChromePath, urlValue, opts)
+	if err != nil {
+		return nil, fmt.Errorf("capture %s: %w", urlValue, err)
+	}
+
+	return map[string]interface{}{
+		"ref_id":    strings.TrimSpace(input.RefID),
+		"url":       urlValue,
+		"width":     opts.Width,
+		"height":    opts.Height,
+		"full_page": opts.FullPage,
+		"file_path": filePath,
+		"mime_type": "image/png",
+	}, nil
+}
+
 func resolveScreenshotURL(refID string) (string, error) {
 	value := strings.TrimSpace(refID)
 	if value == "" {
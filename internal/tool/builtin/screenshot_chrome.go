@@ -0,0 +1,423 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	screenshotRendererChrome   = "chrome"
+	defaultScreenshotViewportW = 1280
+	defaultScreenshotViewportH = 800
+	maxScreenshotViewportSize  = 4096
+	// maxScreenshotFullPageHeight bounds full-page captures of endless pages.
+	maxScreenshotFullPageHeight = 16384
+	// chromeSelectorPollInterval is how often wait_for is re-checked.
+	chromeSelectorPollInterval = 100 * time.Millisecond
+)
+
+// chromeCandidates are the browser binaries tried when no path is set.
+var chromeCandidates = []string{
+	"google-chrome",
+	"google-chrome-stable",
+	"chromium",
+	"chromium-browser",
+	"chrome",
+	"headless_shell",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// pageCaptureOptions control a browser capture.
+type pageCaptureOptions struct {
+	Width    int
+	Height   int
+	FullPage bool
+	// WaitFor is a CSS selector that must match before capturing.
+	WaitFor string
+}
+
+type pageCaptureFn func(ctx context.Context, chromePath, pageURL string, opts pageCaptureOptions) (string, error)
+
+func findChrome(chromePath string) (string, error) {
+	if path := strings.TrimSpace(chromePath); path != "" {
+		bin, err := exec.LookPath(path)
+		if err != nil {
+			return "", fmt.Errorf("screenshot chrome_path %q not found", path)
+		}
+		return bin, nil
+	}
+	for _, candidate := range chromeCandidates {
+		if bin, err := exec.LookPath(candidate); err == nil {
+			return bin, nil
+		}
+	}
+	return "", fmt.Errorf("no Chrome or Chromium found in PATH; set tools.screenshot.chrome_path")
+}
+
+// capturePageWithChrome starts a headless browser with a throwaway profile,
+// captures pageURL into a PNG file and returns its path.
+func capturePageWithChrome(ctx context.Context, chromePath, pageURL string, opts pageCaptureOptions) (string, error) {
+	bin, err := findChrome(chromePath)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "heike-screenshot-*")
+	if err != nil {
+		return "", err
+	}
+	profile := filepath.Join(dir, "profile")
+	defer os.RemoveAll(profile)
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--hide-scrollbars",
+		"--mute-audio",
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--remote-debugging-port=0",
+		"--user-data-dir=" + profile,
+		fmt.Sprintf("--window-size=%d,%d", opts.Width, opts.Height),
+	}
+	// Chrome refuses to start its sandbox as root, as in most containers.
+	if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "about:blank")
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("start chrome: %w", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	browserURL, err := readDevToolsURL(ctx, stderr)
+	if err != nil {
+		return "", err
+	}
+	pageWS, err := devToolsPageURL(ctx, browserURL)
+	if err != nil {
+		return "", err
+	}
+	png, err := captureWithDevTools(ctx, pageWS, pageURL, opts)
+	if err != nil {
+		return "", err
+	}
+	outPath := filepath.Join(dir, "page.png")
+	if err := os.WriteFile(outPath, png, 0644); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// readDevToolsURL waits for Chrome to print its DevTools endpoint.
+func readDevToolsURL(ctx context.Context, stderr io.Reader) (string, error) {
+	found := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if _, rest, ok := strings.Cut(line, "DevTools listening on "); ok {
+				found <- strings.TrimSpace(rest)
+				// Keep draining so Chrome never blocks on a full pipe.
+				_, _ = io.Copy(io.Discard, stderr)
+				return
+			}
+		}
+		close(found)
+	}()
+	select {
+	case wsURL, ok := <-found:
+		if !ok {
+			return "", fmt.Errorf("chrome exited before opening DevTools")
+		}
+		return wsURL, nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("chrome did not start: %w", ctx.Err())
+	}
+}
+
+// devToolsPageURL returns the DevTools WebSocket of the browser's open tab.
+func devToolsPageURL(ctx context.Context, browserURL string) (string, error) {
+	parsed, err := url.Parse(browserURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DevTools endpoint: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+parsed.Host+"/json/list", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("list chrome targets: %w", err)
+	}
+	defer resp.Body.Close()
+	var targets []struct {
+		Type                 string `json:"type"`
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&targets); err != nil {
+		return "", fmt.Errorf("decode chrome targets: %w", err)
+	}
+	for _, target := range targets {
+		if target.Type == "page" && target.WebSocketDebuggerURL != "" {
+			return target.WebSocketDebuggerURL, nil
+		}
+	}
+	return "", fmt.Errorf("chrome has no open page")
+}
+
+// captureWithDevTools drives one tab over the Chrome DevTools Protocol:
+// size the viewport, load the page, wait for the selector, and capture.
+func captureWithDevTools(ctx context.Context, wsURL, pageURL string, opts pageCaptureOptions) ([]byte, error) {
+	client, err := dialDevTools(ctx, wsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.close()
+
+	if err := client.call(ctx, "Page.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	if err := client.call(ctx, "Emulation.setDeviceMetricsOverride", map[string]interface{}{
+		"width":             opts.Width,
+		"height":            opts.Height,
+		"deviceScaleFactor": 1,
+		"mobile":            false,
+	}, nil); err != nil {
+		return nil, err
+	}
+	var navigated struct {
+		ErrorText string `json:"errorText"`
+	}
+	if err := client.call(ctx, "Page.navigate", map[string]interface{}{"url": pageURL}, &navigated); err != nil {
+		return nil, err
+	}
+	if navigated.ErrorText != "" {
+		return nil, fmt.Errorf("page load failed: %s", navigated.ErrorText)
+	}
+	if err := client.waitEvent(ctx, "Page.loadEventFired"); err != nil {
+		return nil, fmt.Errorf("page load failed: %w", err)
+	}
+	if opts.WaitFor != "" {
+		if err := client.waitForSelector(ctx, opts.WaitFor); err != nil {
+			return nil, err
+		}
+	}
+
+	params := map[string]interface{}{"format": "png"}
+	if opts.FullPage {
+		var metrics struct {
+			CSSContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"cssContentSize"`
+			ContentSize struct {
+				Width  float64 `json:"width"`
+				Height float64 `json:"height"`
+			} `json:"contentSize"`
+		}
+		if err := client.call(ctx, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		width, height := metrics.CSSContentSize.Width, metrics.CSSContentSize.Height
+		if width == 0 || height == 0 {
+			width, height = metrics.ContentSize.Width, metrics.ContentSize.Height
+		}
+		if width < float64(opts.Width) {
+			width = float64(opts.Width)
+		}
+		if height > maxScreenshotFullPageHeight {
+			height = maxScreenshotFullPageHeight
+		}
+		params["captureBeyondViewport"] = true
+		params["clip"] = map[string]interface{}{"x": 0, "y": 0, "width": width, "height": height, "scale": 1}
+	}
+	var shot struct {
+		Data string `json:"data"`
+	}
+	if err := client.call(ctx, "Page.captureScreenshot", params, &shot); err != nil {
+		return nil, err
+	}
+	png, err := base64.StdEncoding.DecodeString(shot.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode screenshot: %w", err)
+	}
+	return png, nil
+}
+
+type devToolsMessage struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// devToolsClient is a minimal single-caller DevTools Protocol connection.
+// Events that arrive while waiting for a reply are kept for waitEvent.
+type devToolsClient struct {
+	conn     *websocket.Conn
+	nextID   int64
+	messages chan devToolsMessage
+	readErr  error
+	events   []string
+}
+
+func dialDevTools(ctx context.Context, wsURL string) (*devToolsClient, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connect to chrome: %w", err)
+	}
+	c := &devToolsClient{conn: conn, messages: make(chan devToolsMessage, 64)}
+	go func() {
+		defer close(c.messages)
+		for {
+			var msg devToolsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				c.readErr = err
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	return c, nil
+}
+
+func (c *devToolsClient) close() {
+	_ = c.conn.Close()
+	for range c.messages {
+	}
+}
+
+// next returns the next message, failing once ctx is done or the
+// connection drops.
+func (c *devToolsClient) next(ctx context.Context) (devToolsMessage, error) {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			return devToolsMessage{}, fmt.Errorf("chrome connection closed: %v", c.readErr)
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return devToolsMessage{}, ctx.Err()
+	}
+}
+
+func (c *devToolsClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.nextID++
+	id := c.nextID
+	request := map[string]interface{}{"id": id, "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	if err := c.conn.WriteJSON(request); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	for {
+		msg, err := c.next(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		if msg.ID == 0 {
+			c.events = append(c.events, msg.Method)
+			continue
+		}
+		if msg.ID != id {
+			continue
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %s", method, msg.Error.Message)
+		}
+		if result != nil && len(msg.Result) > 0 {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("%s: decode result: %w", method, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *devToolsClient) waitEvent(ctx context.Context, method string) error {
+	for i, event := range c.events {
+		if event == method {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return nil
+		}
+	}
+	for {
+		msg, err := c.next(ctx)
+		if err != nil {
+			return err
+		}
+		if msg.ID == 0 && msg.Method == method {
+			return nil
+		}
+	}
+}
+
+// waitForSelector polls until selector matches an element in the page.
+func (c *devToolsClient) waitForSelector(ctx context.Context, selector string) error {
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return err
+	}
+	expression := "document.querySelector(" + string(quoted) + ") !== null"
+	for {
+		var evaluated struct {
+			Result struct {
+				Value bool `json:"value"`
+			} `json:"result"`
+			ExceptionDetails *struct {
+				Exception struct {
+					Description string `json:"description"`
+				} `json:"exception"`
+			} `json:"exceptionDetails"`
+		}
+		if err := c.call(ctx, "Runtime.evaluate", map[string]interface{}{
+			"expression":    expression,
+			"returnByValue": true,
+		}, &evaluated); err != nil {
+			return err
+		}
+		if evaluated.ExceptionDetails != nil {
+			return fmt.Errorf("invalid wait_for selector %q: %s", selector, firstLine(evaluated.ExceptionDetails.Exception.Description))
+		}
+		if evaluated.Result.Value {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %q", selector)
+		case <-time.After(chromeSelectorPollInterval):
+		}
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
@@ -9,7 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,3 +86,135 @@ func TestScreenshotToolExecute_Batch(t *testing.T) {
 	require.True(t, ok)
 	require.Len(t, results, 2)
 }
+
+func TestScreenshotToolExecute_WebPageWithChrome(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html><div id=chart></div></html>")
+	}))
+	defer server.Close()
+
+	var got pageCaptureOptions
+	tool := &ScreenshotTool{
+		Client:   server.Client(),
+		Renderer: "chrome",
+		capture: func(ctx context.Context, chromePath, pageURL string, opts pageCaptureOptions) (string, error) {
+			assert.Equal(t, server.URL+"/dashboard", pageURL)
+			got = opts
+			path := filepath.Join(t.TempDir(), "page.png")
+			return path, os.WriteFile(path, []byte("png"), 0644)
+		},
+		render: func(ctx context.Context, renderer string, pdfBytes []byte, pageNo int) (string, error) {
+			t.Fatal("web pages must not go to the PDF renderer")
+			return "", nil
+		},
+	}
+
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"ref_id":"`+server.URL+`/dashboard","width":800,"full_page":true,"wait_for":"#chart"}`))
+	require.NoError(t, err)
+	assert.Equal(t, pageCaptureOptions{Width: 800, Height: defaultScreenshotViewportH, FullPage: true, WaitFor: "#chart"}, got)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "image/png", resp["mime_type"])
+	assert.Equal(t, true, resp["full_page"])
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"ref_id":"`+server.URL+`/dashboard","width":10000}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at most 4096")
+}
+
+func TestScreenshotToolExecute_ChromeRendersPDFWithPdftoppm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = io.WriteString(w, "%PDF-1.4 sample")
+	}))
+	defer server.Close()
+
+	tool := &ScreenshotTool{
+		Client:   server.Client(),
+		Renderer: "chrome",
+		render: func(ctx context.Context, renderer string, pdfBytes []byte, pageNo int) (string, error) {
+			assert.Empty(t, renderer)
+			path := filepath.Join(t.TempDir(), "page.png")
+			return path, os.WriteFile(path, []byte("png"), 0644)
+		},
+	}
+	_, err := tool.Execute(context.Background(), json.RawMessage(`{"ref_id":"`+server.URL+`/doc.pdf"}`))
+	require.NoError(t, err)
+}
+
+// fakeDevTools answers the DevTools Protocol calls captureWithDevTools
+// makes, reporting the selector present on the second poll.
+func fakeDevTools(t *testing.T, methods *[]string) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		polls := 0
+		for {
+			var msg struct {
+				ID     int64                  `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			*methods = append(*methods, msg.Method)
+			result := map[string]interface{}{}
+			switch msg.Method {
+			case "Page.navigate":
+				assert.Equal(t, "https://example.com/app", msg.Params["url"])
+				result["frameId"] = "frame"
+				require.NoError(t, conn.WriteJSON(map[string]interface{}{"id": msg.ID, "result": result}))
+				require.NoError(t, conn.WriteJSON(map[string]interface{}{"method": "Page.loadEventFired", "params": map[string]interface{}{}}))
+				continue
+			case "Runtime.evaluate":
+				assert.Contains(t, msg.Params["expression"], `document.querySelector("#chart")`)
+				polls++
+				result["result"] = map[string]interface{}{"type": "boolean", "value": polls > 1}
+			case "Page.getLayoutMetrics":
+				result["cssContentSize"] = map[string]interface{}{"width": 1024, "height": 3000}
+			case "Page.captureScreenshot":
+				assert.Equal(t, true, msg.Params["captureBeyondViewport"])
+				assert.Equal(t, map[string]interface{}{"x": 0.0, "y": 0.0, "width": 1280.0, "height": 3000.0, "scale": 1.0}, msg.Params["clip"])
+				result["data"] = "cG5n"
+			}
+			require.NoError(t, conn.WriteJSON(map[string]interface{}{"id": msg.ID, "result": result}))
+		}
+	}))
+}
+
+func TestCaptureWithDevTools(t *testing.T) {
+	var methods []string
+	server := fakeDevTools(t, &methods)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	png, err := captureWithDevTools(ctx, "ws"+server.URL[len("http"):], "https://example.com/app", pageCaptureOptions{
+		Width:    1280,
+		Height:   800,
+		FullPage: true,
+		WaitFor:  "#chart",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(png))
+	assert.Equal(t, []string{
+		"Page.enable",
+		"Emulation.setDeviceMetricsOverride",
+		"Page.navigate",
+		"Runtime.evaluate",
+		"Runtime.evaluate",
+		"Page.getLayoutMetrics",
+		"Page.captureScreenshot",
+	}, methods)
+}
+
+func TestCapturePageWithChrome_MissingBinary(t *testing.T) {
+	_, err := capturePageWithChrome(context.Background(), "heike-missing-chrome", "https://example.com", pageCaptureOptions{Width: 800, Height: 600})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chrome_path")
+}
@@ -0,0 +1,578 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultEmailTimeout            = 30 * time.Second
+	defaultEmailMaxAttachmentBytes = 10 << 20
+	maxEmailRecipients             = 50
+	// emailBase64LineLength is the line length of base64 attachment bodies.
+	emailBase64LineLength = 76
+)
+
+func init() {
+	toolcore.RegisterBuiltin("send_email", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewSendEmailTool(options), nil
+	})
+}
+
+// SendEmailTool sends mail over SMTP, with Go templates for the subject
+// and bodies and attachments read from the session sandbox.
+type SendEmailTool struct {
+	options toolcore.EmailOptions
+	files   sandboxFilePolicy
+	now     func() time.Time
+	// send delivers a composed message; tests replace it.
+	send func(ctx context.Context, from string, to []string, msg []byte) error
+}
+
+func NewSendEmailTool(options toolcore.BuiltinOptions) *SendEmailTool {
+	t := &SendEmailTool{
+		options: options.Email,
+		files:   newSandboxFilePolicy(options),
+		now:     time.Now,
+	}
+	if t.options.Timeout <= 0 {
+		t.options.Timeout = defaultEmailTimeout
+	}
+	if t.options.MaxAttachmentBytes <= 0 {
+		t.options.MaxAttachmentBytes = defaultEmailMaxAttachmentBytes
+	}
+	t.send = t.sendSMTP
+	return t
+}
+
+func (t *SendEmailTool) Name() string { return "send_email" }
+
+func (t *SendEmailTool) Description() string {
+	return "Send an email with a plain text and optional HTML body, rendered as Go templates with data, and attachments from the session sandbox. Requires approval."
+}
+
+func (t *SendEmailTool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"email.send",
+			"notify",
+		},
+		Risk: toolcore.RiskHigh,
+	}
+}
+
+func (t *SendEmailTool) Parameters() map[string]interface{} {
+	stringList := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": description,
+		}
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"to":  stringList("Recipient addresses"),
+			"cc":  stringList("Cc addresses (optional)"),
+			"bcc": stringList("Bcc addresses (optional)"),
+			"subject": map[string]interface{}{
+				"type":        "string",
+				"description": "Subject line; a Go template over data",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "Plain text body; a Go template over data, e.g. \"Hi {{.name}}\"",
+			},
+			"html_body": map[string]interface{}{
+				"type":        "string",
+				"description": "HTML body sent alongside body (optional); an HTML template over data with values escaped",
+			},
+			"data": map[string]interface{}{
+				"type":        "object",
+				"description": "Values for the templates (optional)",
+			},
+			"attachments": stringList("Sandbox-relative paths of files to attach (optional)"),
+			"reply_to": map[string]interface{}{
+				"type":        "string",
+				"description": "Reply-To address (optional)",
+			},
+		},
+		"required": []string{"to", "subject", "body"},
+	}
+}
+
+type sendEmailInput struct {
+	To          []string               `json:"to"`
+	Cc          []string               `json:"cc"`
+	Bcc         []string               `json:"bcc"`
+	Subject     string                 `json:"subject"`
+	Body        string                 `json:"body"`
+	HTMLBody    string                 `json:"html_body"`
+	Data        map[string]interface{} `json:"data"`
+	Attachments []string               `json:"attachments"`
+	ReplyTo     string                 `json:"reply_to"`
+}
+
+type emailAttachment struct {
+	name string
+	data []byte
+}
+
+func (t *SendEmailTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args sendEmailInput
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	if t.options.SMTPHost == "" {
+		return nil, fmt.Errorf("send_email is not configured; set tools.email.smtp or adapters.email.smtp")
+	}
+	from, err := mail.ParseAddress(t.options.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", t.options.From, err)
+	}
+
+	to, err := t.parseRecipients("to", args.To)
+	if err != nil {
+		return nil, err
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("to is required")
+	}
+	cc, err := t.parseRecipients("cc", args.Cc)
+	if err != nil {
+		return nil, err
+	}
+	bcc, err := t.parseRecipients("bcc", args.Bcc)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(to) + len(cc) + len(bcc); n > maxEmailRecipients {
+		return nil, fmt.Errorf("%d recipients exceed the limit of %d", n, maxEmailRecipients)
+	}
+	var replyTo *mail.Address
+	if strings.TrimSpace(args.ReplyTo) != "" {
+		if replyTo, err = mail.ParseAddress(args.ReplyTo); err != nil {
+			return nil, fmt.Errorf("invalid reply_to address: %w", err)
+		}
+	}
+
+	subject, err := renderTextTemplate("subject", args.Subject, args.Data)
+	if err != nil {
+		return nil, err
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+	if subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	body, err := renderTextTemplate("body", args.Body, args.Data)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("body is required")
+	}
+	htmlBody := ""
+	if strings.TrimSpace(args.HTMLBody) != "" {
+		tmpl, err := htmltemplate.New("html_body").Option("missingkey=error").Parse(args.HTMLBody)
+		if err != nil {
+			return nil, fmt.Errorf("parse html_body template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, args.Data); err != nil {
+			return nil, fmt.Errorf("render html_body template: %w", err)
+		}
+		htmlBody = buf.String()
+	}
+
+	attachments, err := t.loadAttachments(ctx, args.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	messageID := fmt.Sprintf("<%s@%s>", newEmailToken(), emailDomain(from.Address))
+	msg, err := composeEmail(emailMessage{
+		from:        from,
+		to:          to,
+		cc:          cc,
+		replyTo:     replyTo,
+		subject:     subject,
+		body:        body,
+		htmlBody:    htmlBody,
+		attachments: attachments,
+		messageID:   messageID,
+		date:        t.now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("compose email: %w", err)
+	}
+
+	envelope := make([]string, 0, len(to)+len(cc)+len(bcc))
+	for _, list := range [][]*mail.Address{to, cc, bcc} {
+		for _, addr := range list {
+			envelope = append(envelope, addr.Address)
+		}
+	}
+	if err := t.send(ctx, from.Address, envelope, msg); err != nil {
+		return nil, fmt.Errorf("send email: %w", err)
+	}
+
+	attached := make([]map[string]interface{}, 0, len(attachments))
+	for _, attachment := range attachments {
+		attached = append(attached, map[string]interface{}{
+			"name": attachment.name,
+			"size": len(attachment.data),
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"sent":        true,
+		"message_id":  messageID,
+		"from":        from.Address,
+		"to":          addressStrings(to),
+		"cc":          addressStrings(cc),
+		"bcc":         addressStrings(bcc),
+		"subject":     subject,
+		"attachments": attached,
+	})
+}
+
+// parseRecipients parses addresses and applies tools.email.allowed_recipients.
+func (t *SendEmailTool) parseRecipients(field string, values []string) ([]*mail.Address, error) {
+	out := make([]*mail.Address, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address %q: %w", field, value, err)
+		}
+		if !recipientAllowed(addr.Address, t.options.AllowedRecipients) {
+			return nil, fmt.Errorf("recipient %s is not in tools.email.allowed_recipients", addr.Address)
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+func recipientAllowed(address string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	address = strings.ToLower(address)
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "@") {
+			if strings.HasSuffix(address, entry) {
+				return true
+			}
+			continue
+		}
+		if address == entry {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *SendEmailTool) loadAttachments(ctx context.Context, paths []string) ([]emailAttachment, error) {
+	attachments := make([]emailAttachment, 0, len(paths))
+	var total int64
+	for _, rel := range paths {
+		if strings.TrimSpace(rel) == "" {
+			continue
+		}
+		_, path, err := t.files.resolve(ctx, rel)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.files.checkExtension(path); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", rel, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("attachment %s is not a regular file", rel)
+		}
+		total += info.Size()
+		if total > t.options.MaxAttachmentBytes {
+			return nil, fmt.Errorf("attachments exceed the %d byte limit", t.options.MaxAttachmentBytes)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", rel, err)
+		}
+		attachments = append(attachments, emailAttachment{name: filepath.Base(path), data: data})
+	}
+	return attachments, nil
+}
+
+func renderTextTemplate(name, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+type emailMessage struct {
+	from        *mail.Address
+	to          []*mail.Address
+	cc          []*mail.Address
+	replyTo     *mail.Address
+	subject     string
+	body        string
+	htmlBody    string
+	attachments []emailAttachment
+	messageID   string
+	date        time.Time
+}
+
+// composeEmail renders m as a MIME message: text/plain, wrapped in
+// multipart/alternative with an HTML body and in multipart/mixed with
+// attachments. Bcc recipients are left out of the headers.
+func composeEmail(m emailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", joinAddresses(m.to))
+	if len(m.cc) > 0 {
+		fmt.Fprintf(&buf, "Cc: %s\r\n", joinAddresses(m.cc))
+	}
+	if m.replyTo != nil {
+		fmt.Fprintf(&buf, "Reply-To: %s\r\n", m.replyTo.String())
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", m.date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", m.messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	bodyHeader, body, err := emailBodyPart(m.body, m.htmlBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.attachments) == 0 {
+		writeMIMEHeader(&buf, bodyHeader)
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+	w, err := mixed.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range m.attachments {
+		contentType := mime.TypeByExtension(filepath.Ext(attachment.name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.name}))
+		w, err := mixed.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.data)
+		for len(encoded) > emailBase64LineLength {
+			if _, err := w.Write([]byte(encoded[:emailBase64LineLength] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[emailBase64LineLength:]
+		}
+		if _, err := w.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// emailBodyPart returns the headers and content of the message body: the
+// text alone, or text and HTML as multipart/alternative.
+func emailBodyPart(text, htmlBody string) (textproto.MIMEHeader, []byte, error) {
+	header := textproto.MIMEHeader{}
+	var buf bytes.Buffer
+	if htmlBody == "" {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		if err := writeQuotedPrintable(&buf, text); err != nil {
+			return nil, nil, err
+		}
+		return header, buf.Bytes(), nil
+	}
+
+	alternative := multipart.NewWriter(&buf)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alternative.Boundary()}))
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", htmlBody},
+	} {
+		partHeader := textproto.MIMEHeader{}
+		partHeader.Set("Content-Type", part.contentType)
+		partHeader.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := alternative.CreatePart(partHeader)
+		if err != nil {
+			return nil, nil, err
+		}
+		var encoded bytes.Buffer
+		if err := writeQuotedPrintable(&encoded, part.content); err != nil {
+			return nil, nil, err
+		}
+		if _, err := w.Write(encoded.Bytes()); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, nil, err
+	}
+	return header, buf.Bytes(), nil
+}
+
+func writeMIMEHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := header.Get(key); value != "" {
+			fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, content string) error {
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func joinAddresses(addresses []*mail.Address) string {
+	out := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		out = append(out, addr.String())
+	}
+	return strings.Join(out, ", ")
+}
+
+func addressStrings(addresses []*mail.Address) []string {
+	out := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		out = append(out, addr.Address)
+	}
+	return out
+}
+
+func emailDomain(address string) string {
+	if at := strings.LastIndexByte(address, '@'); at >= 0 {
+		return address[at+1:]
+	}
+	return "heike.local"
+}
+
+func newEmailToken() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// sendSMTP delivers msg with STARTTLS when the server offers it, or over
+// implicit TLS on port 465, within the configured timeout.
+func (t *SendEmailTool) sendSMTP(ctx context.Context, from string, to []string, msg []byte) error {
+	host := t.options.SMTPHost
+	addr := net.JoinHostPort(host, strconv.Itoa(t.options.SMTPPort))
+	ctx, cancel := context.WithTimeout(ctx, t.options.Timeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if t.options.SMTPPort == 465 {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if t.options.SMTPPort != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return err
+			}
+		}
+	}
+	if t.options.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.options.SMTPUsername, t.options.SMTPPassword, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
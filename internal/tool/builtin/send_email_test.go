@@ -0,0 +1,202 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentEmail struct {
+	from string
+	to   []string
+	msg  []byte
+}
+
+func newTestSendEmailTool(t *testing.T, email toolcore.EmailOptions) (*SendEmailTool, *[]sentEmail, context.Context) {
+	t.Helper()
+	sandbox := t.TempDir()
+	ctx := toolcore.WithSandboxPath(context.Background(), func() (string, error) { return sandbox, nil })
+	require.NoError(t, os.WriteFile(filepath.Join(sandbox, "report.csv"), []byte("region,total\nemea,42\n"), 0o644))
+
+	if email.SMTPHost == "" {
+		email.SMTPHost = "smtp.example.com"
+	}
+	if email.From == "" {
+		email.From = "Heike <agent@example.com>"
+	}
+	tool := NewSendEmailTool(toolcore.BuiltinOptions{Email: email})
+	var sent []sentEmail
+	tool.send = func(_ context.Context, from string, to []string, msg []byte) error {
+		sent = append(sent, sentEmail{from: from, to: to, msg: msg})
+		return nil
+	}
+	return tool, &sent, ctx
+}
+
+func TestSendEmailTool_TemplatesAndAttachments(t *testing.T) {
+	tool, sent, ctx := newTestSendEmailTool(t, toolcore.EmailOptions{})
+
+	raw, err := tool.Execute(ctx, json.RawMessage(`{
+		"to":["Ana <ana@example.com>"],
+		"cc":["bo@example.com"],
+		"bcc":["audit@example.com"],
+		"subject":"Weekly report for {{.team}}",
+		"body":"Hi {{.name}},\nTotal: {{.total}}",
+		"html_body":"<p>Hi {{.name}}</p>",
+		"data":{"team":"EMEA","name":"<Ana>","total":42},
+		"attachments":["report.csv"]
+	}`))
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.Equal(t, true, out["sent"])
+	assert.Equal(t, "Weekly report for EMEA", out["subject"])
+
+	require.Len(t, *sent, 1)
+	delivery := (*sent)[0]
+	assert.Equal(t, "agent@example.com", delivery.from)
+	assert.Equal(t, []string{"ana@example.com", "bo@example.com", "audit@example.com"}, delivery.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(delivery.msg)))
+	require.NoError(t, err)
+	assert.Equal(t, "Weekly report for EMEA", msg.Header.Get("Subject"))
+	assert.Equal(t, `"Ana" <ana@example.com>`, msg.Header.Get("To"))
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	assert.Equal(t, out["message_id"], msg.Header.Get("Message-ID"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	bodyPart, err := mixed.NextPart()
+	require.NoError(t, err)
+	mediaType, params, err = mime.ParseMediaType(bodyPart.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+	alternative := multipart.NewReader(bodyPart, params["boundary"])
+	text, err := alternative.NextPart()
+	require.NoError(t, err)
+	textBody, _ := io.ReadAll(text)
+	assert.Equal(t, "Hi <Ana>,\r\nTotal: 42", string(textBody))
+	htmlPart, err := alternative.NextPart()
+	require.NoError(t, err)
+	htmlBody, _ := io.ReadAll(htmlPart)
+	assert.Equal(t, "<p>Hi &lt;Ana&gt;</p>", string(htmlBody))
+
+	attachment, err := mixed.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "report.csv", attachment.FileName())
+	assert.Equal(t, "base64", attachment.Header.Get("Content-Transfer-Encoding"))
+	attachmentBody, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+	require.NoError(t, err)
+	assert.Equal(t, "region,total\nemea,42\n", string(attachmentBody))
+}
+
+func TestSendEmailTool_Validation(t *testing.T) {
+	tool, sent, ctx := newTestSendEmailTool(t, toolcore.EmailOptions{
+		AllowedRecipients:  []string{"@example.com", "ops@partner.org"},
+		MaxAttachmentBytes: 8,
+	})
+
+	cases := map[string]string{
+		`{"subject":"s","body":"b"}`:                                                          "to is required",
+		`{"to":["not an address"],"subject":"s","body":"b"}`:                                  "invalid to address",
+		`{"to":["eve@evil.example"],"subject":"s","body":"b"}`:                                "not in tools.email.allowed_recipients",
+		`{"to":["ana@example.com"],"subject":"{{.missing}}","body":"b"}`:                      "render subject template",
+		`{"to":["ana@example.com"],"subject":"s","body":"{{if}}"}`:                            "parse body template",
+		`{"to":["ana@example.com"],"subject":"s","body":"b","attachments":["x.txt"]}`:         "no such file",
+		`{"to":["ana@example.com"],"subject":"s","body":"b","attachments":["../etc/passwd"]}`: "outside the session sandbox",
+		`{"to":["ana@example.com"],"subject":"s","body":"b","attachments":["report.csv"]}`:    "exceed the 8 byte limit",
+	}
+	for input, want := range cases {
+		_, err := tool.Execute(ctx, json.RawMessage(input))
+		require.Error(t, err, input)
+		assert.Contains(t, err.Error(), want, input)
+	}
+	assert.Empty(t, *sent)
+
+	_, err := tool.Execute(ctx, json.RawMessage(`{"to":["ops@partner.org"],"subject":"s","body":"b"}`))
+	require.NoError(t, err)
+
+	_, err = NewSendEmailTool(toolcore.BuiltinOptions{}).Execute(ctx, json.RawMessage(`{"to":["ana@example.com"],"subject":"s","body":"b"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not configured")
+}
+
+func TestSendEmailTool_SendSMTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		var commands []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			commands = append(commands, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case line == "DATA":
+				reply("354 go ahead")
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+				}
+				reply("250 queued")
+			case line == "QUIT":
+				reply("221 bye")
+				received <- commands
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	tool := NewSendEmailTool(toolcore.BuiltinOptions{Email: toolcore.EmailOptions{
+		From:     "agent@example.com",
+		SMTPHost: "127.0.0.1",
+		SMTPPort: portNumber,
+	}})
+
+	_, err = tool.Execute(context.Background(), json.RawMessage(`{"to":["ana@example.com"],"subject":"Done","body":"Report attached."}`))
+	require.NoError(t, err)
+	commands := <-received
+	assert.Contains(t, commands, "MAIL FROM:<agent@example.com>")
+	assert.Contains(t, commands, "RCPT TO:<ana@example.com>")
+}
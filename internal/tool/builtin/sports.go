@@ -59,7 +59,7 @@ func init() {
 		}
 
 		return &SportsTool{
-			Client:  &http.Client{Timeout: timeout},
+			Client:  options.HTTPClients.Client("sports", timeout),
 			BaseURL: baseURL,
 		}, nil
 	})
@@ -0,0 +1,606 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultSQLTimeout        = 30 * time.Second
+	defaultSQLMaxRows        = 200
+	defaultSQLMaxOutputBytes = 1 << 20
+)
+
+// sqlReadKeywords are the statements a read-only sql_query accepts.
+var sqlReadKeywords = map[string]struct{}{
+	"select":  {},
+	"with":    {},
+	"values":  {},
+	"table":   {},
+	"explain": {},
+	"show":    {},
+	"pragma":  {},
+}
+
+// sqlCommandRunner runs a database client and returns at most limit bytes
+// of its standard output, reporting whether more was written.
+type sqlCommandRunner func(ctx context.Context, command string, args, env []string, limit int64) (out []byte, overflow bool, err error)
+
+func init() {
+	toolcore.RegisterBuiltin("sql_query", func(options toolcore.BuiltinOptions) (toolcore.Tool, error) {
+		return NewSQLQueryTool(options), nil
+	})
+}
+
+// SQLQueryTool runs queries against the databases in tools.sql through the
+// sqlite3 and psql command-line clients.
+type SQLQueryTool struct {
+	databases       []toolcore.SQLDatabase
+	timeout         time.Duration
+	maxRows         int
+	maxOutputBytes  int64
+	readOnly        bool
+	sqliteCommand   string
+	postgresCommand string
+	run             sqlCommandRunner
+}
+
+func NewSQLQueryTool(options toolcore.BuiltinOptions) *SQLQueryTool {
+	t := &SQLQueryTool{
+		databases:       options.SQLDatabases,
+		timeout:         options.SQLTimeout,
+		maxRows:         options.SQLMaxRows,
+		maxOutputBytes:  options.SQLMaxOutputBytes,
+		readOnly:        options.SQLReadOnly,
+		sqliteCommand:   strings.TrimSpace(options.SQLSQLiteCommand),
+		postgresCommand: strings.TrimSpace(options.SQLPostgresCommand),
+		run:             runSQLCommand,
+	}
+	if t.timeout <= 0 {
+		t.timeout = defaultSQLTimeout
+	}
+	if t.maxRows <= 0 {
+		t.maxRows = defaultSQLMaxRows
+	}
+	if t.maxOutputBytes <= 0 {
+		t.maxOutputBytes = defaultSQLMaxOutputBytes
+	}
+	if t.sqliteCommand == "" {
+		t.sqliteCommand = "sqlite3"
+	}
+	if t.postgresCommand == "" {
+		t.postgresCommand = "psql"
+	}
+	return t
+}
+
+func (t *SQLQueryTool) Name() string { return "sql_query" }
+
+func (t *SQLQueryTool) Description() string {
+	names := make([]string, 0, len(t.databases))
+	for _, database := range t.databases {
+		names = append(names, fmt.Sprintf("%s (%s)", database.Name, database.Driver))
+	}
+	desc := "Run a SQL query against a configured database and return its rows. Large results are truncated and summarized per column."
+	if t.readOnly {
+		desc += " Only a single read statement is allowed."
+	}
+	if len(names) == 0 {
+		return desc + " No databases are configured."
+	}
+	return desc + " Databases: " + strings.Join(names, ", ") + "."
+}
+
+func (t *SQLQueryTool) ToolMetadata() toolcore.ToolMetadata {
+	risk := toolcore.RiskMedium
+	if !t.readOnly {
+		risk = toolcore.RiskHigh
+	}
+	return toolcore.ToolMetadata{
+		Source: "builtin",
+		Capabilities: []string{
+			"sql.query",
+			"data.analyze",
+		},
+		Risk: risk,
+	}
+}
+
+func (t *SQLQueryTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"database": map[string]interface{}{
+				"type":        "string",
+				"description": "Configured database name (optional when only one is configured)",
+			},
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "SQL to run",
+			},
+			"max_rows": map[string]interface{}{
+				"type":        "integer",
+				"description": "Rows to return, up to the configured limit (optional)",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *SQLQueryTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	var args struct {
+		Database string `json:"database"`
+		Query    string `json:"query"`
+		MaxRows  int    `json:"max_rows"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return nil, fmt.Errorf("invalid input: %w", err)
+	}
+	query := strings.TrimSpace(args.Query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	database, err := t.database(args.Database)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkQuery(query); err != nil {
+		return nil, err
+	}
+	maxRows := t.maxRows
+	if args.MaxRows > 0 && args.MaxRows < maxRows {
+		maxRows = args.MaxRows
+	}
+
+	command, cmdArgs, env, err := t.command(database, query)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	out, overflow, err := t.run(ctx, command, cmdArgs, env, t.maxOutputBytes)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("query timed out after %s", t.timeout)
+		}
+		return nil, err
+	}
+
+	result, err := summarizeSQLOutput(out, overflow, maxRows)
+	if err != nil {
+		return nil, err
+	}
+	result["database"] = database.Name
+	result["driver"] = database.Driver
+	return json.Marshal(result)
+}
+
+func (t *SQLQueryTool) database(name string) (toolcore.SQLDatabase, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		if len(t.databases) == 1 {
+			return t.databases[0], nil
+		}
+		if len(t.databases) == 0 {
+			return toolcore.SQLDatabase{}, fmt.Errorf("no databases are configured under tools.sql.databases")
+		}
+		return toolcore.SQLDatabase{}, fmt.Errorf("database is required: choose one of %s", strings.Join(t.databaseNames(), ", "))
+	}
+	for _, database := range t.databases {
+		if database.Name == name {
+			return database, nil
+		}
+	}
+	return toolcore.SQLDatabase{}, fmt.Errorf("unknown database %q: choose one of %s", name, strings.Join(t.databaseNames(), ", "))
+}
+
+func (t *SQLQueryTool) databaseNames() []string {
+	names := make([]string, 0, len(t.databases))
+	for _, database := range t.databases {
+		names = append(names, database.Name)
+	}
+	if len(names) == 0 {
+		return []string{"(none configured)"}
+	}
+	return names
+}
+
+// checkQuery refuses client meta-commands, which could reach the shell,
+// and in read-only mode anything but a single read statement. The clients
+// also open the database read-only, so this is not the only guard.
+func (t *SQLQueryTool) checkQuery(query string) error {
+	if strings.HasPrefix(query, ".") || strings.HasPrefix(query, `\`) {
+		return fmt.Errorf("client meta-commands are not allowed; send SQL only")
+	}
+	if !t.readOnly {
+		return nil
+	}
+	statements := splitSQLStatements(query)
+	if len(statements) != 1 {
+		return fmt.Errorf("read-only mode allows a single statement, got %d", len(statements))
+	}
+	words := strings.FieldsFunc(statements[0], func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return fmt.Errorf("read-only mode allows only read statements")
+	}
+	keyword := strings.ToLower(words[0])
+	if _, ok := sqlReadKeywords[keyword]; !ok {
+		return fmt.Errorf("read-only mode does not allow %s statements", strings.ToUpper(keyword))
+	}
+	return nil
+}
+
+// command builds the client invocation for database. The query is passed
+// as a single argument, which both clients send to the server as SQL. A
+// Postgres password is moved from the DSN to PGPASSWORD so it does not show
+// up in the process list.
+func (t *SQLQueryTool) command(database toolcore.SQLDatabase, query string) (string, []string, []string, error) {
+	if database.Driver == toolcore.SQLDriverPostgres {
+		dsn, password, err := splitPostgresPassword(database.DSN)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("database %q: %w", database.Name, err)
+		}
+		args := []string{"--no-psqlrc", "--quiet", "--csv", "-v", "ON_ERROR_STOP=1", "-d", dsn, "-c", query}
+		var env []string
+		if password != "" {
+			env = append(env, "PGPASSWORD="+password)
+		}
+		if t.readOnly {
+			pgOptions := strings.TrimSpace(os.Getenv("PGOPTIONS") + " -c default_transaction_read_only=on")
+			env = append(env, "PGOPTIONS="+pgOptions)
+		}
+		return t.postgresCommand, args, env, nil
+	}
+
+	args := []string{"-safe", "-batch", "-bail", "-csv", "-header"}
+	if t.readOnly {
+		args = append(args, "-readonly")
+	}
+	return t.sqliteCommand, append(args, database.DSN, query), nil, nil
+}
+
+// splitPostgresPassword removes the password from a Postgres connection URI
+// or keyword/value string and returns it separately.
+func splitPostgresPassword(dsn string) (string, string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid Postgres DSN")
+		}
+		var password string
+		if u.User != nil {
+			password, _ = u.User.Password()
+			u.User = url.User(u.User.Username())
+		}
+		if q := u.Query(); q.Has("password") {
+			password = q.Get("password")
+			q.Del("password")
+			u.RawQuery = q.Encode()
+		}
+		return u.String(), password, nil
+	}
+
+	pairs, err := parsePostgresKeywords(dsn)
+	if err != nil {
+		return "", "", err
+	}
+	var (
+		password string
+		rest     []string
+	)
+	for _, pair := range pairs {
+		if pair[0] == "password" {
+			password = pair[1]
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(pair[1])
+		rest = append(rest, pair[0]+"='"+value+"'")
+	}
+	return strings.Join(rest, " "), password, nil
+}
+
+// parsePostgresKeywords splits a libpq keyword/value string such as
+// "host=db user='app' password='it\'s'" into key/value pairs.
+func parsePostgresKeywords(dsn string) ([][2]string, error) {
+	var pairs [][2]string
+	i := 0
+	skipSpace := func() {
+		for i < len(dsn) && unicode.IsSpace(rune(dsn[i])) {
+			i++
+		}
+	}
+	for {
+		skipSpace()
+		if i >= len(dsn) {
+			return pairs, nil
+		}
+		start := i
+		for i < len(dsn) && dsn[i] != '=' && !unicode.IsSpace(rune(dsn[i])) {
+			i++
+		}
+		key := dsn[start:i]
+		skipSpace()
+		if key == "" || i >= len(dsn) || dsn[i] != '=' {
+			return nil, fmt.Errorf("invalid Postgres DSN: expected key=value")
+		}
+		i++
+		skipSpace()
+
+		var value strings.Builder
+		if i < len(dsn) && dsn[i] == '\'' {
+			i++
+			closed := false
+			for i < len(dsn) {
+				c := dsn[i]
+				i++
+				if c == '\\' && i < len(dsn) {
+					value.WriteByte(dsn[i])
+					i++
+					continue
+				}
+				if c == '\'' {
+					closed = true
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("invalid Postgres DSN: unterminated quoted value")
+			}
+		} else {
+			for i < len(dsn) && !unicode.IsSpace(rune(dsn[i])) {
+				if dsn[i] == '\\' && i+1 < len(dsn) {
+					i++
+				}
+				value.WriteByte(dsn[i])
+				i++
+			}
+		}
+		pairs = append(pairs, [2]string{key, value.String()})
+	}
+}
+
+// splitSQLStatements splits query on semicolons outside quotes and
+// comments, returning the non-empty statements with comments removed.
+func splitSQLStatements(query string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				current.WriteString(query[i:])
+				i = len(query)
+				continue
+			}
+			current.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+				continue
+			}
+			current.WriteByte(' ')
+			i += end
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+				continue
+			}
+			current.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// summarizeSQLOutput parses the clients' CSV output. Rows past maxRows are
+// dropped from the result but still counted in the per-column summary.
+func summarizeSQLOutput(out []byte, overflow bool, maxRows int) (map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(out))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var (
+		columns []string
+		rows    [][]string
+		stats   []*sqlColumnStats
+		count   int
+		partial bool
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if overflow {
+				// The output was cut off mid-row.
+				partial = true
+				break
+			}
+			return nil, fmt.Errorf("parse query output: %w", err)
+		}
+		if columns == nil {
+			columns = record
+			stats = make([]*sqlColumnStats, len(columns))
+			for i, name := range columns {
+				stats[i] = &sqlColumnStats{Name: name, numeric: true}
+			}
+			continue
+		}
+		count++
+		for i, value := range record {
+			if i < len(stats) {
+				stats[i].add(value)
+			}
+		}
+		if len(rows) < maxRows {
+			rows = append(rows, record)
+		}
+	}
+	if overflow && !partial && count > 0 {
+		// The last row parsed may have been cut off.
+		count--
+		if len(rows) > count {
+			rows = rows[:count]
+		}
+	}
+
+	if columns == nil {
+		columns = []string{}
+	}
+	if rows == nil {
+		rows = [][]string{}
+	}
+	truncated := overflow || count > len(rows)
+	result := map[string]interface{}{
+		"columns":   columns,
+		"rows":      rows,
+		"row_count": count,
+		"truncated": truncated,
+	}
+	if overflow {
+		result["output_limited"] = true
+	}
+	if truncated {
+		summary := make([]map[string]interface{}, 0, len(stats))
+		for _, column := range stats {
+			summary = append(summary, column.summary())
+		}
+		result["summary"] = summary
+	}
+	return result, nil
+}
+
+// sqlColumnStats summarizes one result column. Empty values, which is how
+// the clients print NULL, are not counted.
+type sqlColumnStats struct {
+	Name     string
+	nonEmpty int
+	distinct map[string]struct{}
+	numeric  bool
+	min, max float64
+	sum      float64
+}
+
+// sqlSummaryMaxDistinct bounds the distinct values tracked per column.
+const sqlSummaryMaxDistinct = 1000
+
+func (s *sqlColumnStats) add(value string) {
+	if value == "" {
+		return
+	}
+	if s.distinct == nil {
+		s.distinct = make(map[string]struct{})
+	}
+	if len(s.distinct) <= sqlSummaryMaxDistinct {
+		s.distinct[value] = struct{}{}
+	}
+	s.nonEmpty++
+	if !s.numeric {
+		return
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		s.numeric = false
+		return
+	}
+	if s.nonEmpty == 1 || n < s.min {
+		s.min = n
+	}
+	if s.nonEmpty == 1 || n > s.max {
+		s.max = n
+	}
+	s.sum += n
+}
+
+func (s *sqlColumnStats) summary() map[string]interface{} {
+	out := map[string]interface{}{
+		"column":    s.Name,
+		"non_empty": s.nonEmpty,
+	}
+	if len(s.distinct) > sqlSummaryMaxDistinct {
+		out["distinct"] = fmt.Sprintf(">%d", sqlSummaryMaxDistinct)
+	} else {
+		out["distinct"] = len(s.distinct)
+	}
+	if s.numeric && s.nonEmpty > 0 {
+		out["min"] = s.min
+		out["max"] = s.max
+		out["mean"] = s.sum / float64(s.nonEmpty)
+	}
+	return out
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); int64(len(p)) > room {
+		b.buf.Write(p[:room])
+		b.overflow = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func runSQLCommand(ctx context.Context, command string, args, env []string, limit int64) ([]byte, bool, error) {
+	if _, err := exec.LookPath(command); err != nil {
+		return nil, false, fmt.Errorf("sql_query command %q not found in PATH", command)
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	stdout := &limitedBuffer{limit: limit}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, false, fmt.Errorf("query failed: %s", msg)
+	}
+	return stdout.buf.Bytes(), stdout.overflow, nil
+}
@@ -86,7 +86,7 @@ func init() {
 		}
 
 		return &WeatherTool{
-			Client:  &http.Client{Timeout: timeout},
+			Client:  options.HTTPClients.Client("weather", timeout),
 			BaseURL: baseURL,
 		}, nil
 	})
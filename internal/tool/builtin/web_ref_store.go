@@ -15,6 +15,8 @@ type webPageRef struct {
 	RefID   string                   `json:"ref_id"`
 	URL     string                   `json:"url"`
 	Status  string                   `json:"status"`
+	Title   string                   `json:"title,omitempty"`
+	Byline  string                   `json:"byline,omitempty"`
 	Content string                   `json:"content"`
 	Links   []map[string]interface{} `json:"links"`
 }
@@ -46,19 +48,23 @@ var globalWebRefState = &webRefState{
 }
 
 func storeWebPage(urlValue, status, content string, links []map[string]interface{}) string {
-	id := atomic.AddInt64(&globalWebRefState.nextFetch, 1)
-	refID := "turn0fetch" + formatInt64(id)
-
-	globalWebRefState.mu.Lock()
-	globalWebRefState.pages[refID] = &webPageRef{
-		RefID:   refID,
+	return storeWebPageRef(webPageRef{
 		URL:     urlValue,
 		Status:  status,
 		Content: content,
 		Links:   links,
-	}
+	})
+}
+
+// storeWebPageRef stores page under a new fetch ref id and returns it.
+func storeWebPageRef(page webPageRef) string {
+	id := atomic.AddInt64(&globalWebRefState.nextFetch, 1)
+	page.RefID = "turn0fetch" + formatInt64(id)
+
+	globalWebRefState.mu.Lock()
+	globalWebRefState.pages[page.RefID] = &page
 	globalWebRefState.mu.Unlock()
-	return refID
+	return page.RefID
 }
 
 func getWebPage(refID string) (*webPageRef, bool) {
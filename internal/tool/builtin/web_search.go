@@ -5,9 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
-	"io"
 	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 
@@ -44,10 +42,14 @@ type webSearchInput struct {
 	ResponseLength string           `json:"response_length"`
 }
 
+// WebSearchTool searches the web through the configured engine. An empty
+// Engine scrapes Bing's HTML results.
 type WebSearchTool struct {
 	Client     *http.Client
 	BaseURL    string
 	MaxResults int
+	Engine     string
+	APIKey     string
 }
 
 func init() {
@@ -56,15 +58,12 @@ func init() {
 		if timeout <= 0 {
 			timeout = toolcore.DefaultBuiltinWebTimeout
 		}
-		baseURL := strings.TrimSpace(options.WebBaseURL)
-		if baseURL == "" {
-			baseURL = defaultWebSearchBaseURL
-		}
-
 		return &WebSearchTool{
-			Client:     &http.Client{Timeout: timeout},
-			BaseURL:    baseURL,
+			Client:     options.HTTPClients.Client("search_query", timeout),
+			BaseURL:    strings.TrimSpace(options.WebBaseURL),
 			MaxResults: defaultWebSearchMaxResults,
+			Engine:     strings.ToLower(strings.TrimSpace(options.WebEngine)),
+			APIKey:     strings.TrimSpace(options.WebAPIKey),
 		}, nil
 	})
 }
@@ -193,13 +192,9 @@ func (t *WebSearchTool) executeSearchQuery(
 		return nil, fmt.Errorf("query or q is required")
 	}
 
-	baseURL := strings.TrimSpace(t.BaseURL)
-	if baseURL == "" {
-		baseURL = defaultWebSearchBaseURL
-	}
-	parsed, err := url.Parse(baseURL)
+	backend, err := t.backend()
 	if err != nil {
-		return nil, fmt.Errorf("invalid search endpoint: %w", err)
+		return nil, err
 	}
 
 	finalQuery := query
@@ -211,39 +206,17 @@ func (t *WebSearchTool) executeSearchQuery(
 		finalQuery += " site:" + d
 	}
 
-	q := parsed.Query()
-	q.Set("q", finalQuery)
-	if recencyQFT := bingRecencyQFT(recency); recencyQFT != "" {
-		q.Set("qft", recencyQFT)
-	}
-	parsed.RawQuery = q.Encode()
-
-	client := t.Client
-	if client == nil {
-		client = &http.Client{Timeout: toolcore.DefaultBuiltinWebTimeout}
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "Heike/1.0 (+https://example.invalid)")
-
-	resp, err := client.Do(req)
+	results, err := backend.search(ctx, webSearchRequest{
+		Query:      finalQuery,
+		Recency:    recency,
+		MaxResults: maxResults,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		return nil, fmt.Errorf("search request failed: %s", resp.Status)
+	if len(results) > maxResults {
+		results = results[:maxResults]
 	}
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
-	if err != nil {
-		return nil, err
-	}
-
-	results := parseBingSearchResults(string(body), maxResults)
 	results = attachSearchRefs(results)
 
 	return map[string]interface{}{
@@ -251,6 +224,7 @@ func (t *WebSearchTool) executeSearchQuery(
 		"effective_query": finalQuery,
 		"domains":         domains,
 		"recency_days":    recency,
+		"engine":          backend.name(),
 		"results":         results,
 	}, nil
 }
@@ -324,6 +298,9 @@ func attachSearchRefs(results []map[string]string) []map[string]string {
 			"title": title,
 			"url":   link,
 		}
+		if snippet := strings.TrimSpace(result["snippet"]); snippet != "" {
+			entry["snippet"] = snippet
+		}
 		if link != "" {
 			entry["ref_id"] = storeWebSearchRef(link, title)
 		}
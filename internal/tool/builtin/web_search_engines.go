@@ -0,0 +1,309 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+const (
+	defaultBraveSearchBaseURL      = "https://api.search.brave.com/res/v1/web/search"
+	defaultDuckDuckGoSearchBaseURL = "https://html.duckduckgo.com/html/"
+	// maxWebSearchResponseBytes bounds a search results page or payload.
+	maxWebSearchResponseBytes = 2 << 20
+)
+
+var duckDuckGoResultRe = regexp.MustCompile(`(?is)<a[^>]*class="[^"]*\bresult__a\b[^"]*"[^>]*href="([^"]+)"[^>]*>(.*?)</a>`)
+
+// webSearchRequest is one query as sent to an engine, with domain filters
+// already folded into Query as site: operators.
+type webSearchRequest struct {
+	Query      string
+	Recency    int
+	MaxResults int
+}
+
+// webSearchBackend runs queries against one search engine and returns
+// results with title, url and, when the engine gives one, snippet.
+type webSearchBackend interface {
+	name() string
+	search(ctx context.Context, req webSearchRequest) ([]map[string]string, error)
+}
+
+// backend returns the engine named by t.Engine, reached at t.BaseURL or
+// the engine's default endpoint.
+func (t *WebSearchTool) backend() (webSearchBackend, error) {
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: toolcore.DefaultBuiltinWebTimeout}
+	}
+	baseURL := strings.TrimSpace(t.BaseURL)
+	engine := strings.ToLower(strings.TrimSpace(t.Engine))
+	switch engine {
+	case "", toolcore.WebEngineBing:
+		if baseURL == "" {
+			baseURL = defaultWebSearchBaseURL
+		}
+		return &bingSearch{client: client, baseURL: baseURL}, nil
+	case toolcore.WebEngineBrave:
+		if t.APIKey == "" {
+			return nil, fmt.Errorf("the brave search engine needs tools.web.api_key")
+		}
+		if baseURL == "" {
+			baseURL = defaultBraveSearchBaseURL
+		}
+		return &braveSearch{client: client, baseURL: baseURL, apiKey: t.APIKey}, nil
+	case toolcore.WebEngineSearxNG:
+		if baseURL == "" {
+			return nil, fmt.Errorf("the searxng search engine needs tools.web.base_url")
+		}
+		return &searxngSearch{client: client, baseURL: baseURL}, nil
+	case toolcore.WebEngineDuckDuckGo:
+		if baseURL == "" {
+			baseURL = defaultDuckDuckGoSearchBaseURL
+		}
+		return &duckDuckGoSearch{client: client, baseURL: baseURL}, nil
+	default:
+		return nil, fmt.Errorf("unsupported search engine %q", t.Engine)
+	}
+}
+
+// fetchSearchResponse GETs baseURL with query merged into its query
+// string and returns the body.
+func fetchSearchResponse(ctx context.Context, client *http.Client, baseURL string, query url.Values, header http.Header) ([]byte, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search endpoint: %w", err)
+	}
+	q := parsed.Query()
+	for key, values := range query {
+		q[key] = values
+	}
+	parsed.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Heike/1.0 (+https://example.invalid)")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("search request failed: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxWebSearchResponseBytes))
+}
+
+// recencyBucket maps a recency in days to the coarsest of day, week,
+// month and year that still covers it, as the API engines filter.
+func recencyBucket(days int) string {
+	switch {
+	case days <= 0:
+		return ""
+	case days <= 1:
+		return "day"
+	case days <= 7:
+		return "week"
+	case days <= 31:
+		return "month"
+	case days <= 366:
+		return "year"
+	}
+	return ""
+}
+
+// bingSearch scrapes Bing's HTML result page.
+type bingSearch struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (b *bingSearch) name() string { return toolcore.WebEngineBing }
+
+func (b *bingSearch) search(ctx context.Context, req webSearchRequest) ([]map[string]string, error) {
+	query := url.Values{"q": {req.Query}}
+	if recencyQFT := bingRecencyQFT(req.Recency); recencyQFT != "" {
+		query.Set("qft", recencyQFT)
+	}
+	body, err := fetchSearchResponse(ctx, b.client, b.baseURL, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseBingSearchResults(string(body), req.MaxResults), nil
+}
+
+// braveSearch calls the Brave Search web API.
+type braveSearch struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+func (b *braveSearch) name() string { return toolcore.WebEngineBrave }
+
+func (b *braveSearch) search(ctx context.Context, req webSearchRequest) ([]map[string]string, error) {
+	query := url.Values{
+		"q":     {req.Query},
+		"count": {strconv.Itoa(req.MaxResults)},
+	}
+	if bucket := recencyBucket(req.Recency); bucket != "" {
+		// Brave spells the buckets pd, pw, pm and py.
+		query.Set("freshness", "p"+bucket[:1])
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("X-Subscription-Token", b.apiKey)
+	body, err := fetchSearchResponse(ctx, b.client, b.baseURL, query, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode brave search response: %w", err)
+	}
+	results := make([]map[string]string, 0, len(payload.Web.Results))
+	for _, item := range payload.Web.Results {
+		results = appendSearchResult(results, item.Title, item.URL, item.Description)
+	}
+	return results, nil
+}
+
+// searxngSearch calls a SearxNG instance's JSON API, which must have the
+// json format enabled in its settings.
+type searxngSearch struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (s *searxngSearch) name() string { return toolcore.WebEngineSearxNG }
+
+func (s *searxngSearch) search(ctx context.Context, req webSearchRequest) ([]map[string]string, error) {
+	endpoint := strings.TrimRight(s.baseURL, "/")
+	if !strings.HasSuffix(endpoint, "/search") {
+		endpoint += "/search"
+	}
+	query := url.Values{
+		"q":      {req.Query},
+		"format": {"json"},
+	}
+	if bucket := recencyBucket(req.Recency); bucket != "" {
+		query.Set("time_range", bucket)
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	body, err := fetchSearchResponse(ctx, s.client, endpoint, query, header)
+	if err != nil {
+		if strings.Contains(err.Error(), "403") {
+			return nil, fmt.Errorf("%w (enable the json format under search.formats in the SearxNG settings)", err)
+		}
+		return nil, err
+	}
+
+	var payload struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode searxng response: %w", err)
+	}
+	results := make([]map[string]string, 0, len(payload.Results))
+	for _, item := range payload.Results {
+		results = appendSearchResult(results, item.Title, item.URL, item.Content)
+	}
+	return results, nil
+}
+
+// duckDuckGoSearch scrapes DuckDuckGo's HTML-only result page.
+type duckDuckGoSearch struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (d *duckDuckGoSearch) name() string { return toolcore.WebEngineDuckDuckGo }
+
+func (d *duckDuckGoSearch) search(ctx context.Context, req webSearchRequest) ([]map[string]string, error) {
+	query := url.Values{"q": {req.Query}}
+	if bucket := recencyBucket(req.Recency); bucket != "" {
+		query.Set("df", bucket[:1])
+	}
+	body, err := fetchSearchResponse(ctx, d.client, d.baseURL, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseDuckDuckGoResults(string(body), req.MaxResults), nil
+}
+
+func parseDuckDuckGoResults(doc string, maxResults int) []map[string]string {
+	if maxResults <= 0 {
+		maxResults = defaultWebSearchMaxResults
+	}
+	results := make([]map[string]string, 0, maxResults)
+	for _, m := range duckDuckGoResultRe.FindAllStringSubmatch(doc, -1) {
+		if len(results) >= maxResults {
+			break
+		}
+		link := unwrapDuckDuckGoLink(html.UnescapeString(strings.TrimSpace(m[1])))
+		// Ads link through DuckDuckGo's own click tracker.
+		if strings.Contains(link, "duckduckgo.com/y.js") {
+			continue
+		}
+		results = appendSearchResult(results, html.UnescapeString(htmlTagRe.ReplaceAllString(m[2], "")), link, "")
+	}
+	return results
+}
+
+// unwrapDuckDuckGoLink returns the target of a //duckduckgo.com/l/?uddg=
+// redirect link, or link unchanged.
+func unwrapDuckDuckGoLink(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || !strings.HasSuffix(parsed.Host, "duckduckgo.com") || parsed.Path != "/l/" {
+		return link
+	}
+	if target := parsed.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return link
+}
+
+// appendSearchResult adds a result with tags stripped from the title and
+// snippet, skipping entries without a title or link.
+func appendSearchResult(results []map[string]string, title, link, snippet string) []map[string]string {
+	title = strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(title, "")))
+	link = strings.TrimSpace(link)
+	if title == "" || link == "" {
+		return results
+	}
+	entry := map[string]string{"title": title, "url": link}
+	if snippet = strings.TrimSpace(html.UnescapeString(htmlTagRe.ReplaceAllString(snippet, ""))); snippet != "" {
+		entry["snippet"] = snippet
+	}
+	return append(results, entry)
+}
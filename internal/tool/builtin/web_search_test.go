@@ -140,3 +140,101 @@ func TestWebSearchTool_Execute_BatchMode(t *testing.T) {
 	assert.Equal(t, "+filterui:age-lt4320", qftValues[0])
 	assert.Equal(t, "+filterui:age-lt1440", qftValues[1])
 }
+
+func TestWebSearchTool_Execute_BraveEngine(t *testing.T) {
+	var observed *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observed = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"web":{"results":[
+{"title":"Go <strong>1.25</strong> released","url":"https://go.dev/blog/go1.25","description":"The <strong>Go</strong> team is happy to announce"},
+{"title":"","url":"https://example.com/untitled"}
+]}}`)
+	}))
+	defer server.Close()
+
+	tool := &WebSearchTool{Client: server.Client(), BaseURL: server.URL, MaxResults: 5, Engine: "brave", APIKey: "brave-key"}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"q":"go release","recency":3,"max_results":3}`))
+	require.NoError(t, err)
+	require.NotNil(t, observed)
+	assert.Equal(t, "brave-key", observed.Header.Get("X-Subscription-Token"))
+	assert.Equal(t, "application/json", observed.Header.Get("Accept"))
+	assert.Equal(t, "go release", observed.URL.Query().Get("q"))
+	assert.Equal(t, "3", observed.URL.Query().Get("count"))
+	assert.Equal(t, "pw", observed.URL.Query().Get("freshness"))
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "brave", resp["engine"])
+	results := resp["results"].([]interface{})
+	require.Len(t, results, 1)
+	first := results[0].(map[string]interface{})
+	assert.Equal(t, "Go 1.25 released", first["title"])
+	assert.Equal(t, "https://go.dev/blog/go1.25", first["url"])
+	assert.Equal(t, "The Go team is happy to announce", first["snippet"])
+	assert.NotEmpty(t, first["ref_id"])
+}
+
+func TestWebSearchTool_Execute_SearxNGEngine(t *testing.T) {
+	var observedPath, observedFormat, observedRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observedPath = r.URL.Path
+		observedFormat = r.URL.Query().Get("format")
+		observedRange = r.URL.Query().Get("time_range")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"results":[{"url":"https://example.com/a","title":"Alpha","content":"First &amp; best"}]}`)
+	}))
+	defer server.Close()
+
+	tool := &WebSearchTool{Client: server.Client(), BaseURL: server.URL + "/", Engine: "searxng"}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"q":"alpha","recency":30}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/search", observedPath)
+	assert.Equal(t, "json", observedFormat)
+	assert.Equal(t, "month", observedRange)
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	assert.Equal(t, "searxng", resp["engine"])
+	results := resp["results"].([]interface{})
+	require.Len(t, results, 1)
+	assert.Equal(t, "First & best", results[0].(map[string]interface{})["snippet"])
+}
+
+func TestWebSearchTool_Execute_DuckDuckGoEngine(t *testing.T) {
+	var observedDF string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observedDF = r.URL.Query().Get("df")
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `
+<a rel="nofollow" class="result__a" href="https://duckduckgo.com/y.js?ad_provider=x">Sponsored</a>
+<a rel="nofollow" class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fexample.com%2Fa%3Fx%3D1&amp;rut=abc">Alpha <b>Result</b></a>
+<a rel="nofollow" class="result__a" href="https://example.com/b">Beta</a>
+`)
+	}))
+	defer server.Close()
+
+	tool := &WebSearchTool{Client: server.Client(), BaseURL: server.URL, Engine: "duckduckgo"}
+	raw, err := tool.Execute(context.Background(), json.RawMessage(`{"q":"alpha","recency":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, "d", observedDF)
+
+	resp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(raw, &resp))
+	results := resp["results"].([]interface{})
+	require.Len(t, results, 2)
+	first := results[0].(map[string]interface{})
+	assert.Equal(t, "Alpha Result", first["title"])
+	assert.Equal(t, "https://example.com/a?x=1", first["url"])
+}
+
+func TestWebSearchTool_Execute_EngineValidation(t *testing.T) {
+	for _, tool := range []*WebSearchTool{
+		{Engine: "brave"},
+		{Engine: "searxng"},
+		{Engine: "altavista"},
+	} {
+		_, err := tool.Execute(context.Background(), json.RawMessage(`{"q":"x"}`))
+		assert.Error(t, err, tool.Engine)
+	}
+}
@@ -16,18 +16,29 @@ func TestBuiltinNames_DeterministicAndComplete(t *testing.T) {
 
 	assert.Equal(t, []string{
 		"apply_patch",
+		"calendar",
 		"click",
+		"convert",
 		"exec_command",
 		"finance",
 		"find",
+		"github",
+		"http_request",
 		"image_query",
+		"list_dir",
+		"news",
 		"open",
+		"python_exec",
+		"read_file",
 		"screenshot",
 		"search_query",
+		"send_email",
 		"sports",
+		"sql_query",
 		"time",
 		"view_image",
 		"weather",
+		"write_file",
 		"write_stdin",
 	}, names)
 }
@@ -35,7 +46,7 @@ func TestBuiltinNames_DeterministicAndComplete(t *testing.T) {
 func TestInstantiateBuiltins_UsesRegisteredFactories(t *testing.T) {
 	builtins, err := tool.InstantiateBuiltins(tool.BuiltinOptions{})
 	require.NoError(t, err)
-	require.Len(t, builtins, 14)
+	require.Len(t, builtins, 25)
 
 	names := make([]string, 0, len(builtins))
 	for _, builtin := range builtins {
@@ -44,18 +55,29 @@ func TestInstantiateBuiltins_UsesRegisteredFactories(t *testing.T) {
 
 	assert.Equal(t, []string{
 		"apply_patch",
+		"calendar",
 		"click",
+		"convert",
 		"exec_command",
 		"finance",
 		"find",
+		"github",
+		"http_request",
 		"image_query",
+		"list_dir",
+		"news",
 		"open",
+		"python_exec",
+		"read_file",
 		"screenshot",
 		"search_query",
+		"send_email",
 		"sports",
+		"sql_query",
 		"time",
 		"view_image",
 		"weather",
+		"write_file",
 		"write_stdin",
 	}, names)
 }
@@ -84,7 +106,7 @@ func TestRegistryDescriptors_IncludeBuiltinMetadata(t *testing.T) {
 	}
 
 	descriptors := registry.GetDescriptors()
-	require.Len(t, descriptors, 14)
+	require.Len(t, descriptors, 25)
 
 	var openDescriptor *tool.ToolDescriptor
 	for i := range descriptors {
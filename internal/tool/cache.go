@@ -0,0 +1,142 @@
+package tool
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResultCache is an in-memory LRU of tool results keyed by session, tool
+// and normalized input. Only tools with a TTL are cached, and entries
+// expire after their tool's TTL.
+type ResultCache struct {
+	mu         sync.Mutex
+	ttls       map[string]time.Duration
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type resultCacheEntry struct {
+	key       string
+	result    json.RawMessage
+	expiresAt time.Time
+}
+
+// NewResultCache creates a cache holding at most maxEntries results. ttls
+// maps tool names to how long their results are reused; tools without a
+// positive TTL are never cached.
+func NewResultCache(ttls map[string]time.Duration, maxEntries int) *ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	normalized := make(map[string]time.Duration, len(ttls))
+	for name, ttl := range ttls {
+		if ttl > 0 {
+			normalized[NormalizeToolName(name)] = ttl
+		}
+	}
+	return &ResultCache{
+		ttls:       normalized,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Caches reports whether results of toolName are cached.
+func (c *ResultCache) Caches(toolName string) bool {
+	if c == nil {
+		return false
+	}
+	return c.ttls[NormalizeToolName(toolName)] > 0
+}
+
+// ResultCacheKey hashes a call so inputs that differ only in key order or
+// whitespace share an entry. Input that is not valid JSON is hashed as is.
+func ResultCacheKey(sessionID, toolName string, input json.RawMessage) string {
+	normalized := []byte(input)
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err == nil {
+		if encoded, err := json.Marshal(decoded); err == nil {
+			normalized = encoded
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(sessionID))
+	h.Write([]byte{0})
+	h.Write([]byte(NormalizeToolName(toolName)))
+	h.Write([]byte{0})
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns a copy of the cached result for key, if present and fresh.
+func (c *ResultCache) Get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.removeLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return cloneRawMessage(entry.result), true
+}
+
+// Put stores a copy of result under key for toolName's TTL, evicting the
+// least recently used entry when full. Tools without a TTL are ignored.
+func (c *ResultCache) Put(key, toolName string, result json.RawMessage) {
+	ttl := c.ttls[NormalizeToolName(toolName)]
+	if ttl <= 0 || result == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*resultCacheEntry)
+		entry.result = cloneRawMessage(result)
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&resultCacheEntry{key: key, result: cloneRawMessage(result), expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *ResultCache) removeLocked(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*resultCacheEntry).key)
+}
+
+func cloneRawMessage(raw json.RawMessage) json.RawMessage {
+	out := make(json.RawMessage, len(raw))
+	copy(out, raw)
+	return out
+}
@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCacheKey_NormalizesInput(t *testing.T) {
+	key := ResultCacheKey("s1", "weather", json.RawMessage(`{"location":"Oslo","units":"metric"}`))
+
+	assert.Equal(t, key, ResultCacheKey("s1", "weather", json.RawMessage(`{ "units": "metric", "location": "Oslo" }`)))
+	assert.Equal(t, key, ResultCacheKey("s1", " weather ", json.RawMessage(`{"location":"Oslo","units":"metric"}`)))
+	assert.NotEqual(t, key, ResultCacheKey("s2", "weather", json.RawMessage(`{"location":"Oslo","units":"metric"}`)))
+	assert.NotEqual(t, key, ResultCacheKey("s1", "finance", json.RawMessage(`{"location":"Oslo","units":"metric"}`)))
+	assert.NotEqual(t, key, ResultCacheKey("s1", "weather", json.RawMessage(`{"location":"Bergen","units":"metric"}`)))
+	assert.NotEqual(t,
+		ResultCacheKey("s1", "finance", json.RawMessage(`{"amount":1.0}`)),
+		ResultCacheKey("s1", "finance", json.RawMessage(`{"amount":1.00000000000000001}`)),
+	)
+}
+
+func TestResultCache_PerToolTTLAndEviction(t *testing.T) {
+	cache := NewResultCache(map[string]time.Duration{"weather": time.Minute, "finance": 10 * time.Second, "time": 0}, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	assert.True(t, cache.Caches("weather"))
+	assert.False(t, cache.Caches("time"))
+	assert.False(t, cache.Caches("open"))
+
+	cache.Put("w", "weather", json.RawMessage(`{"temp":3}`))
+	cache.Put("f", "finance", json.RawMessage(`{"price":1}`))
+	cache.Put("o", "open", json.RawMessage(`{}`))
+	assert.Equal(t, 2, cache.Len())
+
+	now = now.Add(30 * time.Second)
+	result, ok := cache.Get("w")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"temp":3}`, string(result))
+	_, ok = cache.Get("f")
+	assert.False(t, ok, "finance entries expire after their own TTL")
+
+	cache.Put("w2", "weather", json.RawMessage(`{"temp":4}`))
+	cache.Put("w3", "weather", json.RawMessage(`{"temp":5}`))
+	_, ok = cache.Get("w")
+	assert.False(t, ok, "least recently used entry is evicted")
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestResultCache_ReturnsCopies(t *testing.T) {
+	cache := NewResultCache(map[string]time.Duration{"weather": time.Minute}, 4)
+	stored := json.RawMessage(`{"temp":3}`)
+	cache.Put("w", "weather", stored)
+	stored[1] = 'X'
+
+	result, ok := cache.Get("w")
+	require.True(t, ok)
+	result[1] = 'Y'
+
+	again, ok := cache.Get("w")
+	require.True(t, ok)
+	assert.Equal(t, `{"temp":3}`, string(again))
+}
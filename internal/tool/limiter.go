@@ -0,0 +1,103 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ToolLimit caps one tool's call rate and concurrency. Zero leaves either
+// unlimited.
+type ToolLimit struct {
+	QPS           float64
+	MaxConcurrent int
+}
+
+// Limiter throttles tool executions per tool name. Calls over a limit wait
+// for their turn rather than fail, so a burst of parallel calls is spread
+// out instead of hitting a remote service all at once.
+type Limiter struct {
+	limits map[string]*toolLimiter
+}
+
+type toolLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewLimiter creates a limiter for the tools in limits; tools without a
+// positive QPS or MaxConcurrent run unthrottled.
+func NewLimiter(limits map[string]ToolLimit) *Limiter {
+	l := &Limiter{limits: make(map[string]*toolLimiter, len(limits))}
+	for name, limit := range limits {
+		if limit.QPS <= 0 && limit.MaxConcurrent <= 0 {
+			continue
+		}
+		tl := &toolLimiter{}
+		if limit.MaxConcurrent > 0 {
+			tl.slots = make(chan struct{}, limit.MaxConcurrent)
+		}
+		if limit.QPS > 0 {
+			tl.interval = time.Duration(float64(time.Second) / limit.QPS)
+		}
+		l.limits[NormalizeToolName(name)] = tl
+	}
+	return l
+}
+
+// Acquire waits until toolName may run and returns a release func to call
+// when the execution ends, along with how long it waited. It fails only
+// when ctx ends first.
+func (l *Limiter) Acquire(ctx context.Context, toolName string) (release func(), waited time.Duration, err error) {
+	noop := func() {}
+	if l == nil {
+		return noop, 0, nil
+	}
+	tl, ok := l.limits[NormalizeToolName(toolName)]
+	if !ok {
+		return noop, 0, nil
+	}
+
+	start := time.Now()
+	release = noop
+	if tl.slots != nil {
+		select {
+		case tl.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, time.Since(start), fmt.Errorf("wait for %s concurrency slot: %w", toolName, ctx.Err())
+		}
+		release = func() { <-tl.slots }
+	}
+
+	if delay := tl.reserve(time.Now()); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, time.Since(start), fmt.Errorf("wait for %s rate limit: %w", toolName, ctx.Err())
+		}
+	}
+	return release, time.Since(start), nil
+}
+
+// reserve books the next start time allowed by the rate and returns how
+// long the caller has to wait for it.
+func (tl *toolLimiter) reserve(now time.Time) time.Duration {
+	if tl.interval <= 0 {
+		return 0
+	}
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	at := tl.next
+	if at.Before(now) {
+		at = now
+	}
+	tl.next = at.Add(tl.interval)
+	return at.Sub(now)
+}
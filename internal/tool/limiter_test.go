@@ -0,0 +1,83 @@
+package tool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_CapsConcurrency(t *testing.T) {
+	limiter := NewLimiter(map[string]ToolLimit{"search_query": {MaxConcurrent: 2}})
+
+	var running, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, _, err := limiter.Acquire(context.Background(), "search_query")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer release()
+			now := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak)
+}
+
+func TestLimiter_SpacesCallsByQPS(t *testing.T) {
+	limiter := NewLimiter(map[string]ToolLimit{"search_query": {QPS: 20}})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, _, err := limiter.Acquire(context.Background(), "search_query")
+		require.NoError(t, err)
+		release()
+	}
+	// The first call starts at once and the next two wait 50ms each.
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	release, waited, err := limiter.Acquire(context.Background(), "time")
+	require.NoError(t, err)
+	release()
+	assert.Zero(t, waited, "tools without limits are not throttled")
+}
+
+func TestLimiter_AcquireHonorsContext(t *testing.T) {
+	limiter := NewLimiter(map[string]ToolLimit{"search_query": {MaxConcurrent: 1}})
+	release, _, err := limiter.Acquire(context.Background(), "search_query")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = limiter.Acquire(ctx, "search_query")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	release, _, err = limiter.Acquire(context.Background(), "search_query")
+	require.NoError(t, err)
+	release()
+}
+
+func TestLimiter_Nil(t *testing.T) {
+	var limiter *Limiter
+	release, waited, err := limiter.Acquire(context.Background(), "search_query")
+	require.NoError(t, err)
+	assert.Zero(t, waited)
+	release()
+}
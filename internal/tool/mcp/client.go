@@ -0,0 +1,274 @@
+// Package mcp connects to Model Context Protocol servers and exposes their
+// tools to the tool registry.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProtocolVersion is the MCP revision the client negotiates.
+const ProtocolVersion = "2024-11-05"
+
+// Transports supported by Connect.
+const (
+	TransportStdio = "stdio"
+	TransportSSE   = "sse"
+)
+
+// ServerOptions configures the connection to one MCP server.
+type ServerOptions struct {
+	// Name identifies the server; it prefixes the names of its tools.
+	Name      string
+	Transport string
+	// Command, Args and Env start a stdio server. Env is added to the
+	// daemon's environment.
+	Command string
+	Args    []string
+	Env     map[string]string
+	// URL is the SSE endpoint of an sse server; Headers go with every
+	// request, e.g. for authorization.
+	URL     string
+	Headers map[string]string
+	// Timeout bounds the handshake and each request.
+	Timeout time.Duration
+	// HTTPClient carries sse traffic; it must not set a request timeout,
+	// since the event stream stays open. Nil uses http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// transport moves JSON-RPC messages to and from a server.
+type transport interface {
+	send(ctx context.Context, msg []byte) error
+	// receive blocks until the next message arrives or the connection ends.
+	receive() ([]byte, error)
+	close() error
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  interface{}      `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// Client is a connection to one MCP server. It is safe for concurrent use.
+type Client struct {
+	name      string
+	timeout   time.Duration
+	transport transport
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]chan rpcMessage
+	err     error
+	done    chan struct{}
+}
+
+// Connect starts or dials the server described by opts and completes the
+// MCP initialize handshake.
+func Connect(ctx context.Context, opts ServerOptions) (*Client, error) {
+	if strings.TrimSpace(opts.Name) == "" {
+		return nil, fmt.Errorf("mcp server name is required")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var (
+		t   transport
+		err error
+	)
+	switch opts.Transport {
+	case TransportStdio:
+		t, err = startStdio(opts)
+	case TransportSSE:
+		t, err = dialSSE(ctx, opts)
+	default:
+		err = fmt.Errorf("unsupported transport %q", opts.Transport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", opts.Name, err)
+	}
+
+	c := &Client{
+		name:      opts.Name,
+		timeout:   opts.Timeout,
+		transport: t,
+		pending:   make(map[int64]chan rpcMessage),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("mcp server %s: initialize: %w", opts.Name, err)
+	}
+	return c, nil
+}
+
+// Name returns the configured server name.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Close ends the connection and stops a stdio server.
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "heike", "version": "1.0.0"},
+	}
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	slog.Debug("MCP server initialized", "server", c.name, "implementation", result.ServerInfo.Name, "version", result.ServerInfo.Version, "protocol", result.ProtocolVersion)
+	return c.notify(ctx, "notifications/initialized")
+}
+
+// call sends a request and decodes its result into out.
+func (c *Client) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	id := c.nextID.Add(1)
+	reply := make(chan rpcMessage, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.pending[id] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	rawID := json.RawMessage(fmt.Sprintf("%d", id))
+	msg, err := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: &rawID, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", method, err)
+	}
+	if err := c.transport.send(ctx, msg); err != nil {
+		return fmt.Errorf("send %s: %w", method, err)
+	}
+
+	select {
+	case resp := <-reply:
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %w", method, resp.Error)
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			return fmt.Errorf("decode %s result: %w", method, err)
+		}
+		return nil
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) notify(ctx context.Context, method string) error {
+	msg, err := json.Marshal(rpcMessage{JSONRPC: "2.0", Method: method})
+	if err != nil {
+		return err
+	}
+	return c.transport.send(ctx, msg)
+}
+
+func (c *Client) readLoop() {
+	for {
+		raw, err := c.transport.receive()
+		if err != nil {
+			c.mu.Lock()
+			c.err = fmt.Errorf("mcp server %s: connection closed: %w", c.name, err)
+			c.mu.Unlock()
+			close(c.done)
+			return
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			slog.Warn("Ignoring malformed MCP message", "server", c.name, "error", err)
+			continue
+		}
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.answer(msg)
+		case msg.Method != "":
+			// Notifications such as tools/list_changed are not acted on;
+			// tools are listed once at startup.
+		case msg.ID != nil:
+			var id int64
+			if err := json.Unmarshal(*msg.ID, &id); err != nil {
+				continue
+			}
+			c.mu.Lock()
+			reply, ok := c.pending[id]
+			c.mu.Unlock()
+			if ok {
+				reply <- msg
+			}
+		}
+	}
+}
+
+// answer replies to a server-initiated request. Only ping is supported.
+func (c *Client) answer(req rpcMessage) {
+	resp := rpcMessage{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &rpcError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	if err := c.transport.send(ctx, msg); err != nil {
+		slog.Debug("MCP reply failed", "server", c.name, "method", req.Method, "error", err)
+	}
+}
+
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,244 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+// fakeServer answers the requests the client sends; it returns nil for
+// notifications.
+func fakeServer(raw []byte) []byte {
+	var req struct {
+		ID     *json.RawMessage `json:"id"`
+		Method string           `json:"method"`
+		Params struct {
+			Cursor    string          `json:"cursor"`
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(raw, &req); err != nil || req.ID == nil {
+		return nil
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "initialize":
+		result = map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "fake", "version": "0.1"},
+		}
+	case "tools/list":
+		// Two pages, to exercise the cursor.
+		if req.Params.Cursor == "" {
+			result = map[string]interface{}{
+				"tools": []interface{}{map[string]interface{}{
+					"name":        "echo",
+					"description": "Echo the text back.",
+					"inputSchema": map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+					},
+					"annotations": map[string]interface{}{"readOnlyHint": true},
+				}},
+				"nextCursor": "page-2",
+			}
+		} else {
+			result = map[string]interface{}{
+				"tools": []interface{}{map[string]interface{}{"name": "drop.table", "annotations": map[string]interface{}{"destructiveHint": true}}},
+			}
+		}
+	case "tools/call":
+		var args struct {
+			Text string `json:"text"`
+		}
+		json.Unmarshal(req.Params.Arguments, &args)
+		if req.Params.Name == "echo" {
+			result = map[string]interface{}{
+				"content":           []interface{}{map[string]interface{}{"type": "text", "text": "echo: " + args.Text}},
+				"structuredContent": map[string]interface{}{"text": args.Text},
+			}
+		} else {
+			result = map[string]interface{}{
+				"content": []interface{}{map[string]interface{}{"type": "text", "text": "permission denied"}},
+				"isError": true,
+			}
+		}
+	default:
+		resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "unknown method"}})
+		return resp
+	}
+	resp, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	return resp
+}
+
+// TestHelperStdioServer is not a test: it runs fakeServer over stdio when
+// started by startStdio from the tests below.
+func TestHelperStdioServer(t *testing.T) {
+	if os.Getenv("HEIKE_MCP_FAKE_SERVER") != "1" {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "fake server ready")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if resp := fakeServer(scanner.Bytes()); resp != nil {
+			os.Stdout.Write(append(resp, '\n'))
+		}
+	}
+	os.Exit(0)
+}
+
+func stdioOptions() ServerOptions {
+	return ServerOptions{
+		Name:      "fake",
+		Transport: TransportStdio,
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=^TestHelperStdioServer$"},
+		Env:       map[string]string{"HEIKE_MCP_FAKE_SERVER": "1"},
+		Timeout:   10 * time.Second,
+	}
+}
+
+func sseServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	replies := make(chan []byte, 16)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, ": hello\n\nevent: endpoint\ndata: /messages?session=1\n\n")
+		flusher.Flush()
+		for {
+			select {
+			case reply := <-replies:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Query().Get("session") != "1" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if reply := fakeServer(raw); reply != nil {
+			replies <- reply
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Transports(t *testing.T) {
+	sse := sseServer(t)
+	transports := map[string]ServerOptions{
+		"stdio": stdioOptions(),
+		"sse": {
+			Name:       "fake",
+			Transport:  TransportSSE,
+			URL:        sse.URL + "/sse",
+			Headers:    map[string]string{"Authorization": "Bearer secret"},
+			Timeout:    10 * time.Second,
+			HTTPClient: sse.Client(),
+		},
+	}
+	for name, opts := range transports {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client, err := Connect(ctx, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			tools, err := client.Tools(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tools) != 2 || tools[0].Name() != "fake_echo" || tools[1].Name() != "fake_drop_table" {
+				t.Fatalf("tools = %+v", tools)
+			}
+			echo, drop := tools[0], tools[1]
+			if echo.Description() != "Echo the text back." || echo.Parameters()["type"] != "object" {
+				t.Fatalf("echo = %+v", echo)
+			}
+			if meta := echo.ToolMetadata(); meta.Source != "mcp" || meta.Risk != toolcore.RiskLow {
+				t.Fatalf("echo metadata = %+v", meta)
+			}
+			if meta := drop.ToolMetadata(); meta.Risk != toolcore.RiskHigh {
+				t.Fatalf("drop metadata = %+v", meta)
+			}
+
+			out, err := echo.Execute(ctx, json.RawMessage(`{"text":"hi"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result struct {
+				Content    string            `json:"content"`
+				Structured map[string]string `json:"structured_content"`
+			}
+			if err := json.Unmarshal(out, &result); err != nil || result.Content != "echo: hi" || result.Structured["text"] != "hi" {
+				t.Fatalf("echo output = %s, %v", out, err)
+			}
+
+			if _, err := drop.Execute(ctx, nil); err == nil || !strings.Contains(err.Error(), "permission denied") {
+				t.Fatalf("drop error = %v", err)
+			}
+		})
+	}
+}
+
+func TestConnect_Errors(t *testing.T) {
+	sse := sseServer(t)
+	for name, opts := range map[string]ServerOptions{
+		"no command":     {Name: "fake", Transport: TransportStdio},
+		"missing binary": {Name: "fake", Transport: TransportStdio, Command: "heike-no-such-mcp-server"},
+		"unauthorized":   {Name: "fake", Transport: TransportSSE, URL: sse.URL + "/sse", HTTPClient: sse.Client()},
+		"transport":      {Name: "fake", Transport: "websocket"},
+	} {
+		if client, err := Connect(context.Background(), opts); err == nil {
+			client.Close()
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// A server that never answers fails the handshake at the timeout.
+	opts := ServerOptions{Name: "silent", Transport: TransportStdio, Command: "sleep", Args: []string{"30"}, Timeout: 200 * time.Millisecond}
+	if client, err := Connect(context.Background(), opts); err == nil {
+		client.Close()
+		t.Fatal("expected handshake timeout")
+	}
+}
+
+func TestToolName(t *testing.T) {
+	if got := ToolName("git hub", "create.issue"); got != "git_hub_create_issue" {
+		t.Fatalf("ToolName = %q", got)
+	}
+	if got := ToolName("s", strings.Repeat("x", 100)); len(got) != maxToolNameLength {
+		t.Fatalf("ToolName length = %d", len(got))
+	}
+}
@@ -0,0 +1,316 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// ToolExecutor lists and runs tools under governance; *tool.Runner
+// implements it.
+type ToolExecutor interface {
+	GetDescriptors() []toolcore.ToolDescriptor
+	Execute(ctx context.Context, toolName string, input json.RawMessage, approvalID string) (json.RawMessage, error)
+}
+
+// Prompt is a prompt offered to clients; heike serves its skills as prompts.
+type Prompt struct {
+	Name        string
+	Description string
+	Text        string
+}
+
+// ServeOptions configures a Server.
+type ServeOptions struct {
+	Tools   ToolExecutor
+	Prompts []Prompt
+	// Allow limits the tools offered to these names; empty offers all.
+	Allow []string
+	// Version is reported as the server version during initialize.
+	Version string
+}
+
+// Server exposes tools and prompts to an MCP client over a stream.
+type Server struct {
+	tools   ToolExecutor
+	prompts map[string]Prompt
+	order   []string
+	allow   map[string]struct{}
+	version string
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewServer returns a server for opts.
+func NewServer(opts ServeOptions) *Server {
+	s := &Server{
+		tools:   opts.Tools,
+		prompts: make(map[string]Prompt, len(opts.Prompts)),
+		version: opts.Version,
+		cancels: make(map[string]context.CancelFunc),
+	}
+	if s.version == "" {
+		s.version = "dev"
+	}
+	for _, prompt := range opts.Prompts {
+		if _, exists := s.prompts[prompt.Name]; exists || prompt.Name == "" {
+			continue
+		}
+		s.prompts[prompt.Name] = prompt
+		s.order = append(s.order, prompt.Name)
+	}
+	if len(opts.Allow) > 0 {
+		s.allow = make(map[string]struct{}, len(opts.Allow))
+		for _, name := range opts.Allow {
+			s.allow[toolcore.NormalizeToolName(name)] = struct{}{}
+		}
+	}
+	return s
+}
+
+// Serve reads newline-delimited JSON-RPC requests from in and writes
+// responses to out until in ends or ctx is done. Requests run
+// concurrently, so a slow tool call does not hold up pings or listings.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = out
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReaderSize(in, 64*1024)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case line := <-lines:
+			var msg rpcMessage
+			if err := json.Unmarshal(line, &msg); err != nil {
+				s.reply(nil, nil, &rpcError{Code: codeParseError, Message: "parse error"})
+				continue
+			}
+			if msg.ID == nil {
+				s.handleNotification(msg)
+				continue
+			}
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			key := string(*msg.ID)
+			s.mu.Lock()
+			s.cancels[key] = reqCancel
+			s.mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					s.mu.Lock()
+					delete(s.cancels, key)
+					s.mu.Unlock()
+					reqCancel()
+				}()
+				result, rpcErr := s.handle(reqCtx, msg.Method, line)
+				s.reply(msg.ID, result, rpcErr)
+			}()
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Server) handleNotification(msg rpcMessage) {
+	if msg.Method != "notifications/cancelled" {
+		return
+	}
+	raw, err := json.Marshal(msg.Params)
+	if err != nil {
+		return
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(raw, &params) != nil {
+		return
+	}
+	s.mu.Lock()
+	cancel, ok := s.cancels[string(params.RequestID)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (s *Server) handle(ctx context.Context, method string, raw []byte) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities": map[string]interface{}{
+				"tools":   map[string]interface{}{},
+				"prompts": map[string]interface{}{},
+			},
+			"serverInfo": map[string]interface{}{"name": "heike", "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.listTools()}, nil
+	case "tools/call":
+		var req struct {
+			Params struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.callTool(ctx, req.Params.Name, req.Params.Arguments)
+	case "prompts/list":
+		prompts := make([]map[string]interface{}, 0, len(s.order))
+		for _, name := range s.order {
+			prompts = append(prompts, map[string]interface{}{"name": name, "description": s.prompts[name].Description})
+		}
+		return map[string]interface{}{"prompts": prompts}, nil
+	case "prompts/get":
+		var req struct {
+			Params struct {
+				Name string `json:"name"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		prompt, ok := s.prompts[req.Params.Name]
+		if !ok {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown prompt: " + req.Params.Name}
+		}
+		return map[string]interface{}{
+			"description": prompt.Description,
+			"messages": []interface{}{map[string]interface{}{
+				"role":    "user",
+				"content": map[string]interface{}{"type": "text", "text": prompt.Text},
+			}},
+		}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+func (s *Server) allowed(name string) bool {
+	if s.allow == nil {
+		return true
+	}
+	_, ok := s.allow[toolcore.NormalizeToolName(name)]
+	return ok
+}
+
+func (s *Server) listTools() []map[string]interface{} {
+	descriptors := s.tools.GetDescriptors()
+	tools := make([]map[string]interface{}, 0, len(descriptors))
+	for _, descriptor := range descriptors {
+		def := descriptor.Definition
+		if !s.allowed(def.Name) {
+			continue
+		}
+		schema := def.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		tools = append(tools, map[string]interface{}{
+			"name":        def.Name,
+			"description": def.Description,
+			"inputSchema": schema,
+		})
+	}
+	return tools
+}
+
+// callTool runs a tool through the executor, so workspace governance
+// applies. Calls that need approval are refused: there is no one to ask.
+func (s *Server) callTool(ctx context.Context, name string, args json.RawMessage) (interface{}, *rpcError) {
+	if !s.allowed(name) {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + name}
+	}
+	if len(bytes.TrimSpace(args)) == 0 || string(args) == "null" {
+		args = json.RawMessage(`{}`)
+	}
+
+	out, err := s.tools.Execute(ctx, name, args, "")
+	if err != nil {
+		if errors.Is(err, heikeErrors.ErrNotFound) {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + name}
+		}
+		text := err.Error()
+		if errors.Is(err, heikeErrors.ErrApprovalRequired) {
+			text = fmt.Sprintf("%s requires approval under the workspace governance policy, which cannot be granted over MCP; allow it with `heike policy set %s --allow` to use it here", name, name)
+		}
+		slog.Warn("MCP tool call failed", "tool", name, "error", err)
+		return toolResult(text, true), nil
+	}
+	return toolResult(string(out), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rpcErr *rpcError) {
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if rpcErr != nil {
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Marshal MCP response failed", "error", err)
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.out.Write(append(msg, '\n'))
+}
@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/model/contract"
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+type fakeExecutor struct{}
+
+func (fakeExecutor) GetDescriptors() []toolcore.ToolDescriptor {
+	return []toolcore.ToolDescriptor{
+		{Definition: contract.ToolDef{Name: "exec_command", Description: "Run a command.", Parameters: map[string]interface{}{"type": "object"}}},
+		{Definition: contract.ToolDef{Name: "time", Description: "Get the current time."}},
+		{Definition: contract.ToolDef{Name: "wait"}},
+	}
+}
+
+func (fakeExecutor) Execute(ctx context.Context, name string, input json.RawMessage, approvalID string) (json.RawMessage, error) {
+	switch name {
+	case "time":
+		return json.RawMessage(`{"now":"12:00","input":` + string(input) + `}`), nil
+	case "exec_command":
+		return nil, fmt.Errorf("%w: approval-1", heikeErrors.ErrApprovalRequired)
+	case "wait":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	default:
+		return nil, heikeErrors.NotFound("tool not found")
+	}
+}
+
+// session drives a Server over pipes, one request and response at a time.
+type session struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *bufio.Reader
+}
+
+func startServer(t *testing.T, opts ServeOptions) *session {
+	t.Helper()
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(opts).Serve(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Serve() = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Serve() did not return after stdin closed")
+		}
+	})
+	return &session{t: t, in: inW, out: bufio.NewReader(outR)}
+}
+
+func (s *session) send(line string) {
+	s.t.Helper()
+	if _, err := io.WriteString(s.in, line+"\n"); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func (s *session) read() map[string]interface{} {
+	s.t.Helper()
+	line, err := s.out.ReadString('\n')
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &resp); err != nil {
+		s.t.Fatalf("response %q: %v", line, err)
+	}
+	return resp
+}
+
+func (s *session) call(id int, method, params string) map[string]interface{} {
+	s.t.Helper()
+	s.send(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, params))
+	return s.read()
+}
+
+func TestServer_Tools(t *testing.T) {
+	s := startServer(t, ServeOptions{Tools: fakeExecutor{}, Version: "1.2.3", Allow: []string{"time", "exec_command", "wait"}})
+
+	init := s.call(1, "initialize", `{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test"}}`)
+	info := init["result"].(map[string]interface{})["serverInfo"].(map[string]interface{})
+	if info["name"] != "heike" || info["version"] != "1.2.3" {
+		t.Fatalf("initialize = %v", init)
+	}
+	s.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	list := s.call(2, "tools/list", `{}`)
+	tools := list["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 3 {
+		t.Fatalf("tools = %v", tools)
+	}
+	if schema := tools[1].(map[string]interface{})["inputSchema"].(map[string]interface{}); schema["type"] != "object" {
+		t.Fatalf("default input schema = %v", schema)
+	}
+
+	result := s.call(3, "tools/call", `{"name":"time"}`)["result"].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if result["isError"] != false || text != `{"now":"12:00","input":{}}` {
+		t.Fatalf("time result = %v", result)
+	}
+
+	result = s.call(4, "tools/call", `{"name":"exec_command","arguments":{"cmd":"ls"}}`)["result"].(map[string]interface{})
+	text = result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if result["isError"] != true || !strings.Contains(text.(string), "heike policy set exec_command --allow") {
+		t.Fatalf("exec_command result = %v", result)
+	}
+
+	if resp := s.call(5, "tools/call", `{"name":"view_image"}`); resp["error"] == nil {
+		t.Fatalf("tool outside the allowlist = %v", resp)
+	}
+	if resp := s.call(6, "resources/list", `{}`); resp["error"].(map[string]interface{})["code"] != float64(codeMethodNotFound) {
+		t.Fatalf("unknown method = %v", resp)
+	}
+
+	// A cancelled call ends without holding up other requests.
+	s.send(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait"}}`)
+	if resp := s.call(8, "ping", `{}`); resp["id"] != float64(8) {
+		t.Fatalf("ping = %v", resp)
+	}
+	s.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+	if resp := s.read(); resp["id"] != float64(7) || resp["result"].(map[string]interface{})["isError"] != true {
+		t.Fatalf("cancelled call = %v", resp)
+	}
+}
+
+func TestServer_Prompts(t *testing.T) {
+	s := startServer(t, ServeOptions{Tools: fakeExecutor{}, Prompts: []Prompt{
+		{Name: "code-review", Description: "Review a change.", Text: "Check tests first."},
+	}})
+
+	list := s.call(1, "prompts/list", `{}`)["result"].(map[string]interface{})["prompts"].([]interface{})
+	if len(list) != 1 || list[0].(map[string]interface{})["name"] != "code-review" {
+		t.Fatalf("prompts = %v", list)
+	}
+	got := s.call(2, "prompts/get", `{"name":"code-review"}`)["result"].(map[string]interface{})
+	message := got["messages"].([]interface{})[0].(map[string]interface{})
+	if message["content"].(map[string]interface{})["text"] != "Check tests first." {
+		t.Fatalf("prompt = %v", got)
+	}
+	if resp := s.call(3, "prompts/get", `{"name":"missing"}`); resp["error"] == nil {
+		t.Fatalf("missing prompt = %v", resp)
+	}
+}
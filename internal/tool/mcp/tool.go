@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	toolcore "github.com/harunnryd/heike/internal/tool"
+)
+
+// maxToolNameLength is the longest tool name model providers accept.
+const maxToolNameLength = 64
+
+var unsafeToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// ToolName returns the registry name of tool on server: the two joined by
+// an underscore, with characters model providers reject replaced.
+func ToolName(server, tool string) string {
+	name := unsafeToolNameChars.ReplaceAllString(server+"_"+tool, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+type remoteTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Annotations struct {
+		ReadOnlyHint    *bool `json:"readOnlyHint"`
+		DestructiveHint *bool `json:"destructiveHint"`
+	} `json:"annotations"`
+}
+
+// Tools lists the server's tools, following pagination.
+func (c *Client) Tools(ctx context.Context) ([]*Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var tools []*Tool
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []remoteTool `json:"tools"`
+			NextCursor string       `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("mcp server %s: %w", c.name, err)
+		}
+		for _, rt := range page.Tools {
+			if strings.TrimSpace(rt.Name) == "" {
+				continue
+			}
+			tools = append(tools, newTool(c, rt))
+		}
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// Tool is a tool of an MCP server, registered under ToolName.
+type Tool struct {
+	client      *Client
+	name        string
+	remote      string
+	description string
+	params      map[string]interface{}
+	risk        toolcore.RiskLevel
+}
+
+func newTool(c *Client, rt remoteTool) *Tool {
+	params := rt.InputSchema
+	if params == nil {
+		params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	risk := toolcore.RiskMedium
+	switch {
+	case rt.Annotations.DestructiveHint != nil && *rt.Annotations.DestructiveHint:
+		risk = toolcore.RiskHigh
+	case rt.Annotations.ReadOnlyHint != nil && *rt.Annotations.ReadOnlyHint:
+		risk = toolcore.RiskLow
+	}
+	description := strings.TrimSpace(rt.Description)
+	if description == "" {
+		description = fmt.Sprintf("%s tool from the %s MCP server.", rt.Name, c.name)
+	}
+	return &Tool{
+		client:      c,
+		name:        ToolName(c.name, rt.Name),
+		remote:      rt.Name,
+		description: description,
+		params:      params,
+		risk:        risk,
+	}
+}
+
+func (t *Tool) Name() string {
+	return t.name
+}
+
+func (t *Tool) Description() string {
+	return t.description
+}
+
+func (t *Tool) Parameters() map[string]interface{} {
+	return t.params
+}
+
+func (t *Tool) ToolMetadata() toolcore.ToolMetadata {
+	return toolcore.ToolMetadata{
+		Source:       "mcp",
+		Capabilities: []string{"mcp.call", "mcp." + t.client.name},
+		Risk:         t.risk,
+	}
+}
+
+type contentBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	MimeType string `json:"mimeType"`
+}
+
+// Execute calls the tool. Text content is joined into "content"; a result
+// the server flags as an error is returned as an error.
+func (t *Tool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	args := json.RawMessage(`{}`)
+	if len(strings.TrimSpace(string(input))) > 0 {
+		args = input
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.client.timeout)
+	defer cancel()
+
+	var result struct {
+		Content           []contentBlock  `json:"content"`
+		StructuredContent json.RawMessage `json:"structuredContent"`
+		IsError           bool            `json:"isError"`
+	}
+	params := map[string]interface{}{"name": t.remote, "arguments": args}
+	if err := t.client.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+
+	parts := make([]string, 0, len(result.Content))
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			parts = append(parts, block.Text)
+		default:
+			label := block.Type
+			if block.MimeType != "" {
+				label += " " + block.MimeType
+			}
+			parts = append(parts, "["+label+" content]")
+		}
+	}
+	text := strings.Join(parts, "\n")
+	if result.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		return nil, fmt.Errorf("mcp tool %s: %s", t.remote, text)
+	}
+
+	out := map[string]interface{}{"content": text}
+	if len(result.StructuredContent) > 0 && string(result.StructuredContent) != "null" {
+		out["structured_content"] = result.StructuredContent
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,251 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdioStopGrace is how long a stdio server gets to exit after its stdin
+// is closed before it is killed.
+const stdioStopGrace = 2 * time.Second
+
+// stdioTransport speaks newline-delimited JSON-RPC over a child process's
+// stdin and stdout.
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+
+	writeMu sync.Mutex
+	once    sync.Once
+	exited  chan struct{}
+}
+
+func startStdio(opts ServerOptions) (*stdioTransport, error) {
+	if strings.TrimSpace(opts.Command) == "" {
+		return nil, fmt.Errorf("command is required for the stdio transport")
+	}
+	cmd := exec.Command(opts.Command, opts.Args...)
+	cmd.Env = os.Environ()
+	for key, value := range opts.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", opts.Command, err)
+	}
+
+	t := &stdioTransport{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReaderSize(stdout, 64*1024),
+		exited: make(chan struct{}),
+	}
+	go func() {
+		// Servers log to stderr; keep it out of the protocol stream.
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			slog.Debug("MCP server stderr", "server", opts.Name, "line", scanner.Text())
+		}
+		cmd.Wait()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+func (t *stdioTransport) send(ctx context.Context, msg []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err := t.stdin.Write(append(msg, '\n'))
+	return err
+}
+
+func (t *stdioTransport) receive() ([]byte, error) {
+	for {
+		line, err := t.stdout.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *stdioTransport) close() error {
+	t.once.Do(func() {
+		t.stdin.Close()
+		select {
+		case <-t.exited:
+		case <-time.After(stdioStopGrace):
+			t.cmd.Process.Kill()
+			<-t.exited
+		}
+	})
+	return nil
+}
+
+// sseTransport implements the HTTP+SSE transport: server messages arrive
+// as "message" events on a long-lived GET stream, and client messages are
+// POSTed to the endpoint announced by the stream's "endpoint" event.
+type sseTransport struct {
+	client   *http.Client
+	headers  map[string]string
+	endpoint string
+	body     io.ReadCloser
+	events   *bufio.Reader
+	cancel   context.CancelFunc
+}
+
+func dialSSE(ctx context.Context, opts ServerOptions) (*sseTransport, error) {
+	if strings.TrimSpace(opts.URL) == "" {
+		return nil, fmt.Errorf("url is required for the sse transport")
+	}
+	base, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	// The stream outlives the handshake context, so it gets its own.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, base.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range opts.Headers {
+		req.Header.Set(key, value)
+	}
+	stop := context.AfterFunc(ctx, cancel)
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("open event stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("open event stream: %s", resp.Status)
+	}
+
+	t := &sseTransport{
+		client:  client,
+		headers: opts.Headers,
+		body:    resp.Body,
+		events:  bufio.NewReaderSize(resp.Body, 64*1024),
+		cancel:  cancel,
+	}
+	for t.endpoint == "" {
+		event, data, err := t.next()
+		if err != nil {
+			t.close()
+			return nil, fmt.Errorf("wait for endpoint event: %w", err)
+		}
+		if event != "endpoint" {
+			continue
+		}
+		ref, err := url.Parse(strings.TrimSpace(data))
+		if err != nil {
+			t.close()
+			return nil, fmt.Errorf("parse endpoint: %w", err)
+		}
+		t.endpoint = base.ResolveReference(ref).String()
+	}
+	if !stop() {
+		// The handshake deadline passed while the endpoint arrived.
+		t.close()
+		return nil, ctx.Err()
+	}
+	return t, nil
+}
+
+// next reads one server-sent event.
+func (t *sseTransport) next() (event, data string, err error) {
+	var lines []string
+	for {
+		line, err := t.events.ReadString('\n')
+		if err != nil {
+			return "", "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if len(lines) > 0 || event != "" {
+				if event == "" {
+					event = "message"
+				}
+				return event, strings.Join(lines, "\n"), nil
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			lines = append(lines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+func (t *sseTransport) send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post message: %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *sseTransport) receive() ([]byte, error) {
+	for {
+		event, data, err := t.next()
+		if err != nil {
+			return nil, err
+		}
+		if event == "message" && strings.TrimSpace(data) != "" {
+			return []byte(data), nil
+		}
+	}
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return t.body.Close()
+}
@@ -0,0 +1,42 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ProgressFunc receives partial output of a running tool, such as a chunk
+// of command output or a note that one of several pages was fetched.
+type ProgressFunc func(chunk string)
+
+// StreamingTool is a Tool that can report progress while it runs. The
+// runner calls ExecuteStream instead of Execute when the caller listens for
+// progress; emit may be called any number of times before it returns, and
+// the returned result is still the tool's complete output.
+type StreamingTool interface {
+	Tool
+	ExecuteStream(ctx context.Context, input json.RawMessage, emit ProgressFunc) (json.RawMessage, error)
+}
+
+type progressContextKey struct{}
+
+// WithProgress makes the runner stream progress of StreamingTools to emit.
+func WithProgress(ctx context.Context, emit ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressContextKey{}, emit)
+}
+
+func progressFromContext(ctx context.Context) ProgressFunc {
+	emit, _ := ctx.Value(progressContextKey{}).(ProgressFunc)
+	return emit
+}
+
+// executeTool runs t, streaming its progress when it supports that and
+// ctx carries a ProgressFunc.
+func executeTool(ctx context.Context, t Tool, input json.RawMessage) (json.RawMessage, error) {
+	if streaming, ok := t.(StreamingTool); ok {
+		if emit := progressFromContext(ctx); emit != nil {
+			return streaming.ExecuteStream(ctx, input, emit)
+		}
+	}
+	return t.Execute(ctx, input)
+}
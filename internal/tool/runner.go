@@ -9,14 +9,23 @@ import (
 
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
 	"github.com/harunnryd/heike/internal/logger"
+	"github.com/harunnryd/heike/internal/metrics"
 	"github.com/harunnryd/heike/internal/policy"
 )
 
 type Runner struct {
-	registry *Registry
-	policy   *policy.Engine
+	registry  *Registry
+	policy    *policy.Engine
+	sandbox   SandboxResolver
+	allowlist AllowlistResolver
+	cache     *ResultCache
+	limiter   *Limiter
 }
 
+// AllowlistResolver returns the tools a session may use and whether the
+// session is restricted at all.
+type AllowlistResolver func(sessionID string) (allow []string, restricted bool, err error)
+
 func (r *Runner) GetDescriptors() []ToolDescriptor {
 	if r == nil || r.registry == nil {
 		return nil
@@ -31,6 +40,28 @@ func NewRunner(registry *Registry, policy *policy.Engine) *Runner {
 	}
 }
 
+// SetSandboxResolver gives tools a per-session sandbox directory via
+// SandboxPath.
+func (r *Runner) SetSandboxResolver(resolve SandboxResolver) {
+	r.sandbox = resolve
+}
+
+// SetAllowlistResolver enforces per-session tool allowlists.
+func (r *Runner) SetAllowlistResolver(resolve AllowlistResolver) {
+	r.allowlist = resolve
+}
+
+// SetResultCache reuses results of the tools cache holds a TTL for; nil
+// turns caching off.
+func (r *Runner) SetResultCache(cache *ResultCache) {
+	r.cache = cache
+}
+
+// SetLimiter throttles executions per tool; nil runs them unthrottled.
+func (r *Runner) SetLimiter(limiter *Limiter) {
+	r.limiter = limiter
+}
+
 // Execute handles the full lifecycle: Check Policy -> Run Tool -> Return Result
 // It accepts an optional approvalID for retrying previously denied requests.
 func (r *Runner) Execute(ctx context.Context, toolName string, input json.RawMessage, approvalID string) (json.RawMessage, error) {
@@ -47,6 +78,19 @@ func (r *Runner) Execute(ctx context.Context, toolName string, input json.RawMes
 		return nil, fmt.Errorf("invalid input: %w", err)
 	}
 
+	// Session Allowlist
+	if sessionID := logger.GetSessionID(ctx); r.allowlist != nil && sessionID != "" {
+		allow, restricted, err := r.allowlist(sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve session tool allowlist: %w", err)
+		}
+		if restricted {
+			if err := r.policy.CheckToolAllowlist(resolvedToolName, allow); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Policy Check
 	consumedByPolicy := false
 	if approvalID != "" {
@@ -60,7 +104,7 @@ func (r *Runner) Execute(ctx context.Context, toolName string, input json.RawMes
 		}
 	} else {
 		// New check
-		allowed, id, err := r.policy.Check(resolvedToolName, input)
+		allowed, id, err := r.policy.CheckSession(logger.GetSessionID(ctx), resolvedToolName, input)
 		if !allowed {
 			if id != "" {
 				// Return specific error wrapping as ID so caller can parse it
@@ -79,12 +123,42 @@ func (r *Runner) Execute(ctx context.Context, toolName string, input json.RawMes
 		}
 	}
 
+	// Result Cache
+	// Checked after policy so a cached call still needs the same approval
+	// and counts against the same quota as a fresh one.
+	traceID := logger.GetTraceID(ctx)
+	var cacheKey string
+	if r.cache.Caches(resolvedToolName) {
+		cacheKey = ResultCacheKey(logger.GetSessionID(ctx), resolvedToolName, input)
+		if result, ok := r.cache.Get(cacheKey); ok {
+			slog.Debug("Tool cache hit", "tool", resolvedToolName, "trace_id", traceID)
+			metrics.Inc("tool_cache_hits_total", "tool", resolvedToolName)
+			return result, nil
+		}
+	}
+
+	// Throttling
+	release, waited, err := r.limiter.Acquire(ctx, resolvedToolName)
+	if err != nil {
+		return nil, heikeErrors.WrapWithCategory(err, "throttle tool", heikeErrors.ErrTransient)
+	}
+	defer release()
+	if waited >= 10*time.Millisecond {
+		slog.Debug("Tool call throttled", "tool", resolvedToolName, "waited", waited, "trace_id", traceID)
+		metrics.Inc("tool_throttled_total", "tool", resolvedToolName)
+	}
+
 	// Execution
 	start := time.Now()
-	traceID := logger.GetTraceID(ctx)
 	slog.Info("Executing tool", "tool", resolvedToolName, "requested_name", NormalizeToolName(toolName), "trace_id", traceID)
 
-	result, err := t.Execute(ctx, input)
+	if sessionID := logger.GetSessionID(ctx); r.sandbox != nil && sessionID != "" {
+		ctx = WithSandboxPath(ctx, func() (string, error) {
+			return r.sandbox(sessionID)
+		})
+	}
+
+	result, err := executeTool(ctx, t, input)
 
 	duration := time.Since(start)
 	if err != nil {
@@ -93,5 +167,8 @@ func (r *Runner) Execute(ctx context.Context, toolName string, input json.RawMes
 	}
 
 	slog.Info("Tool execution success", "tool", resolvedToolName, "requested_name", NormalizeToolName(toolName), "duration", duration, "trace_id", traceID)
+	if cacheKey != "" {
+		r.cache.Put(cacheKey, resolvedToolName, result)
+	}
 	return result, nil
 }
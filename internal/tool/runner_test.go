@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
 	heikeErrors "github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/logger"
 	"github.com/harunnryd/heike/internal/policy"
 
 	"github.com/stretchr/testify/assert"
@@ -32,6 +34,16 @@ func (t *stubLookupTool) Execute(ctx context.Context, input json.RawMessage) (js
 	return json.Marshal(map[string]string{"status": "ok"})
 }
 
+type countingTool struct {
+	stubLookupTool
+	calls int
+}
+
+func (t *countingTool) Execute(ctx context.Context, input json.RawMessage) (json.RawMessage, error) {
+	t.calls++
+	return json.Marshal(map[string]int{"call": t.calls})
+}
+
 func TestRegistryRegister_UsesSingleName(t *testing.T) {
 	registry := NewRegistry()
 	registry.Register(&stubLookupTool{name: "search_query"})
@@ -67,6 +79,35 @@ func TestRunnerExecute_UsesResolvedNameForPolicy(t *testing.T) {
 	assert.True(t, errors.Is(err, heikeErrors.ErrApprovalRequired))
 }
 
+func TestRunnerExecute_EnforcesSessionAllowlist(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pol, err := policy.NewEngine(config.GovernanceConfig{
+		AutoAllow: []string{"search_query", "exec_command"},
+	}, "allowlist-policy-"+t.Name(), "")
+	require.NoError(t, err)
+
+	registry := NewRegistry()
+	registry.Register(&stubLookupTool{name: "search_query"})
+	registry.Register(&stubLookupTool{name: "exec_command"})
+	runner := NewRunner(registry, pol)
+	runner.SetAllowlistResolver(func(sessionID string) ([]string, bool, error) {
+		return []string{"search_query"}, sessionID == "untrusted", nil
+	})
+
+	ctx := logger.WithSessionID(context.Background(), "untrusted")
+	_, err = runner.Execute(ctx, "search_query", json.RawMessage(`{}`), "")
+	require.NoError(t, err)
+
+	_, err = runner.Execute(ctx, "exec_command", json.RawMessage(`{}`), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, heikeErrors.ErrPermissionDenied))
+
+	trusted := logger.WithSessionID(context.Background(), "trusted")
+	_, err = runner.Execute(trusted, "exec_command", json.RawMessage(`{}`), "")
+	require.NoError(t, err)
+}
+
 func TestRunnerExecute_SandboxRequireEscalatedRequiresApproval(t *testing.T) {
 	t.Setenv("HOME", t.TempDir())
 
@@ -110,3 +151,103 @@ func TestRunnerExecute_ApprovalPathConsumesQuota(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "quota exceeded")
 }
+
+func TestRunnerExecute_ResultCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pol, err := policy.NewEngine(config.GovernanceConfig{
+		AutoAllow: []string{"weather", "time"},
+	}, "result-cache-"+t.Name(), "")
+	require.NoError(t, err)
+
+	weather := &countingTool{stubLookupTool: stubLookupTool{name: "weather"}}
+	clock := &countingTool{stubLookupTool: stubLookupTool{name: "time"}}
+	registry := NewRegistry()
+	registry.Register(weather)
+	registry.Register(clock)
+	runner := NewRunner(registry, pol)
+	runner.SetResultCache(NewResultCache(map[string]time.Duration{"weather": time.Minute}, 8))
+
+	ctx := logger.WithSessionID(context.Background(), "s1")
+	first, err := runner.Execute(ctx, "weather", json.RawMessage(`{"location":"Oslo","units":"metric"}`), "")
+	require.NoError(t, err)
+	second, err := runner.Execute(ctx, "weather", json.RawMessage(`{"units":"metric","location":"Oslo"}`), "")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(first), string(second))
+	assert.Equal(t, 1, weather.calls)
+
+	_, err = runner.Execute(logger.WithSessionID(context.Background(), "s2"), "weather", json.RawMessage(`{"location":"Oslo","units":"metric"}`), "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, weather.calls, "sessions do not share results")
+
+	for i := 0; i < 2; i++ {
+		_, err = runner.Execute(ctx, "time", json.RawMessage(`{}`), "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, clock.calls, "tools without a TTL are not cached")
+}
+
+func TestRunnerExecute_ThrottlesPerTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pol, err := policy.NewEngine(config.GovernanceConfig{
+		AutoAllow: []string{"search_query"},
+	}, "tool-limits-"+t.Name(), "")
+	require.NoError(t, err)
+
+	registry := NewRegistry()
+	registry.Register(&stubLookupTool{name: "search_query"})
+	runner := NewRunner(registry, pol)
+	runner.SetLimiter(NewLimiter(map[string]ToolLimit{"search_query": {MaxConcurrent: 1}}))
+
+	release, _, err := runner.limiter.Acquire(context.Background(), "search_query")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = runner.Execute(ctx, "search_query", json.RawMessage(`{}`), "")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, heikeErrors.ErrTransient))
+
+	release()
+	_, err = runner.Execute(context.Background(), "search_query", json.RawMessage(`{}`), "")
+	require.NoError(t, err)
+}
+
+type streamingStubTool struct {
+	stubLookupTool
+	streamed bool
+}
+
+func (t *streamingStubTool) ExecuteStream(ctx context.Context, input json.RawMessage, emit ProgressFunc) (json.RawMessage, error) {
+	t.streamed = true
+	emit("half way")
+	emit("done")
+	return json.Marshal(map[string]string{"status": "streamed"})
+}
+
+func TestRunnerExecute_StreamsProgressWhenRequested(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	pol, err := policy.NewEngine(config.GovernanceConfig{
+		AutoAllow: []string{"long_job"},
+	}, "tool-progress-"+t.Name(), "")
+	require.NoError(t, err)
+
+	streaming := &streamingStubTool{stubLookupTool: stubLookupTool{name: "long_job"}}
+	registry := NewRegistry()
+	registry.Register(streaming)
+	runner := NewRunner(registry, pol)
+
+	result, err := runner.Execute(context.Background(), "long_job", json.RawMessage(`{}`), "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"ok"}`, string(result))
+	assert.False(t, streaming.streamed, "without a listener the tool runs as usual")
+
+	var chunks []string
+	ctx := WithProgress(context.Background(), func(chunk string) { chunks = append(chunks, chunk) })
+	result, err = runner.Execute(ctx, "long_job", json.RawMessage(`{}`), "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"streamed"}`, string(result))
+	assert.Equal(t, []string{"half way", "done"}, chunks)
+}
@@ -0,0 +1,26 @@
+package tool
+
+import "context"
+
+// SandboxResolver returns the sandbox directory of a session, creating it
+// if needed.
+type SandboxResolver func(sessionID string) (string, error)
+
+type sandboxContextKey struct{}
+
+// WithSandboxPath makes a session's sandbox available to tools through
+// SandboxPath. The directory is only resolved, and so created, when a tool
+// asks for it.
+func WithSandboxPath(ctx context.Context, resolve func() (string, error)) context.Context {
+	return context.WithValue(ctx, sandboxContextKey{}, resolve)
+}
+
+// SandboxPath returns the calling session's sandbox directory, or "" when no
+// sandbox is configured for the call.
+func SandboxPath(ctx context.Context) (string, error) {
+	resolve, ok := ctx.Value(sandboxContextKey{}).(func() (string, error))
+	if !ok || resolve == nil {
+		return "", nil
+	}
+	return resolve()
+}
@@ -18,11 +18,24 @@ import (
 	"github.com/harunnryd/heike/internal/store"
 	"github.com/harunnryd/heike/internal/tool"
 	_ "github.com/harunnryd/heike/internal/tool/builtin"
+	"github.com/harunnryd/heike/internal/tool/mcp"
 )
 
 type Components struct {
 	Registry *tool.Registry
 	Runner   *tool.Runner
+
+	mcpClients []*mcp.Client
+}
+
+// Close disconnects MCP servers and stops the stdio ones.
+func (c *Components) Close() {
+	for _, client := range c.mcpClients {
+		if err := client.Close(); err != nil {
+			slog.Warn("Failed to close MCP server", "server", client.Name(), "error", err)
+		}
+	}
+	c.mcpClients = nil
 }
 
 func Build(workspaceID string, policyEngine *policy.Engine, workspacePath string, cfg *config.Config) (*Components, error) {
@@ -45,6 +58,22 @@ func Build(workspaceID string, policyEngine *policy.Engine, workspacePath string
 	if err != nil {
 		return nil, err
 	}
+	builtinOptions.FinanceWatchlistsPath, err = store.GetWatchlistsPath(workspaceID, cfg.Daemon.WorkspacePath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve watchlists path: %w", err)
+	}
+	mcpServers, err := resolveMCPServers(cfg.Tools.MCP, builtinOptions.HTTPClients)
+	if err != nil {
+		return nil, err
+	}
+	resultCache, err := resolveResultCache(cfg.Tools.Cache)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := resolveToolLimiter(cfg.Governance.ToolLimits)
+	if err != nil {
+		return nil, err
+	}
 
 	toolRegistry := tool.NewRegistry()
 
@@ -68,6 +97,7 @@ func Build(workspaceID string, policyEngine *policy.Engine, workspacePath string
 		WorkspaceRootPath: cfg.Daemon.WorkspacePath,
 		WorkspacePath:     workspacePath,
 		ProjectPath:       cfg.Discovery.ProjectPath,
+		BundledVersion:    cfg.Discovery.BundledVersion,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("resolve runtime tool sources: %w", err)
@@ -75,10 +105,16 @@ func Build(workspaceID string, policyEngine *policy.Engine, workspacePath string
 	if err := registerCustomTools(toolRegistry, workspaceID, sandboxBasePath, sources); err != nil {
 		return nil, fmt.Errorf("register custom tools: %w", err)
 	}
+	mcpClients := registerMCPTools(context.Background(), toolRegistry, mcpServers, workspaceID)
+
+	runner := tool.NewRunner(toolRegistry, policyEngine)
+	runner.SetResultCache(resultCache)
+	runner.SetLimiter(limiter)
 
 	return &Components{
-		Registry: toolRegistry,
-		Runner:   tool.NewRunner(toolRegistry, policyEngine),
+		Registry:   toolRegistry,
+		Runner:     runner,
+		mcpClients: mcpClients,
 	}, nil
 }
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/harunnryd/heike/internal/config"
 	"github.com/harunnryd/heike/internal/policy"
+	"github.com/harunnryd/heike/internal/tool"
 )
 
 func TestBuildRegistersBuiltInTools(t *testing.T) {
@@ -31,18 +32,29 @@ func TestBuildRegistersBuiltInTools(t *testing.T) {
 
 	required := []string{
 		"apply_patch",
+		"calendar",
 		"click",
+		"convert",
 		"exec_command",
 		"finance",
 		"find",
+		"github",
+		"http_request",
 		"image_query",
+		"list_dir",
+		"news",
 		"open",
+		"python_exec",
+		"read_file",
 		"screenshot",
 		"search_query",
+		"send_email",
 		"sports",
+		"sql_query",
 		"time",
 		"view_image",
 		"weather",
+		"write_file",
 		"write_stdin",
 	}
 	for _, name := range required {
@@ -291,3 +303,269 @@ func TestBuildHonorsConfiguredRuntimeToolSourceOrder(t *testing.T) {
 		t.Fatalf("expected bundled source due configured order, got: %v", payload)
 	}
 }
+
+func TestBuildValidatesMCPServers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	workspaceID := "test-workspace-" + t.Name()
+	policyEngine, err := policy.NewEngine(config.GovernanceConfig{}, workspaceID, "")
+	if err != nil {
+		t.Fatalf("create policy engine: %v", err)
+	}
+
+	invalid := [][]config.MCPServerConfig{
+		{{Command: "mcp-server"}},
+		{{Name: "a", Command: "mcp-server"}, {Name: "a", Command: "mcp-server"}},
+		{{Name: "a", Transport: "sse"}},
+		{{Name: "a", Transport: "websocket", URL: "ws://localhost"}},
+		{{Name: "a", Command: "mcp-server", Timeout: "soon"}},
+	}
+	for _, servers := range invalid {
+		cfg := &config.Config{}
+		cfg.Tools.MCP.Servers = servers
+		if _, err := Build(workspaceID, policyEngine, t.TempDir(), cfg); err == nil {
+			t.Errorf("expected error for %+v", servers)
+		}
+	}
+
+	// A server that cannot be started is skipped rather than failing startup.
+	cfg := &config.Config{}
+	cfg.Tools.MCP.Servers = []config.MCPServerConfig{{Name: "missing", Command: "heike-no-such-mcp-server"}}
+	components, err := Build(workspaceID, policyEngine, t.TempDir(), cfg)
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	defer components.Close()
+	if _, ok := components.Registry.Get("time"); !ok {
+		t.Fatal("built-in tools missing")
+	}
+}
+
+func TestResolveSQLDatabases(t *testing.T) {
+	databases, err := resolveSQLDatabases([]config.SQLDatabaseConfig{
+		{Name: "local", DSN: "data/app.db"},
+		{Name: "warehouse", DSN: "postgresql://localhost/wh"},
+		{Name: "reports", Driver: "PostgreSQL", DSN: "host=db dbname=reports"},
+	})
+	if err != nil {
+		t.Fatalf("resolveSQLDatabases() failed: %v", err)
+	}
+	drivers := []string{databases[0].Driver, databases[1].Driver, databases[2].Driver}
+	want := []string{tool.SQLDriverSQLite, tool.SQLDriverPostgres, tool.SQLDriverPostgres}
+	for i := range want {
+		if drivers[i] != want[i] {
+			t.Fatalf("drivers = %v, want %v", drivers, want)
+		}
+	}
+
+	invalid := [][]config.SQLDatabaseConfig{
+		{{DSN: "a.db"}},
+		{{Name: "a"}},
+		{{Name: "a", DSN: "a.db"}, {Name: "a", DSN: "b.db"}},
+		{{Name: "a", Driver: "mysql", DSN: "a.db"}},
+	}
+	for _, databases := range invalid {
+		if _, err := resolveSQLDatabases(databases); err == nil {
+			t.Errorf("expected error for %+v", databases)
+		}
+	}
+}
+
+func TestResolveCalendarOptions(t *testing.T) {
+	options, err := resolveCalendarOptions(config.CalendarToolConfig{})
+	if err != nil {
+		t.Fatalf("resolveCalendarOptions() failed: %v", err)
+	}
+	if options.Provider != "" {
+		t.Fatalf("provider = %q, want unconfigured", options.Provider)
+	}
+
+	options, err = resolveCalendarOptions(config.CalendarToolConfig{
+		Timezone: "Asia/Tokyo",
+		Google:   config.GoogleCalendarConfig{AccessToken: "ya29.token"},
+	})
+	if err != nil {
+		t.Fatalf("resolveCalendarOptions() failed: %v", err)
+	}
+	if options.Provider != tool.CalendarProviderGoogle {
+		t.Fatalf("provider = %q, want %q", options.Provider, tool.CalendarProviderGoogle)
+	}
+	if options.Location.String() != "Asia/Tokyo" {
+		t.Fatalf("location = %s, want Asia/Tokyo", options.Location)
+	}
+	if options.GoogleBaseURL != config.DefaultGoogleCalendarBaseURL || options.GoogleCalendarID != config.DefaultGoogleCalendarID {
+		t.Fatalf("google defaults not applied: %+v", options)
+	}
+
+	options, err = resolveCalendarOptions(config.CalendarToolConfig{
+		CalDAV: config.CalDAVCalendarConfig{URL: "https://dav.example.com/cal/"},
+	})
+	if err != nil {
+		t.Fatalf("resolveCalendarOptions() failed: %v", err)
+	}
+	if options.Provider != tool.CalendarProviderCalDAV {
+		t.Fatalf("provider = %q, want %q", options.Provider, tool.CalendarProviderCalDAV)
+	}
+
+	invalid := []config.CalendarToolConfig{
+		{Provider: "outlook"},
+		{Provider: "caldav"},
+		{Provider: "google"},
+		{Google: config.GoogleCalendarConfig{RefreshToken: "rt"}},
+		{Timezone: "Mars/Olympus"},
+		{Timeout: "soon"},
+	}
+	for _, cfg := range invalid {
+		if _, err := resolveCalendarOptions(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestResolveWebSearchEngine(t *testing.T) {
+	engine, baseURL, err := resolveWebSearchEngine(config.WebToolConfig{})
+	if err != nil {
+		t.Fatalf("resolveWebSearchEngine() failed: %v", err)
+	}
+	if engine != tool.WebEngineBing || baseURL != config.DefaultWebToolBaseURL {
+		t.Fatalf("engine = %q, base url = %q, want bing defaults", engine, baseURL)
+	}
+
+	engine, baseURL, err = resolveWebSearchEngine(config.WebToolConfig{
+		Engine:  "Brave",
+		BaseURL: config.DefaultWebToolBaseURL,
+		APIKey:  "key",
+	})
+	if err != nil {
+		t.Fatalf("resolveWebSearchEngine() failed: %v", err)
+	}
+	if engine != tool.WebEngineBrave || baseURL != config.DefaultWebToolBraveBaseURL {
+		t.Fatalf("engine = %q, base url = %q, want brave defaults", engine, baseURL)
+	}
+
+	_, baseURL, err = resolveWebSearchEngine(config.WebToolConfig{Engine: "searxng", BaseURL: "https://searx.example.com"})
+	if err != nil {
+		t.Fatalf("resolveWebSearchEngine() failed: %v", err)
+	}
+	if baseURL != "https://searx.example.com" {
+		t.Fatalf("base url = %q, want the configured instance", baseURL)
+	}
+
+	invalid := []config.WebToolConfig{
+		{Engine: "brave"},
+		{Engine: "searxng", BaseURL: config.DefaultWebToolBaseURL},
+		{Engine: "altavista"},
+	}
+	for _, cfg := range invalid {
+		if _, _, err := resolveWebSearchEngine(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestResolveResultCache(t *testing.T) {
+	cache, err := resolveResultCache(config.ToolCacheConfig{TTLs: map[string]string{"weather": "10m"}})
+	if err != nil {
+		t.Fatalf("resolveResultCache() failed: %v", err)
+	}
+	if cache != nil {
+		t.Fatal("expected no cache while tools.cache.enabled is false")
+	}
+
+	cache, err = resolveResultCache(config.ToolCacheConfig{
+		Enabled: true,
+		TTLs:    map[string]string{"weather": "10m", "finance": "0s"},
+	})
+	if err != nil {
+		t.Fatalf("resolveResultCache() failed: %v", err)
+	}
+	if !cache.Caches("weather") || cache.Caches("finance") || cache.Caches("search_query") {
+		t.Fatal("expected only weather to be cached")
+	}
+
+	for _, raw := range []string{"soon", "-1m"} {
+		if _, err := resolveResultCache(config.ToolCacheConfig{Enabled: true, TTLs: map[string]string{"weather": raw}}); err == nil {
+			t.Errorf("expected error for ttl %q", raw)
+		}
+	}
+}
+
+func TestResolveToolLimiter(t *testing.T) {
+	if _, err := resolveToolLimiter(map[string]config.ToolLimitConfig{
+		"search_query": {QPS: 1, MaxConcurrent: 2},
+		"open":         {},
+	}); err != nil {
+		t.Fatalf("resolveToolLimiter() failed: %v", err)
+	}
+
+	invalid := []map[string]config.ToolLimitConfig{
+		{"search_query": {QPS: -1}},
+		{"search_query": {MaxConcurrent: -2}},
+	}
+	for _, cfg := range invalid {
+		if _, err := resolveToolLimiter(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestResolveNewsFeeds(t *testing.T) {
+	feeds, err := resolveNewsFeeds([]config.NewsFeedConfig{
+		{Name: "go", URL: " https://go.dev/blog/feed.atom "},
+		{Name: "hn", URL: "https://hnrss.org/frontpage"},
+	})
+	if err != nil {
+		t.Fatalf("resolveNewsFeeds() failed: %v", err)
+	}
+	if len(feeds) != 2 || feeds[0].URL != "https://go.dev/blog/feed.atom" {
+		t.Fatalf("feeds = %+v", feeds)
+	}
+
+	invalid := [][]config.NewsFeedConfig{
+		{{URL: "https://go.dev/blog/feed.atom"}},
+		{{Name: "go"}},
+		{{Name: "go", URL: "feed.atom"}},
+		{{Name: "go", URL: "file:///tmp/feed.xml"}},
+		{{Name: "go", URL: "https://a.example/rss"}, {Name: "go", URL: "https://b.example/rss"}},
+	}
+	for _, feeds := range invalid {
+		if _, err := resolveNewsFeeds(feeds); err == nil {
+			t.Errorf("expected error for %+v", feeds)
+		}
+	}
+}
+
+func TestResolveEmailOptions(t *testing.T) {
+	adapter := config.EmailConfig{
+		Address: "agent@example.com",
+		SMTP:    config.EmailServerConfig{Host: "smtp.example.com", Username: "agent", Password: "secret"},
+	}
+	options, err := resolveEmailOptions(config.EmailToolConfig{}, adapter)
+	if err != nil {
+		t.Fatalf("resolveEmailOptions() failed: %v", err)
+	}
+	if options.From != "agent@example.com" || options.SMTPHost != "smtp.example.com" || options.SMTPPassword != "secret" {
+		t.Fatalf("options = %+v", options)
+	}
+	if options.SMTPPort != config.DefaultEmailSMTPPort || options.MaxAttachmentBytes != config.DefaultEmailToolMaxAttachmentBytes {
+		t.Fatalf("defaults not applied: %+v", options)
+	}
+
+	options, err = resolveEmailOptions(config.EmailToolConfig{
+		From: "reports@example.com",
+		SMTP: config.EmailServerConfig{Host: "relay.example.com", Port: 465},
+	}, adapter)
+	if err != nil {
+		t.Fatalf("resolveEmailOptions() failed: %v", err)
+	}
+	if options.From != "reports@example.com" || options.SMTPHost != "relay.example.com" || options.SMTPPort != 465 || options.SMTPUsername != "" {
+		t.Fatalf("options = %+v", options)
+	}
+
+	if _, err := resolveEmailOptions(config.EmailToolConfig{SMTP: config.EmailServerConfig{Host: "relay.example.com"}}, config.EmailConfig{}); err == nil {
+		t.Fatal("expected error when no sender address is configured")
+	}
+	if _, err := resolveEmailOptions(config.EmailToolConfig{Timeout: "soon"}, adapter); err == nil {
+		t.Fatal("expected error for invalid timeout")
+	}
+}
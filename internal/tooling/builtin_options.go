@@ -2,9 +2,12 @@ package tooling
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
 	"github.com/harunnryd/heike/internal/tool"
 )
 
@@ -17,9 +20,9 @@ func resolveBuiltinOptions(cfg *config.Config) (tool.BuiltinOptions, error) {
 	if err != nil {
 		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.web.timeout: %w", err)
 	}
-	webBaseURL := strings.TrimSpace(cfg.Tools.Web.BaseURL)
-	if webBaseURL == "" {
-		webBaseURL = config.DefaultWebToolBaseURL
+	webEngine, webBaseURL, err := resolveWebSearchEngine(cfg.Tools.Web)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
 	}
 	webMaxContentLength := cfg.Tools.Web.MaxContentLength
 	if webMaxContentLength <= 0 {
@@ -43,6 +46,14 @@ func resolveBuiltinOptions(cfg *config.Config) (tool.BuiltinOptions, error) {
 	if financeBaseURL == "" {
 		financeBaseURL = config.DefaultFinanceToolBaseURL
 	}
+	financeChartBaseURL := strings.TrimSpace(cfg.Tools.Finance.ChartBaseURL)
+	if financeChartBaseURL == "" {
+		financeChartBaseURL = config.DefaultFinanceToolChartBaseURL
+	}
+	financeCryptoBaseURL := strings.TrimSpace(cfg.Tools.Finance.CryptoBaseURL)
+	if financeCryptoBaseURL == "" {
+		financeCryptoBaseURL = config.DefaultFinanceToolCryptoBaseURL
+	}
 
 	sportsTimeout, err := config.DurationOrDefault(cfg.Tools.Sports.Timeout, config.DefaultSportsToolTimeout)
 	if err != nil {
@@ -76,20 +87,424 @@ func resolveBuiltinOptions(cfg *config.Config) (tool.BuiltinOptions, error) {
 		applyPatchCommand = config.DefaultApplyPatchToolCommand
 	}
 
+	filesMaxReadBytes := cfg.Tools.Files.MaxReadBytes
+	if filesMaxReadBytes <= 0 {
+		filesMaxReadBytes = config.DefaultFilesToolMaxReadBytes
+	}
+	filesMaxWriteBytes := cfg.Tools.Files.MaxWriteBytes
+	if filesMaxWriteBytes <= 0 {
+		filesMaxWriteBytes = config.DefaultFilesToolMaxWriteBytes
+	}
+
+	httpRequestTimeout, err := config.DurationOrDefault(cfg.Tools.HTTP.Timeout, config.DefaultHTTPRequestToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.http.timeout: %w", err)
+	}
+	httpRequestMaxRequestBytes := cfg.Tools.HTTP.MaxRequestBytes
+	if httpRequestMaxRequestBytes <= 0 {
+		httpRequestMaxRequestBytes = config.DefaultHTTPRequestToolMaxRequestBytes
+	}
+	httpRequestMaxResponseBytes := cfg.Tools.HTTP.MaxResponseBytes
+	if httpRequestMaxResponseBytes <= 0 {
+		httpRequestMaxResponseBytes = config.DefaultHTTPRequestToolMaxResponseBytes
+	}
+
+	sqlTimeout, err := config.DurationOrDefault(cfg.Tools.SQL.Timeout, config.DefaultSQLToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.sql.timeout: %w", err)
+	}
+	sqlMaxRows := cfg.Tools.SQL.MaxRows
+	if sqlMaxRows <= 0 {
+		sqlMaxRows = config.DefaultSQLToolMaxRows
+	}
+	sqlMaxOutputBytes := cfg.Tools.SQL.MaxOutputBytes
+	if sqlMaxOutputBytes <= 0 {
+		sqlMaxOutputBytes = config.DefaultSQLToolMaxOutputBytes
+	}
+	sqliteCommand := strings.TrimSpace(cfg.Tools.SQL.SQLiteCommand)
+	if sqliteCommand == "" {
+		sqliteCommand = config.DefaultSQLToolSQLiteCommand
+	}
+	postgresCommand := strings.TrimSpace(cfg.Tools.SQL.PostgresCommand)
+	if postgresCommand == "" {
+		postgresCommand = config.DefaultSQLToolPostgresCommand
+	}
+	sqlDatabases, err := resolveSQLDatabases(cfg.Tools.SQL.Databases)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
+	}
+
+	githubTimeout, err := config.DurationOrDefault(cfg.Tools.GitHub.Timeout, config.DefaultGitHubToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.github.timeout: %w", err)
+	}
+	githubBaseURL := strings.TrimSpace(cfg.Tools.GitHub.BaseURL)
+	if githubBaseURL == "" {
+		githubBaseURL = config.DefaultGitHubToolBaseURL
+	}
+
+	calendar, err := resolveCalendarOptions(cfg.Tools.Calendar)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
+	}
+
+	newsTimeout, err := config.DurationOrDefault(cfg.Tools.News.Timeout, config.DefaultNewsToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.news.timeout: %w", err)
+	}
+	newsCacheTTL, err := config.DurationOrDefault(cfg.Tools.News.CacheTTL, config.DefaultNewsToolCacheTTL)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.news.cache_ttl: %w", err)
+	}
+	newsMaxItems := cfg.Tools.News.MaxItems
+	if newsMaxItems <= 0 {
+		newsMaxItems = config.DefaultNewsToolMaxItems
+	}
+	newsFeeds, err := resolveNewsFeeds(cfg.Tools.News.Feeds)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
+	}
+
+	convertTimeout, err := config.DurationOrDefault(cfg.Tools.Convert.Timeout, config.DefaultConvertToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.convert.timeout: %w", err)
+	}
+	convertCacheTTL, err := config.DurationOrDefault(cfg.Tools.Convert.CacheTTL, config.DefaultConvertToolCacheTTL)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.convert.cache_ttl: %w", err)
+	}
+	convertBaseURL := strings.TrimSpace(cfg.Tools.Convert.BaseURL)
+	if convertBaseURL == "" {
+		convertBaseURL = config.DefaultConvertToolBaseURL
+	}
+
+	email, err := resolveEmailOptions(cfg.Tools.Email, cfg.Adapters.Email)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
+	}
+
+	pythonCommand := strings.TrimSpace(cfg.Tools.Python.Command)
+	if pythonCommand == "" {
+		pythonCommand = config.DefaultPythonToolCommand
+	}
+	pythonTimeout, err := config.DurationOrDefault(cfg.Tools.Python.Timeout, config.DefaultPythonToolTimeout)
+	if err != nil {
+		return tool.BuiltinOptions{}, fmt.Errorf("parse tools.python.timeout: %w", err)
+	}
+	pythonMaxMemoryBytes := cfg.Tools.Python.MaxMemoryBytes
+	if pythonMaxMemoryBytes <= 0 {
+		pythonMaxMemoryBytes = config.DefaultPythonToolMaxMemoryBytes
+	}
+	pythonMaxOutputBytes := cfg.Tools.Python.MaxOutputBytes
+	if pythonMaxOutputBytes <= 0 {
+		pythonMaxOutputBytes = config.DefaultPythonToolMaxOutputBytes
+	}
+
+	httpClients, err := httpclient.ForConfig(cfg.HTTP)
+	if err != nil {
+		return tool.BuiltinOptions{}, err
+	}
+
 	return tool.BuiltinOptions{
-		WebTimeout:          webTimeout,
-		WebBaseURL:          webBaseURL,
-		WebMaxContentLength: webMaxContentLength,
-		WeatherBaseURL:      weatherBaseURL,
-		WeatherTimeout:      weatherTimeout,
-		FinanceBaseURL:      financeBaseURL,
-		FinanceTimeout:      financeTimeout,
-		SportsBaseURL:       sportsBaseURL,
-		SportsTimeout:       sportsTimeout,
-		ImageQueryBaseURL:   imageQueryBaseURL,
-		ImageQueryTimeout:   imageQueryTimeout,
-		ScreenshotTimeout:   screenshotTimeout,
-		ScreenshotRenderer:  screenshotRenderer,
-		ApplyPatchCommand:   applyPatchCommand,
+		WebTimeout:                  webTimeout,
+		WebBaseURL:                  webBaseURL,
+		WebEngine:                   webEngine,
+		WebAPIKey:                   strings.TrimSpace(cfg.Tools.Web.APIKey),
+		WebMaxContentLength:         webMaxContentLength,
+		WeatherBaseURL:              weatherBaseURL,
+		WeatherTimeout:              weatherTimeout,
+		FinanceBaseURL:              financeBaseURL,
+		FinanceTimeout:              financeTimeout,
+		FinanceChartBaseURL:         financeChartBaseURL,
+		FinanceCryptoBaseURL:        financeCryptoBaseURL,
+		FinanceCryptoAPIKey:         strings.TrimSpace(cfg.Tools.Finance.CryptoAPIKey),
+		SportsBaseURL:               sportsBaseURL,
+		SportsTimeout:               sportsTimeout,
+		ImageQueryBaseURL:           imageQueryBaseURL,
+		ImageQueryTimeout:           imageQueryTimeout,
+		ScreenshotTimeout:           screenshotTimeout,
+		ScreenshotRenderer:          screenshotRenderer,
+		ScreenshotChromePath:        strings.TrimSpace(cfg.Tools.Screenshot.ChromePath),
+		ApplyPatchCommand:           applyPatchCommand,
+		FilesMaxReadBytes:           filesMaxReadBytes,
+		FilesMaxWriteBytes:          filesMaxWriteBytes,
+		FilesAllowedExtensions:      cfg.Tools.Files.AllowedExtensions,
+		FilesDeniedExtensions:       cfg.Tools.Files.DeniedExtensions,
+		HTTPRequestTimeout:          httpRequestTimeout,
+		HTTPRequestAllowedDomains:   cfg.Tools.HTTP.AllowedDomains,
+		HTTPRequestDeniedDomains:    cfg.Tools.HTTP.DeniedDomains,
+		HTTPRequestMaxRequestBytes:  httpRequestMaxRequestBytes,
+		HTTPRequestMaxResponseBytes: httpRequestMaxResponseBytes,
+		HTTPRequestAllowPrivate:     cfg.Tools.HTTP.AllowPrivateNetworks,
+		SQLTimeout:                  sqlTimeout,
+		SQLMaxRows:                  sqlMaxRows,
+		SQLMaxOutputBytes:           sqlMaxOutputBytes,
+		SQLReadOnly:                 cfg.Tools.SQL.ReadOnly,
+		SQLSQLiteCommand:            sqliteCommand,
+		SQLPostgresCommand:          postgresCommand,
+		SQLDatabases:                sqlDatabases,
+		GitHubBaseURL:               githubBaseURL,
+		GitHubTimeout:               githubTimeout,
+		GitHubToken:                 strings.TrimSpace(cfg.Tools.GitHub.Token),
+		Calendar:                    calendar,
+		NewsTimeout:                 newsTimeout,
+		NewsCacheTTL:                newsCacheTTL,
+		NewsMaxItems:                newsMaxItems,
+		NewsFeeds:                   newsFeeds,
+		ConvertBaseURL:              convertBaseURL,
+		ConvertTimeout:              convertTimeout,
+		ConvertCacheTTL:             convertCacheTTL,
+		Email:                       email,
+		PythonCommand:               pythonCommand,
+		PythonVenv:                  strings.TrimSpace(cfg.Tools.Python.Venv),
+		PythonTimeout:               pythonTimeout,
+		PythonMaxMemoryBytes:        pythonMaxMemoryBytes,
+		PythonMaxOutputBytes:        pythonMaxOutputBytes,
+		HTTPClients:                 httpClients,
 	}, nil
 }
+
+// resolveSQLDatabases validates tools.sql.databases and infers drivers.
+func resolveSQLDatabases(databases []config.SQLDatabaseConfig) ([]tool.SQLDatabase, error) {
+	seen := make(map[string]struct{}, len(databases))
+	resolved := make([]tool.SQLDatabase, 0, len(databases))
+	for i, database := range databases {
+		name := strings.TrimSpace(database.Name)
+		if name == "" {
+			return nil, fmt.Errorf("tools.sql.databases[%d].name is required", i)
+		}
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf("tools.sql.databases: duplicate database %q", name)
+		}
+		seen[name] = struct{}{}
+
+		dsn := strings.TrimSpace(database.DSN)
+		if dsn == "" {
+			return nil, fmt.Errorf("tools.sql.databases[%s].dsn is required", name)
+		}
+		driver := strings.ToLower(strings.TrimSpace(database.Driver))
+		switch driver {
+		case "":
+			driver = tool.SQLDriverSQLite
+			if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+				driver = tool.SQLDriverPostgres
+			}
+		case "sqlite", "sqlite3":
+			driver = tool.SQLDriverSQLite
+		case "postgres", "postgresql":
+			driver = tool.SQLDriverPostgres
+		default:
+			return nil, fmt.Errorf("tools.sql.databases[%s].driver must be %q or %q", name, tool.SQLDriverSQLite, tool.SQLDriverPostgres)
+		}
+		resolved = append(resolved, tool.SQLDatabase{Name: name, Driver: driver, DSN: dsn})
+	}
+	return resolved, nil
+}
+
+// resolveNewsFeeds validates tools.news.feeds.
+func resolveNewsFeeds(feeds []config.NewsFeedConfig) ([]tool.NewsFeed, error) {
+	seen := make(map[string]struct{}, len(feeds))
+	resolved := make([]tool.NewsFeed, 0, len(feeds))
+	for i, feed := range feeds {
+		name := strings.TrimSpace(feed.Name)
+		if name == "" {
+			return nil, fmt.Errorf("tools.news.feeds[%d].name is required", i)
+		}
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf("tools.news.feeds: duplicate feed %q", name)
+		}
+		seen[name] = struct{}{}
+
+		feedURL := strings.TrimSpace(feed.URL)
+		parsed, err := url.Parse(feedURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("tools.news.feeds[%s].url must be an absolute http or https URL", name)
+		}
+		resolved = append(resolved, tool.NewsFeed{Name: name, URL: feedURL})
+	}
+	return resolved, nil
+}
+
+// resolveEmailOptions fills tools.email from adapters.email where the tool
+// has no SMTP server or sender of its own.
+func resolveEmailOptions(cfg config.EmailToolConfig, adapter config.EmailConfig) (tool.EmailOptions, error) {
+	timeout, err := config.DurationOrDefault(cfg.Timeout, config.DefaultEmailToolTimeout)
+	if err != nil {
+		return tool.EmailOptions{}, fmt.Errorf("parse tools.email.timeout: %w", err)
+	}
+	maxAttachmentBytes := cfg.MaxAttachmentBytes
+	if maxAttachmentBytes <= 0 {
+		maxAttachmentBytes = config.DefaultEmailToolMaxAttachmentBytes
+	}
+
+	server := cfg.SMTP
+	if strings.TrimSpace(server.Host) == "" {
+		server = adapter.SMTP
+	}
+	if server.Port <= 0 {
+		server.Port = config.DefaultEmailSMTPPort
+	}
+	from := strings.TrimSpace(cfg.From)
+	if from == "" {
+		from = strings.TrimSpace(adapter.Address)
+	}
+	host := strings.TrimSpace(server.Host)
+	if host != "" && from == "" {
+		return tool.EmailOptions{}, fmt.Errorf("tools.email.from is required when adapters.email.address is not set")
+	}
+
+	return tool.EmailOptions{
+		From:               from,
+		Timeout:            timeout,
+		AllowedRecipients:  cfg.AllowedRecipients,
+		MaxAttachmentBytes: maxAttachmentBytes,
+		SMTPHost:           host,
+		SMTPPort:           server.Port,
+		SMTPUsername:       server.Username,
+		SMTPPassword:       server.Password,
+	}, nil
+}
+
+// resolveWebSearchEngine validates tools.web.engine and returns it with
+// the endpoint to search. The Bing default base_url, which generated
+// configs spell out, stands for "unset" with the other engines.
+func resolveWebSearchEngine(cfg config.WebToolConfig) (string, string, error) {
+	engine := strings.ToLower(strings.TrimSpace(cfg.Engine))
+	if engine == "" {
+		engine = config.DefaultWebToolEngine
+	}
+	baseURL := strings.TrimSpace(cfg.BaseURL)
+	if engine != tool.WebEngineBing && baseURL == config.DefaultWebToolBaseURL {
+		baseURL = ""
+	}
+	switch engine {
+	case tool.WebEngineBing:
+		if baseURL == "" {
+			baseURL = config.DefaultWebToolBaseURL
+		}
+	case tool.WebEngineBrave:
+		if strings.TrimSpace(cfg.APIKey) == "" {
+			return "", "", fmt.Errorf("tools.web.api_key is required for the brave engine")
+		}
+		if baseURL == "" {
+			baseURL = config.DefaultWebToolBraveBaseURL
+		}
+	case tool.WebEngineSearxNG:
+		if baseURL == "" {
+			return "", "", fmt.Errorf("tools.web.base_url is required for the searxng engine")
+		}
+	case tool.WebEngineDuckDuckGo:
+		if baseURL == "" {
+			baseURL = config.DefaultWebToolDuckDuckGoBaseURL
+		}
+	default:
+		return "", "", fmt.Errorf("tools.web.engine must be one of %s", strings.Join(tool.WebEngines, ", "))
+	}
+	return engine, baseURL, nil
+}
+
+// resolveResultCache builds the runner's result cache from tools.cache,
+// or returns nil when it is disabled.
+func resolveResultCache(cfg config.ToolCacheConfig) (*tool.ResultCache, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	ttls := make(map[string]time.Duration, len(cfg.TTLs))
+	for name, raw := range cfg.TTLs {
+		ttl, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("tools.cache.ttls.%s must be a non-negative duration, got %q", name, raw)
+		}
+		ttls[name] = ttl
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultToolCacheMaxEntries
+	}
+	return tool.NewResultCache(ttls, maxEntries), nil
+}
+
+// resolveToolLimiter validates governance.tool_limits and builds the
+// runner's per-tool throttle.
+func resolveToolLimiter(cfg map[string]config.ToolLimitConfig) (*tool.Limiter, error) {
+	limits := make(map[string]tool.ToolLimit, len(cfg))
+	for name, limit := range cfg {
+		if limit.QPS < 0 {
+			return nil, fmt.Errorf("governance.tool_limits.%s.qps must not be negative", name)
+		}
+		if limit.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("governance.tool_limits.%s.max_concurrent must not be negative", name)
+		}
+		limits[name] = tool.ToolLimit{QPS: limit.QPS, MaxConcurrent: limit.MaxConcurrent}
+	}
+	return tool.NewLimiter(limits), nil
+}
+
+// resolveCalendarOptions validates tools.calendar and picks the provider
+// when it is not set.
+func resolveCalendarOptions(cfg config.CalendarToolConfig) (tool.CalendarOptions, error) {
+	timeout, err := config.DurationOrDefault(cfg.Timeout, config.DefaultCalendarToolTimeout)
+	if err != nil {
+		return tool.CalendarOptions{}, fmt.Errorf("parse tools.calendar.timeout: %w", err)
+	}
+	location := time.Local
+	if tz := strings.TrimSpace(cfg.Timezone); tz != "" {
+		if location, err = time.LoadLocation(tz); err != nil {
+			return tool.CalendarOptions{}, fmt.Errorf("parse tools.calendar.timezone: %w", err)
+		}
+	}
+
+	google := cfg.Google
+	googleConfigured := strings.TrimSpace(google.AccessToken) != "" || strings.TrimSpace(google.RefreshToken) != ""
+	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if provider == "" {
+		switch {
+		case strings.TrimSpace(cfg.CalDAV.URL) != "":
+			provider = tool.CalendarProviderCalDAV
+		case googleConfigured:
+			provider = tool.CalendarProviderGoogle
+		}
+	}
+	switch provider {
+	case "":
+	case tool.CalendarProviderCalDAV:
+		if strings.TrimSpace(cfg.CalDAV.URL) == "" {
+			return tool.CalendarOptions{}, fmt.Errorf("tools.calendar.caldav.url is required for the caldav provider")
+		}
+	case tool.CalendarProviderGoogle:
+		if !googleConfigured {
+			return tool.CalendarOptions{}, fmt.Errorf("tools.calendar.google needs refresh_token or access_token for the google provider")
+		}
+		if strings.TrimSpace(google.RefreshToken) != "" && (strings.TrimSpace(google.ClientID) == "" || strings.TrimSpace(google.ClientSecret) == "") {
+			return tool.CalendarOptions{}, fmt.Errorf("tools.calendar.google.refresh_token needs client_id and client_secret")
+		}
+	default:
+		return tool.CalendarOptions{}, fmt.Errorf("tools.calendar.provider must be %q or %q", tool.CalendarProviderCalDAV, tool.CalendarProviderGoogle)
+	}
+
+	options := tool.CalendarOptions{
+		Provider:           provider,
+		Timeout:            timeout,
+		Location:           location,
+		CalDAVURL:          strings.TrimSpace(cfg.CalDAV.URL),
+		CalDAVUsername:     cfg.CalDAV.Username,
+		CalDAVPassword:     cfg.CalDAV.Password,
+		GoogleBaseURL:      strings.TrimSpace(google.BaseURL),
+		GoogleTokenURL:     strings.TrimSpace(google.TokenURL),
+		GoogleCalendarID:   strings.TrimSpace(google.CalendarID),
+		GoogleAccessToken:  strings.TrimSpace(google.AccessToken),
+		GoogleClientID:     strings.TrimSpace(google.ClientID),
+		GoogleClientSecret: strings.TrimSpace(google.ClientSecret),
+		GoogleRefreshToken: strings.TrimSpace(google.RefreshToken),
+	}
+	if options.GoogleBaseURL == "" {
+		options.GoogleBaseURL = config.DefaultGoogleCalendarBaseURL
+	}
+	if options.GoogleTokenURL == "" {
+		options.GoogleTokenURL = config.DefaultGoogleCalendarTokenURL
+	}
+	if options.GoogleCalendarID == "" {
+		options.GoogleCalendarID = config.DefaultGoogleCalendarID
+	}
+	return options, nil
+}
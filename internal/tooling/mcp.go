@@ -0,0 +1,112 @@
+package tooling
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/harunnryd/heike/internal/config"
+	"github.com/harunnryd/heike/internal/httpclient"
+	"github.com/harunnryd/heike/internal/tool"
+	"github.com/harunnryd/heike/internal/tool/mcp"
+)
+
+// resolveMCPServers validates tools.mcp and fills in defaults.
+func resolveMCPServers(cfg config.MCPToolConfig, httpClients *httpclient.Factory) ([]mcp.ServerOptions, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, nil
+	}
+	timeout, err := config.DurationOrDefault(cfg.Timeout, config.DefaultMCPToolTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("parse tools.mcp.timeout: %w", err)
+	}
+
+	seen := make(map[string]struct{}, len(cfg.Servers))
+	servers := make([]mcp.ServerOptions, 0, len(cfg.Servers))
+	for i, server := range cfg.Servers {
+		name := strings.TrimSpace(server.Name)
+		if name == "" {
+			return nil, fmt.Errorf("tools.mcp.servers[%d].name is required", i)
+		}
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf("tools.mcp.servers: duplicate server %q", name)
+		}
+		seen[name] = struct{}{}
+
+		transport := strings.ToLower(strings.TrimSpace(server.Transport))
+		if transport == "" {
+			transport = mcp.TransportStdio
+			if strings.TrimSpace(server.URL) != "" {
+				transport = mcp.TransportSSE
+			}
+		}
+		switch transport {
+		case mcp.TransportStdio:
+			if strings.TrimSpace(server.Command) == "" {
+				return nil, fmt.Errorf("tools.mcp.servers[%s].command is required for the stdio transport", name)
+			}
+		case mcp.TransportSSE:
+			if strings.TrimSpace(server.URL) == "" {
+				return nil, fmt.Errorf("tools.mcp.servers[%s].url is required for the sse transport", name)
+			}
+		default:
+			return nil, fmt.Errorf("tools.mcp.servers[%s].transport must be %q or %q", name, mcp.TransportStdio, mcp.TransportSSE)
+		}
+
+		serverTimeout := timeout
+		if strings.TrimSpace(server.Timeout) != "" {
+			serverTimeout, err = config.DurationOrDefault(server.Timeout, config.DefaultMCPToolTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("parse tools.mcp.servers[%s].timeout: %w", name, err)
+			}
+		}
+
+		servers = append(servers, mcp.ServerOptions{
+			Name:       name,
+			Transport:  transport,
+			Command:    strings.TrimSpace(server.Command),
+			Args:       server.Args,
+			Env:        server.Env,
+			URL:        strings.TrimSpace(server.URL),
+			Headers:    server.Headers,
+			Timeout:    serverTimeout,
+			HTTPClient: httpClients.Client("mcp", 0),
+		})
+	}
+	return servers, nil
+}
+
+// registerMCPTools connects to each server and registers its tools. A
+// server that cannot be reached is logged and skipped so the daemon still
+// starts; tools whose names are taken by built-ins or custom tools are
+// skipped too. The returned clients stay open until Components.Close.
+func registerMCPTools(ctx context.Context, registry *tool.Registry, servers []mcp.ServerOptions, workspaceID string) []*mcp.Client {
+	clients := make([]*mcp.Client, 0, len(servers))
+	for _, server := range servers {
+		client, err := mcp.Connect(ctx, server)
+		if err != nil {
+			slog.Warn("MCP server unavailable", "server", server.Name, "error", err)
+			continue
+		}
+		tools, err := client.Tools(ctx)
+		if err != nil {
+			slog.Warn("MCP tool discovery failed", "server", server.Name, "error", err)
+			client.Close()
+			continue
+		}
+
+		registered := 0
+		for _, t := range tools {
+			if _, exists := registry.Get(t.Name()); exists {
+				slog.Warn("MCP tool name already registered; skipping", "server", server.Name, "tool", t.Name())
+				continue
+			}
+			registry.Register(t)
+			registered++
+		}
+		clients = append(clients, client)
+		slog.Info("MCP tools registered", "server", server.Name, "count", registered, "workspace", workspaceID)
+	}
+	return clients
+}
@@ -0,0 +1,280 @@
+// Package webhook posts the outcome of an event to the callback URL it was
+// submitted with, and approval requests to a session's notify URL, signed with
+// HMAC-SHA256 so receivers can verify the sender.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harunnryd/heike/internal/errors"
+	"github.com/harunnryd/heike/internal/httpclient"
+	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/store"
+)
+
+// Request headers sent with every callback.
+const (
+	HeaderEventID   = "X-Heike-Event-Id"
+	HeaderTimestamp = "X-Heike-Timestamp"
+	HeaderSignature = "X-Heike-Signature"
+)
+
+// Config controls callback delivery.
+type Config struct {
+	// Secret signs each payload. Callbacks are refused while it is empty.
+	Secret      string
+	Timeout     time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	// AllowedHosts restricts callback hosts; empty allows any host with a
+	// public address.
+	AllowedHosts []string
+	// HTTPClients supplies the outbound client; nil uses the default factory.
+	HTTPClients *httpclient.Factory
+}
+
+// Payload is the JSON body posted to the callback URL.
+type Payload struct {
+	EventID     string    `json:"event_id"`
+	SessionID   string    `json:"session_id"`
+	Status      string    `json:"status"`
+	Output      string    `json:"output,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// ApprovalPayload is the JSON body posted to a session's notify URL when a
+// tool call in the session waits for approval.
+type ApprovalPayload struct {
+	Type       string    `json:"type"`
+	ApprovalID string    `json:"approval_id"`
+	SessionID  string    `json:"session_id"`
+	Tool       string    `json:"tool"`
+	Input      string    `json:"input"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ApprovalRequiredType is the ApprovalPayload type.
+const ApprovalRequiredType = "approval_required"
+
+// TranscriptReader reads session transcripts; *store.Worker implements it.
+type TranscriptReader interface {
+	ReadTranscript(sessionID string, limit int) ([]string, error)
+}
+
+// Notifier delivers callbacks in the background.
+type Notifier struct {
+	cfg         Config
+	client      *http.Client
+	transcripts TranscriptReader
+	now         func() time.Time
+	wg          sync.WaitGroup
+}
+
+func NewNotifier(cfg Config, transcripts TranscriptReader) *Notifier {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	// Any API caller picks callback URLs, so unless the operator lists the
+	// hosts, only public addresses are reachable.
+	client := cfg.HTTPClients.PublicOnly("webhook", cfg.Timeout)
+	if len(cfg.AllowedHosts) > 0 {
+		client = cfg.HTTPClients.Client("webhook", cfg.Timeout)
+	}
+	// A redirect could leave the allowed hosts; receivers must answer directly.
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &Notifier{
+		cfg:         cfg,
+		client:      client,
+		transcripts: transcripts,
+		now:         time.Now,
+	}
+}
+
+// Validate checks that rawURL can be used as a callback.
+func (n *Notifier) Validate(rawURL string) error {
+	if n.cfg.Secret == "" {
+		return errors.InvalidInput("callbacks are disabled: server.callback.secret is not set")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.InvalidInput("callback_url must be an absolute http or https URL")
+	}
+	if len(n.cfg.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, host := range n.cfg.AllowedHosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return errors.InvalidInput(fmt.Sprintf("callback host %s is not in server.callback.allowed_hosts", u.Hostname()))
+}
+
+// Finished is the worker hook: once an event with a callback URL reaches a
+// terminal state, its outcome is posted in the background.
+func (n *Notifier) Finished(evt *ingress.Event, state store.EventState, errMsg string) {
+	if evt == nil || evt.CallbackURL == "" {
+		return
+	}
+	payload := Payload{
+		EventID:     evt.ID,
+		SessionID:   evt.SessionID,
+		Status:      string(state),
+		Error:       errMsg,
+		CompletedAt: n.now().UTC(),
+	}
+	if state == store.EventCompleted {
+		output, err := n.turnOutput(evt.SessionID)
+		if err != nil {
+			slog.Warn("Failed to read callback output", "id", evt.ID, "session", evt.SessionID, "error", err)
+		}
+		payload.Output = output
+	}
+
+	n.deliverAsync(evt.CallbackURL, payload.EventID, payload)
+}
+
+// ApprovalRequested posts payload to notifyURL in the background. The URL is
+// checked again here because session metadata can also be set directly.
+func (n *Notifier) ApprovalRequested(notifyURL string, payload ApprovalPayload) {
+	if err := n.Validate(notifyURL); err != nil {
+		slog.Warn("Skipping approval notification", "approval", payload.ApprovalID, "session", payload.SessionID, "error", err)
+		return
+	}
+	payload.Type = ApprovalRequiredType
+	n.deliverAsync(notifyURL, payload.ApprovalID, payload)
+}
+
+func (n *Notifier) deliverAsync(targetURL, id string, payload interface{}) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.deliver(context.Background(), targetURL, id, payload); err != nil {
+			slog.Warn("Callback delivery failed", "id", id, "url", targetURL, "error", err)
+		}
+	}()
+}
+
+// Wait blocks until in-flight deliveries finish.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// Sign returns the signature header value for body sent at timestamp:
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts payload with id in the HeaderEventID header: the event ID for
+// results, the approval ID for approval notifications.
+func (n *Notifier) deliver(ctx context.Context, callbackURL, id string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(n.cfg.Backoff << (attempt - 2))
+		}
+		retry, err := n.post(ctx, callbackURL, id, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// post sends one attempt and reports whether a failure is worth retrying.
+func (n *Notifier) post(ctx context.Context, callbackURL, eventID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(n.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(n.cfg.Secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("callback returned status %d", resp.StatusCode)
+}
+
+// turnOutputWindow is how many trailing transcript lines turnOutput reads
+// first; the window doubles while it holds no user message.
+const turnOutputWindow = 64
+
+// turnOutput returns the assistant messages written after the last user
+// message, i.e. the reply to the turn that just finished.
+func (n *Notifier) turnOutput(sessionID string) (string, error) {
+	if n.transcripts == nil {
+		return "", nil
+	}
+	for limit := turnOutputWindow; ; limit *= 2 {
+		lines, err := n.transcripts.ReadTranscript(sessionID, limit)
+		if err != nil {
+			return "", err
+		}
+		replies, complete := turnReplies(lines)
+		if complete || len(lines) < limit {
+			return strings.Join(replies, "\n\n"), nil
+		}
+	}
+}
+
+// turnReplies collects the assistant messages after the last user message
+// in lines, and reports whether that user message was found.
+func turnReplies(lines []string) ([]string, bool) {
+	var replies []string
+	for i := len(lines) - 1; i >= 0; i-- {
+		var event struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}
+		if json.Unmarshal([]byte(lines[i]), &event) != nil {
+			continue
+		}
+		if event.Role == "user" {
+			return replies, true
+		}
+		if event.Role == "assistant" && event.Content != "" {
+			replies = append([]string{event.Content}, replies...)
+		}
+	}
+	return replies, false
+}
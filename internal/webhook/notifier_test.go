@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harunnryd/heike/internal/ingress"
+	"github.com/harunnryd/heike/internal/store"
+)
+
+type fakeTranscripts []string
+
+func (f fakeTranscripts) ReadTranscript(sessionID string, limit int) ([]string, error) {
+	if limit > 0 && len(f) > limit {
+		return f[len(f)-limit:], nil
+	}
+	return f, nil
+}
+
+// tailTranscripts records the limits it is read with.
+type tailTranscripts struct {
+	fakeTranscripts
+	limits []int
+}
+
+func (t *tailTranscripts) ReadTranscript(sessionID string, limit int) ([]string, error) {
+	t.limits = append(t.limits, limit)
+	return t.fakeTranscripts.ReadTranscript(sessionID, limit)
+}
+
+// localHosts lets tests deliver to httptest servers on loopback.
+var localHosts = []string{"127.0.0.1"}
+
+type received struct {
+	headers http.Header
+	body    []byte
+}
+
+// callbackServer records requests and answers with the given statuses in
+// order, then 200.
+func callbackServer(t *testing.T, statuses ...int) (*httptest.Server, func() []received) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, received{headers: r.Header.Clone(), body: body})
+		n := len(got)
+		mu.Unlock()
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]received(nil), got...)
+	}
+}
+
+func TestNotifier_PostsSignedOutput(t *testing.T) {
+	srv, requests := callbackServer(t)
+	transcript := fakeTranscripts{
+		`{"role":"user","content":"first"}`,
+		`{"role":"assistant","content":"old reply"}`,
+		`{"role":"user","content":"second"}`,
+		`{"role":"assistant","content":"part one"}`,
+		`{"role":"tool","content":"ignored"}`,
+		`{"role":"assistant","content":"part two"}`,
+	}
+	n := NewNotifier(Config{Secret: "s3cret", Timeout: time.Second, MaxAttempts: 1, AllowedHosts: localHosts}, transcript)
+
+	evt := &ingress.Event{ID: "evt-1", SessionID: "sess-1", CallbackURL: srv.URL}
+	n.Finished(evt, store.EventCompleted, "")
+	n.Wait()
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 callback, got %d", len(got))
+	}
+	req := got[0]
+	if req.headers.Get(HeaderEventID) != "evt-1" {
+		t.Fatalf("unexpected event id header: %q", req.headers.Get(HeaderEventID))
+	}
+	want := Sign("s3cret", req.headers.Get(HeaderTimestamp), req.body)
+	if req.headers.Get(HeaderSignature) != want {
+		t.Fatalf("signature = %q, want %q", req.headers.Get(HeaderSignature), want)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Status != "completed" || payload.SessionID != "sess-1" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if payload.Output != "part one\n\npart two" {
+		t.Fatalf("output = %q", payload.Output)
+	}
+}
+
+func TestNotifier_ReadsOnlyTheTranscriptTail(t *testing.T) {
+	srv, requests := callbackServer(t)
+	var lines fakeTranscripts
+	lines = append(lines, `{"role":"user","content":"question"}`)
+	for i := 0; i < 100; i++ {
+		lines = append(lines, `{"role":"tool","content":"step"}`)
+	}
+	lines = append(lines, `{"role":"assistant","content":"answer"}`)
+	transcripts := &tailTranscripts{fakeTranscripts: append(fakeTranscripts{`{"role":"assistant","content":"older"}`}, lines...)}
+	n := NewNotifier(Config{Secret: "s", Timeout: time.Second, MaxAttempts: 1, AllowedHosts: localHosts}, transcripts)
+
+	n.Finished(&ingress.Event{ID: "evt-long", SessionID: "sess-1", CallbackURL: srv.URL}, store.EventCompleted, "")
+	n.Wait()
+
+	if fmt.Sprint(transcripts.limits) != "[64 128]" {
+		t.Fatalf("read limits = %v, want a doubling tail window", transcripts.limits)
+	}
+	var payload Payload
+	if got := requests(); len(got) != 1 || json.Unmarshal(got[0].body, &payload) != nil || payload.Output != "answer" {
+		t.Fatalf("callbacks = %d, output %q", len(got), payload.Output)
+	}
+}
+
+func TestNotifier_RefusesPrivateTargetsWithoutAllowedHosts(t *testing.T) {
+	srv, requests := callbackServer(t)
+	n := NewNotifier(Config{Secret: "s", Timeout: time.Second, MaxAttempts: 1}, nil)
+
+	n.Finished(&ingress.Event{ID: "evt-internal", CallbackURL: srv.URL}, store.EventFailed, "boom")
+	n.ApprovalRequested(srv.URL, ApprovalPayload{ApprovalID: "app-internal"})
+	n.Wait()
+
+	if got := requests(); len(got) != 0 {
+		t.Fatalf("expected loopback callbacks to be refused, got %d", len(got))
+	}
+}
+
+func TestNotifier_DoesNotFollowRedirects(t *testing.T) {
+	target, targetRequests := callbackServer(t)
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(redirect.Close)
+	n := NewNotifier(Config{Secret: "s", Timeout: time.Second, MaxAttempts: 1, AllowedHosts: localHosts}, nil)
+
+	n.Finished(&ingress.Event{ID: "evt-redirect", CallbackURL: redirect.URL}, store.EventFailed, "boom")
+	n.Wait()
+
+	if got := targetRequests(); len(got) != 0 {
+		t.Fatalf("redirect was followed %d times", len(got))
+	}
+}
+
+func TestNotifier_ReportsFailureAndRetries(t *testing.T) {
+	srv, requests := callbackServer(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	n := NewNotifier(Config{Secret: "s", Timeout: time.Second, MaxAttempts: 3, Backoff: time.Millisecond, AllowedHosts: localHosts}, nil)
+
+	n.Finished(&ingress.Event{ID: "evt-2", CallbackURL: srv.URL}, store.EventFailed, "tool crashed")
+	n.Wait()
+
+	got := requests()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(got))
+	}
+	var payload Payload
+	if err := json.Unmarshal(got[2].body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Status != "failed" || payload.Error != "tool crashed" || payload.Output != "" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestNotifier_DoesNotRetryClientErrors(t *testing.T) {
+	srv, requests := callbackServer(t, http.StatusBadRequest)
+	n := NewNotifier(Config{Secret: "s", Timeout: time.Second, MaxAttempts: 3, Backoff: time.Millisecond, AllowedHosts: localHosts}, nil)
+
+	n.Finished(&ingress.Event{ID: "evt-3", CallbackURL: srv.URL}, store.EventFailed, "")
+	n.Finished(&ingress.Event{ID: "evt-4"}, store.EventCompleted, "")
+	n.Wait()
+
+	if got := len(requests()); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestNotifier_ApprovalRequested(t *testing.T) {
+	srv, requests := callbackServer(t)
+	n := NewNotifier(Config{Secret: "s3cret", Timeout: time.Second, MaxAttempts: 1, AllowedHosts: []string{"127.0.0.1"}}, nil)
+
+	n.ApprovalRequested(srv.URL, ApprovalPayload{ApprovalID: "app-1", SessionID: "sess-1", Tool: "exec_command", Input: `{"cmd":"rm -rf build"}`})
+	n.ApprovalRequested("https://evil.example.com/hook", ApprovalPayload{ApprovalID: "app-2"})
+	n.Wait()
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 notification, got %d", len(got))
+	}
+	req := got[0]
+	if req.headers.Get(HeaderEventID) != "app-1" {
+		t.Fatalf("unexpected id header: %q", req.headers.Get(HeaderEventID))
+	}
+	if req.headers.Get(HeaderSignature) != Sign("s3cret", req.headers.Get(HeaderTimestamp), req.body) {
+		t.Fatal("approval notification is not signed")
+	}
+	var payload ApprovalPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Type != ApprovalRequiredType || payload.SessionID != "sess-1" || payload.Tool != "exec_command" {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestNotifier_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		url     string
+		wantErr bool
+	}{
+		{name: "no secret", cfg: Config{}, url: "https://example.com/hook", wantErr: true},
+		{name: "valid", cfg: Config{Secret: "s"}, url: "https://example.com/hook"},
+		{name: "bad scheme", cfg: Config{Secret: "s"}, url: "ftp://example.com/hook", wantErr: true},
+		{name: "relative", cfg: Config{Secret: "s"}, url: "/hook", wantErr: true},
+		{name: "allowed host", cfg: Config{Secret: "s", AllowedHosts: []string{"Hooks.Example.com"}}, url: "https://hooks.example.com:8443/x"},
+		{name: "host not allowed", cfg: Config{Secret: "s", AllowedHosts: []string{"hooks.example.com"}}, url: "https://evil.example.com/x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewNotifier(tt.cfg, nil).Validate(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -17,8 +17,13 @@ import (
 
 type RuntimeConfig struct {
 	ShutdownTimeout time.Duration
+	// OnFinish, when set, is called after an event completes or fails.
+	OnFinish FinishFunc
 }
 
+// FinishFunc observes the terminal state of a processed event.
+type FinishFunc func(evt *ingress.Event, state store.EventState, errMsg string)
+
 type Worker struct {
 	mu      sync.RWMutex
 	started bool
@@ -32,6 +37,7 @@ type Worker struct {
 	locks  *concurrency.SimpleSessionLockManager
 
 	shutdownTimeout time.Duration
+	onFinish        FinishFunc
 }
 
 func NewWorker(lane string, events <-chan *ingress.Event, store *store.Worker, orch orchestrator.Kernel, locks *concurrency.SimpleSessionLockManager, runtimeCfg RuntimeConfig) *Worker {
@@ -50,6 +56,7 @@ func NewWorker(lane string, events <-chan *ingress.Event, store *store.Worker, o
 		locks:  locks,
 
 		shutdownTimeout: runtimeCfg.ShutdownTimeout,
+		onFinish:        runtimeCfg.OnFinish,
 	}
 }
 
@@ -112,8 +119,20 @@ func (w *Worker) process(ctx context.Context, evt *ingress.Event) {
 			"id", evt.ID,
 			"lane", w.lane,
 			"error", err)
+		state := store.EventFailed
+		switch {
+		case errors.IsCategory(err, errors.ErrInvalidInput):
+			// Retrying an invalid event cannot succeed.
+			state = store.EventDeadLettered
+		case errors.IsCategory(err, orchestrator.ErrCancelled):
+			state = store.EventCancelled
+		}
+		w.track(evt, state, err.Error())
+		w.finish(evt, state, err.Error())
 		return
 	}
+	w.track(evt, store.EventCompleted, "")
+	w.finish(evt, store.EventCompleted, "")
 
 	slog.Debug("Event processed",
 		"id", evt.ID,
@@ -125,6 +144,8 @@ func (w *Worker) processEvent(ctx context.Context, evt *ingress.Event) error {
 		return fmt.Errorf("validate event: %w", err)
 	}
 
+	w.track(evt, store.EventProcessing, "")
+
 	if err := w.acquireSessionLock(ctx, evt.SessionID); err != nil {
 		return fmt.Errorf("acquire session lock: %w", err)
 	}
@@ -157,6 +178,20 @@ func (w *Worker) validateEvent(evt *ingress.Event) error {
 	return nil
 }
 
+func (w *Worker) track(evt *ingress.Event, state store.EventState, errMsg string) {
+	if w.store == nil || evt == nil {
+		return
+	}
+	w.store.TrackEvent(evt.ID, evt.SessionID, state, errMsg)
+}
+
+func (w *Worker) finish(evt *ingress.Event, state store.EventState, errMsg string) {
+	if w.onFinish == nil || evt == nil {
+		return
+	}
+	w.onFinish(evt, state, errMsg)
+}
+
 func (w *Worker) acquireSessionLock(ctx context.Context, sessionID string) error {
 	if w.locks == nil {
 		slog.Warn("No lock manager configured", "lane", w.lane)
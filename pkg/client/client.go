@@ -0,0 +1,484 @@
+// Package client calls the heike daemon HTTP API described by
+// /api/v1/openapi.json. It covers events, sessions, approvals and Zanshin
+// status, so adapters and external tools need not build requests by hand.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds each request made with the default HTTP client.
+const DefaultTimeout = 30 * time.Second
+
+// Client is a daemon API client. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithAPIKey sends key as a bearer credential. It is either a server.auth
+// API key or a JWT.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, whose timeout is
+// DefaultTimeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New returns a client for the daemon at baseURL, such as
+// http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error codes returned in APIError.Code.
+const (
+	CodeInvalidInput     = "invalid_input"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnauthenticated  = "unauthenticated"
+	CodePermissionDenied = "permission_denied"
+	CodeConflict         = "conflict"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeQueueFull        = "queue_full"
+	CodeRateLimited      = "rate_limited"
+	CodeUnavailable      = "unavailable"
+	CodeInternal         = "internal"
+)
+
+// APIError is a non-2xx response from the daemon.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	// Retryable reports that the same request may succeed after a backoff,
+	// as when a queue is full.
+	Retryable bool                   `json:"retryable"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("daemon api returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("daemon api returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Event is an event to queue. Content is required; an empty SessionID starts
+// a new session.
+type Event struct {
+	Source      string            `json:"source,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	SessionID   string            `json:"session_id,omitempty"`
+	Content     string            `json:"content"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CallbackURL string            `json:"callback_url,omitempty"`
+	NotifyURL   string            `json:"notify_url,omitempty"`
+	// IdempotencyKey makes resubmissions within ingress.idempotency_ttl
+	// return the original event ID.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// Submit statuses.
+const (
+	StatusAccepted  = "accepted"
+	StatusDuplicate = "duplicate"
+	StatusRejected  = "rejected"
+)
+
+type SubmitResult struct {
+	Status string `json:"status"`
+	ID     string `json:"id"`
+}
+
+type BatchResult struct {
+	Accepted  int               `json:"accepted"`
+	Duplicate int               `json:"duplicate"`
+	Rejected  int               `json:"rejected"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Retryable reports a rejection because the queue was full.
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+type EventStatus struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	SessionID   string     `json:"session_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	QueuedAt    *time.Time `json:"queued_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type Session struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Status    string            `json:"status"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// SessionMerge reports a merge of Source into Session.
+type SessionMerge struct {
+	Session Session `json:"session"`
+	// Source is the merged session, now archived.
+	Source string `json:"source"`
+	// Events counts the transcript lines copied from the source.
+	Events int `json:"events"`
+	// Vectors counts the context and memory vectors moved to the session.
+	Vectors int `json:"vectors"`
+}
+
+type ContextDocument struct {
+	Text     string            `json:"text"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type ContextResult struct {
+	SessionID string `json:"session_id"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+}
+
+// SessionTask is a persisted report of a decomposed goal.
+type SessionTask struct {
+	ID     string     `json:"id"`
+	TS     time.Time  `json:"ts"`
+	Report TaskReport `json:"report"`
+}
+
+type TaskReport struct {
+	Goal       string          `json:"goal"`
+	Status     string          `json:"status"`
+	SubTasks   []SubTaskReport `json:"sub_tasks"`
+	StartedAt  time.Time       `json:"started_at"`
+	DurationMS int64           `json:"duration_ms"`
+}
+
+type SubTaskReport struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Output      string    `json:"output,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Retries     int       `json:"retries"`
+	StartedAt   time.Time `json:"started_at"`
+	DurationMS  int64     `json:"duration_ms"`
+}
+
+// ToolSelection explains which tools a session's last turn offered the model.
+type ToolSelection struct {
+	SessionID string `json:"session_id"`
+	Goal      string `json:"goal"`
+	// Broker is llm, heuristic or none.
+	Broker     string                `json:"broker"`
+	Available  int                   `json:"available"`
+	Tools      []ToolSelectionDetail `json:"tools"`
+	SelectedAt time.Time             `json:"selected_at"`
+}
+
+type ToolSelectionDetail struct {
+	Name    string   `json:"name"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type Approval struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Tool      string    `json:"tool"`
+	Input     string    `json:"input"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SubmitEvent queues evt. A duplicate of an earlier submission is not an
+// error; its result has StatusDuplicate and the original ID.
+func (c *Client) SubmitEvent(ctx context.Context, evt Event) (*SubmitResult, error) {
+	header := http.Header{}
+	if evt.IdempotencyKey != "" {
+		header.Set("Idempotency-Key", evt.IdempotencyKey)
+		evt.IdempotencyKey = ""
+	}
+	var out SubmitResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/events", header, evt, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SubmitEvents queues events in one request. Each event is accepted or
+// rejected independently; see BatchResult.Results.
+func (c *Client) SubmitEvents(ctx context.Context, events []Event) (*BatchResult, error) {
+	var out BatchResult
+	body := map[string]interface{}{"events": events}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/events/batch", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) EventStatus(ctx context.Context, id string) (*EventStatus, error) {
+	var out EventStatus
+	if err := c.do(ctx, http.MethodGet, "/api/v1/events/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOptions pages and filters ListSessionsPage and ListApprovalsPage.
+type ListOptions struct {
+	// Limit is the page size, at most 500; zero returns every item.
+	Limit  int
+	Offset int
+	// Status keeps items in any of these statuses. Approvals default to
+	// pending; "all" lists every status.
+	Status []string
+	// UpdatedSince keeps sessions updated, or approvals created, at or after
+	// it.
+	UpdatedSince time.Time
+}
+
+func (o ListOptions) query() string {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		q.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if len(o.Status) > 0 {
+		q.Set("status", strings.Join(o.Status, ","))
+	}
+	if !o.UpdatedSince.IsZero() {
+		q.Set("updated_since", o.UpdatedSince.Format(time.RFC3339))
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// Page describes one page of a list.
+type Page struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit,omitempty"`
+	// NextOffset is the offset of the next page, or nil on the last page.
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
+	sessions, _, err := c.ListSessionsPage(ctx, ListOptions{})
+	return sessions, err
+}
+
+func (c *Client) ListSessionsPage(ctx context.Context, opts ListOptions) ([]Session, *Page, error) {
+	var out struct {
+		Page
+		Sessions []Session `json:"sessions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions"+opts.query(), nil, nil, &out); err != nil {
+		return nil, nil, err
+	}
+	return out.Sessions, &out.Page, nil
+}
+
+// CreateSession registers an empty session. An empty ID is generated by the
+// daemon; an existing ID fails with a 409 *APIError.
+func (c *Client) CreateSession(ctx context.Context, id, title string, metadata map[string]string) (*Session, error) {
+	var out Session
+	body := map[string]interface{}{"id": id, "title": title, "metadata": metadata}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SessionUpdate changes a session. A nil Title keeps the title; a metadata
+// key mapped to nil is removed. tools_allow can only be narrowed.
+type SessionUpdate struct {
+	Title    *string            `json:"title,omitempty"`
+	Metadata map[string]*string `json:"metadata,omitempty"`
+}
+
+func (c *Client) UpdateSession(ctx context.Context, sessionID string, update SessionUpdate) (*Session, error) {
+	var out Session
+	if err := c.do(ctx, http.MethodPatch, "/api/v1/sessions/"+url.PathEscape(sessionID), nil, update, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSession removes sessionID and its transcript.
+func (c *Client) DeleteSession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/sessions/"+url.PathEscape(sessionID), nil, nil, nil)
+}
+
+// ResetSession clears the transcript of sessionID, keeping its title and
+// metadata.
+func (c *Client) ResetSession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/sessions/"+url.PathEscape(sessionID)+"?reset=true", nil, nil, nil)
+}
+
+// CancelSession stops the task running in sessionID. The turn ends with
+// status cancelled; an *APIError with CodeNotFound means nothing was running.
+func (c *Client) CancelSession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/cancel", nil, nil, nil)
+}
+
+// MergeSession appends the transcript of sourceID to sessionID, moves its
+// vectors and archives it. A source that was already merged fails with a
+// 409 *APIError.
+func (c *Client) MergeSession(ctx context.Context, sessionID, sourceID string) (*SessionMerge, error) {
+	var out SessionMerge
+	body := map[string]string{"source": sourceID}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/merge", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AddSessionContext indexes docs for memory recall in sessionID.
+func (c *Client) AddSessionContext(ctx context.Context, sessionID string, docs []ContextDocument) (*ContextResult, error) {
+	var out ContextResult
+	body := map[string]interface{}{"documents": docs}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/context", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SessionTasks lists the task reports of sessionID, oldest first.
+func (c *Client) SessionTasks(ctx context.Context, sessionID string) ([]SessionTask, error) {
+	var out struct {
+		Tasks []SessionTask `json:"tasks"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/tasks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Tasks, nil
+}
+
+// ToolSelection returns the tool selection of the last turn in sessionID. It
+// fails with a 404 *APIError when no turn ran since the daemon started.
+func (c *Client) ToolSelection(ctx context.Context, sessionID string) (*ToolSelection, error) {
+	var out ToolSelection
+	if err := c.do(ctx, http.MethodGet, "/api/v1/sessions/"+url.PathEscape(sessionID)+"/tools", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListApprovals lists pending approvals.
+func (c *Client) ListApprovals(ctx context.Context) ([]Approval, error) {
+	approvals, _, err := c.ListApprovalsPage(ctx, ListOptions{})
+	return approvals, err
+}
+
+func (c *Client) ListApprovalsPage(ctx context.Context, opts ListOptions) ([]Approval, *Page, error) {
+	var out struct {
+		Page
+		Approvals []Approval `json:"approvals"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/approvals"+opts.query(), nil, nil, &out); err != nil {
+		return nil, nil, err
+	}
+	return out.Approvals, &out.Page, nil
+}
+
+func (c *Client) ResolveApproval(ctx context.Context, id string, approve bool) error {
+	body := map[string]bool{"approve": approve}
+	return c.do(ctx, http.MethodPost, "/api/v1/approvals/"+url.PathEscape(id)+"/resolve", nil, body, nil)
+}
+
+// ZanshinStatus returns the Zanshin engine status. Its fields depend on the
+// engine state.
+func (c *Client) ZanshinStatus(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/zanshin/status", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var payload struct {
+			Error APIError `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&payload)
+		payload.Error.StatusCode = resp.StatusCode
+		return &payload.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
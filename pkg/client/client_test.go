@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_SubmitEventSendsKeyAndAuth(t *testing.T) {
+	var got Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/events" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if key := r.Header.Get("Idempotency-Key"); key != "k1" {
+			t.Errorf("Idempotency-Key = %q", key)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"accepted","id":"evt_1"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", WithAPIKey("secret"))
+	res, err := c.SubmitEvent(context.Background(), Event{SessionID: "s1", Content: "hi", IdempotencyKey: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != StatusAccepted || res.ID != "evt_1" {
+		t.Fatalf("result = %+v", res)
+	}
+	if got.Content != "hi" || got.SessionID != "s1" || got.IdempotencyKey != "" {
+		t.Fatalf("body = %+v", got)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"code":"not_found","message":"event evt_9 not found","retryable":false}}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).EventStatus(context.Background(), "evt_9")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != CodeNotFound || apiErr.Message != "event evt_9 not found" {
+		t.Fatalf("api error = %+v", apiErr)
+	}
+}
+
+func TestClient_SessionsAndApprovals(t *testing.T) {
+	var resolved map[string]bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/sessions/s%201/tasks", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tasks":[{"id":"r1","report":{"goal":"ship","status":"partial","sub_tasks":[{"id":"deploy","status":"failed","retries":1}]}}]}`))
+	})
+	mux.HandleFunc("/api/v1/approvals", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery == "limit=1&status=pending%2Cgranted" {
+			_, _ = w.Write([]byte(`{"total":2,"offset":0,"limit":1,"next_offset":1,"approvals":[{"id":"a1","tool":"exec_command","status":"pending"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"total":1,"offset":0,"approvals":[{"id":"a1","tool":"exec_command","status":"pending"}]}`))
+	})
+	mux.HandleFunc("/api/v1/approvals/a1/resolve", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&resolved)
+		_, _ = w.Write([]byte(`{"status":"resolved","id":"a1","approve":true}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c := New(srv.URL)
+	ctx := context.Background()
+
+	tasks, err := c.SessionTasks(ctx, "s 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Report.Status != "partial" || tasks[0].Report.SubTasks[0].Retries != 1 {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+
+	approvals, err := c.ListApprovals(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].Tool != "exec_command" {
+		t.Fatalf("approvals = %+v", approvals)
+	}
+	_, page, err := c.ListApprovalsPage(ctx, ListOptions{Limit: 1, Status: []string{"pending", "granted"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.NextOffset == nil || *page.NextOffset != 1 {
+		t.Fatalf("page = %+v", page)
+	}
+	if err := c.ResolveApproval(ctx, "a1", true); err != nil {
+		t.Fatal(err)
+	}
+	if !resolved["approve"] {
+		t.Fatalf("resolve body = %v", resolved)
+	}
+}
+
+func TestClient_SessionCRUD(t *testing.T) {
+	var requests []string
+	var patch map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"sess_1","title":"Inbox","status":"active"}`))
+		case http.MethodPatch:
+			_ = json.NewDecoder(r.Body).Decode(&patch)
+			_, _ = w.Write([]byte(`{"id":"sess_1","title":"Triage","status":"active"}`))
+		default:
+			_, _ = w.Write([]byte(`{"status":"deleted","id":"sess_1"}`))
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+	ctx := context.Background()
+
+	sess, err := c.CreateSession(ctx, "", "Inbox", nil)
+	if err != nil || sess.ID != "sess_1" {
+		t.Fatalf("create = %+v, %v", sess, err)
+	}
+	title := "Triage"
+	if _, err := c.UpdateSession(ctx, "sess_1", SessionUpdate{Title: &title, Metadata: map[string]*string{"team": nil}}); err != nil {
+		t.Fatal(err)
+	}
+	if meta, _ := patch["metadata"].(map[string]interface{}); patch["title"] != "Triage" || meta == nil || meta["team"] != nil {
+		t.Fatalf("patch body = %v", patch)
+	}
+	if err := c.ResetSession(ctx, "sess_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteSession(ctx, "sess_1"); err != nil {
+		t.Fatal(err)
+	}
+	want := "POST /api/v1/sessions,PATCH /api/v1/sessions/sess_1,DELETE /api/v1/sessions/sess_1?reset=true,DELETE /api/v1/sessions/sess_1"
+	if got := strings.Join(requests, ","); got != want {
+		t.Fatalf("requests = %s", got)
+	}
+}
+
+func TestClient_MergeSession(t *testing.T) {
+	var path string
+	var body map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"session":{"id":"sess_1","status":"active"},"source":"sess_2","events":4,"vectors":2}`))
+	}))
+	defer srv.Close()
+
+	merged, err := New(srv.URL).MergeSession(context.Background(), "sess_1", "sess_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "POST /api/v1/sessions/sess_1/merge" || body["source"] != "sess_2" {
+		t.Fatalf("request = %s %v", path, body)
+	}
+	if merged.Session.ID != "sess_1" || merged.Source != "sess_2" || merged.Events != 4 || merged.Vectors != 2 {
+		t.Fatalf("merge = %+v", merged)
+	}
+}
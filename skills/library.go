@@ -0,0 +1,13 @@
+// Package skills embeds the bundled skill library shipped with Heike.
+package skills
+
+import "embed"
+
+// Version identifies the bundled skill set. Bump it whenever a bundled skill
+// is added, removed or changed, so installs can pin and diff releases.
+const Version = "1.2.0"
+
+// FS holds one directory per bundled skill: SKILL.md plus any tools/.
+//
+//go:embed */SKILL.md */tools
+var FS embed.FS